@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 
 	"go.einride.tech/sage/sg"
 	"go.einride.tech/sage/sgtool"
@@ -37,14 +42,120 @@ func FormatYAML(ctx context.Context) error {
 	return sgyamlfmt.Run(ctx)
 }
 
+// goModules are the directories of the Go modules of the repository. The packages that depend on hamba/avro or
+// klauspost/compress are separate modules, so that the root module does not require them and their Go version.
+var goModules = []string{".", "encoding/protoavro/hambaavro", "encoding/protoavro/zstdavro", "avro2go"}
+
 func GoModTidy(ctx context.Context) error {
 	sg.Logger(ctx).Println("tidying Go module files...")
-	return sg.Command(ctx, "go", "mod", "tidy", "-v").Run()
+	for _, module := range goModules {
+		cmd := sg.Command(ctx, "go", "mod", "tidy", "-v")
+		cmd.Dir = sg.FromGitRoot(module)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func GoTest(ctx context.Context) error {
 	sg.Logger(ctx).Println("running Go tests...")
-	return sggo.TestCommand(ctx).Run()
+	for _, module := range goModules {
+		cmd := sggo.TestCommand(ctx)
+		cmd.Dir = sg.FromGitRoot(module)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GoBenchmark(ctx context.Context) error {
+	sg.Logger(ctx).Println("running Go benchmarks...")
+	return runBenchmarks(ctx, sg.FromGitRoot(), sg.FromBuildDir("benchmarks", "new.txt"))
+}
+
+// GoBenchmarkCompare compares the benchmarks of HEAD with the benchmarks of BENCHMARK_BASE (default origin/master),
+// and fails if any benchmark has regressed significantly by more than BENCHMARK_THRESHOLD percent (default 10).
+func GoBenchmarkCompare(ctx context.Context) error {
+	sg.Deps(ctx, GoBenchmark)
+	base := os.Getenv("BENCHMARK_BASE")
+	if base == "" {
+		base = "origin/master"
+	}
+	threshold := 10.0
+	if value := os.Getenv("BENCHMARK_THRESHOLD"); value != "" {
+		var err error
+		if threshold, err = strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("parse BENCHMARK_THRESHOLD: %w", err)
+		}
+	}
+	sg.Logger(ctx).Printf("running Go benchmarks of %s...", base)
+	worktree := sg.FromBuildDir("benchmarks", "base")
+	if err := sg.Command(ctx, "git", "worktree", "add", "--force", "--detach", worktree, base).Run(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = sg.Command(ctx, "git", "worktree", "remove", "--force", worktree).Run()
+	}()
+	oldFile := sg.FromBuildDir("benchmarks", "old.txt")
+	newFile := sg.FromBuildDir("benchmarks", "new.txt")
+	if err := runBenchmarks(ctx, worktree, oldFile); err != nil {
+		return err
+	}
+	sg.Logger(ctx).Println("comparing Go benchmarks...")
+	var output bytes.Buffer
+	benchstat, err := sgtool.GoInstall(ctx, "golang.org/x/perf/cmd/benchstat", benchstatVersion)
+	if err != nil {
+		return err
+	}
+	cmd := sg.Command(ctx, benchstat, oldFile, newFile)
+	cmd.Stdout = &output
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	fmt.Print(output.String())
+	var regressions []string
+	for _, line := range bytes.Split(output.Bytes(), []byte("\n")) {
+		match := benchstatRegression.FindSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if delta, err := strconv.ParseFloat(string(match[1]), 64); err == nil && delta > threshold {
+			regressions = append(regressions, string(bytes.TrimSpace(line)))
+		}
+	}
+	for _, regression := range regressions {
+		sg.Logger(ctx).Printf("regression: %s", regression)
+	}
+	if len(regressions) > 0 {
+		return fmt.Errorf("%d benchmarks regressed by more than %v%%", len(regressions), threshold)
+	}
+	return nil
+}
+
+// benchstatVersion is the version of golang.org/x/perf that benchstat is installed from, which has no tagged
+// releases.
+const benchstatVersion = "v0.0.0-20260409210113-8e83ce0f7b1c"
+
+// benchstatRegression matches the delta of a significant increase in a benchstat comparison.
+var benchstatRegression = regexp.MustCompile(`\+(\d+(?:\.\d+)?)% \(p=`)
+
+func runBenchmarks(ctx context.Context, dir string, outputFile string) error {
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0o755); err != nil {
+		return err
+	}
+	output, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	cmd := sg.Command(
+		ctx, "go", "test", "-run", "^$", "-bench", ".", "-benchmem", "-count", "6", "./encoding/protoavro/...",
+	)
+	cmd.Dir = dir
+	cmd.Stdout = output
+	return cmd.Run()
 }
 
 func GoReview(ctx context.Context) error {
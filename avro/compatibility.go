@@ -0,0 +1,371 @@
+package avro
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// IncompatibilityType is the reason that data written with a writer schema can not be read with a reader schema.
+type IncompatibilityType string
+
+const (
+	// TypeMismatch is an incompatibility of a reader type that can not be resolved from the writer type.
+	TypeMismatch IncompatibilityType = "TYPE_MISMATCH"
+	// NameMismatch is an incompatibility of named types with different names.
+	NameMismatch IncompatibilityType = "NAME_MISMATCH"
+	// FixedSizeMismatch is an incompatibility of fixed types with different sizes.
+	FixedSizeMismatch IncompatibilityType = "FIXED_SIZE_MISMATCH"
+	// MissingFieldDefault is an incompatibility of a reader field without a default, that is missing in the writer.
+	MissingFieldDefault IncompatibilityType = "READER_FIELD_MISSING_DEFAULT_VALUE"
+	// MissingEnumSymbol is an incompatibility of a writer enum symbol that is missing in the reader,
+	// when the reader enum has no default.
+	MissingEnumSymbol IncompatibilityType = "MISSING_ENUM_SYMBOLS"
+	// MissingUnionBranch is an incompatibility of a writer union branch that is missing in the reader.
+	MissingUnionBranch IncompatibilityType = "MISSING_UNION_BRANCH"
+)
+
+// Incompatibility describes why data written with a writer schema can not be read with a reader schema.
+type Incompatibility struct {
+	Type IncompatibilityType
+	// Path is the path of the incompatible value within the reader schema, for example "book.author".
+	// Path is empty for the root of the schema.
+	Path string
+	// Name is the name of the missing field, enum symbol or union branch.
+	Name string
+	// Reader and Writer describe the incompatible types, for example "long" and "string", or
+	// the names of an enum for a missing symbol.
+	Reader string
+	Writer string
+}
+
+func (i Incompatibility) String() string {
+	var message string
+	switch i.Type {
+	case TypeMismatch:
+		message = fmt.Sprintf("reader type %s does not match writer type %s", i.Reader, i.Writer)
+	case NameMismatch:
+		message = fmt.Sprintf("reader name %s does not match writer name %s", i.Reader, i.Writer)
+	case FixedSizeMismatch:
+		message = fmt.Sprintf("reader fixed size %s does not match writer fixed size %s", i.Reader, i.Writer)
+	case MissingFieldDefault:
+		message = fmt.Sprintf("reader field %s has no default value and is missing in writer", i.Name)
+	case MissingEnumSymbol:
+		message = fmt.Sprintf("writer symbol %s is missing in reader enum %s", i.Name, i.Reader)
+	case MissingUnionBranch:
+		message = fmt.Sprintf("writer union branch %s is missing in reader", i.Name)
+	default:
+		message = string(i.Type)
+	}
+	if i.Path == "" {
+		return message
+	}
+	return i.Path + ": " + message
+}
+
+// CheckCompatibility returns the incompatibilities of reading data written with the writer schema
+// with the reader schema, according to the schema resolution rules in
+// https://avro.apache.org/docs/current/specification/#schema-resolution.
+// Primitives with different logical types are incompatible, even though the resolution rules only
+// consider their underlying types, since values would silently change meaning.
+func CheckCompatibility(reader, writer Schema) []Incompatibility {
+	c := compatibilityChecker{
+		reader: validator{names: make(map[string]Schema)},
+		writer: validator{names: make(map[string]Schema)},
+		seen:   make(map[[2]string]struct{}),
+	}
+	c.reader.collectNames(reader, "")
+	c.writer.collectNames(writer, "")
+	c.check(reader, writer, "", "", "")
+	return c.incompatibilities
+}
+
+type compatibilityChecker struct {
+	reader            validator
+	writer            validator
+	seen              map[[2]string]struct{}
+	incompatibilities []Incompatibility
+}
+
+func (c *compatibilityChecker) report(incompatibility Incompatibility) {
+	c.incompatibilities = append(c.incompatibilities, incompatibility)
+}
+
+func (c *compatibilityChecker) check(reader, writer Schema, readerNS, writerNS, path string) {
+	reader, readerNS = c.reader.deref(reader, readerNS)
+	writer, writerNS = c.writer.deref(writer, writerNS)
+	if w, ok := writer.(Union); ok {
+		for _, branch := range w {
+			if r, ok := reader.(Union); ok {
+				matched, ok := c.matchBranch(r, branch, readerNS, writerNS)
+				if !ok {
+					c.report(Incompatibility{
+						Type: MissingUnionBranch,
+						Path: path,
+						Name: c.writer.branchName(branch, writerNS),
+					})
+					continue
+				}
+				c.check(matched, branch, readerNS, writerNS, path)
+				continue
+			}
+			c.check(reader, branch, readerNS, writerNS, path)
+		}
+		return
+	}
+	if r, ok := reader.(Union); ok {
+		matched, ok := c.matchBranch(r, writer, readerNS, writerNS)
+		if !ok {
+			c.report(Incompatibility{
+				Type:   TypeMismatch,
+				Path:   path,
+				Reader: c.describe(c.reader, reader, readerNS),
+				Writer: c.writer.branchName(writer, writerNS),
+			})
+			return
+		}
+		c.check(matched, writer, readerNS, writerNS, path)
+		return
+	}
+	if !c.matches(reader, writer, readerNS, writerNS) {
+		incompatibility := Incompatibility{
+			Type:   TypeMismatch,
+			Path:   path,
+			Reader: c.reader.branchName(reader, readerNS),
+			Writer: c.writer.branchName(writer, writerNS),
+		}
+		if isNamedPair(reader, writer) && !sameName(incompatibility.Reader, incompatibility.Writer) {
+			incompatibility.Type = NameMismatch
+		}
+		c.report(incompatibility)
+		return
+	}
+	switch r := reader.(type) {
+	case Record:
+		w := writer.(Record)
+		readerName := fullName(r.Name, r.Namespace, readerNS)
+		writerName := fullName(w.Name, w.Namespace, writerNS)
+		key := [2]string{readerName, writerName}
+		if _, ok := c.seen[key]; ok {
+			return
+		}
+		c.seen[key] = struct{}{}
+		for _, field := range r.Fields {
+			writerField, ok := aliasedRecordField(w, field)
+			if !ok {
+				if field.Default != nil {
+					continue
+				}
+				c.report(Incompatibility{
+					Type: MissingFieldDefault,
+					Path: joinPath(path, field.Name),
+					Name: field.Name,
+				})
+				continue
+			}
+			c.check(
+				field.Type,
+				writerField.Type,
+				namespaceOf(readerName),
+				namespaceOf(writerName),
+				joinPath(path, field.Name),
+			)
+		}
+	case Enum:
+		w := writer.(Enum)
+		readerName := fullName(r.Name, r.Namespace, readerNS)
+		writerName := fullName(w.Name, w.Namespace, writerNS)
+		if r.Default != "" {
+			return
+		}
+		for _, symbol := range w.Symbols {
+			if !hasSymbol(r, symbol) {
+				c.report(Incompatibility{
+					Type:   MissingEnumSymbol,
+					Path:   path,
+					Name:   symbol,
+					Reader: readerName,
+					Writer: writerName,
+				})
+			}
+		}
+	case Fixed:
+		w := writer.(Fixed)
+		if r.Size != w.Size {
+			c.report(Incompatibility{
+				Type:   FixedSizeMismatch,
+				Path:   path,
+				Reader: strconv.Itoa(r.Size),
+				Writer: strconv.Itoa(w.Size),
+			})
+		}
+	case Array:
+		c.check(r.Items, writer.(Array).Items, readerNS, writerNS, path+"[]")
+	case Map:
+		c.check(r.Values, writer.(Map).Values, readerNS, writerNS, path+"[]")
+	}
+}
+
+// matchBranch returns the first branch of the reader union that matches the writer schema exactly,
+// or else the first branch that the writer schema can be promoted to.
+func (c *compatibilityChecker) matchBranch(reader Union, writer Schema, readerNS, writerNS string) (Schema, bool) {
+	writer, writerNS = c.writer.deref(writer, writerNS)
+	for _, exact := range []bool{true, false} {
+		for _, branch := range reader {
+			resolved, ns := c.reader.deref(branch, readerNS)
+			if exact && c.reader.branchName(resolved, ns) != c.writer.branchName(writer, writerNS) {
+				continue
+			}
+			if c.matches(resolved, writer, ns, writerNS) {
+				return branch, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// matches returns true if the reader and writer schemas are of the same kind, or the writer
+// primitive can be promoted to the reader primitive. Named types are matched by unqualified name.
+func (c *compatibilityChecker) matches(reader, writer Schema, readerNS, writerNS string) bool {
+	switch r := reader.(type) {
+	case Primitive:
+		w, ok := writer.(Primitive)
+		if !ok {
+			return false
+		}
+		if r.LogicalType != w.LogicalType {
+			return false
+		}
+		return r.Type == w.Type || (r.LogicalType == "" && isPromotable(w.Type, r.Type))
+	case Record:
+		w, ok := writer.(Record)
+		return ok && sameName(fullName(r.Name, r.Namespace, readerNS), fullName(w.Name, w.Namespace, writerNS))
+	case Enum:
+		w, ok := writer.(Enum)
+		return ok && sameName(fullName(r.Name, r.Namespace, readerNS), fullName(w.Name, w.Namespace, writerNS))
+	case Fixed:
+		w, ok := writer.(Fixed)
+		return ok &&
+			r.LogicalType == w.LogicalType &&
+			sameName(fullName(r.Name, r.Namespace, readerNS), fullName(w.Name, w.Namespace, writerNS))
+	case Array:
+		_, ok := writer.(Array)
+		return ok
+	case Map:
+		_, ok := writer.(Map)
+		return ok
+	}
+	return false
+}
+
+func (c *compatibilityChecker) describe(v validator, schema Schema, namespace string) string {
+	union, ok := schema.(Union)
+	if !ok {
+		return v.branchName(schema, namespace)
+	}
+	description := "["
+	for i, branch := range union {
+		if i > 0 {
+			description += ", "
+		}
+		description += v.branchName(branch, namespace)
+	}
+	return description + "]"
+}
+
+// deref resolves a reference to the named type it refers to.
+// Unknown references are returned as they are.
+func (v validator) deref(schema Schema, namespace string) (Schema, string) {
+	ref, ok := schema.(Reference)
+	if !ok {
+		return schema, namespace
+	}
+	if resolved, ns, ok := v.resolve(ref, namespace); ok {
+		return resolved, ns
+	}
+	return schema, namespace
+}
+
+// isPromotable returns true if values of the writer type can be read as values of the reader type.
+func isPromotable(writer, reader Type) bool {
+	switch writer {
+	case IntType:
+		return reader == LongType || reader == FloatType || reader == DoubleType
+	case LongType:
+		return reader == FloatType || reader == DoubleType
+	case FloatType:
+		return reader == DoubleType
+	case StringType:
+		return reader == BytesType
+	case BytesType:
+		return reader == StringType
+	}
+	return false
+}
+
+// isNamedPair returns true if the reader and writer schemas are named types of the same kind.
+func isNamedPair(reader, writer Schema) bool {
+	switch reader.(type) {
+	case Record:
+		_, ok := writer.(Record)
+		return ok
+	case Enum:
+		_, ok := writer.(Enum)
+		return ok
+	case Fixed:
+		_, ok := writer.(Fixed)
+		return ok
+	}
+	return false
+}
+
+// sameName returns true if the full names have the same unqualified name.
+func sameName(reader, writer string) bool {
+	return unqualifiedName(reader) == unqualifiedName(writer)
+}
+
+func unqualifiedName(fullName string) string {
+	if ns := namespaceOf(fullName); ns != "" {
+		return fullName[len(ns)+1:]
+	}
+	return fullName
+}
+
+// aliasedRecordField returns the field of the writer record that resolves to the reader field, by the name of
+// the reader field, by its aliases, or by the aliases of the writer field, which resolve fields of readers that
+// come before renames, for example when checking compatibility in both directions.
+func aliasedRecordField(writer Record, reader Field) (Field, bool) {
+	if field, ok := recordField(writer, reader.Name); ok {
+		return field, true
+	}
+	for _, alias := range reader.Aliases {
+		if field, ok := recordField(writer, alias); ok {
+			return field, true
+		}
+	}
+	for _, field := range writer.Fields {
+		for _, alias := range field.Aliases {
+			if alias == reader.Name {
+				return field, true
+			}
+		}
+	}
+	return Field{}, false
+}
+
+func recordField(record Record, name string) (Field, bool) {
+	for _, field := range record.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+func hasSymbol(enum Enum, symbol string) bool {
+	for _, candidate := range enum.Symbols {
+		if candidate == symbol {
+			return true
+		}
+	}
+	return false
+}
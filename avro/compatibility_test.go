@@ -0,0 +1,172 @@
+package avro
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	genre := func(symbols ...string) Enum {
+		return Enum{Type: EnumType, Name: "Genre", Symbols: symbols}
+	}
+	book := func(fields ...Field) Union {
+		return Nullable(Record{
+			Type:      RecordType,
+			Name:      "Book",
+			Namespace: "library.v1",
+			Fields:    fields,
+		})
+	}
+	title := Field{Name: "title", Type: Nullable(String())}
+	for _, tt := range []struct {
+		name     string
+		reader   Schema
+		writer   Schema
+		expected []Incompatibility
+	}{
+		{
+			name:   "identical",
+			reader: book(title),
+			writer: book(title),
+		},
+		{
+			name:   "writer field removed from reader",
+			reader: book(title),
+			writer: book(title, Field{Name: "pages", Type: Integer()}),
+		},
+		{
+			name:   "reader field missing in writer",
+			reader: book(title, Field{Name: "pages", Type: Integer()}),
+			writer: book(title),
+			expected: []Incompatibility{
+				{Type: MissingFieldDefault, Path: "pages", Name: "pages"},
+			},
+		},
+		{
+			name:   "reader field with default missing in writer",
+			reader: book(title, Field{Name: "pages", Type: Nullable(Integer()), Default: NullDefault()}),
+			writer: book(title),
+		},
+		{
+			name:   "reader field aliased in writer",
+			reader: book(Field{Name: "name", Aliases: []string{"title"}, Type: Nullable(String())}),
+			writer: book(title),
+		},
+		{
+			name:   "writer field aliased in reader",
+			reader: book(title),
+			writer: book(Field{Name: "name", Aliases: []string{"title"}, Type: Nullable(String())}),
+		},
+		{
+			name:   "promotion",
+			reader: book(Field{Name: "pages", Type: Long()}),
+			writer: book(Field{Name: "pages", Type: Integer()}),
+		},
+		{
+			name:   "type mismatch",
+			reader: book(Field{Name: "pages", Type: Integer()}),
+			writer: book(Field{Name: "pages", Type: Long()}),
+			expected: []Incompatibility{
+				{Type: TypeMismatch, Path: "pages", Reader: "int", Writer: "long"},
+			},
+		},
+		{
+			name:   "logical type mismatch",
+			reader: book(Field{Name: "published", Type: TimestampMillis()}),
+			writer: book(Field{Name: "published", Type: TimestampMicros()}),
+			expected: []Incompatibility{
+				{
+					Type:   TypeMismatch,
+					Path:   "published",
+					Reader: "long.timestamp-millis",
+					Writer: "long.timestamp-micros",
+				},
+			},
+		},
+		{
+			name:   "writer enum symbol missing in reader",
+			reader: book(Field{Name: "genre", Type: genre("UNSPECIFIED")}),
+			writer: book(Field{Name: "genre", Type: genre("UNSPECIFIED", "FANTASY")}),
+			expected: []Incompatibility{
+				{
+					Type:   MissingEnumSymbol,
+					Path:   "genre",
+					Name:   "FANTASY",
+					Reader: "library.v1.Genre",
+					Writer: "library.v1.Genre",
+				},
+			},
+		},
+		{
+			name: "reader enum default",
+			reader: book(Field{Name: "genre", Type: Enum{
+				Type:    EnumType,
+				Name:    "Genre",
+				Symbols: []string{"UNSPECIFIED"},
+				Default: "UNSPECIFIED",
+			}}),
+			writer: book(Field{Name: "genre", Type: genre("UNSPECIFIED", "FANTASY")}),
+		},
+		{
+			name:   "writer union branch missing in reader",
+			reader: book(Field{Name: "pages", Type: Nullable(Integer())}),
+			writer: book(Field{Name: "pages", Type: Union{Null(), Integer(), String()}}),
+			expected: []Incompatibility{
+				{Type: MissingUnionBranch, Path: "pages", Name: "string"},
+			},
+		},
+		{
+			name:   "nullable reader",
+			reader: book(Field{Name: "pages", Type: Nullable(Integer())}),
+			writer: book(Field{Name: "pages", Type: Integer()}),
+		},
+		{
+			name:   "name mismatch",
+			reader: Record{Type: RecordType, Name: "Book"},
+			writer: Record{Type: RecordType, Name: "Novel"},
+			expected: []Incompatibility{
+				{Type: NameMismatch, Reader: "Book", Writer: "Novel"},
+			},
+		},
+		{
+			name:   "fixed size mismatch",
+			reader: Fixed{Type: FixedType, Name: "ISBN", Size: 13},
+			writer: Fixed{Type: FixedType, Name: "ISBN", Size: 10},
+			expected: []Incompatibility{
+				{Type: FixedSizeMismatch, Reader: "13", Writer: "10"},
+			},
+		},
+		{
+			name:   "array items",
+			reader: book(Field{Name: "authors", Type: Array{Type: ArrayType, Items: Integer()}}),
+			writer: book(Field{Name: "authors", Type: Array{Type: ArrayType, Items: String()}}),
+			expected: []Incompatibility{
+				{Type: TypeMismatch, Path: "authors[]", Reader: "int", Writer: "string"},
+			},
+		},
+		{
+			name:   "recursive",
+			reader: book(title, Field{Name: "sequel", Type: Nullable(Reference("library.v1.Book"))}),
+			writer: book(title, Field{Name: "sequel", Type: Nullable(Reference("Book"))}),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.DeepEqual(t, tt.expected, CheckCompatibility(tt.reader, tt.writer))
+		})
+	}
+}
+
+func TestIncompatibility_String(t *testing.T) {
+	assert.Equal(
+		t,
+		Incompatibility{Type: MissingFieldDefault, Path: "book.pages", Name: "pages"}.String(),
+		"book.pages: reader field pages has no default value and is missing in writer",
+	)
+	assert.Equal(
+		t,
+		Incompatibility{Type: TypeMismatch, Reader: "int", Writer: "long"}.String(),
+		"reader type int does not match writer type long",
+	)
+}
@@ -0,0 +1,72 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MarshalIndent returns the JSON encoding of the schema, with each attribute on a new line that begins with
+// prefix, followed by copies of indent according to the nesting.
+// Indented schemas are suitable for checking into version control, where changes to a schema show up
+// as readable diffs.
+func MarshalIndent(schema Schema, prefix, indent string) ([]byte, error) {
+	data, err := marshalJSON(schema)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, prefix, indent); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// MarshalMinified returns the most compact JSON encoding of the schema, for example to send to a schema registry.
+// Whitespace is omitted, and primitive types without a logical type are written as their type name.
+// The encoding is stable: equal schemas are always encoded to the same bytes.
+func MarshalMinified(schema Schema) ([]byte, error) {
+	return marshalJSON(minify(schema))
+}
+
+// marshalJSON returns the JSON encoding of v, without escaping HTML characters, for example in docs.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// minify returns a copy of the schema where primitive types without a logical type are replaced by
+// references to their type name, which encode as JSON strings.
+func minify(schema Schema) Schema {
+	switch schema := schema.(type) {
+	case Primitive:
+		if schema.LogicalType == "" {
+			return Reference(schema.Type)
+		}
+	case Union:
+		union := make(Union, 0, len(schema))
+		for _, branch := range schema {
+			union = append(union, minify(branch))
+		}
+		return union
+	case Record:
+		fields := make([]Field, 0, len(schema.Fields))
+		for _, field := range schema.Fields {
+			field.Type = minify(field.Type)
+			fields = append(fields, field)
+		}
+		schema.Fields = fields
+		return schema
+	case Array:
+		schema.Items = minify(schema.Items)
+		return schema
+	case Map:
+		schema.Values = minify(schema.Values)
+		return schema
+	}
+	return schema
+}
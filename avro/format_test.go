@@ -0,0 +1,113 @@
+package avro
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMarshalIndent(t *testing.T) {
+	schema := Record{
+		Type:      RecordType,
+		Name:      "Book",
+		Namespace: "example",
+		Doc:       "A <single> book & more.",
+		Fields: []Field{
+			{Name: "title", Type: Nullable(String())},
+			{Name: "published", Type: TimestampMicros()},
+		},
+	}
+	got, err := MarshalIndent(schema, "", "  ")
+	assert.NilError(t, err)
+	assert.Equal(t, `{
+  "type": "record",
+  "namespace": "example",
+  "doc": "A <single> book & more.",
+  "name": "Book",
+  "fields": [
+    {
+      "name": "title",
+      "type": [
+        {
+          "type": "null"
+        },
+        {
+          "type": "string"
+        }
+      ]
+    },
+    {
+      "name": "published",
+      "type": {
+        "type": "long",
+        "logicalType": "timestamp-micros"
+      }
+    }
+  ]
+}`, string(got))
+	parsed, err := Parse(got)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, parsed)
+}
+
+func TestMarshalMinified(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		schema   Schema
+		expected string
+	}{
+		{name: "primitive", schema: String(), expected: `"string"`},
+		{name: "logical type", schema: Date(), expected: `{"type":"int","logicalType":"date"}`},
+		{name: "union", schema: Nullable(Long()), expected: `["null","long"]`},
+		{
+			name:     "array",
+			schema:   Array{Type: ArrayType, Items: Nullable(Double())},
+			expected: `{"type":"array","items":["null","double"]}`,
+		},
+		{
+			name:     "map",
+			schema:   Map{Type: MapType, Values: Boolean()},
+			expected: `{"type":"map","values":"boolean"}`,
+		},
+		{
+			name: "enum",
+			schema: Enum{
+				Type:          EnumType,
+				Name:          "Genre",
+				Symbols:       []string{"FICTION", "POETRY"},
+				SymbolAliases: map[string]string{"VERSE": "POETRY", "NOVEL": "FICTION"},
+			},
+			expected: `{"type":"enum","name":"Genre","symbols":["FICTION","POETRY"],` +
+				`"symbolAliases":{"NOVEL":"FICTION","VERSE":"POETRY"}}`,
+		},
+		{
+			name: "record",
+			schema: Record{
+				Type: RecordType,
+				Name: "Book",
+				Doc:  "A <single> book.",
+				Fields: []Field{
+					{
+						Name:       "title",
+						Type:       Nullable(String()),
+						Properties: Properties{"protoKind": "string", "protoFieldNumber": 1.0},
+					},
+					{Name: "sequel", Type: Nullable(Reference("Book")), Default: NullDefault()},
+				},
+			},
+			expected: `{"type":"record","doc":"A <single> book.","name":"Book","fields":[` +
+				`{"name":"title","type":["null","string"],"protoFieldNumber":1,"protoKind":"string"},` +
+				`{"name":"sequel","type":["null","Book"],"default":null}]}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalMinified(tt.schema)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, string(got))
+			parsed, err := Parse(got)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.schema, parsed)
+		})
+	}
+}
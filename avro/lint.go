@@ -0,0 +1,166 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintRule names a check of Lint.
+type LintRule string
+
+const (
+	// LintNameLength reports names that are longer than LintRules.MaxNameLength.
+	LintNameLength LintRule = "name-length"
+	// LintReservedWord reports names that are in LintRules.ReservedWords.
+	LintReservedWord LintRule = "reserved-word"
+	// LintUnionComplexity reports unions with more branches than LintRules.MaxUnionBranches.
+	LintUnionComplexity LintRule = "union-complexity"
+	// LintMissingDoc reports records, enums and fields without a doc, with LintRules.RequireDocs.
+	LintMissingDoc LintRule = "missing-doc"
+	// LintMissingDefault reports fields without a default, with LintRules.RequireDefaults.
+	LintMissingDefault LintRule = "missing-default"
+)
+
+// LintRules configures the checks of Lint. Checks with zero values are disabled.
+type LintRules struct {
+	// MaxNameLength is the maximum length of the names of records, enums, fixed types, fields and enum symbols,
+	// without their namespace, for example 128 for the column names of Hive.
+	MaxNameLength int
+	// ReservedWords are words that can not be used as names in target systems, for example "select" or "from"
+	// in SQL engines, compared case-insensitively with the names of records, enums, fixed types, fields and enum
+	// symbols.
+	ReservedWords []string
+	// MaxUnionBranches is the maximum number of branches of unions, null included, for example 2 for target
+	// systems that only support nullable types.
+	MaxUnionBranches int
+	// RequireDocs requires a doc on every record, enum and field.
+	RequireDocs bool
+	// RequireDefaults requires a default on every field, so that fields can be added and removed with full
+	// compatibility.
+	RequireDefaults bool
+}
+
+// LintFinding is a problematic pattern found by Lint.
+type LintFinding struct {
+	// Rule is the violated rule.
+	Rule LintRule `json:"rule"`
+	// Path is the path of the offending type within the schema, for example "google.example.library.v1.Book" for a
+	// record and "google.example.library.v1.Book.title" for a field or an enum symbol. The items of arrays and the
+	// values of maps are suffixed by "[]" and "{}", for example "google.example.library.v1.Book.authors[]". The
+	// path of the schema itself is empty.
+	Path string `json:"path"`
+	// Message describes the finding.
+	Message string `json:"message"`
+}
+
+// String returns the path, message and rule of the finding.
+func (f LintFinding) String() string {
+	if f.Path == "" {
+		return fmt.Sprintf("%s (%s)", f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%s: %s (%s)", f.Path, f.Message, f.Rule)
+}
+
+// Lint checks the schema for patterns that are problematic in target systems, according to the rules, and returns
+// the findings in the order of the schema, for example to gate the publication of schemas. Named types are checked
+// where they are defined, and references are not followed.
+func Lint(schema Schema, rules LintRules) []LintFinding {
+	l := linter{
+		rules:    rules,
+		reserved: make(map[string]struct{}, len(rules.ReservedWords)),
+		linted:   make(map[string]struct{}),
+	}
+	for _, word := range rules.ReservedWords {
+		l.reserved[strings.ToLower(word)] = struct{}{}
+	}
+	l.lint(schema, "", "")
+	return l.findings
+}
+
+type linter struct {
+	rules    LintRules
+	reserved map[string]struct{}
+	// linted holds the full names of the named types that have been checked.
+	linted   map[string]struct{}
+	findings []LintFinding
+}
+
+func (l *linter) lint(schema Schema, path, namespace string) {
+	switch s := schema.(type) {
+	case Record:
+		name := fullName(s.Name, s.Namespace, namespace)
+		if !l.define(name) {
+			return
+		}
+		l.lintName(unqualifiedName(name), name)
+		l.lintDoc(s.Doc, name, "record")
+		for _, field := range s.Fields {
+			fieldPath := name + "." + field.Name
+			l.lintName(field.Name, fieldPath)
+			l.lintDoc(field.Doc, fieldPath, "field")
+			if l.rules.RequireDefaults && field.Default == nil {
+				l.add(LintMissingDefault, fieldPath, "field has no default")
+			}
+			l.lint(field.Type, fieldPath, namespaceOf(name))
+		}
+	case Enum:
+		name := fullName(s.Name, s.Namespace, namespace)
+		if !l.define(name) {
+			return
+		}
+		l.lintName(unqualifiedName(name), name)
+		l.lintDoc(s.Doc, name, "enum")
+		for _, symbol := range s.Symbols {
+			l.lintName(symbol, name+"."+symbol)
+		}
+	case Fixed:
+		name := fullName(s.Name, s.Namespace, namespace)
+		if !l.define(name) {
+			return
+		}
+		l.lintName(unqualifiedName(name), name)
+	case Union:
+		if l.rules.MaxUnionBranches > 0 && len(s) > l.rules.MaxUnionBranches {
+			l.add(LintUnionComplexity, path, fmt.Sprintf(
+				"union has %d branches, more than %d", len(s), l.rules.MaxUnionBranches,
+			))
+		}
+		for _, branch := range s {
+			l.lint(branch, path, namespace)
+		}
+	case Array:
+		l.lint(s.Items, path+"[]", namespace)
+	case Map:
+		l.lint(s.Values, path+"{}", namespace)
+	}
+}
+
+// define returns true the first time a named type is defined.
+func (l *linter) define(name string) bool {
+	if _, ok := l.linted[name]; ok {
+		return false
+	}
+	l.linted[name] = struct{}{}
+	return true
+}
+
+func (l *linter) lintName(name, path string) {
+	if l.rules.MaxNameLength > 0 && len(name) > l.rules.MaxNameLength {
+		l.add(LintNameLength, path, fmt.Sprintf(
+			"name %s has %d characters, more than %d", name, len(name), l.rules.MaxNameLength,
+		))
+	}
+	if _, ok := l.reserved[strings.ToLower(name)]; ok {
+		l.add(LintReservedWord, path, fmt.Sprintf("name %s is a reserved word", name))
+	}
+}
+
+func (l *linter) lintDoc(doc, path, kind string) {
+	if l.rules.RequireDocs && strings.TrimSpace(doc) == "" {
+		l.add(LintMissingDoc, path, kind+" has no doc")
+	}
+}
+
+func (l *linter) add(rule LintRule, path, message string) {
+	l.findings = append(l.findings, LintFinding{Rule: rule, Path: path, Message: message})
+}
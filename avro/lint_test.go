@@ -0,0 +1,128 @@
+package avro
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLint(t *testing.T) {
+	book := Nullable(Record{
+		Type:      RecordType,
+		Name:      "Book",
+		Namespace: "google.example.library.v1",
+		Doc:       "A book.",
+		Fields: []Field{
+			{Name: "name", Doc: "The name of the book.", Type: Nullable(String()), Default: NullDefault()},
+			{Name: "select", Type: Union{Null(), String(), Long()}},
+			{
+				Name: "genres",
+				Doc:  "The genres of the book.",
+				Type: Array{
+					Type: ArrayType,
+					Items: Enum{
+						Type:    EnumType,
+						Name:    "Genre",
+						Symbols: []string{"GENRE_UNSPECIFIED", "FROM"},
+					},
+				},
+				Default: json.RawMessage("[]"),
+			},
+			{Name: "sequel", Doc: "The sequel.", Type: Nullable(Reference("Book")), Default: NullDefault()},
+			{Name: "prequel", Doc: "The prequel.", Type: Nullable(Reference("Genre")), Default: NullDefault()},
+			{
+				Name:    "ratings",
+				Doc:     "Ratings by source.",
+				Type:    Map{Type: MapType, Values: Union{Null(), Integer(), Double()}},
+				Default: json.RawMessage("{}"),
+			},
+		},
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		assert.Assert(t, len(Lint(book, LintRules{})) == 0)
+	})
+
+	t.Run("all rules", func(t *testing.T) {
+		findings := Lint(book, LintRules{
+			MaxNameLength:    6,
+			ReservedWords:    []string{"SELECT", "from"},
+			MaxUnionBranches: 2,
+			RequireDocs:      true,
+			RequireDefaults:  true,
+		})
+		assert.DeepEqual(t, []LintFinding{
+			{
+				Rule:    LintReservedWord,
+				Path:    "google.example.library.v1.Book.select",
+				Message: "name select is a reserved word",
+			},
+			{
+				Rule:    LintMissingDoc,
+				Path:    "google.example.library.v1.Book.select",
+				Message: "field has no doc",
+			},
+			{
+				Rule:    LintMissingDefault,
+				Path:    "google.example.library.v1.Book.select",
+				Message: "field has no default",
+			},
+			{
+				Rule:    LintUnionComplexity,
+				Path:    "google.example.library.v1.Book.select",
+				Message: "union has 3 branches, more than 2",
+			},
+			{
+				Rule:    LintMissingDoc,
+				Path:    "google.example.library.v1.Genre",
+				Message: "enum has no doc",
+			},
+			{
+				Rule:    LintNameLength,
+				Path:    "google.example.library.v1.Genre.GENRE_UNSPECIFIED",
+				Message: "name GENRE_UNSPECIFIED has 17 characters, more than 6",
+			},
+			{
+				Rule:    LintReservedWord,
+				Path:    "google.example.library.v1.Genre.FROM",
+				Message: "name FROM is a reserved word",
+			},
+			{
+				Rule:    LintNameLength,
+				Path:    "google.example.library.v1.Book.prequel",
+				Message: "name prequel has 7 characters, more than 6",
+			},
+			{
+				Rule:    LintNameLength,
+				Path:    "google.example.library.v1.Book.ratings",
+				Message: "name ratings has 7 characters, more than 6",
+			},
+			{
+				Rule:    LintUnionComplexity,
+				Path:    "google.example.library.v1.Book.ratings{}",
+				Message: "union has 3 branches, more than 2",
+			},
+		}, findings)
+	})
+
+	t.Run("root union", func(t *testing.T) {
+		findings := Lint(Union{Null(), Integer(), String()}, LintRules{MaxUnionBranches: 2})
+		assert.Equal(t, 1, len(findings))
+		assert.Equal(t, "union has 3 branches, more than 2 (union-complexity)", findings[0].String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		findings := Lint(book, LintRules{ReservedWords: []string{"select"}})
+		b, err := json.Marshal(findings)
+		assert.NilError(t, err)
+		assert.Equal(
+			t,
+			`[{"rule":"reserved-word","path":"google.example.library.v1.Book.select",`+
+				`"message":"name select is a reserved word"}]`,
+			string(b),
+		)
+		assert.Assert(t, strings.HasPrefix(findings[0].String(), "google.example.library.v1.Book.select: "))
+	})
+}
@@ -0,0 +1,18 @@
+package avro
+
+// Marshaler is the interface implemented by types that can marshal themselves into Avro binary format, for
+// serialization frameworks that discover codecs by interface.
+type Marshaler interface {
+	// AvroSchema returns the schema of the Avro binary encoding.
+	AvroSchema() Schema
+	// MarshalAvro returns the Avro binary encoding.
+	MarshalAvro() ([]byte, error)
+}
+
+// Unmarshaler is the interface implemented by types that can unmarshal an Avro binary encoding of themselves.
+//
+// UnmarshalAvro must copy the data if it wishes to retain the data after returning.
+type Unmarshaler interface {
+	// UnmarshalAvro decodes the Avro binary encoding.
+	UnmarshalAvro(data []byte) error
+}
@@ -0,0 +1,192 @@
+package avro
+
+import "fmt"
+
+// NamedSchema is a named type of a schema.
+type NamedSchema struct {
+	// FullName is the full name of the type, for example "einride.example.v1.Book".
+	FullName string
+	// Schema is the definition of the type, a Record, Enum or Fixed named by its full name, where named types
+	// it defines are replaced by references to their full name. Schema is nil for types that are referenced,
+	// but not defined, by the schema.
+	Schema Schema
+}
+
+// NamedTypes returns the named types defined or referenced by the schema, where types come after the types
+// they reference, for example to register the types one by one with schema registries that require references
+// to be registered first. Each type is listed once, by its first definition.
+func NamedTypes(schema Schema) []NamedSchema {
+	c := namedTypeCollector{index: make(map[string]int)}
+	c.collect(schema, "")
+	return c.types
+}
+
+type namedTypeCollector struct {
+	types []NamedSchema
+	// index holds the index of types by full name, or -1 for records that are being collected.
+	index map[string]int
+}
+
+// collect collects the named types of the schema, and returns the schema with named types replaced by
+// references to their full name.
+func (c *namedTypeCollector) collect(schema Schema, namespace string) Schema {
+	switch s := schema.(type) {
+	case Record:
+		name := fullName(s.Name, s.Namespace, namespace)
+		if _, ok := c.index[name]; ok {
+			return Reference(name)
+		}
+		c.index[name] = -1
+		fields := make([]Field, 0, len(s.Fields))
+		for _, field := range s.Fields {
+			field.Type = c.collect(field.Type, namespaceOf(name))
+			fields = append(fields, field)
+		}
+		s.Name, s.Namespace, s.Fields = unqualifiedName(name), namespaceOf(name), fields
+		c.add(name, s)
+		return Reference(name)
+	case Enum:
+		name := fullName(s.Name, s.Namespace, namespace)
+		if _, ok := c.index[name]; !ok {
+			s.Name, s.Namespace = unqualifiedName(name), namespaceOf(name)
+			c.add(name, s)
+		}
+		return Reference(name)
+	case Fixed:
+		name := fullName(s.Name, s.Namespace, namespace)
+		if _, ok := c.index[name]; !ok {
+			s.Name, s.Namespace = unqualifiedName(name), namespaceOf(name)
+			c.add(name, s)
+		}
+		return Reference(name)
+	case Reference:
+		if isPrimitiveType(Type(s)) {
+			return s
+		}
+		for _, name := range []string{fullName(string(s), "", namespace), string(s)} {
+			if _, ok := c.index[name]; ok {
+				return Reference(name)
+			}
+		}
+		name := fullName(string(s), "", namespace)
+		c.add(name, nil)
+		return Reference(name)
+	case Union:
+		union := make(Union, 0, len(s))
+		for _, branch := range s {
+			union = append(union, c.collect(branch, namespace))
+		}
+		return union
+	case Array:
+		s.Items = c.collect(s.Items, namespace)
+		return s
+	case Map:
+		s.Values = c.collect(s.Values, namespace)
+		return s
+	}
+	return schema
+}
+
+func (c *namedTypeCollector) add(name string, schema Schema) {
+	c.index[name] = len(c.types)
+	c.types = append(c.types, NamedSchema{FullName: name, Schema: schema})
+}
+
+// ResolveReferences returns a copy of the schema where references to named types that the schema does not
+// define are replaced by their definitions in defs, as returned by NamedTypes, for example for schema registries
+// that require self-contained schemas. Each type is defined at its first use, and referenced by its full name
+// elsewhere. An error is returned for references to types that are neither defined by the schema nor by defs.
+func ResolveReferences(schema Schema, defs []NamedSchema) (Schema, error) {
+	r := referenceResolver{
+		defs:    make(map[string]Schema, len(defs)),
+		defined: make(map[string]struct{}),
+	}
+	for _, def := range defs {
+		if def.Schema != nil {
+			r.defs[def.FullName] = def.Schema
+		}
+	}
+	// types defined by the schema take precedence over defs
+	for _, named := range NamedTypes(schema) {
+		if named.Schema != nil {
+			r.defs[named.FullName] = nil
+		}
+	}
+	return r.resolve(schema, "")
+}
+
+type referenceResolver struct {
+	// defs holds the definitions of types by full name, and nil for types defined by the schema.
+	defs map[string]Schema
+	// defined holds the full names of the types defined so far.
+	defined map[string]struct{}
+}
+
+func (r referenceResolver) resolve(schema Schema, namespace string) (Schema, error) {
+	switch s := schema.(type) {
+	case Record:
+		name := fullName(s.Name, s.Namespace, namespace)
+		r.defined[name] = struct{}{}
+		fields := make([]Field, 0, len(s.Fields))
+		for _, field := range s.Fields {
+			fieldType, err := r.resolve(field.Type, namespaceOf(name))
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", name, field.Name, err)
+			}
+			field.Type = fieldType
+			fields = append(fields, field)
+		}
+		s.Fields = fields
+		return s, nil
+	case Enum:
+		r.defined[fullName(s.Name, s.Namespace, namespace)] = struct{}{}
+		return s, nil
+	case Fixed:
+		r.defined[fullName(s.Name, s.Namespace, namespace)] = struct{}{}
+		return s, nil
+	case Reference:
+		if isPrimitiveType(Type(s)) {
+			return s, nil
+		}
+		for _, name := range []string{fullName(string(s), "", namespace), string(s)} {
+			if _, ok := r.defined[name]; ok {
+				return Reference(name), nil
+			}
+			def, ok := r.defs[name]
+			if !ok {
+				continue
+			}
+			if def == nil {
+				// defined later by the schema
+				return Reference(name), nil
+			}
+			return r.resolve(def, "")
+		}
+		return nil, fmt.Errorf("unresolved reference %s", s)
+	case Union:
+		union := make(Union, 0, len(s))
+		for _, branch := range s {
+			resolved, err := r.resolve(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			union = append(union, resolved)
+		}
+		return union, nil
+	case Array:
+		items, err := r.resolve(s.Items, namespace)
+		if err != nil {
+			return nil, err
+		}
+		s.Items = items
+		return s, nil
+	case Map:
+		values, err := r.resolve(s.Values, namespace)
+		if err != nil {
+			return nil, err
+		}
+		s.Values = values
+		return s, nil
+	}
+	return schema, nil
+}
@@ -0,0 +1,122 @@
+package avro
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNamedTypes(t *testing.T) {
+	schema := Nullable(Record{
+		Type:      RecordType,
+		Name:      "Library",
+		Namespace: "example.v1",
+		Fields: []Field{
+			{
+				Name: "books",
+				Type: Array{Type: ArrayType, Items: Record{
+					Type: RecordType,
+					Name: "Book",
+					Fields: []Field{
+						{Name: "title", Type: Reference("string")},
+						{Name: "genre", Type: Enum{Type: EnumType, Name: "Genre", Symbols: []string{"FICTION"}}},
+						{Name: "related", Type: Nullable(Reference("Book"))},
+					},
+				}},
+			},
+			{Name: "favorite", Type: Nullable(Reference("example.v1.Book"))},
+			{Name: "checksum", Type: Fixed{Type: FixedType, Name: "Checksum", Namespace: "common", Size: 4}},
+			{Name: "owner", Type: Reference("common.Person")},
+		},
+	})
+	got := NamedTypes(schema)
+	names := make([]string, 0, len(got))
+	for _, named := range got {
+		names = append(names, named.FullName)
+	}
+	assert.DeepEqual(t, names, []string{
+		"example.v1.Genre",
+		"example.v1.Book",
+		"common.Checksum",
+		"common.Person",
+		"example.v1.Library",
+	})
+	assert.DeepEqual(t, got[1].Schema, Record{
+		Type:      RecordType,
+		Name:      "Book",
+		Namespace: "example.v1",
+		Fields: []Field{
+			{Name: "title", Type: Reference("string")},
+			{Name: "genre", Type: Reference("example.v1.Genre")},
+			{Name: "related", Type: Nullable(Reference("example.v1.Book"))},
+		},
+	})
+	assert.Assert(t, got[3].Schema == nil)
+	assert.DeepEqual(t, got[4].Schema.(Record).Fields[0].Type, Array{Type: ArrayType, Items: Reference("example.v1.Book")})
+}
+
+func TestResolveReferences(t *testing.T) {
+	person := Record{
+		Type:      RecordType,
+		Name:      "Person",
+		Namespace: "common",
+		Fields: []Field{
+			{Name: "name", Type: String()},
+			{Name: "address", Type: Reference("common.Address")},
+		},
+	}
+	address := Record{
+		Type:      RecordType,
+		Name:      "Address",
+		Namespace: "common",
+		Fields:    []Field{{Name: "city", Type: String()}},
+	}
+	defs := []NamedSchema{
+		{FullName: "common.Address", Schema: address},
+		{FullName: "common.Person", Schema: person},
+	}
+	schema := Record{
+		Type:      RecordType,
+		Name:      "Book",
+		Namespace: "example.v1",
+		Fields: []Field{
+			{Name: "author", Type: Reference("common.Person")},
+			{Name: "editor", Type: Nullable(Reference("common.Person"))},
+			{Name: "sequel", Type: Nullable(Reference("Book"))},
+		},
+	}
+
+	t.Run("inline", func(t *testing.T) {
+		got, err := ResolveReferences(schema, defs)
+		assert.NilError(t, err)
+		expectedPerson := person
+		expectedPerson.Fields = []Field{person.Fields[0], {Name: "address", Type: address}}
+		assert.DeepEqual(t, got, Record{
+			Type:      RecordType,
+			Name:      "Book",
+			Namespace: "example.v1",
+			Fields: []Field{
+				{Name: "author", Type: expectedPerson},
+				{Name: "editor", Type: Nullable(Reference("common.Person"))},
+				{Name: "sequel", Type: Nullable(Reference("example.v1.Book"))},
+			},
+		})
+		// the resolved schema is self-contained
+		for _, named := range NamedTypes(got) {
+			assert.Assert(t, named.Schema != nil, named.FullName)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		types := NamedTypes(Nullable(schema))
+		assert.Equal(t, len(types), 2)
+		got, err := ResolveReferences(types[1].Schema, append(defs, types...))
+		assert.NilError(t, err)
+		assert.Equal(t, got.(Record).Name, "Book")
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		_, err := ResolveReferences(schema, defs[:1])
+		assert.ErrorContains(t, err, "example.v1.Book.author: unresolved reference common.Person")
+	})
+}
@@ -0,0 +1,181 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Parse parses a JSON encoded Avro schema declaration.
+// Names of types that are not primitive types are parsed as references to named types.
+// Attributes of records, fields and enums that have no corresponding field in the schema types are parsed
+// as their Properties. Other attributes without a corresponding field are ignored.
+func Parse(data []byte) (Schema, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return parse(v)
+}
+
+func parse(v interface{}) (Schema, error) {
+	switch v := v.(type) {
+	case string:
+		if isPrimitiveType(Type(v)) {
+			return Primitive{Type: Type(v)}, nil
+		}
+		return Reference(v), nil
+	case []interface{}:
+		union := make(Union, 0, len(v))
+		for _, branch := range v {
+			schema, err := parse(branch)
+			if err != nil {
+				return nil, err
+			}
+			union = append(union, schema)
+		}
+		return union, nil
+	case map[string]interface{}:
+		return parseObject(v)
+	}
+	return nil, fmt.Errorf("parse schema: unexpected %T", v)
+}
+
+func parseObject(v map[string]interface{}) (Schema, error) {
+	t, ok := v["type"].(string)
+	if !ok {
+		// the type attribute is itself a schema
+		return parse(v["type"])
+	}
+	switch Type(t) {
+	case RecordType, "error":
+		return parseRecord(v)
+	case EnumType:
+		e := Enum{
+			Type:       EnumType,
+			Name:       stringAttr(v, "name"),
+			Namespace:  stringAttr(v, "namespace"),
+			Doc:        stringAttr(v, "doc"),
+			Default:    stringAttr(v, "default"),
+			Properties: parseProperties(v, enumAttributes),
+		}
+		symbols, ok := v["symbols"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("parse schema: enum %s: missing symbols", e.Name)
+		}
+		for _, symbol := range symbols {
+			s, ok := symbol.(string)
+			if !ok {
+				return nil, fmt.Errorf("parse schema: enum %s: unexpected symbol %v", e.Name, symbol)
+			}
+			e.Symbols = append(e.Symbols, s)
+		}
+		if aliases, ok := v["symbolAliases"].(map[string]interface{}); ok {
+			e.SymbolAliases = make(map[string]string, len(aliases))
+			for alias, symbol := range aliases {
+				e.SymbolAliases[alias], _ = symbol.(string)
+			}
+		}
+		return e, nil
+	case ArrayType:
+		items, err := parse(v["items"])
+		if err != nil {
+			return nil, err
+		}
+		return Array{Type: ArrayType, Items: items}, nil
+	case MapType:
+		values, err := parse(v["values"])
+		if err != nil {
+			return nil, err
+		}
+		return Map{Type: MapType, Values: values}, nil
+	case FixedType:
+		return Fixed{
+			Type:        FixedType,
+			Name:        stringAttr(v, "name"),
+			Namespace:   stringAttr(v, "namespace"),
+			Size:        intAttr(v, "size"),
+			LogicalType: LogicalType(stringAttr(v, "logicalType")),
+		}, nil
+	}
+	if !isPrimitiveType(Type(t)) {
+		if len(v) == 1 {
+			return Reference(t), nil
+		}
+		return nil, fmt.Errorf("parse schema: unknown type %s", t)
+	}
+	return Primitive{
+		Type:        Type(t),
+		LogicalType: LogicalType(stringAttr(v, "logicalType")),
+		Precision:   intAttr(v, "precision"),
+		Scale:       intAttr(v, "scale"),
+	}, nil
+}
+
+func parseRecord(v map[string]interface{}) (Schema, error) {
+	r := Record{
+		Type:       RecordType,
+		Name:       stringAttr(v, "name"),
+		Namespace:  stringAttr(v, "namespace"),
+		Doc:        stringAttr(v, "doc"),
+		Properties: parseProperties(v, recordAttributes),
+	}
+	fields, ok := v["fields"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("parse schema: record %s: missing fields", r.Name)
+	}
+	r.Fields = make([]Field, 0, len(fields))
+	for _, field := range fields {
+		f, ok := field.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("parse schema: record %s: unexpected field %v", r.Name, field)
+		}
+		fieldType, err := parse(f["type"])
+		if err != nil {
+			return nil, fmt.Errorf("record %s: field %s: %w", r.Name, stringAttr(f, "name"), err)
+		}
+		var fieldDefault json.RawMessage
+		if value, ok := f["default"]; ok {
+			if fieldDefault, err = json.Marshal(value); err != nil {
+				return nil, fmt.Errorf("record %s: field %s: %w", r.Name, stringAttr(f, "name"), err)
+			}
+		}
+		r.Fields = append(r.Fields, Field{
+			Name:       stringAttr(f, "name"),
+			Doc:        stringAttr(f, "doc"),
+			Type:       fieldType,
+			Default:    fieldDefault,
+			Aliases:    stringsAttr(f, "aliases"),
+			Properties: parseProperties(f, fieldAttributes),
+		})
+	}
+	return r, nil
+}
+
+func isPrimitiveType(t Type) bool {
+	switch t {
+	case NullType, BooleanType, IntType, LongType, FloatType, DoubleType, BytesType, StringType:
+		return true
+	}
+	return false
+}
+
+func stringAttr(v map[string]interface{}, name string) string {
+	s, _ := v[name].(string)
+	return s
+}
+
+func stringsAttr(v map[string]interface{}, name string) []string {
+	values, _ := v[name].([]interface{})
+	var s []string
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			s = append(s, str)
+		}
+	}
+	return s
+}
+
+func intAttr(v map[string]interface{}, name string) int {
+	f, _ := v[name].(float64)
+	return int(f)
+}
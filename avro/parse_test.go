@@ -0,0 +1,82 @@
+package avro
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		schema Schema
+	}{
+		{name: "primitive", schema: String()},
+		{name: "logical type", schema: TimestampMicros()},
+		{name: "decimal", schema: Decimal(38, 9)},
+		{name: "union", schema: Nullable(Long())},
+		{name: "array", schema: Array{Type: ArrayType, Items: Nullable(Double())}},
+		{name: "map", schema: Map{Type: MapType, Values: Boolean()}},
+		{name: "fixed", schema: Fixed{Type: FixedType, Name: "MD5", Namespace: "example", Size: 16}},
+		{name: "duration", schema: Duration("Duration", "google.protobuf")},
+		{
+			name: "record",
+			schema: Nullable(Record{
+				Type:      RecordType,
+				Name:      "Book",
+				Namespace: "google.example.library.v1",
+				Doc:       "A single book in the library.",
+				Fields: []Field{
+					{
+						Name:       "name",
+						Doc:        "The resource name of the book.",
+						Type:       Nullable(String()),
+						Properties: Properties{"protoKind": "string", "protoFieldNumber": 1.0},
+					},
+					{
+						Name: "genre",
+						Type: Nullable(Enum{
+							Type:      EnumType,
+							Name:      "Genre",
+							Namespace: "google.example.library.v1.Book",
+							Symbols:   []string{"GENRE_UNSPECIFIED", "FANTASY"},
+							Default:   "GENRE_UNSPECIFIED",
+						}),
+					},
+					{
+						Name:    "sequel",
+						Type:    Nullable(Reference("google.example.library.v1.Book")),
+						Default: NullDefault(),
+						Aliases: []string{"next"},
+					},
+				},
+			}),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.schema)
+			assert.NilError(t, err)
+			parsed, err := Parse(data)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.schema, parsed)
+		})
+	}
+}
+
+func TestParse_Shorthand(t *testing.T) {
+	parsed, err := Parse([]byte(`{"type": {"type": "array", "items": "string"}}`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, Array{Type: ArrayType, Items: String()}, parsed)
+	parsed, err = Parse([]byte(`{"type": "example.Book"}`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, Reference("example.Book"), parsed)
+}
+
+func TestParse_Error(t *testing.T) {
+	_, err := Parse([]byte(`{"type": "enum", "name": "Genre"}`))
+	assert.ErrorContains(t, err, "enum Genre: missing symbols")
+	_, err = Parse([]byte(`{"type": "record", "name": "Book", "fields": [{"name": "name", "type": 1}]}`))
+	assert.ErrorContains(t, err, "record Book: field name: parse schema: unexpected float64")
+}
@@ -0,0 +1,90 @@
+package avro
+
+import "sort"
+
+// Properties are custom attributes of a record, field or enum, for example {"owner": "team-x"}.
+// Avro readers ignore custom attributes, but they are kept in the schema, for example by schema registries.
+// Values are encoded with encoding/json, and parsed as the values of encoding/json: nil, bool, float64,
+// string, []interface{} and map[string]interface{}.
+type Properties map[string]interface{}
+
+// recordAttributes are the attributes of a record, which can not be used as properties.
+var recordAttributes = map[string]struct{}{
+	"type": {}, "namespace": {}, "doc": {}, "name": {}, "fields": {},
+}
+
+// fieldAttributes are the attributes of a field, which can not be used as properties.
+var fieldAttributes = map[string]struct{}{
+	"name": {}, "doc": {}, "type": {}, "default": {}, "aliases": {},
+}
+
+// enumAttributes are the attributes of an enum, which can not be used as properties.
+var enumAttributes = map[string]struct{}{
+	"type": {}, "namespace": {}, "doc": {}, "name": {}, "symbols": {}, "default": {}, "symbolAliases": {},
+}
+
+// MarshalJSON encodes the record, followed by its properties.
+func (p Record) MarshalJSON() ([]byte, error) {
+	type record Record
+	return marshalWithProperties(record(p), p.Properties, recordAttributes)
+}
+
+// MarshalJSON encodes the field, followed by its properties.
+func (f Field) MarshalJSON() ([]byte, error) {
+	type field Field
+	return marshalWithProperties(field(f), f.Properties, fieldAttributes)
+}
+
+// MarshalJSON encodes the enum, followed by its properties.
+func (e Enum) MarshalJSON() ([]byte, error) {
+	type enum Enum
+	return marshalWithProperties(enum(e), e.Properties, enumAttributes)
+}
+
+// marshalWithProperties encodes the JSON object v, followed by the properties in sorted order.
+// Properties named like attributes of the object are ignored.
+func marshalWithProperties(v interface{}, properties Properties, attributes map[string]struct{}) ([]byte, error) {
+	data, err := marshalJSON(v)
+	if err != nil || len(properties) == 0 {
+		return data, err
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		if _, ok := attributes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	data = data[:len(data)-1]
+	for _, name := range names {
+		key, err := marshalJSON(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalJSON(properties[name])
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, ',')
+		data = append(data, key...)
+		data = append(data, ':')
+		data = append(data, value...)
+	}
+	return append(data, '}'), nil
+}
+
+// parseProperties returns the attributes of a parsed JSON object that are not in attributes,
+// or nil if there are none.
+func parseProperties(v map[string]interface{}, attributes map[string]struct{}) Properties {
+	var properties Properties
+	for name, value := range v {
+		if _, ok := attributes[name]; ok {
+			continue
+		}
+		if properties == nil {
+			properties = make(Properties)
+		}
+		properties[name] = value
+	}
+	return properties
+}
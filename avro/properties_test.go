@@ -0,0 +1,57 @@
+package avro
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProperties(t *testing.T) {
+	schema := Record{
+		Type: RecordType,
+		Name: "Book",
+		Fields: []Field{
+			{
+				Name:       "author",
+				Type:       String(),
+				Properties: Properties{"sensitivity": "pii", "tags": []interface{}{"a", "b"}},
+			},
+		},
+		Properties: Properties{"owner": "team-x", "retention": map[string]interface{}{"days": 30.0}},
+	}
+	data, err := MarshalMinified(schema)
+	assert.NilError(t, err)
+	assert.Equal(
+		t,
+		`{"type":"record","name":"Book","fields":[`+
+			`{"name":"author","type":"string","sensitivity":"pii","tags":["a","b"]}],`+
+			`"owner":"team-x","retention":{"days":30}}`,
+		string(data),
+	)
+	parsed, err := Parse(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, parsed)
+}
+
+func TestProperties_Enum(t *testing.T) {
+	data := []byte(`{"type": "enum", "name": "Genre", "symbols": ["FICTION"], "aliases": ["Category"], "owner": 1}`)
+	parsed, err := Parse(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, Enum{
+		Type:       EnumType,
+		Name:       "Genre",
+		Symbols:    []string{"FICTION"},
+		Properties: Properties{"aliases": []interface{}{"Category"}, "owner": 1.0},
+	}, parsed)
+	got, err := json.Marshal(parsed)
+	assert.NilError(t, err)
+	assert.Equal(t, `{"type":"enum","name":"Genre","symbols":["FICTION"],"aliases":["Category"],"owner":1}`, string(got))
+}
+
+func TestProperties_Attributes(t *testing.T) {
+	// properties named like attributes are ignored
+	got, err := json.Marshal(Field{Name: "title", Type: String(), Properties: Properties{"name": "foo", "type": 1}})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"name":"title","type":{"type":"string"}}`, string(got))
+}
@@ -2,6 +2,8 @@
 // to spec at http://avro.apache.org/docs/current/spec.html.
 package avro
 
+import "encoding/json"
+
 // Schema describes an Avro schema.
 // JSON encoding of a Schema value matches the specification
 // for a schema declaration.
@@ -26,6 +28,8 @@ const (
 	RecordType  Type = "record"
 	EnumType    Type = "enum"
 	ArrayType   Type = "array"
+	MapType     Type = "map"
+	FixedType   Type = "fixed"
 )
 
 // LogicalType is an Avro primitive or complex type with extra attributes to represent a derived type.
@@ -34,8 +38,12 @@ type LogicalType string
 
 const (
 	DateLogicalType            LogicalType = "date"
+	DecimalLogicalType         LogicalType = "decimal"
+	DurationLogicalType        LogicalType = "duration"
 	TimeMicrosLogicalType      LogicalType = "time-micros"
+	TimestampMillisLogicalType LogicalType = "timestamp-millis"
 	TimestampMicrosLogicalType LogicalType = "timestamp-micros"
+	TimestampNanosLogicalType  LogicalType = "timestamp-nanos"
 )
 
 type Reference string
@@ -49,6 +57,10 @@ func (e Union) isSchema() {}
 type Primitive struct {
 	Type        Type        `json:"type"`
 	LogicalType LogicalType `json:"logicalType,omitempty"`
+	// Precision is the maximum number of digits of a decimal.
+	Precision int `json:"precision,omitempty"`
+	// Scale is the number of digits to the right of the decimal point of a decimal.
+	Scale int `json:"scale,omitempty"`
 }
 
 func (p Primitive) isSchema() {}
@@ -91,6 +103,8 @@ type Record struct {
 	Doc       string  `json:"doc,omitempty"`
 	Name      string  `json:"name"`
 	Fields    []Field `json:"fields"`
+	// Properties are custom attributes of the record.
+	Properties Properties `json:"-"`
 }
 
 func (p Record) isSchema() {}
@@ -99,6 +113,14 @@ type Field struct {
 	Name string `json:"name"`
 	Doc  string `json:"doc,omitempty"`
 	Type Schema `json:"type"`
+	// Default is the JSON encoded default value of the field, which readers use when the field is missing
+	// from the writer schema. Nil means that the field has no default.
+	Default json.RawMessage `json:"default,omitempty"`
+	// Aliases are alternative names of the field, which readers use to resolve fields of writer schemas that are
+	// named by an alias, for example the name of a renamed field.
+	Aliases []string `json:"aliases,omitempty"`
+	// Properties are custom attributes of the field.
+	Properties Properties `json:"-"`
 }
 
 type Enum struct {
@@ -107,6 +129,13 @@ type Enum struct {
 	Doc       string   `json:"doc,omitempty"`
 	Name      string   `json:"name"`
 	Symbols   []string `json:"symbols"`
+	// Default is the symbol used by readers for symbols that are not in Symbols.
+	Default string `json:"default,omitempty"`
+	// SymbolAliases is a custom property that maps alias symbols to the symbols in Symbols.
+	// Avro has no native aliases for symbols.
+	SymbolAliases map[string]string `json:"symbolAliases,omitempty"`
+	// Properties are custom attributes of the enum.
+	Properties Properties `json:"-"`
 }
 
 func (e Enum) isSchema() {}
@@ -118,11 +147,19 @@ type Array struct {
 
 func (e Array) isSchema() {}
 
+type Map struct {
+	Type   Type   `json:"type"`
+	Values Schema `json:"values"`
+}
+
+func (m Map) isSchema() {}
+
 type Fixed struct {
-	Type      Type   `json:"type"`
-	Name      string `json:"name"`
-	Namespace string `json:"namespace,omitempty"`
-	Size      int    `json:"size"`
+	Type        Type        `json:"type"`
+	Name        string      `json:"name"`
+	Namespace   string      `json:"namespace,omitempty"`
+	Size        int         `json:"size"`
+	LogicalType LogicalType `json:"logicalType,omitempty"`
 }
 
 func (e Fixed) isSchema() {}
@@ -141,6 +178,13 @@ func TimeMicros() Primitive {
 	}
 }
 
+func TimestampMillis() Primitive {
+	return Primitive{
+		Type:        LongType,
+		LogicalType: TimestampMillisLogicalType,
+	}
+}
+
 func TimestampMicros() Primitive {
 	return Primitive{
 		Type:        LongType,
@@ -148,6 +192,39 @@ func TimestampMicros() Primitive {
 	}
 }
 
+func TimestampNanos() Primitive {
+	return Primitive{
+		Type:        LongType,
+		LogicalType: TimestampNanosLogicalType,
+	}
+}
+
+// Duration returns a duration logical type with the given name, backed by a fixed of size 12.
+func Duration(name, namespace string) Fixed {
+	return Fixed{
+		Type:        FixedType,
+		Name:        name,
+		Namespace:   namespace,
+		Size:        12,
+		LogicalType: DurationLogicalType,
+	}
+}
+
+// Decimal returns a decimal logical type with the given precision and scale, backed by bytes.
+func Decimal(precision, scale int) Primitive {
+	return Primitive{
+		Type:        BytesType,
+		LogicalType: DecimalLogicalType,
+		Precision:   precision,
+		Scale:       scale,
+	}
+}
+
+// NullDefault returns the default value null, for fields of a union type where null is the first branch.
+func NullDefault() json.RawMessage {
+	return json.RawMessage("null")
+}
+
 func Nullable(schema Schema) Union {
 	if union, ok := schema.(Union); ok {
 		var found bool
@@ -0,0 +1,333 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError is returned by Validate when a datum does not match a schema.
+type ValidationError struct {
+	// Path is the path of the invalid value within the datum, for example "book.authors[1]".
+	// Path is empty when the datum itself is invalid.
+	Path string
+	// Message describes why the value is invalid.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks that datum is a valid value of schema.
+// The datum is expected in the generic form used by github.com/linkedin/goavro/v2, where records are
+// map[string]interface{}, arrays are []interface{}, and non-null union values are wrapped in a
+// map[string]interface{} with a single key naming the selected branch.
+// A *ValidationError is returned for the first invalid value.
+func Validate(schema Schema, datum interface{}) error {
+	v := validator{names: make(map[string]Schema)}
+	v.collectNames(schema, "")
+	return v.validate(schema, datum, "", "")
+}
+
+type validator struct {
+	names map[string]Schema
+}
+
+// collectNames collects all named types defined in schema by their full name.
+func (v validator) collectNames(schema Schema, namespace string) {
+	switch s := schema.(type) {
+	case Record:
+		name := fullName(s.Name, s.Namespace, namespace)
+		v.names[name] = s
+		for _, field := range s.Fields {
+			v.collectNames(field.Type, namespaceOf(name))
+		}
+	case Enum:
+		v.names[fullName(s.Name, s.Namespace, namespace)] = s
+	case Fixed:
+		v.names[fullName(s.Name, s.Namespace, namespace)] = s
+	case Array:
+		v.collectNames(s.Items, namespace)
+	case Map:
+		v.collectNames(s.Values, namespace)
+	case Union:
+		for _, branch := range s {
+			v.collectNames(branch, namespace)
+		}
+	}
+}
+
+// fullName returns the full name of a named type, according to the rules in
+// https://avro.apache.org/docs/current/specification/#names.
+func fullName(name, namespace, enclosing string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	if namespace == "" {
+		namespace = enclosing
+	}
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func namespaceOf(fullName string) string {
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		return fullName[:i]
+	}
+	return ""
+}
+
+func (v validator) resolve(ref Reference, namespace string) (Schema, string, bool) {
+	for _, name := range []string{fullName(string(ref), "", namespace), string(ref)} {
+		if s, ok := v.names[name]; ok {
+			return s, namespaceOf(name), true
+		}
+	}
+	return nil, "", false
+}
+
+func (v validator) validate(schema Schema, datum interface{}, path, namespace string) error {
+	switch s := schema.(type) {
+	case Reference:
+		resolved, ns, ok := v.resolve(s, namespace)
+		if !ok {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("unknown named type %s", s)}
+		}
+		return v.validate(resolved, datum, path, ns)
+	case Primitive:
+		return validatePrimitive(s, datum, path)
+	case Record:
+		record, ok := datum.(map[string]interface{})
+		if !ok {
+			return invalidType(path, "record", datum)
+		}
+		ns := namespaceOf(fullName(s.Name, s.Namespace, namespace))
+		for _, field := range s.Fields {
+			value, ok := record[field.Name]
+			if !ok {
+				return &ValidationError{Path: joinPath(path, field.Name), Message: "missing field"}
+			}
+			if err := v.validate(field.Type, value, joinPath(path, field.Name), ns); err != nil {
+				return err
+			}
+		}
+		for name := range record {
+			if !hasField(s, name) {
+				return &ValidationError{Path: joinPath(path, name), Message: "unexpected field"}
+			}
+		}
+		return nil
+	case Enum:
+		symbol, ok := datum.(string)
+		if !ok {
+			return invalidType(path, "enum", datum)
+		}
+		for _, candidate := range s.Symbols {
+			if candidate == symbol {
+				return nil
+			}
+		}
+		return &ValidationError{Path: path, Message: fmt.Sprintf("unknown symbol %q for enum %s", symbol, s.Name)}
+	case Fixed:
+		var size int
+		switch b := datum.(type) {
+		case []byte:
+			size = len(b)
+		case string:
+			size = len(b)
+		default:
+			return invalidType(path, "fixed", datum)
+		}
+		if size != s.Size {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected %d bytes, got %d", s.Size, size)}
+		}
+		return nil
+	case Array:
+		items, ok := datum.([]interface{})
+		if !ok {
+			return invalidType(path, "array", datum)
+		}
+		for i, item := range items {
+			if err := v.validate(s.Items, item, path+"["+strconv.Itoa(i)+"]", namespace); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Map:
+		values, ok := datum.(map[string]interface{})
+		if !ok {
+			return invalidType(path, "map", datum)
+		}
+		for key, value := range values {
+			if err := v.validate(s.Values, value, path+"["+strconv.Quote(key)+"]", namespace); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Union:
+		return v.validateUnion(s, datum, path, namespace)
+	}
+	return &ValidationError{Path: path, Message: fmt.Sprintf("unsupported schema %T", schema)}
+}
+
+func (v validator) validateUnion(union Union, datum interface{}, path, namespace string) error {
+	if datum == nil {
+		for _, branch := range union {
+			if branch == Null() {
+				return nil
+			}
+		}
+		return &ValidationError{Path: path, Message: "null is not allowed"}
+	}
+	wrapped, ok := datum.(map[string]interface{})
+	if !ok || len(wrapped) != 1 {
+		return &ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf("expected union value wrapped in a single-key map, got %T", datum),
+		}
+	}
+	for key, value := range wrapped {
+		for _, branch := range union {
+			if v.branchName(branch, namespace) == key {
+				return v.validate(branch, value, path, namespace)
+			}
+		}
+		return &ValidationError{Path: path, Message: fmt.Sprintf("unknown union branch %s", key)}
+	}
+	return nil
+}
+
+// branchName returns the name used to select a branch of a union.
+func (v validator) branchName(schema Schema, namespace string) string {
+	switch s := schema.(type) {
+	case Reference:
+		if _, ns, ok := v.resolve(s, namespace); ok {
+			return fullName(string(s), "", ns)
+		}
+		return string(s)
+	case Primitive:
+		if s.LogicalType != "" {
+			return string(s.Type) + "." + string(s.LogicalType)
+		}
+		return string(s.Type)
+	case Record:
+		return fullName(s.Name, s.Namespace, namespace)
+	case Enum:
+		return fullName(s.Name, s.Namespace, namespace)
+	case Fixed:
+		return fullName(s.Name, s.Namespace, namespace)
+	case Array:
+		return string(ArrayType)
+	case Map:
+		return string(MapType)
+	}
+	return ""
+}
+
+func validatePrimitive(p Primitive, datum interface{}, path string) error {
+	switch p.Type {
+	case NullType:
+		if datum != nil {
+			return invalidType(path, "null", datum)
+		}
+	case BooleanType:
+		if _, ok := datum.(bool); !ok {
+			return invalidType(path, "boolean", datum)
+		}
+	case IntType:
+		if p.LogicalType == DateLogicalType {
+			if _, ok := datum.(time.Time); ok {
+				return nil
+			}
+		}
+		i, ok := integer(datum)
+		if !ok {
+			return invalidType(path, "int", datum)
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("value %d overflows int", i)}
+		}
+	case LongType:
+		switch datum.(type) {
+		case time.Time:
+			if p.LogicalType == TimestampMillisLogicalType || p.LogicalType == TimestampMicrosLogicalType {
+				return nil
+			}
+		case time.Duration:
+			if p.LogicalType == TimeMicrosLogicalType {
+				return nil
+			}
+		}
+		if _, ok := integer(datum); !ok {
+			return invalidType(path, "long", datum)
+		}
+	case FloatType, DoubleType:
+		switch datum.(type) {
+		case float32, float64, int, int32, int64:
+		default:
+			return invalidType(path, string(p.Type), datum)
+		}
+	case BytesType:
+		switch datum.(type) {
+		case []byte, string, *big.Rat:
+		default:
+			return invalidType(path, "bytes", datum)
+		}
+	case StringType:
+		switch datum.(type) {
+		case string, []byte:
+		default:
+			return invalidType(path, "string", datum)
+		}
+	default:
+		return &ValidationError{Path: path, Message: fmt.Sprintf("unsupported primitive type %s", p.Type)}
+	}
+	return nil
+}
+
+// integer returns the value of an integer datum. Floating point values are
+// accepted if they have no fractional part.
+func integer(datum interface{}) (int64, bool) {
+	switch i := datum.(type) {
+	case int:
+		return int64(i), true
+	case int32:
+		return int64(i), true
+	case int64:
+		return i, true
+	case float32:
+		return int64(i), float32(int64(i)) == i
+	case float64:
+		return int64(i), float64(int64(i)) == i
+	}
+	return 0, false
+}
+
+func hasField(record Record, name string) bool {
+	for _, field := range record.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func invalidType(path, expected string, datum interface{}) error {
+	return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %T", expected, datum)}
+}
@@ -0,0 +1,192 @@
+package avro
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestValidate(t *testing.T) {
+	book := Record{
+		Type:      RecordType,
+		Name:      "Book",
+		Namespace: "google.example.library.v1",
+		Fields: []Field{
+			{Name: "name", Type: Nullable(String())},
+			{Name: "pages", Type: Nullable(Integer())},
+			{Name: "published", Type: Nullable(TimestampMicros())},
+			{
+				Name: "genre",
+				Type: Nullable(Enum{
+					Type:    EnumType,
+					Name:    "Genre",
+					Symbols: []string{"GENRE_UNSPECIFIED", "FANTASY"},
+				}),
+			},
+			{
+				Name: "authors",
+				Type: Array{
+					Type: ArrayType,
+					Items: Record{
+						Type:   RecordType,
+						Name:   "Author",
+						Fields: []Field{{Name: "name", Type: String()}},
+					},
+				},
+			},
+			{Name: "sequel", Type: Nullable(Reference("google.example.library.v1.Book"))},
+			{Name: "isbn", Type: Fixed{Type: "fixed", Name: "ISBN", Size: 4}},
+		},
+	}
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"name":      map[string]interface{}{"string": "Harry Potter"},
+			"pages":     nil,
+			"published": map[string]interface{}{"long.timestamp-micros": time.Unix(0, 0)},
+			"genre":     map[string]interface{}{"google.example.library.v1.Genre": "FANTASY"},
+			"authors": []interface{}{
+				map[string]interface{}{"name": "J. K. Rowling"},
+			},
+			"sequel": nil,
+			"isbn":   []byte{1, 2, 3, 4},
+		}
+	}
+	for _, tt := range []struct {
+		name        string
+		datum       func() interface{}
+		errContains string
+	}{
+		{
+			name:  "valid",
+			datum: func() interface{} { return valid() },
+		},
+		{
+			name: "valid reference",
+			datum: func() interface{} {
+				datum := valid()
+				datum["sequel"] = map[string]interface{}{"google.example.library.v1.Book": valid()}
+				return datum
+			},
+		},
+		{
+			name:        "not a record",
+			datum:       func() interface{} { return "book" },
+			errContains: "expected record, got string",
+		},
+		{
+			name: "missing field",
+			datum: func() interface{} {
+				datum := valid()
+				delete(datum, "name")
+				return datum
+			},
+			errContains: "name: missing field",
+		},
+		{
+			name: "unexpected field",
+			datum: func() interface{} {
+				datum := valid()
+				datum["title"] = nil
+				return datum
+			},
+			errContains: "title: unexpected field",
+		},
+		{
+			name: "unwrapped union",
+			datum: func() interface{} {
+				datum := valid()
+				datum["name"] = "Harry Potter"
+				return datum
+			},
+			errContains: "name: expected union value wrapped in a single-key map, got string",
+		},
+		{
+			name: "unknown union branch",
+			datum: func() interface{} {
+				datum := valid()
+				datum["name"] = map[string]interface{}{"bytes": []byte("Harry Potter")}
+				return datum
+			},
+			errContains: "name: unknown union branch bytes",
+		},
+		{
+			name: "int overflow",
+			datum: func() interface{} {
+				datum := valid()
+				datum["pages"] = map[string]interface{}{"int": int64(1) << 40}
+				return datum
+			},
+			errContains: "pages: value 1099511627776 overflows int",
+		},
+		{
+			name: "unknown enum symbol",
+			datum: func() interface{} {
+				datum := valid()
+				datum["genre"] = map[string]interface{}{"google.example.library.v1.Genre": "HORROR"}
+				return datum
+			},
+			errContains: `genre: unknown symbol "HORROR" for enum Genre`,
+		},
+		{
+			name: "invalid array item",
+			datum: func() interface{} {
+				datum := valid()
+				datum["authors"] = []interface{}{
+					map[string]interface{}{"name": "J. K. Rowling"},
+					map[string]interface{}{"name": 1},
+				}
+				return datum
+			},
+			errContains: "authors[1].name: expected string, got int",
+		},
+		{
+			name: "invalid nested reference",
+			datum: func() interface{} {
+				sequel := valid()
+				sequel["pages"] = map[string]interface{}{"int": "many"}
+				datum := valid()
+				datum["sequel"] = map[string]interface{}{"google.example.library.v1.Book": sequel}
+				return datum
+			},
+			errContains: "sequel.pages: expected int, got string",
+		},
+		{
+			name: "invalid fixed size",
+			datum: func() interface{} {
+				datum := valid()
+				datum["isbn"] = []byte{1}
+				return datum
+			},
+			errContains: "isbn: expected 4 bytes, got 1",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(book, tt.datum())
+			if tt.errContains == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.errContains)
+			var validationErr *ValidationError
+			assert.ErrorType(t, err, validationErr)
+		})
+	}
+}
+
+func TestValidate_NullableRecord(t *testing.T) {
+	schema := Nullable(Record{
+		Type:      RecordType,
+		Name:      "Book",
+		Namespace: "google.example.library.v1",
+		Fields:    []Field{{Name: "name", Type: Nullable(String())}},
+	})
+	assert.NilError(t, Validate(schema, nil))
+	assert.NilError(t, Validate(schema, map[string]interface{}{
+		"google.example.library.v1.Book": map[string]interface{}{"name": nil},
+	}))
+	assert.ErrorContains(t, Validate(schema, map[string]interface{}{
+		"Book": map[string]interface{}{"name": nil},
+	}), "unknown union branch Book")
+}
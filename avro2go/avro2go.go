@@ -0,0 +1,414 @@
+// Package avro2go generates Go struct types from Avro schemas, so that Avro data can be decoded with
+// github.com/hamba/avro into plain Go values, without protobuf dependencies.
+package avro2go
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.einride.tech/protobuf-avro/avro"
+)
+
+// Generate returns the source of a Go file of the package, declaring Go types for the named types of the Avro
+// schema, with the struct tags of github.com/hamba/avro. The root of the schema must be a record, or a nullable
+// record, as inferred by protoavro.SchemaOptions.InferSchema, and data of a nullable root record is decoded into a
+// pointer to its struct.
+//
+// Records are mapped to structs, with a field for each field of the record. Named types that are nested in a
+// record, by having its full name as namespace, are named by the Go type of the record, an underscore and their
+// name, as protoc-gen-go names nested messages and enums, so that the types match the layout of the protobuf
+// messages that the schema was inferred from. Enums are mapped to string types, with a constant for each symbol,
+// named as protoc-gen-go names enum values, and fixed types to byte arrays.
+//
+// Nullable types are mapped to pointers, except for arrays and bytes, which are nil when null. Arrays are mapped
+// to slices and maps to maps with string keys. Logical types are mapped to the types that hamba/avro decodes
+// them into: timestamp-millis, timestamp-micros and date to time.Time, time-micros to time.Duration, decimal to
+// *big.Rat and duration to avro.LogicalDuration. The logical type timestamp-nanos, which hamba/avro does not
+// support, is mapped to int64 nanoseconds.
+//
+// Unions of several non-null types are not supported.
+func Generate(pkg string, schema avro.Schema) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	root, ok := nonNull(schema)
+	if !ok {
+		return nil, fmt.Errorf("unsupported root schema: %s", describe(schema))
+	}
+	if _, ok := root.(avro.Record); !ok {
+		return nil, fmt.Errorf("unsupported root schema: %s", describe(schema))
+	}
+	g := generator{
+		named:   make(map[string]avro.Schema),
+		goNames: make(map[string]string),
+		taken:   make(map[string]string),
+		imports: make(map[string]struct{}),
+	}
+	if err := g.declare(root, ""); err != nil {
+		return nil, err
+	}
+	for _, name := range g.order {
+		if err := g.generateNamed(name); err != nil {
+			return nil, err
+		}
+	}
+	var file bytes.Buffer
+	file.WriteString("// Code generated by avro2go from an Avro schema. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&file, "package %s\n", pkg)
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		file.WriteString("\nimport (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&file, "\t%q\n", path)
+		}
+		file.WriteString(")\n")
+	}
+	file.Write(g.body.Bytes())
+	source, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format: %w", err)
+	}
+	return source, nil
+}
+
+type generator struct {
+	// named holds the named types of the schema by Avro full name.
+	named map[string]avro.Schema
+	// order holds the Avro full names of the named types, in order of declaration.
+	order []string
+	// goNames holds the Go names of the named types by Avro full name.
+	goNames map[string]string
+	// taken holds the Avro full names of the named types and enum symbols by Go name.
+	taken map[string]string
+	// imports holds the import paths of the generated types.
+	imports map[string]struct{}
+	body    bytes.Buffer
+}
+
+// declare declares Go names for all named types of the schema.
+func (g *generator) declare(schema avro.Schema, enclosing string) error {
+	switch s := schema.(type) {
+	case avro.Record:
+		name := fullName(s.Name, s.Namespace, enclosing)
+		if err := g.declareNamed(name, s); err != nil {
+			return err
+		}
+		for _, field := range s.Fields {
+			if err := g.declare(field.Type, namespaceOf(name)); err != nil {
+				return err
+			}
+		}
+	case avro.Enum:
+		return g.declareNamed(fullName(s.Name, s.Namespace, enclosing), s)
+	case avro.Fixed:
+		return g.declareNamed(fullName(s.Name, s.Namespace, enclosing), s)
+	case avro.Array:
+		return g.declare(s.Items, enclosing)
+	case avro.Map:
+		return g.declare(s.Values, enclosing)
+	case avro.Union:
+		for _, branch := range s {
+			if err := g.declare(branch, enclosing); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) declareNamed(name string, schema avro.Schema) error {
+	if _, ok := g.named[name]; ok {
+		return fmt.Errorf("named type %s is defined more than once", name)
+	}
+	g.named[name] = schema
+	g.order = append(g.order, name)
+	goName := camelCase(name[strings.LastIndex(name, ".")+1:])
+	if _, nested := g.named[namespaceOf(name)].(avro.Record); nested {
+		goName = g.goNames[namespaceOf(name)] + "_" + goName
+	}
+	if fixed, ok := schema.(avro.Fixed); ok && fixed.LogicalType != "" {
+		// fixed types of logical types are mapped to the Go types of the logical types
+		return nil
+	}
+	if err := g.take(goName, name); err != nil {
+		return err
+	}
+	g.goNames[name] = goName
+	return nil
+}
+
+// take reserves the Go name for the Avro name.
+func (g *generator) take(goName, name string) error {
+	if !token.IsExported(goName) {
+		return fmt.Errorf("%s: unsupported Go name %s", name, goName)
+	}
+	if other, ok := g.taken[goName]; ok {
+		return fmt.Errorf("%s and %s both map to Go name %s", other, name, goName)
+	}
+	g.taken[goName] = name
+	return nil
+}
+
+func (g *generator) generateNamed(name string) error {
+	switch s := g.named[name].(type) {
+	case avro.Record:
+		return g.generateRecord(name, s)
+	case avro.Enum:
+		return g.generateEnum(name, s)
+	case avro.Fixed:
+		if s.LogicalType == "" {
+			g.body.WriteString("\n")
+			fmt.Fprintf(&g.body, "type %s [%d]byte\n", g.goNames[name], s.Size)
+		}
+	}
+	return nil
+}
+
+func (g *generator) generateRecord(name string, record avro.Record) error {
+	g.body.WriteString("\n")
+	g.writeDoc("", record.Doc)
+	fmt.Fprintf(&g.body, "type %s struct {\n", g.goNames[name])
+	fieldNames := make(map[string]string, len(record.Fields))
+	for _, field := range record.Fields {
+		goName := camelCase(field.Name)
+		if !token.IsExported(goName) {
+			return fmt.Errorf("record %s: field %s: unsupported Go name %s", name, field.Name, goName)
+		}
+		if other, ok := fieldNames[goName]; ok {
+			return fmt.Errorf("record %s: fields %s and %s both map to Go name %s", name, other, field.Name, goName)
+		}
+		fieldNames[goName] = field.Name
+		goType, err := g.goType(field.Type, namespaceOf(name))
+		if err != nil {
+			return fmt.Errorf("record %s: field %s: %w", name, field.Name, err)
+		}
+		g.writeDoc("\t", field.Doc)
+		fmt.Fprintf(&g.body, "\t%s %s `avro:%s`\n", goName, goType, strconv.Quote(field.Name))
+	}
+	g.body.WriteString("}\n")
+	return nil
+}
+
+func (g *generator) generateEnum(name string, enum avro.Enum) error {
+	goName := g.goNames[name]
+	// values are prefixed by the type of the enclosing record, or by the type of the enum if it is not nested,
+	// as by protoc-gen-go.
+	prefix := goName
+	if _, nested := g.named[namespaceOf(name)].(avro.Record); nested {
+		prefix = g.goNames[namespaceOf(name)]
+	}
+	g.body.WriteString("\n")
+	g.writeDoc("", enum.Doc)
+	fmt.Fprintf(&g.body, "type %s string\n", goName)
+	if len(enum.Symbols) == 0 {
+		return nil
+	}
+	g.body.WriteString("\nconst (\n")
+	for _, symbol := range enum.Symbols {
+		constName := prefix + "_" + symbol
+		if err := g.take(constName, name+"."+symbol); err != nil {
+			return err
+		}
+		fmt.Fprintf(&g.body, "\t%s %s = %s\n", constName, goName, strconv.Quote(symbol))
+	}
+	g.body.WriteString(")\n")
+	return nil
+}
+
+func (g *generator) writeDoc(indent, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		fmt.Fprintf(&g.body, "%s// %s\n", indent, strings.TrimRight(line, " \t"))
+	}
+}
+
+// goType returns the Go type of values of the schema.
+func (g *generator) goType(schema avro.Schema, enclosing string) (string, error) {
+	if union, ok := schema.(avro.Union); ok {
+		t, ok := nonNull(union)
+		if !ok {
+			return "", fmt.Errorf("unsupported type %s", describe(schema))
+		}
+		goType, err := g.goType(t, enclosing)
+		if err != nil {
+			return "", err
+		}
+		if len(union) == 1 || strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "*") {
+			// hamba/avro decodes null into nil slices and pointers
+			return goType, nil
+		}
+		return "*" + goType, nil
+	}
+	switch s := schema.(type) {
+	case avro.Primitive:
+		return g.goPrimitive(s)
+	case avro.Array:
+		items, err := g.goType(s.Items, enclosing)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + items, nil
+	case avro.Map:
+		values, err := g.goType(s.Values, enclosing)
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + values, nil
+	case avro.Record:
+		return g.goNamed(fullName(s.Name, s.Namespace, enclosing))
+	case avro.Enum:
+		return g.goNamed(fullName(s.Name, s.Namespace, enclosing))
+	case avro.Fixed:
+		return g.goNamed(fullName(s.Name, s.Namespace, enclosing))
+	case avro.Reference:
+		name := fullName(string(s), "", enclosing)
+		if _, ok := g.named[name]; !ok {
+			name = string(s)
+		}
+		return g.goNamed(name)
+	}
+	return "", fmt.Errorf("unsupported type %s", describe(schema))
+}
+
+func (g *generator) goNamed(name string) (string, error) {
+	switch named := g.named[name].(type) {
+	case avro.Record, avro.Enum:
+		return g.goNames[name], nil
+	case avro.Fixed:
+		switch named.LogicalType {
+		case "":
+			return g.goNames[name], nil
+		case avro.DurationLogicalType:
+			g.imports["github.com/hamba/avro/v2"] = struct{}{}
+			return "avro.LogicalDuration", nil
+		case avro.DecimalLogicalType:
+			g.imports["math/big"] = struct{}{}
+			return "*big.Rat", nil
+		}
+		return "", fmt.Errorf("unsupported logical type %s of fixed %s", named.LogicalType, name)
+	}
+	return "", fmt.Errorf("unknown named type %s", name)
+}
+
+func (g *generator) goPrimitive(p avro.Primitive) (string, error) {
+	switch {
+	case p.Type == avro.LongType &&
+		(p.LogicalType == avro.TimestampMillisLogicalType || p.LogicalType == avro.TimestampMicrosLogicalType),
+		p.Type == avro.IntType && p.LogicalType == avro.DateLogicalType:
+		g.imports["time"] = struct{}{}
+		return "time.Time", nil
+	case p.Type == avro.LongType && p.LogicalType == avro.TimeMicrosLogicalType:
+		g.imports["time"] = struct{}{}
+		return "time.Duration", nil
+	case p.Type == avro.BytesType && p.LogicalType == avro.DecimalLogicalType:
+		g.imports["math/big"] = struct{}{}
+		return "*big.Rat", nil
+	}
+	switch p.Type {
+	case avro.BooleanType:
+		return "bool", nil
+	case avro.IntType:
+		return "int32", nil
+	case avro.LongType:
+		return "int64", nil
+	case avro.FloatType:
+		return "float32", nil
+	case avro.DoubleType:
+		return "float64", nil
+	case avro.BytesType:
+		return "[]byte", nil
+	case avro.StringType:
+		return "string", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", p.Type)
+}
+
+// nonNull returns the non-null type of a nullable schema. It returns false for unions
+// of several non-null types.
+func nonNull(schema avro.Schema) (avro.Schema, bool) {
+	union, ok := schema.(avro.Union)
+	if !ok {
+		return schema, true
+	}
+	var result avro.Schema
+	for _, branch := range union {
+		if branch == avro.Null() {
+			continue
+		}
+		if result != nil {
+			return nil, false
+		}
+		result = branch
+	}
+	return result, result != nil
+}
+
+func describe(schema avro.Schema) string {
+	switch s := schema.(type) {
+	case avro.Primitive:
+		return string(s.Type)
+	case avro.Record:
+		return string(avro.RecordType)
+	case avro.Enum:
+		return string(avro.EnumType)
+	case avro.Array:
+		return string(avro.ArrayType)
+	case avro.Map:
+		return string(avro.MapType)
+	case avro.Fixed:
+		return string(avro.FixedType)
+	case avro.Union:
+		return "union"
+	case avro.Reference:
+		return string(s)
+	}
+	return fmt.Sprintf("%T", schema)
+}
+
+func fullName(name, namespace, enclosing string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	if namespace == "" {
+		namespace = enclosing
+	}
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func namespaceOf(fullName string) string {
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		return fullName[:i]
+	}
+	return ""
+}
+
+func camelCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,297 @@
+package avro2go
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	hamba "github.com/hamba/avro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/avro2go/internal/examplev1go"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/hambaavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/timeofday"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gotest.tools/v3/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	schema, err := avro.Parse([]byte(`{
+  "type": "record",
+  "name": "Shipment",
+  "namespace": "einride.example.v1",
+  "doc": "A shipment.",
+  "fields": [
+    {"name": "id", "doc": "The ID of the shipment.", "type": "string"},
+    {"name": "weight", "type": ["null", "double"]},
+    {"name": "created", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+    {"name": "tags", "type": ["null", {"type": "array", "items": "string"}]},
+    {"name": "attributes", "type": ["null", {"type": "map", "values": "long"}]},
+    {
+      "name": "status",
+      "type": {
+        "type": "enum",
+        "name": "Status",
+        "namespace": "einride.example.v1.Shipment",
+        "symbols": ["STATUS_UNSPECIFIED", "DELIVERED"]
+      }
+    },
+    {
+      "name": "origin",
+      "type": {
+        "type": "record",
+        "name": "Location",
+        "fields": [{"name": "name", "type": "string"}]
+      }
+    },
+    {"name": "destination", "type": ["null", "Location"]},
+    {"name": "checksum", "type": {"type": "fixed", "name": "MD5", "size": 16}},
+    {"name": "price", "type": ["null", {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}]},
+    {"name": "grade", "type": {"type": "enum", "name": "Grade", "symbols": ["GRADE_A", "GRADE_B"]}}
+  ]
+}`))
+	assert.NilError(t, err)
+	source, err := Generate("shipments", avro.Nullable(schema))
+	assert.NilError(t, err)
+	assert.Equal(t, string(source), `// Code generated by avro2go from an Avro schema. DO NOT EDIT.
+
+package shipments
+
+import (
+	"math/big"
+	"time"
+)
+
+// A shipment.
+type Shipment struct {
+	// The ID of the shipment.
+	Id          string            `+"`avro:\"id\"`"+`
+	Weight      *float64          `+"`avro:\"weight\"`"+`
+	Created     time.Time         `+"`avro:\"created\"`"+`
+	Tags        []string          `+"`avro:\"tags\"`"+`
+	Attributes  *map[string]int64 `+"`avro:\"attributes\"`"+`
+	Status      Shipment_Status   `+"`avro:\"status\"`"+`
+	Origin      Location          `+"`avro:\"origin\"`"+`
+	Destination *Location         `+"`avro:\"destination\"`"+`
+	Checksum    MD5               `+"`avro:\"checksum\"`"+`
+	Price       *big.Rat          `+"`avro:\"price\"`"+`
+	Grade       Grade             `+"`avro:\"grade\"`"+`
+}
+
+type Shipment_Status string
+
+const (
+	Shipment_STATUS_UNSPECIFIED Shipment_Status = "STATUS_UNSPECIFIED"
+	Shipment_DELIVERED          Shipment_Status = "DELIVERED"
+)
+
+type Location struct {
+	Name string `+"`avro:\"name\"`"+`
+}
+
+type MD5 [16]byte
+
+type Grade string
+
+const (
+	Grade_GRADE_A Grade = "GRADE_A"
+	Grade_GRADE_B Grade = "GRADE_B"
+)
+`)
+}
+
+func TestGenerate_Examples(t *testing.T) {
+	opts := protoavro.SchemaOptions{WKTMappings: protoavro.WKTMappings{Duration: protoavro.WKTLogicalType}}
+	for _, tt := range []struct {
+		file string
+		msg  proto.Message
+		// decoded is a pointer to a nil pointer to the generated type of the message, and expected is the value
+		// that the message is decoded into.
+		decoded  interface{}
+		expected interface{}
+	}{
+		{
+			file: "example_scalars.go",
+			msg: &examplev1.ExampleScalars{
+				Double: 1.5, Float: 2.5, Int32: -1, Int64: -2, Uint32: 3, Uint64: 4, Sint32: -5, Sint64: -6,
+				Fixed32: 7, Fixed64: 8, Sfixed32: -9, Sfixed64: -10, Bool: true, String_: "a", Bytes: []byte{0xff},
+			},
+			decoded: new(*examplev1go.ExampleScalars),
+			expected: &examplev1go.ExampleScalars{
+				Double: ptr(1.5), Float: ptr(float32(2.5)), Int32: ptr(int32(-1)), Int64: ptr(int64(-2)),
+				Uint32: ptr(int64(3)), Uint64: ptr(int64(4)), Sint32: ptr(int32(-5)), Sint64: ptr(int64(-6)),
+				Fixed32: ptr(int32(7)), Fixed64: ptr(int64(8)), Sfixed32: ptr(int32(-9)), Sfixed64: ptr(int64(-10)),
+				Bool: ptr(true), String: ptr("a"), Bytes: []byte{0xff},
+			},
+		},
+		{
+			file:     "example_enum.go",
+			msg:      &examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE2},
+			decoded:  new(*examplev1go.ExampleEnum),
+			expected: &examplev1go.ExampleEnum{EnumValue: ptr(examplev1go.ExampleEnum_ENUM_VALUE2)},
+		},
+		{
+			file: "example_list.go",
+			msg: &examplev1.ExampleList{
+				Int64List:  []int64{1, 2},
+				EnumList:   []examplev1.ExampleList_Enum{examplev1.ExampleList_ENUM_VALUE1},
+				NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"a"}}},
+			},
+			decoded: new(*examplev1go.ExampleList),
+			expected: &examplev1go.ExampleList{
+				Int64List:  []*int64{ptr(int64(1)), ptr(int64(2))},
+				EnumList:   []*examplev1go.ExampleList_Enum{ptr(examplev1go.ExampleList_ENUM_VALUE1)},
+				NestedList: []*examplev1go.ExampleList_Nested{{StringList: []*string{ptr("a")}}},
+			},
+		},
+		{
+			file: "example_map.go",
+			msg: &examplev1.ExampleMap{
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{"a": {}},
+				StringToEnum:   map[string]examplev1.ExampleMap_Enum{"b": examplev1.ExampleMap_ENUM_VALUE1},
+			},
+			decoded: new(*examplev1go.ExampleMap),
+			expected: &examplev1go.ExampleMap{
+				StringToNested: []examplev1go.ExampleMap_StringToNestedEntry{
+					{
+						Key:   ptr("a"),
+						Value: &examplev1go.ExampleMap_Nested{},
+					},
+				},
+				StringToEnum: []examplev1go.ExampleMap_StringToEnumEntry{
+					{Key: ptr("b"), Value: ptr(examplev1go.ExampleMap_ENUM_VALUE1)},
+				},
+			},
+		},
+		{
+			file: "example_oneof.go",
+			msg: &examplev1.ExampleOneof{
+				OneofFields_1: &examplev1.ExampleOneof_OneofBool_1{OneofBool_1: true},
+				OneofFields_2: &examplev1.ExampleOneof_OneofMessage{
+					OneofMessage: &examplev1.ExampleOneof_Message{StringValue: "a"},
+				},
+			},
+			decoded: new(*examplev1go.ExampleOneof),
+			expected: &examplev1go.ExampleOneof{
+				OneofBool1:   ptr(true),
+				OneofMessage: &examplev1go.ExampleOneof_Message{StringValue: ptr("a")},
+			},
+		},
+		{
+			file:     "example_recursive.go",
+			msg:      &examplev1.ExampleRecursive{Recursive: &examplev1.ExampleRecursive{}},
+			decoded:  new(*examplev1go.ExampleRecursive),
+			expected: &examplev1go.ExampleRecursive{Recursive: &examplev1go.ExampleRecursive{}},
+		},
+		{
+			file: "example_timestamp.go",
+			msg: &examplev1.ExampleTimestamp{
+				Timestamp: timestamppb.New(time.Date(2021, 6, 27, 1, 39, 24, 123000, time.UTC)),
+			},
+			decoded: new(*examplev1go.ExampleTimestamp),
+			expected: &examplev1go.ExampleTimestamp{
+				Timestamp: ptr(time.Date(2021, 6, 27, 1, 39, 24, 123000, time.UTC)),
+			},
+		},
+		{
+			file:     "example_date.go",
+			msg:      &examplev1.ExampleDate{Date: &date.Date{Year: 2021, Month: 6, Day: 27}},
+			decoded:  new(*examplev1go.ExampleDate),
+			expected: &examplev1go.ExampleDate{Date: ptr(time.Date(2021, 6, 27, 0, 0, 0, 0, time.UTC))},
+		},
+		{
+			file:     "example_timeofday.go",
+			msg:      &examplev1.ExampleTimeOfDay{TimeOfDay: &timeofday.TimeOfDay{Hours: 19, Minutes: 42}},
+			decoded:  new(*examplev1go.ExampleTimeOfDay),
+			expected: &examplev1go.ExampleTimeOfDay{TimeOfDay: ptr(19*time.Hour + 42*time.Minute)},
+		},
+		{
+			file:     "example_duration.go",
+			msg:      &examplev1.ExampleDuration{Duration: durationpb.New(1500 * time.Millisecond)},
+			decoded:  new(*examplev1go.ExampleDuration),
+			expected: &examplev1go.ExampleDuration{Duration: &hamba.LogicalDuration{Milliseconds: 1500}},
+		},
+	} {
+		tt := tt
+		t.Run(tt.file, func(t *testing.T) {
+			desc := tt.msg.ProtoReflect().Descriptor()
+			schema, err := opts.InferSchema(desc)
+			assert.NilError(t, err)
+			source, err := Generate("examplev1go", schema)
+			assert.NilError(t, err)
+			generated, err := os.ReadFile(filepath.Join("internal", "examplev1go", tt.file))
+			assert.NilError(t, err)
+			assert.Equal(t, string(generated), string(source), "generated code of %s is out of date", tt.file)
+
+			// data marshaled by protoavro is decoded into the generated types
+			codec, err := hambaavro.NewCodec(opts, desc)
+			assert.NilError(t, err)
+			data, err := codec.Marshal(tt.msg)
+			assert.NilError(t, err)
+			assert.NilError(t, hamba.Unmarshal(codec.Schema(), data, tt.decoded))
+			assert.DeepEqual(t, tt.expected, reflect.ValueOf(tt.decoded).Elem().Interface())
+		})
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		schema   string
+		expected string
+	}{
+		{
+			name:     "root",
+			schema:   `{"type": "array", "items": "string"}`,
+			expected: "unsupported root schema: array",
+		},
+		{
+			name:     "union",
+			schema:   `{"type": "record", "name": "R", "fields": [{"name": "f", "type": ["null", "int", "string"]}]}`,
+			expected: "record R: field f: unsupported type union",
+		},
+		{
+			name: "field names",
+			schema: `{"type": "record", "name": "R", "fields": [
+				{"name": "foo_bar", "type": "int"},
+				{"name": "fooBar", "type": "int"}
+			]}`,
+			expected: "record R: fields foo_bar and fooBar both map to Go name FooBar",
+		},
+		{
+			name: "type names",
+			schema: `{"type": "record", "name": "a.R", "fields": [
+				{"name": "f", "type": {"type": "record", "name": "b.R", "fields": []}}
+			]}`,
+			expected: "a.R and b.R both map to Go name R",
+		},
+		{
+			name: "enum values",
+			schema: `{"type": "record", "name": "R", "fields": [
+				{"name": "f", "type": {"type": "enum", "name": "R.E", "symbols": ["A"]}},
+				{"name": "g", "type": {"type": "enum", "name": "R.F", "symbols": ["A"]}}
+			]}`,
+			expected: "R.E.A and R.F.A both map to Go name R_A",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := avro.Parse([]byte(tt.schema))
+			assert.NilError(t, err)
+			_, err = Generate("p", schema)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+	_, err := Generate("not a package", avro.Record{Type: avro.RecordType, Name: "R"})
+	assert.ErrorContains(t, err, `invalid package name "not a package"`)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
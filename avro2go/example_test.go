@@ -0,0 +1,28 @@
+package avro2go_test
+
+import (
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/avro2go"
+)
+
+func ExampleGenerate() {
+	schema, err := avro.Parse([]byte(`{"type": "record", "name": "Book", "fields": [{"name": "title", "type": "string"}]}`))
+	if err != nil {
+		panic(err)
+	}
+	source, err := avro2go.Generate("books", schema)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(source))
+	// Output:
+	// // Code generated by avro2go from an Avro schema. DO NOT EDIT.
+	//
+	// package books
+	//
+	// type Book struct {
+	// 	Title string `avro:"title"`
+	// }
+}
@@ -0,0 +1,526 @@
+// Package avro2proto synthesizes protobuf descriptors from Avro schemas,
+// so that Avro data can be consumed through protobuf tooling.
+package avro2proto
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// register descriptors of types that logical types are mapped to.
+	_ "google.golang.org/genproto/googleapis/type/date"
+	_ "google.golang.org/genproto/googleapis/type/timeofday"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MessageDescriptor returns the message descriptor of the root record of the Avro schema.
+// See FileDescriptorProto for how the schema is mapped.
+func MessageDescriptor(schema avro.Schema) (protoreflect.MessageDescriptor, error) {
+	fileDescriptor, err := FileDescriptorProto(schema)
+	if err != nil {
+		return nil, err
+	}
+	file, err := protodesc.NewFile(fileDescriptor, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("new file: %w", err)
+	}
+	return file.Messages().Get(0), nil
+}
+
+// FileDescriptorProto returns a proto3 file descriptor synthesized from the Avro schema.
+// The root of the schema must be a record, or a nullable record, and the package of the file is
+// the namespace of the root record. The root record is the first message of the file.
+//
+// Records are mapped to messages, and enums to enums. Named types with the namespace of a record are nested
+// in the message of that record. Nullable types are mapped to their non-null type, arrays to repeated fields,
+// and maps to map fields with string keys. Fixed types are mapped to bytes.
+// The names of enum values are the symbols prefixed with the conventional prefix of the enum, for example
+// STATUS_OPEN for the symbol OPEN of the enum Status, unless all symbols already have the prefix, so that
+// enums in the same scope can share symbols. Symbols without the prefix are decoded by TranscodeToProtoJSON,
+// and by encoding/protoavro.
+// Symbol aliases of enums, see the custom property "symbolAliases", are mapped to aliased enum values.
+// Logical types are mapped to the well-known types that encoding/protoavro maps to the logical types:
+// timestamp-millis, timestamp-micros and timestamp-nanos to google.protobuf.Timestamp, duration to
+// google.protobuf.Duration, date to google.type.Date, and time-micros to google.type.TimeOfDay.
+// Other logical types are mapped to their underlying type.
+//
+// Fields annotated with the custom property "protoKind", see protoavro.SchemaOptions.AnnotateProtoKinds,
+// are mapped to the annotated protobuf kind. Fields annotated with the custom property "protoFieldNumber",
+// see protoavro.SchemaOptions.AnnotateFieldNumbers, are numbered by the annotated field number. Other fields are
+// numbered in order, skipping annotated field numbers.
+//
+// Unions of several non-null types, and arrays or maps of arrays or maps, are not supported.
+func FileDescriptorProto(schema avro.Schema) (*descriptorpb.FileDescriptorProto, error) {
+	root, ok := nonNull(schema)
+	if !ok {
+		return nil, fmt.Errorf("unsupported root schema: %s", describe(schema))
+	}
+	rootRecord, ok := root.(avro.Record)
+	if !ok {
+		return nil, fmt.Errorf("unsupported root schema: %s", describe(schema))
+	}
+	rootName := fullName(rootRecord.Name, rootRecord.Namespace, "")
+	pkg := namespaceOf(rootName)
+	c := converter{
+		pkg:        pkg,
+		named:      make(map[string]avro.Schema),
+		protoNames: make(map[string]string),
+		messages:   make(map[string]*descriptorpb.DescriptorProto),
+		taken:      make(map[string]string),
+		file: &descriptorpb.FileDescriptorProto{
+			Name:    proto.String(strings.ReplaceAll(rootName, ".", "/") + ".proto"),
+			Package: proto.String(pkg),
+			Syntax:  proto.String("proto3"),
+		},
+		deps: make(map[string]struct{}),
+	}
+	if err := c.declare(root, ""); err != nil {
+		return nil, err
+	}
+	for _, name := range c.order {
+		record, ok := c.named[name].(avro.Record)
+		if !ok {
+			continue
+		}
+		if err := c.convertRecord(name, record); err != nil {
+			return nil, err
+		}
+	}
+	return c.file, nil
+}
+
+type converter struct {
+	pkg string
+	// named holds the named types of the schema by Avro full name.
+	named map[string]avro.Schema
+	// order holds the Avro full names of the named types, in order of declaration.
+	order []string
+	// protoNames holds the proto full names of the named types by Avro full name.
+	protoNames map[string]string
+	// messages holds the message descriptors of records by Avro full name.
+	messages map[string]*descriptorpb.DescriptorProto
+	// taken holds the Avro full names of the named types by proto full name.
+	taken map[string]string
+	file  *descriptorpb.FileDescriptorProto
+	deps  map[string]struct{}
+}
+
+// declare declares descriptors for all named types of the schema.
+func (c *converter) declare(schema avro.Schema, enclosing string) error {
+	switch s := schema.(type) {
+	case avro.Record:
+		name := fullName(s.Name, s.Namespace, enclosing)
+		if err := c.declareNamed(name, s); err != nil {
+			return err
+		}
+		for _, field := range s.Fields {
+			if err := c.declare(field.Type, namespaceOf(name)); err != nil {
+				return err
+			}
+		}
+	case avro.Enum:
+		return c.declareNamed(fullName(s.Name, s.Namespace, enclosing), s)
+	case avro.Fixed:
+		return c.declareNamed(fullName(s.Name, s.Namespace, enclosing), s)
+	case avro.Array:
+		return c.declare(s.Items, enclosing)
+	case avro.Map:
+		return c.declare(s.Values, enclosing)
+	case avro.Union:
+		for _, branch := range s {
+			if err := c.declare(branch, enclosing); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *converter) declareNamed(name string, schema avro.Schema) error {
+	if _, ok := c.named[name]; ok {
+		return fmt.Errorf("named type %s is defined more than once", name)
+	}
+	c.named[name] = schema
+	c.order = append(c.order, name)
+	simpleName := name[strings.LastIndex(name, ".")+1:]
+	parent, nested := c.messages[namespaceOf(name)]
+	protoName := simpleName
+	switch {
+	case nested:
+		protoName = c.protoNames[namespaceOf(name)] + "." + simpleName
+	case c.pkg != "":
+		protoName = c.pkg + "." + simpleName
+	}
+	if other, ok := c.taken[protoName]; ok {
+		return fmt.Errorf("named types %s and %s both map to %s", other, name, protoName)
+	}
+	c.taken[protoName] = name
+	c.protoNames[name] = protoName
+	switch s := schema.(type) {
+	case avro.Record:
+		message := &descriptorpb.DescriptorProto{Name: proto.String(simpleName)}
+		c.messages[name] = message
+		if nested {
+			parent.NestedType = append(parent.NestedType, message)
+		} else {
+			c.file.MessageType = append(c.file.MessageType, message)
+		}
+	case avro.Enum:
+		enum := &descriptorpb.EnumDescriptorProto{Name: proto.String(simpleName)}
+		prefix := enumValuePrefix(s)
+		numbers := make(map[string]int32, len(s.Symbols))
+		for i, symbol := range s.Symbols {
+			numbers[symbol] = int32(i)
+			enum.Value = append(enum.Value, &descriptorpb.EnumValueDescriptorProto{
+				Name:   proto.String(prefix + symbol),
+				Number: proto.Int32(int32(i)),
+			})
+		}
+		aliases := make([]string, 0, len(s.SymbolAliases))
+		for alias := range s.SymbolAliases {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		for _, alias := range aliases {
+			number, ok := numbers[s.SymbolAliases[alias]]
+			if !ok {
+				return fmt.Errorf("enum %s: alias %s of unknown symbol %s", name, alias, s.SymbolAliases[alias])
+			}
+			enum.Value = append(enum.Value, &descriptorpb.EnumValueDescriptorProto{
+				Name:   proto.String(prefix + alias),
+				Number: proto.Int32(number),
+			})
+			enum.Options = &descriptorpb.EnumOptions{AllowAlias: proto.Bool(true)}
+		}
+		if nested {
+			parent.EnumType = append(parent.EnumType, enum)
+		} else {
+			c.file.EnumType = append(c.file.EnumType, enum)
+		}
+	}
+	return nil
+}
+
+func (c *converter) convertRecord(name string, record avro.Record) error {
+	message := c.messages[name]
+	annotated := make(map[int32]struct{}, len(record.Fields))
+	for _, field := range record.Fields {
+		if number := protoFieldNumber(field); number != 0 {
+			annotated[number] = struct{}{}
+		}
+	}
+	var next int32
+	for _, field := range record.Fields {
+		number := protoFieldNumber(field)
+		if number == 0 {
+			next++
+			for _, ok := annotated[next]; ok; _, ok = annotated[next] {
+				next++
+			}
+			number = next
+		}
+		fieldDescriptor := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(field.Name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if err := c.convertField(message, name, fieldDescriptor, field.Type); err != nil {
+			return fmt.Errorf("record %s: field %s: %w", name, field.Name, err)
+		}
+		if err := convertProtoKind(fieldDescriptor, protoKind(field)); err != nil {
+			return fmt.Errorf("record %s: field %s: %w", name, field.Name, err)
+		}
+		message.Field = append(message.Field, fieldDescriptor)
+	}
+	return nil
+}
+
+func (c *converter) convertField(
+	message *descriptorpb.DescriptorProto,
+	recordName string,
+	field *descriptorpb.FieldDescriptorProto,
+	schema avro.Schema,
+) error {
+	enclosing := namespaceOf(recordName)
+	t, ok := nonNull(schema)
+	if !ok {
+		return fmt.Errorf("unsupported type %s", describe(schema))
+	}
+	switch s := t.(type) {
+	case avro.Array:
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return c.convertType(field, s.Items, enclosing)
+	case avro.Map:
+		entry := &descriptorpb.DescriptorProto{
+			Name:    proto.String(camelCase(field.GetName()) + "Entry"),
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:   proto.String("key"),
+					Number: proto.Int32(1),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				},
+				{
+					Name:   proto.String("value"),
+					Number: proto.Int32(2),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				},
+			},
+		}
+		if err := c.convertType(entry.Field[1], s.Values, enclosing); err != nil {
+			return err
+		}
+		message.NestedType = append(message.NestedType, entry)
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		field.TypeName = proto.String("." + c.protoNames[recordName] + "." + entry.GetName())
+		return nil
+	}
+	return c.convertType(field, t, enclosing)
+}
+
+// convertType sets the type of field to the type of a singular value of schema.
+func (c *converter) convertType(field *descriptorpb.FieldDescriptorProto, schema avro.Schema, enclosing string) error {
+	t, ok := nonNull(schema)
+	if !ok {
+		return fmt.Errorf("unsupported type %s", describe(schema))
+	}
+	switch s := t.(type) {
+	case avro.Primitive:
+		return c.convertPrimitive(field, s)
+	case avro.Record:
+		return c.convertNamed(field, fullName(s.Name, s.Namespace, enclosing))
+	case avro.Enum:
+		return c.convertNamed(field, fullName(s.Name, s.Namespace, enclosing))
+	case avro.Fixed:
+		return c.convertNamed(field, fullName(s.Name, s.Namespace, enclosing))
+	case avro.Reference:
+		name := fullName(string(s), "", enclosing)
+		if _, ok := c.named[name]; !ok {
+			name = string(s)
+		}
+		return c.convertNamed(field, name)
+	}
+	return fmt.Errorf("unsupported type %s", describe(t))
+}
+
+func (c *converter) convertNamed(field *descriptorpb.FieldDescriptorProto, name string) error {
+	switch named := c.named[name].(type) {
+	case avro.Record:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	case avro.Enum:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
+	case avro.Fixed:
+		if named.LogicalType == avro.DurationLogicalType {
+			c.convertWellKnown(field, "google.protobuf.Duration", "google/protobuf/duration.proto")
+			return nil
+		}
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+		return nil
+	default:
+		return fmt.Errorf("unknown named type %s", name)
+	}
+	field.TypeName = proto.String("." + c.protoNames[name])
+	return nil
+}
+
+func (c *converter) convertPrimitive(field *descriptorpb.FieldDescriptorProto, p avro.Primitive) error {
+	switch p.LogicalType {
+	case avro.TimestampMillisLogicalType, avro.TimestampMicrosLogicalType, avro.TimestampNanosLogicalType:
+		c.convertWellKnown(field, "google.protobuf.Timestamp", "google/protobuf/timestamp.proto")
+		return nil
+	case avro.DateLogicalType:
+		c.convertWellKnown(field, "google.type.Date", "google/type/date.proto")
+		return nil
+	case avro.TimeMicrosLogicalType:
+		c.convertWellKnown(field, "google.type.TimeOfDay", "google/type/timeofday.proto")
+		return nil
+	}
+	switch p.Type {
+	case avro.BooleanType:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum()
+	case avro.IntType:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum()
+	case avro.LongType:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+	case avro.FloatType:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_FLOAT.Enum()
+	case avro.DoubleType:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE.Enum()
+	case avro.BytesType:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+	case avro.StringType:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	default:
+		return fmt.Errorf("unsupported type %s", p.Type)
+	}
+	return nil
+}
+
+// protoKind returns the protobuf kind that a field is annotated with, or an empty string.
+func protoKind(field avro.Field) string {
+	kind, _ := field.Properties[protoavro.ProtoKindProperty].(string)
+	return kind
+}
+
+// protoFieldNumber returns the protobuf field number that a field is annotated with, or 0.
+func protoFieldNumber(field avro.Field) int32 {
+	number, _ := field.Properties[protoavro.ProtoFieldNumberProperty].(float64)
+	return int32(number)
+}
+
+// convertProtoKind sets the type of a scalar field to the scalar protobuf kind it is annotated with.
+func convertProtoKind(field *descriptorpb.FieldDescriptorProto, protoKind string) error {
+	if protoKind == "" || field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE ||
+		field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+		return nil
+	}
+	kind, ok := descriptorpb.FieldDescriptorProto_Type_value["TYPE_"+strings.ToUpper(protoKind)]
+	if !ok {
+		return fmt.Errorf("unknown protoKind %s", protoKind)
+	}
+	switch t := descriptorpb.FieldDescriptorProto_Type(kind); t {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+		descriptorpb.FieldDescriptorProto_TYPE_ENUM,
+		descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		// messages mapped to scalars, such as wrappers, are kept as scalars
+	default:
+		field.Type = t.Enum()
+	}
+	return nil
+}
+
+func (c *converter) convertWellKnown(field *descriptorpb.FieldDescriptorProto, name, path string) {
+	field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	field.TypeName = proto.String("." + name)
+	if _, ok := c.deps[path]; !ok {
+		c.deps[path] = struct{}{}
+		c.file.Dependency = append(c.file.Dependency, path)
+	}
+}
+
+// enumValuePrefix returns the prefix of the names of the enum values of an Avro enum. Enum values are scoped
+// to the enclosing message or package in protobuf, so the symbols are prefixed with the conventional prefix
+// of the enum, for example "VEHICLE_STATE_" for the enum VehicleState, unless all of them already have it.
+func enumValuePrefix(enum avro.Enum) string {
+	name := enum.Name[strings.LastIndex(enum.Name, ".")+1:]
+	prefix := screamingSnakeCase(name) + "_"
+	for _, symbol := range enum.Symbols {
+		if !strings.HasPrefix(symbol, prefix) {
+			return prefix
+		}
+	}
+	for alias := range enum.SymbolAliases {
+		if !strings.HasPrefix(alias, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// nonNull returns the non-null type of a nullable schema. It returns false for unions
+// of several non-null types.
+func nonNull(schema avro.Schema) (avro.Schema, bool) {
+	union, ok := schema.(avro.Union)
+	if !ok {
+		return schema, true
+	}
+	var result avro.Schema
+	for _, branch := range union {
+		if branch == avro.Null() {
+			continue
+		}
+		if result != nil {
+			return nil, false
+		}
+		result = branch
+	}
+	return result, result != nil
+}
+
+func describe(schema avro.Schema) string {
+	switch s := schema.(type) {
+	case avro.Primitive:
+		return string(s.Type)
+	case avro.Record:
+		return string(avro.RecordType)
+	case avro.Enum:
+		return string(avro.EnumType)
+	case avro.Array:
+		return string(avro.ArrayType)
+	case avro.Map:
+		return string(avro.MapType)
+	case avro.Fixed:
+		return string(avro.FixedType)
+	case avro.Union:
+		return "union"
+	case avro.Reference:
+		return string(s)
+	}
+	return fmt.Sprintf("%T", schema)
+}
+
+func fullName(name, namespace, enclosing string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	if namespace == "" {
+		namespace = enclosing
+	}
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func namespaceOf(fullName string) string {
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		return fullName[:i]
+	}
+	return ""
+}
+
+// screamingSnakeCase converts a CamelCase name to SCREAMING_SNAKE_CASE.
+func screamingSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func camelCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
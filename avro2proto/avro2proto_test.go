@@ -0,0 +1,230 @@
+package avro2proto
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestMessageDescriptor(t *testing.T) {
+	schema, err := avro.Parse([]byte(`{
+  "type": "record",
+  "name": "Shipment",
+  "namespace": "einride.example.v1",
+  "fields": [
+    {"name": "id", "type": "string"},
+    {"name": "weight", "type": ["null", "double"]},
+    {"name": "created", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+    {"name": "tags", "type": {"type": "array", "items": "string"}},
+    {"name": "attributes", "type": {"type": "map", "values": "long"}},
+    {
+      "name": "status",
+      "type": {
+        "type": "enum",
+        "name": "Status",
+        "namespace": "einride.example.v1.Shipment",
+        "symbols": ["STATUS_UNSPECIFIED", "DELIVERED"]
+      }
+    },
+    {
+      "name": "origin",
+      "type": {
+        "type": "record",
+        "name": "Location",
+        "fields": [{"name": "name", "type": "string"}]
+      }
+    },
+    {"name": "destination", "type": ["null", "Location"]},
+    {"name": "checksum", "type": {"type": "fixed", "name": "MD5", "size": 16}},
+    {"name": "transit", "type": {"type": "fixed", "name": "Duration", "size": 12, "logicalType": "duration"}}
+  ]
+}`))
+	assert.NilError(t, err)
+	desc, err := MessageDescriptor(schema)
+	assert.NilError(t, err)
+	assert.Equal(t, desc.FullName(), protoreflect.FullName("einride.example.v1.Shipment"))
+
+	fields := desc.Fields()
+	assert.Equal(t, fields.Len(), 10)
+	assert.Equal(t, fields.ByName("id").Kind(), protoreflect.StringKind)
+	assert.Equal(t, fields.ByName("weight").Kind(), protoreflect.DoubleKind)
+	assert.Equal(t, fields.ByName("created").Message().FullName(), protoreflect.FullName("google.protobuf.Timestamp"))
+	assert.Assert(t, fields.ByName("tags").IsList())
+	assert.Assert(t, fields.ByName("attributes").IsMap())
+	assert.Equal(t, fields.ByName("attributes").MapValue().Kind(), protoreflect.Int64Kind)
+	assert.Equal(
+		t,
+		fields.ByName("status").Enum().FullName(),
+		protoreflect.FullName("einride.example.v1.Shipment.Status"),
+	)
+	assert.Equal(
+		t,
+		fields.ByName("origin").Message().FullName(),
+		protoreflect.FullName("einride.example.v1.Location"),
+	)
+	assert.Equal(t, fields.ByName("destination").Message(), fields.ByName("origin").Message())
+	assert.Equal(t, fields.ByName("checksum").Kind(), protoreflect.BytesKind)
+	assert.Equal(t, fields.ByName("transit").Message().FullName(), protoreflect.FullName("google.protobuf.Duration"))
+}
+
+func TestMessageDescriptor_RoundTrip(t *testing.T) {
+	for _, tt := range []proto.Message{
+		&library.Book{
+			Name:   "shelves/1/books/1",
+			Title:  "Harry Potter",
+			Author: "J. K. Rowling",
+			Read:   true,
+		},
+		&examplev1.ExampleList{
+			Int64List:  []int64{1, 2},
+			EnumList:   []examplev1.ExampleList_Enum{examplev1.ExampleList_ENUM_VALUE2},
+			NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"a"}}},
+		},
+	} {
+		tt := tt
+		t.Run(string(tt.ProtoReflect().Descriptor().FullName()), func(t *testing.T) {
+			schema, err := protoavro.InferSchema(tt.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+			desc, err := MessageDescriptor(schema)
+			assert.NilError(t, err)
+			assert.Equal(t, desc.FullName(), tt.ProtoReflect().Descriptor().FullName())
+
+			// write the original message, and read it as a dynamic message of the synthesized descriptor
+			var b bytes.Buffer
+			marshaler, err := protoavro.NewMarshaler(tt.ProtoReflect().Descriptor(), &b)
+			assert.NilError(t, err)
+			assert.NilError(t, marshaler.Marshal(tt))
+			unmarshaler, err := protoavro.NewUnmarshaler(&b)
+			assert.NilError(t, err)
+			assert.Assert(t, unmarshaler.Scan())
+			dynamic := dynamicpb.NewMessage(desc)
+			assert.NilError(t, unmarshaler.Unmarshal(dynamic))
+
+			data, err := protojson.Marshal(dynamic)
+			assert.NilError(t, err)
+			got := tt.ProtoReflect().New().Interface()
+			assert.NilError(t, protojson.Unmarshal(data, got))
+			assert.DeepEqual(t, tt, got, protocmp.Transform())
+		})
+	}
+}
+
+func TestMessageDescriptor_Error(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		schema      string
+		errContains string
+	}{
+		{
+			name:        "primitive root",
+			schema:      `"string"`,
+			errContains: "unsupported root schema: string",
+		},
+		{
+			name: "union",
+			schema: `{"type": "record", "name": "Book", "fields": [
+				{"name": "id", "type": ["null", "string", "long"]}
+			]}`,
+			errContains: "record Book: field id: unsupported type union",
+		},
+		{
+			name: "nested array",
+			schema: `{"type": "record", "name": "Book", "fields": [
+				{"name": "pages", "type": {"type": "array", "items": {"type": "array", "items": "string"}}}
+			]}`,
+			errContains: "record Book: field pages: unsupported type array",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := avro.Parse([]byte(tt.schema))
+			assert.NilError(t, err)
+			_, err = MessageDescriptor(schema)
+			assert.ErrorContains(t, err, tt.errContains)
+		})
+	}
+}
+
+func TestMessageDescriptor_ProtoKinds(t *testing.T) {
+	original := (&examplev1.ExampleScalars{}).ProtoReflect().Descriptor()
+	schema, err := protoavro.SchemaOptions{AnnotateProtoKinds: true}.InferSchema(original)
+	assert.NilError(t, err)
+	data, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	parsed, err := avro.Parse(data)
+	assert.NilError(t, err)
+	desc, err := MessageDescriptor(parsed)
+	assert.NilError(t, err)
+	assert.Equal(t, desc.Fields().Len(), original.Fields().Len())
+	for i := 0; i < original.Fields().Len(); i++ {
+		assert.Equal(t, desc.Fields().Get(i).Kind(), original.Fields().Get(i).Kind())
+	}
+}
+
+func TestMessageDescriptor_FieldNumbers(t *testing.T) {
+	schema, err := avro.Parse([]byte(`{"type": "record", "name": "Book", "fields": [
+		{"name": "title", "type": "string", "protoFieldNumber": 3},
+		{"name": "author", "type": "string", "protoFieldNumber": 7},
+		{"name": "read", "type": "boolean"}
+	]}`))
+	assert.NilError(t, err)
+	desc, err := MessageDescriptor(schema)
+	assert.NilError(t, err)
+	assert.Equal(t, desc.Fields().ByName("title").Number(), protoreflect.FieldNumber(3))
+	assert.Equal(t, desc.Fields().ByName("author").Number(), protoreflect.FieldNumber(7))
+	assert.Equal(t, desc.Fields().ByName("read").Number(), protoreflect.FieldNumber(1))
+}
+
+func TestMessageDescriptor_EnumAlias(t *testing.T) {
+	schema, err := protoavro.InferSchema((&examplev1.ExampleEnumAlias{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	desc, err := MessageDescriptor(schema)
+	assert.NilError(t, err)
+	enum := desc.Fields().ByName("enum_value").Enum()
+	assert.Equal(t, enum.Values().Len(), 4)
+	assert.Equal(t, enum.Values().ByName("ENUM_RUNNING").Number(), enum.Values().ByName("ENUM_STARTED").Number())
+}
+
+func TestMessageDescriptor_EnumsSharingSymbols(t *testing.T) {
+	schema, err := avro.Parse([]byte(`{
+		"type": "record",
+		"name": "Order",
+		"namespace": "example.v1",
+		"fields": [
+			{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["UNKNOWN", "OPEN"]}},
+			{"name": "priority", "type": {"type": "enum", "name": "Priority", "symbols": ["UNKNOWN", "HIGH"]}}
+		]
+	}`))
+	assert.NilError(t, err)
+	desc, err := MessageDescriptor(schema)
+	assert.NilError(t, err)
+	status := desc.Fields().ByName("status").Enum()
+	assert.Equal(t, status.Values().ByName("STATUS_UNKNOWN").Number(), protoreflect.EnumNumber(0))
+	assert.Equal(t, status.Values().ByName("STATUS_OPEN").Number(), protoreflect.EnumNumber(1))
+	priority := desc.Fields().ByName("priority").Enum()
+	assert.Equal(t, priority.Values().ByName("PRIORITY_UNKNOWN").Number(), protoreflect.EnumNumber(0))
+	assert.Equal(t, priority.Values().ByName("PRIORITY_HIGH").Number(), protoreflect.EnumNumber(1))
+
+	got, err := TranscodeToProtoJSON(schema, []byte(`{"status": "OPEN", "priority": "HIGH"}`))
+	assert.NilError(t, err)
+	assertJSONEqual(t, `{"status": "STATUS_OPEN", "priority": "PRIORITY_HIGH"}`, got)
+
+	message := dynamicpb.NewMessage(desc)
+	assert.NilError(t, protoavro.SchemaOptions{}.Decode(
+		map[string]interface{}{"status": "OPEN", "priority": "HIGH"},
+		message,
+	))
+	assert.Equal(t, message.Get(desc.Fields().ByName("status")).Enum(), protoreflect.EnumNumber(1))
+	assert.Equal(t, message.Get(desc.Fields().ByName("priority")).Enum(), protoreflect.EnumNumber(1))
+}
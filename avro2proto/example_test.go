@@ -0,0 +1,21 @@
+package avro2proto_test
+
+import (
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/avro2proto"
+)
+
+func ExampleMessageDescriptor() {
+	schema, err := avro.Parse([]byte(`{"type": "record", "name": "Book", "fields": [{"name": "title", "type": "string"}]}`))
+	if err != nil {
+		panic(err)
+	}
+	desc, err := avro2proto.MessageDescriptor(schema)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(desc.Fields().ByName("title").Kind())
+	// Output: string
+}
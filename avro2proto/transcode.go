@@ -0,0 +1,390 @@
+package avro2proto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/timeofday"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// transcoders caches the transcoders of writer schemas, by their minified JSON encoding.
+var transcoders sync.Map // map[string]*transcoder
+
+// TranscodeToProtoJSON converts Avro data of the writer schema to the canonical protobuf JSON encoding, see
+// protojson.Marshal, of a dynamic message of the descriptor returned by MessageDescriptor, so that Avro data of
+// any schema can be served as protobuf JSON without registered Go types of its messages.
+//
+// Data that is a JSON object, or null, is decoded as Avro JSON, and other data as Avro binary. Null values of
+// nullable root records are converted to empty messages. Decimals are converted to the big-endian two's
+// complement of their unscaled value, as in Avro binary. The descriptor of the schema is synthesized once, and
+// reused by later calls with the same schema.
+func TranscodeToProtoJSON(writerSchema avro.Schema, avroData []byte) ([]byte, error) {
+	t, err := loadTranscoder(writerSchema)
+	if err != nil {
+		return nil, fmt.Errorf("transcode to proto json: %w", err)
+	}
+	message, err := t.decode(avroData)
+	if err != nil {
+		return nil, fmt.Errorf("transcode to proto json: %w", err)
+	}
+	data, err := protojson.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("transcode to proto json: %w", err)
+	}
+	return data, nil
+}
+
+type transcoder struct {
+	schema avro.Schema
+	codec  *goavro.Codec
+	desc   protoreflect.MessageDescriptor
+	// named holds the named types of the schema by Avro full name.
+	named map[string]avro.Schema
+}
+
+func loadTranscoder(schema avro.Schema) (*transcoder, error) {
+	schemaBytes, err := avro.MarshalMinified(schema)
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+	if t, ok := transcoders.Load(string(schemaBytes)); ok {
+		return t.(*transcoder), nil
+	}
+	desc, err := MessageDescriptor(schema)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new codec: %w", err)
+	}
+	t := &transcoder{schema: schema, codec: codec, desc: desc, named: make(map[string]avro.Schema)}
+	for _, named := range avro.NamedTypes(schema) {
+		t.named[named.FullName] = named.Schema
+	}
+	actual, _ := transcoders.LoadOrStore(string(schemaBytes), t)
+	return actual.(*transcoder), nil
+}
+
+// decode decodes the Avro JSON or binary data into a dynamic message.
+func (t *transcoder) decode(data []byte) (*dynamicpb.Message, error) {
+	var native interface{}
+	var err error
+	if isAvroJSON(data) {
+		if native, _, err = t.codec.NativeFromTextual(data); err != nil {
+			return nil, fmt.Errorf("native from textual: %w", err)
+		}
+	} else {
+		var rest []byte
+		if native, rest, err = t.codec.NativeFromBinary(data); err != nil {
+			return nil, fmt.Errorf("native from binary: %w", err)
+		}
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("native from binary: %d trailing bytes", len(rest))
+		}
+	}
+	message := dynamicpb.NewMessage(t.desc)
+	root, namespace := t.deref(t.schema, "")
+	if native = unwrapNullable(root, native); native == nil {
+		return message, nil
+	}
+	record, _ := nonNull(root)
+	record, namespace = t.deref(record, namespace)
+	if err := t.decodeRecord(record.(avro.Record), namespace, native, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func isAvroJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '{' && !bytes.Equal(data, []byte("null"))) {
+		return false
+	}
+	return json.Valid(data)
+}
+
+// deref returns the definition of named types referenced by the schema, and the namespace of the schema.
+func (t *transcoder) deref(schema avro.Schema, namespace string) (avro.Schema, string) {
+	ref, ok := schema.(avro.Reference)
+	if !ok {
+		return schema, namespace
+	}
+	name := fullName(string(ref), "", namespace)
+	if _, ok := t.named[name]; !ok {
+		name = string(ref)
+	}
+	if def, ok := t.named[name]; ok {
+		return def, namespaceOf(name)
+	}
+	return schema, namespace
+}
+
+// unwrapNullable returns the value of the non-null branch of values of nullable schemas.
+func unwrapNullable(schema avro.Schema, value interface{}) interface{} {
+	if _, ok := schema.(avro.Union); !ok {
+		return value
+	}
+	if branch, ok := value.(map[string]interface{}); ok && len(branch) == 1 {
+		for _, v := range branch {
+			return v
+		}
+	}
+	return value
+}
+
+func (t *transcoder) decodeRecord(
+	record avro.Record,
+	enclosing string,
+	data interface{},
+	message protoreflect.Message,
+) error {
+	d, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("record %s: expected map[string]interface{}, got %T", record.Name, data)
+	}
+	name := fullName(record.Name, record.Namespace, enclosing)
+	for _, field := range record.Fields {
+		value, ok := d[field.Name]
+		if !ok {
+			continue
+		}
+		fd := message.Descriptor().Fields().ByName(protoreflect.Name(field.Name))
+		if err := t.decodeField(message, fd, field.Type, namespaceOf(name), value); err != nil {
+			return fmt.Errorf("record %s: field %s: %w", name, field.Name, err)
+		}
+	}
+	return nil
+}
+
+func (t *transcoder) decodeField(
+	message protoreflect.Message,
+	fd protoreflect.FieldDescriptor,
+	schema avro.Schema,
+	namespace string,
+	data interface{},
+) error {
+	if data = unwrapNullable(schema, data); data == nil {
+		return nil
+	}
+	schema, _ = nonNull(schema)
+	schema, namespace = t.deref(schema, namespace)
+	switch s := schema.(type) {
+	case avro.Array:
+		items, ok := data.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected []interface{}, got %T", data)
+		}
+		list := message.Mutable(fd).List()
+		for _, item := range items {
+			value, err := t.decodeValue(list.NewElement(), fd, s.Items, namespace, item)
+			if err != nil {
+				return err
+			}
+			list.Append(value)
+		}
+		return nil
+	case avro.Map:
+		entries, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected map[string]interface{}, got %T", data)
+		}
+		mp := message.Mutable(fd).Map()
+		for key, entry := range entries {
+			value, err := t.decodeValue(mp.NewValue(), fd.MapValue(), s.Values, namespace, entry)
+			if err != nil {
+				return err
+			}
+			mp.Set(protoreflect.ValueOfString(key).MapKey(), value)
+		}
+		return nil
+	}
+	value, err := t.decodeValue(message.NewField(fd), fd, schema, namespace, data)
+	if err != nil {
+		return err
+	}
+	message.Set(fd, value)
+	return nil
+}
+
+// decodeValue decodes a singular value of the field, where zero is a new value of the field.
+func (t *transcoder) decodeValue(
+	zero protoreflect.Value,
+	fd protoreflect.FieldDescriptor,
+	schema avro.Schema,
+	namespace string,
+	data interface{},
+) (protoreflect.Value, error) {
+	if data = unwrapNullable(schema, data); data == nil {
+		// elements of lists and maps can not be null
+		return zero, nil
+	}
+	schema, _ = nonNull(schema)
+	schema, namespace = t.deref(schema, namespace)
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		if record, ok := schema.(avro.Record); ok {
+			return zero, t.decodeRecord(record, namespace, data, zero.Message())
+		}
+		wellKnown, err := decodeWellKnown(fd.Message().FullName(), data)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		proto.Merge(zero.Message().Interface(), wellKnown)
+		return zero, nil
+	case protoreflect.EnumKind:
+		symbol, ok := data.(string)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected string, got %T", data)
+		}
+		name := symbol
+		if enum, ok := schema.(avro.Enum); ok {
+			name = enumValuePrefix(enum) + symbol
+		}
+		value := fd.Enum().Values().ByName(protoreflect.Name(name))
+		if value == nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown symbol %s of enum %s", symbol, fd.Enum().FullName())
+		}
+		return protoreflect.ValueOfEnum(value.Number()), nil
+	}
+	switch v := data.(type) {
+	case bool:
+		if fd.Kind() == protoreflect.BoolKind {
+			return protoreflect.ValueOfBool(v), nil
+		}
+	case int32:
+		return integerValue(fd, int64(v))
+	case int64:
+		return integerValue(fd, v)
+	case float32:
+		return floatValue(fd, float64(v))
+	case float64:
+		return floatValue(fd, v)
+	case string:
+		if fd.Kind() == protoreflect.StringKind {
+			return protoreflect.ValueOfString(v), nil
+		}
+	case []byte:
+		if fd.Kind() == protoreflect.BytesKind {
+			return protoreflect.ValueOfBytes(v), nil
+		}
+	case *big.Rat:
+		if fd.Kind() == protoreflect.BytesKind {
+			return protoreflect.ValueOfBytes(decimalBytes(v, decimalScale(schema))), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("unexpected value of type %T for field of kind %s", data, fd.Kind())
+}
+
+func integerValue(fd protoreflect.FieldDescriptor, i int64) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(i)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(i), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(i)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(i)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unexpected integer value for field of kind %s", fd.Kind())
+}
+
+func floatValue(fd protoreflect.FieldDescriptor, f float64) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(f)), nil
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(f), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unexpected floating point value for field of kind %s", fd.Kind())
+}
+
+// decodeWellKnown decodes the value of a logical type to the well-known type it is mapped to.
+func decodeWellKnown(name protoreflect.FullName, data interface{}) (proto.Message, error) {
+	switch v := data.(type) {
+	case time.Time:
+		switch name {
+		case "google.protobuf.Timestamp":
+			return timestamppb.New(v), nil
+		case "google.type.Date":
+			v = v.UTC()
+			return &date.Date{Year: int32(v.Year()), Month: int32(v.Month()), Day: int32(v.Day())}, nil
+		}
+	case time.Duration:
+		if name == "google.type.TimeOfDay" {
+			return &timeofday.TimeOfDay{
+				Hours:   int32(v / time.Hour),
+				Minutes: int32(v % time.Hour / time.Minute),
+				Seconds: int32(v % time.Minute / time.Second),
+				Nanos:   int32(v % time.Second),
+			}, nil
+		}
+	case int64:
+		// goavro decodes timestamp-nanos as its underlying long
+		if name == "google.protobuf.Timestamp" {
+			return timestamppb.New(time.Unix(0, v)), nil
+		}
+	case []byte:
+		if name == "google.protobuf.Duration" {
+			return decodeDuration(v)
+		}
+	}
+	return nil, fmt.Errorf("unexpected value of type %T for %s", data, name)
+}
+
+// decodeDuration decodes the fixed of the logical type duration: months, days and milliseconds, in
+// little-endian unsigned integers of 4 bytes. Months have no fixed length, and are not supported.
+func decodeDuration(b []byte) (*durationpb.Duration, error) {
+	if len(b) != 12 {
+		return nil, fmt.Errorf("duration: expected 12 bytes, got %d", len(b))
+	}
+	if months := binary.LittleEndian.Uint32(b[0:4]); months != 0 {
+		return nil, fmt.Errorf("duration: unsupported duration of %d months", months)
+	}
+	days := time.Duration(binary.LittleEndian.Uint32(b[4:8]))
+	millis := time.Duration(binary.LittleEndian.Uint32(b[8:12]))
+	return durationpb.New(days*24*time.Hour + millis*time.Millisecond), nil
+}
+
+func decimalScale(schema avro.Schema) int {
+	if p, ok := schema.(avro.Primitive); ok {
+		return p.Scale
+	}
+	return 0
+}
+
+// decimalBytes returns the big-endian two's complement of the unscaled value of the decimal.
+func decimalBytes(r *big.Rat, scale int) []byte {
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	unscaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow))
+	i := new(big.Int).Quo(unscaled.Num(), unscaled.Denom())
+	if i.Sign() >= 0 {
+		b := i.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return b
+	}
+	// two's complement of negative values, in the least number of bytes
+	n := new(big.Int).Not(i).BitLen()/8 + 1
+	b := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), uint(n*8)), i).Bytes()
+	for len(b) < n {
+		b = append([]byte{0xff}, b...)
+	}
+	return b
+}
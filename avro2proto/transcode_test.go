@@ -0,0 +1,146 @@
+package avro2proto
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestTranscodeToProtoJSON(t *testing.T) {
+	t.Run("protoavro", func(t *testing.T) {
+		book := &library.Book{Name: "shelves/1/books/1", Title: "Dune", Author: "Frank Herbert", Read: true}
+		schema, err := protoavro.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		codec := newTestCodec(t, schema)
+		native, err := protoavro.SchemaOptions{}.Encode(book)
+		assert.NilError(t, err)
+		binary, err := codec.BinaryFromNative(nil, native)
+		assert.NilError(t, err)
+		textual, err := codec.TextualFromNative(nil, native)
+		assert.NilError(t, err)
+		for _, data := range [][]byte{binary, textual} {
+			got, err := TranscodeToProtoJSON(schema, data)
+			assert.NilError(t, err)
+			var decoded library.Book
+			assert.NilError(t, protojson.Unmarshal(got, &decoded))
+			assert.DeepEqual(t, book, &decoded, protocmp.Transform())
+		}
+	})
+
+	t.Run("avro first", func(t *testing.T) {
+		schema, err := avro.Parse([]byte(`{
+			"type": "record",
+			"name": "Order",
+			"namespace": "example.v1",
+			"fields": [
+				{"name": "id", "type": "string"},
+				{"name": "quantity", "type": "int"},
+				{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["OPEN", "CLOSED"]}},
+				{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+				{"name": "due", "type": ["null", {"type": "int", "logicalType": "date"}]},
+				{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}},
+				{"name": "labels", "type": {"type": "map", "values": "string"}},
+				{"name": "lines", "type": {"type": "array", "items": {
+					"type": "record", "name": "Line", "fields": [{"name": "sku", "type": "string"}]
+				}}},
+				{"name": "first", "type": ["null", "Line"]}
+			]
+		}`))
+		assert.NilError(t, err)
+		got, err := TranscodeToProtoJSON(schema, []byte(`{
+			"id": "o1",
+			"quantity": 2,
+			"status": "CLOSED",
+			"created": 1600000000000,
+			"due": {"int.date": 18000},
+			"price": "\u0004R",
+			"labels": {"a": "b"},
+			"lines": [{"sku": "s1"}, {"sku": "s2"}],
+			"first": {"example.v1.Line": {"sku": "s1"}}
+		}`))
+		assert.NilError(t, err)
+		assertJSONEqual(t, `{
+			"id": "o1",
+			"quantity": 2,
+			"status": "STATUS_CLOSED",
+			"created": "2020-09-13T12:26:40Z",
+			"due": {"year": 2019, "month": 4, "day": 14},
+			"price": "BFI=",
+			"labels": {"a": "b"},
+			"lines": [{"sku": "s1"}, {"sku": "s2"}],
+			"first": {"sku": "s1"}
+		}`, got)
+	})
+
+	t.Run("null root", func(t *testing.T) {
+		schema, err := protoavro.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		for _, data := range [][]byte{[]byte("null"), {0}} {
+			got, err := TranscodeToProtoJSON(schema, data)
+			assert.NilError(t, err)
+			assertJSONEqual(t, `{}`, got)
+		}
+	})
+
+	t.Run("trailing bytes", func(t *testing.T) {
+		schema := avro.Record{
+			Type:   avro.RecordType,
+			Name:   "Book",
+			Fields: []avro.Field{{Name: "title", Type: avro.String()}},
+		}
+		_, err := TranscodeToProtoJSON(schema, []byte{2, 'a', 0})
+		assert.ErrorContains(t, err, "1 trailing bytes")
+	})
+
+	t.Run("unsupported schema", func(t *testing.T) {
+		_, err := TranscodeToProtoJSON(avro.String(), []byte(`"a"`))
+		assert.ErrorContains(t, err, "unsupported root schema: string")
+	})
+}
+
+func TestDecimalBytes(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected []byte
+	}{
+		{value: "0", expected: []byte{0}},
+		{value: "1.27", expected: []byte{127}},
+		{value: "1.28", expected: []byte{0, 128}},
+		{value: "-0.01", expected: []byte{0xff}},
+		{value: "-1.28", expected: []byte{0x80}},
+		{value: "-1.29", expected: []byte{0xff, 0x7f}},
+	} {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			r, ok := new(big.Rat).SetString(tt.value)
+			assert.Assert(t, ok)
+			assert.DeepEqual(t, tt.expected, decimalBytes(r, 2))
+		})
+	}
+}
+
+func newTestCodec(t *testing.T, schema avro.Schema) *goavro.Codec {
+	t.Helper()
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	return codec
+}
+
+// assertJSONEqual compares JSON semantically, since protojson output is deliberately unstable.
+func assertJSONEqual(t *testing.T, expected string, got []byte) {
+	t.Helper()
+	var e, g interface{}
+	assert.NilError(t, json.Unmarshal([]byte(expected), &e))
+	assert.NilError(t, json.Unmarshal(got, &g), string(got))
+	assert.DeepEqual(t, e, g)
+}
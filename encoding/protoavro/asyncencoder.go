@@ -0,0 +1,154 @@
+package protoavro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// EncodeResult is the result of encoding a message with an AsyncEncoder.
+type EncodeResult struct {
+	// Message is the encoded message, for example to acknowledge it once its data is written.
+	Message proto.Message
+	// Data is the Avro binary encoding of the message, or nil when encoding failed.
+	Data []byte
+	// Err is the error of encoding the message.
+	Err error
+}
+
+// AsyncEncoder encodes streams of messages in Avro binary format with a pool of workers, and emits the results
+// in the order the messages are received.
+// An AsyncEncoder is safe for concurrent use, and can encode several streams.
+type AsyncEncoder struct {
+	opts    SchemaOptions
+	desc    protoreflect.MessageDescriptor
+	codec   *goavro.Codec
+	workers int
+}
+
+// NewAsyncEncoder returns a new encoder of messages of desc, with SchemaOptions.Workers workers.
+// Records are compressed with SchemaOptions.RecordCompression, like records encoded by Codec.
+func (o SchemaOptions) NewAsyncEncoder(desc protoreflect.MessageDescriptor) (*AsyncEncoder, error) {
+	o = o.clone()
+	schema, err := o.InferSchema(desc)
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new codec: %w", err)
+	}
+	if _, err := lookupCompressionCodec(o.RecordCompression); err != nil {
+		return nil, fmt.Errorf("record compression: %w", err)
+	}
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &AsyncEncoder{opts: o, desc: desc, codec: codec, workers: workers}, nil
+}
+
+// Encode encodes the messages received from in, and sends a result for each message to the returned channel, in
+// the order the messages are received. Messages that fail to encode do not stop the stream: their results hold
+// the error. The returned channel is closed once in is closed and all results are sent, or when the context is
+// done, which drops the results that are not sent yet.
+//
+// Memory is bounded: at most the number of workers plus two messages are received ahead of the results sent,
+// so that a slow consumer of the results applies back-pressure to the producer of the messages.
+func (e *AsyncEncoder) Encode(ctx context.Context, in <-chan proto.Message) <-chan EncodeResult {
+	type job struct {
+		message proto.Message
+		result  chan<- EncodeResult
+	}
+	results := make(chan EncodeResult)
+	// pending holds the results of the messages being encoded, in the order the messages were received
+	pending := make(chan chan EncodeResult, e.workers)
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	wg.Add(e.workers)
+	for w := 0; w < e.workers; w++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.result <- e.encode(ctx, job.message)
+			}
+		}()
+	}
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for {
+			var message proto.Message
+			select {
+			case m, ok := <-in:
+				if !ok {
+					return
+				}
+				message = m
+			case <-ctx.Done():
+				return
+			}
+			result := make(chan EncodeResult, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job{message: message, result: result}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(results)
+		defer wg.Wait()
+		for result := range pending {
+			var r EncodeResult
+			select {
+			case r = <-result:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case results <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
+
+func (e *AsyncEncoder) encode(ctx context.Context, message proto.Message) EncodeResult {
+	result := EncodeResult{Message: message}
+	if got := message.ProtoReflect().Descriptor().FullName(); got != e.desc.FullName() {
+		result.Err = fmt.Errorf("expected message '%s' but got '%s'", e.desc.FullName(), got)
+		return result
+	}
+	data, err := e.opts.encodeJSON(message)
+	if err != nil {
+		result.Err = fmt.Errorf("encode json: %w", err)
+		return result
+	}
+	b, err := e.codec.BinaryFromNative(nil, data)
+	if err != nil {
+		result.Err = fmt.Errorf("binary from native: %w", err)
+		return result
+	}
+	if result.Data, result.Err = CompressRecord(e.opts.RecordCompression, b); result.Err == nil {
+		e.opts.recordsEncoded(ctx, 1)
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package protoavro_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func Test_AsyncEncoder(t *testing.T) {
+	msgs := books(1000)
+	opts := protoavro.SchemaOptions{Workers: 4}
+	encoder, err := opts.NewAsyncEncoder((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	codec, err := protoavro.NewCodec[*library.Book](opts)
+	assert.NilError(t, err)
+	in := make(chan proto.Message)
+	go func() {
+		defer close(in)
+		for _, msg := range msgs {
+			in <- msg
+		}
+	}()
+	var i int
+	for result := range encoder.Encode(context.Background(), in) {
+		assert.NilError(t, result.Err)
+		// results are in the order of the messages
+		assert.Equal(t, msgs[i], result.Message)
+		got, err := codec.Unmarshal(result.Data)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, msgs[i], got, protocmp.Transform())
+		i++
+	}
+	assert.Equal(t, len(msgs), i)
+}
+
+func Test_AsyncEncoder_MessageErrors(t *testing.T) {
+	encoder, err := protoavro.SchemaOptions{Workers: 2}.NewAsyncEncoder((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	in := make(chan proto.Message, 3)
+	in <- &library.Book{Name: "shelves/1/books/1"}
+	in <- &library.Shelf{Name: "shelves/1"}
+	in <- &library.Book{Name: "shelves/1/books/2"}
+	close(in)
+	var results []protoavro.EncodeResult
+	for result := range encoder.Encode(context.Background(), in) {
+		results = append(results, result)
+	}
+	assert.Equal(t, 3, len(results))
+	assert.NilError(t, results[0].Err)
+	assert.Error(
+		t,
+		results[1].Err,
+		"expected message 'google.example.library.v1.Book' but got 'google.example.library.v1.Shelf'",
+	)
+	assert.Assert(t, results[1].Data == nil)
+	assert.NilError(t, results[2].Err)
+}
+
+func Test_AsyncEncoder_BackPressure(t *testing.T) {
+	const workers = 2
+	encoder, err := protoavro.SchemaOptions{Workers: workers}.NewAsyncEncoder(
+		(&library.Book{}).ProtoReflect().Descriptor(),
+	)
+	assert.NilError(t, err)
+	msgs := books(100)
+	in := make(chan proto.Message, len(msgs))
+	for _, msg := range msgs {
+		in <- msg
+	}
+	close(in)
+	ctx, cancel := context.WithCancel(context.Background())
+	results := encoder.Encode(ctx, in)
+	// without a consumer of the results, only a bounded number of messages are received
+	time.Sleep(50 * time.Millisecond)
+	assert.Assert(t, len(msgs)-len(in) <= workers+2, "received %d messages", len(msgs)-len(in))
+	result := <-results
+	assert.Equal(t, msgs[0], result.Message)
+	// the results channel is closed when the context is done
+	cancel()
+	for range results {
+	}
+	assert.Assert(t, len(in) > 0)
+}
@@ -0,0 +1,586 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// avroJSONDecoder decodes data in the JSON encoding of the Avro specification directly into messages,
+// from the token stream of a json.Decoder, without materializing the data as generic values.
+// Well-known types and messages with custom codecs are decoded through goavro, since their decoding
+// is defined in terms of the native values of goavro.
+type avroJSONDecoder struct {
+	root jsonValueDecoder
+	// requireFields checks that required fields are set in decoded messages.
+	requireFields bool
+}
+
+// newAvroJSONDecoder returns a decoder of Avro JSON data of the schema, inferred with the options for the
+// message descriptor. The returned error wraps errUnsupported when options that can not be decoded
+// directly are set.
+func (o *SchemaOptions) newAvroJSONDecoder(
+	desc protoreflect.MessageDescriptor,
+	schema avro.Schema,
+) (*avroJSONDecoder, error) {
+	if err := o.checkDirectDecoding(desc); err != nil {
+		return nil, err
+	}
+	opts := o.withNames(desc)
+	c := avroJSONCompiler{
+		opts:        &opts,
+		definitions: make(definitions),
+		records:     make(map[string]*jsonRecordDecoder),
+	}
+	c.definitions.collect(schema)
+	root, err := c.compileMessage(schema, desc)
+	if err != nil {
+		return nil, err
+	}
+	return &avroJSONDecoder{root: root, requireFields: o.RequireFields}, nil
+}
+
+// decode decodes the Avro JSON data into the message.
+func (d *avroJSONDecoder) decode(r io.Reader, msg protoreflect.Message) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if _, _, err := d.root(decoder, container{value: protoreflect.ValueOfMessage(msg)}); err != nil {
+		return err
+	}
+	if d.requireFields {
+		return checkRequiredFields(msg, nil)
+	}
+	return nil
+}
+
+// jsonValueDecoder decodes a value of a schema. Null values are reported as null, with an invalid value.
+type jsonValueDecoder func(d *json.Decoder, c container) (value protoreflect.Value, null bool, err error)
+
+// jsonRecordDecoder decodes the fields of a record into a message.
+type jsonRecordDecoder struct {
+	fields map[string]func(d *json.Decoder, msg protoreflect.Message) error
+}
+
+func (r *jsonRecordDecoder) decode(d *json.Decoder, msg protoreflect.Message) error {
+	if err := expectDelim(d, '{'); err != nil {
+		return err
+	}
+	for d.More() {
+		name, err := readJSONString(d)
+		if err != nil {
+			return err
+		}
+		field, ok := r.fields[name]
+		if !ok {
+			return fmt.Errorf("unexpected field %s", name)
+		}
+		if err := field(d, msg); err != nil {
+			return err
+		}
+	}
+	return expectDelim(d, '}')
+}
+
+type avroJSONCompiler struct {
+	opts        *SchemaOptions
+	definitions definitions
+	// records holds the decoders of records by full name, to support recursive messages.
+	records map[string]*jsonRecordDecoder
+}
+
+// compileMessage compiles a decoder of a message, encoded as a record or a union of null and a record.
+func (c *avroJSONCompiler) compileMessage(
+	schema avro.Schema,
+	desc protoreflect.MessageDescriptor,
+) (jsonValueDecoder, error) {
+	if union, ok := schema.(avro.Union); ok {
+		return c.compileUnion(union, func(branch avro.Schema) (jsonValueDecoder, error) {
+			return c.compileMessage(branch, desc)
+		})
+	}
+	record, ok := c.definitions.deref(schema).(avro.Record)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected record for '%s', got %T", errUnsupported, desc.FullName(), schema)
+	}
+	r, err := c.compileRecord(record, desc)
+	if err != nil {
+		return nil, err
+	}
+	return func(d *json.Decoder, ct container) (protoreflect.Value, bool, error) {
+		value := ct.newValue()
+		if err := r.decode(d, value.Message()); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		return value, false, nil
+	}, nil
+}
+
+func (c *avroJSONCompiler) compileRecord(
+	record avro.Record,
+	desc protoreflect.MessageDescriptor,
+) (*jsonRecordDecoder, error) {
+	name := joinName(record.Namespace, record.Name)
+	if r, ok := c.records[name]; ok {
+		return r, nil
+	}
+	r := &jsonRecordDecoder{fields: make(map[string]func(*json.Decoder, protoreflect.Message) error, len(record.Fields))}
+	c.records[name] = r
+	for _, field := range record.Fields {
+		fd, ok := c.opts.findField(desc, field.Name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unexpected field %s", errUnsupported, field.Name)
+		}
+		decodeField, err := c.compileField(field.Type, fd)
+		if err != nil {
+			return nil, err
+		}
+		r.fields[field.Name] = decodeField
+	}
+	return r, nil
+}
+
+func (c *avroJSONCompiler) compileField(
+	schema avro.Schema,
+	fd protoreflect.FieldDescriptor,
+) (func(*json.Decoder, protoreflect.Message) error, error) {
+	var decodeValue jsonValueDecoder
+	var err error
+	switch {
+	case fd.IsList():
+		decodeValue, err = c.compileUnion(schema, func(branch avro.Schema) (jsonValueDecoder, error) {
+			return c.compileList(branch, fd)
+		})
+	case fd.IsMap():
+		decodeValue, err = c.compileUnion(schema, func(branch avro.Schema) (jsonValueDecoder, error) {
+			return c.compileMap(branch, fd)
+		})
+	default:
+		decodeValue, err = c.compileValue(schema, fd)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func(d *json.Decoder, msg protoreflect.Message) error {
+		value, null, err := decodeValue(d, container{message: msg, field: fd})
+		if err != nil {
+			return fmt.Errorf("field %s: %w", fd.Name(), err)
+		}
+		if !null {
+			if err := checkOneofCase(msg, fd); err != nil {
+				return err
+			}
+			msg.Set(fd, value)
+		}
+		return nil
+	}, nil
+}
+
+// compileUnion compiles a decoder of a union, encoded as null or an object keyed by the name of the branch,
+// with the branches compiled by compileBranch. Schemas that are not unions are compiled as they are.
+func (c *avroJSONCompiler) compileUnion(
+	schema avro.Schema,
+	compileBranch func(avro.Schema) (jsonValueDecoder, error),
+) (jsonValueDecoder, error) {
+	union, ok := schema.(avro.Union)
+	if !ok {
+		return compileBranch(schema)
+	}
+	branches := make(map[string]jsonValueDecoder, len(union))
+	for _, branch := range union {
+		if branch == avro.Null() {
+			continue
+		}
+		d, err := compileBranch(branch)
+		if err != nil {
+			return nil, err
+		}
+		branches[c.opts.unionBranchName(branch)] = d
+	}
+	decode := func(d *json.Decoder, ct container) (protoreflect.Value, bool, error) {
+		token, err := d.Token()
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		if token == nil {
+			return protoreflect.Value{}, true, nil
+		}
+		if token != json.Delim('{') {
+			return protoreflect.Value{}, false, fmt.Errorf("expected union object, got %v", token)
+		}
+		name, err := readJSONString(d)
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		decodeBranch, ok := branches[name]
+		if !ok {
+			return protoreflect.Value{}, false, fmt.Errorf("unexpected union branch %s", name)
+		}
+		value, null, err := decodeBranch(d, ct)
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		if err := expectDelim(d, '}'); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		return value, null, nil
+	}
+	if !c.opts.LenientUnions {
+		return decode, nil
+	}
+	// values that are not wrapped by the name of their branch are wrapped before they are decoded
+	names := c.opts.unionBranches(union)
+	return func(d *json.Decoder, ct container) (protoreflect.Value, bool, error) {
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(wrapLenientJSON(raw, names)))
+		decoder.UseNumber()
+		return decode(decoder, ct)
+	}, nil
+}
+
+func (c *avroJSONCompiler) compileList(schema avro.Schema, fd protoreflect.FieldDescriptor) (jsonValueDecoder, error) {
+	array, ok := schema.(avro.Array)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected array for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	decodeItem, err := c.compileValue(array.Items, fd)
+	if err != nil {
+		return nil, err
+	}
+	return func(d *json.Decoder, ct container) (protoreflect.Value, bool, error) {
+		if err := expectDelim(d, '['); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		list := ct.message.NewField(fd).List()
+		for d.More() {
+			item, null, err := decodeItem(d, container{list: list})
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			if null {
+				var ok bool
+				if item, ok, err = c.opts.nullListItem(list, fd); err != nil {
+					return protoreflect.Value{}, false, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			list.Append(item)
+		}
+		if err := expectDelim(d, ']'); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		return protoreflect.ValueOfList(list), false, nil
+	}, nil
+}
+
+func (c *avroJSONCompiler) compileMap(schema avro.Schema, fd protoreflect.FieldDescriptor) (jsonValueDecoder, error) {
+	array, ok := schema.(avro.Array)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected array for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	entry, ok := c.definitions.deref(array.Items).(avro.Record)
+	if !ok || len(entry.Fields) != 2 || entry.Fields[0].Name != "key" || entry.Fields[1].Name != "value" {
+		return nil, fmt.Errorf("%w: expected map entry record for '%s'", errUnsupported, fd.Name())
+	}
+	decodeKey, err := c.compileValue(entry.Fields[0].Type, fd.MapKey())
+	if err != nil {
+		return nil, err
+	}
+	decodeMapValue, err := c.compileValue(entry.Fields[1].Type, fd.MapValue())
+	if err != nil {
+		return nil, err
+	}
+	return func(d *json.Decoder, ct container) (protoreflect.Value, bool, error) {
+		if err := expectDelim(d, '['); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		mp := ct.message.NewField(fd).Map()
+		for d.More() {
+			if err := expectDelim(d, '{'); err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			var key, value protoreflect.Value
+			var keyNull bool
+			valueNull := true
+			for d.More() {
+				name, err := readJSONString(d)
+				if err != nil {
+					return protoreflect.Value{}, false, err
+				}
+				switch name {
+				case "key":
+					key, keyNull, err = decodeKey(d, container{})
+				case "value":
+					value, valueNull, err = decodeMapValue(d, container{mp: mp})
+				default:
+					err = fmt.Errorf("unexpected field %s in map entry", name)
+				}
+				if err != nil {
+					return protoreflect.Value{}, false, err
+				}
+			}
+			if err := expectDelim(d, '}'); err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			if !key.IsValid() || keyNull {
+				return protoreflect.Value{}, false, fmt.Errorf("missing 'key' in map entry for '%s'", fd.Name())
+			}
+			if valueNull {
+				var ok bool
+				if value, ok, err = c.opts.nullMapValue(mp, fd); err != nil {
+					return protoreflect.Value{}, false, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			mp.Set(key.MapKey(), value)
+		}
+		if err := expectDelim(d, ']'); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		return protoreflect.ValueOfMap(mp), false, nil
+	}, nil
+}
+
+// compileValue compiles a decoder of a single value of the field kind.
+func (c *avroJSONCompiler) compileValue(schema avro.Schema, fd protoreflect.FieldDescriptor) (jsonValueDecoder, error) {
+	if fd.Message() != nil && c.opts.isWKT(fd.Message().FullName()) {
+		return c.compileWKT(schema, fd)
+	}
+	if union, ok := schema.(avro.Union); ok {
+		return c.compileUnion(union, func(branch avro.Schema) (jsonValueDecoder, error) {
+			return c.compileValue(branch, fd)
+		})
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return c.compileMessage(schema, fd.Message())
+	case protoreflect.EnumKind:
+		return c.compileEnum(schema, fd)
+	}
+	if fixed, ok := c.definitions.deref(schema).(avro.Fixed); ok && fd.Kind() == protoreflect.BytesKind {
+		return func(d *json.Decoder, _ container) (protoreflect.Value, bool, error) {
+			s, err := readJSONString(d)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			b, err := bytesFromCodePoints(s)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			if err := checkFixedSize(fd, fixed.Size, b); err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			return protoreflect.ValueOfBytes(b), false, nil
+		}, nil
+	}
+	primitive, ok := schema.(avro.Primitive)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected primitive for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	switch {
+	case (primitive.Type == avro.IntType || primitive.Type == avro.LongType) && isIntegerKind(fd.Kind()):
+		return func(d *json.Decoder, _ container) (protoreflect.Value, bool, error) {
+			number, err := readJSONNumber(d)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			i, err := strconv.ParseInt(number.String(), 10, 64)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			return integerValue(fd.Kind(), i), false, nil
+		}, nil
+	case primitive.Type == avro.BooleanType && fd.Kind() == protoreflect.BoolKind:
+		return func(d *json.Decoder, _ container) (protoreflect.Value, bool, error) {
+			token, err := d.Token()
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			b, ok := token.(bool)
+			if !ok {
+				return protoreflect.Value{}, false, fmt.Errorf("expected boolean, got %v", token)
+			}
+			return protoreflect.ValueOfBool(b), false, nil
+		}, nil
+	case primitive.Type == avro.FloatType && fd.Kind() == protoreflect.FloatKind:
+		return func(d *json.Decoder, _ container) (protoreflect.Value, bool, error) {
+			f, err := readJSONFloat(d, 32)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			return protoreflect.ValueOfFloat32(float32(f)), false, nil
+		}, nil
+	case primitive.Type == avro.DoubleType && fd.Kind() == protoreflect.DoubleKind:
+		return func(d *json.Decoder, _ container) (protoreflect.Value, bool, error) {
+			f, err := readJSONFloat(d, 64)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			return protoreflect.ValueOfFloat64(f), false, nil
+		}, nil
+	case primitive.Type == avro.StringType && fd.Kind() == protoreflect.StringKind:
+		return func(d *json.Decoder, _ container) (protoreflect.Value, bool, error) {
+			s, err := readJSONString(d)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			return protoreflect.ValueOfString(s), false, nil
+		}, nil
+	case primitive.Type == avro.BytesType && fd.Kind() == protoreflect.BytesKind:
+		return func(d *json.Decoder, _ container) (protoreflect.Value, bool, error) {
+			s, err := readJSONString(d)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			b, err := bytesFromCodePoints(s)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			return protoreflect.ValueOfBytes(b), false, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected %s for '%s' of kind %s", errUnsupported, primitive.Type, fd.Name(), fd.Kind())
+}
+
+func (c *avroJSONCompiler) compileEnum(schema avro.Schema, fd protoreflect.FieldDescriptor) (jsonValueDecoder, error) {
+	enum, ok := c.definitions.deref(schema).(avro.Enum)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected enum for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	// symbols that are not values of the enum decode to the zero value
+	numbers := make(map[string]protoreflect.EnumNumber, len(enum.Symbols))
+	for _, symbol := range enum.Symbols {
+		numbers[symbol] = 0
+		if v := c.opts.enumValue(fd.Enum(), symbol); v != nil {
+			numbers[symbol] = v.Number()
+		}
+	}
+	return func(d *json.Decoder, _ container) (protoreflect.Value, bool, error) {
+		symbol, err := readJSONString(d)
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		if number, ok := numbers[symbol]; ok {
+			return protoreflect.ValueOfEnum(number), false, nil
+		}
+		// original symbols of stripped enums are accepted, like when decoding generic values
+		if v := c.opts.enumValue(fd.Enum(), symbol); v != nil {
+			return protoreflect.ValueOfEnum(v.Number()), false, nil
+		}
+		return protoreflect.Value{}, false, fmt.Errorf("unknown symbol %s of enum '%s'", symbol, fd.Name())
+	}, nil
+}
+
+// compileWKT compiles a decoder of a well-known type, or a message with a custom codec, that decodes
+// the value with goavro and the Avro JSON decoding of the message.
+func (c *avroJSONCompiler) compileWKT(schema avro.Schema, fd protoreflect.FieldDescriptor) (jsonValueDecoder, error) {
+	codec, branch, err := c.opts.newWKTCodec(c.definitions, schema, fd)
+	if err != nil {
+		return nil, err
+	}
+	names := c.opts.unionBranches(c.definitions.inline(schema))
+	return func(d *json.Decoder, ct container) (protoreflect.Value, bool, error) {
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		if c.opts.LenientUnions {
+			raw = wrapLenientJSON(raw, names)
+		}
+		native, _, err := codec.NativeFromTextual(escapeNonASCII(raw))
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		if native == nil {
+			return protoreflect.Value{}, true, nil
+		}
+		if branch != "" {
+			native = map[string]interface{}{branch: native}
+		}
+		value := ct.newValue()
+		if err := c.opts.decodeMessage(native, value.Message(), nil); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		return value, false, nil
+	}, nil
+}
+
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+func readJSONString(d *json.Decoder) (string, error) {
+	token, err := d.Token()
+	if err != nil {
+		return "", err
+	}
+	s, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("expected string, got %v", token)
+	}
+	return s, nil
+}
+
+func readJSONNumber(d *json.Decoder) (json.Number, error) {
+	token, err := d.Token()
+	if err != nil {
+		return "", err
+	}
+	number, ok := token.(json.Number)
+	if !ok {
+		return "", fmt.Errorf("expected number, got %v", token)
+	}
+	return number, nil
+}
+
+// readJSONFloat reads a float of the bit size, with NaN and infinities encoded like goavro does.
+func readJSONFloat(d *json.Decoder, bitSize int) (float64, error) {
+	token, err := d.Token()
+	if err != nil {
+		return 0, err
+	}
+	switch token {
+	case nil:
+		return math.NaN(), nil
+	case json.Number("1e999"):
+		return math.Inf(1), nil
+	case json.Number("-1e999"):
+		return math.Inf(-1), nil
+	}
+	number, ok := token.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected number, got %v", token)
+	}
+	return strconv.ParseFloat(number.String(), bitSize)
+}
+
+// bytesFromCodePoints returns the bytes of a string of the code points 0-255, as bytes are encoded in Avro JSON.
+func bytesFromCodePoints(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("code point %U out of range for bytes", r)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
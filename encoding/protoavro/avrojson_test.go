@@ -0,0 +1,110 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestAvroJSONDecoder(t *testing.T) {
+	for _, tt := range directDecodingTestCases() {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			desc := tt.msg.ProtoReflect().Descriptor()
+			schema, err := opts.InferSchema(desc)
+			assert.NilError(t, err)
+			decoder, err := opts.newAvroJSONDecoder(desc, schema)
+			assert.NilError(t, err)
+			data, err := opts.MarshalAvroJSON(tt.msg)
+			assert.NilError(t, err)
+
+			got := tt.msg.ProtoReflect().New()
+			assert.NilError(t, decoder.decode(bytes.NewReader(data), got))
+			assert.DeepEqual(t, tt.msg, got.Interface(), protocmp.Transform())
+
+			// the direct decoder decodes like the generic decoder
+			codec := newTestGoavroCodec(t, opts, tt.msg)
+			native, _, err := codec.NativeFromTextual(data)
+			assert.NilError(t, err)
+			expected := tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, opts.decodeJSON(native, expected))
+			assert.DeepEqual(t, expected, got.Interface(), protocmp.Transform())
+		})
+	}
+}
+
+func TestAvroJSONDecoder_Data(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		data          string
+		expected      *examplev1.ExampleList
+		errorContains string
+	}{
+		{
+			name: "fields in any order",
+			data: `{"einride.avro.example.v1.ExampleList": {
+				"string_list": {"array": [{"string": "a"}, null]},
+				"int64_list": {"array": [{"long": 1}]},
+				"nested_list": null
+			}}`,
+			expected: &examplev1.ExampleList{Int64List: []int64{1}, StringList: []string{"a", ""}},
+		},
+		{
+			name:     "null",
+			data:     `null`,
+			expected: &examplev1.ExampleList{},
+		},
+		{
+			name:          "unknown field",
+			data:          `{"einride.avro.example.v1.ExampleList": {"foo": null}}`,
+			errorContains: "unexpected field foo",
+		},
+		{
+			name:          "unknown branch",
+			data:          `{"einride.avro.example.v1.ExampleList": {"int64_list": {"map": {}}}}`,
+			errorContains: "field int64_list: unexpected union branch map",
+		},
+		{
+			name:          "wrong type",
+			data:          `{"einride.avro.example.v1.ExampleList": {"int64_list": {"array": [{"long": "1"}]}}}`,
+			errorContains: "expected number",
+		},
+		{
+			name: "unknown symbol",
+			data: `{"einride.avro.example.v1.ExampleList": {"enum_list": {"array": [
+				{"einride.avro.example.v1.ExampleList.Enum": "FOO"}
+			]}}}`,
+			errorContains: "unknown symbol FOO of enum 'enum_list'",
+		},
+		{
+			name:          "truncated",
+			data:          `{"einride.avro.example.v1.ExampleList": {"int64_list": {"array": [`,
+			errorContains: "unexpected end of JSON input",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &examplev1.ExampleList{}
+			err := SchemaOptions{}.UnmarshalAvroJSON([]byte(tt.data), got)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, got, protocmp.Transform())
+		})
+	}
+}
+
+func TestAvroJSONDecoder_Bytes(t *testing.T) {
+	// bytes are strings of code points, both escaped and unescaped
+	data := `{"einride.avro.example.v1.ExampleBytes": {"bytes": {"bytes": "a\u00ffÿ"}}}`
+	got := &examplev1.ExampleBytes{}
+	assert.NilError(t, SchemaOptions{}.UnmarshalAvroJSON([]byte(data), got))
+	assert.DeepEqual(t, []byte{'a', 0xff, 0xff}, got.GetBytes())
+
+	data = `{"einride.avro.example.v1.ExampleBytes": {"bytes": {"bytes": "☃"}}}`
+	assert.ErrorContains(t, SchemaOptions{}.UnmarshalAvroJSON([]byte(data), got), "out of range for bytes")
+}
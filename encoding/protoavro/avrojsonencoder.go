@@ -0,0 +1,616 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NewAvroJSONEncoder returns a new encoder, with default SchemaOptions, that writes protobuf messages to
+// writer in the JSON encoding of the Avro specification.
+func NewAvroJSONEncoder(descriptor protoreflect.MessageDescriptor, writer io.Writer) (*AvroJSONEncoder, error) {
+	return SchemaOptions{}.NewAvroJSONEncoder(descriptor, writer)
+}
+
+// NewAvroJSONEncoder returns a new encoder that writes protobuf messages to writer in the JSON encoding
+// of the Avro specification.
+func (o SchemaOptions) NewAvroJSONEncoder(
+	descriptor protoreflect.MessageDescriptor,
+	writer io.Writer,
+) (*AvroJSONEncoder, error) {
+	o = o.clone()
+	schema, err := o.InferSchema(descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	e := &AvroJSONEncoder{opts: o, descriptor: descriptor, schema: schema, writer: writer}
+	e.encoder, err = o.newAvroJSONEncoder(descriptor, schema)
+	switch {
+	case errors.Is(err, errUnsupported):
+		if e.codec, err = newGoavroCodec(schema); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+	return e, nil
+}
+
+// AvroJSONEncoder writes messages in the JSON encoding of the Avro specification.
+// Messages are written directly from their fields, without building the intermediate Avro JSON values
+// first, unless PreserveUnknownFields, EnvelopeFields, RawProto, FieldTransforms or ValidateEncoding are set, or
+// recursive fields are mapped with RecursionJSONString. The buffer messages are encoded into is reused across
+// messages. An AvroJSONEncoder is safe for concurrent use, and writes one message at a time.
+type AvroJSONEncoder struct {
+	opts       SchemaOptions
+	descriptor protoreflect.MessageDescriptor
+	schema     avro.Schema
+	writer     io.Writer
+	encoder    *avroJSONEncoder
+	codec      *goavro.Codec
+	// mu guards the buffer and the writer.
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Encode writes the message to the writer, followed by a newline.
+func (e *AvroJSONEncoder) Encode(message proto.Message) error {
+	if message.ProtoReflect().Descriptor().FullName() != e.descriptor.FullName() {
+		return fmt.Errorf(
+			"unexpected message '%s', expected '%s'",
+			message.ProtoReflect().Descriptor().FullName(),
+			e.descriptor.FullName(),
+		)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var err error
+	if e.encoder != nil {
+		e.buf, err = e.encoder.encode(e.buf[:0], message.ProtoReflect())
+	} else {
+		e.buf, err = e.opts.appendAvroJSON(e.codec, e.buf[:0], message)
+	}
+	if err != nil {
+		return err
+	}
+	if e.buf, err = e.opts.rewriteAvroJSON(e.schema, e.buf, true); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, '\n')
+	if _, err := e.writer.Write(e.buf); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+// appendAvroJSON appends the Avro JSON encoding of the message, through goavro, to the buffer.
+func (o SchemaOptions) appendAvroJSON(codec *goavro.Codec, buf []byte, message proto.Message) ([]byte, error) {
+	data, err := o.encodeJSON(message)
+	if err != nil {
+		return nil, fmt.Errorf("encode json: %w", err)
+	}
+	b, err := o.textualFromNative(codec, buf, data)
+	if err != nil {
+		return nil, fmt.Errorf("textual from native: %w", err)
+	}
+	return b, nil
+}
+
+// textualFromNative appends the Avro JSON encoding of the native value to the buffer, with goavro. Since goavro
+// writes the fields of records in random order, the keys of objects are sorted with Deterministic.
+func (o SchemaOptions) textualFromNative(codec *goavro.Codec, buf []byte, native interface{}) ([]byte, error) {
+	b, err := codec.TextualFromNative(buf, native)
+	if err != nil || !o.Deterministic {
+		return b, err
+	}
+	return appendSortedJSON(buf, b[len(buf):])
+}
+
+// appendSortedJSON appends the JSON value to the buffer, with the keys of objects sorted, and other values as
+// they are.
+func appendSortedJSON(buf, data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("unexpected end of JSON input")
+	}
+	switch data[0] {
+	case '{':
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf = append(buf, '{')
+		for i, key := range keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(appendJSONString(buf, key), ':')
+			var err error
+			if buf, err = appendSortedJSON(buf, object[key]); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+	case '[':
+		var array []json.RawMessage
+		if err := json.Unmarshal(data, &array); err != nil {
+			return nil, err
+		}
+		buf = append(buf, '[')
+		for i, item := range array {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = appendSortedJSON(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	}
+	return append(buf, data...), nil
+}
+
+// avroJSONEncoder writes messages in the JSON encoding of the Avro specification directly from the values
+// of their fields. Well-known types and messages with custom codecs are encoded through goavro, since their
+// encoding is defined in terms of the native values of goavro.
+type avroJSONEncoder struct {
+	root jsonValueEncoder
+}
+
+// newAvroJSONEncoder returns an encoder of messages in Avro JSON of the schema, inferred with the options
+// for the message descriptor. The returned error wraps errUnsupported when options that can not be encoded
+// directly are set.
+func (o *SchemaOptions) newAvroJSONEncoder(
+	desc protoreflect.MessageDescriptor,
+	schema avro.Schema,
+) (*avroJSONEncoder, error) {
+	switch {
+	case o.PreserveUnknownFields, len(o.envelopeFields()) > 0, o.ValidateEncoding, o.isWKT(desc.FullName()),
+		o.RecursionStrategy == RecursionJSONString, len(o.FieldTransforms) > 0, o.FlattenDepth > 0,
+		o.EncryptedFieldFunc != nil:
+		return nil, errUnsupported
+	}
+	opts := o.withNames(desc)
+	c := avroJSONEncoderCompiler{
+		opts:        &opts,
+		definitions: make(definitions),
+		records:     make(map[string]*jsonRecordEncoder),
+	}
+	c.definitions.collect(schema)
+	root, err := c.compileValue(schema, nil, desc)
+	if err != nil {
+		return nil, err
+	}
+	return &avroJSONEncoder{root: root}, nil
+}
+
+// encode appends the Avro JSON encoding of the message to the buffer.
+func (e *avroJSONEncoder) encode(buf []byte, msg protoreflect.Message) ([]byte, error) {
+	return e.root(buf, protoreflect.ValueOfMessage(msg))
+}
+
+// jsonValueEncoder appends the Avro JSON encoding of a value to the buffer.
+type jsonValueEncoder func(buf []byte, value protoreflect.Value) ([]byte, error)
+
+// jsonRecordEncoder appends the fields of a message to the buffer, as an Avro JSON record.
+type jsonRecordEncoder struct {
+	fields []func(buf []byte, msg protoreflect.Message) ([]byte, error)
+}
+
+func (r *jsonRecordEncoder) encode(buf []byte, msg protoreflect.Message) ([]byte, error) {
+	buf = append(buf, '{')
+	for i, field := range r.fields {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		var err error
+		if buf, err = field(buf, msg); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+type avroJSONEncoderCompiler struct {
+	opts        *SchemaOptions
+	definitions definitions
+	// records holds the encoders of records by full name, to support recursive messages.
+	records map[string]*jsonRecordEncoder
+}
+
+func (c *avroJSONEncoderCompiler) compileRecord(
+	record avro.Record,
+	desc protoreflect.MessageDescriptor,
+) (*jsonRecordEncoder, error) {
+	name := joinName(record.Namespace, record.Name)
+	if r, ok := c.records[name]; ok {
+		return r, nil
+	}
+	r := &jsonRecordEncoder{fields: make([]func([]byte, protoreflect.Message) ([]byte, error), 0, len(record.Fields))}
+	c.records[name] = r
+	for _, field := range record.Fields {
+		fd, ok := c.opts.findField(desc, field.Name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unexpected field %s", errUnsupported, field.Name)
+		}
+		encodeField, err := c.compileField(field, fd)
+		if err != nil {
+			return nil, err
+		}
+		r.fields = append(r.fields, encodeField)
+	}
+	return r, nil
+}
+
+func (c *avroJSONEncoderCompiler) compileField(
+	field avro.Field,
+	fd protoreflect.FieldDescriptor,
+) (func([]byte, protoreflect.Message) ([]byte, error), error) {
+	prefix := appendJSONString(nil, field.Name)
+	prefix = append(prefix, ':')
+	var encodeValue jsonValueEncoder
+	var err error
+	switch {
+	case fd.IsList():
+		encodeValue, err = c.compileUnion(field.Type, false, func(branch avro.Schema) (jsonValueEncoder, error) {
+			return c.compileList(branch, fd)
+		})
+	case fd.IsMap():
+		encodeValue, err = c.compileUnion(field.Type, false, func(branch avro.Schema) (jsonValueEncoder, error) {
+			return c.compileMap(branch, fd)
+		})
+	default:
+		encodeValue, err = c.compileValue(field.Type, fd, fd.Message())
+	}
+	if err != nil {
+		return nil, err
+	}
+	nullWhenUnset := c.opts.nullWhenUnset(fd)
+	return func(buf []byte, msg protoreflect.Message) ([]byte, error) {
+		buf = append(buf, prefix...)
+		if nullWhenUnset && !msg.Has(fd) {
+			// scalar fields of oneofs, and with explicit presence, are null when not set
+			return append(buf, "null"...), nil
+		}
+		return encodeValue(buf, msg.Get(fd))
+	}, nil
+}
+
+// compileUnion compiles an encoder of a union of null and the branch compiled by compileBranch, that wraps
+// values in an object keyed by the name of the branch. Values of messages that are not set are encoded
+// as null. Schemas that are not unions are compiled as they are.
+func (c *avroJSONEncoderCompiler) compileUnion(
+	schema avro.Schema,
+	isMessage bool,
+	compileBranch func(avro.Schema) (jsonValueEncoder, error),
+) (jsonValueEncoder, error) {
+	union, ok := schema.(avro.Union)
+	if !ok {
+		return compileBranch(schema)
+	}
+	branch, ok := singleBranch(union)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected union of null and one branch", errUnsupported)
+	}
+	encodeBranch, err := compileBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	prefix := append([]byte{'{'}, appendJSONString(nil, c.opts.unionBranchName(branch))...)
+	prefix = append(prefix, ':')
+	return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+		if isMessage && !value.Message().IsValid() {
+			return append(buf, "null"...), nil
+		}
+		buf = append(buf, prefix...)
+		buf, err := encodeBranch(buf, value)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, '}'), nil
+	}, nil
+}
+
+func (c *avroJSONEncoderCompiler) compileList(
+	schema avro.Schema,
+	fd protoreflect.FieldDescriptor,
+) (jsonValueEncoder, error) {
+	array, ok := schema.(avro.Array)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected array for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	encodeItem, err := c.compileValue(array.Items, fd, fd.Message())
+	if err != nil {
+		return nil, err
+	}
+	return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+		list := value.List()
+		buf = append(buf, '[')
+		for i := 0; i < list.Len(); i++ {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = encodeItem(buf, list.Get(i)); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	}, nil
+}
+
+func (c *avroJSONEncoderCompiler) compileMap(
+	schema avro.Schema,
+	fd protoreflect.FieldDescriptor,
+) (jsonValueEncoder, error) {
+	array, ok := schema.(avro.Array)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected array for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	entry, ok := c.definitions.deref(array.Items).(avro.Record)
+	if !ok || len(entry.Fields) != 2 || entry.Fields[0].Name != "key" || entry.Fields[1].Name != "value" {
+		return nil, fmt.Errorf("%w: expected map entry record for '%s'", errUnsupported, fd.Name())
+	}
+	encodeKey, err := c.compileValue(entry.Fields[0].Type, fd.MapKey(), nil)
+	if err != nil {
+		return nil, err
+	}
+	encodeMapValue, err := c.compileValue(entry.Fields[1].Type, fd.MapValue(), fd.MapValue().Message())
+	if err != nil {
+		return nil, err
+	}
+	return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+		mp := value.Map()
+		// keys are sorted, like in the entries of SchemaOptions.Encode
+		keys := make([]protoreflect.MapKey, 0, mp.Len())
+		mp.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, key)
+			return true
+		})
+		c.opts.sortMapKeys(keys)
+		buf = append(buf, '[')
+		for i, key := range keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			buf = append(buf, `{"key":`...)
+			if buf, err = encodeKey(buf, key.Value()); err != nil {
+				return nil, err
+			}
+			buf = append(buf, `,"value":`...)
+			if buf, err = encodeMapValue(buf, mp.Get(key)); err != nil {
+				return nil, err
+			}
+			buf = append(buf, '}')
+		}
+		return append(buf, ']'), nil
+	}, nil
+}
+
+// compileValue compiles an encoder of a single value of the field kind, or of a message of the message
+// descriptor when the field is nil.
+func (c *avroJSONEncoderCompiler) compileValue(
+	schema avro.Schema,
+	fd protoreflect.FieldDescriptor,
+	desc protoreflect.MessageDescriptor,
+) (jsonValueEncoder, error) {
+	if desc != nil && c.opts.isWKT(desc.FullName()) {
+		return c.compileWKT(schema, fd)
+	}
+	if union, ok := schema.(avro.Union); ok {
+		encodeUnion, err := c.compileUnion(union, desc != nil, func(branch avro.Schema) (jsonValueEncoder, error) {
+			return c.compileValue(branch, fd, desc)
+		})
+		if err != nil || desc != nil || fd.IsList() || c.opts.fixedSize(fd) == 0 {
+			return encodeUnion, err
+		}
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			if len(value.Bytes()) == 0 {
+				// fixed values can not be empty
+				return append(buf, "null"...), nil
+			}
+			return encodeUnion(buf, value)
+		}, nil
+	}
+	if desc != nil {
+		record, ok := c.definitions.deref(schema).(avro.Record)
+		if !ok {
+			return nil, fmt.Errorf("%w: expected record for '%s', got %T", errUnsupported, desc.FullName(), schema)
+		}
+		r, err := c.compileRecord(record, desc)
+		if err != nil {
+			return nil, err
+		}
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return r.encode(buf, value.Message())
+		}, nil
+	}
+	if fd.Kind() == protoreflect.EnumKind {
+		return c.compileEnum(fd)
+	}
+	if fixed, ok := c.definitions.deref(schema).(avro.Fixed); ok && fd.Kind() == protoreflect.BytesKind {
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			if err := checkFixedSize(fd, fixed.Size, value.Bytes()); err != nil {
+				return nil, err
+			}
+			return appendJSONCodePoints(buf, value.Bytes()), nil
+		}, nil
+	}
+	return compilePrimitive(schema, fd)
+}
+
+func (c *avroJSONEncoderCompiler) compileEnum(fd protoreflect.FieldDescriptor) (jsonValueEncoder, error) {
+	values := fd.Enum().Values()
+	if values.Len() == 0 {
+		return nil, fmt.Errorf("%w: enum without values for '%s'", errUnsupported, fd.Name())
+	}
+	symbols := make(map[protoreflect.EnumNumber][]byte, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		value := values.Get(i)
+		if _, ok := symbols[value.Number()]; !ok {
+			symbols[value.Number()] = appendJSONString(nil, c.opts.enumSymbol(value))
+		}
+	}
+	// numbers that are not values of the enum are encoded as the zero value
+	zero := symbols[0]
+	return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+		symbol, ok := symbols[value.Enum()]
+		if !ok {
+			symbol = zero
+		}
+		return append(buf, symbol...), nil
+	}, nil
+}
+
+func compilePrimitive(schema avro.Schema, fd protoreflect.FieldDescriptor) (jsonValueEncoder, error) {
+	primitive, ok := schema.(avro.Primitive)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected primitive for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sfixed32Kind, protoreflect.Sint32Kind,
+		protoreflect.Int64Kind, protoreflect.Sfixed64Kind, protoreflect.Sint64Kind:
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return strconv.AppendInt(buf, value.Int(), 10), nil
+		}, nil
+	case protoreflect.Fixed32Kind:
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return strconv.AppendInt(buf, int64(int32(value.Uint())), 10), nil
+		}, nil
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return strconv.AppendInt(buf, int64(value.Uint()), 10), nil
+		}, nil
+	case protoreflect.BoolKind:
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return strconv.AppendBool(buf, value.Bool()), nil
+		}, nil
+	case protoreflect.FloatKind:
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return appendJSONFloat(buf, value.Float(), 32), nil
+		}, nil
+	case protoreflect.DoubleKind:
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return appendJSONFloat(buf, value.Float(), 64), nil
+		}, nil
+	case protoreflect.StringKind:
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return appendJSONString(buf, value.String()), nil
+		}, nil
+	case protoreflect.BytesKind:
+		return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+			return appendJSONCodePoints(buf, value.Bytes()), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected %s for '%s' of kind %s", errUnsupported, primitive.Type, fd.Name(), fd.Kind())
+}
+
+// compileWKT compiles an encoder of a well-known type, or a message with a custom codec, that encodes
+// the Avro JSON encoding of the message with goavro.
+func (c *avroJSONEncoderCompiler) compileWKT(
+	schema avro.Schema,
+	fd protoreflect.FieldDescriptor,
+) (jsonValueEncoder, error) {
+	codec, branch, err := c.opts.newWKTCodec(c.definitions, schema, fd)
+	if err != nil {
+		return nil, err
+	}
+	return func(buf []byte, value protoreflect.Value) ([]byte, error) {
+		native, err := c.opts.messageJSON(value.Message(), 1, nil)
+		if err != nil {
+			return nil, err
+		}
+		if branch != "" {
+			native = unwrapUnion(native)
+		}
+		return c.opts.textualFromNative(codec, buf, native)
+	}, nil
+}
+
+// appendJSONFloat appends the float to the buffer, with NaN and infinities encoded like goavro does.
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, "null"...)
+	case math.IsInf(f, 1):
+		return append(buf, "1e999"...)
+	case math.IsInf(f, -1):
+		return append(buf, "-1e999"...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+// appendJSONString appends the string to the buffer as a JSON string.
+// Invalid UTF-8 is replaced by the Unicode replacement character.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, `�`...)
+		case r < utf8.RuneSelf:
+			buf = appendJSONASCII(buf, byte(r))
+		default:
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+// appendJSONCodePoints appends the bytes to the buffer as a JSON string of the code points 0-255,
+// as bytes are encoded in Avro JSON. Code points above 127 are escaped, since they would otherwise
+// be read back as their UTF-8 encoding by goavro.
+func appendJSONCodePoints(buf []byte, b []byte) []byte {
+	buf = append(buf, '"')
+	for _, c := range b {
+		if c < utf8.RuneSelf {
+			buf = appendJSONASCII(buf, c)
+			continue
+		}
+		buf = appendUnicodeEscape(buf, rune(c))
+	}
+	return append(buf, '"')
+}
+
+func appendJSONASCII(buf []byte, c byte) []byte {
+	switch c {
+	case '"', '\\':
+		return append(buf, '\\', c)
+	case '\n':
+		return append(buf, '\\', 'n')
+	case '\r':
+		return append(buf, '\\', 'r')
+	case '\t':
+		return append(buf, '\\', 't')
+	}
+	if c < 0x20 || c == 0x7f {
+		return appendUnicodeEscape(buf, rune(c))
+	}
+	return append(buf, c)
+}
+
+func appendUnicodeEscape(buf []byte, r rune) []byte {
+	const hex = "0123456789abcdef"
+	return append(buf, '\\', 'u', hex[r>>12&0xf], hex[r>>8&0xf], hex[r>>4&0xf], hex[r&0xf])
+}
@@ -0,0 +1,156 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestAvroJSONEncoder(t *testing.T) {
+	for _, tt := range append(
+		directDecodingTestCases(),
+		directDecodingTestCase{
+			name: "strings",
+			msg:  &library.Book{Name: "shelves/1/books/1", Title: "\"Tab\t\\ \x00 å ⚡🧙\"", Read: true},
+		},
+	) {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			desc := tt.msg.ProtoReflect().Descriptor()
+			schema, err := opts.InferSchema(desc)
+			assert.NilError(t, err)
+			encoder, err := opts.newAvroJSONEncoder(desc, schema)
+			assert.NilError(t, err)
+			got, err := encoder.encode(nil, tt.msg.ProtoReflect())
+			assert.NilError(t, err)
+
+			// the direct encoder encodes like goavro, up to the order of fields and escaping of strings
+			codec := newTestGoavroCodec(t, opts, tt.msg)
+			expected, err := opts.appendAvroJSON(codec, nil, tt.msg)
+			assert.NilError(t, err)
+			var gotValue, expectedValue interface{}
+			assert.NilError(t, json.Unmarshal(got, &gotValue))
+			assert.NilError(t, json.Unmarshal(expected, &expectedValue))
+			assert.DeepEqual(t, expectedValue, gotValue)
+
+			native, _, err := codec.NativeFromTextual(got)
+			assert.NilError(t, err)
+			decoded := tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, opts.decodeJSON(native, decoded))
+			assert.DeepEqual(t, tt.msg, decoded, protocmp.Transform())
+		})
+	}
+}
+
+func TestAvroJSONEncoder_Stream(t *testing.T) {
+	for _, opts := range []SchemaOptions{
+		{},
+		// encoded through goavro
+		{PreserveUnknownFields: true},
+	} {
+		var b bytes.Buffer
+		encoder, err := opts.NewAvroJSONEncoder((&library.Book{}).ProtoReflect().Descriptor(), &b)
+		assert.NilError(t, err)
+		books := []*library.Book{
+			{Name: "shelves/1/books/1", Title: "Harry Potter"},
+			{Name: "shelves/1/books/2", Author: "J. K. Rowling"},
+		}
+		for _, book := range books {
+			assert.NilError(t, encoder.Encode(book))
+		}
+		assert.ErrorContains(
+			t,
+			encoder.Encode(&library.Shelf{}),
+			"unexpected message 'google.example.library.v1.Shelf', expected 'google.example.library.v1.Book'",
+		)
+		lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+		assert.Equal(t, len(lines), len(books))
+		for i, line := range lines {
+			got := &library.Book{}
+			assert.NilError(t, opts.UnmarshalAvroJSON([]byte(line), got))
+			assert.DeepEqual(t, books[i], got, protocmp.Transform())
+		}
+	}
+}
+
+func TestAvroJSONEncoder_Concurrent(t *testing.T) {
+	var b bytes.Buffer
+	encoder, err := NewAvroJSONEncoder((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := encoder.Encode(&library.Book{Name: "shelves/1/books/1", Title: "Harry Potter"}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// messages are written one at a time, one per line
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	assert.Equal(t, 8*50, len(lines))
+	for _, line := range lines {
+		got := &library.Book{}
+		assert.NilError(t, SchemaOptions{}.UnmarshalAvroJSON([]byte(line), got))
+		assert.Equal(t, "shelves/1/books/1", got.GetName())
+	}
+}
+
+func TestAvroJSONEncoder_Floats(t *testing.T) {
+	var opts SchemaOptions
+	msg := &examplev1.ExampleScalars{Double: math.Inf(1), Float: float32(math.Inf(-1))}
+	desc := msg.ProtoReflect().Descriptor()
+	schema, err := opts.InferSchema(desc)
+	assert.NilError(t, err)
+	encoder, err := opts.newAvroJSONEncoder(desc, schema)
+	assert.NilError(t, err)
+	got, err := encoder.encode(nil, msg.ProtoReflect())
+	assert.NilError(t, err)
+	assert.Assert(t, bytes.Contains(got, []byte(`"double":{"double":1e999}`)), string(got))
+	assert.Assert(t, bytes.Contains(got, []byte(`"float":{"float":-1e999}`)), string(got))
+}
+
+func TestAvroJSONEncoder_Allocations(t *testing.T) {
+	var opts SchemaOptions
+	msg := &examplev1.ExampleMap{
+		StringToString: map[string]string{"a": "b", "c": "d"},
+		StringToNested: map[string]*examplev1.ExampleMap_Nested{
+			"a": {StringToString: map[string]string{"x": "y"}},
+		},
+		StringToEnum:   map[string]examplev1.ExampleMap_Enum{"a": examplev1.ExampleMap_ENUM_VALUE2},
+		Int32ToString:  map[int32]string{-1: "a", 1: "b"},
+		Int64ToString:  map[int64]string{1 << 40: "a"},
+		Uint32ToString: map[uint32]string{1 << 31: "a"},
+		BoolToString:   map[bool]string{true: "a", false: "b"},
+	}
+	direct, err := opts.NewAvroJSONEncoder(msg.ProtoReflect().Descriptor(), io.Discard)
+	assert.NilError(t, err)
+	codec := newTestGoavroCodec(t, opts, msg)
+	var buf []byte
+	directAllocs := testing.AllocsPerRun(100, func() {
+		if err := direct.Encode(msg); err != nil {
+			t.Fatal(err)
+		}
+	})
+	genericAllocs := testing.AllocsPerRun(100, func() {
+		if buf, err = opts.appendAvroJSON(codec, buf[:0], msg); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Logf("allocations per encode: direct %v, generic %v", directAllocs, genericAllocs)
+	assert.Assert(t, directAllocs*3 <= genericAllocs, "direct %v, generic %v", directAllocs, genericAllocs)
+}
@@ -0,0 +1,345 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"go.einride.tech/protobuf-avro/avro"
+)
+
+// AvroJSONMode is how values are represented in the JSON encoding of the Avro specification, where Avro
+// implementations differ, for interop with the readers and writers of other implementations.
+type AvroJSONMode int
+
+const (
+	// AvroJSONGoavro names the branches of unions of types with logical types by the type and the logical type,
+	// such as long.timestamp-micros, and encodes NaN and infinities as null, 1e999 and -1e999, like goavro.
+	AvroJSONGoavro AvroJSONMode = iota
+	// AvroJSONStrict names the branches of unions by the names of their types, as specified by Avro, such as
+	// long for timestamps, and fails to encode NaN and infinities, which JSON can not represent. Data with
+	// branches named otherwise, or with NaN encoded as null, fails to decode.
+	AvroJSONStrict
+	// AvroJSONJava names the branches of unions like AvroJSONStrict, and encodes NaN and infinities as the
+	// strings NaN, Infinity and -Infinity, like the Java implementation of Avro. Data encoded like goavro is
+	// decoded as well.
+	AvroJSONJava
+)
+
+// specBranchName returns the name of the branch of a union of the schema as specified by Avro, which is the
+// name of the type, without its logical type.
+func (o SchemaOptions) specBranchName(schema avro.Schema) string {
+	if primitive, ok := schema.(avro.Primitive); ok {
+		return string(primitive.Type)
+	}
+	return o.unionBranchName(schema)
+}
+
+// rewriteAvroJSON rewrites the Avro JSON data of the schema from the encoding of goavro to the encoding of
+// AvroJSONMode when encoding, and from the encoding of AvroJSONMode to the encoding of goavro when decoding,
+// which the encoders and decoders of the package are built on. Bytes and fixed values are rewritten with
+// their code points above 127 escaped, since goavro reads unescaped characters as their UTF-8 encoding. The
+// data is returned as it is with AvroJSONGoavro.
+func (o SchemaOptions) rewriteAvroJSON(schema avro.Schema, data []byte, encoding bool) ([]byte, error) {
+	if o.AvroJSONMode == AvroJSONGoavro {
+		return data, nil
+	}
+	r := avroJSONRewriter{
+		opts:        &o,
+		definitions: make(definitions),
+		decoder:     newJSONNumberDecoder(data),
+		buf:         make([]byte, 0, len(data)),
+		encoding:    encoding,
+	}
+	r.definitions.collect(schema)
+	if err := r.rewrite(schema); err != nil {
+		return nil, fmt.Errorf("rewrite avro json: %w", err)
+	}
+	if _, err := r.decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("rewrite avro json: unexpected data after value")
+	}
+	return r.buf, nil
+}
+
+// avroJSONRewriter rewrites Avro JSON data of a schema value by value, keeping the order of the fields of
+// records.
+type avroJSONRewriter struct {
+	opts        *SchemaOptions
+	definitions definitions
+	decoder     *json.Decoder
+	buf         []byte
+	// encoding rewrites data from the encoding of goavro, instead of to it.
+	encoding bool
+}
+
+func newJSONNumberDecoder(data []byte) *json.Decoder {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder
+}
+
+func (r *avroJSONRewriter) rewrite(schema avro.Schema) error {
+	switch s := r.definitions.deref(schema).(type) {
+	case avro.Union:
+		return r.rewriteUnion(s)
+	case avro.Record:
+		return r.rewriteRecord(s)
+	case avro.Array:
+		return r.rewriteArray(s)
+	case avro.Map:
+		return r.rewriteMap(s)
+	case avro.Fixed:
+		return r.rewriteBytes()
+	case avro.Primitive:
+		switch s.Type {
+		case avro.BytesType:
+			return r.rewriteBytes()
+		case avro.FloatType, avro.DoubleType:
+			return r.rewriteFloat()
+		}
+	}
+	return r.copyValue()
+}
+
+// rewriteUnion rewrites a union value, as null or an object keyed by the name of the branch. Values that are
+// not wrapped by the name of their branch are rewritten as they are with LenientUnions.
+func (r *avroJSONRewriter) rewriteUnion(union avro.Union) error {
+	if r.encoding || !r.opts.LenientUnions {
+		return r.rewriteWrapped(union)
+	}
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return err
+	}
+	nested := *r
+	nested.decoder = newJSONNumberDecoder(raw)
+	var err error
+	if branch, ok := singleBranch(union); ok && !r.isWrapped(union, raw) {
+		err = nested.rewrite(branch)
+	} else {
+		err = nested.rewriteWrapped(union)
+	}
+	r.buf = nested.buf
+	return err
+}
+
+func (r *avroJSONRewriter) isWrapped(union avro.Union, raw json.RawMessage) bool {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return true
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil || len(object) != 1 {
+		return false
+	}
+	for name := range object {
+		_, ok := r.branch(union, name)
+		return ok
+	}
+	return false
+}
+
+func (r *avroJSONRewriter) rewriteWrapped(union avro.Union) error {
+	token, err := r.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		r.buf = append(r.buf, "null"...)
+		return nil
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("expected union object, got %v", token)
+	}
+	name, err := readJSONString(r.decoder)
+	if err != nil {
+		return err
+	}
+	branch, ok := r.branch(union, name)
+	if !ok {
+		return fmt.Errorf("unexpected union branch %s", name)
+	}
+	if r.encoding {
+		name = r.opts.specBranchName(branch)
+	} else {
+		name = r.opts.unionBranchName(branch)
+	}
+	r.buf = appendJSONString(append(r.buf, '{'), name)
+	r.buf = append(r.buf, ':')
+	if err := r.rewrite(branch); err != nil {
+		return err
+	}
+	if err := expectDelim(r.decoder, '}'); err != nil {
+		return err
+	}
+	r.buf = append(r.buf, '}')
+	return nil
+}
+
+// branch returns the branch of the union of the name. Branches of types with logical types are named as
+// specified by Avro, and as goavro names them unless strict data is decoded.
+func (r *avroJSONRewriter) branch(union avro.Union, name string) (avro.Schema, bool) {
+	for _, branch := range union {
+		if branch == avro.Null() {
+			continue
+		}
+		if name == r.opts.specBranchName(branch) {
+			return branch, true
+		}
+		if name == r.opts.unionBranchName(branch) && (r.encoding || r.opts.AvroJSONMode != AvroJSONStrict) {
+			return branch, true
+		}
+	}
+	return nil, false
+}
+
+func (r *avroJSONRewriter) rewriteRecord(record avro.Record) error {
+	fields := make(map[string]avro.Schema, len(record.Fields))
+	for _, field := range record.Fields {
+		fields[field.Name] = field.Type
+	}
+	return r.rewriteObject(func(name string) error {
+		if schema, ok := fields[name]; ok {
+			return r.rewrite(schema)
+		}
+		return r.copyValue()
+	})
+}
+
+func (r *avroJSONRewriter) rewriteMap(mp avro.Map) error {
+	return r.rewriteObject(func(string) error {
+		return r.rewrite(mp.Values)
+	})
+}
+
+func (r *avroJSONRewriter) rewriteObject(rewriteValue func(name string) error) error {
+	if err := expectDelim(r.decoder, '{'); err != nil {
+		return err
+	}
+	r.buf = append(r.buf, '{')
+	for i := 0; r.decoder.More(); i++ {
+		name, err := readJSONString(r.decoder)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			r.buf = append(r.buf, ',')
+		}
+		r.buf = append(appendJSONString(r.buf, name), ':')
+		if err := rewriteValue(name); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if err := expectDelim(r.decoder, '}'); err != nil {
+		return err
+	}
+	r.buf = append(r.buf, '}')
+	return nil
+}
+
+func (r *avroJSONRewriter) rewriteArray(array avro.Array) error {
+	if err := expectDelim(r.decoder, '['); err != nil {
+		return err
+	}
+	r.buf = append(r.buf, '[')
+	for i := 0; r.decoder.More(); i++ {
+		if i > 0 {
+			r.buf = append(r.buf, ',')
+		}
+		if err := r.rewrite(array.Items); err != nil {
+			return err
+		}
+	}
+	if err := expectDelim(r.decoder, ']'); err != nil {
+		return err
+	}
+	r.buf = append(r.buf, ']')
+	return nil
+}
+
+func (r *avroJSONRewriter) rewriteBytes() error {
+	s, err := readJSONString(r.decoder)
+	if err != nil {
+		return err
+	}
+	b, err := bytesFromCodePoints(s)
+	if err != nil {
+		return err
+	}
+	r.buf = appendJSONCodePoints(r.buf, b)
+	return nil
+}
+
+// rewriteFloat rewrites a float or double value, where NaN and infinities are represented differently by
+// goavro and Java, and can not be represented in strict data.
+func (r *avroJSONRewriter) rewriteFloat() error {
+	token, err := r.decoder.Token()
+	if err != nil {
+		return err
+	}
+	var f float64
+	switch t := token.(type) {
+	case json.Number:
+		switch t {
+		case "1e999":
+			f = math.Inf(1)
+		case "-1e999":
+			f = math.Inf(-1)
+		default:
+			r.buf = append(r.buf, t...)
+			return nil
+		}
+	case nil:
+		if !r.encoding && r.opts.AvroJSONMode == AvroJSONStrict {
+			return fmt.Errorf("expected number, got %v", token)
+		}
+		f = math.NaN()
+	case string:
+		if r.encoding || r.opts.AvroJSONMode != AvroJSONJava {
+			return fmt.Errorf("expected number, got %q", t)
+		}
+		switch t {
+		case "NaN":
+			f = math.NaN()
+		case "Infinity":
+			f = math.Inf(1)
+		case "-Infinity":
+			f = math.Inf(-1)
+		default:
+			return fmt.Errorf("expected number, got %q", t)
+		}
+	default:
+		return fmt.Errorf("expected number, got %v", token)
+	}
+	if !r.encoding {
+		r.buf = appendJSONFloat(r.buf, f, 64)
+		return nil
+	}
+	if r.opts.AvroJSONMode == AvroJSONStrict {
+		return fmt.Errorf("%v can not be represented in Avro JSON", f)
+	}
+	switch {
+	case math.IsNaN(f):
+		r.buf = appendJSONString(r.buf, "NaN")
+	case f > 0:
+		r.buf = appendJSONString(r.buf, "Infinity")
+	default:
+		r.buf = appendJSONString(r.buf, "-Infinity")
+	}
+	return nil
+}
+
+func (r *avroJSONRewriter) copyValue() error {
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return err
+	}
+	b := bytes.NewBuffer(r.buf)
+	if err := json.Compact(b, raw); err != nil {
+		return err
+	}
+	r.buf = b.Bytes()
+	return nil
+}
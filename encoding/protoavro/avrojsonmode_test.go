@@ -0,0 +1,133 @@
+package protoavro
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestAvroJSONMode(t *testing.T) {
+	timestamp := &examplev1.ExampleTimestamp{Timestamp: timestamppb.New(time.Unix(1, 0))}
+
+	t.Run("union branch names", func(t *testing.T) {
+		for _, tt := range []struct {
+			mode     AvroJSONMode
+			expected string
+		}{
+			{mode: AvroJSONGoavro, expected: `{"timestamp":{"long.timestamp-micros":1000000}}`},
+			{mode: AvroJSONStrict, expected: `{"timestamp":{"long":1000000}}`},
+			{mode: AvroJSONJava, expected: `{"timestamp":{"long":1000000}}`},
+		} {
+			// directly, and through goavro
+			for _, opts := range []SchemaOptions{
+				{AvroJSONMode: tt.mode, OmitRootElement: true},
+				{AvroJSONMode: tt.mode, OmitRootElement: true, ValidateEncoding: true},
+			} {
+				data, err := opts.MarshalAvroJSON(timestamp)
+				assert.NilError(t, err)
+				assert.Equal(t, tt.expected, string(data))
+				decoded := &examplev1.ExampleTimestamp{}
+				assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+				assert.DeepEqual(t, timestamp, decoded, protocmp.Transform())
+			}
+		}
+	})
+
+	t.Run("encoder", func(t *testing.T) {
+		opts := SchemaOptions{AvroJSONMode: AvroJSONStrict, OmitRootElement: true}
+		var b bytes.Buffer
+		encoder, err := opts.NewAvroJSONEncoder(timestamp.ProtoReflect().Descriptor(), &b)
+		assert.NilError(t, err)
+		assert.NilError(t, encoder.Encode(timestamp))
+		assert.NilError(t, encoder.Encode(timestamp))
+		assert.Equal(t, strings.Repeat(`{"timestamp":{"long":1000000}}`+"\n", 2), b.String())
+	})
+
+	t.Run("decode names of goavro", func(t *testing.T) {
+		data := []byte(`{"timestamp":{"long.timestamp-micros":1000000}}`)
+		decoded := &examplev1.ExampleTimestamp{}
+		opts := SchemaOptions{AvroJSONMode: AvroJSONJava, OmitRootElement: true}
+		assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+		assert.DeepEqual(t, timestamp, decoded, protocmp.Transform())
+		opts.AvroJSONMode = AvroJSONStrict
+		err := opts.UnmarshalAvroJSON(data, decoded)
+		assert.ErrorContains(t, err, "timestamp: unexpected union branch long.timestamp-micros")
+	})
+
+	t.Run("NaN and infinities", func(t *testing.T) {
+		msg := &examplev1.ExampleScalars{Double: math.NaN(), Float: float32(math.Inf(-1))}
+		for _, tt := range []struct {
+			mode   AvroJSONMode
+			double string
+			float  string
+		}{
+			{mode: AvroJSONGoavro, double: `"double":null`, float: `"float":-1e999`},
+			{mode: AvroJSONJava, double: `"double":"NaN"`, float: `"float":"-Infinity"`},
+		} {
+			opts := SchemaOptions{AvroJSONMode: tt.mode}
+			data, err := opts.MarshalAvroJSON(msg)
+			assert.NilError(t, err)
+			assert.Assert(t, bytes.Contains(data, []byte(tt.double)), string(data))
+			assert.Assert(t, bytes.Contains(data, []byte(tt.float)), string(data))
+			decoded := &examplev1.ExampleScalars{}
+			assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+			assert.Assert(t, math.IsNaN(decoded.GetDouble()))
+			assert.Assert(t, math.IsInf(float64(decoded.GetFloat()), -1))
+		}
+		_, err := SchemaOptions{AvroJSONMode: AvroJSONStrict}.MarshalAvroJSON(msg)
+		assert.ErrorContains(t, err, "can not be represented in Avro JSON")
+	})
+
+	t.Run("unescaped bytes", func(t *testing.T) {
+		// the Java implementation writes the code points of bytes unescaped
+		msg := &examplev1.ExampleBytes{Bytes: []byte{0x00, 0xc8, 'a'}}
+		data := []byte("{\"bytes\":{\"bytes\":\"\\u0000\u00c8a\"}}")
+		for _, opts := range []SchemaOptions{
+			{OmitRootElement: true},
+			{OmitRootElement: true, PreserveUnknownFields: true},
+			{OmitRootElement: true, AvroJSONMode: AvroJSONJava},
+			{OmitRootElement: true, AvroJSONMode: AvroJSONJava, PreserveUnknownFields: true},
+		} {
+			decoded := &examplev1.ExampleBytes{}
+			assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+			assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+		}
+	})
+
+	t.Run("lenient unions", func(t *testing.T) {
+		opts := SchemaOptions{AvroJSONMode: AvroJSONStrict, OmitRootElement: true, LenientUnions: true}
+		for _, data := range []string{
+			`{"timestamp":1000000}`,
+			`{"timestamp":{"long":1000000}}`,
+		} {
+			decoded := &examplev1.ExampleTimestamp{}
+			assert.NilError(t, opts.UnmarshalAvroJSON([]byte(data), decoded))
+			assert.DeepEqual(t, timestamp, decoded, protocmp.Transform())
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, msg := range []proto.Message{
+			&examplev1.ExampleScalars{Double: 1.5, Bytes: []byte{0xff, 0x00}, String_: "ü"},
+			&examplev1.ExampleWrappers{BytesValue: wrapperspb.Bytes([]byte{0xc8})},
+		} {
+			for _, mode := range []AvroJSONMode{AvroJSONStrict, AvroJSONJava} {
+				opts := SchemaOptions{AvroJSONMode: mode, Deterministic: true}
+				data, err := opts.MarshalAvroJSON(msg)
+				assert.NilError(t, err)
+				decoded := msg.ProtoReflect().New().Interface()
+				assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+				assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+			}
+		}
+	})
+}
@@ -0,0 +1,321 @@
+package protoavro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// BatchError is returned by batch operations when one or more messages of the batch fail.
+type BatchError struct {
+	// Errors holds the errors of the failed messages, ordered by index.
+	Errors []*MessageError
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", e.Errors[0].Error(), len(e.Errors)-1)
+}
+
+// MessageError is the error of a single message in a batch.
+type MessageError struct {
+	// Index of the message in the batch.
+	Index int
+	// Err is the error of the message.
+	Err error
+}
+
+func (e *MessageError) Error() string {
+	return fmt.Sprintf("message %d: %v", e.Index, e.Err)
+}
+
+func (e *MessageError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalBatch encodes messages, with default SchemaOptions, in Avro binary format.
+// See SchemaOptions.MarshalBatch.
+func MarshalBatch(messages []proto.Message) ([][]byte, error) {
+	return SchemaOptions{}.MarshalBatch(messages)
+}
+
+// MarshalBatch encodes messages in Avro binary format, using the schema inferred from the first message.
+// All messages must be of the same type. Messages are encoded in parallel by SchemaOptions.Workers goroutines.
+// If any message fails to encode, a *BatchError is returned with the error of each failed message.
+func (o SchemaOptions) MarshalBatch(messages []proto.Message) ([][]byte, error) {
+	return o.MarshalBatchContext(context.Background(), messages)
+}
+
+// MarshalBatchContext encodes messages in Avro binary format, like MarshalBatch.
+// Encoding stops, and the context error is returned, when the context is done.
+func (o SchemaOptions) MarshalBatchContext(ctx context.Context, messages []proto.Message) (_ [][]byte, err error) {
+	ctx, end := o.startSpan(ctx, "protoavro.MarshalBatch")
+	defer func() { end(err) }()
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	desc := messages[0].ProtoReflect().Descriptor()
+	schema, err := o.InferSchema(desc)
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new codec: %w", err)
+	}
+	result := make([][]byte, len(messages))
+	if err := o.encodeBatch(ctx, desc, messages, func(i int, data interface{}) error {
+		b, err := codec.BinaryFromNative(nil, data)
+		if err != nil {
+			return fmt.Errorf("binary from native: %w", err)
+		}
+		result[i] = b
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	o.recordsEncoded(ctx, len(messages))
+	return result, nil
+}
+
+// MarshalBatch encodes messages in parallel, and writes them to the writer as a single block.
+// If any message fails to encode, no messages are written and a *BatchError is returned with the error of
+// each failed message.
+func (m *Marshaler) MarshalBatch(messages []proto.Message) error {
+	return m.MarshalBatchContext(context.Background(), messages)
+}
+
+// MarshalBatchContext encodes messages in parallel, and writes them to the writer as a single block,
+// like MarshalBatch. No messages are written if the context is done before all messages are encoded.
+func (m *Marshaler) MarshalBatchContext(ctx context.Context, messages []proto.Message) (err error) {
+	ctx, end := m.opts.startSpan(ctx, "protoavro.Marshaler.MarshalBatch")
+	defer func() { end(err) }()
+	data := make([]interface{}, len(messages))
+	if err := m.opts.encodeBatch(ctx, m.desc, messages, func(i int, d interface{}) error {
+		data[i] = d
+		return nil
+	}); err != nil {
+		return err
+	}
+	return m.write(ctx, messages, data)
+}
+
+// encodeBatch encodes messages across a pool of workers, and calls fn with the Avro JSON encoding of each message.
+func (o SchemaOptions) encodeBatch(
+	ctx context.Context,
+	desc protoreflect.MessageDescriptor,
+	messages []proto.Message,
+	fn func(i int, data interface{}) error,
+) error {
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(messages) {
+		workers = len(messages)
+	}
+	errs := make([]error, len(messages))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = o.encodeBatchMessage(desc, messages[i], i, fn)
+			}
+		}()
+	}
+SendLoop:
+	for i := range messages {
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			break SendLoop
+		}
+	}
+	close(indices)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var batchErr BatchError
+	for i, err := range errs {
+		if err != nil {
+			batchErr.Errors = append(batchErr.Errors, &MessageError{Index: i, Err: err})
+		}
+	}
+	if len(batchErr.Errors) > 0 {
+		return &batchErr
+	}
+	return nil
+}
+
+func (o SchemaOptions) encodeBatchMessage(
+	desc protoreflect.MessageDescriptor,
+	message proto.Message,
+	i int,
+	fn func(i int, data interface{}) error,
+) error {
+	if got := message.ProtoReflect().Descriptor().FullName(); got != desc.FullName() {
+		return fmt.Errorf("expected message '%s' but got '%s'", desc.FullName(), got)
+	}
+	data, err := o.encodeJSON(message)
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	return fn(i, data)
+}
+
+// DecodeFailure is a datum of a batch that failed to decode.
+type DecodeFailure struct {
+	// Index of the datum in the batch.
+	Index int
+	// Datum is the datum that failed to decode, for example to be sent to a dead-letter queue.
+	Datum []byte
+	// Err is the cause of the failure.
+	Err error
+}
+
+// UnmarshalBatchLenient decodes datums, with default SchemaOptions, from Avro binary format.
+// See SchemaOptions.UnmarshalBatchLenient.
+func UnmarshalBatchLenient(
+	datums [][]byte,
+	newMsg func() proto.Message,
+) (ok []proto.Message, failed []DecodeFailure) {
+	return SchemaOptions{}.UnmarshalBatchLenient(datums, newMsg)
+}
+
+// UnmarshalBatchLenient decodes datums in Avro binary format, as encoded by MarshalBatch, into messages returned
+// by newMsg. Datums that fail to decode do not fail the batch: the decoded messages are returned in the order
+// of the batch, and the failed datums are returned with their index and the cause of the failure, ordered by
+// index. Datums are decoded in parallel by SchemaOptions.Workers goroutines, which call newMsg concurrently.
+func (o SchemaOptions) UnmarshalBatchLenient(
+	datums [][]byte,
+	newMsg func() proto.Message,
+) (ok []proto.Message, failed []DecodeFailure) {
+	if len(datums) == 0 {
+		return nil, nil
+	}
+	ctx := context.Background()
+	desc := newMsg().ProtoReflect().Descriptor()
+	decode, err := o.datumDecoder(desc)
+	if err != nil {
+		failed = make([]DecodeFailure, 0, len(datums))
+		for i, datum := range datums {
+			failed = append(failed, DecodeFailure{Index: i, Datum: datum, Err: err})
+		}
+		return nil, failed
+	}
+	messages := make([]proto.Message, len(datums))
+	errs := make([]error, len(datums))
+	o.forEachParallel(len(datums), func(i int) {
+		message := newMsg()
+		if got := message.ProtoReflect().Descriptor().FullName(); got != desc.FullName() {
+			errs[i] = fmt.Errorf("expected message '%s' but got '%s'", desc.FullName(), got)
+			return
+		}
+		if errs[i] = decode(datums[i], message); errs[i] == nil {
+			messages[i] = message
+		}
+	})
+	ok = make([]proto.Message, 0, len(datums))
+	for i, message := range messages {
+		if errs[i] != nil {
+			o.decodeError(ctx, decodeErrorTypeDecode, errs[i])
+			failed = append(failed, DecodeFailure{Index: i, Datum: datums[i], Err: errs[i]})
+			continue
+		}
+		ok = append(ok, message)
+	}
+	return ok, failed
+}
+
+// datumDecoder returns a function that decodes a datum in Avro binary format into a message of desc.
+func (o SchemaOptions) datumDecoder(
+	desc protoreflect.MessageDescriptor,
+) (func(datum []byte, message proto.Message) error, error) {
+	schema, err := o.InferSchema(desc)
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	decoder, err := o.newBinaryDecoder(desc, schema)
+	if err == nil {
+		return func(datum []byte, message proto.Message) error {
+			if err := o.checkRecordBytes(len(datum)); err != nil {
+				return err
+			}
+			if err := decoder.decode(datum, message.ProtoReflect()); err != nil {
+				return fmt.Errorf("native from binary: %w", err)
+			}
+			return nil
+		}, nil
+	}
+	if !errors.Is(err, errUnsupported) {
+		return nil, fmt.Errorf("new decoder: %w", err)
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new codec: %w", err)
+	}
+	return func(datum []byte, message proto.Message) error {
+		if err := o.checkRecordBytes(len(datum)); err != nil {
+			return err
+		}
+		data, rest, err := codec.NativeFromBinary(datum)
+		if err != nil {
+			return fmt.Errorf("native from binary: %w", err)
+		}
+		if err := checkTrailingBytes(len(rest)); err != nil {
+			return fmt.Errorf("native from binary: %w", err)
+		}
+		if err := o.decodeJSON(data, message); err != nil {
+			return fmt.Errorf("decode message: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// forEachParallel calls fn with the indices 0 to n-1 across a pool of SchemaOptions.Workers goroutines.
+func (o SchemaOptions) forEachParallel(n int, fn func(i int)) {
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
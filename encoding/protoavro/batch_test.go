@@ -0,0 +1,119 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func books(n int) []proto.Message {
+	msgs := make([]proto.Message, 0, n)
+	for i := 0; i < n; i++ {
+		msgs = append(msgs, &library.Book{
+			Name:  fmt.Sprintf("shelves/1/books/%d", i),
+			Title: fmt.Sprintf("Book %d", i),
+		})
+	}
+	return msgs
+}
+
+func Test_MarshalBatch(t *testing.T) {
+	msgs := books(100)
+	encoded, err := protoavro.SchemaOptions{Workers: 4}.MarshalBatch(msgs)
+	assert.NilError(t, err)
+	assert.Equal(t, len(encoded), len(msgs))
+
+	schema, err := protoavro.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	for i, b := range encoded {
+		native, _, err := codec.NativeFromBinary(b)
+		assert.NilError(t, err)
+		var got library.Book
+		assert.NilError(t, protoavro.SchemaOptions{}.Decode(native, &got))
+		assert.DeepEqual(t, msgs[i], &got, protocmp.Transform())
+	}
+}
+
+func Test_MarshalBatch_MessageErrors(t *testing.T) {
+	msgs := books(10)
+	msgs[3] = &library.Shelf{Name: "shelves/1"}
+	msgs[7] = &library.Shelf{Name: "shelves/2"}
+	_, err := protoavro.MarshalBatch(msgs)
+	var batchErr *protoavro.BatchError
+	assert.Assert(t, errors.As(err, &batchErr))
+	assert.Equal(t, len(batchErr.Errors), 2)
+	assert.Equal(t, batchErr.Errors[0].Index, 3)
+	assert.Equal(t, batchErr.Errors[1].Index, 7)
+	assert.ErrorContains(t, err, "message 3: expected message 'google.example.library.v1.Book'")
+}
+
+func Test_Marshaler_MarshalBatch(t *testing.T) {
+	msgs := books(100)
+	var b bytes.Buffer
+	marshaler, err := protoavro.SchemaOptions{Workers: 4}.NewMarshaler(msgs[0].ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.MarshalBatch(msgs))
+
+	unmarshaler, err := protoavro.NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	got := make([]proto.Message, 0, len(msgs))
+	for unmarshaler.Scan() {
+		var msg library.Book
+		assert.NilError(t, unmarshaler.Unmarshal(&msg))
+		got = append(got, &msg)
+	}
+	assert.DeepEqual(t, msgs, got, protocmp.Transform())
+}
+
+func Test_UnmarshalBatchLenient(t *testing.T) {
+	msgs := books(100)
+	encoded, err := protoavro.MarshalBatch(msgs)
+	assert.NilError(t, err)
+	// datums truncated by a bad record
+	encoded[3] = encoded[3][:len(encoded[3])-2]
+	encoded[42] = []byte{0x02, 0x02}
+	newBook := func() proto.Message { return &library.Book{} }
+	for _, opts := range []protoavro.SchemaOptions{
+		{Workers: 4},
+		// decoding through goavro
+		{Workers: 4, PreserveUnknownFields: true},
+	} {
+		ok, failed := opts.UnmarshalBatchLenient(encoded, newBook)
+		expected := append(append(append([]proto.Message{}, msgs[:3]...), msgs[4:42]...), msgs[43:]...)
+		assert.DeepEqual(t, expected, ok, protocmp.Transform())
+		assert.Equal(t, len(failed), 2)
+		assert.Equal(t, failed[0].Index, 3)
+		assert.DeepEqual(t, failed[0].Datum, encoded[3])
+		assert.ErrorContains(t, failed[0].Err, "native from binary")
+		assert.Equal(t, failed[1].Index, 42)
+	}
+}
+
+func Test_UnmarshalBatchLenient_UnexpectedMessage(t *testing.T) {
+	encoded, err := protoavro.MarshalBatch(books(2))
+	assert.NilError(t, err)
+	var n int
+	ok, failed := protoavro.SchemaOptions{Workers: 1}.UnmarshalBatchLenient(encoded, func() proto.Message {
+		n++
+		if n == 3 {
+			return &library.Shelf{}
+		}
+		return &library.Book{}
+	})
+	assert.Equal(t, len(ok), 1)
+	assert.Equal(t, len(failed), 1)
+	assert.ErrorContains(t, failed[0].Err, "expected message 'google.example.library.v1.Book' but got")
+}
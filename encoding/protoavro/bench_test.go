@@ -0,0 +1,54 @@
+package protoavro_test
+
+import (
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/protoavrotest"
+	publicv1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/bigquery/public/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func BenchmarkFixtures(b *testing.B) {
+	protoavrotest.Benchmark(b, protoavro.SchemaOptions{}, protoavrotest.Fixtures()...)
+}
+
+func BenchmarkCodec(b *testing.B) {
+	for _, fixture := range protoavrotest.Fixtures() {
+		switch msg := fixture.Message.(type) {
+		case *library.Book:
+			benchmarkCodec(b, fixture.Name, msg)
+		case *publicv1.DogecoinTransaction:
+			benchmarkCodec(b, fixture.Name, msg)
+		}
+	}
+}
+
+func benchmarkCodec[T proto.Message](b *testing.B, name string, message T) {
+	b.Helper()
+	codec, err := protoavro.NewCodec[T](protoavro.SchemaOptions{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run(name+"/Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := codec.Marshal(message); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	data, err := codec.Marshal(message)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run(name+"/Unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := codec.Unmarshal(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,3 @@
+// Package bigqueryavro loads protobuf messages into BigQuery through Avro, and maps the Avro schemas of
+// protobuf messages to the BigQuery table schemas that Avro files are loaded as.
+package bigqueryavro
@@ -0,0 +1,132 @@
+package bigqueryavro
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/proto"
+)
+
+// Storage is the part of Cloud Storage used by a Loader, typically implemented by an adapter of the Cloud
+// Storage client.
+type Storage interface {
+	// NewWriter returns a writer of the object in the bucket, which is created when the writer is closed.
+	NewWriter(ctx context.Context, bucket string, object string) (io.WriteCloser, error)
+	// Delete deletes the object in the bucket.
+	Delete(ctx context.Context, bucket string, object string) error
+}
+
+// LoadJob is a BigQuery load job of an Avro file in Cloud Storage.
+type LoadJob struct {
+	// SourceURI is the Cloud Storage URI of the Avro file, for example "gs://bucket/object.avro".
+	SourceURI string
+	// Table is the ID of the destination table, for example "project.dataset.table".
+	Table string
+	// Schema is the schema of the destination table, for example to create the table before loading.
+	// BigQuery loads Avro files with Avro logical types enabled as columns of the schema.
+	Schema TableSchema
+}
+
+// Jobs is the part of BigQuery used by a Loader, typically implemented by an adapter of the BigQuery client
+// that runs a load job of Avro files, with Avro logical types enabled, and waits for its completion.
+type Jobs interface {
+	// Load runs the load job and waits for its completion.
+	Load(ctx context.Context, job LoadJob) error
+}
+
+// LoaderOptions configures a Loader.
+type LoaderOptions struct {
+	// SchemaOptions are the options of the schema inferred for messages, and of their encoding.
+	SchemaOptions protoavro.SchemaOptions
+	// Bucket is the Cloud Storage bucket that temporary Avro files are written to.
+	Bucket string
+	// ObjectPrefix is the prefix of the names of temporary Avro files, for example "tmp/bigquery/".
+	ObjectPrefix string
+	// KeepObjects keeps the temporary Avro files after load jobs, which are deleted by default.
+	KeepObjects bool
+}
+
+// Loader loads protobuf messages into BigQuery tables, through temporary Avro files in Cloud Storage.
+type Loader struct {
+	storage Storage
+	jobs    Jobs
+	opts    LoaderOptions
+}
+
+// NewLoader returns a new loader that writes temporary Avro files to the storage and loads them with the jobs.
+func NewLoader(storage Storage, jobs Jobs, opts LoaderOptions) *Loader {
+	return &Loader{storage: storage, jobs: jobs, opts: opts}
+}
+
+// Load appends the messages to the table, for example "project.dataset.table", in one load job.
+// The messages must be of the same type. The messages are written to a temporary Avro file in Cloud Storage,
+// which is deleted after the load job unless KeepObjects is set.
+func (l *Loader) Load(ctx context.Context, table string, messages []proto.Message) (err error) {
+	if len(messages) == 0 {
+		return nil
+	}
+	descriptor := messages[0].ProtoReflect().Descriptor()
+	schema, err := l.opts.SchemaOptions.InferSchema(descriptor)
+	if err != nil {
+		return fmt.Errorf("load: infer schema: %w", err)
+	}
+	tableSchema, err := InferTableSchema(schema)
+	if err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+	object, err := l.objectName()
+	if err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+	w, err := l.storage.NewWriter(ctx, l.opts.Bucket, object)
+	if err != nil {
+		return fmt.Errorf("load: new writer: %w", err)
+	}
+	if err := l.write(w, messages); err != nil {
+		return fmt.Errorf("load: write gs://%s/%s: %w", l.opts.Bucket, object, err)
+	}
+	if !l.opts.KeepObjects {
+		defer func() {
+			if errDelete := l.storage.Delete(ctx, l.opts.Bucket, object); errDelete != nil {
+				err = errors.Join(err, fmt.Errorf("load: delete gs://%s/%s: %w", l.opts.Bucket, object, errDelete))
+			}
+		}()
+	}
+	job := LoadJob{
+		SourceURI: "gs://" + l.opts.Bucket + "/" + object,
+		Table:     table,
+		Schema:    tableSchema,
+	}
+	if err := l.jobs.Load(ctx, job); err != nil {
+		return fmt.Errorf("load: %s: %w", table, err)
+	}
+	return nil
+}
+
+// write writes the messages to the writer as an Avro file, and closes the writer.
+func (l *Loader) write(w io.WriteCloser, messages []proto.Message) error {
+	marshaler, err := l.opts.SchemaOptions.NewMarshaler(messages[0].ProtoReflect().Descriptor(), w)
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err := marshaler.Marshal(messages...); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// objectName returns a unique name of a temporary Avro file.
+func (l *Loader) objectName() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("object name: %w", err)
+	}
+	return l.opts.ObjectPrefix + hex.EncodeToString(b[:]) + ".avro", nil
+}
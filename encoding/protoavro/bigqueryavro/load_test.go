@@ -0,0 +1,112 @@
+package bigqueryavro_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/bigqueryavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+// fakeStorage is an in-memory Cloud Storage.
+type fakeStorage struct {
+	objects map[string][]byte
+	deleted []string
+}
+
+func (s *fakeStorage) NewWriter(_ context.Context, bucket string, object string) (io.WriteCloser, error) {
+	return &fakeObjectWriter{storage: s, name: bucket + "/" + object}, nil
+}
+
+func (s *fakeStorage) Delete(_ context.Context, bucket string, object string) error {
+	s.deleted = append(s.deleted, bucket+"/"+object)
+	delete(s.objects, bucket+"/"+object)
+	return nil
+}
+
+type fakeObjectWriter struct {
+	bytes.Buffer
+	storage *fakeStorage
+	name    string
+}
+
+func (w *fakeObjectWriter) Close() error {
+	w.storage.objects[w.name] = w.Bytes()
+	return nil
+}
+
+// fakeJobs loads the Avro files of load jobs from a fakeStorage.
+type fakeJobs struct {
+	storage *fakeStorage
+	jobs    []bigqueryavro.LoadJob
+	books   []*library.Book
+	err     error
+}
+
+func (j *fakeJobs) Load(_ context.Context, job bigqueryavro.LoadJob) error {
+	j.jobs = append(j.jobs, job)
+	if j.err != nil {
+		return j.err
+	}
+	data, ok := j.storage.objects[strings.TrimPrefix(job.SourceURI, "gs://")]
+	if !ok {
+		return errors.New("source not found")
+	}
+	unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for unmarshaler.Scan() {
+		var book library.Book
+		if err := unmarshaler.Unmarshal(&book); err != nil {
+			return err
+		}
+		j.books = append(j.books, &book)
+	}
+	return unmarshaler.Err()
+}
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	books := []*library.Book{
+		{Name: "shelves/1/books/1", Title: "Harry Potter"},
+		{Name: "shelves/1/books/2", Title: "The Hobbit"},
+	}
+	messages := []proto.Message{books[0], books[1]}
+	storage := &fakeStorage{objects: map[string][]byte{}}
+	jobs := &fakeJobs{storage: storage}
+	loader := bigqueryavro.NewLoader(storage, jobs, bigqueryavro.LoaderOptions{Bucket: "bucket", ObjectPrefix: "tmp/"})
+	assert.NilError(t, loader.Load(ctx, "project.dataset.books", messages))
+	assert.DeepEqual(t, books, jobs.books, protocmp.Transform())
+	assert.Equal(t, 1, len(jobs.jobs))
+	job := jobs.jobs[0]
+	assert.Equal(t, "project.dataset.books", job.Table)
+	assert.Assert(t, strings.HasPrefix(job.SourceURI, "gs://bucket/tmp/"))
+	assert.Assert(t, strings.HasSuffix(job.SourceURI, ".avro"))
+	assert.Equal(t, 4, len(job.Schema.Fields))
+	// temporary files are deleted
+	assert.DeepEqual(t, []string{strings.TrimPrefix(job.SourceURI, "gs://")}, storage.deleted)
+	assert.Equal(t, 0, len(storage.objects))
+	// temporary files are deleted when load jobs fail
+	jobs.err = errors.New("table not found")
+	err := loader.Load(ctx, "project.dataset.books", messages)
+	assert.ErrorContains(t, err, "load: project.dataset.books: table not found")
+	assert.Equal(t, 2, len(storage.deleted))
+	// temporary files are kept with KeepObjects
+	jobs.err = nil
+	loader = bigqueryavro.NewLoader(storage, jobs, bigqueryavro.LoaderOptions{Bucket: "bucket", KeepObjects: true})
+	assert.NilError(t, loader.Load(ctx, "project.dataset.books", messages))
+	assert.Equal(t, 1, len(storage.objects))
+	assert.Equal(t, 2, len(storage.deleted))
+	// no messages are no load job
+	assert.NilError(t, loader.Load(ctx, "project.dataset.books", nil))
+	assert.Equal(t, 3, len(jobs.jobs))
+}
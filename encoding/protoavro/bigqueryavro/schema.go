@@ -0,0 +1,267 @@
+package bigqueryavro
+
+import (
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+)
+
+// FieldType is the type of a BigQuery column.
+type FieldType string
+
+// BigQuery column types.
+const (
+	FieldTypeString     FieldType = "STRING"
+	FieldTypeBytes      FieldType = "BYTES"
+	FieldTypeInteger    FieldType = "INTEGER"
+	FieldTypeFloat      FieldType = "FLOAT"
+	FieldTypeBoolean    FieldType = "BOOLEAN"
+	FieldTypeTimestamp  FieldType = "TIMESTAMP"
+	FieldTypeDate       FieldType = "DATE"
+	FieldTypeTime       FieldType = "TIME"
+	FieldTypeNumeric    FieldType = "NUMERIC"
+	FieldTypeBigNumeric FieldType = "BIGNUMERIC"
+	FieldTypeRecord     FieldType = "RECORD"
+)
+
+// FieldMode is the mode of a BigQuery column.
+type FieldMode string
+
+// BigQuery column modes.
+const (
+	FieldModeNullable FieldMode = "NULLABLE"
+	FieldModeRequired FieldMode = "REQUIRED"
+	FieldModeRepeated FieldMode = "REPEATED"
+)
+
+// TableSchema is the schema of a BigQuery table. The JSON encoding of a TableSchema is the TableSchema
+// resource of the BigQuery API, and the JSON encoding of its fields is the schema file accepted by the bq tool.
+type TableSchema struct {
+	Fields []FieldSchema `json:"fields"`
+}
+
+// FieldSchema is the schema of a BigQuery column.
+type FieldSchema struct {
+	Name        string        `json:"name"`
+	Type        FieldType     `json:"type"`
+	Mode        FieldMode     `json:"mode,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Fields      []FieldSchema `json:"fields,omitempty"`
+	// Precision is the maximum number of digits of NUMERIC and BIGNUMERIC columns.
+	Precision int `json:"precision,omitempty,string"`
+	// Scale is the number of digits to the right of the decimal point of NUMERIC and BIGNUMERIC columns.
+	Scale int `json:"scale,omitempty,string"`
+}
+
+// InferTableSchema returns the schema of the BigQuery table that Avro files of the schema are loaded as,
+// with Avro logical types enabled, according to the Avro conversions of BigQuery.
+// Nullable types are NULLABLE columns, arrays are REPEATED columns, and maps are REPEATED records of a key and
+// a value. Records and enums are RECORD and STRING columns, and decimals are NUMERIC or BIGNUMERIC columns by
+// their precision and scale. The schema must be a record, or a nullable record, without recursive records or
+// unions of more than one non-null type, which BigQuery does not support.
+func InferTableSchema(schema avro.Schema) (TableSchema, error) {
+	c := tableSchemaConverter{definitions: make(map[string]avro.Schema)}
+	root, ok := c.nonNull(schema)
+	if !ok {
+		return TableSchema{}, fmt.Errorf("infer table schema: unsupported root union")
+	}
+	record, ok := c.deref(root, "").(avro.Record)
+	if !ok {
+		return TableSchema{}, fmt.Errorf("infer table schema: root schema is not a record")
+	}
+	fields, err := c.recordFields(record, "", nil)
+	if err != nil {
+		return TableSchema{}, fmt.Errorf("infer table schema: %w", err)
+	}
+	return TableSchema{Fields: fields}, nil
+}
+
+// tableSchemaConverter converts Avro schemas to BigQuery table schemas.
+type tableSchemaConverter struct {
+	// definitions holds the named types defined so far by full name.
+	definitions map[string]avro.Schema
+}
+
+func (c tableSchemaConverter) recordFields(
+	record avro.Record,
+	namespace string,
+	parents []string,
+) ([]FieldSchema, error) {
+	if record.Namespace != "" {
+		namespace = record.Namespace
+	}
+	name := joinName(namespace, record.Name)
+	for _, parent := range parents {
+		if parent == name {
+			return nil, fmt.Errorf("recursive record %s", name)
+		}
+	}
+	c.definitions[name] = record
+	parents = append(parents, name)
+	fields := make([]FieldSchema, 0, len(record.Fields))
+	for _, field := range record.Fields {
+		fieldSchema, err := c.field(field.Name, field.Type, namespace, parents)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Name, err)
+		}
+		if field.Doc != "" {
+			fieldSchema.Description = field.Doc
+		}
+		fields = append(fields, fieldSchema)
+	}
+	return fields, nil
+}
+
+func (c tableSchemaConverter) field(
+	name string,
+	schema avro.Schema,
+	namespace string,
+	parents []string,
+) (FieldSchema, error) {
+	mode := FieldModeRequired
+	if union, ok := schema.(avro.Union); ok {
+		nonNull, ok := c.nonNull(union)
+		if !ok {
+			return FieldSchema{}, fmt.Errorf("unions of more than one non-null type are not supported")
+		}
+		if len(union) > 1 {
+			mode = FieldModeNullable
+		}
+		schema = nonNull
+	}
+	schema = c.deref(schema, namespace)
+	switch s := schema.(type) {
+	case avro.Array:
+		items, ok := c.nonNull(s.Items)
+		if !ok {
+			return FieldSchema{}, fmt.Errorf("unions of more than one non-null type are not supported")
+		}
+		if _, ok := c.deref(items, namespace).(avro.Array); ok {
+			return FieldSchema{}, fmt.Errorf("arrays of arrays are not supported")
+		}
+		fieldSchema, err := c.field(name, items, namespace, parents)
+		if err != nil {
+			return FieldSchema{}, err
+		}
+		fieldSchema.Mode = FieldModeRepeated
+		return fieldSchema, nil
+	case avro.Map:
+		value, err := c.field("value", s.Values, namespace, parents)
+		if err != nil {
+			return FieldSchema{}, err
+		}
+		return FieldSchema{
+			Name: name,
+			Type: FieldTypeRecord,
+			Mode: FieldModeRepeated,
+			Fields: []FieldSchema{
+				{Name: "key", Type: FieldTypeString, Mode: FieldModeRequired},
+				value,
+			},
+		}, nil
+	case avro.Record:
+		fields, err := c.recordFields(s, namespace, parents)
+		if err != nil {
+			return FieldSchema{}, err
+		}
+		return FieldSchema{Name: name, Type: FieldTypeRecord, Mode: mode, Description: s.Doc, Fields: fields}, nil
+	case avro.Enum:
+		c.definitions[joinName(namespaceOf(s.Namespace, namespace), s.Name)] = s
+		return FieldSchema{Name: name, Type: FieldTypeString, Mode: mode}, nil
+	case avro.Fixed:
+		c.definitions[joinName(namespaceOf(s.Namespace, namespace), s.Name)] = s
+		return FieldSchema{Name: name, Type: FieldTypeBytes, Mode: mode}, nil
+	case avro.Primitive:
+		fieldSchema := FieldSchema{Name: name, Type: primitiveFieldType(s), Mode: mode}
+		if s.LogicalType == avro.DecimalLogicalType {
+			fieldSchema.Precision, fieldSchema.Scale = s.Precision, s.Scale
+		}
+		return fieldSchema, nil
+	case avro.Reference:
+		return FieldSchema{}, fmt.Errorf("undefined named type %s", s)
+	}
+	return FieldSchema{}, fmt.Errorf("unsupported schema %T", schema)
+}
+
+// primitiveFieldType returns the column type of a primitive type, with Avro logical types enabled.
+// Logical types that BigQuery does not support are loaded as their underlying type.
+func primitiveFieldType(p avro.Primitive) FieldType {
+	switch p.LogicalType {
+	case avro.DateLogicalType:
+		return FieldTypeDate
+	case avro.TimeMicrosLogicalType, "time-millis":
+		return FieldTypeTime
+	case avro.TimestampMicrosLogicalType, avro.TimestampMillisLogicalType:
+		return FieldTypeTimestamp
+	case avro.DecimalLogicalType:
+		if p.Precision-p.Scale <= 29 && p.Scale <= 9 {
+			return FieldTypeNumeric
+		}
+		return FieldTypeBigNumeric
+	}
+	switch p.Type {
+	case avro.BooleanType:
+		return FieldTypeBoolean
+	case avro.IntType, avro.LongType:
+		return FieldTypeInteger
+	case avro.FloatType, avro.DoubleType:
+		return FieldTypeFloat
+	case avro.BytesType:
+		return FieldTypeBytes
+	default:
+		return FieldTypeString
+	}
+}
+
+// nonNull returns the non-null type of a schema, which is false for unions of more than one non-null type.
+func (c tableSchemaConverter) nonNull(schema avro.Schema) (avro.Schema, bool) {
+	union, ok := schema.(avro.Union)
+	if !ok {
+		return schema, true
+	}
+	var nonNull avro.Schema
+	for _, branch := range union {
+		if branch == avro.Schema(avro.Null()) || branch == avro.Schema(avro.Reference(avro.NullType)) {
+			continue
+		}
+		if nonNull != nil {
+			return nil, false
+		}
+		nonNull = branch
+	}
+	return nonNull, nonNull != nil
+}
+
+// deref resolves a reference to the named type it refers to, or a primitive type named by the reference.
+func (c tableSchemaConverter) deref(schema avro.Schema, namespace string) avro.Schema {
+	ref, ok := schema.(avro.Reference)
+	if !ok {
+		return schema
+	}
+	switch avro.Type(ref) {
+	case avro.NullType, avro.BooleanType, avro.IntType, avro.LongType, avro.FloatType, avro.DoubleType,
+		avro.BytesType, avro.StringType:
+		return avro.Primitive{Type: avro.Type(ref)}
+	}
+	if definition, ok := c.definitions[joinName(namespace, string(ref))]; ok {
+		return definition
+	}
+	if definition, ok := c.definitions[string(ref)]; ok {
+		return definition
+	}
+	return schema
+}
+
+func namespaceOf(namespace, enclosing string) string {
+	if namespace != "" {
+		return namespace
+	}
+	return enclosing
+}
+
+func joinName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
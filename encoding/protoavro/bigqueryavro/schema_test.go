@@ -0,0 +1,174 @@
+package bigqueryavro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/bigqueryavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/v3/assert"
+)
+
+func TestInferTableSchema(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		opts     protoavro.SchemaOptions
+		message  proto.Message
+		expected bigqueryavro.TableSchema
+	}{
+		{
+			name:    "scalars",
+			message: &library.Book{},
+			expected: bigqueryavro.TableSchema{
+				Fields: []bigqueryavro.FieldSchema{
+					{Name: "name", Type: bigqueryavro.FieldTypeString, Mode: bigqueryavro.FieldModeNullable},
+					{Name: "author", Type: bigqueryavro.FieldTypeString, Mode: bigqueryavro.FieldModeNullable},
+					{Name: "title", Type: bigqueryavro.FieldTypeString, Mode: bigqueryavro.FieldModeNullable},
+					{Name: "read", Type: bigqueryavro.FieldTypeBoolean, Mode: bigqueryavro.FieldModeNullable},
+				},
+			},
+		},
+		{
+			name:    "lists",
+			message: &examplev1.ExampleList{},
+			expected: bigqueryavro.TableSchema{
+				Fields: []bigqueryavro.FieldSchema{
+					{Name: "int64_list", Type: bigqueryavro.FieldTypeInteger, Mode: bigqueryavro.FieldModeRepeated},
+					{Name: "string_list", Type: bigqueryavro.FieldTypeString, Mode: bigqueryavro.FieldModeRepeated},
+					{Name: "enum_list", Type: bigqueryavro.FieldTypeString, Mode: bigqueryavro.FieldModeRepeated},
+					{
+						Name: "nested_list",
+						Type: bigqueryavro.FieldTypeRecord,
+						Mode: bigqueryavro.FieldModeRepeated,
+						Fields: []bigqueryavro.FieldSchema{
+							{Name: "string_list", Type: bigqueryavro.FieldTypeString, Mode: bigqueryavro.FieldModeRepeated},
+						},
+					},
+					{Name: "float_value_list", Type: bigqueryavro.FieldTypeFloat, Mode: bigqueryavro.FieldModeRepeated},
+				},
+			},
+		},
+		{
+			name:    "timestamp",
+			message: &examplev1.ExampleTimestamp{},
+			expected: bigqueryavro.TableSchema{
+				Fields: []bigqueryavro.FieldSchema{
+					{Name: "timestamp", Type: bigqueryavro.FieldTypeTimestamp, Mode: bigqueryavro.FieldModeNullable},
+				},
+			},
+		},
+		{
+			name:    "timestamp nanos",
+			opts:    protoavro.SchemaOptions{TimestampPrecision: protoavro.TimestampNanos},
+			message: &examplev1.ExampleTimestamp{},
+			expected: bigqueryavro.TableSchema{
+				Fields: []bigqueryavro.FieldSchema{
+					{Name: "timestamp", Type: bigqueryavro.FieldTypeInteger, Mode: bigqueryavro.FieldModeNullable},
+				},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := tt.opts.InferSchema(tt.message.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+			tableSchema, err := bigqueryavro.InferTableSchema(schema)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, tableSchema)
+		})
+	}
+}
+
+func TestInferTableSchema_Avro(t *testing.T) {
+	nested := avro.Record{
+		Type:   avro.RecordType,
+		Name:   "Nested",
+		Doc:    "A nested record.",
+		Fields: []avro.Field{{Name: "id", Type: avro.Long()}},
+	}
+	schema := avro.Record{
+		Type:      avro.RecordType,
+		Name:      "Root",
+		Namespace: "example.v1",
+		Fields: []avro.Field{
+			{Name: "amount", Doc: "The amount.", Type: avro.Decimal(38, 9)},
+			{Name: "big_amount", Type: avro.Decimal(76, 38)},
+			{Name: "date", Type: avro.Nullable(avro.Date())},
+			{Name: "labels", Type: avro.Map{Type: avro.MapType, Values: avro.String()}},
+			{Name: "first", Type: nested},
+			{Name: "second", Type: avro.Nullable(avro.Reference("Nested"))},
+			{Name: "hash", Type: avro.Fixed{Type: avro.FixedType, Name: "Hash", Size: 16}},
+		},
+	}
+	tableSchema, err := bigqueryavro.InferTableSchema(schema)
+	assert.NilError(t, err)
+	nestedField := bigqueryavro.FieldSchema{
+		Name:        "first",
+		Type:        bigqueryavro.FieldTypeRecord,
+		Mode:        bigqueryavro.FieldModeRequired,
+		Description: "A nested record.",
+		Fields: []bigqueryavro.FieldSchema{
+			{Name: "id", Type: bigqueryavro.FieldTypeInteger, Mode: bigqueryavro.FieldModeRequired},
+		},
+	}
+	secondField := nestedField
+	secondField.Name, secondField.Mode = "second", bigqueryavro.FieldModeNullable
+	assert.DeepEqual(t, bigqueryavro.TableSchema{
+		Fields: []bigqueryavro.FieldSchema{
+			{
+				Name:        "amount",
+				Type:        bigqueryavro.FieldTypeNumeric,
+				Mode:        bigqueryavro.FieldModeRequired,
+				Description: "The amount.",
+				Precision:   38,
+				Scale:       9,
+			},
+			{
+				Name:      "big_amount",
+				Type:      bigqueryavro.FieldTypeBigNumeric,
+				Mode:      bigqueryavro.FieldModeRequired,
+				Precision: 76,
+				Scale:     38,
+			},
+			{Name: "date", Type: bigqueryavro.FieldTypeDate, Mode: bigqueryavro.FieldModeNullable},
+			{
+				Name: "labels",
+				Type: bigqueryavro.FieldTypeRecord,
+				Mode: bigqueryavro.FieldModeRepeated,
+				Fields: []bigqueryavro.FieldSchema{
+					{Name: "key", Type: bigqueryavro.FieldTypeString, Mode: bigqueryavro.FieldModeRequired},
+					{Name: "value", Type: bigqueryavro.FieldTypeString, Mode: bigqueryavro.FieldModeRequired},
+				},
+			},
+			nestedField,
+			secondField,
+			{Name: "hash", Type: bigqueryavro.FieldTypeBytes, Mode: bigqueryavro.FieldModeRequired},
+		},
+	}, tableSchema)
+	data, err := json.Marshal(tableSchema.Fields[0])
+	assert.NilError(t, err)
+	assert.Equal(
+		t,
+		`{"name":"amount","type":"NUMERIC","mode":"REQUIRED","description":"The amount.","precision":"38","scale":"9"}`,
+		string(data),
+	)
+}
+
+func TestInferTableSchema_Errors(t *testing.T) {
+	schema, err := protoavro.InferSchema((&examplev1.ExampleRecursive{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	_, err = bigqueryavro.InferTableSchema(schema)
+	assert.ErrorContains(t, err, "recursive record einride.avro.example.v1.ExampleRecursive")
+	_, err = bigqueryavro.InferTableSchema(avro.String())
+	assert.ErrorContains(t, err, "root schema is not a record")
+	_, err = bigqueryavro.InferTableSchema(avro.Record{
+		Type:   avro.RecordType,
+		Name:   "Root",
+		Fields: []avro.Field{{Name: "value", Type: avro.Union{avro.Null(), avro.String(), avro.Long()}}},
+	})
+	assert.ErrorContains(t, err, "value: unions of more than one non-null type are not supported")
+}
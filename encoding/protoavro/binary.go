@@ -0,0 +1,747 @@
+package protoavro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// errUnsupported is returned when a schema can not be decoded directly, and must be decoded through
+// the intermediate Avro JSON encoding instead.
+var errUnsupported = errors.New("unsupported by direct decoding")
+
+// binaryDecoder decodes Avro binary data directly into messages, without materializing the intermediate
+// Avro JSON encoding of the data. Well-known types and messages with custom codecs are decoded through
+// their Avro JSON encoding, since their decoding is defined in terms of it.
+type binaryDecoder struct {
+	root valueDecoder
+	// requireFields checks that required fields are set in decoded messages.
+	requireFields bool
+	// maxNestingDepth and maxRecordBytes are the limits of SchemaOptions, enforced while decoding.
+	maxNestingDepth int
+	maxRecordBytes  int
+}
+
+// newBinaryDecoder returns a decoder of Avro binary data of the schema, inferred with the options for the
+// message descriptor. The returned error wraps errUnsupported when options that can not be decoded
+// directly are set.
+func (o *SchemaOptions) newBinaryDecoder(
+	desc protoreflect.MessageDescriptor,
+	schema avro.Schema,
+) (*binaryDecoder, error) {
+	if err := o.checkDirectDecoding(desc); err != nil {
+		return nil, err
+	}
+	opts := o.withNames(desc)
+	c := binaryCompiler{
+		opts:        &opts,
+		definitions: make(definitions),
+		records:     make(map[string]*recordDecoder),
+	}
+	c.definitions.collect(schema)
+	root, err := c.compileMessage(schema, desc)
+	if err != nil {
+		return nil, err
+	}
+	return o.withDecoderOptions(root), nil
+}
+
+// withDecoderOptions returns a decoder of the compiled root, with the options that do not change how it is
+// compiled.
+func (o *SchemaOptions) withDecoderOptions(root valueDecoder) *binaryDecoder {
+	return &binaryDecoder{
+		root:            root,
+		requireFields:   o.RequireFields,
+		maxNestingDepth: o.MaxNestingDepth,
+		maxRecordBytes:  o.MaxRecordBytes,
+	}
+}
+
+// checkDirectDecoding returns errUnsupported when messages can not be decoded directly with the options,
+// which is when options rewrite the decoded data, or when the message is decoded through a codec.
+func (o *SchemaOptions) checkDirectDecoding(desc protoreflect.MessageDescriptor) error {
+	switch {
+	case o.PreserveUnknownFields, len(o.envelopeFields()) > 0, len(o.DecodeMask.GetPaths()) > 0,
+		o.RecursionStrategy == RecursionJSONString, len(o.FieldTransforms) > 0, o.CollectErrors,
+		o.FlattenDepth > 0, o.EncryptedFieldFunc != nil:
+		return errUnsupported
+	case o.isWKT(desc.FullName()):
+		return errUnsupported
+	}
+	return nil
+}
+
+// decode decodes the Avro binary data into the message.
+func (d *binaryDecoder) decode(data []byte, msg protoreflect.Message) error {
+	r := binaryReader{buf: data, maxDepth: d.maxNestingDepth, maxItems: d.maxRecordBytes}
+	if _, _, err := d.root(&r, container{value: protoreflect.ValueOfMessage(msg)}); err != nil {
+		return err
+	}
+	if err := checkTrailingBytes(len(data) - r.pos); err != nil {
+		return err
+	}
+	if d.requireFields {
+		return checkRequiredFields(msg, nil)
+	}
+	return nil
+}
+
+// checkTrailingBytes returns an error when n bytes remain after a decoded datum.
+func checkTrailingBytes(n int) error {
+	if n > 0 {
+		return fmt.Errorf("%d trailing bytes after the datum", n)
+	}
+	return nil
+}
+
+// container creates the mutable values that decoded values are placed in.
+type container struct {
+	message protoreflect.Message
+	field   protoreflect.FieldDescriptor
+	list    protoreflect.List
+	mp      protoreflect.Map
+	value   protoreflect.Value
+}
+
+func (c container) newValue() protoreflect.Value {
+	switch {
+	case c.value.IsValid():
+		return c.value
+	case c.list != nil:
+		return c.list.NewElement()
+	case c.mp != nil:
+		return c.mp.NewValue()
+	case c.message != nil:
+		return c.message.NewField(c.field)
+	}
+	return protoreflect.Value{}
+}
+
+// valueDecoder decodes a value of a schema. Null values are reported as null, with an invalid value.
+type valueDecoder func(r *binaryReader, c container) (value protoreflect.Value, null bool, err error)
+
+// recordDecoder decodes the fields of a record into a message.
+type recordDecoder struct {
+	fields []func(r *binaryReader, msg protoreflect.Message) error
+}
+
+func (d *recordDecoder) decode(r *binaryReader, msg protoreflect.Message) error {
+	for _, field := range d.fields {
+		if err := field(r, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type binaryCompiler struct {
+	opts        *SchemaOptions
+	definitions definitions
+	// records holds the decoders of records by full name, to support recursive messages.
+	records map[string]*recordDecoder
+}
+
+// compileMessage compiles a decoder of a message, encoded as a record or a union of null and a record.
+func (c *binaryCompiler) compileMessage(
+	schema avro.Schema,
+	desc protoreflect.MessageDescriptor,
+) (valueDecoder, error) {
+	if union, ok := schema.(avro.Union); ok {
+		return c.compileUnion(union, func(branch avro.Schema) (valueDecoder, error) {
+			return c.compileMessage(branch, desc)
+		})
+	}
+	record, ok := c.definitions.deref(schema).(avro.Record)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected record for '%s', got %T", errUnsupported, desc.FullName(), schema)
+	}
+	d, err := c.compileRecord(record, desc)
+	if err != nil {
+		return nil, err
+	}
+	return func(r *binaryReader, ct container) (protoreflect.Value, bool, error) {
+		if err := r.enter(); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		value := ct.newValue()
+		if err := d.decode(r, value.Message()); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		r.leave()
+		return value, false, nil
+	}, nil
+}
+
+func (c *binaryCompiler) compileRecord(
+	record avro.Record,
+	desc protoreflect.MessageDescriptor,
+) (*recordDecoder, error) {
+	name := joinName(record.Namespace, record.Name)
+	if d, ok := c.records[name]; ok {
+		return d, nil
+	}
+	d := &recordDecoder{fields: make([]func(*binaryReader, protoreflect.Message) error, 0, len(record.Fields))}
+	c.records[name] = d
+	for _, field := range record.Fields {
+		fd, ok := c.opts.findField(desc, field.Name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unexpected field %s", errUnsupported, field.Name)
+		}
+		decodeField, err := c.compileField(field.Type, fd)
+		if err != nil {
+			return nil, err
+		}
+		d.fields = append(d.fields, decodeField)
+	}
+	return d, nil
+}
+
+func (c *binaryCompiler) compileField(
+	schema avro.Schema,
+	fd protoreflect.FieldDescriptor,
+) (func(*binaryReader, protoreflect.Message) error, error) {
+	var decodeValue valueDecoder
+	var err error
+	switch {
+	case fd.IsList():
+		decodeValue, err = c.compileUnion(schema, func(branch avro.Schema) (valueDecoder, error) {
+			return c.compileList(branch, fd)
+		})
+	case fd.IsMap():
+		decodeValue, err = c.compileUnion(schema, func(branch avro.Schema) (valueDecoder, error) {
+			return c.compileMap(branch, fd)
+		})
+	default:
+		decodeValue, err = c.compileValue(schema, fd)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func(r *binaryReader, msg protoreflect.Message) error {
+		value, null, err := decodeValue(r, container{message: msg, field: fd})
+		if err != nil {
+			return err
+		}
+		if !null {
+			if err := checkOneofCase(msg, fd); err != nil {
+				return err
+			}
+			msg.Set(fd, value)
+		}
+		return nil
+	}, nil
+}
+
+// compileUnion compiles a decoder of a union, with the branches compiled by compileBranch.
+// Schemas that are not unions are compiled as they are.
+func (c *binaryCompiler) compileUnion(
+	schema avro.Schema,
+	compileBranch func(avro.Schema) (valueDecoder, error),
+) (valueDecoder, error) {
+	union, ok := schema.(avro.Union)
+	if !ok {
+		return compileBranch(schema)
+	}
+	branches := make([]valueDecoder, len(union))
+	for i, branch := range union {
+		if branch == avro.Null() {
+			continue
+		}
+		d, err := compileBranch(branch)
+		if err != nil {
+			return nil, err
+		}
+		branches[i] = d
+	}
+	return func(r *binaryReader, ct container) (protoreflect.Value, bool, error) {
+		index, err := r.readLong()
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		if index < 0 || index >= int64(len(branches)) {
+			return protoreflect.Value{}, false, fmt.Errorf("union index %d out of range", index)
+		}
+		if branches[index] == nil {
+			return protoreflect.Value{}, true, nil
+		}
+		// values of unions are nested in the union, like the generic values of goavro
+		if err := r.enter(); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		value, null, err := branches[index](r, ct)
+		r.leave()
+		return value, null, err
+	}, nil
+}
+
+func (c *binaryCompiler) compileList(schema avro.Schema, fd protoreflect.FieldDescriptor) (valueDecoder, error) {
+	array, ok := schema.(avro.Array)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected array for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	decodeItem, err := c.compileValue(array.Items, fd)
+	if err != nil {
+		return nil, err
+	}
+	return func(r *binaryReader, ct container) (protoreflect.Value, bool, error) {
+		list := ct.message.NewField(fd).List()
+		if err := r.enter(); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		err := r.readBlocks(func() error {
+			item, null, err := decodeItem(r, container{list: list})
+			if err != nil {
+				return err
+			}
+			if null {
+				var ok bool
+				if item, ok, err = c.opts.nullListItem(list, fd); err != nil || !ok {
+					return err
+				}
+			}
+			list.Append(item)
+			return nil
+		})
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		r.leave()
+		return protoreflect.ValueOfList(list), false, nil
+	}, nil
+}
+
+func (c *binaryCompiler) compileMap(schema avro.Schema, fd protoreflect.FieldDescriptor) (valueDecoder, error) {
+	array, ok := schema.(avro.Array)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected array for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	entry, ok := c.definitions.deref(array.Items).(avro.Record)
+	if !ok || len(entry.Fields) != 2 || entry.Fields[0].Name != "key" || entry.Fields[1].Name != "value" {
+		return nil, fmt.Errorf("%w: expected map entry record for '%s'", errUnsupported, fd.Name())
+	}
+	decodeKey, err := c.compileValue(entry.Fields[0].Type, fd.MapKey())
+	if err != nil {
+		return nil, err
+	}
+	decodeMapValue, err := c.compileValue(entry.Fields[1].Type, fd.MapValue())
+	if err != nil {
+		return nil, err
+	}
+	return func(r *binaryReader, ct container) (protoreflect.Value, bool, error) {
+		mp := ct.message.NewField(fd).Map()
+		if err := r.enter(); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		err := r.readBlocks(func() error {
+			// entries are records nested in the array
+			if err := r.enter(); err != nil {
+				return err
+			}
+			defer r.leave()
+			key, null, err := decodeKey(r, container{})
+			if err != nil {
+				return err
+			}
+			if null {
+				return fmt.Errorf("missing 'key' in map entry for '%s'", fd.Name())
+			}
+			value, null, err := decodeMapValue(r, container{mp: mp})
+			if err != nil {
+				return err
+			}
+			if null {
+				var ok bool
+				if value, ok, err = c.opts.nullMapValue(mp, fd); err != nil || !ok {
+					return err
+				}
+			}
+			mp.Set(key.MapKey(), value)
+			return nil
+		})
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		r.leave()
+		return protoreflect.ValueOfMap(mp), false, nil
+	}, nil
+}
+
+// compileValue compiles a decoder of a single value of the field kind.
+func (c *binaryCompiler) compileValue(schema avro.Schema, fd protoreflect.FieldDescriptor) (valueDecoder, error) {
+	if fd.Message() != nil && c.opts.isWKT(fd.Message().FullName()) {
+		return c.compileWKT(schema, fd)
+	}
+	if union, ok := schema.(avro.Union); ok {
+		return c.compileUnion(union, func(branch avro.Schema) (valueDecoder, error) {
+			return c.compileValue(branch, fd)
+		})
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return c.compileMessage(schema, fd.Message())
+	case protoreflect.EnumKind:
+		return c.compileEnum(schema, fd)
+	}
+	if fixed, ok := c.definitions.deref(schema).(avro.Fixed); ok && fd.Kind() == protoreflect.BytesKind {
+		return func(r *binaryReader, _ container) (protoreflect.Value, bool, error) {
+			b, err := r.readFixed(fixed.Size)
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			return protoreflect.ValueOfBytes(append([]byte(nil), b...)), false, nil
+		}, nil
+	}
+	primitive, ok := schema.(avro.Primitive)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected primitive for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	switch primitive.Type {
+	case avro.IntType, avro.LongType:
+		return compileInteger(fd)
+	case avro.BooleanType:
+		if fd.Kind() != protoreflect.BoolKind {
+			break
+		}
+		return func(r *binaryReader, _ container) (protoreflect.Value, bool, error) {
+			b, err := r.readBool()
+			return protoreflect.ValueOfBool(b), false, err
+		}, nil
+	case avro.FloatType:
+		if fd.Kind() != protoreflect.FloatKind {
+			break
+		}
+		return func(r *binaryReader, _ container) (protoreflect.Value, bool, error) {
+			f, err := r.readFloat()
+			return protoreflect.ValueOfFloat32(f), false, err
+		}, nil
+	case avro.DoubleType:
+		if fd.Kind() != protoreflect.DoubleKind {
+			break
+		}
+		return func(r *binaryReader, _ container) (protoreflect.Value, bool, error) {
+			f, err := r.readDouble()
+			return protoreflect.ValueOfFloat64(f), false, err
+		}, nil
+	case avro.StringType:
+		if fd.Kind() != protoreflect.StringKind {
+			break
+		}
+		return func(r *binaryReader, _ container) (protoreflect.Value, bool, error) {
+			b, err := r.readBytes()
+			return protoreflect.ValueOfString(string(b)), false, err
+		}, nil
+	case avro.BytesType:
+		if fd.Kind() != protoreflect.BytesKind {
+			break
+		}
+		return func(r *binaryReader, _ container) (protoreflect.Value, bool, error) {
+			b, err := r.readBytes()
+			if err != nil {
+				return protoreflect.Value{}, false, err
+			}
+			return protoreflect.ValueOfBytes(append([]byte(nil), b...)), false, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected %s for '%s' of kind %s", errUnsupported, primitive.Type, fd.Name(), fd.Kind())
+}
+
+func compileInteger(fd protoreflect.FieldDescriptor) (valueDecoder, error) {
+	if !isIntegerKind(fd.Kind()) {
+		return nil, fmt.Errorf("%w: unexpected integer for '%s' of kind %s", errUnsupported, fd.Name(), fd.Kind())
+	}
+	return func(r *binaryReader, _ container) (protoreflect.Value, bool, error) {
+		i, err := r.readLong()
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		return integerValue(fd.Kind(), i), false, nil
+	}, nil
+}
+
+func isIntegerKind(kind protoreflect.Kind) bool {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sfixed32Kind, protoreflect.Sint32Kind,
+		protoreflect.Int64Kind, protoreflect.Sfixed64Kind, protoreflect.Sint64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return true
+	}
+	return false
+}
+
+// integerValue returns the value of an Avro int or long as a value of the integer kind.
+func integerValue(kind protoreflect.Kind, i int64) protoreflect.Value {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sfixed32Kind, protoreflect.Sint32Kind:
+		return protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(i))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(i))
+	}
+	return protoreflect.ValueOfInt64(i)
+}
+
+func (c *binaryCompiler) compileEnum(schema avro.Schema, fd protoreflect.FieldDescriptor) (valueDecoder, error) {
+	enum, ok := c.definitions.deref(schema).(avro.Enum)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected enum for '%s', got %T", errUnsupported, fd.Name(), schema)
+	}
+	// symbols that are not values of the enum decode to the zero value
+	numbers := make([]protoreflect.EnumNumber, len(enum.Symbols))
+	for i, symbol := range enum.Symbols {
+		if v := c.opts.enumValue(fd.Enum(), symbol); v != nil {
+			numbers[i] = v.Number()
+		}
+	}
+	return func(r *binaryReader, _ container) (protoreflect.Value, bool, error) {
+		index, err := r.readLong()
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		if index < 0 || index >= int64(len(numbers)) {
+			return protoreflect.Value{}, false, fmt.Errorf("enum index %d out of range for '%s'", index, fd.Name())
+		}
+		return protoreflect.ValueOfEnum(numbers[index]), false, nil
+	}, nil
+}
+
+// compileWKT compiles a decoder of a well-known type, or a message with a custom codec, that decodes
+// the value with goavro and the Avro JSON decoding of the message.
+func (c *binaryCompiler) compileWKT(schema avro.Schema, fd protoreflect.FieldDescriptor) (valueDecoder, error) {
+	codec, branch, err := c.opts.newWKTCodec(c.definitions, schema, fd)
+	if err != nil {
+		return nil, err
+	}
+	return func(r *binaryReader, ct container) (protoreflect.Value, bool, error) {
+		native, rest, err := codec.NativeFromBinary(r.buf[r.pos:])
+		if err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		r.pos = len(r.buf) - len(rest)
+		if r.maxDepth > 0 && !withinNestingDepth(native, r.maxDepth-r.depth) {
+			return protoreflect.Value{}, false, &LimitError{Limit: "MaxNestingDepth", Max: r.maxDepth}
+		}
+		if native == nil {
+			return protoreflect.Value{}, true, nil
+		}
+		if branch != "" {
+			native = map[string]interface{}{branch: native}
+		}
+		value := ct.newValue()
+		if err := c.opts.decodeMessage(native, value.Message(), nil); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		return value, false, nil
+	}, nil
+}
+
+// definitions holds the named types of a schema by full name.
+type definitions map[string]avro.Schema
+
+func (d definitions) collect(schema avro.Schema) {
+	switch s := schema.(type) {
+	case avro.Union:
+		for _, branch := range s {
+			d.collect(branch)
+		}
+	case avro.Record:
+		d[joinName(s.Namespace, s.Name)] = s
+		for _, field := range s.Fields {
+			d.collect(field.Type)
+		}
+	case avro.Enum:
+		d[joinName(s.Namespace, s.Name)] = s
+	case avro.Fixed:
+		d[joinName(s.Namespace, s.Name)] = s
+	case avro.Array:
+		d.collect(s.Items)
+	case avro.Map:
+		d.collect(s.Values)
+	}
+}
+
+// deref resolves a reference to the named type it refers to.
+func (d definitions) deref(schema avro.Schema) avro.Schema {
+	if ref, ok := schema.(avro.Reference); ok {
+		if definition, ok := d[string(ref)]; ok {
+			return definition
+		}
+	}
+	return schema
+}
+
+// inline replaces references to named types by their definitions, for the schema to stand on its own.
+func (d definitions) inline(schema avro.Schema) avro.Schema {
+	if union, ok := schema.(avro.Union); ok {
+		inlined := make(avro.Union, 0, len(union))
+		for _, branch := range union {
+			inlined = append(inlined, d.deref(branch))
+		}
+		return inlined
+	}
+	return d.deref(schema)
+}
+
+// newWKTCodec returns a goavro codec for the schema of a well-known type, or a message with a custom codec,
+// of the field. The branch is the name of the union branch that decoded values must be wrapped by, for items
+// of lists of non-nullable items that are encoded without the union.
+func (o *SchemaOptions) newWKTCodec(
+	d definitions,
+	schema avro.Schema,
+	fd protoreflect.FieldDescriptor,
+) (codec *goavro.Codec, branch string, err error) {
+	if _, ok := schema.(avro.Union); !ok {
+		if branch, _, err = o.unwrapsListItems(fd); err != nil {
+			return nil, "", err
+		}
+	}
+	schemaBytes, err := json.Marshal(d.inline(schema))
+	if err != nil {
+		return nil, "", fmt.Errorf("json marshal schema: %w", err)
+	}
+	codec, err = goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errUnsupported, err)
+	}
+	return codec, branch, nil
+}
+
+// binaryReader reads values in Avro binary encoding.
+type binaryReader struct {
+	buf []byte
+	pos int
+	// depth is the number of records, arrays, maps and unions that the current value is nested in, which may not
+	// exceed maxDepth, unless it is zero.
+	depth    int
+	maxDepth int
+	// items is the number of items of the arrays and maps read, which may not exceed maxItems, unless it is zero,
+	// so that items that take no bytes, such as empty records, can not decode to unbounded lists.
+	items    int64
+	maxItems int
+}
+
+// enter enters a nested record, array, map or union, and returns a LimitError when it is nested deeper than
+// maxDepth. Values are left with leave once they are decoded.
+func (r *binaryReader) enter() error {
+	r.depth++
+	if r.maxDepth > 0 && r.depth > r.maxDepth {
+		return &LimitError{Limit: "MaxNestingDepth", Max: r.maxDepth}
+	}
+	return nil
+}
+
+// leave leaves a value entered with enter.
+func (r *binaryReader) leave() {
+	r.depth--
+}
+
+func (r *binaryReader) readLong() (int64, error) {
+	var u uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if r.pos >= len(r.buf) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		b := r.buf[r.pos]
+		r.pos++
+		u |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			// zig-zag decoding
+			return int64(u>>1) ^ -int64(u&1), nil
+		}
+	}
+	return 0, fmt.Errorf("varint overflows a 64-bit integer")
+}
+
+func (r *binaryReader) readBool() (bool, error) {
+	if r.pos >= len(r.buf) {
+		return false, io.ErrUnexpectedEOF
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b != 0, nil
+}
+
+func (r *binaryReader) readFloat() (float32, error) {
+	if len(r.buf)-r.pos < 4 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	f := math.Float32frombits(binary.LittleEndian.Uint32(r.buf[r.pos:]))
+	r.pos += 4
+	return f, nil
+}
+
+func (r *binaryReader) readDouble() (float64, error) {
+	if len(r.buf)-r.pos < 8 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	f := math.Float64frombits(binary.LittleEndian.Uint64(r.buf[r.pos:]))
+	r.pos += 8
+	return f, nil
+}
+
+// readBytes reads bytes or a string. The returned slice aliases the data of the reader.
+func (r *binaryReader) readBytes() ([]byte, error) {
+	n, err := r.readLong()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("negative length %d", n)
+	}
+	if int64(len(r.buf)-r.pos) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// readFixed reads a fixed of the size. The returned slice aliases the data of the reader.
+func (r *binaryReader) readFixed(size int) ([]byte, error) {
+	if len(r.buf)-r.pos < size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.buf[r.pos : r.pos+size]
+	r.pos += size
+	return b, nil
+}
+
+// readBlocks reads the blocks of an array or a map, calling readItem for each item.
+func (r *binaryReader) readBlocks(readItem func() error) error {
+	for {
+		count, err := r.readLong()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// negative counts are followed by the size of the block in bytes
+			count = -count
+			if _, err := r.readLong(); err != nil {
+				return err
+			}
+		}
+		if count < 0 || count > goavro.MaxBlockCount {
+			return fmt.Errorf("invalid block count %d", count)
+		}
+		r.items += count
+		if r.maxItems > 0 && r.items > int64(r.maxItems) {
+			return &LimitError{Limit: "MaxRecordBytes", Max: r.maxItems}
+		}
+		for i := int64(0); i < count; i++ {
+			if err := readItem(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+type directDecodingTestCase struct {
+	name string
+	opts SchemaOptions
+	msg  proto.Message
+}
+
+// directDecodingTestCases returns messages that are decoded directly, without the intermediate Avro JSON encoding.
+func directDecodingTestCases() []directDecodingTestCase {
+	return []directDecodingTestCase{
+		{
+			name: "bytes",
+			msg:  &examplev1.ExampleBytes{Bytes: []byte("abc\x00\xff")},
+		},
+		{
+			name: "enum",
+			msg:  &examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE2},
+		},
+		{
+			name: "enum stripped prefix",
+			opts: SchemaOptions{StripEnumPrefix: true},
+			msg:  &examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE2},
+		},
+		{
+			name: "list",
+			msg: &examplev1.ExampleList{
+				Int64List:  []int64{-1, 0, 1 << 40},
+				StringList: []string{"a", "", "åäö"},
+				EnumList:   []examplev1.ExampleList_Enum{examplev1.ExampleList_ENUM_VALUE2},
+				NestedList: []*examplev1.ExampleList_Nested{
+					{StringList: []string{"c"}},
+					{},
+				},
+				FloatValueList: []*wrapperspb.FloatValue{wrapperspb.Float(1.5), wrapperspb.Float(0)},
+			},
+		},
+		{
+			name: "list non-null items",
+			opts: SchemaOptions{NonNullListItems: true},
+			msg: &examplev1.ExampleList{
+				Int64List:      []int64{1, 2},
+				NestedList:     []*examplev1.ExampleList_Nested{{StringList: []string{"c"}}},
+				FloatValueList: []*wrapperspb.FloatValue{wrapperspb.Float(1.5)},
+			},
+		},
+		{
+			name: "map",
+			msg: &examplev1.ExampleMap{
+				StringToString: map[string]string{"a": "b", "c": ""},
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{
+					"a": {StringToString: map[string]string{"x": "y"}},
+				},
+				StringToEnum:       map[string]examplev1.ExampleMap_Enum{"a": examplev1.ExampleMap_ENUM_VALUE2},
+				Int32ToString:      map[int32]string{-1: "a", 1: "b"},
+				Int64ToString:      map[int64]string{1 << 40: "a"},
+				Uint32ToString:     map[uint32]string{1 << 31: "a"},
+				BoolToString:       map[bool]string{true: "a", false: "b"},
+				StringToFloatValue: map[string]*wrapperspb.FloatValue{"a": wrapperspb.Float(1.5)},
+			},
+		},
+		{
+			name: "map empty values",
+			opts: SchemaOptions{StripEnumPrefix: true},
+			msg: &examplev1.ExampleMap{
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{"a": {}},
+				StringToEnum: map[string]examplev1.ExampleMap_Enum{
+					"a": examplev1.ExampleMap_ENUM_UNSPECIFIED,
+					"b": examplev1.ExampleMap_ENUM_VALUE1,
+				},
+				StringToFloatValue: map[string]*wrapperspb.FloatValue{"a": {}},
+			},
+		},
+		{
+			name: "oneof",
+			msg: &examplev1.ExampleOneof{
+				OneofFields_1: &examplev1.ExampleOneof_OneofBool_1{OneofBool_1: true},
+				OneofFields_2: &examplev1.ExampleOneof_OneofMessage{
+					OneofMessage: &examplev1.ExampleOneof_Message{StringValue: "a"},
+				},
+			},
+		},
+		{
+			name: "oneof empty message",
+			msg: &examplev1.ExampleOneof{
+				OneofFields_1: &examplev1.ExampleOneof_OneofEmptyMessage_1{
+					OneofEmptyMessage_1: &examplev1.ExampleOneof_EmptyMessage{},
+				},
+			},
+		},
+		{
+			name: "recursive",
+			msg: &examplev1.ExampleRecursive{
+				Recursive: &examplev1.ExampleRecursive{Recursive: &examplev1.ExampleRecursive{}},
+			},
+		},
+		{
+			name: "omit root element",
+			opts: SchemaOptions{OmitRootElement: true},
+			msg: &examplev1.ExampleRecursive{
+				Recursive: &examplev1.ExampleRecursive{},
+			},
+		},
+		{
+			name: "wrappers",
+			msg: &examplev1.ExampleWrappers{
+				FloatValue:  wrapperspb.Float(1.5),
+				DoubleValue: wrapperspb.Double(2.5),
+				StringValue: wrapperspb.String("a"),
+				BytesValue:  wrapperspb.Bytes([]byte("b")),
+				BoolValue:   wrapperspb.Bool(true),
+			},
+		},
+		{
+			name: "timestamp",
+			opts: SchemaOptions{TimestampPrecision: TimestampNanos},
+			msg:  &examplev1.ExampleTimestamp{Timestamp: timestamppb.New(time.Unix(1, 2).UTC())},
+		},
+		{
+			name: "duration fixed",
+			opts: SchemaOptions{DurationEncoding: DurationFixed},
+			msg:  &examplev1.ExampleDuration{Duration: durationpb.New(time.Hour + time.Millisecond)},
+		},
+		{
+			name: "date",
+			msg:  &examplev1.ExampleDate{Date: &date.Date{Year: 2021, Month: 1, Day: 2}},
+		},
+		{
+			name: "disambiguated names",
+			opts: SchemaOptions{DisambiguateNames: true, StripNamespaces: true},
+			msg: &examplev1.ExampleList{
+				NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"c"}}},
+			},
+		},
+		{
+			name: "empty",
+			msg:  &examplev1.ExampleList{},
+		},
+	}
+}
+
+func TestBinaryDecoder(t *testing.T) {
+	for _, tt := range directDecodingTestCases() {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			desc := tt.msg.ProtoReflect().Descriptor()
+			schema, err := opts.InferSchema(desc)
+			assert.NilError(t, err)
+			decoder, err := opts.newBinaryDecoder(desc, schema)
+			assert.NilError(t, err)
+			codec := newTestGoavroCodec(t, opts, tt.msg)
+			encoded, err := opts.encodeJSON(tt.msg)
+			assert.NilError(t, err)
+			data, err := codec.BinaryFromNative(nil, encoded)
+			assert.NilError(t, err)
+
+			got := tt.msg.ProtoReflect().New()
+			assert.NilError(t, decoder.decode(data, got))
+			assert.DeepEqual(t, tt.msg, got.Interface(), protocmp.Transform())
+
+			// the direct decoder decodes like the generic decoder
+			native, _, err := codec.NativeFromBinary(data)
+			assert.NilError(t, err)
+			expected := tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, opts.decodeJSON(native, expected))
+			assert.DeepEqual(t, expected, got.Interface(), protocmp.Transform())
+		})
+	}
+}
+
+func TestBinaryDecoder_Unsupported(t *testing.T) {
+	for _, opts := range []SchemaOptions{
+		{PreserveUnknownFields: true},
+		{EnvelopeFields: []EnvelopeField{{Name: "ingest_time", Schema: avro.Long()}}},
+		{DecodeMask: &fieldmaskpb.FieldMask{Paths: []string{"nested_list"}}},
+	} {
+		desc := (&examplev1.ExampleList{}).ProtoReflect().Descriptor()
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		_, err = opts.newBinaryDecoder(desc, schema)
+		assert.Assert(t, errors.Is(err, errUnsupported))
+	}
+}
+
+func TestBinaryDecoder_Truncated(t *testing.T) {
+	var opts SchemaOptions
+	msg := &examplev1.ExampleList{StringList: []string{"abc"}}
+	schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	decoder, err := opts.newBinaryDecoder(msg.ProtoReflect().Descriptor(), schema)
+	assert.NilError(t, err)
+	codec := newTestGoavroCodec(t, opts, msg)
+	encoded, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	data, err := codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+	for i := range data {
+		assert.Assert(t, decoder.decode(data[:i], msg.ProtoReflect().New()) != nil, "truncated at %d", i)
+	}
+}
+
+func TestBinaryDecoder_Allocations(t *testing.T) {
+	var opts SchemaOptions
+	msg := &examplev1.ExampleMap{
+		StringToString: map[string]string{"a": "b", "c": "d"},
+		StringToNested: map[string]*examplev1.ExampleMap_Nested{
+			"a": {StringToString: map[string]string{"x": "y"}},
+		},
+		StringToEnum:   map[string]examplev1.ExampleMap_Enum{"a": examplev1.ExampleMap_ENUM_VALUE2},
+		Int32ToString:  map[int32]string{-1: "a", 1: "b"},
+		Int64ToString:  map[int64]string{1 << 40: "a"},
+		Uint32ToString: map[uint32]string{1 << 31: "a"},
+		BoolToString:   map[bool]string{true: "a", false: "b"},
+	}
+	desc := msg.ProtoReflect().Descriptor()
+	schema, err := opts.InferSchema(desc)
+	assert.NilError(t, err)
+	decoder, err := opts.newBinaryDecoder(desc, schema)
+	assert.NilError(t, err)
+	codec := newTestGoavroCodec(t, opts, msg)
+	encoded, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	data, err := codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+	direct := testing.AllocsPerRun(100, func() {
+		if err := decoder.decode(data, msg.ProtoReflect().New()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	generic := testing.AllocsPerRun(100, func() {
+		native, _, err := codec.NativeFromBinary(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := opts.decodeJSON(native, msg.ProtoReflect().New().Interface()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Logf("allocations per decode: direct %v, generic %v", direct, generic)
+	assert.Assert(t, direct*3 <= generic, "direct %v, generic %v", direct, generic)
+}
+
+func newTestGoavroCodec(t *testing.T, opts SchemaOptions, msg proto.Message) *goavro.Codec {
+	t.Helper()
+	schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	return codec
+}
@@ -0,0 +1,95 @@
+package protoavro
+
+import (
+	"encoding"
+	"reflect"
+	"sync"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = &BinaryMessage[proto.Message]{}
+	_ encoding.BinaryUnmarshaler = &BinaryMessage[proto.Message]{}
+	_ avro.Marshaler             = &BinaryMessage[proto.Message]{}
+	_ avro.Unmarshaler           = &BinaryMessage[proto.Message]{}
+)
+
+// defaultCodecs holds the codecs of BinaryMessage values that are not created by a Codec.
+var defaultCodecs sync.Map // map[reflect.Type]interface{}, of *Codec[T] keyed by T
+
+// BinaryMessage wraps a message of type T, to plug messages into serialization frameworks that discover codecs
+// by interface. It implements encoding.BinaryMarshaler and encoding.BinaryUnmarshaler, and avro.Marshaler and
+// avro.Unmarshaler, with the Avro binary encoding of the Codec that wrapped the message, or of a Codec with
+// default SchemaOptions for the zero value and values created without Codec.Wrap.
+type BinaryMessage[T proto.Message] struct {
+	// Message is the wrapped message, which is replaced by the decoded message when unmarshaling.
+	Message T
+	codec   *Codec[T]
+}
+
+// Wrap returns the message as a BinaryMessage, which is encoded and decoded with the codec.
+func (c *Codec[T]) Wrap(message T) *BinaryMessage[T] {
+	return &BinaryMessage[T]{Message: message, codec: c}
+}
+
+// AvroSchema implements avro.Marshaler. It returns nil when the schema of the message can not be inferred.
+func (m *BinaryMessage[T]) AvroSchema() avro.Schema {
+	codec, err := m.getCodec()
+	if err != nil {
+		return nil
+	}
+	return codec.Schema()
+}
+
+// MarshalAvro implements avro.Marshaler.
+func (m *BinaryMessage[T]) MarshalAvro() ([]byte, error) {
+	codec, err := m.getCodec()
+	if err != nil {
+		return nil, err
+	}
+	return codec.Marshal(m.Message)
+}
+
+// UnmarshalAvro implements avro.Unmarshaler.
+func (m *BinaryMessage[T]) UnmarshalAvro(data []byte) error {
+	codec, err := m.getCodec()
+	if err != nil {
+		return err
+	}
+	message, err := codec.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	m.Message = message
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, see MarshalAvro.
+func (m *BinaryMessage[T]) MarshalBinary() ([]byte, error) {
+	return m.MarshalAvro()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, see UnmarshalAvro.
+func (m *BinaryMessage[T]) UnmarshalBinary(data []byte) error {
+	return m.UnmarshalAvro(data)
+}
+
+// getCodec returns the codec of the message, or the shared codec with default SchemaOptions of messages of
+// type T.
+func (m *BinaryMessage[T]) getCodec() (*Codec[T], error) {
+	if m.codec != nil {
+		return m.codec, nil
+	}
+	key := reflect.TypeOf((*T)(nil)).Elem()
+	if codec, ok := defaultCodecs.Load(key); ok {
+		return codec.(*Codec[T]), nil
+	}
+	codec, err := NewCodec[T](SchemaOptions{})
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := defaultCodecs.LoadOrStore(key, codec)
+	return actual.(*Codec[T]), nil
+}
@@ -0,0 +1,73 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestBinaryMessage(t *testing.T) {
+	book := &library.Book{Name: "shelves/1/books/1", Title: "Dune", Author: "Frank Herbert"}
+
+	t.Run("codec", func(t *testing.T) {
+		codec, err := protoavro.NewCodec[*library.Book](protoavro.SchemaOptions{
+			RecordCompression: protoavro.CompressionDeflate,
+		})
+		assert.NilError(t, err)
+		var marshaler interface{} = codec.Wrap(book)
+		avroMarshaler, ok := marshaler.(avro.Marshaler)
+		assert.Assert(t, ok)
+		assert.DeepEqual(t, codec.Schema(), avroMarshaler.AvroSchema())
+		data, err := avroMarshaler.MarshalAvro()
+		assert.NilError(t, err)
+		expected, err := codec.Marshal(book)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, expected, data)
+		var unmarshaler interface{} = codec.Wrap(nil)
+		assert.NilError(t, unmarshaler.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		assert.DeepEqual(t, book, unmarshaler.(*protoavro.BinaryMessage[*library.Book]).Message, protocmp.Transform())
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		data, err := (&protoavro.BinaryMessage[*library.Book]{Message: book}).MarshalBinary()
+		assert.NilError(t, err)
+		codec, err := protoavro.NewCodec[*library.Book](protoavro.SchemaOptions{})
+		assert.NilError(t, err)
+		expected, err := codec.Marshal(book)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, expected, data)
+		var decoded protoavro.BinaryMessage[*library.Book]
+		assert.NilError(t, decoded.UnmarshalAvro(data))
+		assert.DeepEqual(t, book, decoded.Message, protocmp.Transform())
+		assert.DeepEqual(t, codec.Schema(), decoded.AvroSchema())
+	})
+
+	t.Run("gob", func(t *testing.T) {
+		// gob encodes values that implement encoding.BinaryMarshaler by their binary encoding
+		type shelf struct {
+			Name string
+			Book *protoavro.BinaryMessage[*library.Book]
+		}
+		var b bytes.Buffer
+		assert.NilError(t, gob.NewEncoder(&b).Encode(shelf{
+			Name: "shelves/1",
+			Book: &protoavro.BinaryMessage[*library.Book]{Message: book},
+		}))
+		var decoded shelf
+		assert.NilError(t, gob.NewDecoder(&b).Decode(&decoded))
+		assert.Equal(t, "shelves/1", decoded.Name)
+		assert.DeepEqual(t, book, decoded.Book.Message, protocmp.Transform())
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		var decoded protoavro.BinaryMessage[*library.Book]
+		assert.ErrorContains(t, decoded.UnmarshalBinary([]byte{0xff}), "native from binary")
+	})
+}
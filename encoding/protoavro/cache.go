@@ -0,0 +1,47 @@
+package protoavro
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// maxCachedDescriptors bounds the number of entries of each cache of values computed from descriptors.
+// Descriptors are created anew for every file by protodesc.NewFiles, for example by the readers of
+// Object Container Files with embedded descriptors, so the caches of long-running processes would otherwise
+// grow without bound.
+const maxCachedDescriptors = 4096
+
+// descriptorCache is a cache of values computed from descriptors, safe for concurrent use, that is cleared
+// when it holds more than maxCachedDescriptors entries. Values are cheap to compute again, so the cache starts
+// over instead of tracking which entries were used recently, and loads never lock.
+// The zero value is an empty cache.
+type descriptorCache[K comparable, V any] struct {
+	entries atomic.Pointer[sync.Map] // map[K]V
+	size    atomic.Int64
+}
+
+// load returns the cached value of the key.
+func (c *descriptorCache[K, V]) load(key K) (V, bool) {
+	if entries := c.entries.Load(); entries != nil {
+		if value, ok := entries.Load(key); ok {
+			return value.(V), true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// loadOrStore returns the cached value of the key, or else caches and returns the value.
+func (c *descriptorCache[K, V]) loadOrStore(key K, value V) V {
+	entries := c.entries.Load()
+	if entries == nil {
+		c.entries.CompareAndSwap(nil, &sync.Map{})
+		entries = c.entries.Load()
+	}
+	actual, loaded := entries.LoadOrStore(key, value)
+	if !loaded && c.size.Add(1) > maxCachedDescriptors {
+		c.entries.Store(&sync.Map{})
+		c.size.Store(0)
+	}
+	return actual.(V)
+}
@@ -0,0 +1,39 @@
+package protoavro
+
+import (
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_DescriptorCache(t *testing.T) {
+	var cache descriptorCache[int, string]
+	_, ok := cache.load(1)
+	assert.Assert(t, !ok)
+	assert.Equal(t, "a", cache.loadOrStore(1, "a"))
+	// the first value of a key is kept
+	assert.Equal(t, "a", cache.loadOrStore(1, "b"))
+	value, ok := cache.load(1)
+	assert.Assert(t, ok)
+	assert.Equal(t, "a", value)
+
+	// the cache is bounded, also when filled concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < maxCachedDescriptors; j++ {
+				cache.loadOrStore(i*maxCachedDescriptors+j, "x")
+			}
+		}(i)
+	}
+	wg.Wait()
+	var n int
+	cache.entries.Load().Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	assert.Assert(t, n <= maxCachedDescriptors+8, n)
+}
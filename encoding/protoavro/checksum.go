@@ -0,0 +1,147 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+)
+
+const (
+	// ChecksumField is the name of the trailing field of root records that holds the checksum of the record,
+	// see SchemaOptions.RecordChecksums.
+	ChecksumField = "_checksum"
+	// ChecksumProperty is the custom property of root records with a checksum field. The value of the property
+	// is the checksum algorithm, "crc32c".
+	ChecksumProperty = "protoavro.checksum"
+)
+
+// ErrChecksumMismatch is returned when the checksum of a record does not match the data of the record.
+var ErrChecksumMismatch = errors.New("record checksum mismatch")
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumSchema is the schema of the checksum field, the big-endian CRC-32C checksum of the binary encoding
+// of the other fields of the record.
+func checksumSchema() avro.Fixed {
+	return avro.Fixed{Type: avro.FixedType, Name: "CRC32C", Namespace: "protoavro", Size: crc32.Size}
+}
+
+// withChecksumField returns the schema with the checksum field appended to its root record.
+func withChecksumField(schema avro.Schema) (avro.Schema, error) {
+	return mapRootRecord(schema, func(record avro.Record) (avro.Record, error) {
+		for _, field := range record.Fields {
+			if field.Name == ChecksumField {
+				return record, fmt.Errorf("checksum field collides with a field of record %s", record.Name)
+			}
+		}
+		record.Fields = append(append([]avro.Field(nil), record.Fields...), avro.Field{
+			Name: ChecksumField,
+			Doc:  "CRC-32C checksum of the binary encoding of the other fields of the record.",
+			Type: checksumSchema(),
+		})
+		properties := make(avro.Properties, len(record.Properties)+1)
+		for name, value := range record.Properties {
+			properties[name] = value
+		}
+		properties[ChecksumProperty] = "crc32c"
+		record.Properties = properties
+		return record, nil
+	})
+}
+
+// checksumPayloadCodec returns the codec of the schema without its checksum field, or nil if the schema has
+// no record checksums.
+func checksumPayloadCodec(schemaJSON string) (*goavro.Codec, error) {
+	if !bytes.Contains([]byte(schemaJSON), []byte(ChecksumProperty)) {
+		return nil, nil
+	}
+	schema, err := avro.Parse([]byte(schemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	var hasChecksum bool
+	payload, err := mapRootRecord(schema, func(record avro.Record) (avro.Record, error) {
+		n := len(record.Fields)
+		if record.Properties[ChecksumProperty] != "crc32c" || n == 0 || record.Fields[n-1].Name != ChecksumField {
+			return record, nil
+		}
+		hasChecksum = true
+		record.Fields = record.Fields[:n-1]
+		return record, nil
+	})
+	if err != nil || !hasChecksum {
+		// the property is only recognized on the root record
+		return nil, nil
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	return goavro.NewCodec(string(payloadJSON))
+}
+
+// mapRootRecord applies fn to the root record of a record schema, or of a nullable record schema.
+func mapRootRecord(schema avro.Schema, fn func(avro.Record) (avro.Record, error)) (avro.Schema, error) {
+	switch schema := schema.(type) {
+	case avro.Record:
+		return fn(schema)
+	case avro.Union:
+		if record, ok := schema[len(schema)-1].(avro.Record); ok && len(schema) == 2 && schema[0] == avro.Null() {
+			record, err := fn(record)
+			if err != nil {
+				return nil, err
+			}
+			return avro.Nullable(record), nil
+		}
+	}
+	return nil, errors.New("record checksums are only supported for records")
+}
+
+// datumCodec returns the codec of the values of the file, without the checksum field when records have
+// checksums, and with the values of dictionary fields when the file has dictionary fields.
+func (h *ocfHeader) datumCodec() *goavro.Codec {
+	if h.datum != nil {
+		return h.datum
+	}
+	if h.payload != nil {
+		return h.payload
+	}
+	return h.codec
+}
+
+// encode appends the binary encoding of the value, followed by its checksum if the file has record checksums.
+func (h *ocfHeader) encode(b []byte, value interface{}) ([]byte, error) {
+	if h.payload == nil {
+		return h.codec.BinaryFromNative(b, value)
+	}
+	n := len(b)
+	b, err := h.payload.BinaryFromNative(b, value)
+	if err != nil || value == nil {
+		return b, err
+	}
+	return binary.BigEndian.AppendUint32(b, crc32.Checksum(b[n:], crc32c)), nil
+}
+
+// decode decodes the next value, and verifies its checksum if the file has record checksums.
+func (h *ocfHeader) decode(b []byte) (interface{}, []byte, error) {
+	if h.payload == nil {
+		return h.codec.NativeFromBinary(b)
+	}
+	value, rest, err := h.payload.NativeFromBinary(b)
+	if err != nil || value == nil {
+		return value, rest, err
+	}
+	if len(rest) < crc32.Size {
+		return nil, nil, fmt.Errorf("%w: missing checksum", ErrChecksumMismatch)
+	}
+	if crc32.Checksum(b[:len(b)-len(rest)], crc32c) != binary.BigEndian.Uint32(rest) {
+		return nil, nil, ErrChecksumMismatch
+	}
+	return value, rest[crc32.Size:], nil
+}
@@ -0,0 +1,99 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestRecordChecksums(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	for _, tt := range []struct {
+		name string
+		opts protoavro.SchemaOptions
+	}{
+		{name: "null"},
+		{name: "deflate", opts: protoavro.SchemaOptions{Compression: protoavro.CompressionDeflate}},
+		{name: "parallel", opts: protoavro.SchemaOptions{ReadParallelism: 2}},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			books := testBooks(0, 10)
+			var b bytes.Buffer
+			opts := tt.opts
+			opts.RecordChecksums = true
+			marshaler, err := opts.NewMarshaler(desc, &b)
+			assert.NilError(t, err)
+			marshalBooks(t, marshaler, books)
+			unmarshaler, err := opts.NewUnmarshaler(bytes.NewReader(b.Bytes()))
+			assert.NilError(t, err)
+			defer unmarshaler.Close()
+			assert.DeepEqual(t, books, unmarshalBooks(t, unmarshaler), protocmp.Transform())
+			assert.NilError(t, unmarshaler.VerifyCompatible(desc))
+		})
+	}
+}
+
+func TestRecordChecksums_Schema(t *testing.T) {
+	var b bytes.Buffer
+	opts := protoavro.SchemaOptions{RecordChecksums: true}
+	marshaler, err := opts.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	marshalBooks(t, marshaler, testBooks(0, 1))
+	// other readers read the checksum as a field
+	r, err := goavro.NewOCFReader(&b)
+	assert.NilError(t, err)
+	schema, err := avro.Parse([]byte(r.Codec().Schema()))
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	assert.Equal(t, "crc32c", record.Properties[protoavro.ChecksumProperty])
+	assert.Equal(t, protoavro.ChecksumField, record.Fields[len(record.Fields)-1].Name)
+	assert.Assert(t, r.Scan())
+	value, err := r.Read()
+	assert.NilError(t, err)
+	checksum := value.(map[string]interface{})["google.example.library.v1.Book"].(map[string]interface{})["_checksum"]
+	assert.Equal(t, 4, len(checksum.([]byte)))
+}
+
+func TestRecordChecksums_Corrupted(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	corrupt := func(t *testing.T, opts protoavro.SchemaOptions) []byte {
+		var b bytes.Buffer
+		marshaler, err := opts.NewMarshaler(desc, &b)
+		assert.NilError(t, err)
+		marshalBooks(t, marshaler, testBooks(0, 3))
+		data := b.Bytes()
+		i := bytes.LastIndex(data, []byte("Rowling"))
+		assert.Assert(t, i > 0)
+		data[i] = 'H'
+		return data
+	}
+	t.Run("without checksums", func(t *testing.T) {
+		unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(corrupt(t, protoavro.SchemaOptions{})))
+		assert.NilError(t, err)
+		books := unmarshalBooks(t, unmarshaler)
+		assert.Equal(t, "J. K. Howling", books[2].GetAuthor())
+	})
+	t.Run("with checksums", func(t *testing.T) {
+		data := corrupt(t, protoavro.SchemaOptions{RecordChecksums: true})
+		unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(data))
+		assert.NilError(t, err)
+		var n int
+		for unmarshaler.Scan() {
+			var book library.Book
+			if err := unmarshaler.Unmarshal(&book); err != nil {
+				assert.Assert(t, errors.Is(err, protoavro.ErrChecksumMismatch))
+				break
+			}
+			n++
+		}
+		assert.Equal(t, 2, n)
+	})
+}
@@ -0,0 +1,52 @@
+package protoavro
+
+import (
+	"sync"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// SchemaFunc returns the Avro schema of a message with a custom codec.
+type SchemaFunc func(desc protoreflect.MessageDescriptor) (avro.Schema, error)
+
+// EncodeFunc returns the Avro JSON encoding of a message with a custom codec.
+// The encoding must match the schema returned by the SchemaFunc of the codec.
+// In particular, values of union schemas must be wrapped in a map keyed by
+// the name of the selected branch, and nil must be returned for null.
+type EncodeFunc func(message protoreflect.Message) (interface{}, error)
+
+// DecodeFunc decodes the Avro JSON encoded data of a message with a custom codec.
+// The data is nil when the message is null.
+type DecodeFunc func(data interface{}, message protoreflect.Message) error
+
+type messageCodec struct {
+	schema SchemaFunc
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+var messageCodecs = struct {
+	mu     sync.RWMutex
+	codecs map[protoreflect.FullName]messageCodec
+}{codecs: make(map[protoreflect.FullName]messageCodec)}
+
+// RegisterMessageCodec registers a custom codec for the message with the full name.
+// Messages with a custom codec are handled like well-known types: schema inference, encoding and decoding
+// of the message is delegated to the codec, instead of mapping the message to a record.
+// A registered codec takes precedence over the built-in handling of well-known types, and replaces any codec
+// previously registered for the same message.
+//
+// RegisterMessageCodec is typically called from an init function.
+func RegisterMessageCodec(fullName protoreflect.FullName, schema SchemaFunc, encode EncodeFunc, decode DecodeFunc) {
+	messageCodecs.mu.Lock()
+	defer messageCodecs.mu.Unlock()
+	messageCodecs.codecs[fullName] = messageCodec{schema: schema, encode: encode, decode: decode}
+}
+
+func lookupMessageCodec(fullName protoreflect.FullName) (messageCodec, bool) {
+	messageCodecs.mu.RLock()
+	defer messageCodecs.mu.RUnlock()
+	codec, ok := messageCodecs.codecs[fullName]
+	return codec, ok
+}
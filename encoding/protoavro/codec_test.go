@@ -0,0 +1,128 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/type/datetime"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func Test_RegisterMessageCodec(t *testing.T) {
+	const timeZone protoreflect.FullName = "google.type.TimeZone"
+	RegisterMessageCodec(
+		timeZone,
+		func(protoreflect.MessageDescriptor) (avro.Schema, error) {
+			return avro.Nullable(avro.String()), nil
+		},
+		func(message protoreflect.Message) (interface{}, error) {
+			return map[string]interface{}{"string": message.Interface().(*datetime.TimeZone).GetId()}, nil
+		},
+		func(data interface{}, message protoreflect.Message) error {
+			id, err := decodeStringLike(data, "string")
+			if err != nil {
+				return fmt.Errorf("google.type.TimeZone: %w", err)
+			}
+			proto.Merge(message.Interface(), &datetime.TimeZone{Id: id})
+			return nil
+		},
+	)
+	t.Cleanup(func() {
+		messageCodecs.mu.Lock()
+		defer messageCodecs.mu.Unlock()
+		delete(messageCodecs.codecs, timeZone)
+	})
+
+	msg := &examplev1.ExampleDateTime{
+		DateTime: &datetime.DateTime{
+			Year: 2021,
+			TimeOffset: &datetime.DateTime_TimeZone{
+				TimeZone: &datetime.TimeZone{Id: "Europe/Stockholm"},
+			},
+		},
+	}
+	schema, err := InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	dateTime := schema.(avro.Union)[1].(avro.Record).Fields[0].Type.(avro.Union)[1].(avro.Record)
+	timeZoneField := dateTime.Fields[len(dateTime.Fields)-1]
+	assert.Equal(t, timeZoneField.Name, "time_zone")
+	assert.DeepEqual(t, timeZoneField.Type, avro.Nullable(avro.String()))
+
+	encoded, err := SchemaOptions{}.encodeJSON(msg)
+	assert.NilError(t, err)
+	record := encoded.(map[string]interface{})["einride.avro.example.v1.ExampleDateTime"].(map[string]interface{})
+	dateTimeRecord := record["date_time"].(map[string]interface{})["google.type.DateTime"].(map[string]interface{})
+	assert.DeepEqual(t, dateTimeRecord["time_zone"], map[string]interface{}{"string": "Europe/Stockholm"})
+
+	// assert that it matches schema
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	_, err = codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+
+	decoded := &examplev1.ExampleDateTime{}
+	var opts SchemaOptions
+	assert.NilError(t, opts.decodeJSON(encoded, decoded))
+	assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+}
+
+func Test_ValidateEncoding(t *testing.T) {
+	const timeZone protoreflect.FullName = "google.type.TimeZone"
+	// the codec encodes the time zone without wrapping it in the branch of the union
+	RegisterMessageCodec(
+		timeZone,
+		func(protoreflect.MessageDescriptor) (avro.Schema, error) {
+			return avro.Nullable(avro.String()), nil
+		},
+		func(message protoreflect.Message) (interface{}, error) {
+			return message.Interface().(*datetime.TimeZone).GetId(), nil
+		},
+		func(data interface{}, message protoreflect.Message) error {
+			return nil
+		},
+	)
+	t.Cleanup(func() {
+		messageCodecs.mu.Lock()
+		defer messageCodecs.mu.Unlock()
+		delete(messageCodecs.codecs, timeZone)
+	})
+	valid := &examplev1.ExampleDateTime{DateTime: &datetime.DateTime{Year: 2021}}
+	invalid := &examplev1.ExampleDateTime{
+		DateTime: &datetime.DateTime{
+			Year:       2021,
+			TimeOffset: &datetime.DateTime_TimeZone{TimeZone: &datetime.TimeZone{Id: "Europe/Stockholm"}},
+		},
+	}
+	opts := SchemaOptions{ValidateEncoding: true}
+	_, err := opts.Encode(valid)
+	assert.NilError(t, err)
+	_, err = opts.Encode(invalid)
+	assert.ErrorContains(t, err, "validate encoding of einride.avro.example.v1.ExampleDateTime: ")
+	assert.ErrorContains(t, err, "date_time.time_zone")
+	var validationErr *avro.ValidationError
+	assert.Assert(t, errors.As(err, &validationErr))
+
+	// the mismatch is not caught without validation
+	_, err = SchemaOptions{}.Encode(invalid)
+	assert.NilError(t, err)
+
+	// encoders validate through the generic encoding
+	_, err = opts.MarshalAvroJSON(invalid)
+	assert.ErrorContains(t, err, "date_time.time_zone")
+	codec, err := NewCodec[*examplev1.ExampleDateTime](opts)
+	assert.NilError(t, err)
+	_, err = codec.Marshal(invalid)
+	assert.ErrorContains(t, err, "date_time.time_zone")
+	_, err = codec.Marshal(valid)
+	assert.NilError(t, err)
+}
@@ -0,0 +1,84 @@
+package protoavro
+
+import (
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// MarshalOptions are the options of a Codec that can be set for each call to Codec.MarshalWithOptions, since
+// they do not change the schema of the codec.
+type MarshalOptions struct {
+	// RecordCompression overrides SchemaOptions.RecordCompression.
+	RecordCompression string
+	// Deterministic overrides SchemaOptions.Deterministic.
+	Deterministic bool
+	// ValidateEncoding overrides SchemaOptions.ValidateEncoding.
+	ValidateEncoding bool
+}
+
+// UnmarshalOptions are the options of a Codec that can be set for each call to Codec.UnmarshalWithOptions,
+// since they do not change the schema of the codec.
+type UnmarshalOptions struct {
+	// DecodeMask overrides SchemaOptions.DecodeMask.
+	DecodeMask *fieldmaskpb.FieldMask
+	// RequireFields overrides SchemaOptions.RequireFields.
+	RequireFields bool
+	// CollectErrors overrides SchemaOptions.CollectErrors.
+	CollectErrors bool
+	// MaxRecordBytes overrides SchemaOptions.MaxRecordBytes.
+	MaxRecordBytes int
+	// MaxNestingDepth overrides SchemaOptions.MaxNestingDepth.
+	MaxNestingDepth int
+}
+
+// MarshalOptions returns the per-call marshal options of the codec, which Codec.Marshal uses.
+func (c *Codec[T]) MarshalOptions() MarshalOptions {
+	return MarshalOptions{
+		RecordCompression: c.opts.RecordCompression,
+		Deterministic:     c.opts.Deterministic,
+		ValidateEncoding:  c.opts.ValidateEncoding,
+	}
+}
+
+// UnmarshalOptions returns the per-call unmarshal options of the codec, which Codec.Unmarshal uses.
+func (c *Codec[T]) UnmarshalOptions() UnmarshalOptions {
+	return UnmarshalOptions{
+		DecodeMask:      c.opts.DecodeMask,
+		RequireFields:   c.opts.RequireFields,
+		CollectErrors:   c.opts.CollectErrors,
+		MaxRecordBytes:  c.opts.MaxRecordBytes,
+		MaxNestingDepth: c.opts.MaxNestingDepth,
+	}
+}
+
+// MarshalWithOptions encodes the message like Marshal, with the options of the codec overridden by opts, so
+// that settings can differ per call, for example per tenant, while the compiled codec is reused.
+// Options that are not set in opts are reset to their zero values, so callers that override a single option
+// start from the options returned by Codec.MarshalOptions.
+func (c *Codec[T]) MarshalWithOptions(message T, opts MarshalOptions) ([]byte, error) {
+	o := c.opts
+	o.RecordCompression = opts.RecordCompression
+	o.Deterministic = opts.Deterministic
+	o.ValidateEncoding = opts.ValidateEncoding
+	return c.marshal(message, o)
+}
+
+// UnmarshalWithOptions decodes a message like Unmarshal, with the options of the codec overridden by opts, so
+// that settings can differ per call, for example per tenant, while the compiled codec is reused.
+// Options that are not set in opts are reset to their zero values, so callers that override a single option
+// start from the options returned by Codec.UnmarshalOptions. Messages are decoded directly from the binary
+// data when both the options of the codec and opts allow it.
+func (c *Codec[T]) UnmarshalWithOptions(b []byte, opts UnmarshalOptions) (T, error) {
+	o := c.opts
+	o.DecodeMask = opts.DecodeMask
+	o.RequireFields = opts.RequireFields
+	o.CollectErrors = opts.CollectErrors
+	o.MaxRecordBytes = opts.MaxRecordBytes
+	o.MaxNestingDepth = opts.MaxNestingDepth
+	var decoder *binaryDecoder
+	var zero T
+	if c.decoder != nil && o.checkDirectDecoding(zero.ProtoReflect().Descriptor()) == nil {
+		// the decoder is compiled from options that do not vary per call, except for RequireFields and limits
+		decoder = o.withDecoderOptions(c.decoder.root)
+	}
+	return c.unmarshal(b, o, decoder)
+}
@@ -0,0 +1,59 @@
+package protoavro_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestCodec_WithOptions(t *testing.T) {
+	codec, err := protoavro.NewCodec[*library.CreateBookRequest](protoavro.SchemaOptions{})
+	assert.NilError(t, err)
+	msg := &library.CreateBookRequest{Book: &library.Book{Title: "Dune", Author: "Frank Herbert"}}
+
+	t.Run("marshal", func(t *testing.T) {
+		opts := codec.MarshalOptions()
+		assert.DeepEqual(t, protoavro.MarshalOptions{}, opts)
+		opts.RecordCompression = protoavro.CompressionDeflate
+		compressed, err := codec.MarshalWithOptions(msg, opts)
+		assert.NilError(t, err)
+		uncompressed, err := codec.Marshal(msg)
+		assert.NilError(t, err)
+		assert.Assert(t, string(compressed) != string(uncompressed))
+		got, err := codec.Unmarshal(compressed)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, msg, got, protocmp.Transform())
+		_, err = codec.MarshalWithOptions(msg, protoavro.MarshalOptions{RecordCompression: "unknown"})
+		assert.ErrorContains(t, err, "compress record")
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		b, err := codec.Marshal(msg)
+		assert.NilError(t, err)
+		// the codec does not require fields, and decodes the message directly
+		got, err := codec.Unmarshal(b)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, msg, got, protocmp.Transform())
+		opts := codec.UnmarshalOptions()
+		opts.RequireFields = true
+		_, err = codec.UnmarshalWithOptions(b, opts)
+		assert.ErrorContains(t, err, "missing required field google.example.library.v1.CreateBookRequest.parent")
+		// decoded through goavro
+		opts.RequireFields = false
+		opts.DecodeMask = &fieldmaskpb.FieldMask{Paths: []string{"book.title"}}
+		got, err = codec.UnmarshalWithOptions(b, opts)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, &library.CreateBookRequest{Book: &library.Book{Title: "Dune"}}, got, protocmp.Transform())
+		_, err = codec.UnmarshalWithOptions(b, protoavro.UnmarshalOptions{MaxRecordBytes: 1})
+		assert.Assert(t, errors.Is(err, protoavro.ErrLimitExceeded))
+		// the options of the codec are not changed
+		got, err = codec.Unmarshal(b)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, msg, got, protocmp.Transform())
+	})
+}
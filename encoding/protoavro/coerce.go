@@ -0,0 +1,136 @@
+package protoavro
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// coerceScalar converts a generic Avro value of a boolean, string or integer field to the Avro type of the
+// kind of the field, with CoerceScalars. Values of the type of the kind, nulls, and values of fields of other
+// kinds are returned as they are.
+func coerceScalar(data interface{}, field protoreflect.FieldDescriptor) (interface{}, error) {
+	value := unwrapUnion(data)
+	if value == nil {
+		return data, nil
+	}
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return coerceBool(value)
+	case protoreflect.StringKind:
+		return coerceString(value)
+	case protoreflect.Int32Kind, protoreflect.Sfixed32Kind, protoreflect.Sint32Kind,
+		protoreflect.Int64Kind, protoreflect.Sfixed64Kind, protoreflect.Sint64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return coerceInteger(value, field.Kind())
+	}
+	return data, nil
+}
+
+// coerceBool converts the strings accepted by strconv.ParseBool, such as "true" and "False", and the integers
+// 0 and 1, to booleans.
+func coerceBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("coerce %q to boolean: %w", v, err)
+		}
+		return b, nil
+	case int, int32, int64:
+		switch integerOf(v) {
+		case 0:
+			return false, nil
+		case 1:
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("can not coerce %v of type %T to boolean", value, value)
+}
+
+// coerceString converts booleans, integers and floating point numbers to their shortest string representation.
+func coerceString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int32, int64:
+		return strconv.FormatInt(integerOf(v), 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	}
+	return "", fmt.Errorf("can not coerce %v of type %T to string", value, value)
+}
+
+// coerceInteger converts integers of either width, decimal strings, booleans, and floating point numbers without
+// a fractional part to integers. Integers of 32-bit kinds must be in the range of the kind.
+func coerceInteger(value interface{}, kind protoreflect.Kind) (int64, error) {
+	var i int64
+	switch v := value.(type) {
+	case int, int32, int64:
+		i = integerOf(v)
+	case string:
+		var err error
+		if kind == protoreflect.Uint64Kind || kind == protoreflect.Fixed64Kind {
+			// unsigned 64-bit integers are encoded as longs of the same bits
+			var u uint64
+			u, err = strconv.ParseUint(v, 10, 64)
+			i = int64(u)
+		} else {
+			i, err = strconv.ParseInt(v, 10, 64)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("coerce %q to integer: %w", v, err)
+		}
+	case bool:
+		if v {
+			i = 1
+		}
+	case float32, float64:
+		f := float64FromFloat(v)
+		// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("can not coerce %v to integer", v)
+		}
+		i = int64(f)
+	default:
+		return 0, fmt.Errorf("can not coerce %v of type %T to integer", value, value)
+	}
+	var minValue, maxValue int64 = math.MinInt64, math.MaxInt64
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sfixed32Kind, protoreflect.Sint32Kind:
+		minValue, maxValue = math.MinInt32, math.MaxInt32
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		minValue, maxValue = 0, math.MaxUint32
+	}
+	if i < minValue || i > maxValue {
+		return 0, fmt.Errorf("%d out of range of %s", i, kind)
+	}
+	return i, nil
+}
+
+// integerOf returns the value of an int, int32 or int64.
+func integerOf(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	}
+	return value.(int64)
+}
+
+func float64FromFloat(value interface{}) float64 {
+	if f, ok := value.(float32); ok {
+		return float64(f)
+	}
+	return value.(float64)
+}
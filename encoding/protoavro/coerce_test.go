@@ -0,0 +1,136 @@
+package protoavro
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestCoerceScalars(t *testing.T) {
+	opts := SchemaOptions{CoerceScalars: true}
+	for _, tt := range []struct {
+		name          string
+		data          map[string]interface{}
+		expected      *examplev1.ExampleScalars
+		errorContains string
+	}{
+		{
+			name:     "booleans",
+			data:     map[string]interface{}{"bool": map[string]interface{}{"string": "True"}},
+			expected: &examplev1.ExampleScalars{Bool: true},
+		},
+		{
+			name:     "boolean of int",
+			data:     map[string]interface{}{"bool": map[string]interface{}{"long": int64(1)}},
+			expected: &examplev1.ExampleScalars{Bool: true},
+		},
+		{
+			name:          "boolean of other int",
+			data:          map[string]interface{}{"bool": int64(2)},
+			errorContains: "field bool: can not coerce 2 of type int64 to boolean",
+		},
+		{
+			name:          "boolean of other string",
+			data:          map[string]interface{}{"bool": "yes"},
+			errorContains: `field bool: coerce "yes" to boolean`,
+		},
+		{
+			name:     "strings",
+			data:     map[string]interface{}{"string": map[string]interface{}{"long": int64(42)}},
+			expected: &examplev1.ExampleScalars{String_: "42"},
+		},
+		{
+			name:     "string of float",
+			data:     map[string]interface{}{"string": float32(1.5)},
+			expected: &examplev1.ExampleScalars{String_: "1.5"},
+		},
+		{
+			name: "integers",
+			data: map[string]interface{}{
+				"int32":   map[string]interface{}{"long": int64(-1)},
+				"int64":   map[string]interface{}{"int": int32(2)},
+				"uint32":  "3",
+				"uint64":  "18446744073709551615",
+				"sint32":  true,
+				"sint64":  float64(4),
+				"fixed32": map[string]interface{}{"string": "5"},
+			},
+			expected: &examplev1.ExampleScalars{
+				Int32:   -1,
+				Int64:   2,
+				Uint32:  3,
+				Uint64:  math.MaxUint64,
+				Sint32:  1,
+				Sint64:  4,
+				Fixed32: 5,
+			},
+		},
+		{
+			name:          "int out of range",
+			data:          map[string]interface{}{"int32": int64(math.MaxInt32 + 1)},
+			errorContains: "field int32: 2147483648 out of range of int32",
+		},
+		{
+			name:          "unsigned int out of range",
+			data:          map[string]interface{}{"uint32": "-1"},
+			errorContains: "field uint32: -1 out of range of uint32",
+		},
+		{
+			name:          "fractional float",
+			data:          map[string]interface{}{"int64": 1.5},
+			errorContains: "field int64: can not coerce 1.5 to integer",
+		},
+		{
+			name:          "bytes",
+			data:          map[string]interface{}{"string": []byte("a")},
+			errorContains: "can not coerce [97] of type []uint8 to string",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var actual examplev1.ExampleScalars
+			err := opts.Decode(tt.data, &actual)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, &actual, protocmp.Transform())
+			// values are not coerced by default
+			assert.Assert(t, SchemaOptions{}.Decode(tt.data, &examplev1.ExampleScalars{}) != nil)
+		})
+	}
+
+	t.Run("object container file", func(t *testing.T) {
+		// a file written by another producer, with a string for the read field
+		var b bytes.Buffer
+		w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+			W: &b,
+			Schema: `{"type":"record","name":"Book","namespace":"google.example.library.v1","fields":[` +
+				`{"name":"name","type":"string"},{"name":"read","type":["null","string"]}]}`,
+		})
+		assert.NilError(t, err)
+		assert.NilError(t, w.Append([]interface{}{
+			map[string]interface{}{"name": "shelves/1/books/1", "read": map[string]interface{}{"string": "true"}},
+			map[string]interface{}{"name": "shelves/1/books/2", "read": nil},
+		}))
+		unmarshaler, err := opts.NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		var books []*library.Book
+		for unmarshaler.Scan() {
+			var book library.Book
+			assert.NilError(t, unmarshaler.Unmarshal(&book))
+			books = append(books, &book)
+		}
+		assert.DeepEqual(t, []*library.Book{
+			{Name: "shelves/1/books/1", Read: true},
+			{Name: "shelves/1/books/2"},
+		}, books, protocmp.Transform())
+	})
+}
@@ -0,0 +1,85 @@
+package protoavro
+
+import (
+	"fmt"
+	"strings"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// VerifyCompatible checks, with default SchemaOptions, that data written with the writer schema can be decoded
+// into messages described by desc.
+func VerifyCompatible(writer avro.Schema, desc protoreflect.MessageDescriptor) error {
+	return SchemaOptions{}.VerifyCompatible(writer, desc)
+}
+
+// VerifyCompatible checks that data written with the writer schema, for example the schema of an Object
+// Container File, can be decoded into messages described by desc, before any data is decoded.
+//
+// Fields of the message that are missing in the writer schema are left unset when decoding. Record fields of
+// the writer schema without a matching message field can only be decoded with PreserveUnknownFields, and
+// values must be readable as the types of the schema inferred for the message. A *CompatibilityError
+// describes every incompatibility.
+func (o SchemaOptions) VerifyCompatible(writer avro.Schema, desc protoreflect.MessageDescriptor) error {
+	reader, err := o.InferSchema(desc)
+	if err != nil {
+		return fmt.Errorf("verify compatible: infer schema: %w", err)
+	}
+	compatibilityErr := &CompatibilityError{Message: desc.FullName()}
+	for _, incompatibility := range avro.CheckCompatibility(reader, writer) {
+		if incompatibility.Type == avro.MissingFieldDefault {
+			// message fields missing in the writer schema are left unset
+			continue
+		}
+		compatibilityErr.Incompatibilities = append(compatibilityErr.Incompatibilities, incompatibility)
+	}
+	if !o.PreserveUnknownFields {
+		// fields of the writer schema that are missing in the message are missing fields of the reverse check
+		for _, incompatibility := range avro.CheckCompatibility(writer, reader) {
+			if incompatibility.Type == avro.MissingFieldDefault {
+				compatibilityErr.UnknownFields = append(compatibilityErr.UnknownFields, incompatibility.Path)
+			}
+		}
+	}
+	if len(compatibilityErr.Incompatibilities) == 0 && len(compatibilityErr.UnknownFields) == 0 {
+		return nil
+	}
+	return compatibilityErr
+}
+
+// CompatibilityError is returned by VerifyCompatible when data written with a writer schema can not be decoded
+// into a message. Incompatibilities are usually caused by a skew between the version of the message that
+// wrote the data, and the version of the message that reads it.
+type CompatibilityError struct {
+	// Message is the full name of the message the data is decoded into.
+	Message protoreflect.FullName
+	// UnknownFields are the paths of the record fields in the writer schema without a matching message field.
+	UnknownFields []string
+	// Incompatibilities are the incompatibilities of reading the writer schema with the schema inferred for
+	// the message.
+	Incompatibilities []avro.Incompatibility
+}
+
+func (e *CompatibilityError) Error() string {
+	parts := []string{fmt.Sprintf("data is incompatible with message %s", e.Message)}
+	if len(e.UnknownFields) > 0 {
+		parts = append(parts, fmt.Sprintf(
+			"fields %s are not in the message, which suggests that the data was written by a newer version"+
+				" of the message (set PreserveUnknownFields to keep them as unknown fields)",
+			strings.Join(e.UnknownFields, ", "),
+		))
+	}
+	if len(e.Incompatibilities) > 0 {
+		descriptions := make([]string, 0, len(e.Incompatibilities))
+		for _, incompatibility := range e.Incompatibilities {
+			descriptions = append(descriptions, incompatibility.String())
+		}
+		parts = append(parts, fmt.Sprintf(
+			"%s, which suggests that the types of fields changed between the version of the message that"+
+				" wrote the data and the version that reads it",
+			strings.Join(descriptions, ", "),
+		))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,135 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"gotest.tools/v3/assert"
+)
+
+// bookWriterSchema returns the schema of library.Book, with the fields changed by fn.
+func bookWriterSchema(t *testing.T, fn func(fields []avro.Field) []avro.Field) avro.Schema {
+	t.Helper()
+	schema, err := InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	record.Fields = fn(append([]avro.Field(nil), record.Fields...))
+	return avro.Nullable(record)
+}
+
+func TestVerifyCompatible(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	for _, tt := range []struct {
+		name              string
+		opts              SchemaOptions
+		writer            func(fields []avro.Field) []avro.Field
+		unknownFields     []string
+		incompatibilities []avro.Incompatibility
+	}{
+		{
+			name:   "identical",
+			writer: func(fields []avro.Field) []avro.Field { return fields },
+		},
+		{
+			name:   "removed field",
+			writer: func(fields []avro.Field) []avro.Field { return fields[:2] },
+		},
+		{
+			name: "added field",
+			writer: func(fields []avro.Field) []avro.Field {
+				return append(fields, avro.Field{Name: "isbn", Type: avro.Nullable(avro.String())})
+			},
+			unknownFields: []string{"isbn"},
+		},
+		{
+			name: "added field, preserved",
+			opts: SchemaOptions{PreserveUnknownFields: true},
+			writer: func(fields []avro.Field) []avro.Field {
+				return append(fields, avro.Field{Name: "isbn", Type: avro.Nullable(avro.String())})
+			},
+		},
+		{
+			name: "changed type",
+			writer: func(fields []avro.Field) []avro.Field {
+				fields[3].Type = avro.Nullable(avro.String())
+				return fields
+			},
+			incompatibilities: []avro.Incompatibility{
+				{Type: avro.MissingUnionBranch, Path: "read", Name: "string"},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.VerifyCompatible(bookWriterSchema(t, tt.writer), desc)
+			if len(tt.unknownFields) == 0 && len(tt.incompatibilities) == 0 {
+				assert.NilError(t, err)
+				return
+			}
+			var compatibilityErr *CompatibilityError
+			assert.Assert(t, errors.As(err, &compatibilityErr))
+			assert.Equal(t, desc.FullName(), compatibilityErr.Message)
+			assert.DeepEqual(t, tt.unknownFields, compatibilityErr.UnknownFields)
+			assert.DeepEqual(t, tt.incompatibilities, compatibilityErr.Incompatibilities)
+		})
+	}
+}
+
+func TestCompatibilityError(t *testing.T) {
+	err := &CompatibilityError{
+		Message:           "google.example.library.v1.Book",
+		UnknownFields:     []string{"isbn"},
+		Incompatibilities: []avro.Incompatibility{{Type: avro.MissingUnionBranch, Path: "read", Name: "string"}},
+	}
+	assert.Equal(
+		t,
+		err.Error(),
+		"data is incompatible with message google.example.library.v1.Book; "+
+			"fields isbn are not in the message, which suggests that the data was written by a newer version "+
+			"of the message (set PreserveUnknownFields to keep them as unknown fields); "+
+			"read: writer union branch string is missing in reader, which suggests that the types of fields "+
+			"changed between the version of the message that wrote the data and the version that reads it",
+	)
+}
+
+func TestUnmarshaler_VerifyCompatible(t *testing.T) {
+	writer := bookWriterSchema(t, func(fields []avro.Field) []avro.Field {
+		return append(fields, avro.Field{Name: "isbn", Type: avro.Nullable(avro.String())})
+	})
+	schemaBytes, err := json.Marshal(writer)
+	assert.NilError(t, err)
+	var b bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{W: &b, Schema: string(schemaBytes)})
+	assert.NilError(t, err)
+	assert.NilError(t, w.Append([]interface{}{
+		map[string]interface{}{
+			"google.example.library.v1.Book": map[string]interface{}{
+				"name":   map[string]interface{}{"string": "shelves/1/books/1"},
+				"author": nil,
+				"title":  nil,
+				"read":   nil,
+				"isbn":   map[string]interface{}{"string": "978-0747532699"},
+			},
+		},
+	}))
+
+	unmarshaler, err := NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	assert.ErrorContains(
+		t,
+		unmarshaler.VerifyCompatible((&library.Book{}).ProtoReflect().Descriptor()),
+		"fields isbn are not in the message",
+	)
+	// decoding errors are explained by the incompatibility
+	assert.Assert(t, unmarshaler.Scan())
+	err = unmarshaler.Unmarshal(&library.Book{})
+	assert.ErrorContains(t, err, "unexpected field isbn in message google.example.library.v1.Book")
+	var compatibilityErr *CompatibilityError
+	assert.Assert(t, errors.As(err, &compatibilityErr))
+	assert.DeepEqual(t, []string{"isbn"}, compatibilityErr.UnknownFields)
+}
@@ -0,0 +1,35 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"gotest.tools/v3/assert"
+)
+
+func Test_Context(t *testing.T) {
+	msgs := books(10)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var b bytes.Buffer
+	marshaler, err := protoavro.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.Assert(t, errors.Is(marshaler.MarshalContext(canceled, msgs...), context.Canceled))
+	assert.Assert(t, errors.Is(marshaler.MarshalBatchContext(canceled, msgs), context.Canceled))
+	_, err = protoavro.SchemaOptions{}.MarshalBatchContext(canceled, msgs)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+	assert.NilError(t, marshaler.MarshalContext(context.Background(), msgs...))
+
+	unmarshaler, err := protoavro.NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	assert.Assert(t, unmarshaler.ScanContext(context.Background()))
+	assert.Assert(t, errors.Is(unmarshaler.UnmarshalContext(canceled, &library.Book{}), context.Canceled))
+	assert.NilError(t, unmarshaler.UnmarshalContext(context.Background(), &library.Book{}))
+	assert.Assert(t, !unmarshaler.ScanContext(canceled))
+	assert.Assert(t, errors.Is(unmarshaler.Err(), context.Canceled))
+}
@@ -10,71 +10,178 @@ import (
 // decodeJSON decodes the JSON encoded avro data and places the
 // result in msg.
 func (o *SchemaOptions) decodeJSON(data interface{}, msg proto.Message) error {
-	return o.decodeMessage(data, msg.ProtoReflect())
+	if err := o.checkNestingDepth(data); err != nil {
+		return err
+	}
+	opts := o.withNames(msg.ProtoReflect().Descriptor())
+	if raw, ok := opts.rawProto(data, msg.ProtoReflect().Descriptor()); ok && len(opts.DecodeMask.GetPaths()) == 0 {
+		if err := proto.Unmarshal(raw, msg); err != nil {
+			return fmt.Errorf("unmarshal %s: %w", RawProtoField, err)
+		}
+		if opts.RequireFields {
+			return checkRequiredFields(msg.ProtoReflect(), newFieldMaskTree(nil))
+		}
+		return nil
+	}
+	data = opts.stripEnvelope(data, msg.ProtoReflect().Descriptor())
+	data = opts.unflattenRecord(data, msg.ProtoReflect().Descriptor())
+	mask := newFieldMaskTree(opts.DecodeMask)
+	if err := opts.decodeMessage(data, msg.ProtoReflect(), mask); err != nil {
+		return err
+	}
+	if opts.RequireFields {
+		return checkRequiredFields(msg.ProtoReflect(), mask)
+	}
+	return nil
 }
 
-func (o *SchemaOptions) decodeMessage(data interface{}, msg protoreflect.Message) error {
+func (o *SchemaOptions) decodeMessage(data interface{}, msg protoreflect.Message, mask fieldMaskTree) error {
 	if data == nil {
 		return nil
 	}
+	if o.isWKT(msg.Descriptor().FullName()) {
+		data = o.wrapLenientWKT(data, msg.Descriptor())
+	}
+	if codec, ok := lookupMessageCodec(msg.Descriptor().FullName()); ok {
+		return codec.decode(data, msg)
+	}
 	d, ok := data.(map[string]interface{})
 	if !ok {
 		return fmt.Errorf("expected message encoded as map[string]interface{}, got %T", data)
 	}
 
-	if isWKT(msg.Descriptor().FullName()) {
-		return decodeWKT(d, msg)
+	if o.isWKT(msg.Descriptor().FullName()) {
+		return o.decodeWKT(d, msg)
 	}
 	// unwrap union
 	desc := msg.Descriptor()
-	if msgData, ok := d[string(desc.FullName())]; len(d) == 1 && ok {
-		return o.decodeMessage(msgData, msg)
+	if msgData, ok := d[o.avroName(desc)]; len(d) == 1 && ok {
+		return o.decodeMessage(msgData, msg, mask)
 	}
+	if msgData, ok := o.unwrapDepthRecord(d, desc); ok {
+		return o.decodeMessage(msgData, msg, mask)
+	}
+	d = o.untransformRecord(d, desc)
+	var unknown map[string]interface{}
+	errs := fieldErrors{}
+	names := o.recordFieldNames(desc)
 	for fieldName, fieldValue := range d {
-		fd, ok := findField(desc, fieldName)
+		fd, ok := o.findRecordField(desc, names, fieldName)
+		if !ok && o.PreserveUnknownFields {
+			if unknown == nil {
+				unknown = make(map[string]interface{})
+			}
+			unknown[fieldName] = fieldValue
+			continue
+		}
+		var err error
 		if !ok {
-			return fmt.Errorf("unexpected field %s", fieldName)
+			err = fmt.Errorf("unexpected field %s in message %s", fieldName, desc.FullName())
+		} else {
+			err = o.decodeRecordField(fieldValue, msg, fd, mask)
 		}
-		if err := o.decodeField(fieldValue, msg, fd); err != nil {
+		if err != nil {
+			if !o.CollectErrors {
+				return err
+			}
+			// the other fields are decoded, to report the errors of all fields
+			errs.add(fieldName, fieldName, err)
+		}
+	}
+	if len(unknown) > 0 {
+		if err := preserveUnknownFields(msg, unknown); err != nil {
 			return err
 		}
 	}
-	return nil
+	return errs.err()
+}
+
+// decodeRecordField decodes the value of a record field into the message field.
+func (o *SchemaOptions) decodeRecordField(
+	fieldValue interface{},
+	msg protoreflect.Message,
+	fd protoreflect.FieldDescriptor,
+	mask fieldMaskTree,
+) error {
+	fieldMask, ok := mask.child(string(fd.Name()))
+	if !ok {
+		return nil
+	}
+	if fieldValue != nil {
+		if err := checkOneofCase(msg, fd); err != nil {
+			return err
+		}
+	}
+	if o.encodesJSONString(fd) {
+		if value, ok := unwrapUnion(fieldValue).(string); ok {
+			return decodeJSONString(value, msg, fd)
+		}
+	}
+	if o.encryptsField(fd) {
+		if value, ok := unwrapUnion(fieldValue).([]byte); ok {
+			return o.decryptField(value, msg, fd)
+		}
+	}
+	return o.decodeField(fieldValue, msg, fd, fieldMask)
 }
 
-func (o *SchemaOptions) decodeField(data interface{}, val protoreflect.Message, f protoreflect.FieldDescriptor) error {
+func (o *SchemaOptions) decodeField(
+	data interface{},
+	val protoreflect.Message,
+	f protoreflect.FieldDescriptor,
+	mask fieldMaskTree,
+) error {
 	if data == nil {
 		return nil
 	}
 	switch {
 	case f.IsMap():
 		mp := val.NewField(f).Map()
-		if err := o.decodeMap(data, f, mp); err != nil {
+		err := o.decodeMap(data, f, mp, mask)
+		if _, ok := err.(*DecodeErrors); err != nil && !ok {
 			return err
 		}
 		val.Set(f, protoreflect.ValueOfMap(mp))
-		return nil
+		return err
 	case f.IsList():
 		listData, err := decodeListLike(data, "array")
 		if err != nil {
 			return err
 		}
+		branch, _, err := o.unwrapsListItems(f)
+		if err != nil {
+			return err
+		}
 		list := val.NewField(f).List()
-		for _, el := range listData {
+		errs := fieldErrors{}
+		for i, el := range listData {
 			if el == nil {
-				list.Append(list.NewElement())
+				item, ok, err := o.nullListItem(list, f)
+				if err != nil {
+					return err
+				}
+				if ok {
+					list.Append(item)
+				}
 				continue
 			}
-			fieldValue, err := o.decodeFieldKind(el, list.NewElement(), f)
+			if branch != "" {
+				el = map[string]interface{}{branch: el}
+			}
+			fieldValue, err := o.decodeFieldKind(el, list.NewElement(), f, mask)
 			if err != nil {
-				return err
+				if !o.CollectErrors {
+					return err
+				}
+				errs.add("", fmt.Sprintf("[%d]", i), err)
+				continue
 			}
 			list.Append(fieldValue)
 		}
 		val.Set(f, protoreflect.ValueOfList(list))
-		return nil
+		return errs.err()
 	default:
-		fieldValue, err := o.decodeFieldKind(data, val.NewField(f), f)
+		fieldValue, err := o.decodeFieldKind(data, val.NewField(f), f, mask)
 		if err != nil {
 			return err
 		}
@@ -87,10 +194,17 @@ func (o *SchemaOptions) decodeFieldKind(
 	data interface{},
 	mutable protoreflect.Value,
 	f protoreflect.FieldDescriptor,
+	mask fieldMaskTree,
 ) (protoreflect.Value, error) {
+	if o.CoerceScalars {
+		var err error
+		if data, err = coerceScalar(data, f); err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", f.Name(), err)
+		}
+	}
 	switch f.Kind() {
 	case protoreflect.MessageKind, protoreflect.GroupKind:
-		if err := o.decodeMessage(data, mutable.Message()); err != nil {
+		if err := o.decodeMessage(data, mutable.Message(), mask); err != nil {
 			return protoreflect.Value{}, err
 		}
 		return mutable, nil
@@ -118,12 +232,18 @@ func (o *SchemaOptions) decodeFieldKind(
 			return protoreflect.Value{}, fmt.Errorf("field %s: %w", f.Name(), err)
 		}
 		return protoreflect.ValueOfInt64(i), nil
-	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+	case protoreflect.Uint32Kind:
 		i, err := decodeIntLike(data, "long")
 		if err != nil {
 			return protoreflect.Value{}, fmt.Errorf("field %s: %w", f.Name(), err)
 		}
 		return protoreflect.ValueOfUint32(uint32(i)), nil
+	case protoreflect.Fixed32Kind:
+		i, err := decodeIntLike(data, "int")
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", f.Name(), err)
+		}
+		return protoreflect.ValueOfUint32(uint32(i)), nil
 	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
 		i, err := decodeIntLike(data, "long")
 		if err != nil {
@@ -131,28 +251,38 @@ func (o *SchemaOptions) decodeFieldKind(
 		}
 		return protoreflect.ValueOfUint64(uint64(i)), nil
 	case protoreflect.BytesKind:
+		if size := o.fixedSize(f); size > 0 {
+			bs, err := decodeBytesLike(data, o.fixedName(f))
+			if err != nil {
+				return protoreflect.Value{}, fmt.Errorf("field %s: %w", f.Name(), err)
+			}
+			if err := checkFixedSize(f, size, bs); err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfBytes(bs), nil
+		}
 		bs, err := decodeBytesLike(data, "bytes")
 		if err != nil {
 			return protoreflect.Value{}, fmt.Errorf("field %s: %w", f.Name(), err)
 		}
 		return protoreflect.ValueOfBytes(bs), nil
 	case protoreflect.EnumKind:
-		str, err := decodeStringLike(data, string(f.Enum().FullName()))
+		str, err := decodeStringLike(data, o.avroName(f.Enum()))
 		if err != nil {
 			return protoreflect.Value{}, fmt.Errorf("field %s: %w", f.Name(), err)
 		}
-		if v := f.Enum().Values().ByName(protoreflect.Name(str)); v != nil {
+		if v := o.enumValue(f.Enum(), str); v != nil {
 			return protoreflect.ValueOfEnum(v.Number()), nil
 		}
 		return protoreflect.ValueOfEnum(0), nil
 	case protoreflect.DoubleKind:
-		dbl, ok := data.(float64)
+		dbl, ok := unwrapUnion(data).(float64)
 		if !ok {
 			return protoreflect.Value{}, fmt.Errorf("field %s: expected float64, got %T", f.Name(), data)
 		}
 		return protoreflect.ValueOfFloat64(dbl), nil
 	case protoreflect.FloatKind:
-		flt, ok := data.(float32)
+		flt, ok := unwrapUnion(data).(float32)
 		if !ok {
 			return protoreflect.Value{}, fmt.Errorf("field %s: expected float32, got %T", f.Name(), data)
 		}
@@ -161,11 +291,34 @@ func (o *SchemaOptions) decodeFieldKind(
 	return protoreflect.Value{}, fmt.Errorf("unexpected kind %s", f.Kind())
 }
 
-func findField(desc protoreflect.MessageDescriptor, name string) (protoreflect.FieldDescriptor, bool) {
-	if fd := desc.Fields().ByJSONName(name); fd != nil {
+// findField returns the field of the message of a field of its record, by the name of the field in records
+// first, and by its other names otherwise, see SchemaOptions.UseJSONNames and SchemaOptions.FieldNameFunc.
+func (o SchemaOptions) findField(
+	desc protoreflect.MessageDescriptor,
+	name string,
+) (protoreflect.FieldDescriptor, bool) {
+	return o.findRecordField(desc, o.recordFieldNames(desc), name)
+}
+
+// findRecordField is findField with the names of the fields of the message in its records.
+func (o SchemaOptions) findRecordField(
+	desc protoreflect.MessageDescriptor,
+	names []string,
+	name string,
+) (protoreflect.FieldDescriptor, bool) {
+	for i, fieldName := range names {
+		if fieldName == name {
+			return desc.Fields().Get(i), true
+		}
+	}
+	first, second := desc.Fields().ByTextName, desc.Fields().ByJSONName
+	if o.UseJSONNames {
+		first, second = second, first
+	}
+	if fd := first(name); fd != nil {
 		return fd, true
 	}
-	if fd := desc.Fields().ByTextName(name); fd != nil {
+	if fd := second(name); fd != nil {
 		return fd, true
 	}
 	return nil, false
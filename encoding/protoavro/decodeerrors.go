@@ -0,0 +1,90 @@
+package protoavro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DecodeErrors is the error of decoding a record with SchemaOptions.CollectErrors, with the errors of every
+// field of the record that failed to decode.
+type DecodeErrors struct {
+	// Errors holds the errors of the fields, ordered by the names of the fields of the root record.
+	Errors []*FieldError
+}
+
+// Error implements error.
+func (e *DecodeErrors) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d fields failed to decode: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the fields, for errors.Is and errors.As.
+func (e *DecodeErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// FieldError is the error of a field that failed to decode.
+type FieldError struct {
+	// Path is the path of the field within the record, for example "shelf.books[2].title", where items of lists
+	// and entries of maps are indexed by their position in the Avro array.
+	Path string
+	// Err is the error of the field.
+	Err error
+}
+
+// Error implements error.
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the error of the field.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldErrors collects the errors of the fields of a message, with CollectErrors.
+type fieldErrors map[string][]*FieldError
+
+// add adds the error of a field, or the errors of the fields of a message of the field.
+func (f fieldErrors) add(field, path string, err error) {
+	if nested, ok := err.(*DecodeErrors); ok {
+		for _, fieldErr := range nested.Errors {
+			f[field] = append(f[field], &FieldError{Path: joinFieldPath(path, fieldErr.Path), Err: fieldErr.Err})
+		}
+		return
+	}
+	f[field] = append(f[field], &FieldError{Path: path, Err: err})
+}
+
+// joinFieldPath joins the path of a field and a path within the value of the field.
+func joinFieldPath(path, nested string) string {
+	if path == "" || strings.HasPrefix(nested, "[") {
+		return path + nested
+	}
+	return path + "." + nested
+}
+
+// err returns the collected errors ordered by the names of their fields, or nil if there are none.
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	fields := make([]string, 0, len(f))
+	for field := range f {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	var errs DecodeErrors
+	for _, field := range fields {
+		errs.Errors = append(errs.Errors, f[field]...)
+	}
+	return &errs
+}
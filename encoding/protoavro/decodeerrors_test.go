@@ -0,0 +1,119 @@
+package protoavro
+
+import (
+	"errors"
+	"testing"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestCollectErrors(t *testing.T) {
+	opts := SchemaOptions{CollectErrors: true}
+	for _, tt := range []struct {
+		name     string
+		data     map[string]interface{}
+		msg      proto.Message
+		paths    []string
+		expected proto.Message
+	}{
+		{
+			name: "fields",
+			data: map[string]interface{}{
+				"name":   "shelves/1/books/1",
+				"read":   "yes",
+				"author": int64(1),
+				"pages":  int64(100),
+			},
+			msg:      &library.Book{},
+			paths:    []string{"author", "pages", "read"},
+			expected: &library.Book{Name: "shelves/1/books/1"},
+		},
+		{
+			name: "lists",
+			data: map[string]interface{}{
+				"int64_list": []interface{}{"1", int64(2)},
+				"nested_list": []interface{}{
+					map[string]interface{}{"string_list": []interface{}{int64(1), "a", int64(2)}},
+				},
+			},
+			msg:   &examplev1.ExampleList{},
+			paths: []string{"int64_list[0]", "nested_list[0].string_list[0]", "nested_list[0].string_list[2]"},
+			expected: &examplev1.ExampleList{
+				Int64List: []int64{2},
+			},
+		},
+		{
+			name: "maps",
+			data: map[string]interface{}{
+				"string_to_nested": []interface{}{
+					map[string]interface{}{
+						"key": "a",
+						"value": map[string]interface{}{
+							"string_to_string": []interface{}{
+								map[string]interface{}{"key": "b", "value": int64(1)},
+							},
+						},
+					},
+				},
+				"int32_to_string": []interface{}{
+					map[string]interface{}{"key": "c", "value": "d"},
+					map[string]interface{}{"key": int32(1), "value": "e"},
+				},
+			},
+			msg:   &examplev1.ExampleMap{},
+			paths: []string{"int32_to_string[0]", "string_to_nested[0].string_to_string[0]"},
+			expected: &examplev1.ExampleMap{
+				Int32ToString: map[int32]string{1: "e"},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.msg.ProtoReflect().New().Interface()
+			err := opts.Decode(tt.data, msg)
+			var decodeErrs *DecodeErrors
+			assert.Assert(t, errors.As(err, &decodeErrs), err)
+			paths := make([]string, 0, len(decodeErrs.Errors))
+			for _, fieldErr := range decodeErrs.Errors {
+				paths = append(paths, fieldErr.Path)
+			}
+			assert.DeepEqual(t, tt.paths, paths)
+			assert.DeepEqual(t, tt.expected, msg, protocmp.Transform())
+			// decoding stops at the first error by default
+			err = SchemaOptions{}.Decode(tt.data, tt.msg.ProtoReflect().New().Interface())
+			assert.Assert(t, err != nil)
+			assert.Assert(t, !errors.As(err, &decodeErrs))
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		err := opts.Decode(map[string]interface{}{"read": "yes", "title": int64(1)}, &library.Book{})
+		assert.Error(t, err, "encode json: 2 fields failed to decode: "+
+			"read: field read: expected bool-like, got yes; title: field title: expected string-like, got 1")
+		var fieldErr *FieldError
+		assert.Assert(t, errors.As(err, &fieldErr))
+		assert.Equal(t, "read", fieldErr.Path)
+	})
+
+	t.Run("limit errors", func(t *testing.T) {
+		// errors that are not errors of fields are not collected
+		opts := SchemaOptions{CollectErrors: true, MaxNestingDepth: 1}
+		err := opts.Decode(map[string]interface{}{"author": map[string]interface{}{"string": "a"}}, &library.Book{})
+		assert.Assert(t, errors.Is(err, ErrLimitExceeded))
+	})
+
+	t.Run("codec", func(t *testing.T) {
+		codec, err := NewCodec[*library.Book](opts)
+		assert.NilError(t, err)
+		book := &library.Book{Name: "shelves/1/books/1", Title: "Dune", Read: true}
+		b, err := codec.Marshal(book)
+		assert.NilError(t, err)
+		got, err := codec.Unmarshal(b)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, book, got, protocmp.Transform())
+	})
+}
@@ -0,0 +1,32 @@
+package protoavro
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// isDeprecated returns true if the message or field is marked with the deprecated option.
+func isDeprecated(desc protoreflect.Descriptor) bool {
+	switch desc.(type) {
+	case protoreflect.MessageDescriptor, protoreflect.FieldDescriptor:
+	default:
+		return false
+	}
+	options, ok := desc.Options().(interface{ GetDeprecated() bool })
+	return ok && options.GetDeprecated()
+}
+
+// annotatesDeprecated returns true if the record or field of the descriptor is annotated as deprecated, see
+// SchemaOptions.AnnotateDeprecated.
+func (o SchemaOptions) annotatesDeprecated(desc protoreflect.Descriptor) bool {
+	return o.AnnotateDeprecated && isDeprecated(desc)
+}
+
+// deprecatedDoc returns the doc with a line marking it as deprecated.
+func deprecatedDoc(doc string) string {
+	if doc != "" && !strings.HasSuffix(doc, "\n") {
+		doc += "\n"
+	}
+	return doc + "Deprecated."
+}
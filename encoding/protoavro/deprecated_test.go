@@ -0,0 +1,120 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestDeprecated(t *testing.T) {
+	desc := newDeprecatedDescriptor(t)
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("id"), protoreflect.ValueOfString("shipments/1"))
+	msg.Set(desc.Fields().ByName("legacy_id"), protoreflect.ValueOfInt64(1))
+	msg.Set(desc.Fields().ByName("title"), protoreflect.ValueOfString("Dune"))
+
+	t.Run("annotate", func(t *testing.T) {
+		schema, err := SchemaOptions{AnnotateDeprecated: true, OmitRootElement: true}.InferSchema(desc)
+		assert.NilError(t, err)
+		record := recordOf(t, schema)
+		assert.DeepEqual(t, avro.Properties{"deprecated": true}, record.Properties)
+		assert.Equal(t, " A shipment.\nDeprecated.", record.Doc)
+		assert.Assert(t, record.Fields[0].Properties == nil)
+		assert.Equal(t, "", record.Fields[0].Doc)
+		assert.DeepEqual(t, avro.Properties{"deprecated": true}, record.Fields[1].Properties)
+		assert.Equal(t, "Deprecated.", record.Fields[1].Doc)
+		// the annotations are written as attributes
+		data, err := json.Marshal(record.Fields[1])
+		assert.NilError(t, err)
+		assert.Equal(
+			t,
+			`{"name":"legacy_id","doc":"Deprecated.","type":[{"type":"null"},{"type":"long"}],"deprecated":true}`,
+			string(data),
+		)
+		// properties of RecordPropertiesFunc take precedence
+		schema, err = SchemaOptions{
+			AnnotateDeprecated: true,
+			OmitDocs:           true,
+			OmitRootElement:    true,
+			RecordPropertiesFunc: func(protoreflect.MessageDescriptor) avro.Properties {
+				return avro.Properties{"deprecated": "since v2"}
+			},
+		}.InferSchema(desc)
+		assert.NilError(t, err)
+		record = recordOf(t, schema)
+		assert.DeepEqual(t, avro.Properties{"deprecated": "since v2"}, record.Properties)
+		assert.Equal(t, "", record.Doc)
+	})
+
+	t.Run("omit fields", func(t *testing.T) {
+		opts := SchemaOptions{OmitDeprecatedFields: true}
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		record := recordOf(t, schema)
+		assert.Equal(t, 2, len(record.Fields))
+		assert.Equal(t, "id", record.Fields[0].Name)
+		assert.Equal(t, "title", record.Fields[1].Name)
+		expected := proto.Clone(msg)
+		expected.ProtoReflect().Clear(desc.Fields().ByName("legacy_id"))
+		assertRecursionRoundTrip(t, opts, msg, expected, true)
+		data, err := opts.MarshalAvroJSON(msg)
+		assert.NilError(t, err)
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+		assert.DeepEqual(t, expected, decoded, protocmp.Transform())
+	})
+}
+
+// newDeprecatedDescriptor returns the descriptor of a deprecated message Shipment, with a deprecated field
+// legacy_id.
+func newDeprecatedDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/deprecated_shipment.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:    proto.String("Shipment"),
+				Options: &descriptorpb.MessageOptions{Deprecated: proto.Bool(true)},
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:   proto.String("id"),
+						Number: proto.Int32(1),
+						Label:  optional,
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+					{
+						Name:    proto.String("legacy_id"),
+						Number:  proto.Int32(2),
+						Label:   optional,
+						Type:    descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+						Options: &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)},
+					},
+					{
+						Name:   proto.String("title"),
+						Number: proto.Int32(3),
+						Label:  optional,
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptorpb.SourceCodeInfo{
+			Location: []*descriptorpb.SourceCodeInfo_Location{
+				{Path: []int32{4, 0}, Span: []int32{0, 0, 0}, LeadingComments: proto.String(" A shipment.\n")},
+			},
+		},
+	}, nil)
+	assert.NilError(t, err)
+	return file.Messages().Get(0)
+}
@@ -0,0 +1,352 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// DictionaryField is the name of the trailing field of root records that holds the values that are added to
+	// the dictionary of the file by the record, see SchemaOptions.DictionaryFieldFunc.
+	DictionaryField = "_dictionary"
+	// DictionaryMetadataKey is the key of the metadata of Object Container Files with dictionary encoded fields.
+	// The value is a JSON array of the names of the fields.
+	DictionaryMetadataKey = "protoavro.dictionary"
+)
+
+// dictionaryFields returns the names of the string fields of the root message that are dictionary encoded.
+func (o SchemaOptions) dictionaryFields(desc protoreflect.MessageDescriptor) []string {
+	if o.DictionaryFieldFunc == nil {
+		return nil
+	}
+	var fields []string
+	for i := 0; i < desc.Fields().Len(); i++ {
+		field := desc.Fields().Get(i)
+		if field.Kind() != protoreflect.StringKind || field.IsList() || field.IsMap() || o.omitsField(field, 0) {
+			continue
+		}
+		if o.DictionaryFieldFunc(field) {
+			fields = append(fields, o.fieldName(field))
+		}
+	}
+	return fields
+}
+
+// withDictionaryMetadata returns the metadata of files of the message, with the dictionary fields of the message.
+// The metadata is not modified.
+func (o SchemaOptions) withDictionaryMetadata(
+	desc protoreflect.MessageDescriptor,
+	metadata map[string][]byte,
+) (map[string][]byte, error) {
+	fields := o.dictionaryFields(desc)
+	if len(fields) == 0 {
+		return metadata, nil
+	}
+	if _, ok := metadata[DictionaryMetadataKey]; ok {
+		return nil, fmt.Errorf("metadata key %s is reserved for dictionary fields", DictionaryMetadataKey)
+	}
+	value, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal dictionary fields: %w", err)
+	}
+	withDictionary := make(map[string][]byte, len(metadata)+1)
+	for key, value := range metadata {
+		withDictionary[key] = value
+	}
+	withDictionary[DictionaryMetadataKey] = value
+	return withDictionary, nil
+}
+
+// withDictionaryFields returns the schema with the fields of its root record replaced by indexes into the
+// dictionary of the file, and with the dictionary field appended to its root record.
+func withDictionaryFields(schema avro.Schema, fields []string) (avro.Schema, error) {
+	return mapRootRecord(schema, func(record avro.Record) (avro.Record, error) {
+		record.Fields = append([]avro.Field(nil), record.Fields...)
+		for _, name := range fields {
+			i := fieldIndex(record, name)
+			if i < 0 {
+				return record, fmt.Errorf("dictionary field %s is not a field of record %s", name, record.Name)
+			}
+			var ok bool
+			if record.Fields[i].Type, ok = replacePrimitive(record.Fields[i].Type, avro.String(), avro.Integer()); !ok {
+				return record, fmt.Errorf("dictionary field %s of record %s is not a string", name, record.Name)
+			}
+		}
+		if fieldIndex(record, DictionaryField) >= 0 {
+			return record, fmt.Errorf("dictionary field collides with a field of record %s", record.Name)
+		}
+		record.Fields = append(record.Fields, avro.Field{
+			Name: DictionaryField,
+			Doc:  "Values added to the dictionary of the file by the record, by field.",
+			Type: avro.Map{Type: avro.MapType, Values: avro.Array{Type: avro.ArrayType, Items: avro.String()}},
+		})
+		return record, nil
+	})
+}
+
+// dictionaryDatumCodec returns the codec of the values of a file with dictionary fields, once the values are
+// decoded from the dictionary, which is the codec of the schema with string fields and without the dictionary
+// field.
+func dictionaryDatumCodec(schemaJSON string, fields []string) (*goavro.Codec, error) {
+	schema, err := avro.Parse([]byte(schemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	datum, err := mapRootRecord(schema, func(record avro.Record) (avro.Record, error) {
+		record.Fields = append([]avro.Field(nil), record.Fields...)
+		for _, name := range fields {
+			i := fieldIndex(record, name)
+			if i < 0 {
+				return record, fmt.Errorf("dictionary field %s is not a field of record %s", name, record.Name)
+			}
+			var ok bool
+			if record.Fields[i].Type, ok = replacePrimitive(record.Fields[i].Type, avro.Integer(), avro.String()); !ok {
+				return record, fmt.Errorf("dictionary field %s of record %s is not an int", name, record.Name)
+			}
+		}
+		i := fieldIndex(record, DictionaryField)
+		if i < 0 {
+			return record, fmt.Errorf("record %s has no dictionary field", record.Name)
+		}
+		record.Fields = append(record.Fields[:i], record.Fields[i+1:]...)
+		return record, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	datumJSON, err := json.Marshal(datum)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	return goavro.NewCodec(string(datumJSON))
+}
+
+// replacePrimitive replaces the primitive from by to in a schema that is the primitive, or the nullable
+// primitive. False is returned for other schemas.
+func replacePrimitive(schema avro.Schema, from, to avro.Primitive) (avro.Schema, bool) {
+	switch s := schema.(type) {
+	case avro.Primitive:
+		if s == from {
+			return to, true
+		}
+	case avro.Union:
+		if len(s) == 2 && s[0] == avro.Null() && s[1] == from {
+			return avro.Union{avro.Null(), to}, true
+		}
+	}
+	return schema, false
+}
+
+// fieldIndex returns the index of the field of the record with the name, or -1.
+func fieldIndex(record avro.Record, name string) int {
+	for i, field := range record.Fields {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseDictionaryMetadata returns the dictionary fields of the metadata of a file, if any.
+func parseDictionaryMetadata(metadata map[string][]byte) ([]string, error) {
+	value, ok := metadata[DictionaryMetadataKey]
+	if !ok {
+		return nil, nil
+	}
+	var fields []string
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, fmt.Errorf("parse %s metadata: %w", DictionaryMetadataKey, err)
+	}
+	return fields, nil
+}
+
+// isUnionRoot returns true when the schema is a union of the root record.
+func isUnionRoot(schemaJSON string) bool {
+	return strings.HasPrefix(strings.TrimSpace(schemaJSON), "[")
+}
+
+// rootRecord returns the record of a value, without the union of the root record.
+func rootRecord(value interface{}, unionRoot bool) map[string]interface{} {
+	record, _ := value.(map[string]interface{})
+	if unionRoot {
+		// the union value of the root is keyed by the name of its record, or nil for null
+		for _, branch := range record {
+			record, _ = branch.(map[string]interface{})
+		}
+	}
+	return record
+}
+
+// dictionaryEncoder replaces the values of dictionary fields of the records written to a file by their
+// indexes in the dictionary of the file. Values are added to the dictionary by the first record they are
+// written in.
+type dictionaryEncoder struct {
+	fields    []string
+	unionRoot bool
+	// indexes are the indexes of the values of the dictionary, by field.
+	indexes map[string]map[string]int32
+}
+
+func newDictionaryEncoder(codec *goavro.Codec, fields []string) *dictionaryEncoder {
+	indexes := make(map[string]map[string]int32, len(fields))
+	for _, field := range fields {
+		indexes[field] = make(map[string]int32)
+	}
+	return &dictionaryEncoder{fields: fields, unionRoot: isUnionRoot(codec.Schema()), indexes: indexes}
+}
+
+// encode returns a copy of the value with dictionary encoded fields. The value is not modified.
+func (e *dictionaryEncoder) encode(value interface{}) (interface{}, error) {
+	record := rootRecord(value, e.unionRoot)
+	if record == nil {
+		return value, nil
+	}
+	encoded := make(map[string]interface{}, len(record)+1)
+	for name, fieldValue := range record {
+		encoded[name] = fieldValue
+	}
+	added := make(map[string]interface{})
+	for _, field := range e.fields {
+		var err error
+		if encoded[field], err = e.encodeField(field, record[field], added); err != nil {
+			return nil, fmt.Errorf("dictionary field %s: %w", field, err)
+		}
+	}
+	encoded[DictionaryField] = added
+	if !e.unionRoot {
+		return encoded, nil
+	}
+	for name := range value.(map[string]interface{}) {
+		return map[string]interface{}{name: encoded}, nil
+	}
+	return encoded, nil
+}
+
+func (e *dictionaryEncoder) encodeField(
+	field string,
+	value interface{},
+	added map[string]interface{},
+) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return e.index(field, v, added)
+	case map[string]interface{}:
+		if s, ok := v["string"].(string); ok && len(v) == 1 {
+			index, err := e.index(field, s, added)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"int": index}, nil
+		}
+	}
+	return nil, fmt.Errorf("expected string, got %T", value)
+}
+
+// index returns the index of the value in the dictionary of the field, and adds the value to the dictionary
+// and to the values added by the record when it is not in the dictionary.
+func (e *dictionaryEncoder) index(field, value string, added map[string]interface{}) (int32, error) {
+	indexes := e.indexes[field]
+	if index, ok := indexes[value]; ok {
+		return index, nil
+	}
+	if len(indexes) == math.MaxInt32 {
+		return 0, errors.New("dictionary is full")
+	}
+	index := int32(len(indexes))
+	indexes[value] = index
+	values, _ := added[field].([]interface{})
+	added[field] = append(values, value)
+	return index, nil
+}
+
+// dictionaryDecoder replaces the indexes of dictionary fields of the records read from a file by their
+// values. Records must be decoded in the order of the file, since values are added to the dictionary by the
+// first record they are written in.
+type dictionaryDecoder struct {
+	fields    []string
+	unionRoot bool
+	// values are the values of the dictionary, by field.
+	values map[string][]string
+}
+
+func newDictionaryDecoder(codec *goavro.Codec, fields []string) *dictionaryDecoder {
+	return &dictionaryDecoder{
+		fields:    fields,
+		unionRoot: isUnionRoot(codec.Schema()),
+		values:    make(map[string][]string, len(fields)),
+	}
+}
+
+// decode decodes the dictionary fields of the value in place.
+func (d *dictionaryDecoder) decode(value interface{}) error {
+	record := rootRecord(value, d.unionRoot)
+	if record == nil {
+		return nil
+	}
+	added, _ := record[DictionaryField].(map[string]interface{})
+	for field, values := range added {
+		if !d.isField(field) {
+			return fmt.Errorf("unexpected dictionary field %s", field)
+		}
+		items, _ := values.([]interface{})
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("dictionary field %s: expected string, got %T", field, item)
+			}
+			d.values[field] = append(d.values[field], s)
+		}
+	}
+	delete(record, DictionaryField)
+	for _, field := range d.fields {
+		var err error
+		if record[field], err = d.decodeField(field, record[field]); err != nil {
+			return fmt.Errorf("dictionary field %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+func (d *dictionaryDecoder) decodeField(field string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case int32:
+		return d.value(field, v)
+	case map[string]interface{}:
+		if index, ok := v["int"].(int32); ok && len(v) == 1 {
+			s, err := d.value(field, index)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"string": s}, nil
+		}
+	}
+	return nil, fmt.Errorf("expected int, got %T", value)
+}
+
+func (d *dictionaryDecoder) value(field string, index int32) (string, error) {
+	values := d.values[field]
+	if index < 0 || int(index) >= len(values) {
+		return "", fmt.Errorf("index %d out of range of %d values", index, len(values))
+	}
+	return values[index], nil
+}
+
+func (d *dictionaryDecoder) isField(field string) bool {
+	for _, f := range d.fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+// dictionaryAuthors selects the author and title fields of books for dictionary encoding, and the read field,
+// which is ignored since it is not a string field.
+func dictionaryAuthors(field protoreflect.FieldDescriptor) bool {
+	return field.Name() == "author" || field.Name() == "title" || field.Name() == "read"
+}
+
+func TestDictionaryFieldFunc(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	for _, tt := range []struct {
+		name string
+		opts protoavro.SchemaOptions
+	}{
+		{name: "default"},
+		{name: "checksums", opts: protoavro.SchemaOptions{RecordChecksums: true}},
+		{name: "parallel", opts: protoavro.SchemaOptions{ReadParallelism: 2}},
+		{name: "deflate", opts: protoavro.SchemaOptions{Compression: protoavro.CompressionDeflate}},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			books := testBooks(0, 10)
+			books[3].Author = "Frank Herbert"
+			books[5].Title = ""
+			var b bytes.Buffer
+			opts := tt.opts
+			opts.DictionaryFieldFunc = dictionaryAuthors
+			marshaler, err := opts.NewMarshaler(desc, &b)
+			assert.NilError(t, err)
+			marshalBooks(t, marshaler, books)
+			unmarshaler, err := opts.NewUnmarshaler(bytes.NewReader(b.Bytes()))
+			assert.NilError(t, err)
+			defer unmarshaler.Close()
+			assert.Equal(t, `["author","title"]`, string(unmarshaler.Metadata()[protoavro.DictionaryMetadataKey]))
+			assert.DeepEqual(t, books, unmarshalBooks(t, unmarshaler), protocmp.Transform())
+			assert.NilError(t, unmarshaler.VerifyCompatible(desc))
+		})
+	}
+
+	t.Run("schema", func(t *testing.T) {
+		var b bytes.Buffer
+		opts := protoavro.SchemaOptions{DictionaryFieldFunc: dictionaryAuthors}
+		marshaler, err := opts.NewMarshaler(desc, &b)
+		assert.NilError(t, err)
+		marshalBooks(t, marshaler, testBooks(0, 2))
+		// other readers read the indexes and the values added to the dictionary as fields
+		r, err := goavro.NewOCFReader(&b)
+		assert.NilError(t, err)
+		schema, err := avro.Parse([]byte(r.Codec().Schema()))
+		assert.NilError(t, err)
+		record := schema.(avro.Union)[1].(avro.Record)
+		assert.DeepEqual(t, avro.Union{avro.Null(), avro.Integer()}, record.Fields[1].Type)
+		assert.Equal(t, protoavro.DictionaryField, record.Fields[len(record.Fields)-1].Name)
+		var added []interface{}
+		for r.Scan() {
+			value, err := r.Read()
+			assert.NilError(t, err)
+			record := value.(map[string]interface{})["google.example.library.v1.Book"].(map[string]interface{})
+			assert.DeepEqual(t, map[string]interface{}{"int": int32(0)}, record["author"])
+			added = append(added, record[protoavro.DictionaryField])
+		}
+		assert.DeepEqual(t, []interface{}{
+			map[string]interface{}{
+				"author": []interface{}{"J. K. Rowling"},
+				"title":  []interface{}{"Harry Potter"},
+			},
+			map[string]interface{}{},
+		}, added)
+	})
+
+	t.Run("smaller files", func(t *testing.T) {
+		books := testBooks(0, 100)
+		var plain, dictionary bytes.Buffer
+		marshaler, err := protoavro.NewMarshaler(desc, &plain)
+		assert.NilError(t, err)
+		marshalBooks(t, marshaler, books)
+		marshaler, err = protoavro.SchemaOptions{DictionaryFieldFunc: dictionaryAuthors}.NewMarshaler(desc, &dictionary)
+		assert.NilError(t, err)
+		marshalBooks(t, marshaler, books)
+		assert.Assert(t, dictionary.Len() < plain.Len(), "dictionary %d, plain %d", dictionary.Len(), plain.Len())
+	})
+
+	t.Run("record filter", func(t *testing.T) {
+		books := testBooks(0, 4)
+		books[2].Author = "Frank Herbert"
+		var b bytes.Buffer
+		opts := protoavro.SchemaOptions{
+			DictionaryFieldFunc: dictionaryAuthors,
+			// the filter sees the values of dictionary fields, and skipped records still add to the dictionary
+			RecordFilter: func(record map[string]interface{}) bool {
+				author, _ := record["author"].(map[string]interface{})
+				return author["string"] == "Frank Herbert" || record["name"].(map[string]interface{})["string"] ==
+					"shelves/1/books/3"
+			},
+		}
+		marshaler, err := opts.NewMarshaler(desc, &b)
+		assert.NilError(t, err)
+		marshalBooks(t, marshaler, books)
+		unmarshaler, err := opts.NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, books[2:], unmarshalBooks(t, unmarshaler), protocmp.Transform())
+	})
+
+	t.Run("append", func(t *testing.T) {
+		opts := protoavro.SchemaOptions{DictionaryFieldFunc: dictionaryAuthors}
+		file, err := os.Create(filepath.Join(t.TempDir(), "books.avro"))
+		assert.NilError(t, err)
+		defer file.Close()
+		marshaler, err := opts.NewAppendingMarshaler(desc, file)
+		assert.NilError(t, err)
+		marshalBooks(t, marshaler, testBooks(0, 1))
+		_, err = opts.NewAppendingMarshaler(desc, file)
+		assert.ErrorContains(t, err, "appending to files with dictionary fields is not supported")
+	})
+
+	t.Run("reserved metadata", func(t *testing.T) {
+		opts := protoavro.SchemaOptions{
+			DictionaryFieldFunc: dictionaryAuthors,
+			OCFMetadata:         map[string][]byte{protoavro.DictionaryMetadataKey: []byte(`[]`)},
+		}
+		_, err := opts.NewMarshaler(desc, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "reserved for dictionary fields")
+	})
+}
@@ -0,0 +1,38 @@
+package protoavro
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// doc returns the Avro doc of a message, field or enum, from the leading comments of the descriptor.
+func (o SchemaOptions) doc(desc protoreflect.Descriptor) string {
+	if o.OmitDocs {
+		return ""
+	}
+	doc := desc.ParentFile().SourceLocations().ByDescriptor(desc).LeadingComments
+	if o.DocFunc != nil {
+		doc = o.DocFunc(desc, doc)
+	}
+	if o.annotatesDeprecated(desc) {
+		doc = deprecatedDoc(doc)
+	}
+	return o.truncateDoc(doc)
+}
+
+// oneofDoc returns the Avro doc of a field in a oneof, which lists the fields of the oneof.
+func (o SchemaOptions) oneofDoc(doc string, oneof protoreflect.OneofDescriptor) string {
+	if o.OmitDocs {
+		return ""
+	}
+	return o.truncateDoc(oneofDoc(doc, oneof))
+}
+
+func (o SchemaOptions) truncateDoc(doc string) string {
+	if o.MaxDocLength <= 0 {
+		return doc
+	}
+	if runes := []rune(doc); len(runes) > o.MaxDocLength {
+		return string(runes[:o.MaxDocLength])
+	}
+	return doc
+}
@@ -0,0 +1,111 @@
+package protoavro
+
+import (
+	"strings"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gotest.tools/v3/assert"
+)
+
+// documentedMessage returns a message with leading comments on the message and its field.
+func documentedMessage(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/shipment.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Shipment"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("id"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						JsonName: proto.String("id"),
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptorpb.SourceCodeInfo{
+			Location: []*descriptorpb.SourceCodeInfo_Location{
+				{
+					Path:            []int32{4, 0},
+					Span:            []int32{0, 0, 0},
+					LeadingComments: proto.String(" A shipment of goods.\n TODO(INC-123): Deprecate.\n"),
+				},
+				{
+					Path:            []int32{4, 0, 2, 0},
+					Span:            []int32{1, 0, 0},
+					LeadingComments: proto.String(" The unique identifier of the shipment.\n"),
+				},
+			},
+		},
+	}, nil)
+	assert.NilError(t, err)
+	return file.Messages().Get(0)
+}
+
+func TestInferSchema_Docs(t *testing.T) {
+	removeTODOs := func(_ protoreflect.Descriptor, doc string) string {
+		lines := strings.Split(doc, "\n")
+		kept := lines[:0]
+		for _, line := range lines {
+			if !strings.HasPrefix(strings.TrimSpace(line), "TODO") {
+				kept = append(kept, line)
+			}
+		}
+		return strings.TrimSpace(strings.Join(kept, "\n"))
+	}
+	for _, tt := range []struct {
+		name      string
+		opts      SchemaOptions
+		recordDoc string
+		fieldDoc  string
+	}{
+		{
+			name:      "default",
+			recordDoc: " A shipment of goods.\n TODO(INC-123): Deprecate.\n",
+			fieldDoc:  " The unique identifier of the shipment.\n",
+		},
+		{
+			name: "omit",
+			opts: SchemaOptions{OmitDocs: true},
+		},
+		{
+			name:      "sanitize",
+			opts:      SchemaOptions{DocFunc: removeTODOs},
+			recordDoc: "A shipment of goods.",
+			fieldDoc:  "The unique identifier of the shipment.",
+		},
+		{
+			name:      "truncate",
+			opts:      SchemaOptions{MaxDocLength: 10},
+			recordDoc: " A shipmen",
+			fieldDoc:  " The uniqu",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := tt.opts.InferSchema(documentedMessage(t))
+			assert.NilError(t, err)
+			record := schema.(avro.Union)[1].(avro.Record)
+			assert.Equal(t, record.Doc, tt.recordDoc)
+			assert.Equal(t, record.Fields[0].Doc, tt.fieldDoc)
+		})
+	}
+}
+
+func TestInferSchema_OmitDocsOneof(t *testing.T) {
+	schema, err := SchemaOptions{OmitDocs: true}.InferSchema((&examplev1.ExampleOneof{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	for _, field := range schema.(avro.Union)[1].(avro.Record).Fields {
+		assert.Equal(t, field.Doc, "")
+	}
+}
@@ -0,0 +1,126 @@
+package protoavro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/internal/wkt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// DurationEncoding is how google.protobuf.Duration values are encoded as Avro.
+type DurationEncoding int
+
+const (
+	// DurationSeconds encodes durations as a float of seconds.
+	DurationSeconds DurationEncoding = iota
+	// DurationLong encodes durations as a long of SchemaOptions.DurationUnit.
+	DurationLong
+	// DurationFixed encodes durations as the logical type duration, a fixed of size 12 named
+	// google.protobuf.Duration. The duration is split into days and milliseconds, the month component is
+	// always zero. Negative durations can not be encoded.
+	DurationFixed
+	// DurationString encodes durations as strings of seconds, like protojson, such as "1.500s".
+	DurationString
+)
+
+const day = 24 * time.Hour
+
+func (o SchemaOptions) durationUnit() time.Duration {
+	if o.DurationUnit <= 0 {
+		return time.Microsecond
+	}
+	return o.DurationUnit
+}
+
+func (o SchemaOptions) schemaDuration() avro.Schema {
+	switch o.durationEncoding() {
+	case DurationLong:
+		return avro.Nullable(avro.Long())
+	case DurationFixed:
+		return avro.Nullable(avro.Duration("Duration", "google.protobuf"))
+	case DurationString:
+		return avro.Nullable(avro.String())
+	default:
+		return avro.Nullable(avro.Float())
+	}
+}
+
+func (o SchemaOptions) encodeDuration(dur *durationpb.Duration) (map[string]interface{}, error) {
+	switch o.durationEncoding() {
+	case DurationLong:
+		return o.unionValue("long", int64(dur.AsDuration()/o.durationUnit())), nil
+	case DurationFixed:
+		d := dur.AsDuration()
+		if d < 0 {
+			return nil, fmt.Errorf("google.protobuf.Duration: negative duration %v", d)
+		}
+		b := make([]byte, 12)
+		binary.LittleEndian.PutUint32(b[4:8], uint32(d/day))
+		binary.LittleEndian.PutUint32(b[8:12], uint32(d%day/time.Millisecond))
+		return o.unionValue(wkt.Duration, b), nil
+	case DurationString:
+		data, err := protojson.Marshal(dur)
+		if err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: marshal: %w", err)
+		}
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: marshal: %w", err)
+		}
+		return o.unionValue("string", s), nil
+	default:
+		return o.unionValue("float", dur.AsDuration().Seconds()), nil
+	}
+}
+
+func (o SchemaOptions) decodeDuration(v map[string]interface{}) (*durationpb.Duration, error) {
+	switch o.durationEncoding() {
+	case DurationLong:
+		n, err := decodeInt(v, "long")
+		if err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: %w", err)
+		}
+		return durationpb.New(time.Duration(n) * o.durationUnit()), nil
+	case DurationFixed:
+		b, err := decodeBytes(v, wkt.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: %w", err)
+		}
+		if len(b) != 12 {
+			return nil, fmt.Errorf("google.protobuf.Duration: expected 12 bytes, got %d", len(b))
+		}
+		if months := binary.LittleEndian.Uint32(b[0:4]); months != 0 {
+			return nil, fmt.Errorf("google.protobuf.Duration: unsupported duration of %d months", months)
+		}
+		days := time.Duration(binary.LittleEndian.Uint32(b[4:8]))
+		millis := time.Duration(binary.LittleEndian.Uint32(b[8:12]))
+		return durationpb.New(days*day + millis*time.Millisecond), nil
+	case DurationString:
+		s, err := decodeString(v, "string")
+		if err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: %w", err)
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: %w", err)
+		}
+		var dur durationpb.Duration
+		if err := protojson.Unmarshal(data, &dur); err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: unmarshal: %w", err)
+		}
+		return &dur, nil
+	default:
+		seconds, err := decodeFloatLike(v, "float")
+		if err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: %w", err)
+		}
+		// prevent downcasting float64 to int64 when passing to time.Duration
+		micros := seconds / time.Microsecond.Seconds()
+		return durationpb.New(time.Microsecond * time.Duration(micros)), nil
+	}
+}
@@ -0,0 +1,143 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"gotest.tools/v3/assert"
+)
+
+func Test_DurationEncoding(t *testing.T) {
+	dur := 50*time.Hour + 1500*time.Millisecond + 250*time.Microsecond
+	for _, tt := range []struct {
+		name     string
+		opts     SchemaOptions
+		schema   avro.Schema
+		expected map[string]interface{}
+		decoded  time.Duration
+	}{
+		{
+			name:     "default",
+			schema:   avro.Float(),
+			expected: map[string]interface{}{"float": dur.Seconds()},
+			// float is not precise enough for microseconds
+			decoded: 50*time.Hour + 1500*time.Millisecond,
+		},
+		{
+			name:     "long micros",
+			opts:     SchemaOptions{DurationEncoding: DurationLong},
+			schema:   avro.Long(),
+			expected: map[string]interface{}{"long": dur.Microseconds()},
+			decoded:  dur,
+		},
+		{
+			name:     "long millis",
+			opts:     SchemaOptions{DurationEncoding: DurationLong, DurationUnit: time.Millisecond},
+			schema:   avro.Long(),
+			expected: map[string]interface{}{"long": dur.Milliseconds()},
+			decoded:  dur.Truncate(time.Millisecond),
+		},
+		{
+			name:   "fixed",
+			opts:   SchemaOptions{DurationEncoding: DurationFixed},
+			schema: avro.Duration("Duration", "google.protobuf"),
+			expected: map[string]interface{}{
+				"google.protobuf.Duration": []byte{0, 0, 0, 0, 2, 0, 0, 0, 0xdc, 0xe2, 0x6d, 0},
+			},
+			decoded: dur.Truncate(time.Millisecond),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &examplev1.ExampleDuration{Duration: durationpb.New(dur)}
+			schema, err := tt.opts.InferSchema(msg.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+			field := schema.(avro.Union)[1].(avro.Record).Fields[0]
+			assert.DeepEqual(t, field.Type, avro.Nullable(tt.schema))
+
+			encoded, err := tt.opts.encodeJSON(msg)
+			assert.NilError(t, err)
+			record := encoded.(map[string]interface{})["einride.avro.example.v1.ExampleDuration"].(map[string]interface{})
+			assert.DeepEqual(t, record["duration"], tt.expected)
+
+			// round-trip through the binary encoding
+			schemaBytes, err := json.Marshal(schema)
+			assert.NilError(t, err)
+			codec, err := goavro.NewCodec(string(schemaBytes))
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, encoded)
+			assert.NilError(t, err)
+			native, _, err := codec.NativeFromBinary(binary)
+			assert.NilError(t, err)
+			decoded := &examplev1.ExampleDuration{}
+			assert.NilError(t, tt.opts.decodeJSON(native, decoded))
+			assert.DeepEqual(
+				t,
+				&examplev1.ExampleDuration{Duration: durationpb.New(tt.decoded)},
+				decoded,
+				protocmp.Transform(),
+			)
+		})
+	}
+}
+
+func Test_DurationFixed_Reference(t *testing.T) {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("example/v1/interval.proto"),
+		Package:    proto.String("example.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/duration.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Interval"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("min"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".google.protobuf.Duration"),
+						JsonName: proto.String("min"),
+					},
+					{
+						Name:     proto.String("max"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".google.protobuf.Duration"),
+						JsonName: proto.String("max"),
+					},
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	assert.NilError(t, err)
+	desc := file.Messages().Get(0)
+	opts := SchemaOptions{DurationEncoding: DurationFixed}
+	schema, err := opts.InferSchema(desc)
+	assert.NilError(t, err)
+	fields := schema.(avro.Union)[1].(avro.Record).Fields
+	assert.DeepEqual(t, fields[1].Type, avro.Nullable(avro.Reference("google.protobuf.Duration")))
+
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("min"), protoreflect.ValueOfMessage(durationpb.New(time.Second).ProtoReflect()))
+	msg.Set(desc.Fields().ByName("max"), protoreflect.ValueOfMessage(durationpb.New(time.Minute).ProtoReflect()))
+	encoded, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	_, err = codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+}
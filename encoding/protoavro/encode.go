@@ -1,13 +1,56 @@
 package protoavro
 
 import (
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-// encodeJSON returns the Avro JSON encoding of message.
+// encodeJSON returns the Avro JSON encoding of message, validated against its schema with ValidateEncoding.
 func (o SchemaOptions) encodeJSON(message proto.Message) (interface{}, error) {
-	return o.messageJSON(message.ProtoReflect(), 0)
+	data, err := o.encodeMessageJSON(message)
+	if err != nil || !o.ValidateEncoding {
+		return data, err
+	}
+	if err := o.validateEncoding(message.ProtoReflect().Descriptor(), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// encodeMessageJSON returns the Avro JSON encoding of message, including its envelope fields.
+func (o SchemaOptions) encodeMessageJSON(message proto.Message) (interface{}, error) {
+	o = o.withNames(message.ProtoReflect().Descriptor())
+	data, err := o.messageJSON(message.ProtoReflect(), 0, newFieldMaskTree(o.SchemaMask))
+	if err != nil || data == nil || len(o.envelopeFields()) == 0 {
+		return data, err
+	}
+	name := message.ProtoReflect().Descriptor().FullName()
+	record, ok := data.(map[string]interface{})
+	if ok && !o.OmitRootElement {
+		record, ok = record[o.avroName(message.ProtoReflect().Descriptor())].(map[string]interface{})
+	}
+	if !ok || o.isWKT(name) {
+		return nil, fmt.Errorf("envelope fields are not supported for message %s", name)
+	}
+	if err := o.encodeEnvelope(message, record); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// validateEncoding validates the encoded data of a message against the schema inferred for the message.
+func (o SchemaOptions) validateEncoding(desc protoreflect.MessageDescriptor, data interface{}) error {
+	schema, err := o.InferSchema(desc)
+	if err != nil {
+		return fmt.Errorf("validate encoding: infer schema: %w", err)
+	}
+	if err := avro.Validate(schema, data); err != nil {
+		return fmt.Errorf("validate encoding of %s: %w", desc.FullName(), err)
+	}
+	return nil
 }
 
 func (o SchemaOptions) unionValue(key string, value interface{}) map[string]interface{} {
@@ -16,11 +59,18 @@ func (o SchemaOptions) unionValue(key string, value interface{}) map[string]inte
 	}
 }
 
-func (o SchemaOptions) messageJSON(message protoreflect.Message, recursiveIndex int) (interface{}, error) {
+func (o SchemaOptions) messageJSON(
+	message protoreflect.Message,
+	recursiveIndex int,
+	mask fieldMaskTree,
+) (interface{}, error) {
 	if !message.IsValid() {
 		return nil, nil
 	}
-	if isWKT(message.Descriptor().FullName()) {
+	if codec, ok := lookupMessageCodec(message.Descriptor().FullName()); ok {
+		return codec.encode(message)
+	}
+	if o.isWKT(message.Descriptor().FullName()) {
 		value, err := o.encodeWKT(message)
 		if err != nil {
 			return nil, err
@@ -29,99 +79,182 @@ func (o SchemaOptions) messageJSON(message protoreflect.Message, recursiveIndex
 	}
 	desc := message.Descriptor()
 	record := make(map[string]interface{}, desc.Fields().Len())
+	if err := o.recordFieldsJSON(message, record, recursiveIndex, mask, ""); err != nil {
+		return nil, err
+	}
+	if err := o.transformRecord(message, record, recursiveIndex); err != nil {
+		return nil, err
+	}
+	if o.PreserveUnknownFields {
+		if err := restoreUnknownFields(message, record); err != nil {
+			return nil, err
+		}
+	}
+	if o.OmitRootElement && recursiveIndex == 0 {
+		return record, nil
+	}
+	return map[string]interface{}{
+		o.recordName(desc, recursiveIndex): record,
+	}, nil
+}
+
+// recordFieldsJSON encodes the fields of the message into the record, where the fields of messages that are
+// flattened into the root record are named by their path from the prefix, and are null when the message is not
+// set, see SchemaOptions.FlattenDepth.
+func (o SchemaOptions) recordFieldsJSON(
+	message protoreflect.Message,
+	record map[string]interface{},
+	recursiveIndex int,
+	mask fieldMaskTree,
+	prefix string,
+) error {
+	desc := message.Descriptor()
+	names := o.recordFieldNames(desc)
 	for i := 0; i < desc.Fields().Len(); i++ {
 		field := desc.Fields().Get(i)
-		if field.ContainingOneof() != nil {
+		name := string(field.Name())
+		if names != nil {
+			name = names[i]
+		}
+		if prefix != "" {
+			name = o.flatFieldName(prefix, field)
+		}
+		fieldMask, ok := mask.child(string(field.Name()))
+		if !ok || o.omitsField(field, recursiveIndex) {
+			continue
+		}
+		if o.flattensField(field, recursiveIndex, prefix) {
+			nested := message.Get(field).Message()
+			if err := o.recordFieldsJSON(nested, record, recursiveIndex+1, fieldMask, name); err != nil {
+				return err
+			}
+			continue
+		}
+		if !message.IsValid() {
+			// fields of flattened messages that are not set
+			record[name] = nil
+			continue
+		}
+		if o.encodesJSONString(field) {
+			if !message.Has(field) {
+				record[name] = nil
+				continue
+			}
+			value, err := encodeJSONString(message, field)
+			if err != nil {
+				return err
+			}
+			record[name] = o.unionValue("string", value)
+			continue
+		}
+		if o.encryptsField(field) {
 			if !message.Has(field) {
-				// dont populate scalar fields belonging to
-				// a oneof (.Get returns the default value)
-				record[string(field.Name())] = nil
-			} else {
-				value := message.Get(field)
-				jsonValue, err := o.fieldJSON(field, value, recursiveIndex+1)
-				if err != nil {
-					return nil, err
-				}
-				record[string(field.Name())] = jsonValue
+				record[name] = nil
+				continue
+			}
+			value, err := o.encryptField(message, field)
+			if err != nil {
+				return err
 			}
+			record[name] = o.unionValue("bytes", value)
+			continue
+		}
+		if o.encodesNull(message, field) {
+			// dont populate scalar fields that are not set (.Get returns the default value)
+			record[name] = nil
 			continue
 		}
 		value := message.Get(field)
-		jsonValue, err := o.fieldJSON(field, value, recursiveIndex+1)
+		jsonValue, err := o.fieldJSON(field, value, recursiveIndex+1, fieldMask)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		record[string(field.Name())] = jsonValue
-	}
-	if o.OmitRootElement && recursiveIndex == 0 {
-		return record, nil
+		record[name] = jsonValue
 	}
-	return map[string]interface{}{
-		string(desc.FullName()): record,
-	}, nil
+	return nil
 }
 
 func (o SchemaOptions) fieldJSON(
 	field protoreflect.FieldDescriptor,
 	value protoreflect.Value,
 	recursiveIndex int,
+	mask fieldMaskTree,
 ) (interface{}, error) {
 	if field.IsList() {
+		_, unwrap, err := o.unwrapsListItems(field)
+		if err != nil {
+			return nil, err
+		}
 		list := make([]interface{}, 0, value.List().Len())
 		for i := 0; i < value.List().Len(); i++ {
 			v := value.List().Get(i)
-			fieldValue, err := o.fieldKindJSON(field, v, recursiveIndex)
+			fieldValue, err := o.fieldKindJSON(field, v, recursiveIndex, mask)
 			if err != nil {
 				return nil, err
 			}
+			if unwrap {
+				fieldValue = unwrapUnion(fieldValue)
+			}
 			list = append(list, fieldValue)
 		}
 		return o.unionValue("array", list), nil
 	}
 	if field.IsMap() {
-		return o.encodeMap(field, value.Map(), recursiveIndex)
+		return o.encodeMap(field, value.Map(), recursiveIndex, mask)
 	}
-	return o.fieldKindJSON(field, value, recursiveIndex)
+	return o.fieldKindJSON(field, value, recursiveIndex, mask)
 }
 
 func (o SchemaOptions) fieldKindJSON(
 	field protoreflect.FieldDescriptor,
 	value protoreflect.Value,
 	recursiveIndex int,
+	mask fieldMaskTree,
 ) (interface{}, error) {
 	switch field.Kind() {
 	case protoreflect.MessageKind, protoreflect.GroupKind:
-		return o.messageJSON(value.Message(), recursiveIndex)
+		return o.messageJSON(value.Message(), recursiveIndex, mask)
 	case protoreflect.EnumKind:
 		if field.Enum().Values().ByNumber(value.Enum()) == nil {
 			return o.unionValue(
-				string(field.Enum().FullName()),
-				string(field.Enum().Values().ByNumber(protoreflect.EnumNumber(0)).Name()),
+				o.avroName(field.Enum()),
+				o.enumSymbol(field.Enum().Values().ByNumber(protoreflect.EnumNumber(0))),
 			), nil
 		}
 		return o.unionValue(
-			string(field.Enum().FullName()),
-			string(field.Enum().Values().ByNumber(value.Enum()).Name()),
+			o.avroName(field.Enum()),
+			o.enumSymbol(field.Enum().Values().ByNumber(value.Enum())),
 		), nil
 	case protoreflect.StringKind:
 		return o.unionValue("string", value.String()), nil
 	case protoreflect.Int32Kind,
-		protoreflect.Fixed32Kind,
 		protoreflect.Sfixed32Kind,
 		protoreflect.Sint32Kind:
 		return o.unionValue("int", int32(value.Int())), nil
+	case protoreflect.Fixed32Kind:
+		// fixed32 is mapped to int, with values above math.MaxInt32 wrapping around
+		return o.unionValue("int", int32(value.Uint())), nil
 	case protoreflect.Uint32Kind:
 		return o.unionValue("long", int64(value.Uint())), nil
 	case protoreflect.Int64Kind,
-		protoreflect.Fixed64Kind,
 		protoreflect.Sfixed64Kind,
 		protoreflect.Sint64Kind:
 		return o.unionValue("long", value.Int()), nil
-	case protoreflect.Uint64Kind:
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
 		return o.unionValue("long", int64(value.Uint())), nil
 	case protoreflect.BoolKind:
 		return o.unionValue("boolean", value.Bool()), nil
 	case protoreflect.BytesKind:
+		if size := o.fixedSize(field); size > 0 {
+			if len(value.Bytes()) == 0 && !field.IsList() {
+				// fixed values can not be empty
+				return nil, nil
+			}
+			if err := checkFixedSize(field, size, value.Bytes()); err != nil {
+				return nil, err
+			}
+			return o.unionValue(o.fixedName(field), value.Bytes()), nil
+		}
 		return o.unionValue("bytes", value.Bytes()), nil
 	case protoreflect.DoubleKind:
 		return o.unionValue("double", value.Float()), nil
@@ -2,6 +2,7 @@ package protoavro
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
 	"time"
 
@@ -567,6 +568,31 @@ func Test_JSON(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "example.ExampleScalars fixed kinds",
+			msg: &examplev1.ExampleScalars{
+				Fixed32: math.MaxUint32,
+				Fixed64: math.MaxUint64,
+			},
+			opts: SchemaOptions{OmitRootElement: true},
+			expected: map[string]interface{}{
+				"double":   map[string]interface{}{"double": float64(0)},
+				"float":    map[string]interface{}{"float": float32(0)},
+				"int32":    map[string]interface{}{"int": int32(0)},
+				"int64":    map[string]interface{}{"long": int64(0)},
+				"uint32":   map[string]interface{}{"long": int64(0)},
+				"uint64":   map[string]interface{}{"long": int64(0)},
+				"sint32":   map[string]interface{}{"int": int32(0)},
+				"sint64":   map[string]interface{}{"long": int64(0)},
+				"fixed32":  map[string]interface{}{"int": int32(-1)},
+				"fixed64":  map[string]interface{}{"long": int64(-1)},
+				"sfixed32": map[string]interface{}{"int": int32(0)},
+				"sfixed64": map[string]interface{}{"long": int64(0)},
+				"bool":     map[string]interface{}{"boolean": false},
+				"string":   map[string]interface{}{"string": ""},
+				"bytes":    map[string]interface{}{"bytes": []byte(nil)},
+			},
+		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,81 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// EncryptionKeyProperty is the custom property of encrypted record fields, with the ID of the key that their
+// values are encrypted with. See SchemaOptions.EncryptedFieldFunc.
+const EncryptionKeyProperty = "encryptionKeyId"
+
+// FieldCipher encrypts and decrypts the values of encrypted fields, for example with data keys wrapped by a
+// KMS. Implementations must be safe for concurrent use.
+type FieldCipher interface {
+	// Encrypt returns the ciphertext of the plaintext value of the field, encrypted with the key of the ID.
+	Encrypt(field protoreflect.FieldDescriptor, keyID string, plaintext []byte) ([]byte, error)
+	// Decrypt returns the plaintext value of the field of the ciphertext, encrypted with the key of the ID.
+	Decrypt(field protoreflect.FieldDescriptor, keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// encryptionKey returns the ID of the key that values of the field are encrypted with, or false if the field
+// is not encrypted.
+func (o SchemaOptions) encryptionKey(field protoreflect.FieldDescriptor) (string, bool) {
+	if o.EncryptedFieldFunc == nil || field.ContainingMessage().IsMapEntry() {
+		return "", false
+	}
+	keyID := o.EncryptedFieldFunc(field)
+	return keyID, keyID != ""
+}
+
+// encryptsField returns true if the values of the field are encrypted.
+func (o SchemaOptions) encryptsField(field protoreflect.FieldDescriptor) bool {
+	_, ok := o.encryptionKey(field)
+	return ok
+}
+
+// encryptField returns the ciphertext of the value of a field of the message, which is the protobuf binary
+// encoding of the message with only the field set, so that values of all kinds are encrypted alike.
+func (o SchemaOptions) encryptField(message protoreflect.Message, field protoreflect.FieldDescriptor) ([]byte, error) {
+	keyID, _ := o.encryptionKey(field)
+	if o.FieldCipher == nil {
+		return nil, fmt.Errorf("field %s: encrypted field without FieldCipher", field.Name())
+	}
+	holder := message.New()
+	holder.Set(field, message.Get(field))
+	plaintext, err := proto.MarshalOptions{Deterministic: true}.Marshal(holder.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", field.Name(), err)
+	}
+	ciphertext, err := o.FieldCipher.Encrypt(field, keyID, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: encrypt: %w", field.Name(), err)
+	}
+	return ciphertext, nil
+}
+
+// decryptField decrypts the ciphertext of the value of a field into the message.
+func (o SchemaOptions) decryptField(
+	ciphertext []byte,
+	message protoreflect.Message,
+	field protoreflect.FieldDescriptor,
+) error {
+	keyID, _ := o.encryptionKey(field)
+	if o.FieldCipher == nil {
+		return fmt.Errorf("field %s: encrypted field without FieldCipher", field.Name())
+	}
+	plaintext, err := o.FieldCipher.Decrypt(field, keyID, ciphertext)
+	if err != nil {
+		return fmt.Errorf("field %s: decrypt: %w", field.Name(), err)
+	}
+	holder := message.New()
+	if err := proto.Unmarshal(plaintext, holder.Interface()); err != nil {
+		return fmt.Errorf("field %s: %w", field.Name(), err)
+	}
+	if holder.Has(field) {
+		message.Set(field, holder.Get(field))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package protoavro
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+// xorCipher is a FieldCipher for tests, which XORs values with the byte of their key.
+type xorCipher map[string]byte
+
+func (c xorCipher) xor(keyID string, data []byte) ([]byte, error) {
+	key, ok := c[keyID]
+	if !ok {
+		return nil, errors.New("unknown key " + keyID)
+	}
+	result := make([]byte, len(data))
+	for i, b := range data {
+		result[i] = b ^ key
+	}
+	return result, nil
+}
+
+func (c xorCipher) Encrypt(_ protoreflect.FieldDescriptor, keyID string, plaintext []byte) ([]byte, error) {
+	return c.xor(keyID, plaintext)
+}
+
+func (c xorCipher) Decrypt(_ protoreflect.FieldDescriptor, keyID string, ciphertext []byte) ([]byte, error) {
+	return c.xor(keyID, ciphertext)
+}
+
+func TestEncryptedFields(t *testing.T) {
+	encryptedFields := func(field protoreflect.FieldDescriptor) string {
+		switch field.Name() {
+		case "author":
+			return "pii-key"
+		case "nested_list", "read":
+			return "other-key"
+		}
+		return ""
+	}
+	opts := SchemaOptions{EncryptedFieldFunc: encryptedFields, FieldCipher: xorCipher{"pii-key": 0x5a, "other-key": 0x3c}}
+	book := &library.Book{Name: "shelves/1/books/1", Author: "Frank Herbert", Title: "Dune", Read: true}
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := opts.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		record := recordOf(t, schema)
+		assert.Equal(t, "author", record.Fields[1].Name)
+		assert.DeepEqual(t, avro.Nullable(avro.Bytes()), record.Fields[1].Type)
+		assert.DeepEqual(t, avro.Properties{EncryptionKeyProperty: "pii-key"}, record.Fields[1].Properties)
+		assert.DeepEqual(t, avro.Nullable(avro.String()), record.Fields[2].Type)
+		assert.Assert(t, record.Fields[2].Properties == nil)
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		data, err := opts.Encode(book)
+		assert.NilError(t, err)
+		record := data.(map[string]interface{})["google.example.library.v1.Book"].(map[string]interface{})
+		ciphertext := record["author"].(map[string]interface{})["bytes"].([]byte)
+		assert.Assert(t, !bytes.Contains(ciphertext, []byte("Herbert")))
+		// fields that are not set are null
+		data, err = opts.Encode(&library.Book{Title: "Dune"})
+		assert.NilError(t, err)
+		record = data.(map[string]interface{})["google.example.library.v1.Book"].(map[string]interface{})
+		assert.Equal(t, nil, record["author"])
+		assert.Equal(t, nil, record["read"])
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		nested := &examplev1.ExampleList{
+			Int64List:  []int64{1},
+			NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"a", "b"}}},
+		}
+		for _, msg := range []proto.Message{book, nested, &library.Book{}} {
+			assertRecursionRoundTrip(t, opts, msg, msg, false)
+			data, err := opts.MarshalAvroJSON(msg)
+			assert.NilError(t, err)
+			decoded := msg.ProtoReflect().New().Interface()
+			assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+			assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+			var b bytes.Buffer
+			marshaler, err := opts.NewMarshaler(msg.ProtoReflect().Descriptor(), &b)
+			assert.NilError(t, err)
+			assert.NilError(t, marshaler.Marshal(msg))
+			unmarshaler, err := opts.NewUnmarshaler(&b)
+			assert.NilError(t, err)
+			assert.Assert(t, unmarshaler.Scan())
+			decoded = msg.ProtoReflect().New().Interface()
+			assert.NilError(t, unmarshaler.Unmarshal(decoded))
+			assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+		}
+	})
+
+	t.Run("value", func(t *testing.T) {
+		field := book.ProtoReflect().Descriptor().Fields().ByName("author")
+		value, err := opts.EncodeValue(field, protoreflect.ValueOfString("Frank Herbert"))
+		assert.NilError(t, err)
+		decoded, err := opts.DecodeValue(field, value)
+		assert.NilError(t, err)
+		assert.Equal(t, "Frank Herbert", decoded.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		data, err := opts.Encode(book)
+		assert.NilError(t, err)
+		wrongKeys := opts
+		wrongKeys.FieldCipher = xorCipher{"other-key": 0x3c}
+		err = wrongKeys.Decode(data, &library.Book{})
+		assert.ErrorContains(t, err, "field author: decrypt: unknown key pii-key")
+		_, err = wrongKeys.Encode(book)
+		assert.ErrorContains(t, err, "field author: encrypt: unknown key pii-key")
+		withoutCipher := SchemaOptions{EncryptedFieldFunc: encryptedFields}
+		_, err = withoutCipher.Encode(book)
+		assert.ErrorContains(t, err, "field author: encrypted field without FieldCipher")
+	})
+}
@@ -0,0 +1,96 @@
+package protoavro
+
+import (
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// enumTables caches the symbol tables of enums. The cached tables are shared by concurrent encoders and
+// decoders, and must never be modified.
+var enumTables descriptorCache[protoreflect.EnumDescriptor, *enumTable]
+
+// enumTable holds the symbols of an enum, precomputed so that symbols are looked up without allocating.
+type enumTable struct {
+	// prefix is the conventional prefix of the values of the enum, see enumPrefix.
+	prefix string
+	// values are the values of the enum by their original symbols and, when the enum has a prefix, by their
+	// stripped symbols.
+	values map[string]protoreflect.EnumValueDescriptor
+}
+
+// lookupEnumTable returns the symbol table of an enum.
+func lookupEnumTable(enum protoreflect.EnumDescriptor) *enumTable {
+	if table, ok := enumTables.load(enum); ok {
+		return table
+	}
+	values := enum.Values()
+	table := &enumTable{
+		prefix: enumPrefix(enum),
+		values: make(map[string]protoreflect.EnumValueDescriptor, 2*values.Len()),
+	}
+	for i := 0; i < values.Len(); i++ {
+		table.values[string(values.Get(i).Name())] = values.Get(i)
+	}
+	if table.prefix != "" {
+		// original symbols take precedence over stripped symbols
+		for i := 0; i < values.Len(); i++ {
+			symbol := strings.TrimPrefix(string(values.Get(i).Name()), table.prefix)
+			if _, ok := table.values[symbol]; !ok {
+				table.values[symbol] = values.Get(i)
+			}
+		}
+	}
+	return enumTables.loadOrStore(enum, table)
+}
+
+// enumSymbol returns the Avro symbol of an enum value.
+func (o SchemaOptions) enumSymbol(value protoreflect.EnumValueDescriptor) string {
+	if o.StripEnumPrefix {
+		if prefix := lookupEnumTable(value.Parent().(protoreflect.EnumDescriptor)).prefix; prefix != "" {
+			return strings.TrimPrefix(string(value.Name()), prefix)
+		}
+	}
+	return string(value.Name())
+}
+
+// enumValue returns the enum value of an Avro symbol. Both stripped and original symbols are accepted.
+func (o SchemaOptions) enumValue(enum protoreflect.EnumDescriptor, symbol string) protoreflect.EnumValueDescriptor {
+	return lookupEnumTable(enum).values[symbol]
+}
+
+// enumPrefix returns the conventional prefix of the values of an enum, for example "VEHICLE_STATE_" for
+// the enum VehicleState. An empty string is returned if any value lacks the prefix, or would not be a valid
+// Avro symbol without it.
+func enumPrefix(enum protoreflect.EnumDescriptor) string {
+	prefix := screamingSnakeCase(string(enum.Name())) + "_"
+	for i := 0; i < enum.Values().Len(); i++ {
+		name := string(enum.Values().Get(i).Name())
+		if !strings.HasPrefix(name, prefix) {
+			return ""
+		}
+		stripped := []rune(strings.TrimPrefix(name, prefix))
+		if len(stripped) == 0 || !(unicode.IsLetter(stripped[0]) || stripped[0] == '_') {
+			return ""
+		}
+	}
+	return prefix
+}
+
+// screamingSnakeCase converts a CamelCase name to SCREAMING_SNAKE_CASE.
+func screamingSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gotest.tools/v3/assert"
+)
+
+func Test_StripEnumPrefix(t *testing.T) {
+	opts := SchemaOptions{StripEnumPrefix: true, EnumDefault: true}
+	msg := &examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE2}
+	schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	enum := schema.(avro.Union)[1].(avro.Record).Fields[0].Type.(avro.Union)[1].(avro.Enum)
+	assert.DeepEqual(t, enum.Symbols, []string{"UNSPECIFIED", "VALUE1", "VALUE2", "VALUE3"})
+	assert.Equal(t, enum.Default, "UNSPECIFIED")
+
+	encoded, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	record := encoded.(map[string]interface{})["einride.avro.example.v1.ExampleEnum"].(map[string]interface{})
+	assert.DeepEqual(t, record["enum_value"], map[string]interface{}{"einride.avro.example.v1.ExampleEnum.Enum": "VALUE2"})
+
+	// assert that it matches schema
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	_, err = codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+
+	// decoding accepts both stripped and original symbols
+	for _, symbol := range []string{"VALUE2", "ENUM_VALUE2"} {
+		decoded := &examplev1.ExampleEnum{}
+		assert.NilError(t, opts.decodeJSON(map[string]interface{}{
+			"enum_value": map[string]interface{}{"einride.avro.example.v1.ExampleEnum.Enum": symbol},
+		}, decoded))
+		assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+	}
+}
+
+func Test_enumPrefix(t *testing.T) {
+	assert.Equal(t, enumPrefix(examplev1.ExampleEnum_ENUM_UNSPECIFIED.Descriptor()), "ENUM_")
+	// NULL_VALUE lacks the prefix NULL_VALUE_
+	assert.Equal(t, enumPrefix(structpb.NullValue_NULL_VALUE.Descriptor()), "")
+}
+
+func Test_screamingSnakeCase(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		expected string
+	}{
+		{name: "Enum", expected: "ENUM"},
+		{name: "VehicleState", expected: "VEHICLE_STATE"},
+		{name: "HTTPStatus", expected: "HTTP_STATUS"},
+		{name: "Version2Type", expected: "VERSION2_TYPE"},
+	} {
+		assert.Equal(t, screamingSnakeCase(tt.name), tt.expected)
+	}
+}
+
+func Test_EnumAlias(t *testing.T) {
+	var opts SchemaOptions
+	schema, err := opts.InferSchema((&examplev1.ExampleEnumAlias{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	enum := schema.(avro.Union)[1].(avro.Record).Fields[0].Type.(avro.Union)[1].(avro.Enum)
+	assert.DeepEqual(t, enum.Symbols, []string{"ENUM_UNSPECIFIED", "ENUM_STARTED", "ENUM_STOPPED"})
+	assert.DeepEqual(t, enum.SymbolAliases, map[string]string{"ENUM_RUNNING": "ENUM_STARTED"})
+
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+
+	msg := &examplev1.ExampleEnumAlias{EnumValue: examplev1.ExampleEnumAlias_ENUM_RUNNING}
+	encoded, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	record := encoded.(map[string]interface{})["einride.avro.example.v1.ExampleEnumAlias"].(map[string]interface{})
+	assert.DeepEqual(
+		t,
+		record["enum_value"],
+		map[string]interface{}{"einride.avro.example.v1.ExampleEnumAlias.Enum": "ENUM_STARTED"},
+	)
+	_, err = codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+
+	// decoding accepts both canonical and alias symbols
+	for _, symbol := range []string{"ENUM_STARTED", "ENUM_RUNNING"} {
+		decoded := &examplev1.ExampleEnumAlias{}
+		assert.NilError(t, opts.decodeJSON(map[string]interface{}{
+			"enum_value": map[string]interface{}{"einride.avro.example.v1.ExampleEnumAlias.Enum": symbol},
+		}, decoded))
+		assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+	}
+}
+
+func Test_enumTable_Allocations(t *testing.T) {
+	enum := examplev1.ExampleEnum_ENUM_UNSPECIFIED.Descriptor()
+	opts := SchemaOptions{StripEnumPrefix: true}
+	value := enum.Values().ByName("ENUM_VALUE2")
+	for _, symbol := range []string{"VALUE2", "ENUM_VALUE2"} {
+		assert.Equal(t, opts.enumValue(enum, symbol), value)
+	}
+	assert.Assert(t, opts.enumValue(enum, "VALUE4") == nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		opts.enumValue(enum, "VALUE2")
+		opts.enumValue(enum, "ENUM_VALUE2")
+		opts.enumSymbol(value)
+	})
+	assert.Equal(t, allocs, 0.0)
+}
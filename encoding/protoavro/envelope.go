@@ -0,0 +1,119 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// EnvelopeField is a top-level field injected into the root record, next to the fields of the message.
+// Envelope fields carry metadata about a message, such as when or where it was ingested.
+type EnvelopeField struct {
+	// Name of the field. The name must not collide with a field of the message.
+	Name string
+	// Doc of the field.
+	Doc string
+	// Schema of the field.
+	Schema avro.Schema
+	// Value returns the value of the field for a message being encoded.
+	// The value must match Schema, with values of union schemas wrapped in a map keyed by the
+	// name of the selected branch.
+	Value func(message proto.Message) (interface{}, error)
+}
+
+// RawProtoField is the name of the envelope field with the protobuf wire format of messages, see
+// SchemaOptions.RawProto.
+const RawProtoField = "_raw_proto"
+
+// envelopeFields returns the envelope fields of the root record, including RawProtoField with RawProto.
+func (o SchemaOptions) envelopeFields() []EnvelopeField {
+	if !o.RawProto {
+		return o.EnvelopeFields
+	}
+	fields := make([]EnvelopeField, 0, len(o.EnvelopeFields)+1)
+	return append(append(fields, o.EnvelopeFields...), EnvelopeField{
+		Name:   RawProtoField,
+		Doc:    "The protobuf wire format of the message.",
+		Schema: avro.Nullable(avro.Bytes()),
+		Value: func(message proto.Message) (interface{}, error) {
+			b, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+			if err != nil {
+				return nil, err
+			}
+			return o.unionValue("bytes", b), nil
+		},
+	})
+}
+
+func (o SchemaOptions) envelopeSchema(message protoreflect.MessageDescriptor) ([]avro.Field, error) {
+	envelopeFields := o.envelopeFields()
+	fields := make([]avro.Field, 0, len(envelopeFields))
+	names := make(map[string]struct{}, len(envelopeFields))
+	for _, envelopeField := range envelopeFields {
+		if field, ok := o.findField(message, envelopeField.Name); ok && o.fieldName(field) == envelopeField.Name {
+			return nil, fmt.Errorf(
+				"envelope field %s collides with a field of message %s", envelopeField.Name, message.FullName(),
+			)
+		}
+		if _, ok := names[envelopeField.Name]; ok {
+			return nil, fmt.Errorf("envelope field %s is defined more than once", envelopeField.Name)
+		}
+		names[envelopeField.Name] = struct{}{}
+		fields = append(fields, avro.Field{
+			Name: envelopeField.Name,
+			Doc:  envelopeField.Doc,
+			Type: envelopeField.Schema,
+		})
+	}
+	return fields, nil
+}
+
+func (o SchemaOptions) encodeEnvelope(message proto.Message, record map[string]interface{}) error {
+	for _, envelopeField := range o.envelopeFields() {
+		value, err := envelopeField.Value(message)
+		if err != nil {
+			return fmt.Errorf("envelope field %s: %w", envelopeField.Name, err)
+		}
+		record[envelopeField.Name] = value
+	}
+	return nil
+}
+
+// stripEnvelope returns the root record data without the envelope fields.
+func (o SchemaOptions) stripEnvelope(data interface{}, desc protoreflect.MessageDescriptor) interface{} {
+	envelopeFields := o.envelopeFields()
+	if len(envelopeFields) == 0 {
+		return data
+	}
+	record, ok := data.(map[string]interface{})
+	if !ok {
+		return data
+	}
+	if wrapped, ok := record[o.avroName(desc)].(map[string]interface{}); ok && len(record) == 1 {
+		return o.unionValue(o.avroName(desc), o.stripEnvelope(wrapped, desc))
+	}
+	stripped := make(map[string]interface{}, len(record))
+	for name, value := range record {
+		stripped[name] = value
+	}
+	for _, envelopeField := range envelopeFields {
+		delete(stripped, envelopeField.Name)
+	}
+	return stripped
+}
+
+// rawProto returns the protobuf wire format of the message of the root record data, written with RawProto,
+// or false when the record has no wire format or RawProto is not set.
+func (o SchemaOptions) rawProto(data interface{}, desc protoreflect.MessageDescriptor) ([]byte, bool) {
+	record, ok := data.(map[string]interface{})
+	if !ok || !o.RawProto {
+		return nil, false
+	}
+	if wrapped, ok := record[o.avroName(desc)].(map[string]interface{}); ok && len(record) == 1 {
+		record = wrapped
+	}
+	b, ok := unwrapUnion(record[RawProtoField]).([]byte)
+	return b, ok
+}
@@ -0,0 +1,151 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gotest.tools/v3/assert"
+)
+
+func Test_EnvelopeFields(t *testing.T) {
+	ingestedAt := time.Unix(1626300000, 0).UTC()
+	envelopeFields := []EnvelopeField{
+		{
+			Name:   "_ingested_at",
+			Doc:    "Time of ingestion.",
+			Schema: avro.TimestampMicros(),
+			Value: func(proto.Message) (interface{}, error) {
+				return ingestedAt, nil
+			},
+		},
+		{
+			Name:   "_source",
+			Schema: avro.Nullable(avro.String()),
+			Value: func(message proto.Message) (interface{}, error) {
+				return map[string]interface{}{"string": message.(*library.Book).GetName()}, nil
+			},
+		},
+	}
+	msg := &library.Book{Name: "shelves/1/books/1", Author: "J. K. Rowling"}
+	for _, tt := range []struct {
+		name string
+		opts SchemaOptions
+	}{
+		{name: "default", opts: SchemaOptions{EnvelopeFields: envelopeFields}},
+		{name: "omit root element", opts: SchemaOptions{EnvelopeFields: envelopeFields, OmitRootElement: true}},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := tt.opts.InferSchema(msg.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+			record, ok := schema.(avro.Record)
+			if !ok {
+				record = schema.(avro.Union)[1].(avro.Record)
+			}
+			assert.DeepEqual(t, record.Fields[len(record.Fields)-2:], []avro.Field{
+				{Name: "_ingested_at", Doc: "Time of ingestion.", Type: avro.TimestampMicros()},
+				{Name: "_source", Type: avro.Nullable(avro.String())},
+			})
+
+			encoded, err := tt.opts.encodeJSON(msg)
+			assert.NilError(t, err)
+			schemaBytes, err := json.Marshal(schema)
+			assert.NilError(t, err)
+			codec, err := goavro.NewCodec(string(schemaBytes))
+			assert.NilError(t, err)
+			_, err = codec.BinaryFromNative(nil, encoded)
+			assert.NilError(t, err)
+
+			var b bytes.Buffer
+			marshaler, err := tt.opts.NewMarshaler(msg.ProtoReflect().Descriptor(), &b)
+			assert.NilError(t, err)
+			assert.NilError(t, marshaler.Marshal(msg))
+			unmarshaler, err := tt.opts.NewUnmarshaler(&b)
+			assert.NilError(t, err)
+			assert.Assert(t, unmarshaler.Scan())
+			got := &library.Book{}
+			assert.NilError(t, unmarshaler.Unmarshal(got))
+			assert.DeepEqual(t, msg, got, protocmp.Transform())
+		})
+	}
+}
+
+func Test_EnvelopeFieldsErr(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		msg            proto.Message
+		envelopeFields []EnvelopeField
+		errContains    string
+	}{
+		{
+			name:           "collision",
+			msg:            &library.Book{},
+			envelopeFields: []EnvelopeField{{Name: "author", Schema: avro.String()}},
+			errContains:    "envelope field author collides with a field of message google.example.library.v1.Book",
+		},
+		{
+			name:           "recursive",
+			msg:            &examplev1.ExampleRecursive{},
+			envelopeFields: []EnvelopeField{{Name: "_source", Schema: avro.String()}},
+			errContains:    "envelope fields are not supported for recursive message",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SchemaOptions{EnvelopeFields: tt.envelopeFields}.InferSchema(tt.msg.ProtoReflect().Descriptor())
+			assert.ErrorContains(t, err, tt.errContains)
+		})
+	}
+}
+
+func Test_RawProto(t *testing.T) {
+	msg := &library.Book{Name: "shelves/1/books/1", Author: "J. K. Rowling", Title: "Harry Potter", Read: true}
+	// the mask makes the mapping lossy, but not the wire format
+	opts := SchemaOptions{RawProto: true, SchemaMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}}
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		fields := schema.(avro.Union)[1].(avro.Record).Fields
+		assert.Equal(t, RawProtoField, fields[len(fields)-1].Name)
+		assert.DeepEqual(t, avro.Nullable(avro.Bytes()), fields[len(fields)-1].Type)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		var b bytes.Buffer
+		marshaler, err := opts.NewMarshaler(msg.ProtoReflect().Descriptor(), &b)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(msg))
+		unmarshaler, err := opts.NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		assert.Assert(t, unmarshaler.Scan())
+		got := &library.Book{}
+		assert.NilError(t, unmarshaler.Unmarshal(got))
+		assert.DeepEqual(t, msg, got, protocmp.Transform())
+	})
+
+	t.Run("decode mask", func(t *testing.T) {
+		native, err := opts.Encode(msg)
+		assert.NilError(t, err)
+		decodeOpts := opts
+		decodeOpts.DecodeMask = &fieldmaskpb.FieldMask{Paths: []string{"name"}}
+		got := &library.Book{}
+		assert.NilError(t, decodeOpts.Decode(native, got))
+		assert.DeepEqual(t, &library.Book{Name: msg.GetName()}, got, protocmp.Transform())
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		opts := SchemaOptions{RawProto: true, EnvelopeFields: []EnvelopeField{{Name: RawProtoField, Schema: avro.Bytes()}}}
+		_, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+		assert.ErrorContains(t, err, "envelope field _raw_proto is defined more than once")
+	})
+}
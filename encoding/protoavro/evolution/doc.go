@@ -0,0 +1,3 @@
+// Package evolution checks that changes to protobuf messages keep the compatibility of their Avro schemas,
+// for example in CI before the schemas are registered with a schema registry.
+package evolution
@@ -0,0 +1,210 @@
+package evolution
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Compatibility is the compatibility level of a subject, with the names used by schema registries.
+type Compatibility string
+
+const (
+	// None requires no compatibility.
+	None Compatibility = "NONE"
+	// Backward requires that data written with the previous schema can be read with the current schema.
+	Backward Compatibility = "BACKWARD"
+	// Forward requires that data written with the current schema can be read with the previous schema.
+	Forward Compatibility = "FORWARD"
+	// Full requires both Backward and Forward compatibility.
+	Full Compatibility = "FULL"
+)
+
+// Subject is a schema registry subject, with the protobuf message of its schemas.
+type Subject struct {
+	// Name of the subject, for example "orders-value".
+	Name string
+	// Message is the full name of the protobuf message of the subject.
+	Message protoreflect.FullName
+	// Compatibility is the declared compatibility level of the subject.
+	Compatibility Compatibility
+}
+
+// Violation is a change of the schema of a subject that breaks its declared compatibility.
+type Violation struct {
+	// Subject is the name of the subject.
+	Subject string
+	// Compatibility is the broken compatibility, either Backward or Forward.
+	Compatibility Compatibility
+	// Change describes the change, for example "removing field `driver_id`".
+	Change string
+	// Incompatibility is the incompatibility of the schemas caused by the change.
+	Incompatibility avro.Incompatibility
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s breaks %s compatibility for subject `%s`", v.Change, v.Compatibility, v.Subject)
+}
+
+// Error is returned by Check when changes break the compatibility of subjects.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		lines = append(lines, violation.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ReadFileDescriptorSet reads a binary encoded FileDescriptorSet, as written by
+// "protoc --descriptor_set_out" or "buf build -o".
+func ReadFileDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file descriptor set: %w", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("read file descriptor set %s: %w", path, err)
+	}
+	return &set, nil
+}
+
+// Check infers the Avro schemas of the subjects from the previous and current file descriptors,
+// and returns an *Error with the violations of the declared compatibility of the subjects.
+// Subjects with messages that are not in the previous file descriptors are new, and are not checked.
+func Check(
+	previous *descriptorpb.FileDescriptorSet,
+	current *descriptorpb.FileDescriptorSet,
+	opts protoavro.SchemaOptions,
+	subjects ...Subject,
+) error {
+	previousFiles, err := protodesc.NewFiles(previous)
+	if err != nil {
+		return fmt.Errorf("previous file descriptors: %w", err)
+	}
+	currentFiles, err := protodesc.NewFiles(current)
+	if err != nil {
+		return fmt.Errorf("current file descriptors: %w", err)
+	}
+	var violations []Violation
+	for _, subject := range subjects {
+		currentDesc, err := currentFiles.FindDescriptorByName(subject.Message)
+		if err != nil {
+			return fmt.Errorf("subject `%s`: message %s not found in current file descriptors", subject.Name, subject.Message)
+		}
+		currentMessage, ok := currentDesc.(protoreflect.MessageDescriptor)
+		if !ok {
+			return fmt.Errorf("subject `%s`: %s is not a message", subject.Name, subject.Message)
+		}
+		previousDesc, err := previousFiles.FindDescriptorByName(subject.Message)
+		if err != nil {
+			continue
+		}
+		previousMessage, ok := previousDesc.(protoreflect.MessageDescriptor)
+		if !ok {
+			return fmt.Errorf("subject `%s`: %s is not a message", subject.Name, subject.Message)
+		}
+		previousSchema, err := opts.InferSchema(previousMessage)
+		if err != nil {
+			return fmt.Errorf("subject `%s`: infer previous schema: %w", subject.Name, err)
+		}
+		currentSchema, err := opts.InferSchema(currentMessage)
+		if err != nil {
+			return fmt.Errorf("subject `%s`: infer current schema: %w", subject.Name, err)
+		}
+		subjectViolations, err := CheckSchemas(subject, previousSchema, currentSchema)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, subjectViolations...)
+	}
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+// CheckSchemas returns the violations of the declared compatibility of the subject, by the change
+// from the previous to the current schema.
+func CheckSchemas(subject Subject, previous, current avro.Schema) ([]Violation, error) {
+	var directions []Compatibility
+	switch subject.Compatibility {
+	case None:
+	case Backward, Forward:
+		directions = []Compatibility{subject.Compatibility}
+	case Full:
+		directions = []Compatibility{Backward, Forward}
+	default:
+		return nil, fmt.Errorf("subject `%s`: unknown compatibility %q", subject.Name, subject.Compatibility)
+	}
+	var violations []Violation
+	for _, direction := range directions {
+		reader, writer := current, previous
+		if direction == Forward {
+			reader, writer = previous, current
+		}
+		for _, incompatibility := range avro.CheckCompatibility(reader, writer) {
+			violations = append(violations, Violation{
+				Subject:         subject.Name,
+				Compatibility:   direction,
+				Change:          change(direction, incompatibility),
+				Incompatibility: incompatibility,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// change describes the change from the previous to the current schema that caused the incompatibility.
+func change(direction Compatibility, incompatibility avro.Incompatibility) string {
+	// backward compatibility reads previous data with the current schema, and
+	// forward compatibility reads current data with the previous schema
+	previousType, currentType := incompatibility.Writer, incompatibility.Reader
+	if direction == Forward {
+		previousType, currentType = currentType, previousType
+	}
+	switch incompatibility.Type {
+	case avro.MissingFieldDefault:
+		if direction == Backward {
+			return fmt.Sprintf("adding field `%s` without a default", incompatibility.Path)
+		}
+		return fmt.Sprintf("removing field `%s`", incompatibility.Path)
+	case avro.MissingEnumSymbol:
+		if direction == Forward {
+			return fmt.Sprintf("adding symbol `%s` to enum `%s`", incompatibility.Name, incompatibility.Reader)
+		}
+		return fmt.Sprintf("removing symbol `%s` from enum `%s`", incompatibility.Name, incompatibility.Reader)
+	case avro.MissingUnionBranch:
+		if direction == Forward {
+			return fmt.Sprintf("adding type `%s` to `%s`", incompatibility.Name, incompatibility.Path)
+		}
+		return fmt.Sprintf("removing type `%s` from `%s`", incompatibility.Name, incompatibility.Path)
+	case avro.NameMismatch:
+		return fmt.Sprintf("renaming `%s` to `%s`", previousType, currentType)
+	case avro.FixedSizeMismatch:
+		return fmt.Sprintf(
+			"changing the size of `%s` from %s to %s", pathOrRoot(incompatibility.Path), previousType, currentType,
+		)
+	}
+	return fmt.Sprintf(
+		"changing the type of `%s` from %s to %s", pathOrRoot(incompatibility.Path), previousType, currentType,
+	)
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
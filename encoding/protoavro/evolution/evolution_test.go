@@ -0,0 +1,187 @@
+package evolution_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/evolution"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gotest.tools/v3/assert"
+)
+
+// orders returns a file descriptor set with an Order message with the fields, and a Status enum
+// with the symbols.
+func orders(fields []string, symbols ...string) *descriptorpb.FileDescriptorSet {
+	status := &descriptorpb.EnumDescriptorProto{Name: proto.String("Status")}
+	for i, symbol := range append([]string{"STATUS_UNSPECIFIED"}, symbols...) {
+		status.Value = append(status.Value, &descriptorpb.EnumValueDescriptorProto{
+			Name:   proto.String(symbol),
+			Number: proto.Int32(int32(i)),
+		})
+	}
+	order := &descriptorpb.DescriptorProto{
+		Name: proto.String("Order"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{
+				Name:     proto.String("status"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(),
+				TypeName: proto.String(".orders.v1.Status"),
+				JsonName: proto.String("status"),
+			},
+		},
+	}
+	for i, field := range fields {
+		order.Field = append(order.Field, &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(field),
+			Number:   proto.Int32(int32(i + 2)),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			JsonName: proto.String(field),
+		})
+	}
+	return &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:        proto.String("orders/v1/order.proto"),
+				Package:     proto.String("orders.v1"),
+				Syntax:      proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{order},
+				EnumType:    []*descriptorpb.EnumDescriptorProto{status},
+			},
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	previous := orders([]string{"order_id", "driver_id"}, "STATUS_CREATED")
+	for _, tt := range []struct {
+		name          string
+		current       *descriptorpb.FileDescriptorSet
+		opts          protoavro.SchemaOptions
+		compatibility evolution.Compatibility
+		errs          []string
+	}{
+		{
+			name:          "unchanged",
+			current:       previous,
+			compatibility: evolution.Full,
+		},
+		{
+			name:          "removed field, backward",
+			current:       orders([]string{"order_id"}, "STATUS_CREATED"),
+			compatibility: evolution.Backward,
+		},
+		{
+			name:          "removed field, forward",
+			current:       orders([]string{"order_id"}, "STATUS_CREATED"),
+			compatibility: evolution.Forward,
+			errs: []string{
+				"removing field `driver_id` breaks FORWARD compatibility for subject `orders-value`",
+			},
+		},
+		{
+			name:          "added field, full",
+			current:       orders([]string{"order_id", "driver_id", "vehicle_id"}, "STATUS_CREATED"),
+			compatibility: evolution.Full,
+			errs: []string{
+				"adding field `vehicle_id` without a default breaks BACKWARD compatibility for subject `orders-value`",
+			},
+		},
+		{
+			name:          "added field with null default, full",
+			current:       orders([]string{"order_id", "driver_id", "vehicle_id"}, "STATUS_CREATED"),
+			opts:          protoavro.SchemaOptions{NullDefaults: true},
+			compatibility: evolution.Full,
+		},
+		{
+			name:          "added symbol, full",
+			current:       orders([]string{"order_id", "driver_id"}, "STATUS_CREATED", "STATUS_DELIVERED"),
+			compatibility: evolution.Full,
+			errs: []string{
+				"adding symbol `STATUS_DELIVERED` to enum `orders.v1.Status` " +
+					"breaks FORWARD compatibility for subject `orders-value`",
+			},
+		},
+		{
+			name:          "removed symbol, backward",
+			current:       orders([]string{"order_id", "driver_id"}),
+			compatibility: evolution.Backward,
+			errs: []string{
+				"removing symbol `STATUS_CREATED` from enum `orders.v1.Status` " +
+					"breaks BACKWARD compatibility for subject `orders-value`",
+			},
+		},
+		{
+			name:          "none",
+			current:       orders(nil),
+			compatibility: evolution.None,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := evolution.Check(previous, tt.current, tt.opts, evolution.Subject{
+				Name:          "orders-value",
+				Message:       "orders.v1.Order",
+				Compatibility: tt.compatibility,
+			})
+			if len(tt.errs) == 0 {
+				assert.NilError(t, err)
+				return
+			}
+			var violations *evolution.Error
+			assert.Assert(t, errors.As(err, &violations))
+			got := make([]string, 0, len(violations.Violations))
+			for _, violation := range violations.Violations {
+				got = append(got, violation.String())
+			}
+			assert.DeepEqual(t, tt.errs, got)
+		})
+	}
+}
+
+func TestCheck_Subjects(t *testing.T) {
+	previous := orders([]string{"order_id"})
+	// new subjects are not checked
+	assert.NilError(t, evolution.Check(&descriptorpb.FileDescriptorSet{}, previous, protoavro.SchemaOptions{}, evolution.Subject{
+		Name:          "orders-value",
+		Message:       "orders.v1.Order",
+		Compatibility: evolution.Full,
+	}))
+	err := evolution.Check(previous, &descriptorpb.FileDescriptorSet{}, protoavro.SchemaOptions{}, evolution.Subject{
+		Name:          "orders-value",
+		Message:       "orders.v1.Order",
+		Compatibility: evolution.Full,
+	})
+	assert.Error(t, err, "subject `orders-value`: message orders.v1.Order not found in current file descriptors")
+	err = evolution.Check(previous, previous, protoavro.SchemaOptions{}, evolution.Subject{
+		Name:          "orders-value",
+		Message:       "orders.v1.Order",
+		Compatibility: "SIDEWAYS",
+	})
+	assert.Error(t, err, "subject `orders-value`: unknown compatibility \"SIDEWAYS\"")
+}
+
+func TestAssertCompatible(t *testing.T) {
+	evolution.AssertCompatible(
+		t,
+		orders([]string{"order_id", "driver_id"}),
+		orders([]string{"order_id"}),
+		protoavro.SchemaOptions{},
+		evolution.Subject{Name: "orders-value", Message: "orders.v1.Order", Compatibility: evolution.Backward},
+	)
+}
+
+func TestReadFileDescriptorSet(t *testing.T) {
+	set := orders([]string{"order_id"})
+	data, err := proto.Marshal(set)
+	assert.NilError(t, err)
+	path := filepath.Join(t.TempDir(), "orders.binpb")
+	assert.NilError(t, os.WriteFile(path, data, 0o600))
+	got, err := evolution.ReadFileDescriptorSet(path)
+	assert.NilError(t, err)
+	assert.Assert(t, proto.Equal(set, got))
+}
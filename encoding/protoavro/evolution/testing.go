@@ -0,0 +1,31 @@
+package evolution
+
+import (
+	"errors"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// AssertCompatible checks the subjects with Check, and reports an error of the test for every violation.
+func AssertCompatible(
+	t testing.TB,
+	previous *descriptorpb.FileDescriptorSet,
+	current *descriptorpb.FileDescriptorSet,
+	opts protoavro.SchemaOptions,
+	subjects ...Subject,
+) {
+	t.Helper()
+	err := Check(previous, current, opts, subjects...)
+	var violations *Error
+	switch {
+	case err == nil:
+	case errors.As(err, &violations):
+		for _, violation := range violations.Violations {
+			t.Error(violation.String())
+		}
+	default:
+		t.Error(err)
+	}
+}
@@ -0,0 +1,97 @@
+package protoavro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// fieldMaskTree is a tree of field names built from the paths of a field mask.
+// A nil or empty tree selects all fields.
+type fieldMaskTree map[string]fieldMaskTree
+
+func newFieldMaskTree(mask *fieldmaskpb.FieldMask) fieldMaskTree {
+	if len(mask.GetPaths()) == 0 {
+		return nil
+	}
+	tree := make(fieldMaskTree)
+	for _, path := range mask.GetPaths() {
+		node := tree
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			if i == len(names)-1 {
+				// a leaf selects the whole subtree, even if a
+				// more specific path was added before.
+				node[name] = fieldMaskTree{}
+				break
+			}
+			child, ok := node[name]
+			if ok && len(child) == 0 {
+				// already selected by a shorter path.
+				break
+			}
+			if !ok {
+				child = make(fieldMaskTree)
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// child returns the subtree for the field name, and whether the field
+// is selected by the tree at all.
+func (t fieldMaskTree) child(name string) (fieldMaskTree, bool) {
+	if len(t) == 0 {
+		return nil, true
+	}
+	sub, ok := t[name]
+	if !ok {
+		return nil, false
+	}
+	if len(sub) == 0 {
+		return nil, true
+	}
+	return sub, true
+}
+
+// mapEntry returns the tree for the entry message of a map field, where
+// the paths of the map field apply to the map values.
+func (t fieldMaskTree) mapEntry() fieldMaskTree {
+	if len(t) == 0 {
+		return nil
+	}
+	return fieldMaskTree{"key": nil, "value": t}
+}
+
+// validate returns an error if the tree selects fields that do not exist in the message.
+func (t fieldMaskTree) validate(message protoreflect.MessageDescriptor) error {
+	for name := range t {
+		if message.Fields().ByName(protoreflect.Name(name)) == nil {
+			return fmt.Errorf("field mask: unknown field %s in message %s", name, message.FullName())
+		}
+	}
+	return nil
+}
+
+// String returns the sorted field mask paths of the tree.
+func (t fieldMaskTree) String() string {
+	paths := make([]string, 0, len(t))
+	var walk func(prefix string, node fieldMaskTree)
+	walk = func(prefix string, node fieldMaskTree) {
+		for name, child := range node {
+			if len(child) == 0 {
+				paths = append(paths, prefix+name)
+				continue
+			}
+			walk(prefix+name+".", child)
+		}
+	}
+	walk("", t)
+	sort.Strings(paths)
+	return strings.Join(paths, ",")
+}
@@ -0,0 +1,272 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gotest.tools/v3/assert"
+)
+
+func Test_DecodeMask(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		msg      proto.Message
+		paths    []string
+		expected proto.Message
+	}{
+		{
+			name: "empty mask",
+			msg: &library.Book{
+				Name:   "books/1",
+				Author: "J. K. Rowling",
+			},
+			expected: &library.Book{
+				Name:   "books/1",
+				Author: "J. K. Rowling",
+			},
+		},
+		{
+			name: "top-level fields",
+			msg: &library.Book{
+				Name:   "books/1",
+				Author: "J. K. Rowling",
+				Title:  "Harry Potter",
+				Read:   true,
+			},
+			paths: []string{"name", "read"},
+			expected: &library.Book{
+				Name: "books/1",
+				Read: true,
+			},
+		},
+		{
+			name: "nested fields",
+			msg: &library.UpdateBookRequest{
+				Book: &library.Book{
+					Name:   "books/1",
+					Author: "J. K. Rowling",
+				},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"author"}},
+			},
+			paths: []string{"book.author"},
+			expected: &library.UpdateBookRequest{
+				Book: &library.Book{
+					Author: "J. K. Rowling",
+				},
+			},
+		},
+		{
+			name: "shorter path selects subtree",
+			msg: &library.UpdateBookRequest{
+				Book: &library.Book{
+					Name:   "books/1",
+					Author: "J. K. Rowling",
+				},
+			},
+			paths: []string{"book.author", "book"},
+			expected: &library.UpdateBookRequest{
+				Book: &library.Book{
+					Name:   "books/1",
+					Author: "J. K. Rowling",
+				},
+			},
+		},
+		{
+			name: "list elements",
+			msg: &examplev1.ExampleList{
+				Int64List: []int64{1, 2},
+				NestedList: []*examplev1.ExampleList_Nested{
+					{StringList: []string{"a"}},
+					{StringList: []string{"b"}},
+				},
+			},
+			paths: []string{"nested_list.string_list"},
+			expected: &examplev1.ExampleList{
+				NestedList: []*examplev1.ExampleList_Nested{
+					{StringList: []string{"a"}},
+					{StringList: []string{"b"}},
+				},
+			},
+		},
+		{
+			name: "map values",
+			msg: &examplev1.ExampleMap{
+				StringToString: map[string]string{"a": "b"},
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{
+					"a": {StringToString: map[string]string{"c": "d"}},
+				},
+			},
+			paths: []string{"string_to_nested.string_to_string"},
+			expected: &examplev1.ExampleMap{
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{
+					"a": {StringToString: map[string]string{"c": "d"}},
+				},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := SchemaOptions{}.encodeJSON(tt.msg)
+			assert.NilError(t, err)
+			opts := SchemaOptions{DecodeMask: &fieldmaskpb.FieldMask{Paths: tt.paths}}
+			got := tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, opts.decodeJSON(data, got))
+			assert.DeepEqual(t, tt.expected, got, protocmp.Transform())
+		})
+	}
+}
+
+func Test_SchemaMask(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		msg            proto.Message
+		paths          []string
+		expectedSchema avro.Schema
+		expectedJSON   map[string]interface{}
+	}{
+		{
+			name: "nested fields",
+			msg: &library.UpdateBookRequest{
+				Book: &library.Book{
+					Name:   "books/1",
+					Author: "J. K. Rowling",
+				},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"author"}},
+			},
+			paths: []string{"book.name"},
+			expectedSchema: avro.Nullable(avro.Record{
+				Type:      avro.RecordType,
+				Name:      "UpdateBookRequest",
+				Namespace: "google.example.library.v1",
+				Fields: []avro.Field{
+					{
+						Name: "book",
+						Type: avro.Nullable(avro.Record{
+							Type:      avro.RecordType,
+							Name:      "Book",
+							Namespace: "google.example.library.v1",
+							Fields: []avro.Field{
+								{Name: "name", Type: avro.Nullable(avro.String())},
+							},
+						}),
+					},
+				},
+			}),
+			expectedJSON: map[string]interface{}{
+				"google.example.library.v1.UpdateBookRequest": map[string]interface{}{
+					"book": map[string]interface{}{
+						"google.example.library.v1.Book": map[string]interface{}{
+							"name": map[string]interface{}{"string": "books/1"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "map values",
+			msg: &examplev1.ExampleMap{
+				StringToString: map[string]string{"a": "b"},
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{
+					"a": {},
+				},
+			},
+			paths: []string{"string_to_nested"},
+			expectedSchema: avro.Nullable(avro.Record{
+				Type:      avro.RecordType,
+				Name:      "ExampleMap",
+				Namespace: "einride.avro.example.v1",
+				Fields: []avro.Field{
+					{
+						Name: "string_to_nested",
+						Type: avro.Nullable(avro.Array{
+							Type: avro.ArrayType,
+							Items: avro.Record{
+								Type:      avro.RecordType,
+								Name:      "StringToNestedEntry",
+								Namespace: "einride.avro.example.v1.ExampleMap",
+								Fields: []avro.Field{
+									{Name: "key", Type: avro.Nullable(avro.String())},
+									{
+										Name: "value",
+										Type: avro.Nullable(avro.Record{
+											Type:      avro.RecordType,
+											Name:      "Nested",
+											Namespace: "einride.avro.example.v1.ExampleMap",
+											Fields: []avro.Field{
+												{
+													Name: "string_to_string",
+													Type: avro.Nullable(avro.Array{
+														Type: avro.ArrayType,
+														Items: avro.Record{
+															Type:      avro.RecordType,
+															Name:      "StringToStringEntry",
+															Namespace: "einride.avro.example.v1.ExampleMap.Nested",
+															Fields: []avro.Field{
+																{Name: "key", Type: avro.Nullable(avro.String())},
+																{Name: "value", Type: avro.Nullable(avro.String())},
+															},
+														},
+													}),
+												},
+											},
+										}),
+									},
+								},
+							},
+						}),
+					},
+				},
+			}),
+			expectedJSON: map[string]interface{}{
+				"einride.avro.example.v1.ExampleMap": map[string]interface{}{
+					"string_to_nested": map[string]interface{}{
+						"array": []interface{}{
+							map[string]interface{}{
+								"key": map[string]interface{}{"string": "a"},
+								"value": map[string]interface{}{
+									"einride.avro.example.v1.ExampleMap.Nested": map[string]interface{}{
+										"string_to_string": map[string]interface{}{
+											"array": []interface{}{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SchemaOptions{SchemaMask: &fieldmaskpb.FieldMask{Paths: tt.paths}}
+			schema, err := opts.InferSchema(tt.msg.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expectedSchema, schema)
+			got, err := opts.encodeJSON(tt.msg)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expectedJSON, got)
+
+			// assert that it matches schema
+			schemaBytes, err := json.Marshal(schema)
+			assert.NilError(t, err)
+			codec, err := goavro.NewCodec(string(schemaBytes))
+			assert.NilError(t, err)
+			_, err = codec.BinaryFromNative(nil, got)
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func Test_SchemaMaskErr(t *testing.T) {
+	opts := SchemaOptions{SchemaMask: &fieldmaskpb.FieldMask{Paths: []string{"book.foo"}}}
+	_, err := opts.InferSchema((&library.UpdateBookRequest{}).ProtoReflect().Descriptor())
+	assert.ErrorContains(t, err, "unknown field foo in message google.example.library.v1.Book")
+}
@@ -0,0 +1,102 @@
+package protoavro
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldName returns the name of a field in the records of its message, see SchemaOptions.UseJSONNames and
+// SchemaOptions.FieldNameFunc.
+func (o SchemaOptions) fieldName(field protoreflect.FieldDescriptor) string {
+	if names := o.recordFieldNames(field.ContainingMessage()); names != nil {
+		return names[field.Index()]
+	}
+	return string(field.Name())
+}
+
+// baseFieldName returns the name of a field before FieldNameFunc and the resolution of invalid and colliding
+// names.
+func (o SchemaOptions) baseFieldName(field protoreflect.FieldDescriptor) string {
+	if o.UseJSONNames {
+		return field.JSONName()
+	}
+	return string(field.Name())
+}
+
+// recordFieldNames returns the names of the fields of the message in its records, by field index, or nil when
+// fields are named by their original names, which are valid and unique Avro names.
+//
+// Names returned by FieldNameFunc, and custom JSON names, are made valid Avro names by replacing invalid
+// characters with underscores, and prefixing names that do not start with a letter or an underscore with an
+// underscore. Names that collide with the name of a field with a lower field number are suffixed with _2, _3 and
+// so on, so that fields keep their names when fields with higher numbers are added.
+func (o SchemaOptions) recordFieldNames(desc protoreflect.MessageDescriptor) []string {
+	if (o.FieldNameFunc == nil && !o.UseJSONNames) || desc.IsMapEntry() {
+		return nil
+	}
+	fields := desc.Fields()
+	byNumber := make([]protoreflect.FieldDescriptor, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		byNumber = append(byNumber, fields.Get(i))
+	}
+	sort.Slice(byNumber, func(i, j int) bool {
+		return byNumber[i].Number() < byNumber[j].Number()
+	})
+	names := make([]string, fields.Len())
+	taken := make(map[string]struct{}, fields.Len())
+	for _, field := range byNumber {
+		name := o.baseFieldName(field)
+		if o.FieldNameFunc != nil {
+			name = o.FieldNameFunc(field, name)
+		}
+		name = validAvroName(name)
+		resolved := name
+		for i := 2; ; i++ {
+			if _, ok := taken[resolved]; !ok {
+				break
+			}
+			resolved = name + "_" + strconv.Itoa(i)
+		}
+		taken[resolved] = struct{}{}
+		names[field.Index()] = resolved
+	}
+	return names
+}
+
+// fieldAliases returns the aliases of the record field of a field, which is the base name of the field when the
+// field is renamed by FieldNameFunc or the resolution of names, or its original name when the base name is not
+// a valid Avro name. Aliases that are names of other fields of the record are omitted.
+func (o SchemaOptions) fieldAliases(field protoreflect.FieldDescriptor) []string {
+	names := o.recordFieldNames(field.ContainingMessage())
+	if names == nil {
+		return nil
+	}
+	alias := o.baseFieldName(field)
+	if validAvroName(alias) != alias {
+		alias = string(field.Name())
+	}
+	for _, name := range names {
+		if name == alias {
+			return nil
+		}
+	}
+	return []string{alias}
+}
+
+// validAvroName returns the name with characters that are not valid in Avro names replaced by underscores, and
+// prefixed with an underscore when it does not start with a letter or an underscore.
+func validAvroName(name string) string {
+	valid := strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	if valid == "" || valid[0] >= '0' && valid[0] <= '9' {
+		return "_" + valid
+	}
+	return valid
+}
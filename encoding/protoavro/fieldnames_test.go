@@ -0,0 +1,112 @@
+package protoavro
+
+import (
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestFieldNameFunc(t *testing.T) {
+	book := &library.Book{Name: "shelves/1/books/1", Author: "Frank Herbert", Title: "Dune", Read: true}
+	opts := SchemaOptions{
+		FieldNameFunc: func(_ protoreflect.FieldDescriptor, name string) string {
+			switch name {
+			case "author":
+				return "title"
+			case "read":
+				return "is-read"
+			}
+			return name
+		},
+	}
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := opts.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		record := schema.(avro.Union)[1].(avro.Record)
+		type field struct {
+			Name    string
+			Aliases []string
+		}
+		fields := make([]field, 0, len(record.Fields))
+		for _, f := range record.Fields {
+			fields = append(fields, field{Name: f.Name, Aliases: f.Aliases})
+		}
+		assert.DeepEqual(t, []field{
+			{Name: "name"},
+			{Name: "title", Aliases: []string{"author"}},
+			// the original name is the name of another field
+			{Name: "title_2"},
+			{Name: "is_read", Aliases: []string{"read"}},
+		}, fields)
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		data, err := opts.Encode(book)
+		assert.NilError(t, err)
+		record := data.(map[string]interface{})["google.example.library.v1.Book"].(map[string]interface{})
+		assert.DeepEqual(t, map[string]interface{}{"string": "Frank Herbert"}, record["title"])
+		assert.DeepEqual(t, map[string]interface{}{"string": "Dune"}, record["title_2"])
+		assert.DeepEqual(t, map[string]interface{}{"boolean": true}, record["is_read"])
+		var decoded library.Book
+		assert.NilError(t, opts.Decode(data, &decoded))
+		assert.DeepEqual(t, book, &decoded, protocmp.Transform())
+	})
+
+	t.Run("codec", func(t *testing.T) {
+		codec, err := NewCodec[*library.Book](opts)
+		assert.NilError(t, err)
+		b, err := codec.Marshal(book)
+		assert.NilError(t, err)
+		decoded, err := codec.Unmarshal(b)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, book, decoded, protocmp.Transform())
+	})
+
+	t.Run("avro json", func(t *testing.T) {
+		data, err := opts.MarshalAvroJSON(book)
+		assert.NilError(t, err)
+		var decoded library.Book
+		assert.NilError(t, opts.UnmarshalAvroJSON(data, &decoded))
+		assert.DeepEqual(t, book, &decoded, protocmp.Transform())
+	})
+
+	t.Run("original names", func(t *testing.T) {
+		opts := SchemaOptions{
+			FieldNameFunc: func(_ protoreflect.FieldDescriptor, name string) string {
+				if name == "read" {
+					return "class"
+				}
+				return name
+			},
+		}
+		writer, err := SchemaOptions{}.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		assert.NilError(t, opts.VerifyCompatible(writer, book.ProtoReflect().Descriptor()))
+		data, err := SchemaOptions{}.Encode(book)
+		assert.NilError(t, err)
+		var decoded library.Book
+		assert.NilError(t, opts.Decode(data, &decoded))
+		assert.DeepEqual(t, book, &decoded, protocmp.Transform())
+	})
+}
+
+func Test_validAvroName(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		expected string
+	}{
+		{name: "title", expected: "title"},
+		{name: "_title", expected: "_title"},
+		{name: "book-id", expected: "book_id"},
+		{name: "1st", expected: "_1st"},
+		{name: "", expected: "_"},
+		{name: "ümlaut", expected: "_mlaut"},
+	} {
+		assert.Equal(t, tt.expected, validAvroName(tt.name), tt.name)
+	}
+}
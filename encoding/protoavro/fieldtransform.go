@@ -0,0 +1,331 @@
+package protoavro
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldTransform is a transformation of a field of the records of a message, declared in configuration rather
+// than in code, with a CEL expression. See SchemaOptions.FieldTransforms.
+type FieldTransform struct {
+	// Message is the full name of the message of the transformed records.
+	Message string `json:"message"`
+	// Field is the name of a field of the message, or the name of a derived field that is added to the records.
+	Field string `json:"field"`
+	// Rename is the name of the field in the records, when not empty.
+	Rename string `json:"rename,omitempty"`
+	// Redact encodes the field as null.
+	Redact bool `json:"redact,omitempty"`
+	// Expression is a CEL expression of the value of the field, evaluated with the message bound to the
+	// variable msg, for example msg.title.lowerAscii(). Values of fields of the message must be of the type of
+	// the field, and can only replace values of singular scalar and enum fields.
+	Expression string `json:"expression,omitempty"`
+	// Type is the Avro type of a derived field: boolean, int, long, float, double, bytes or string.
+	// Derived fields are nullable, and are skipped when decoding.
+	Type string `json:"type,omitempty"`
+	// Tokenize replaces values of a singular string field by tokens of SchemaOptions.Tokenizer, TokenizeHMAC or
+	// TokenizeFormatHash, to pseudonymize the values while keeping them joinable. Tokens are decoded as
+	// the values of the field.
+	Tokenize string `json:"tokenize,omitempty"`
+}
+
+// fieldTransformVariable is the variable of the message in the expressions of field transforms.
+const fieldTransformVariable = "msg"
+
+// fieldTransformPrograms caches the compiled programs of field transforms, by message and transform.
+var fieldTransformPrograms descriptorCache[fieldTransformKey, cel.Program]
+
+type fieldTransformKey struct {
+	desc      protoreflect.MessageDescriptor
+	transform FieldTransform
+}
+
+// celValueType is the CEL type of the values of a field, and the Go type they are converted to.
+type celValueType struct {
+	cel    *cel.Type
+	goType reflect.Type
+}
+
+// derivedFieldTypes are the types of derived fields, by their Avro type.
+var derivedFieldTypes = map[string]celValueType{
+	"boolean": {cel: cel.BoolType, goType: reflect.TypeOf(false)},
+	"int":     {cel: cel.IntType, goType: reflect.TypeOf(int32(0))},
+	"long":    {cel: cel.IntType, goType: reflect.TypeOf(int64(0))},
+	"float":   {cel: cel.DoubleType, goType: reflect.TypeOf(float32(0))},
+	"double":  {cel: cel.DoubleType, goType: reflect.TypeOf(float64(0))},
+	"bytes":   {cel: cel.BytesType, goType: reflect.TypeOf([]byte(nil))},
+	"string":  {cel: cel.StringType, goType: reflect.TypeOf("")},
+}
+
+// fieldValueTypes are the types of values of scalar and enum fields, by their kind.
+var fieldValueTypes = map[protoreflect.Kind]celValueType{
+	protoreflect.BoolKind:     {cel: cel.BoolType, goType: reflect.TypeOf(false)},
+	protoreflect.EnumKind:     {cel: cel.IntType, goType: reflect.TypeOf(int32(0))},
+	protoreflect.Int32Kind:    {cel: cel.IntType, goType: reflect.TypeOf(int32(0))},
+	protoreflect.Sint32Kind:   {cel: cel.IntType, goType: reflect.TypeOf(int32(0))},
+	protoreflect.Sfixed32Kind: {cel: cel.IntType, goType: reflect.TypeOf(int32(0))},
+	protoreflect.Int64Kind:    {cel: cel.IntType, goType: reflect.TypeOf(int64(0))},
+	protoreflect.Sint64Kind:   {cel: cel.IntType, goType: reflect.TypeOf(int64(0))},
+	protoreflect.Sfixed64Kind: {cel: cel.IntType, goType: reflect.TypeOf(int64(0))},
+	protoreflect.Uint32Kind:   {cel: cel.UintType, goType: reflect.TypeOf(uint32(0))},
+	protoreflect.Fixed32Kind:  {cel: cel.UintType, goType: reflect.TypeOf(uint32(0))},
+	protoreflect.Uint64Kind:   {cel: cel.UintType, goType: reflect.TypeOf(uint64(0))},
+	protoreflect.Fixed64Kind:  {cel: cel.UintType, goType: reflect.TypeOf(uint64(0))},
+	protoreflect.FloatKind:    {cel: cel.DoubleType, goType: reflect.TypeOf(float32(0))},
+	protoreflect.DoubleKind:   {cel: cel.DoubleType, goType: reflect.TypeOf(float64(0))},
+	protoreflect.StringKind:   {cel: cel.StringType, goType: reflect.TypeOf("")},
+	protoreflect.BytesKind:    {cel: cel.BytesType, goType: reflect.TypeOf([]byte(nil))},
+}
+
+// fieldTransforms returns the field transforms of the records of the message.
+func (o SchemaOptions) fieldTransforms(desc protoreflect.MessageDescriptor) []FieldTransform {
+	var transforms []FieldTransform
+	for _, transform := range o.FieldTransforms {
+		if protoreflect.FullName(transform.Message) == desc.FullName() {
+			transforms = append(transforms, transform)
+		}
+	}
+	return transforms
+}
+
+// transformNames returns the names of the field in the records before and after the transform, where fields of the
+// message are named as in records, see SchemaOptions.UseJSONNames.
+func (o SchemaOptions) transformNames(t FieldTransform, field protoreflect.FieldDescriptor) (string, string) {
+	name := t.Field
+	if field != nil {
+		name = o.fieldName(field)
+	}
+	if t.Rename != "" {
+		return name, t.Rename
+	}
+	return name, name
+}
+
+// valueType returns the type of the values of the field, and the field of the message, or nil for a derived
+// field.
+func (t FieldTransform) valueType(
+	desc protoreflect.MessageDescriptor,
+) (celValueType, protoreflect.FieldDescriptor, error) {
+	if t.Redact && t.Expression != "" {
+		return celValueType{}, nil, fmt.Errorf("expression of redacted field")
+	}
+	field := desc.Fields().ByName(protoreflect.Name(t.Field))
+	if t.Tokenize != "" {
+		if err := t.checkTokenize(field); err != nil {
+			return celValueType{}, nil, err
+		}
+	}
+	if field == nil {
+		if t.Expression == "" {
+			return celValueType{}, nil, fmt.Errorf("unknown field %s of message %s", t.Field, desc.FullName())
+		}
+		valueType, ok := derivedFieldTypes[t.Type]
+		if !ok {
+			return celValueType{}, nil, fmt.Errorf("unsupported type '%s' of derived field", t.Type)
+		}
+		return valueType, nil, nil
+	}
+	switch {
+	case t.Type != "":
+		return celValueType{}, nil, fmt.Errorf("type of field of message %s, not a derived field", desc.FullName())
+	case t.Expression == "":
+		return celValueType{}, field, nil
+	}
+	valueType, ok := fieldValueTypes[field.Kind()]
+	if !ok || field.IsList() || field.IsMap() {
+		return celValueType{}, nil, fmt.Errorf("expression of %s field", describeField(field))
+	}
+	return valueType, field, nil
+}
+
+func describeField(field protoreflect.FieldDescriptor) string {
+	switch {
+	case field.IsMap():
+		return "map"
+	case field.IsList():
+		return "repeated"
+	}
+	return field.Kind().String()
+}
+
+// program returns the compiled program of the expression of the transform, which returns values of the type.
+func (t FieldTransform) program(desc protoreflect.MessageDescriptor, valueType celValueType) (cel.Program, error) {
+	key := fieldTransformKey{desc: desc, transform: t}
+	if program, ok := fieldTransformPrograms.load(key); ok {
+		return program, nil
+	}
+	env, err := cel.NewEnv(
+		cel.TypeDescs(desc.ParentFile()),
+		cel.Variable(fieldTransformVariable, cel.ObjectType(string(desc.FullName()))),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(t.Expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if output := ast.OutputType(); output != cel.DynType && !valueType.cel.IsAssignableType(output) {
+		return nil, fmt.Errorf("expression of type %s, expected %s", output, valueType.cel)
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return fieldTransformPrograms.loadOrStore(key, program), nil
+}
+
+// eval evaluates the expression of the transform for the message, and returns the Go value of its result, or
+// nil for null.
+func (t FieldTransform) eval(message protoreflect.Message, valueType celValueType) (interface{}, error) {
+	program, err := t.program(message.Descriptor(), valueType)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := program.Eval(map[string]interface{}{fieldTransformVariable: message.Interface()})
+	if err != nil {
+		return nil, err
+	}
+	return celNative(out, valueType)
+}
+
+func celNative(value ref.Val, valueType celValueType) (interface{}, error) {
+	if value == types.NullValue {
+		return nil, nil
+	}
+	native, err := value.ConvertToNative(valueType.goType)
+	if err != nil {
+		return nil, err
+	}
+	return native, nil
+}
+
+// transformSchema applies the field transforms of the message to the fields of its record.
+func (o SchemaOptions) transformSchema(desc protoreflect.MessageDescriptor, fields []avro.Field) ([]avro.Field, error) {
+	for _, transform := range o.fieldTransforms(desc) {
+		valueType, field, err := transform.valueType(desc)
+		if err != nil {
+			return nil, fmt.Errorf("field transform %s.%s: %w", transform.Message, transform.Field, err)
+		}
+		if transform.Expression != "" {
+			// invalid expressions fail schema inference, rather than the encoding of the first message
+			if _, err := transform.program(desc, valueType); err != nil {
+				return nil, fmt.Errorf("field transform %s.%s: %w", transform.Message, transform.Field, err)
+			}
+		}
+		name, renamed := o.transformNames(transform, field)
+		if renamed != name || field == nil {
+			for _, existing := range fields {
+				if existing.Name == renamed {
+					return nil, fmt.Errorf(
+						"field transform %s.%s: field %s collides with a field of message %s",
+						transform.Message, transform.Field, renamed, desc.FullName(),
+					)
+				}
+			}
+		}
+		if field == nil {
+			schema := avro.Primitive{Type: avro.Type(transform.Type)}
+			fields = append(fields, avro.Field{Name: renamed, Type: avro.Nullable(schema)})
+			continue
+		}
+		for i := range fields {
+			if fields[i].Name != name {
+				continue
+			}
+			fields[i].Name = renamed
+			if transform.Tokenize != "" {
+				if err := o.tokenizeSchema(&fields[i]); err != nil {
+					return nil, fmt.Errorf("field transform %s.%s: %w", transform.Message, transform.Field, err)
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+// transformRecord applies the field transforms of the message to its record.
+func (o SchemaOptions) transformRecord(
+	message protoreflect.Message,
+	record map[string]interface{},
+	recursiveIndex int,
+) error {
+	for _, transform := range o.fieldTransforms(message.Descriptor()) {
+		valueType, field, err := transform.valueType(message.Descriptor())
+		if err != nil {
+			return fmt.Errorf("field transform %s.%s: %w", transform.Message, transform.Field, err)
+		}
+		name, renamed := o.transformNames(transform, field)
+		if _, ok := record[name]; field != nil && !ok {
+			// the field is not in the record, for example when it is not in the schema mask
+			continue
+		}
+		switch {
+		case transform.Redact:
+			record[name] = nil
+		case transform.Tokenize != "":
+			if record[name], err = o.tokenize(transform, message, field); err != nil {
+				return fmt.Errorf("field transform %s.%s: %w", transform.Message, transform.Field, err)
+			}
+		case transform.Expression != "":
+			native, err := transform.eval(message, valueType)
+			if err != nil {
+				return fmt.Errorf("field transform %s.%s: %w", transform.Message, transform.Field, err)
+			}
+			switch {
+			case native == nil:
+				record[name] = nil
+			case field == nil:
+				record[name] = o.unionValue(transform.Type, native)
+			default:
+				value := protoreflect.ValueOf(native)
+				if field.Kind() == protoreflect.EnumKind {
+					value = protoreflect.ValueOfEnum(protoreflect.EnumNumber(native.(int32)))
+				}
+				if record[name], err = o.fieldJSON(field, value, recursiveIndex+1, nil); err != nil {
+					return err
+				}
+			}
+		}
+		if renamed != name {
+			record[renamed] = record[name]
+			delete(record, name)
+		}
+	}
+	return nil
+}
+
+// untransformRecord returns the record of the message with the names of its fields before the field transforms
+// of the message, and without derived fields.
+func (o SchemaOptions) untransformRecord(
+	record map[string]interface{},
+	desc protoreflect.MessageDescriptor,
+) map[string]interface{} {
+	transforms := o.fieldTransforms(desc)
+	if len(transforms) == 0 {
+		return record
+	}
+	restored := make(map[string]interface{}, len(record))
+	for name, value := range record {
+		restored[name] = value
+	}
+	for _, transform := range transforms {
+		field := desc.Fields().ByName(protoreflect.Name(transform.Field))
+		name, renamed := o.transformNames(transform, field)
+		value, ok := restored[renamed]
+		if !ok {
+			continue
+		}
+		delete(restored, renamed)
+		if field != nil {
+			restored[name] = value
+		}
+	}
+	return restored
+}
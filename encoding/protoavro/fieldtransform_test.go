@@ -0,0 +1,132 @@
+package protoavro
+
+import (
+	"errors"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestFieldTransforms(t *testing.T) {
+	opts := SchemaOptions{
+		FieldTransforms: []FieldTransform{
+			{Message: "google.example.library.v1.Book", Field: "title", Rename: "book_title"},
+			{Message: "google.example.library.v1.Book", Field: "author", Redact: true},
+			{Message: "google.example.library.v1.Book", Field: "name", Expression: `msg.name + "#v1"`},
+			{Message: "google.example.library.v1.Book", Field: "read", Expression: `msg.title != ""`},
+			{Message: "google.example.library.v1.Book", Field: "title_length", Expression: "size(msg.title)", Type: "long"},
+		},
+	}
+	book := &library.Book{Name: "shelves/1/books/1", Author: "Frank Herbert", Title: "Dune"}
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := opts.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		record := schema.(avro.Union)[1].(avro.Record)
+		names := make([]string, 0, len(record.Fields))
+		for _, field := range record.Fields {
+			names = append(names, field.Name)
+		}
+		assert.DeepEqual(t, []string{"name", "author", "book_title", "read", "title_length"}, names)
+		assert.DeepEqual(t, avro.Nullable(avro.Long()), record.Fields[4].Type)
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		codec := newTestGoavroCodec(t, opts, book)
+		native, err := opts.Encode(book)
+		assert.NilError(t, err)
+		// the encoding matches the schema
+		_, err = codec.BinaryFromNative(nil, native)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, map[string]interface{}{
+			"google.example.library.v1.Book": map[string]interface{}{
+				"name":         map[string]interface{}{"string": "shelves/1/books/1#v1"},
+				"author":       nil,
+				"book_title":   map[string]interface{}{"string": "Dune"},
+				"read":         map[string]interface{}{"boolean": true},
+				"title_length": map[string]interface{}{"long": int64(4)},
+			},
+		}, native)
+		var decoded library.Book
+		assert.NilError(t, opts.Decode(native, &decoded))
+		// renamed fields are decoded into their fields, and derived fields are skipped
+		expected := &library.Book{Name: "shelves/1/books/1#v1", Title: "Dune", Read: true}
+		assert.DeepEqual(t, expected, &decoded, protocmp.Transform())
+	})
+
+	t.Run("nested messages", func(t *testing.T) {
+		request := &library.CreateBookRequest{Parent: "shelves/1", Book: book}
+		native, err := opts.Encode(request)
+		assert.NilError(t, err)
+		codec := newTestGoavroCodec(t, opts, request)
+		_, err = codec.BinaryFromNative(nil, native)
+		assert.NilError(t, err)
+		var decoded library.CreateBookRequest
+		assert.NilError(t, opts.Decode(native, &decoded))
+		assert.Equal(t, "Dune", decoded.GetBook().GetTitle())
+		assert.Equal(t, "", decoded.GetBook().GetAuthor())
+	})
+
+	t.Run("direct decoding", func(t *testing.T) {
+		schema, err := opts.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		_, err = opts.newBinaryDecoder(book.ProtoReflect().Descriptor(), schema)
+		assert.Assert(t, errors.Is(err, errUnsupported))
+	})
+
+	for _, tt := range []struct {
+		name      string
+		transform FieldTransform
+		expected  string
+	}{
+		{
+			name:      "unknown field",
+			transform: FieldTransform{Field: "subtitle", Rename: "sub"},
+			expected:  "unknown field subtitle of message google.example.library.v1.Book",
+		},
+		{
+			name:      "derived field without type",
+			transform: FieldTransform{Field: "subtitle", Expression: "msg.title"},
+			expected:  "unsupported type '' of derived field",
+		},
+		{
+			name:      "rename collision",
+			transform: FieldTransform{Field: "title", Rename: "author"},
+			expected:  "field author collides with a field of message google.example.library.v1.Book",
+		},
+		{
+			name:      "expression of wrong type",
+			transform: FieldTransform{Field: "title", Expression: "size(msg.title)"},
+			expected:  "expression of type int, expected string",
+		},
+		{
+			name:      "invalid expression",
+			transform: FieldTransform{Field: "title", Expression: "msg.subtitle"},
+			expected:  "undefined field 'subtitle'",
+		},
+		{
+			name:      "expression of redacted field",
+			transform: FieldTransform{Field: "title", Redact: true, Expression: `""`},
+			expected:  "expression of redacted field",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.transform.Message = "google.example.library.v1.Book"
+			opts := SchemaOptions{FieldTransforms: []FieldTransform{tt.transform}}
+			_, err := opts.InferSchema(book.ProtoReflect().Descriptor())
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	t.Run("expression of message field", func(t *testing.T) {
+		opts := SchemaOptions{FieldTransforms: []FieldTransform{
+			{Message: "google.example.library.v1.CreateBookRequest", Field: "book", Expression: "msg.book"},
+		}}
+		_, err := opts.InferSchema((&library.CreateBookRequest{}).ProtoReflect().Descriptor())
+		assert.ErrorContains(t, err, "expression of message field")
+	})
+}
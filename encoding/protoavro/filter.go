@@ -0,0 +1,37 @@
+package protoavro
+
+import "context"
+
+// filterRecords skips the values of the reader for which filter returns false, see SchemaOptions.RecordFilter.
+func (r *ocfReader) filterRecords(filter func(record map[string]interface{}) bool) {
+	r.filter = filter
+	r.unionRoot = isUnionRoot(r.Codec().Schema())
+}
+
+// scanFiltered reads ahead to the next value that satisfies the filter, without decoding any message. The
+// context is checked before each block, since filters may skip many blocks before a value satisfies them.
+func (r *ocfReader) scanFiltered(ctx context.Context) bool {
+	for !r.hasNext {
+		for r.err == nil && r.count == 0 {
+			if r.err = ctx.Err(); r.err == nil {
+				r.err = r.nextBlock()
+			}
+		}
+		if r.err != nil {
+			return false
+		}
+		value, err := r.read()
+		if err != nil {
+			return false
+		}
+		if r.filter(r.record(value)) {
+			r.next, r.hasNext = value, true
+		}
+	}
+	return true
+}
+
+// record returns the record of a value, without the union of the root record.
+func (r *ocfReader) record(value interface{}) map[string]interface{} {
+	return rootRecord(value, r.unionRoot)
+}
@@ -0,0 +1,121 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+// stringField returns the value of a nullable string field of a record.
+func stringField(record map[string]interface{}, name string) string {
+	value, _ := record[name].(map[string]interface{})["string"].(string)
+	return value
+}
+
+// evenBooks returns true for the records of books with an even number.
+func evenBooks(record map[string]interface{}) bool {
+	name := stringField(record, "name")
+	n, err := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+	return err == nil && n%2 == 0
+}
+
+func TestSchemaOptions_RecordFilter(t *testing.T) {
+	data, books := marshalBlocks(t, 10)
+	var expected []*library.Book
+	for i := 0; i < len(books); i += 2 {
+		expected = append(expected, books[i])
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		opts := protoavro.SchemaOptions{RecordFilter: evenBooks}
+		unmarshaler, err := opts.NewUnmarshaler(bytes.NewReader(data))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, expected, unmarshalBooks(t, unmarshaler), protocmp.Transform())
+	})
+
+	t.Run("read parallelism", func(t *testing.T) {
+		opts := protoavro.SchemaOptions{RecordFilter: evenBooks, ReadParallelism: 3}
+		unmarshaler, err := opts.NewUnmarshaler(bytes.NewReader(data))
+		assert.NilError(t, err)
+		defer unmarshaler.Close()
+		assert.DeepEqual(t, expected, unmarshalBooks(t, unmarshaler), protocmp.Transform())
+	})
+
+	t.Run("seek to block", func(t *testing.T) {
+		opts := protoavro.SchemaOptions{RecordFilter: evenBooks}
+		unmarshaler, err := opts.NewUnmarshaler(bytes.NewReader(data))
+		assert.NilError(t, err)
+		// the record read ahead by Scan is dropped by seeking
+		assert.Assert(t, unmarshaler.Scan())
+		assert.NilError(t, unmarshaler.SeekToBlock(5))
+		assert.DeepEqual(t, expected[8:], unmarshalBooks(t, unmarshaler), protocmp.Transform())
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		opts := protoavro.SchemaOptions{
+			RecordFilter: func(record map[string]interface{}) bool {
+				return stringField(record, "title") != "Harry Potter"
+			},
+		}
+		unmarshaler, err := opts.NewUnmarshaler(bytes.NewReader(data))
+		assert.NilError(t, err)
+		assert.Assert(t, !unmarshaler.Scan())
+		assert.NilError(t, unmarshaler.Err())
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var n int
+		opts := protoavro.SchemaOptions{
+			RecordFilter: func(map[string]interface{}) bool {
+				// no record matches, and the scan is canceled while the first block is skipped
+				n++
+				cancel()
+				return false
+			},
+		}
+		unmarshaler, err := opts.NewUnmarshaler(bytes.NewReader(data))
+		assert.NilError(t, err)
+		assert.Assert(t, !unmarshaler.ScanContext(ctx))
+		assert.ErrorIs(t, unmarshaler.Err(), context.Canceled)
+		// the records of the first block are skipped, and no further blocks are read
+		assert.Equal(t, 3, n)
+	})
+
+	t.Run("union", func(t *testing.T) {
+		descs := []protoreflect.MessageDescriptor{
+			(&library.Book{}).ProtoReflect().Descriptor(),
+			(&library.Shelf{}).ProtoReflect().Descriptor(),
+		}
+		var b bytes.Buffer
+		marshaler, err := protoavro.SchemaOptions{}.NewUnionMarshaler(&b, descs...)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(
+			&library.Shelf{Name: "shelves/1"},
+			books[0],
+			books[1],
+			&library.Shelf{Name: "shelves/2"},
+		))
+		opts := protoavro.SchemaOptions{RecordFilter: evenBooks}
+		unmarshaler, err := opts.NewUnionUnmarshaler(&b, descs...)
+		assert.NilError(t, err)
+		var got []proto.Message
+		for unmarshaler.Scan() {
+			msg, err := unmarshaler.Unmarshal()
+			assert.NilError(t, err)
+			got = append(got, msg)
+		}
+		assert.NilError(t, unmarshaler.Err())
+		assert.DeepEqual(t, []proto.Message{books[0], &library.Shelf{Name: "shelves/2"}}, got, protocmp.Transform())
+	})
+}
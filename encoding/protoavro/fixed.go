@@ -0,0 +1,59 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fixedSize returns the size of the Avro fixed type of a bytes field, or zero when the field is mapped to bytes.
+func (o SchemaOptions) fixedSize(field protoreflect.FieldDescriptor) int {
+	if field.Kind() != protoreflect.BytesKind {
+		return 0
+	}
+	if size, ok := o.FixedSizes[field.FullName()]; ok {
+		return size
+	}
+	if o.FixedSizeFunc != nil {
+		return o.FixedSizeFunc(field)
+	}
+	return 0
+}
+
+// fixedName returns the Avro full name of the fixed type of a bytes field, which is named by the field, in the
+// namespace of the record of its message.
+func (o SchemaOptions) fixedName(field protoreflect.FieldDescriptor) string {
+	return joinName(o.avroName(field.ContainingMessage()), string(field.Name()))
+}
+
+// inferFixedSchema returns the fixed type of a bytes field of the size. The fixed is a named type, which is
+// defined once and referenced afterwards.
+func (s schemaInferrer) inferFixedSchema(field protoreflect.FieldDescriptor, size int) (avro.Schema, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("field %s: negative fixed size %d", field.FullName(), size)
+	}
+	name := s.opts.fixedName(field)
+	if s.references(field.FullName()) {
+		return avro.Reference(name), nil
+	}
+	s.seen[field.FullName()] = struct{}{}
+	if err := s.claimName(name, field.FullName()); err != nil {
+		return nil, err
+	}
+	return avro.Fixed{
+		Type:      avro.FixedType,
+		Name:      string(field.Name()),
+		Namespace: s.opts.avroName(field.ContainingMessage()),
+		Size:      size,
+	}, nil
+}
+
+// checkFixedSize returns an error when the value of a bytes field mapped to a fixed type of the size has
+// another length.
+func checkFixedSize(field protoreflect.FieldDescriptor, size int, value []byte) error {
+	if len(value) != size {
+		return fmt.Errorf("field %s: expected %d bytes of fixed, got %d", field.Name(), size, len(value))
+	}
+	return nil
+}
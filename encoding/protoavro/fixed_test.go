@@ -0,0 +1,95 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestFixedSizes(t *testing.T) {
+	desc := (&examplev1.ExampleBytes{}).ProtoReflect().Descriptor()
+	opts := SchemaOptions{
+		FixedSizes: map[protoreflect.FullName]int{"einride.avro.example.v1.ExampleBytes.bytes": 4},
+	}
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		record := schema.(avro.Union)[1].(avro.Record)
+		// empty values are encoded as null
+		assert.DeepEqual(t, avro.Union{avro.Null(), avro.Fixed{
+			Type:      avro.FixedType,
+			Name:      "bytes",
+			Namespace: "einride.avro.example.v1.ExampleBytes",
+			Size:      4,
+		}}, record.Fields[0].Type)
+	})
+
+	t.Run("size func", func(t *testing.T) {
+		schema, err := SchemaOptions{
+			FixedSizeFunc: func(field protoreflect.FieldDescriptor) int {
+				return 16
+			},
+		}.InferSchema(desc)
+		assert.NilError(t, err)
+		record := schema.(avro.Union)[1].(avro.Record)
+		assert.Equal(t, 16, record.Fields[0].Type.(avro.Union)[1].(avro.Fixed).Size)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, tt := range []struct {
+			name string
+			msg  *examplev1.ExampleBytes
+		}{
+			{name: "fixed", msg: &examplev1.ExampleBytes{Bytes: []byte{1, 2, 3, 4}}},
+			{name: "empty", msg: &examplev1.ExampleBytes{}},
+		} {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				codec, err := NewCodec[*examplev1.ExampleBytes](opts)
+				assert.NilError(t, err)
+				b, err := codec.Marshal(tt.msg)
+				assert.NilError(t, err)
+				got, err := codec.Unmarshal(b)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, tt.msg, got, protocmp.Transform())
+
+				avroJSON, err := opts.MarshalAvroJSON(tt.msg)
+				assert.NilError(t, err)
+				var fromJSON examplev1.ExampleBytes
+				assert.NilError(t, opts.UnmarshalAvroJSON(avroJSON, &fromJSON))
+				assert.DeepEqual(t, tt.msg, &fromJSON, protocmp.Transform())
+
+				var ocf bytes.Buffer
+				marshaler, err := opts.NewMarshaler(desc, &ocf)
+				assert.NilError(t, err)
+				assert.NilError(t, marshaler.Marshal(tt.msg))
+				unmarshaler, err := opts.NewUnmarshaler(&ocf)
+				assert.NilError(t, err)
+				assert.Assert(t, unmarshaler.Scan())
+				var fromOCF examplev1.ExampleBytes
+				assert.NilError(t, unmarshaler.Unmarshal(&fromOCF))
+				assert.DeepEqual(t, tt.msg, &fromOCF, protocmp.Transform())
+			})
+		}
+	})
+
+	t.Run("size mismatch", func(t *testing.T) {
+		msg := &examplev1.ExampleBytes{Bytes: []byte{1, 2, 3}}
+		codec, err := NewCodec[*examplev1.ExampleBytes](opts)
+		assert.NilError(t, err)
+		_, err = codec.Marshal(msg)
+		assert.ErrorContains(t, err, "expected 4 bytes of fixed, got 3")
+		_, err = opts.MarshalAvroJSON(msg)
+		assert.ErrorContains(t, err, "expected 4 bytes of fixed, got 3")
+		avroJSON := []byte(`{"einride.avro.example.v1.ExampleBytes":{"bytes":` +
+			`{"einride.avro.example.v1.ExampleBytes.bytes":"\u0001\u0002\u0003"}}}`)
+		err = opts.UnmarshalAvroJSON(avroJSON, &examplev1.ExampleBytes{})
+		assert.ErrorContains(t, err, "expected 4 bytes of fixed, got 3")
+	})
+}
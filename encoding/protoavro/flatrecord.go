@@ -0,0 +1,124 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// flattensField returns true if the fields of the message of the field are flattened into the root record, for
+// fields of the root record, and of messages flattened into it, nested fewer than FlattenDepth levels below the
+// root record, see SchemaOptions.FlattenDepth.
+func (o SchemaOptions) flattensField(field protoreflect.FieldDescriptor, recursiveIndex int, prefix string) bool {
+	if recursiveIndex >= o.FlattenDepth || (recursiveIndex > 0 && prefix == "") {
+		return false
+	}
+	if field.Message() == nil || field.IsList() || field.IsMap() {
+		return false
+	}
+	if o.encodesJSONString(field) || o.encryptsField(field) {
+		return false
+	}
+	return !o.isWKT(field.Message().FullName())
+}
+
+// flatFieldName returns the name of the field in the root record, which is the path of the names of the
+// fields from the root record joined by FlattenSeparator for fields of flattened messages.
+func (o SchemaOptions) flatFieldName(prefix string, field protoreflect.FieldDescriptor) string {
+	if prefix == "" {
+		return o.fieldName(field)
+	}
+	separator := o.FlattenSeparator
+	if separator == "" {
+		separator = "_"
+	}
+	return prefix + separator + o.fieldName(field)
+}
+
+// checkFlattenedTransforms returns an error if the flattened field, or the fields of its message, are
+// transformed, since field transforms apply to the records of messages.
+func (o SchemaOptions) checkFlattenedTransforms(field protoreflect.FieldDescriptor) error {
+	for _, transform := range o.fieldTransforms(field.ContainingMessage()) {
+		if transform.Field == string(field.Name()) {
+			return fmt.Errorf("field transform %s.%s: field is flattened", transform.Message, transform.Field)
+		}
+	}
+	if len(o.fieldTransforms(field.Message())) > 0 {
+		return fmt.Errorf("field transforms are not supported for flattened message %s", field.Message().FullName())
+	}
+	return nil
+}
+
+// checkFlatFieldNames returns an error if fields of the root record share a name, such as a field of a
+// flattened message and a field named like its path.
+func checkFlatFieldNames(fields []avro.Field) error {
+	names := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		if _, ok := names[field.Name]; ok {
+			return fmt.Errorf("flattened field %s collides with another field of the record", field.Name)
+		}
+		names[field.Name] = struct{}{}
+	}
+	return nil
+}
+
+// unflattenRecord returns the root record data with the fields of flattened messages moved back into records
+// of their messages, so that the data decodes like the nested records.
+func (o SchemaOptions) unflattenRecord(data interface{}, desc protoreflect.MessageDescriptor) interface{} {
+	if o.FlattenDepth <= 0 || o.isWKT(desc.FullName()) {
+		return data
+	}
+	record, ok := data.(map[string]interface{})
+	if !ok {
+		return data
+	}
+	if wrapped, ok := record[o.avroName(desc)].(map[string]interface{}); ok && len(record) == 1 {
+		return o.unionValue(o.avroName(desc), o.unflattenRecord(wrapped, desc))
+	}
+	unflattened := make(map[string]interface{}, len(record))
+	for name, value := range record {
+		unflattened[name] = value
+	}
+	o.unflattenFields(unflattened, unflattened, desc, 0, "")
+	return unflattened
+}
+
+// unflattenFields moves the values of the fields of the message, flattened into the root record with the
+// prefix, into the record of the message, and returns true if any of the values are not null. Messages of
+// which all flattened fields are null decode as not set.
+func (o SchemaOptions) unflattenFields(
+	root map[string]interface{},
+	record map[string]interface{},
+	desc protoreflect.MessageDescriptor,
+	recursiveIndex int,
+	prefix string,
+) bool {
+	var set bool
+	for i := 0; i < desc.Fields().Len(); i++ {
+		field := desc.Fields().Get(i)
+		name := o.flatFieldName(prefix, field)
+		if o.flattensField(field, recursiveIndex, prefix) {
+			nested := make(map[string]interface{})
+			if o.unflattenFields(root, nested, field.Message(), recursiveIndex+1, name) {
+				record[o.fieldName(field)] = o.unionValue(o.recordName(field.Message(), recursiveIndex+1), nested)
+				set = true
+			} else {
+				record[o.fieldName(field)] = nil
+			}
+			continue
+		}
+		value, ok := root[name]
+		if !ok {
+			continue
+		}
+		if prefix != "" {
+			delete(root, name)
+			record[o.fieldName(field)] = value
+		}
+		if value != nil {
+			set = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,196 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestFlattenDepth(t *testing.T) {
+	desc := newFlatOrderDescriptor(t)
+	customerDesc := desc.Fields().ByName("customer").Message()
+	addressDesc := desc.Fields().ByName("shipping_address").Message()
+	address := func(city, zip string) *dynamicpb.Message {
+		msg := dynamicpb.NewMessage(addressDesc)
+		msg.Set(addressDesc.Fields().ByName("city"), protoreflect.ValueOfString(city))
+		msg.Set(addressDesc.Fields().ByName("zip"), protoreflect.ValueOfString(zip))
+		return msg
+	}
+	customer := dynamicpb.NewMessage(customerDesc)
+	customer.Set(customerDesc.Fields().ByName("name"), protoreflect.ValueOfString("Ada"))
+	customer.Set(customerDesc.Fields().ByName("address"), protoreflect.ValueOfMessage(address("London", "N1")))
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("id"), protoreflect.ValueOfString("orders/1"))
+	msg.Set(desc.Fields().ByName("shipping_address"), protoreflect.ValueOfMessage(address("Paris", "75001")))
+	msg.Set(desc.Fields().ByName("customer"), protoreflect.ValueOfMessage(customer))
+	other := msg.Mutable(desc.Fields().ByName("other_addresses")).List()
+	other.Append(protoreflect.ValueOfMessage(address("Berlin", "10115")))
+
+	fieldNames := func(t *testing.T, opts SchemaOptions) []string {
+		t.Helper()
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		record := recordOf(t, schema)
+		names := make([]string, 0, len(record.Fields))
+		for _, field := range record.Fields {
+			names = append(names, field.Name)
+		}
+		return names
+	}
+
+	t.Run("schema", func(t *testing.T) {
+		assert.DeepEqual(t, []string{
+			"id",
+			"shipping_address_city",
+			"shipping_address_zip",
+			"customer_name",
+			"customer_address",
+			"other_addresses",
+		}, fieldNames(t, SchemaOptions{FlattenDepth: 1}))
+		assert.DeepEqual(t, []string{
+			"id",
+			"shipping_address__city",
+			"shipping_address__zip",
+			"customer__name",
+			"customer__address__city",
+			"customer__address__zip",
+			"other_addresses",
+		}, fieldNames(t, SchemaOptions{FlattenDepth: 2, FlattenSeparator: "__"}))
+		// messages that are flattened are defined where they are not flattened
+		schema, err := SchemaOptions{FlattenDepth: 1}.InferSchema(desc)
+		assert.NilError(t, err)
+		record := recordOf(t, schema)
+		assert.DeepEqual(t, avro.Nullable(avro.String()), record.Fields[1].Type)
+		assert.Equal(t, "Address", recordOf(t, record.Fields[4].Type).Name)
+		items := record.Fields[5].Type.(avro.Union)[1].(avro.Array).Items
+		assert.DeepEqual(t, avro.Nullable(avro.Reference("example.v1.Address")), items)
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		opts := SchemaOptions{FlattenDepth: 2}
+		data, err := opts.Encode(msg)
+		assert.NilError(t, err)
+		record := data.(map[string]interface{})["example.v1.Order"].(map[string]interface{})
+		assert.DeepEqual(t, map[string]interface{}{"string": "Paris"}, record["shipping_address_city"])
+		assert.DeepEqual(t, map[string]interface{}{"string": "N1"}, record["customer_address_zip"])
+		// fields of messages that are not set are null
+		data, err = opts.Encode(dynamicpb.NewMessage(desc))
+		assert.NilError(t, err)
+		record = data.(map[string]interface{})["example.v1.Order"].(map[string]interface{})
+		assert.Equal(t, nil, record["shipping_address_city"])
+		assert.Equal(t, nil, record["customer_address_city"])
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, opts.Decode(data, decoded))
+		assert.Assert(t, !decoded.Has(desc.Fields().ByName("shipping_address")))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, opts := range []SchemaOptions{
+			{FlattenDepth: 1},
+			{FlattenDepth: 2, FlattenSeparator: "__"},
+			{FlattenDepth: 2, OmitRootElement: true},
+			{FlattenDepth: 3, UseJSONNames: true},
+		} {
+			assertRecursionRoundTrip(t, opts, msg, msg, false)
+			var b bytes.Buffer
+			marshaler, err := opts.NewMarshaler(desc, &b)
+			assert.NilError(t, err)
+			assert.NilError(t, marshaler.Marshal(msg))
+			unmarshaler, err := opts.NewUnmarshaler(&b)
+			assert.NilError(t, err)
+			assert.Assert(t, unmarshaler.Scan())
+			decoded := dynamicpb.NewMessage(desc)
+			assert.NilError(t, unmarshaler.Unmarshal(decoded))
+			assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+			data, err := opts.MarshalAvroJSON(msg)
+			assert.NilError(t, err)
+			decoded = dynamicpb.NewMessage(desc)
+			assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+			assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := SchemaOptions{
+			FlattenDepth: 1,
+			FieldNameFunc: func(field protoreflect.FieldDescriptor, name string) string {
+				if name == "id" {
+					return "customer_name"
+				}
+				return name
+			},
+		}.InferSchema(desc)
+		assert.ErrorContains(t, err, "flattened field customer_name collides with another field of the record")
+		_, err = SchemaOptions{FlattenDepth: 1}.InferSchema((&examplev1.ExampleRecursive{}).ProtoReflect().Descriptor())
+		assert.ErrorContains(t, err, "flattened records are not supported for recursive message")
+		_, err = SchemaOptions{
+			FlattenDepth:    1,
+			FieldTransforms: []FieldTransform{{Message: "example.v1.Address", Field: "zip", Redact: true}},
+		}.InferSchema(desc)
+		assert.ErrorContains(t, err, "field transforms are not supported for flattened message example.v1.Address")
+	})
+}
+
+// newFlatOrderDescriptor returns the descriptor of a message Order, with fields of messages Customer and Address
+// nested in two levels.
+func newFlatOrderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/flat_order.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("id"), Number: proto.Int32(1), Label: optional, Type: str},
+					{
+						Name: proto.String("shipping_address"), Number: proto.Int32(2), Label: optional, Type: message,
+						TypeName: proto.String(".example.v1.Address"),
+					},
+					{
+						Name: proto.String("customer"), Number: proto.Int32(3), Label: optional, Type: message,
+						TypeName: proto.String(".example.v1.Customer"),
+					},
+					{
+						Name: proto.String("other_addresses"), Number: proto.Int32(4), Label: repeated, Type: message,
+						TypeName: proto.String(".example.v1.Address"),
+					},
+				},
+			},
+			{
+				Name: proto.String("Customer"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1), Label: optional, Type: str},
+					{
+						Name: proto.String("address"), Number: proto.Int32(2), Label: optional, Type: message,
+						TypeName: proto.String(".example.v1.Address"),
+					},
+				},
+			},
+			{
+				Name: proto.String("Address"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("city"), Number: proto.Int32(1), Label: optional, Type: str},
+					{Name: proto.String("zip"), Number: proto.Int32(2), Label: optional, Type: str},
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	assert.NilError(t, err)
+	return file.Messages().ByName("Order")
+}
@@ -0,0 +1,71 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// flattens returns true if the message is mapped to the type of its single field, see
+// SchemaOptions.FlattenMessages.
+func (o SchemaOptions) flattens(name protoreflect.FullName) bool {
+	for _, flattened := range o.FlattenMessages {
+		if flattened == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenedField returns the single field of a message that is flattened, which must be a singular field of a
+// scalar kind.
+func (o SchemaOptions) flattenedField(desc protoreflect.MessageDescriptor) (protoreflect.FieldDescriptor, error) {
+	if n := desc.Fields().Len(); n != 1 {
+		return nil, fmt.Errorf("flatten message %s: message has %d fields", desc.FullName(), n)
+	}
+	field := desc.Fields().Get(0)
+	switch {
+	case field.IsList() || field.IsMap():
+		return nil, fmt.Errorf("flatten message %s: field %s is repeated", desc.FullName(), field.Name())
+	case field.Kind() == protoreflect.MessageKind, field.Kind() == protoreflect.GroupKind,
+		field.Kind() == protoreflect.EnumKind:
+		return nil, fmt.Errorf("flatten message %s: field %s is of kind %s", desc.FullName(), field.Name(), field.Kind())
+	case o.fixedSize(field) > 0:
+		// the fixed type of the field would be defined wherever the message is used
+		return nil, fmt.Errorf("flatten message %s: field %s is fixed", desc.FullName(), field.Name())
+	}
+	return field, nil
+}
+
+func (o SchemaOptions) schemaFlattened(desc protoreflect.MessageDescriptor) (avro.Schema, error) {
+	field, err := o.flattenedField(desc)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := o.newSchemaInferrer().inferFieldKind(field, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	return avro.Nullable(schema), nil
+}
+
+func (o SchemaOptions) encodeFlattened(message protoreflect.Message) (map[string]interface{}, error) {
+	field, err := o.flattenedField(message.Descriptor())
+	if err != nil {
+		return nil, err
+	}
+	value, err := o.fieldKindJSON(field, message.Get(field), 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	return value.(map[string]interface{}), nil
+}
+
+func (o SchemaOptions) decodeFlattened(data map[string]interface{}, msg protoreflect.Message) error {
+	field, err := o.flattenedField(msg.Descriptor())
+	if err != nil {
+		return err
+	}
+	return o.decodeField(data, msg, field, nil)
+}
@@ -0,0 +1,151 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestFlattenMessages(t *testing.T) {
+	desc := newFlattenDescriptor(t)
+	orderIDDesc := desc.Fields().ByName("id").Message()
+	orderID := func(value string) *dynamicpb.Message {
+		msg := dynamicpb.NewMessage(orderIDDesc)
+		msg.Set(orderIDDesc.Fields().ByName("value"), protoreflect.ValueOfString(value))
+		return msg
+	}
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("id"), protoreflect.ValueOfMessage(orderID("orders/1")))
+	related := msg.Mutable(desc.Fields().ByName("related")).List()
+	related.Append(protoreflect.ValueOfMessage(orderID("orders/2")))
+	// messages set to an empty message are not null
+	related.Append(protoreflect.ValueOfMessage(orderID("")))
+	opts := SchemaOptions{FlattenMessages: []protoreflect.FullName{"example.v1.OrderId"}}
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		record := recordOf(t, schema)
+		assert.DeepEqual(t, avro.Nullable(avro.String()), record.Fields[0].Type)
+		assert.DeepEqual(t, avro.Nullable(avro.Array{
+			Type:  avro.ArrayType,
+			Items: avro.Nullable(avro.String()),
+		}), record.Fields[1].Type)
+		// messages that are not listed are not flattened
+		assert.Equal(t, "Note", recordOf(t, record.Fields[2].Type).Name)
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		data, err := opts.Encode(msg)
+		assert.NilError(t, err)
+		record := data.(map[string]interface{})["example.v1.Order"].(map[string]interface{})
+		assert.DeepEqual(t, map[string]interface{}{"string": "orders/1"}, record["id"])
+		assert.DeepEqual(t, map[string]interface{}{"array": []interface{}{
+			map[string]interface{}{"string": "orders/2"},
+			map[string]interface{}{"string": ""},
+		}}, record["related"])
+		empty, err := opts.Encode(dynamicpb.NewMessage(desc))
+		assert.NilError(t, err)
+		assert.Equal(t, nil, empty.(map[string]interface{})["example.v1.Order"].(map[string]interface{})["id"])
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		assertRecursionRoundTrip(t, opts, msg, msg, true)
+		opts := opts
+		opts.NonNullListItems = true
+		assertRecursionRoundTrip(t, opts, msg, msg, true)
+	})
+
+	t.Run("object container file", func(t *testing.T) {
+		var b bytes.Buffer
+		marshaler, err := opts.NewMarshaler(desc, &b)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(msg))
+		unmarshaler, err := opts.NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		assert.Assert(t, unmarshaler.Scan())
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, unmarshaler.Unmarshal(decoded))
+		assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+	})
+
+	t.Run("lenient unions", func(t *testing.T) {
+		opts := opts
+		opts.LenientUnions = true
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, opts.Decode(map[string]interface{}{"id": "orders/1"}, decoded))
+		assert.Equal(t, "orders/1", decoded.Get(desc.Fields().ByName("id")).Message().Get(
+			orderIDDesc.Fields().ByName("value"),
+		).String())
+	})
+
+	t.Run("not flattenable", func(t *testing.T) {
+		opts := SchemaOptions{FlattenMessages: []protoreflect.FullName{"example.v1.Note"}}
+		_, err := opts.InferSchema(desc)
+		assert.ErrorContains(t, err, "flatten message example.v1.Note: message has 2 fields")
+		_, err = opts.Encode(msg)
+		assert.NilError(t, err)
+		note := dynamicpb.NewMessage(desc)
+		note.Mutable(desc.Fields().ByName("note"))
+		_, err = opts.Encode(note)
+		assert.ErrorContains(t, err, "flatten message example.v1.Note: message has 2 fields")
+	})
+}
+
+// newFlattenDescriptor returns the descriptor of a message Order, with fields of a single-field message OrderId,
+// and a field of a message Note with two fields.
+func newFlattenDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/order.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name: proto.String("id"), Number: proto.Int32(1), Label: optional, Type: message,
+						TypeName: proto.String(".example.v1.OrderId"),
+					},
+					{
+						Name: proto.String("related"), Number: proto.Int32(2), Label: repeated, Type: message,
+						TypeName: proto.String(".example.v1.OrderId"),
+					},
+					{
+						Name: proto.String("note"), Number: proto.Int32(3), Label: optional, Type: message,
+						TypeName: proto.String(".example.v1.Note"),
+					},
+				},
+			},
+			{
+				Name: proto.String("OrderId"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("value"), Number: proto.Int32(1), Label: optional, Type: str},
+				},
+			},
+			{
+				Name: proto.String("Note"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("author"), Number: proto.Int32(1), Label: optional, Type: str},
+					{Name: proto.String("text"), Number: proto.Int32(2), Label: optional, Type: str},
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	assert.NilError(t, err)
+	return file.Messages().ByName("Order")
+}
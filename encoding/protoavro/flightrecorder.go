@@ -0,0 +1,153 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// FlightRecorderOptions configures a FlightRecorder.
+type FlightRecorderOptions struct {
+	// Every samples every Nth marshaled record. Defaults to 1, which samples every record.
+	Every int
+	// Capacity is the number of most recent samples kept in memory. Defaults to 100.
+	Capacity int
+	// Writer receives every sample as a line of JSON, in addition to the samples kept in memory, for example
+	// a file. Nil keeps samples in memory only.
+	Writer io.Writer
+	// Disabled creates the recorder disabled, until it is enabled with SetEnabled.
+	Disabled bool
+}
+
+// FlightSample is a record sampled by a FlightRecorder.
+type FlightSample struct {
+	// Time is when the record was marshaled.
+	Time time.Time `json:"time"`
+	// Schema is the schema the record was written with.
+	Schema json.RawMessage `json:"schema"`
+	// Datum is the record in the JSON encoding of the Avro specification.
+	Datum json.RawMessage `json:"datum,omitempty"`
+	// Message is the source protobuf message in the protobuf text format.
+	Message string `json:"message"`
+	// Error is the error of encoding the datum as Avro JSON, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// FlightRecorder samples the records written by marshalers, together with their schema and source message,
+// to inspect exactly what was written when consumers report bad data. The most recent samples are kept in a
+// ring buffer, and optionally written to a writer. A recorder can be enabled and disabled at runtime, and
+// costs a single atomic load per write when disabled. A FlightRecorder is safe for concurrent use.
+type FlightRecorder struct {
+	enabled atomic.Bool
+	every   atomic.Int64
+	count   atomic.Uint64
+	// mu guards the samples, the writer and the error.
+	mu      sync.Mutex
+	samples []FlightSample
+	next    int
+	full    bool
+	writer  io.Writer
+	err     error
+}
+
+// NewFlightRecorder returns a new flight recorder.
+func NewFlightRecorder(opts FlightRecorderOptions) *FlightRecorder {
+	if opts.Every <= 0 {
+		opts.Every = 1
+	}
+	if opts.Capacity <= 0 {
+		opts.Capacity = 100
+	}
+	r := &FlightRecorder{samples: make([]FlightSample, opts.Capacity), writer: opts.Writer}
+	r.enabled.Store(!opts.Disabled)
+	r.every.Store(int64(opts.Every))
+	return r
+}
+
+// SetEnabled enables or disables sampling.
+func (r *FlightRecorder) SetEnabled(enabled bool) {
+	r.enabled.Store(enabled)
+}
+
+// Enabled returns true if sampling is enabled.
+func (r *FlightRecorder) Enabled() bool {
+	return r.enabled.Load()
+}
+
+// SetEvery samples every Nth marshaled record from now on. Values less than 1 sample every record.
+func (r *FlightRecorder) SetEvery(n int) {
+	if n <= 0 {
+		n = 1
+	}
+	r.every.Store(int64(n))
+}
+
+// Samples returns the samples kept in memory, from oldest to most recent.
+func (r *FlightRecorder) Samples() []FlightSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]FlightSample(nil), r.samples[:r.next]...)
+	}
+	samples := make([]FlightSample, 0, len(r.samples))
+	samples = append(samples, r.samples[r.next:]...)
+	return append(samples, r.samples[:r.next]...)
+}
+
+// Err returns the first error of writing samples to the writer.
+func (r *FlightRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// record samples the records of messages written with the codec, where data are the native values
+// of the messages.
+func (r *FlightRecorder) record(codec *goavro.Codec, messages []proto.Message, data []interface{}) {
+	if r == nil || !r.enabled.Load() {
+		return
+	}
+	every := uint64(r.every.Load())
+	for i, datum := range data {
+		if r.count.Add(1)%every != 0 {
+			continue
+		}
+		sample := FlightSample{Time: time.Now(), Schema: json.RawMessage(codec.Schema())}
+		if i < len(messages) {
+			sample.Message = prototext.Format(messages[i])
+		}
+		if datum, err := codec.TextualFromNative(nil, datum); err != nil {
+			sample.Error = err.Error()
+		} else {
+			sample.Datum = datum
+		}
+		r.add(sample)
+	}
+}
+
+func (r *FlightRecorder) add(sample FlightSample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples[r.next] = sample
+	if r.next++; r.next == len(r.samples) {
+		r.next, r.full = 0, true
+	}
+	if r.writer == nil || r.err != nil {
+		return
+	}
+	line, err := json.Marshal(sample)
+	if err != nil {
+		r.err = fmt.Errorf("flight recorder: json marshal sample: %w", err)
+		return
+	}
+	if _, err := r.writer.Write(append(line, '\n')); err != nil {
+		r.err = fmt.Errorf("flight recorder: write: %w", err)
+	}
+}
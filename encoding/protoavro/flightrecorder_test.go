@@ -0,0 +1,74 @@
+package protoavro_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/v3/assert"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	var samples bytes.Buffer
+	recorder := protoavro.NewFlightRecorder(protoavro.FlightRecorderOptions{
+		Every:    3,
+		Capacity: 2,
+		Writer:   &samples,
+	})
+	var b bytes.Buffer
+	opts := protoavro.SchemaOptions{FlightRecorder: recorder}
+	marshaler, err := opts.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	// every third record is sampled, and the two most recent samples are kept
+	marshalBooks(t, marshaler, testBooks(0, 10))
+	got := recorder.Samples()
+	assert.Equal(t, 2, len(got))
+	var book library.Book
+	assert.NilError(t, prototext.Unmarshal([]byte(got[0].Message), &book))
+	assert.Equal(t, "shelves/1/books/5", book.GetName())
+	assert.NilError(t, prototext.Unmarshal([]byte(got[1].Message), &book))
+	assert.Equal(t, "shelves/1/books/8", book.GetName())
+	var datum map[string]map[string]map[string]interface{}
+	assert.NilError(t, json.Unmarshal(got[1].Datum, &datum))
+	assert.DeepEqual(t, map[string]interface{}{"string": "shelves/1/books/8"}, datum["google.example.library.v1.Book"]["name"])
+	schema, err := opts.NewGoavroCodec((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.Equal(t, schema.Schema(), string(got[1].Schema))
+	// all samples are written to the writer
+	var written []protoavro.FlightSample
+	scanner := bufio.NewScanner(&samples)
+	for scanner.Scan() {
+		var sample protoavro.FlightSample
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &sample))
+		written = append(written, sample)
+	}
+	assert.Equal(t, 3, len(written))
+	assert.Equal(t, got[1].Message, written[2].Message)
+	assert.NilError(t, recorder.Err())
+}
+
+func TestFlightRecorder_SetEnabled(t *testing.T) {
+	recorder := protoavro.NewFlightRecorder(protoavro.FlightRecorderOptions{Disabled: true})
+	var b bytes.Buffer
+	opts := protoavro.SchemaOptions{FlightRecorder: recorder}
+	marshaler, err := opts.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	marshalBooks(t, marshaler, testBooks(0, 10))
+	assert.Equal(t, 0, len(recorder.Samples()))
+	recorder.SetEnabled(true)
+	assert.Assert(t, recorder.Enabled())
+	messages := []proto.Message{testBooks(10, 1)[0], testBooks(11, 1)[0]}
+	assert.NilError(t, marshaler.MarshalBatch(messages))
+	assert.Equal(t, 2, len(recorder.Samples()))
+	recorder.SetEvery(10)
+	marshalBooks(t, marshaler, testBooks(12, 10))
+	assert.Equal(t, 3, len(recorder.Samples()))
+	recorder.SetEnabled(false)
+	marshalBooks(t, marshaler, testBooks(22, 10))
+	assert.Equal(t, 3, len(recorder.Samples()))
+}
@@ -0,0 +1,250 @@
+package protoavro
+
+import (
+	"fmt"
+	"math/big"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/internal/wkt"
+	"google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// moneyPrecision is the precision of the decimal amount of google.type.Money.
+	moneyPrecision = 38
+	// moneyScale is the scale of the decimal amount of google.type.Money, which has nano precision.
+	moneyScale = 9
+)
+
+var latLngDocs = map[protoreflect.Name]string{
+	"latitude":  "The latitude in degrees. It must be in the range [-90.0, +90.0].",
+	"longitude": "The longitude in degrees. It must be in the range [-180.0, +180.0].",
+}
+
+func isGoogleType(name protoreflect.FullName) bool {
+	switch name {
+	case wkt.Money, wkt.LatLng, wkt.PostalAddress:
+		return true
+	}
+	return false
+}
+
+func schemaGoogleType(message protoreflect.MessageDescriptor) (avro.Schema, error) {
+	switch message.FullName() {
+	case wkt.Money:
+		return schemaMoney(), nil
+	case wkt.LatLng:
+		return schemaPlainRecord(message, latLngDocs)
+	case wkt.PostalAddress:
+		return schemaPlainRecord(message, nil)
+	}
+	return nil, fmt.Errorf("unknown google type %s", message.FullName())
+}
+
+func (o SchemaOptions) encodeGoogleType(message protoreflect.Message) (map[string]interface{}, error) {
+	desc := message.Descriptor()
+	switch desc.FullName() {
+	case wkt.Money:
+		return o.encodeMoney(message), nil
+	case wkt.LatLng, wkt.PostalAddress:
+		return o.encodePlainRecord(message)
+	}
+	return nil, fmt.Errorf("unknown google type %s", desc.FullName())
+}
+
+func decodeGoogleType(data map[string]interface{}, msg protoreflect.Message) error {
+	desc := msg.Descriptor()
+	// unwrap union
+	if record, ok := data[string(desc.FullName())].(map[string]interface{}); ok && len(data) == 1 {
+		data = record
+	}
+	switch desc.FullName() {
+	case wkt.Money:
+		value, err := decodeMoney(data)
+		if err != nil {
+			return err
+		}
+		return mergeMessage(msg, value)
+	case wkt.LatLng, wkt.PostalAddress:
+		return decodePlainRecord(data, msg)
+	}
+	return fmt.Errorf("unknown google type %s", desc.FullName())
+}
+
+func schemaMoney() avro.Schema {
+	return avro.Nullable(avro.Record{
+		Type:      avro.RecordType,
+		Name:      "Money",
+		Namespace: "google.type",
+		Doc:       "Represents an amount of money with its currency type.",
+		Fields: []avro.Field{
+			{
+				Name: "currency_code",
+				Doc:  "The three-letter currency code defined in ISO 4217.",
+				Type: avro.String(),
+			},
+			{
+				Name: "amount",
+				Doc:  "The decimal amount of money.",
+				Type: avro.Decimal(moneyPrecision, moneyScale),
+			},
+		},
+	})
+}
+
+// encodeMoney encodes a google.type.Money through protoreflect, so that dynamic messages are encoded alike.
+func (o SchemaOptions) encodeMoney(m protoreflect.Message) map[string]interface{} {
+	fields := m.Descriptor().Fields()
+	amount := new(big.Rat).SetInt64(m.Get(fields.ByName("units")).Int())
+	amount.Add(amount, big.NewRat(m.Get(fields.ByName("nanos")).Int(), 1e9))
+	return o.unionValue(wkt.Money, map[string]interface{}{
+		"currency_code": m.Get(fields.ByName("currency_code")).String(),
+		"amount":        amount,
+	})
+}
+
+func decodeMoney(v map[string]interface{}) (*money.Money, error) {
+	currencyCode, err := decodeStringLike(v["currency_code"], "string")
+	if err != nil {
+		return nil, fmt.Errorf("google.type.Money: currency_code: %w", err)
+	}
+	amount, ok := v["amount"].(*big.Rat)
+	if !ok {
+		return nil, fmt.Errorf("google.type.Money: amount: expected *big.Rat, got %T", v["amount"])
+	}
+	nanos := new(big.Rat).Mul(amount, big.NewRat(1e9, 1))
+	if !nanos.IsInt() {
+		return nil, fmt.Errorf("google.type.Money: amount: %s exceeds nano precision", amount.FloatString(moneyScale+1))
+	}
+	units, remainder := new(big.Int).QuoRem(nanos.Num(), big.NewInt(1e9), new(big.Int))
+	if !units.IsInt64() {
+		return nil, fmt.Errorf("google.type.Money: amount: %s overflows units", amount.FloatString(moneyScale))
+	}
+	return &money.Money{
+		CurrencyCode: currencyCode,
+		Units:        units.Int64(),
+		Nanos:        int32(remainder.Int64()),
+	}, nil
+}
+
+// schemaPlainRecord returns a schema for a message of scalar fields, where
+// fields are not nullable.
+func schemaPlainRecord(message protoreflect.MessageDescriptor, docs map[protoreflect.Name]string) (avro.Schema, error) {
+	record := avro.Record{
+		Type:      avro.RecordType,
+		Name:      string(message.Name()),
+		Namespace: namespace(message),
+		Fields:    make([]avro.Field, 0, message.Fields().Len()),
+	}
+	for i := 0; i < message.Fields().Len(); i++ {
+		field := message.Fields().Get(i)
+		var fieldType avro.Schema
+		switch field.Kind() {
+		case protoreflect.StringKind:
+			fieldType = avro.String()
+		case protoreflect.Int32Kind:
+			fieldType = avro.Integer()
+		case protoreflect.DoubleKind:
+			fieldType = avro.Double()
+		default:
+			return nil, fmt.Errorf("%s: unsupported field kind %s %s", message.FullName(), field.Name(), field.Kind())
+		}
+		if field.IsList() {
+			fieldType = avro.Array{Type: avro.ArrayType, Items: fieldType}
+		}
+		record.Fields = append(record.Fields, avro.Field{
+			Name: string(field.Name()),
+			Doc:  docs[field.Name()],
+			Type: fieldType,
+		})
+	}
+	return avro.Nullable(record), nil
+}
+
+func (o SchemaOptions) encodePlainRecord(message protoreflect.Message) (map[string]interface{}, error) {
+	desc := message.Descriptor()
+	record := make(map[string]interface{}, desc.Fields().Len())
+	for i := 0; i < desc.Fields().Len(); i++ {
+		field := desc.Fields().Get(i)
+		if field.IsList() {
+			list := message.Get(field).List()
+			values := make([]interface{}, 0, list.Len())
+			for j := 0; j < list.Len(); j++ {
+				values = append(values, plainValue(field, list.Get(j)))
+			}
+			record[string(field.Name())] = values
+			continue
+		}
+		record[string(field.Name())] = plainValue(field, message.Get(field))
+	}
+	return o.unionValue(string(desc.FullName()), record), nil
+}
+
+func plainValue(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch field.Kind() {
+	case protoreflect.Int32Kind:
+		return int32(value.Int())
+	case protoreflect.DoubleKind:
+		return value.Float()
+	default:
+		return value.String()
+	}
+}
+
+func decodePlainRecord(data map[string]interface{}, msg protoreflect.Message) error {
+	desc := msg.Descriptor()
+	for name, value := range data {
+		field := desc.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return fmt.Errorf("%s: unexpected field %s", desc.FullName(), name)
+		}
+		if field.IsList() {
+			values, err := decodeListLike(value, "array")
+			if err != nil {
+				return fmt.Errorf("%s: field %s: %w", desc.FullName(), name, err)
+			}
+			list := msg.Mutable(field).List()
+			for _, el := range values {
+				v, err := decodePlainValue(field, el)
+				if err != nil {
+					return fmt.Errorf("%s: field %s: %w", desc.FullName(), name, err)
+				}
+				list.Append(v)
+			}
+			continue
+		}
+		v, err := decodePlainValue(field, value)
+		if err != nil {
+			return fmt.Errorf("%s: field %s: %w", desc.FullName(), name, err)
+		}
+		msg.Set(field, v)
+	}
+	return nil
+}
+
+func decodePlainValue(field protoreflect.FieldDescriptor, data interface{}) (protoreflect.Value, error) {
+	switch field.Kind() {
+	case protoreflect.Int32Kind:
+		if i, ok := data.(int32); ok {
+			return protoreflect.ValueOfInt32(i), nil
+		}
+		i, err := decodeIntLike(data, "int")
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt32(int32(i)), nil
+	case protoreflect.DoubleKind:
+		f, ok := data.(float64)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected float64, got %T", data)
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	default:
+		s, err := decodeStringLike(data, "string")
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfString(s), nil
+	}
+}
@@ -0,0 +1,121 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/genproto/googleapis/type/latlng"
+	"google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/genproto/googleapis/type/postaladdress"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func Test_GoogleTypeMappings(t *testing.T) {
+	opts := SchemaOptions{GoogleTypeMappings: true}
+	for _, tt := range []proto.Message{
+		// money
+		&money.Money{CurrencyCode: "SEK", Units: 100, Nanos: 500_000_000},
+		&money.Money{CurrencyCode: "USD", Units: -1, Nanos: -750_000_000},
+		&money.Money{CurrencyCode: "EUR", Units: 0, Nanos: 1},
+		&money.Money{},
+
+		// lat lng
+		&latlng.LatLng{Latitude: 57.7, Longitude: 11.97},
+		&latlng.LatLng{},
+
+		// postal address
+		&postaladdress.PostalAddress{
+			Revision:     1,
+			RegionCode:   "SE",
+			PostalCode:   "411 06",
+			Locality:     "Göteborg",
+			AddressLines: []string{"Drottninggatan 1"},
+			Recipients:   []string{"Einride AB"},
+		},
+		&postaladdress.PostalAddress{},
+	} {
+		tt := tt
+		t.Run(string(tt.ProtoReflect().Descriptor().FullName()), func(t *testing.T) {
+			desc := tt.ProtoReflect().Descriptor()
+			assert.Assert(t, opts.isWKT(desc.FullName()))
+			schema, err := opts.schemaWKT(desc)
+			assert.NilError(t, err)
+
+			encoded, err := opts.encodeWKT(tt.ProtoReflect())
+			assert.NilError(t, err)
+
+			// assert that it matches schema
+			schemaBytes, err := json.Marshal(schema)
+			assert.NilError(t, err)
+			codec, err := goavro.NewCodec(string(schemaBytes))
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, encoded)
+			assert.NilError(t, err)
+			native, _, err := codec.NativeFromBinary(binary)
+			assert.NilError(t, err)
+
+			decoded := tt.ProtoReflect().New()
+			assert.NilError(t, opts.decodeWKT(native.(map[string]interface{}), decoded))
+			assert.DeepEqual(t, tt, decoded.Interface(), protocmp.Transform())
+		})
+	}
+}
+
+func Test_GoogleTypeMappings_Schema(t *testing.T) {
+	opts := SchemaOptions{GoogleTypeMappings: true}
+	schema, err := opts.schemaWKT((&money.Money{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	assert.Equal(t, record.Fields[1].Name, "amount")
+	assert.DeepEqual(t, record.Fields[1].Type, avro.Decimal(38, 9))
+
+	// without the option, google.type messages are regular records
+	assert.Assert(t, !SchemaOptions{}.isWKT((&money.Money{}).ProtoReflect().Descriptor().FullName()))
+}
+
+func Test_GoogleTypeMappings_MarshalUnmarshal(t *testing.T) {
+	opts := SchemaOptions{GoogleTypeMappings: true}
+	msg := &money.Money{CurrencyCode: "SEK", Units: 42, Nanos: 990_000_000}
+	var b bytes.Buffer
+	marshaler, err := opts.NewMarshaler(msg.ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(msg))
+	unmarshaler, err := opts.NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	assert.Assert(t, unmarshaler.Scan())
+	got := &money.Money{}
+	assert.NilError(t, unmarshaler.Unmarshal(got))
+	assert.DeepEqual(t, msg, got, protocmp.Transform())
+}
+
+func Test_GoogleTypeMappings_Dynamic(t *testing.T) {
+	opts := SchemaOptions{GoogleTypeMappings: true}
+	for _, msg := range []proto.Message{
+		&money.Money{CurrencyCode: "SEK", Units: 42, Nanos: 990_000_000},
+		&latlng.LatLng{Latitude: 57.7, Longitude: 11.97},
+	} {
+		msg := msg
+		t.Run(string(msg.ProtoReflect().Descriptor().FullName()), func(t *testing.T) {
+			data, err := proto.Marshal(msg)
+			assert.NilError(t, err)
+			dynamic := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+			assert.NilError(t, proto.Unmarshal(data, dynamic))
+			expected, err := opts.Encode(msg)
+			assert.NilError(t, err)
+			encoded, err := opts.Encode(dynamic)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, expected, encoded, cmp.Comparer(func(a, b *big.Rat) bool { return a.Cmp(b) == 0 }))
+			decoded := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+			assert.NilError(t, opts.Decode(encoded, decoded))
+			assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package hambaavro
+
+import (
+	"fmt"
+
+	hamba "github.com/hamba/avro/v2"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// api is the hamba/avro configuration of codecs.
+// The configuration has no registered types, so that decoded union values are not resolved to types
+// registered by other packages.
+var api = hamba.Config{}.Freeze()
+
+// Codec encodes and decodes protobuf messages of a type with hamba/avro.
+// A Codec is safe for concurrent use.
+type Codec struct {
+	opts   protoavro.SchemaOptions
+	desc   protoreflect.MessageDescriptor
+	schema hamba.Schema
+}
+
+// NewCodec returns a new codec for protobuf messages of the type of desc.
+func NewCodec(opts protoavro.SchemaOptions, desc protoreflect.MessageDescriptor) (*Codec, error) {
+	schema, err := InferSchema(opts, desc)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{opts: opts, desc: desc, schema: schema}, nil
+}
+
+// Schema returns the Avro schema of the codec, parsed by hamba/avro.
+func (c *Codec) Schema() hamba.Schema {
+	return c.schema
+}
+
+// Encode returns the message as a value of the native form of hamba/avro.
+// Records and maps are map[string]any, union values are map[string]any keyed by the name of their branch,
+// and fixed values are byte arrays.
+func (c *Codec) Encode(message proto.Message) (interface{}, error) {
+	if err := c.checkMessage(message); err != nil {
+		return nil, err
+	}
+	data, err := c.opts.Encode(message)
+	if err != nil {
+		return nil, err
+	}
+	return toHamba(c.schema, data)
+}
+
+// Decode decodes a value of the native form of hamba/avro, as decoded by hamba/avro to an interface{},
+// to the message.
+func (c *Codec) Decode(data interface{}, message proto.Message) error {
+	if err := c.checkMessage(message); err != nil {
+		return err
+	}
+	native, err := fromHamba(c.schema, data)
+	if err != nil {
+		return err
+	}
+	return c.opts.Decode(native, message)
+}
+
+// Marshal encodes the message in Avro binary format.
+func (c *Codec) Marshal(message proto.Message) ([]byte, error) {
+	data, err := c.Encode(message)
+	if err != nil {
+		return nil, fmt.Errorf("encode message: %w", err)
+	}
+	b, err := api.Marshal(c.schema, data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	return b, nil
+}
+
+// Unmarshal decodes the message from Avro binary format.
+func (c *Codec) Unmarshal(b []byte, message proto.Message) error {
+	var data interface{}
+	if err := api.Unmarshal(c.schema, b, &data); err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	if err := c.Decode(data, message); err != nil {
+		return fmt.Errorf("decode message: %w", err)
+	}
+	return nil
+}
+
+func (c *Codec) checkMessage(message proto.Message) error {
+	if name := message.ProtoReflect().Descriptor().FullName(); name != c.desc.FullName() {
+		return fmt.Errorf("unexpected message '%s', expected '%s'", name, c.desc.FullName())
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package hambaavro_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/hambaavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/timeofday"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestCodec(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts protoavro.SchemaOptions
+		msg  proto.Message
+	}{
+		{
+			name: "library.Book",
+			msg:  &library.Book{Name: "shelves/1/books/1", Title: "Harry Potter", Read: true},
+		},
+		{
+			name: "library.ListBooksResponse",
+			msg: &library.ListBooksResponse{
+				Books:         []*library.Book{{Title: "Harry Potter"}, {Title: "The Hobbit"}},
+				NextPageToken: "next",
+			},
+		},
+		{
+			name: "examplev1.ExampleEnum",
+			msg:  &examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE2},
+		},
+		{
+			name: "examplev1.ExampleBytes",
+			msg:  &examplev1.ExampleBytes{Bytes: []byte{0x00, 0xff}},
+		},
+		{
+			name: "examplev1.ExampleWrappers",
+			msg: &examplev1.ExampleWrappers{
+				StringValue: wrapperspb.String("foo"),
+				Int32Value:  wrapperspb.Int32(-1),
+				Int64Value:  wrapperspb.Int64(1 << 40),
+				FloatValue:  wrapperspb.Float(1.5),
+				DoubleValue: wrapperspb.Double(2.5),
+				BoolValue:   wrapperspb.Bool(true),
+				BytesValue:  wrapperspb.Bytes([]byte{0xff}),
+			},
+		},
+		{
+			name: "examplev1.ExampleList",
+			msg: &examplev1.ExampleList{
+				Int64List:  []int64{1, 2},
+				StringList: []string{"a", "b"},
+				EnumList:   []examplev1.ExampleList_Enum{examplev1.ExampleList_ENUM_VALUE1},
+				NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"c"}}},
+			},
+		},
+		{
+			name: "examplev1.ExampleMap",
+			msg: &examplev1.ExampleMap{
+				StringToString:     map[string]string{"a": "b"},
+				Int32ToString:      map[int32]string{1: "a"},
+				StringToFloatValue: map[string]*wrapperspb.FloatValue{"a": wrapperspb.Float(1)},
+			},
+		},
+		{
+			name: "examplev1.ExampleOneof",
+			msg: &examplev1.ExampleOneof{
+				OneofFields_1: &examplev1.ExampleOneof_OneofBool_1{OneofBool_1: true},
+			},
+		},
+		{
+			name: "examplev1.ExampleRecursive",
+			msg: &examplev1.ExampleRecursive{
+				Recursive: &examplev1.ExampleRecursive{Recursive: &examplev1.ExampleRecursive{}},
+			},
+		},
+		{
+			name: "examplev1.ExampleStruct",
+			msg: &examplev1.ExampleStruct{
+				Struct: &structpb.Struct{Fields: map[string]*structpb.Value{"a": structpb.NewBoolValue(true)}},
+			},
+		},
+		{
+			name: "examplev1.ExampleDate",
+			msg:  &examplev1.ExampleDate{Date: &date.Date{Year: 2021, Month: 6, Day: 27}},
+		},
+		{
+			name: "examplev1.ExampleTimeOfDay",
+			msg:  &examplev1.ExampleTimeOfDay{TimeOfDay: &timeofday.TimeOfDay{Hours: 19, Minutes: 42}},
+		},
+		{
+			name: "examplev1.ExampleTimestamp",
+			msg: &examplev1.ExampleTimestamp{
+				Timestamp: timestamppb.New(time.Date(2021, 6, 27, 1, 39, 24, 0, time.UTC)),
+			},
+		},
+		{
+			name: "timestamp millis",
+			opts: protoavro.SchemaOptions{TimestampPrecision: protoavro.TimestampMillis},
+			msg: &examplev1.ExampleTimestamp{
+				Timestamp: timestamppb.New(time.Date(2021, 6, 27, 1, 39, 24, 0, time.UTC)),
+			},
+		},
+		{
+			name: "examplev1.ExampleDuration",
+			msg:  &examplev1.ExampleDuration{Duration: durationpb.New(1500 * time.Millisecond)},
+		},
+		{
+			name: "duration fixed",
+			opts: protoavro.SchemaOptions{DurationEncoding: protoavro.DurationFixed},
+			msg:  &examplev1.ExampleDuration{Duration: durationpb.New(90 * time.Second)},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			desc := tt.msg.ProtoReflect().Descriptor()
+			codec, err := hambaavro.NewCodec(tt.opts, desc)
+			assert.NilError(t, err)
+			b, err := codec.Marshal(tt.msg)
+			assert.NilError(t, err)
+			decoded := tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, codec.Unmarshal(b, decoded))
+			assert.DeepEqual(t, tt.msg, decoded, protocmp.Transform())
+
+			// the binary encoding is compatible with goavro
+			goavroCodec := newGoavroCodec(t, tt.opts, tt.msg)
+			native, _, err := goavroCodec.NativeFromBinary(b)
+			assert.NilError(t, err)
+			decoded = tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, tt.opts.Decode(native, decoded))
+			assert.DeepEqual(t, tt.msg, decoded, protocmp.Transform())
+			native, err = tt.opts.Encode(tt.msg)
+			assert.NilError(t, err)
+			goavroBinary, err := goavroCodec.BinaryFromNative(nil, native)
+			assert.NilError(t, err)
+			decoded = tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, codec.Unmarshal(goavroBinary, decoded))
+			assert.DeepEqual(t, tt.msg, decoded, protocmp.Transform())
+		})
+	}
+}
+
+func TestCodec_UnexpectedMessage(t *testing.T) {
+	codec, err := hambaavro.NewCodec(protoavro.SchemaOptions{}, (&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	_, err = codec.Marshal(&library.Shelf{})
+	assert.ErrorContains(t, err, "unexpected message 'google.example.library.v1.Shelf'")
+	err = codec.Unmarshal([]byte{0x02}, &library.Shelf{})
+	assert.ErrorContains(t, err, "unexpected message 'google.example.library.v1.Shelf'")
+}
+
+func newGoavroCodec(t *testing.T, opts protoavro.SchemaOptions, msg proto.Message) *goavro.Codec {
+	t.Helper()
+	schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	return codec
+}
@@ -0,0 +1,3 @@
+// Package hambaavro provides interoperability between the protobuf mapping of package protoavro
+// and the Avro codecs of github.com/hamba/avro.
+package hambaavro
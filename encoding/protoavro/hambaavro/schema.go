@@ -0,0 +1,36 @@
+package hambaavro
+
+import (
+	"encoding/json"
+	"fmt"
+
+	hamba "github.com/hamba/avro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ParseSchema parses the Avro schema with the parser of hamba/avro.
+// Schemas are parsed with a cache of their own, so that named types of different schemas do not conflict.
+func ParseSchema(schema avro.Schema) (hamba.Schema, error) {
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	parsed, err := hamba.ParseBytesWithCache(schemaBytes, "", &hamba.SchemaCache{})
+	if err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return parsed, nil
+}
+
+// InferSchema returns the Avro schema of the protobuf message, parsed by hamba/avro.
+// The parser of hamba/avro is stricter than the parser of goavro, for example for the namespaces
+// of nested named types, so an error is returned for inferred schemas that hamba/avro rejects.
+func InferSchema(opts protoavro.SchemaOptions, desc protoreflect.MessageDescriptor) (hamba.Schema, error) {
+	schema, err := opts.InferSchema(desc)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSchema(schema)
+}
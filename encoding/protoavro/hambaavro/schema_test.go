@@ -0,0 +1,66 @@
+package hambaavro_test
+
+import (
+	"testing"
+
+	hamba "github.com/hamba/avro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/hambaavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/v3/assert"
+)
+
+func TestInferSchema(t *testing.T) {
+	msgs := []proto.Message{
+		&library.Book{},
+		&library.Shelf{},
+		&library.ListBooksResponse{},
+		&examplev1.ExampleAny{},
+		&examplev1.ExampleBytes{},
+		&examplev1.ExampleDate{},
+		&examplev1.ExampleDateTime{},
+		&examplev1.ExampleDuration{},
+		&examplev1.ExampleEnum{},
+		&examplev1.ExampleList{},
+		&examplev1.ExampleMap{},
+		&examplev1.ExampleOneof{},
+		&examplev1.ExampleRecursive{},
+		&examplev1.ExampleScalars{},
+		&examplev1.ExampleStruct{},
+		&examplev1.ExampleTimeOfDay{},
+		&examplev1.ExampleTimestamp{},
+		&examplev1.ExampleWrappers{},
+	}
+	for _, opts := range []protoavro.SchemaOptions{
+		{},
+		{OmitRootElement: true},
+		{GoogleTypeMappings: true},
+		{TimestampPrecision: protoavro.TimestampNanos},
+		{DurationEncoding: protoavro.DurationFixed},
+		{StripNamespaces: true, DisambiguateNames: true},
+	} {
+		for _, msg := range msgs {
+			desc := msg.ProtoReflect().Descriptor()
+			schema, err := hambaavro.InferSchema(opts, desc)
+			assert.NilError(t, err, "%s %+v", desc.FullName(), opts)
+			assert.Assert(t, schema != nil)
+		}
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	schema, err := hambaavro.ParseSchema(avro.Record{
+		Type: avro.RecordType,
+		Name: "Book",
+		Fields: []avro.Field{
+			{Name: "title", Type: avro.String()},
+		},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, schema.Type(), hamba.Record)
+	_, err = hambaavro.ParseSchema(avro.Record{Type: avro.RecordType, Name: "Book-1"})
+	assert.ErrorContains(t, err, "parse schema")
+}
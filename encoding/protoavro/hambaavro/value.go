@@ -0,0 +1,346 @@
+package hambaavro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"time"
+
+	hamba "github.com/hamba/avro/v2"
+)
+
+// resolvedTypes are the names of the types that hamba/avro resolves union values to, when every
+// type of a union is resolved. Values of other unions are decoded as map[string]any keyed by the
+// name of their branch.
+var resolvedTypes = map[string]struct{}{
+	"null":                  {},
+	"int":                   {},
+	"long":                  {},
+	"float":                 {},
+	"double":                {},
+	"string":                {},
+	"bytes":                 {},
+	"boolean":               {},
+	"int.date":              {},
+	"int.time-millis":       {},
+	"long.timestamp-millis": {},
+	"long.timestamp-micros": {},
+	"long.time-micros":      {},
+	"string.uuid":           {},
+}
+
+// toHamba converts a value in the native form of goavro to the native form of hamba/avro.
+func toHamba(schema hamba.Schema, data interface{}) (interface{}, error) {
+	switch s := resolve(schema).(type) {
+	case *hamba.RecordSchema:
+		record, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("record %s: expected map[string]interface{}, got %T", s.FullName(), data)
+		}
+		result := make(map[string]interface{}, len(s.Fields()))
+		for _, field := range s.Fields() {
+			value, err := toHamba(field.Type(), record[field.Name()])
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name(), err)
+			}
+			result[field.Name()] = value
+		}
+		return result, nil
+	case *hamba.UnionSchema:
+		if data == nil {
+			return nil, nil
+		}
+		name, value, err := unionValue(data)
+		if err != nil {
+			return nil, err
+		}
+		branch, _ := s.Types().Get(name)
+		if branch == nil {
+			return nil, fmt.Errorf("unknown union type %s", name)
+		}
+		converted, err := toHamba(branch, value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{name: converted}, nil
+	case *hamba.ArraySchema:
+		return convertArray(data, func(item interface{}) (interface{}, error) {
+			return toHamba(s.Items(), item)
+		})
+	case *hamba.MapSchema:
+		return convertMap(data, func(value interface{}) (interface{}, error) {
+			return toHamba(s.Values(), value)
+		})
+	case *hamba.FixedSchema:
+		b, ok := data.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("fixed %s: expected []byte, got %T", s.FullName(), data)
+		}
+		if len(b) != s.Size() {
+			return nil, fmt.Errorf("fixed %s: expected %d bytes, got %d", s.FullName(), s.Size(), len(b))
+		}
+		array := reflect.New(reflect.ArrayOf(s.Size(), reflect.TypeOf(byte(0)))).Elem()
+		reflect.Copy(array, reflect.ValueOf(b))
+		return array.Interface(), nil
+	case *hamba.PrimitiveSchema:
+		return convertPrimitive(s, data)
+	}
+	return data, nil
+}
+
+// fromHamba converts a value in the native form of hamba/avro, as decoded to an interface{},
+// to the native form of goavro.
+func fromHamba(schema hamba.Schema, data interface{}) (interface{}, error) {
+	switch s := resolve(schema).(type) {
+	case *hamba.RecordSchema:
+		record, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("record %s: expected map[string]interface{}, got %T", s.FullName(), data)
+		}
+		result := make(map[string]interface{}, len(s.Fields()))
+		for _, field := range s.Fields() {
+			value, err := fromHamba(field.Type(), record[field.Name()])
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name(), err)
+			}
+			result[field.Name()] = value
+		}
+		return result, nil
+	case *hamba.UnionSchema:
+		if data == nil {
+			return nil, nil
+		}
+		if !isResolved(s) {
+			name, value, err := unionValue(data)
+			if err != nil {
+				return nil, err
+			}
+			branch, _ := s.Types().Get(name)
+			if branch == nil {
+				return nil, fmt.Errorf("unknown union type %s", name)
+			}
+			converted, err := fromHamba(branch, value)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{name: converted}, nil
+		}
+		for _, branch := range s.Types() {
+			if !isValueOf(branch, data) {
+				continue
+			}
+			converted, err := fromHamba(branch, data)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{typeName(branch): converted}, nil
+		}
+		return nil, fmt.Errorf("union: unexpected value of type %T", data)
+	case *hamba.ArraySchema:
+		return convertArray(data, func(item interface{}) (interface{}, error) {
+			return fromHamba(s.Items(), item)
+		})
+	case *hamba.MapSchema:
+		return convertMap(data, func(value interface{}) (interface{}, error) {
+			return fromHamba(s.Values(), value)
+		})
+	case *hamba.FixedSchema:
+		if d, ok := data.(hamba.LogicalDuration); ok {
+			b := make([]byte, 12)
+			binary.LittleEndian.PutUint32(b[0:4], d.Months)
+			binary.LittleEndian.PutUint32(b[4:8], d.Days)
+			binary.LittleEndian.PutUint32(b[8:12], d.Milliseconds)
+			return b, nil
+		}
+		array := reflect.ValueOf(data)
+		if array.Kind() != reflect.Array || array.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, fmt.Errorf("fixed %s: expected byte array, got %T", s.FullName(), data)
+		}
+		b := make([]byte, array.Len())
+		reflect.Copy(reflect.ValueOf(b), array)
+		return b, nil
+	case *hamba.PrimitiveSchema:
+		return convertPrimitive(s, data)
+	}
+	return data, nil
+}
+
+// convertPrimitive converts integers and floats to the Go types of their Avro type.
+// Times of day are converted to time.Duration, which hamba/avro expects for the time logical types,
+// and other values of logical types are left as they are.
+func convertPrimitive(schema *hamba.PrimitiveSchema, data interface{}) (interface{}, error) {
+	switch typeName(schema) {
+	case "int.time-millis", "long.time-micros":
+		if d, ok := data.(time.Duration); ok {
+			return d, nil
+		}
+		i, ok := integer(data)
+		if !ok {
+			return nil, fmt.Errorf("%s: unexpected value of type %T", typeName(schema), data)
+		}
+		if schema.Type() == hamba.Int {
+			return time.Duration(i) * time.Millisecond, nil
+		}
+		return time.Duration(i) * time.Microsecond, nil
+	}
+	if schema.Logical() != nil {
+		return data, nil
+	}
+	switch schema.Type() {
+	case hamba.Int:
+		i, ok := integer(data)
+		if !ok {
+			return nil, fmt.Errorf("int: unexpected value of type %T", data)
+		}
+		return int32(i), nil
+	case hamba.Long:
+		i, ok := integer(data)
+		if !ok {
+			return nil, fmt.Errorf("long: unexpected value of type %T", data)
+		}
+		return i, nil
+	case hamba.Float:
+		switch f := data.(type) {
+		case float32:
+			return f, nil
+		case float64:
+			return float32(f), nil
+		}
+		return nil, fmt.Errorf("float: unexpected value of type %T", data)
+	}
+	return data, nil
+}
+
+// isResolved returns true if hamba/avro resolves the union values to Go types, rather than
+// to map[string]any keyed by the name of their branch.
+func isResolved(union *hamba.UnionSchema) bool {
+	for _, branch := range union.Types() {
+		if _, ok := resolvedTypes[typeName(branch)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isValueOf returns true if data is of the Go type of a resolved union value of schema.
+func isValueOf(schema hamba.Schema, data interface{}) bool {
+	switch typeName(schema) {
+	case "int":
+		switch data.(type) {
+		case int, int8, int16, int32:
+			return true
+		}
+	case "long":
+		switch data.(type) {
+		case int, int64:
+			return true
+		}
+	case "float":
+		_, ok := data.(float32)
+		return ok
+	case "double":
+		_, ok := data.(float64)
+		return ok
+	case "string", "string.uuid":
+		_, ok := data.(string)
+		return ok
+	case "bytes":
+		_, ok := data.([]byte)
+		return ok
+	case "boolean":
+		_, ok := data.(bool)
+		return ok
+	case "int.date", "long.timestamp-millis", "long.timestamp-micros":
+		_, ok := data.(time.Time)
+		return ok
+	case "int.time-millis", "long.time-micros":
+		_, ok := data.(time.Duration)
+		return ok
+	}
+	return false
+}
+
+// typeName returns the name of the schema in a union, which is the full name of named types, and the type
+// and logical type of other types.
+func typeName(schema hamba.Schema) string {
+	schema = resolve(schema)
+	if named, ok := schema.(hamba.NamedSchema); ok {
+		return named.FullName()
+	}
+	if primitive, ok := schema.(*hamba.PrimitiveSchema); ok && primitive.Logical() != nil {
+		return string(primitive.Type()) + "." + string(primitive.Logical().Type())
+	}
+	return string(schema.Type())
+}
+
+func resolve(schema hamba.Schema) hamba.Schema {
+	if ref, ok := schema.(*hamba.RefSchema); ok {
+		return ref.Schema()
+	}
+	return schema
+}
+
+func unionValue(data interface{}) (string, interface{}, error) {
+	union, ok := data.(map[string]interface{})
+	if !ok || len(union) != 1 {
+		return "", nil, fmt.Errorf("union: expected map[string]interface{} with a single key, got %T", data)
+	}
+	for name, value := range union {
+		return name, value, nil
+	}
+	return "", nil, nil
+}
+
+func integer(data interface{}) (int64, bool) {
+	switch i := data.(type) {
+	case int:
+		return int64(i), true
+	case int8:
+		return int64(i), true
+	case int16:
+		return int64(i), true
+	case int32:
+		return int64(i), true
+	case int64:
+		return i, true
+	}
+	return 0, false
+}
+
+func convertArray(data interface{}, convert func(interface{}) (interface{}, error)) (interface{}, error) {
+	items, ok := data.([]interface{})
+	if !ok {
+		if data == nil {
+			return []interface{}{}, nil
+		}
+		return nil, fmt.Errorf("array: expected []interface{}, got %T", data)
+	}
+	result := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		value, err := convert(item)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+func convertMap(data interface{}, convert func(interface{}) (interface{}, error)) (interface{}, error) {
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		if data == nil {
+			return map[string]interface{}{}, nil
+		}
+		return nil, fmt.Errorf("map: expected map[string]interface{}, got %T", data)
+	}
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		converted, err := convert(value)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key, err)
+		}
+		result[key] = converted
+	}
+	return result, nil
+}
@@ -0,0 +1,468 @@
+package protoavro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// HashMessage returns the hash of the Avro mapping of the message with the options, see SchemaOptions.HashMessage.
+// Unlike Marshal, HashMessage returns an error as well as the hash, since options such as FieldTransforms may
+// fail for a message.
+func HashMessage(message proto.Message, opts SchemaOptions) (uint64, error) {
+	return opts.HashMessage(message)
+}
+
+// HashMessage returns a 64-bit FNV-1a hash of the Avro mapping of the message, for deduplication and change
+// detection without encoding the message first. The fields of the message are hashed while they are walked,
+// without building the generic Avro value of the message, except for well-known types, messages with codecs,
+// and messages with field transforms or unknown fields, whose values are built to be hashed. Messages with the
+// same record have the same hash, so fields that are not in SchemaMask, or are redacted by FieldTransforms, do
+// not change the hash. Envelope fields, which describe rather than make up the message, are not hashed. Entries
+// of maps are hashed in the order of Deterministic, whatever the options.
+//
+// The hash is stable across processes and builds for the same options, but is not a cryptographic hash.
+func (o SchemaOptions) HashMessage(message proto.Message) (uint64, error) {
+	o = o.withNames(message.ProtoReflect().Descriptor())
+	o.Deterministic = true
+	h := messageHasher{opts: o, h: fnv.New64a()}
+	if err := h.message(message.ProtoReflect(), 0, newFieldMaskTree(o.SchemaMask), false); err != nil {
+		return 0, fmt.Errorf("hash message: %w", err)
+	}
+	h.flush()
+	return h.h.Sum64(), nil
+}
+
+// messageHasher hashes messages while walking their fields, like hashValue hashes the generic Avro values that
+// messageJSON encodes them to, in the same order and with the same tags, so that both hash messages alike.
+type messageHasher struct {
+	opts SchemaOptions
+	h    hash.Hash64
+	// buf holds the bytes not yet written to the hash, which are written in batches rather than value by value.
+	buf []byte
+}
+
+// messageHasherBufferSize is the number of bytes that a messageHasher buffers before writing them to the hash.
+const messageHasherBufferSize = 512
+
+// hashedField is a field of a record to be hashed, of the message, which is not valid for the fields of
+// flattened messages that are not set.
+type hashedField struct {
+	name    string
+	message protoreflect.Message
+	field   protoreflect.FieldDescriptor
+	mask    fieldMaskTree
+}
+
+// message hashes the message like messageJSON encodes it, without the union of the record when unwrapped.
+func (m *messageHasher) message(
+	message protoreflect.Message,
+	recursiveIndex int,
+	mask fieldMaskTree,
+	unwrap bool,
+) error {
+	desc := message.Descriptor()
+	if !message.IsValid() {
+		m.null()
+		return nil
+	}
+	_, hasCodec := lookupMessageCodec(desc.FullName())
+	if hasCodec || m.opts.isWKT(desc.FullName()) || len(m.opts.fieldTransforms(desc)) > 0 ||
+		m.opts.PreserveUnknownFields {
+		// the values of these messages are defined by their generic values
+		value, err := m.opts.messageJSON(message, recursiveIndex, mask)
+		if err != nil {
+			return err
+		}
+		if unwrap {
+			value = unwrapUnion(value)
+		}
+		m.flush()
+		return hashValue(m.h, value)
+	}
+	if !unwrap && !(m.opts.OmitRootElement && recursiveIndex == 0) {
+		m.union(m.opts.recordName(desc, recursiveIndex))
+	}
+	fields, err := m.recordFields(nil, message, recursiveIndex, mask, "")
+	if err != nil {
+		return err
+	}
+	// records are hashed as maps, in the order of the names of their fields
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	m.length(hashMap, len(fields))
+	for _, field := range fields {
+		m.blockString(hashString, field.name)
+		if err := m.field(field, recursiveIndex); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+// recordFields appends the fields of the record of the message to fields, with the names and fields that
+// recordFieldsJSON encodes.
+func (m *messageHasher) recordFields(
+	fields []hashedField,
+	message protoreflect.Message,
+	recursiveIndex int,
+	mask fieldMaskTree,
+	prefix string,
+) ([]hashedField, error) {
+	desc := message.Descriptor()
+	names := m.opts.recordFieldNames(desc)
+	for i := 0; i < desc.Fields().Len(); i++ {
+		field := desc.Fields().Get(i)
+		name := string(field.Name())
+		if names != nil {
+			name = names[i]
+		}
+		if prefix != "" {
+			name = m.opts.flatFieldName(prefix, field)
+		}
+		fieldMask, ok := mask.child(string(field.Name()))
+		if !ok || m.opts.omitsField(field, recursiveIndex) {
+			continue
+		}
+		if m.opts.flattensField(field, recursiveIndex, prefix) {
+			nested := message.Get(field).Message()
+			var err error
+			if fields, err = m.recordFields(fields, nested, recursiveIndex+1, fieldMask, name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		fields = append(fields, hashedField{name: name, message: message, field: field, mask: fieldMask})
+	}
+	return fields, nil
+}
+
+// field hashes the field of a record like recordFieldsJSON encodes it.
+func (m *messageHasher) field(f hashedField, recursiveIndex int) error {
+	switch {
+	case !f.message.IsValid():
+		m.null()
+		return nil
+	case m.opts.encodesJSONString(f.field):
+		if !f.message.Has(f.field) {
+			m.null()
+			return nil
+		}
+		value, err := encodeJSONString(f.message, f.field)
+		if err != nil {
+			return err
+		}
+		m.union("string")
+		m.blockString(hashString, value)
+		return nil
+	case m.opts.encryptsField(f.field):
+		if !f.message.Has(f.field) {
+			m.null()
+			return nil
+		}
+		value, err := m.opts.encryptField(f.message, f.field)
+		if err != nil {
+			return err
+		}
+		m.union("bytes")
+		m.block(hashBytes, value)
+		return nil
+	case m.opts.encodesNull(f.message, f.field):
+		m.null()
+		return nil
+	}
+	return m.fieldValue(f.field, f.message.Get(f.field), recursiveIndex+1, f.mask)
+}
+
+// fieldValue hashes the value of the field like fieldJSON encodes it.
+func (m *messageHasher) fieldValue(
+	field protoreflect.FieldDescriptor,
+	value protoreflect.Value,
+	recursiveIndex int,
+	mask fieldMaskTree,
+) error {
+	switch {
+	case field.IsList():
+		_, unwrap, err := m.opts.unwrapsListItems(field)
+		if err != nil {
+			return err
+		}
+		list := value.List()
+		m.union("array")
+		m.length(hashArray, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			if err := m.kind(field, list.Get(i), recursiveIndex, mask, unwrap); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		return nil
+	case field.IsMap():
+		mp := value.Map()
+		keys := make([]protoreflect.MapKey, 0, mp.Len())
+		mp.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, key)
+			return true
+		})
+		m.opts.sortMapKeys(keys)
+		m.union("array")
+		m.length(hashArray, len(keys))
+		for i, key := range keys {
+			// entries are records of the fields key and value, in the order of their names
+			m.length(hashMap, 2)
+			m.blockString(hashString, "key")
+			if err := m.kind(field.MapKey(), key.Value(), recursiveIndex, nil, false); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			m.blockString(hashString, "value")
+			// values are nested in the records of map entries
+			if err := m.kind(field.MapValue(), mp.Get(key), recursiveIndex+1, mask, false); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		return nil
+	}
+	return m.kind(field, value, recursiveIndex, mask, false)
+}
+
+// kind hashes a single value of the field like fieldKindJSON encodes it, without its union when unwrapped, like
+// unwrapUnion.
+func (m *messageHasher) kind(
+	field protoreflect.FieldDescriptor,
+	value protoreflect.Value,
+	recursiveIndex int,
+	mask fieldMaskTree,
+	unwrap bool,
+) error {
+	var b [8]byte
+	var tag byte
+	var data []byte
+	var str string
+	var branch string
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return m.message(value.Message(), recursiveIndex, mask, unwrap)
+	case protoreflect.EnumKind:
+		enumValue := field.Enum().Values().ByNumber(value.Enum())
+		if enumValue == nil {
+			enumValue = field.Enum().Values().ByNumber(protoreflect.EnumNumber(0))
+		}
+		branch, tag, str = m.opts.avroName(field.Enum()), hashString, m.opts.enumSymbol(enumValue)
+	case protoreflect.StringKind:
+		branch, tag, str = "string", hashString, value.String()
+	case protoreflect.Int32Kind, protoreflect.Sfixed32Kind, protoreflect.Sint32Kind:
+		binary.BigEndian.PutUint64(b[:], uint64(int32(value.Int())))
+		branch, tag = "int", hashInt
+	case protoreflect.Fixed32Kind:
+		// fixed32 is mapped to int, with values above math.MaxInt32 wrapping around
+		binary.BigEndian.PutUint64(b[:], uint64(int32(value.Uint())))
+		branch, tag = "int", hashInt
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		binary.BigEndian.PutUint64(b[:], value.Uint())
+		branch, tag = "long", hashLong
+	case protoreflect.Int64Kind, protoreflect.Sfixed64Kind, protoreflect.Sint64Kind:
+		binary.BigEndian.PutUint64(b[:], uint64(value.Int()))
+		branch, tag = "long", hashLong
+	case protoreflect.BoolKind:
+		branch = "boolean"
+		if !unwrap {
+			m.union(branch)
+		}
+		var v byte
+		if value.Bool() {
+			v = 1
+		}
+		m.buf = append(m.buf, hashBoolean, v)
+		m.flushFull()
+		return nil
+	case protoreflect.BytesKind:
+		branch, tag, data = "bytes", hashBytes, value.Bytes()
+		if size := m.opts.fixedSize(field); size > 0 {
+			if len(value.Bytes()) == 0 && !field.IsList() {
+				// fixed values can not be empty
+				m.null()
+				return nil
+			}
+			if err := checkFixedSize(field, size, value.Bytes()); err != nil {
+				return err
+			}
+			branch = m.opts.fixedName(field)
+		}
+	case protoreflect.DoubleKind:
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(value.Float()))
+		branch, tag = "double", hashDouble
+	case protoreflect.FloatKind:
+		binary.BigEndian.PutUint64(b[:], uint64(math.Float32bits(float32(value.Float()))))
+		branch, tag = "float", hashFloat
+	default:
+		m.flush()
+		return hashValue(m.h, value.Interface())
+	}
+	if !unwrap {
+		m.union(branch)
+	}
+	switch tag {
+	case hashString:
+		m.blockString(tag, str)
+	case hashBytes:
+		m.block(tag, data)
+	default:
+		m.buf = append(append(m.buf, tag), b[:]...)
+		m.flushFull()
+	}
+	return nil
+}
+
+// union hashes the union of a value, with the name of its branch, like the generic values of unionValue.
+func (m *messageHasher) union(branch string) {
+	m.length(hashMap, 1)
+	m.blockString(hashString, branch)
+}
+
+// null hashes a null value.
+func (m *messageHasher) null() {
+	m.buf = append(m.buf, hashNull)
+	m.flushFull()
+}
+
+// length hashes the tag and the length of a value, like hashLength.
+func (m *messageHasher) length(tag byte, n int) {
+	m.buf = binary.BigEndian.AppendUint64(append(m.buf, tag), uint64(n))
+	m.flushFull()
+}
+
+// block hashes the tag, the length and the bytes of a value, like hashBlock.
+func (m *messageHasher) block(tag byte, data []byte) {
+	m.length(tag, len(data))
+	m.buf = append(m.buf, data...)
+	m.flushFull()
+}
+
+// blockString hashes the tag, the length and the bytes of a string, like hashBlock.
+func (m *messageHasher) blockString(tag byte, data string) {
+	m.length(tag, len(data))
+	m.buf = append(m.buf, data...)
+	m.flushFull()
+}
+
+// flushFull writes the buffered bytes to the hash once the buffer is full.
+func (m *messageHasher) flushFull() {
+	if len(m.buf) >= messageHasherBufferSize {
+		m.flush()
+	}
+}
+
+// flush writes the buffered bytes to the hash.
+func (m *messageHasher) flush() {
+	_, _ = m.h.Write(m.buf)
+	m.buf = m.buf[:0]
+}
+
+// Tags of the values of hashes, so that values of different types, or of different nesting, hash differently.
+const (
+	hashNull byte = iota
+	hashBoolean
+	hashInt
+	hashLong
+	hashFloat
+	hashDouble
+	hashBytes
+	hashString
+	hashMap
+	hashArray
+	hashDecimal
+	hashTime
+	hashDuration
+)
+
+// hashValue writes a generic Avro value, as returned by Encode, to the hash.
+func hashValue(h hash.Hash64, value interface{}) error {
+	var b [9]byte
+	switch v := value.(type) {
+	case nil:
+		_, _ = h.Write([]byte{hashNull})
+	case bool:
+		b[0], b[1] = hashBoolean, 0
+		if v {
+			b[1] = 1
+		}
+		_, _ = h.Write(b[:2])
+	case int32:
+		b[0] = hashInt
+		binary.BigEndian.PutUint64(b[1:], uint64(v))
+		_, _ = h.Write(b[:])
+	case int64:
+		b[0] = hashLong
+		binary.BigEndian.PutUint64(b[1:], uint64(v))
+		_, _ = h.Write(b[:])
+	case float32:
+		b[0] = hashFloat
+		binary.BigEndian.PutUint64(b[1:], uint64(math.Float32bits(v)))
+		_, _ = h.Write(b[:])
+	case float64:
+		b[0] = hashDouble
+		binary.BigEndian.PutUint64(b[1:], math.Float64bits(v))
+		_, _ = h.Write(b[:])
+	case []byte:
+		hashBlock(h, hashBytes, v)
+	case string:
+		hashBlock(h, hashString, []byte(v))
+	case *big.Rat:
+		hashBlock(h, hashDecimal, []byte(v.RatString()))
+	case time.Time:
+		b[0] = hashTime
+		binary.BigEndian.PutUint64(b[1:], uint64(v.Unix()))
+		_, _ = h.Write(b[:])
+		binary.BigEndian.PutUint64(b[1:], uint64(v.Nanosecond()))
+		_, _ = h.Write(b[1:])
+	case time.Duration:
+		b[0] = hashDuration
+		binary.BigEndian.PutUint64(b[1:], uint64(v))
+		_, _ = h.Write(b[:])
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		hashLength(h, hashMap, len(keys))
+		for _, key := range keys {
+			hashBlock(h, hashString, []byte(key))
+			if err := hashValue(h, v[key]); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	case []interface{}:
+		hashLength(h, hashArray, len(v))
+		for i, item := range v {
+			if err := hashValue(h, item); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported value of type %T", value)
+	}
+	return nil
+}
+
+// hashLength writes the tag and the length of a value to the hash.
+func hashLength(h hash.Hash64, tag byte, n int) {
+	var b [9]byte
+	b[0] = tag
+	binary.BigEndian.PutUint64(b[1:], uint64(n))
+	_, _ = h.Write(b[:])
+}
+
+// hashBlock writes the tag, the length and the bytes of a value to the hash.
+func hashBlock(h hash.Hash64, tag byte, data []byte) {
+	hashLength(h, tag, len(data))
+	_, _ = h.Write(data)
+}
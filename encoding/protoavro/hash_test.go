@@ -0,0 +1,154 @@
+package protoavro
+
+import (
+	"hash/fnv"
+	"math"
+	"testing"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestHashMessage(t *testing.T) {
+	book := &library.Book{Name: "shelves/1/books/1", Title: "Dune", Author: "Frank Herbert"}
+
+	t.Run("stable", func(t *testing.T) {
+		got, err := HashMessage(book, SchemaOptions{})
+		assert.NilError(t, err)
+		// the hash must not change between releases, since it is persisted by deduplication jobs
+		assert.Equal(t, uint64(0xcfa4531446cde339), got)
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		var opts SchemaOptions
+		h1, err := HashMessage(book, opts)
+		assert.NilError(t, err)
+		h2, err := HashMessage(&library.Book{Name: "shelves/1/books/1", Title: "Dune", Author: "F. Herbert"}, opts)
+		assert.NilError(t, err)
+		assert.Assert(t, h1 != h2)
+		// values that move between fields change the hash
+		h3, err := HashMessage(&library.Book{Name: "shelves/1/books/1", Title: "Frank Herbert", Author: "Dune"}, opts)
+		assert.NilError(t, err)
+		assert.Assert(t, h1 != h3)
+	})
+
+	t.Run("maps", func(t *testing.T) {
+		var hashes []uint64
+		for i := 0; i < 10; i++ {
+			m := &examplev1.ExampleMap{
+				StringToString: map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"},
+				Int32ToString:  map[int32]string{1: "a", 10: "b", 2: "c"},
+			}
+			h, err := HashMessage(m, SchemaOptions{})
+			assert.NilError(t, err)
+			hashes = append(hashes, h)
+		}
+		for _, h := range hashes {
+			assert.Equal(t, hashes[0], h)
+		}
+	})
+
+	t.Run("schema mask", func(t *testing.T) {
+		opts := SchemaOptions{SchemaMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "title"}}}
+		h1, err := HashMessage(book, opts)
+		assert.NilError(t, err)
+		h2, err := HashMessage(&library.Book{Name: book.GetName(), Title: book.GetTitle(), Read: true}, opts)
+		assert.NilError(t, err)
+		assert.Equal(t, h1, h2)
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		value, err := structpb.NewStruct(map[string]interface{}{"b": 1, "a": []interface{}{"x"}})
+		assert.NilError(t, err)
+		h1, err := HashMessage(value, SchemaOptions{})
+		assert.NilError(t, err)
+		h2, err := HashMessage(value, SchemaOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, h1, h2)
+	})
+}
+
+func TestHashMessage_Streaming(t *testing.T) {
+	// messages are hashed while walking their fields like their generic values are hashed
+	messages := []proto.Message{
+		&library.Book{Name: "shelves/1/books/1", Title: "Dune", Author: "Frank Herbert", Read: true},
+		&examplev1.ExampleScalars{
+			Double: math.Pi, Float: -1.5, Int32: math.MinInt32, Int64: math.MaxInt64, Uint32: math.MaxUint32,
+			Uint64: math.MaxUint64, Sint32: -3, Sint64: -4, Fixed32: math.MaxUint32, Fixed64: math.MaxUint64,
+			Sfixed32: -7, Sfixed64: -8, Bool: true, String_: "a", Bytes: []byte{0, 1},
+		},
+		&examplev1.ExampleScalars{},
+		&examplev1.ExampleList{
+			Int64List:      []int64{1, 2},
+			StringList:     []string{"a", ""},
+			EnumList:       []examplev1.ExampleList_Enum{1, 0, 42},
+			NestedList:     []*examplev1.ExampleList_Nested{{StringList: []string{"b"}}, {}},
+			FloatValueList: []*wrapperspb.FloatValue{wrapperspb.Float(1)},
+		},
+		&examplev1.ExampleMap{
+			StringToString: map[string]string{"a": "1", "b": "2"},
+			StringToNested: map[string]*examplev1.ExampleMap_Nested{"a": {StringToString: map[string]string{"x": "y"}}},
+			StringToEnum:   map[string]examplev1.ExampleMap_Enum{"a": examplev1.ExampleMap_ENUM_VALUE2},
+			Int32ToString:  map[int32]string{-1: "a", 10: "b", 2: "c"},
+			BoolToString:   map[bool]string{true: "a", false: "b"},
+		},
+		&examplev1.ExampleRecursive{Recursive: &examplev1.ExampleRecursive{Recursive: &examplev1.ExampleRecursive{}}},
+		&examplev1.ExampleOneof{OneofFields_1: &examplev1.ExampleOneof_OneofBool_1{OneofBool_1: true}},
+		&examplev1.ExampleWrappers{StringValue: wrapperspb.String("a"), Int64Value: wrapperspb.Int64(1)},
+	}
+	for _, opts := range []SchemaOptions{
+		{},
+		{OmitRootElement: true},
+		{SchemaMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "string_list", "nested_list.string_list"}}},
+		{FlattenDepth: 1, NonNullListItems: true, StripEnumPrefix: true, UseJSONNames: true},
+		{RecursionStrategy: RecursionJSONString, PreservePresence: true},
+		{FieldTransforms: []FieldTransform{{Message: "google.example.library.v1.Book", Field: "author", Redact: true}}},
+	} {
+		for _, message := range messages {
+			o := opts.withNames(message.ProtoReflect().Descriptor())
+			o.Deterministic = true
+			value, err := o.messageJSON(message.ProtoReflect(), 0, newFieldMaskTree(o.SchemaMask))
+			if err != nil {
+				// options that do not apply to the message
+				_, hashErr := HashMessage(message, opts)
+				assert.Assert(t, hashErr != nil, "%T: %v", message, err)
+				continue
+			}
+			h := fnv.New64a()
+			assert.NilError(t, hashValue(h, value))
+			got, err := HashMessage(message, opts)
+			assert.NilError(t, err)
+			assert.Equal(t, h.Sum64(), got, "%T", message)
+		}
+	}
+}
+
+func TestHashMessage_Allocations(t *testing.T) {
+	message := &examplev1.ExampleList{
+		Int64List:  []int64{1, 2, 3, 4},
+		StringList: []string{"a", "b", "c", "d"},
+		NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"b"}}, {StringList: []string{"c"}}},
+	}
+	var opts SchemaOptions
+	hashed := testing.AllocsPerRun(100, func() {
+		if _, err := HashMessage(message, opts); err != nil {
+			t.Fatal(err)
+		}
+	})
+	encoded := testing.AllocsPerRun(100, func() {
+		value, err := opts.encodeJSON(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := hashValue(fnv.New64a(), value); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Logf("allocations per hash: streaming %v, encoded %v", hashed, encoded)
+	assert.Assert(t, hashed*2 <= encoded, "streaming %v, encoded %v", hashed, encoded)
+}
@@ -0,0 +1,73 @@
+package protoavro
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Instrumentation receives telemetry from marshaling and unmarshaling, for example to
+// report OpenTelemetry spans and metrics.
+// Implementations must be safe for concurrent use.
+type Instrumentation interface {
+	// StartSpan starts a span for an operation, such as "protoavro.Marshal".
+	// The returned function is called with the result of the operation when it ends.
+	StartSpan(ctx context.Context, operation string) (context.Context, func(err error))
+	// RecordsEncoded is called with the number of records encoded by an operation.
+	RecordsEncoded(ctx context.Context, records int)
+	// BytesWritten is called with the number of bytes written by an operation.
+	BytesWritten(ctx context.Context, bytes int64)
+	// DecodeError is called when a record fails to decode.
+	// The errorType is one of "read", for records that fail to be read from the reader,
+	// and "decode", for records that fail to decode into a message.
+	DecodeError(ctx context.Context, errorType string, err error)
+	// RegistryRequest is called when a request to a schema registry of package registry ends, with the
+	// operation, such as "registry.Register", "registry.Lookup" or "registry.Schema", the latency of the
+	// request, including retries, and its error. Schemas resolved from caches are not requested.
+	RegistryRequest(ctx context.Context, operation string, duration time.Duration, err error)
+}
+
+const (
+	decodeErrorTypeRead   = "read"
+	decodeErrorTypeDecode = "decode"
+)
+
+func (o SchemaOptions) startSpan(ctx context.Context, operation string) (context.Context, func(err error)) {
+	if o.Instrumentation == nil {
+		return ctx, func(error) {}
+	}
+	return o.Instrumentation.StartSpan(ctx, operation)
+}
+
+func (o SchemaOptions) recordsEncoded(ctx context.Context, records int) {
+	if o.Instrumentation != nil {
+		o.Instrumentation.RecordsEncoded(ctx, records)
+	}
+}
+
+func (o SchemaOptions) decodeError(ctx context.Context, errorType string, err error) {
+	if o.Instrumentation != nil {
+		o.Instrumentation.DecodeError(ctx, errorType, err)
+	}
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// bytesWritten reports the bytes written to the counting writer since the last call.
+func (m *Marshaler) bytesWritten(ctx context.Context) {
+	if m.opts.Instrumentation == nil || m.counter == nil {
+		return
+	}
+	m.opts.Instrumentation.BytesWritten(ctx, m.counter.n-m.reported)
+	m.reported = m.counter.n
+}
@@ -0,0 +1,75 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"gotest.tools/v3/assert"
+)
+
+type recordingInstrumentation struct {
+	mu             sync.Mutex
+	spans          []string
+	recordsEncoded int
+	bytesWritten   int64
+	decodeErrors   map[string]int
+}
+
+func (r *recordingInstrumentation) StartSpan(
+	ctx context.Context,
+	operation string,
+) (context.Context, func(error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, operation)
+	return ctx, func(error) {}
+}
+
+func (r *recordingInstrumentation) RecordsEncoded(_ context.Context, records int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recordsEncoded += records
+}
+
+func (r *recordingInstrumentation) BytesWritten(_ context.Context, bytes int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bytesWritten += bytes
+}
+
+func (r *recordingInstrumentation) DecodeError(_ context.Context, errorType string, _ error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.decodeErrors == nil {
+		r.decodeErrors = make(map[string]int)
+	}
+	r.decodeErrors[errorType]++
+}
+
+func (r *recordingInstrumentation) RegistryRequest(context.Context, string, time.Duration, error) {}
+
+func Test_Instrumentation(t *testing.T) {
+	var instrumentation recordingInstrumentation
+	opts := protoavro.SchemaOptions{Instrumentation: &instrumentation}
+	msgs := books(10)
+
+	var b bytes.Buffer
+	marshaler, err := opts.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(msgs...))
+	assert.NilError(t, marshaler.MarshalBatch(msgs))
+	assert.DeepEqual(t, instrumentation.spans, []string{"protoavro.Marshal", "protoavro.Marshaler.MarshalBatch"})
+	assert.Equal(t, instrumentation.recordsEncoded, 20)
+	assert.Equal(t, instrumentation.bytesWritten, int64(b.Len()))
+
+	unmarshaler, err := opts.NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	assert.Assert(t, unmarshaler.Scan())
+	assert.ErrorContains(t, unmarshaler.Unmarshal(&library.Shelf{}), "decode message")
+	assert.DeepEqual(t, instrumentation.decodeErrors, map[string]int{"decode": 1})
+}
@@ -0,0 +1,178 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NewGoavroCodec returns a goavro codec, with default SchemaOptions, for the schema of the message.
+// See SchemaOptions.NewGoavroCodec.
+func NewGoavroCodec(desc protoreflect.MessageDescriptor) (*goavro.Codec, error) {
+	return SchemaOptions{}.NewGoavroCodec(desc)
+}
+
+// NewGoavroCodec returns a goavro codec for the Avro schema inferred for the message.
+//
+// Values returned by Encode are in the native form of the codec, and values returned by the codec
+// can be decoded with Decode, so goavro can be used for the binary and OCF encodings
+// while this package is used for the mapping of protobuf messages.
+func (o SchemaOptions) NewGoavroCodec(desc protoreflect.MessageDescriptor) (*goavro.Codec, error) {
+	schema, err := o.InferSchema(desc)
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	return newGoavroCodec(schema)
+}
+
+func newGoavroCodec(schema avro.Schema) (*goavro.Codec, error) {
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new codec: %w", err)
+	}
+	return codec, nil
+}
+
+// MarshalAvroJSON encodes the message in the JSON encoding of the Avro specification, where union values are
+// wrapped by the name of their branch and bytes are strings of the code points 0-255.
+// The message is encoded directly from its fields, without building generic values first,
+// unless PreserveUnknownFields, EnvelopeFields, RawProto, FieldTransforms or ValidateEncoding are set, or recursive
+// fields are mapped with RecursionJSONString.
+func (o SchemaOptions) MarshalAvroJSON(message proto.Message) ([]byte, error) {
+	schema, err := o.InferSchema(message.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	return o.marshalAvroJSON(schema, message)
+}
+
+// marshalAvroJSON encodes the message in Avro JSON of the schema, inferred with the options for the message.
+// The message is encoded through goavro when it can not be encoded directly.
+func (o SchemaOptions) marshalAvroJSON(schema avro.Schema, message proto.Message) ([]byte, error) {
+	encoder, err := o.newAvroJSONEncoder(message.ProtoReflect().Descriptor(), schema)
+	var b []byte
+	switch {
+	case err == nil:
+		b, err = encoder.encode(nil, message.ProtoReflect())
+	case errors.Is(err, errUnsupported):
+		var codec *goavro.Codec
+		if codec, err = newGoavroCodec(schema); err != nil {
+			return nil, err
+		}
+		b, err = o.appendAvroJSON(codec, nil, message)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o.rewriteAvroJSON(schema, b, true)
+}
+
+// UnmarshalAvroJSON decodes a message from the JSON encoding of the Avro specification.
+// The message is decoded directly from the JSON tokens of the data, without decoding the data
+// to generic values first, unless PreserveUnknownFields, EnvelopeFields, RawProto or DecodeMask are set.
+func (o SchemaOptions) UnmarshalAvroJSON(data []byte, message proto.Message) error {
+	schema, err := o.InferSchema(message.ProtoReflect().Descriptor())
+	if err != nil {
+		return fmt.Errorf("infer schema: %w", err)
+	}
+	return o.unmarshalAvroJSON(schema, data, message)
+}
+
+// unmarshalAvroJSON decodes the Avro JSON data of the schema, inferred with the options for the message,
+// into the message. The data is decoded through goavro when it can not be decoded directly.
+func (o SchemaOptions) unmarshalAvroJSON(schema avro.Schema, data []byte, message proto.Message) error {
+	if err := o.checkRecordBytes(len(data)); err != nil {
+		return err
+	}
+	data, err := o.rewriteAvroJSON(schema, data, false)
+	if err != nil {
+		return err
+	}
+	decoder, err := o.newAvroJSONDecoder(message.ProtoReflect().Descriptor(), schema)
+	if err == nil {
+		if o.MaxNestingDepth > 0 && !withinJSONNestingDepth(data, o.MaxNestingDepth) {
+			return &LimitError{Limit: "MaxNestingDepth", Max: o.MaxNestingDepth}
+		}
+		if err := decoder.decode(bytes.NewReader(data), message.ProtoReflect()); err != nil {
+			return fmt.Errorf("decode avro json: %w", err)
+		}
+		return nil
+	}
+	if !errors.Is(err, errUnsupported) {
+		return err
+	}
+	codec, err := newGoavroCodec(schema)
+	if err != nil {
+		return err
+	}
+	native, _, err := codec.NativeFromTextual(escapeNonASCII(data))
+	if err != nil {
+		return fmt.Errorf("native from textual: %w", err)
+	}
+	if err := o.decodeJSON(native, message); err != nil {
+		return fmt.Errorf("decode message: %w", err)
+	}
+	return nil
+}
+
+// NativeFromAvroJSON converts a value in the JSON encoding of the Avro specification, as decoded by
+// encoding/json, to the native form of the codec. Numbers are float64 or json.Number, and bytes and
+// fixed values are strings.
+func NativeFromAvroJSON(codec *goavro.Codec, value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+	// goavro decodes the code points of bytes from escape sequences only, and unescaped characters
+	// from their UTF-8 encoding
+	native, _, err := codec.NativeFromTextual(escapeNonASCII(b))
+	if err != nil {
+		return nil, fmt.Errorf("native from textual: %w", err)
+	}
+	return native, nil
+}
+
+// escapeNonASCII returns the JSON document with non-ASCII characters replaced by escape sequences.
+func escapeNonASCII(b []byte) []byte {
+	escaped := make([]byte, 0, len(b))
+	for _, r := range string(b) {
+		switch {
+		case r < utf8.RuneSelf:
+			escaped = append(escaped, byte(r))
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			escaped = append(escaped, fmt.Sprintf(`\u%04x\u%04x`, r1, r2)...)
+		default:
+			escaped = append(escaped, fmt.Sprintf(`\u%04x`, r)...)
+		}
+	}
+	return escaped
+}
+
+// AvroJSONFromNative converts a value in the native form of the codec to the JSON encoding of the
+// Avro specification, as decoded by encoding/json with numbers as json.Number.
+func AvroJSONFromNative(codec *goavro.Codec, native interface{}) (interface{}, error) {
+	b, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("textual from native: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+	return value, nil
+}
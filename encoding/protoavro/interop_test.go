@@ -0,0 +1,106 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestGoavroInterop(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts SchemaOptions
+		msg  proto.Message
+	}{
+		{
+			name: "book",
+			msg:  &library.Book{Name: "shelves/1/books/1", Title: "Harry Potter och De Vises Sten ⚡🧙", Read: true},
+		},
+		{
+			name: "bytes",
+			msg:  &examplev1.ExampleBytes{Bytes: []byte{0x00, 0x7f, 0x80, 0xff}},
+		},
+		{
+			name: "wrappers",
+			msg: &examplev1.ExampleWrappers{
+				StringValue: wrapperspb.String("foo"),
+				BytesValue:  wrapperspb.Bytes([]byte{0xff}),
+			},
+		},
+		{
+			name: "timestamp",
+			msg: &examplev1.ExampleTimestamp{
+				Timestamp: timestamppb.New(time.Unix(1_600_000_000, 123_000).UTC()),
+			},
+		},
+		{
+			name: "duration fixed",
+			opts: SchemaOptions{DurationEncoding: DurationFixed},
+			msg:  &examplev1.ExampleDuration{Duration: durationpb.New(90 * time.Second)},
+		},
+		{
+			name: "map",
+			msg:  &examplev1.ExampleMap{StringToString: map[string]string{"foo": "bar"}},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := tt.opts.NewGoavroCodec(tt.msg.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+
+			// binary
+			native, err := tt.opts.Encode(tt.msg)
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, native)
+			assert.NilError(t, err)
+			decodedNative, _, err := codec.NativeFromBinary(binary)
+			assert.NilError(t, err)
+			decoded := tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, tt.opts.Decode(decodedNative, decoded))
+			assert.DeepEqual(t, tt.msg, decoded, protocmp.Transform())
+
+			// avro json
+			textual, err := tt.opts.MarshalAvroJSON(tt.msg)
+			assert.NilError(t, err)
+			decoded = tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, tt.opts.UnmarshalAvroJSON(textual, decoded))
+			assert.DeepEqual(t, tt.msg, decoded, protocmp.Transform())
+
+			// generic json values
+			value, err := AvroJSONFromNative(codec, native)
+			assert.NilError(t, err)
+			b, err := json.Marshal(value)
+			assert.NilError(t, err)
+			var generic interface{}
+			assert.NilError(t, json.Unmarshal(b, &generic))
+			native, err = NativeFromAvroJSON(codec, generic)
+			assert.NilError(t, err)
+			decoded = tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, tt.opts.Decode(native, decoded))
+			assert.DeepEqual(t, tt.msg, decoded, protocmp.Transform())
+		})
+	}
+}
+
+func TestAvroJSONFromNative(t *testing.T) {
+	codec, err := NewGoavroCodec((&examplev1.ExampleBytes{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	native, err := SchemaOptions{}.Encode(&examplev1.ExampleBytes{Bytes: []byte{0x61, 0xff}})
+	assert.NilError(t, err)
+	value, err := AvroJSONFromNative(codec, native)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, value, map[string]interface{}{
+		"einride.avro.example.v1.ExampleBytes": map[string]interface{}{
+			"bytes": map[string]interface{}{"bytes": "aÿ"},
+		},
+	})
+}
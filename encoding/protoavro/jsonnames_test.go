@@ -0,0 +1,159 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestUseJSONNames(t *testing.T) {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/json_names.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Article"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("article_id"),
+						JsonName: proto.String("articleId"),
+						Number:   proto.Int32(1),
+						Label:    optional,
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+					{
+						Name:     proto.String("title"),
+						JsonName: proto.String("headline"),
+						Number:   proto.Int32(2),
+						Label:    optional,
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+					{
+						Name:     proto.String("related_articles"),
+						JsonName: proto.String("relatedArticles"),
+						Number:   proto.Int32(3),
+						Label:    repeated,
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".example.v1.Article.Reference"),
+					},
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Reference"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("article_id"),
+								JsonName: proto.String("articleId"),
+								Number:   proto.Int32(1),
+								Label:    optional,
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	assert.NilError(t, err)
+	desc := file.Messages().Get(0)
+	referenceDesc := desc.Messages().Get(0)
+	reference := dynamicpb.NewMessage(referenceDesc)
+	reference.Set(referenceDesc.Fields().ByName("article_id"), protoreflect.ValueOfString("articles/2"))
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("article_id"), protoreflect.ValueOfString("articles/1"))
+	msg.Set(desc.Fields().ByName("title"), protoreflect.ValueOfString("Dune"))
+	related := msg.Mutable(desc.Fields().ByName("related_articles")).List()
+	related.Append(protoreflect.ValueOfMessage(reference))
+	opts := SchemaOptions{UseJSONNames: true}
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		record := schema.(avro.Union)[1].(avro.Record)
+		names := make([]string, 0, len(record.Fields))
+		for _, field := range record.Fields {
+			names = append(names, field.Name)
+		}
+		assert.DeepEqual(t, []string{"articleId", "headline", "relatedArticles"}, names)
+		items := record.Fields[2].Type.(avro.Union)[1].(avro.Array).Items
+		assert.Equal(t, "articleId", items.(avro.Union)[1].(avro.Record).Fields[0].Name)
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		data, err := opts.Encode(msg)
+		assert.NilError(t, err)
+		record := data.(map[string]interface{})["example.v1.Article"].(map[string]interface{})
+		assert.DeepEqual(t, map[string]interface{}{"string": "Dune"}, record["headline"])
+		_, ok := record["title"]
+		assert.Assert(t, !ok)
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, opts.Decode(data, decoded))
+		assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+	})
+
+	t.Run("avro json", func(t *testing.T) {
+		data, err := opts.MarshalAvroJSON(msg)
+		assert.NilError(t, err)
+		assert.Assert(t, bytes.Contains(data, []byte(`"headline":`)), string(data))
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+		assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+	})
+
+	t.Run("object container file", func(t *testing.T) {
+		var b bytes.Buffer
+		marshaler, err := opts.NewMarshaler(desc, &b)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(msg))
+		unmarshaler, err := opts.NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		assert.Assert(t, unmarshaler.Scan())
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, unmarshaler.Unmarshal(decoded))
+		assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+	})
+
+	t.Run("original names", func(t *testing.T) {
+		// records of either naming decode with either option
+		data, err := SchemaOptions{}.Encode(msg)
+		assert.NilError(t, err)
+		record := data.(map[string]interface{})["example.v1.Article"].(map[string]interface{})
+		assert.DeepEqual(t, map[string]interface{}{"string": "Dune"}, record["title"])
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, opts.Decode(data, decoded))
+		assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+	})
+
+	t.Run("field transforms", func(t *testing.T) {
+		opts := opts
+		opts.FieldTransforms = []FieldTransform{
+			{Message: "example.v1.Article", Field: "article_id", Expression: `msg.article_id + "#v1"`},
+			{Message: "example.v1.Article", Field: "title", Rename: "name"},
+		}
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		record := schema.(avro.Union)[1].(avro.Record)
+		assert.Equal(t, "articleId", record.Fields[0].Name)
+		assert.Equal(t, "name", record.Fields[1].Name)
+		data, err := opts.Encode(msg)
+		assert.NilError(t, err)
+		encoded := data.(map[string]interface{})["example.v1.Article"].(map[string]interface{})
+		assert.DeepEqual(t, map[string]interface{}{"string": "articles/1#v1"}, encoded["articleId"])
+		assert.DeepEqual(t, map[string]interface{}{"string": "Dune"}, encoded["name"])
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, opts.Decode(data, decoded))
+		assert.Equal(t, "Dune", decoded.Get(desc.Fields().ByName("title")).String())
+	})
+}
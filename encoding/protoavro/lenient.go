@@ -0,0 +1,72 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// wrapLenientWKT wraps a value of a well-known type, or a message with a custom codec, that is not wrapped
+// by the name of the branch of its union, when LenientUnions is set. Other values are returned as they are.
+func (o SchemaOptions) wrapLenientWKT(data interface{}, desc protoreflect.MessageDescriptor) interface{} {
+	if !o.LenientUnions || data == nil {
+		return data
+	}
+	schema, err := o.schemaWKT(desc)
+	if err != nil {
+		return data
+	}
+	branch, ok := singleBranch(schema)
+	if !ok {
+		return data
+	}
+	name := o.unionBranchName(branch)
+	if m, ok := data.(map[string]interface{}); ok && len(m) == 1 {
+		if _, ok := m[name]; ok {
+			return data
+		}
+	}
+	return map[string]interface{}{name: data}
+}
+
+// unionBranches returns the non-null branches of a union by name, which is empty for schemas that are not unions.
+func (o SchemaOptions) unionBranches(schema avro.Schema) map[string]avro.Schema {
+	union, ok := schema.(avro.Union)
+	if !ok {
+		return nil
+	}
+	branches := make(map[string]avro.Schema, len(union))
+	for _, branch := range union {
+		if branch == avro.Null() {
+			continue
+		}
+		branches[o.unionBranchName(branch)] = branch
+	}
+	return branches
+}
+
+// wrapLenientJSON wraps an Avro JSON value of a union that is not wrapped by the name of a branch, by the
+// name of the single non-null branch of the union. Null values, values of unions of several non-null branches,
+// and values that are objects keyed by the name of a branch are returned as they are.
+func wrapLenientJSON(raw json.RawMessage, branches map[string]avro.Schema) json.RawMessage {
+	raw = bytes.TrimSpace(raw)
+	if bytes.Equal(raw, []byte("null")) || len(branches) != 1 {
+		return raw
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err == nil && len(object) == 1 {
+		for name := range object {
+			if _, ok := branches[name]; ok {
+				return raw
+			}
+		}
+	}
+	for name := range branches {
+		wrapped := append(appendJSONString([]byte{'{'}, name), ':')
+		wrapped = append(wrapped, raw...)
+		return append(wrapped, '}')
+	}
+	return raw
+}
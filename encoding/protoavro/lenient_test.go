@@ -0,0 +1,85 @@
+package protoavro
+
+import (
+	"testing"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestLenientUnions(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		data     string
+		expected proto.Message
+	}{
+		{
+			name: "oneof",
+			data: `{"oneof_empty_message_1":null,"oneof_bool_1":true,"oneof_empty_message_2":null,` +
+				`"oneof_message":{"string_value":"value"}}`,
+			expected: &examplev1.ExampleOneof{
+				OneofFields_1: &examplev1.ExampleOneof_OneofBool_1{OneofBool_1: true},
+				OneofFields_2: &examplev1.ExampleOneof_OneofMessage{
+					OneofMessage: &examplev1.ExampleOneof_Message{StringValue: "value"},
+				},
+			},
+		},
+		{
+			name: "named and unnamed branches",
+			data: `{"einride.avro.example.v1.ExampleOneof":{"oneof_empty_message_1":null,"oneof_bool_1":null,` +
+				`"oneof_empty_message_2":{"einride.avro.example.v1.ExampleOneof.EmptyMessage":{}},` +
+				`"oneof_message":null}}`,
+			expected: &examplev1.ExampleOneof{
+				OneofFields_2: &examplev1.ExampleOneof_OneofEmptyMessage_2{
+					OneofEmptyMessage_2: &examplev1.ExampleOneof_EmptyMessage{},
+				},
+			},
+		},
+		{
+			name: "wrappers",
+			data: `{"float_value":1.5,"double_value":{"double":2.5},"string_value":"value","int64_value":3,` +
+				`"bool_value":true,"bytes_value":null}`,
+			expected: &examplev1.ExampleWrappers{
+				FloatValue:  wrapperspb.Float(1.5),
+				DoubleValue: wrapperspb.Double(2.5),
+				StringValue: wrapperspb.String("value"),
+				Int64Value:  wrapperspb.Int64(3),
+				BoolValue:   wrapperspb.Bool(true),
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.expected.ProtoReflect().New().Interface()
+			assert.NilError(t, SchemaOptions{LenientUnions: true}.UnmarshalAvroJSON([]byte(tt.data), actual))
+			assert.DeepEqual(t, tt.expected, actual, protocmp.Transform())
+		})
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		// branches are required to be named by default
+		err := SchemaOptions{}.UnmarshalAvroJSON([]byte(`{"string_value":"value"}`), &examplev1.ExampleWrappers{})
+		assert.ErrorContains(t, err, "decode avro json")
+	})
+
+	t.Run("generic", func(t *testing.T) {
+		data := map[string]interface{}{
+			"float_value":  float32(1.5),
+			"string_value": map[string]interface{}{"string": "value"},
+			"int64_value":  int64(3),
+			"double_value": nil,
+		}
+		var actual examplev1.ExampleWrappers
+		assert.NilError(t, SchemaOptions{LenientUnions: true}.Decode(data, &actual))
+		expected := &examplev1.ExampleWrappers{
+			FloatValue:  wrapperspb.Float(1.5),
+			StringValue: wrapperspb.String("value"),
+			Int64Value:  wrapperspb.Int64(3),
+		}
+		assert.DeepEqual(t, expected, &actual, protocmp.Transform())
+		assert.ErrorContains(t, SchemaOptions{}.Decode(data, &examplev1.ExampleWrappers{}), "expected message")
+	})
+}
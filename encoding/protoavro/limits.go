@@ -0,0 +1,101 @@
+package protoavro
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLimitExceeded is wrapped by the errors of decoding records that exceed SchemaOptions.MaxRecordBytes or
+// SchemaOptions.MaxNestingDepth.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitError is the error of decoding a record that exceeds a limit of the SchemaOptions.
+// LimitError wraps ErrLimitExceeded.
+type LimitError struct {
+	// Limit is the name of the exceeded option, MaxRecordBytes or MaxNestingDepth.
+	Limit string
+	// Max is the value of the exceeded option.
+	Max int
+}
+
+// Error implements error.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("record exceeds %s of %d", e.Limit, e.Max)
+}
+
+// Unwrap returns ErrLimitExceeded.
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// checkRecordBytes returns a LimitError when a record of n bytes exceeds MaxRecordBytes.
+func (o *SchemaOptions) checkRecordBytes(n int) error {
+	if o.MaxRecordBytes > 0 && n > o.MaxRecordBytes {
+		return &LimitError{Limit: "MaxRecordBytes", Max: o.MaxRecordBytes}
+	}
+	return nil
+}
+
+// checkNestingDepth returns a LimitError when the generic Avro value exceeds MaxNestingDepth.
+func (o *SchemaOptions) checkNestingDepth(data interface{}) error {
+	if o.MaxNestingDepth > 0 && !withinNestingDepth(data, o.MaxNestingDepth) {
+		return &LimitError{Limit: "MaxNestingDepth", Max: o.MaxNestingDepth}
+	}
+	return nil
+}
+
+// withinNestingDepth returns true when the records, maps, arrays and unions of the generic Avro value are
+// nested at most depth levels deep. The value is walked no deeper than depth.
+func withinNestingDepth(data interface{}, depth int) bool {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if depth == 0 {
+			return false
+		}
+		for _, item := range v {
+			if !withinNestingDepth(item, depth-1) {
+				return false
+			}
+		}
+	case []interface{}:
+		if depth == 0 {
+			return false
+		}
+		for _, item := range v {
+			if !withinNestingDepth(item, depth-1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// withinJSONNestingDepth returns true when the objects and arrays of the JSON data are nested at most depth levels
+// deep. Records, maps and unions are the objects of the Avro JSON encoding, and arrays its arrays, so the depth of
+// Avro JSON data is the depth of its generic Avro value. The data is scanned without being decoded.
+func withinJSONNestingDepth(data []byte, depth int) bool {
+	var level int
+	var inString, escaped bool
+	for _, b := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch b {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			if level++; level > depth {
+				return false
+			}
+		case b == '}' || b == ']':
+			level--
+		}
+	}
+	return true
+}
@@ -0,0 +1,241 @@
+package protoavro
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestLimits(t *testing.T) {
+	book := &library.Book{Name: "shelves/1/books/1", Title: "Dune", Author: "Frank Herbert"}
+	plain, err := NewCodec[*library.Book](SchemaOptions{})
+	assert.NilError(t, err)
+	record, err := plain.Marshal(book)
+	assert.NilError(t, err)
+
+	t.Run("max record bytes", func(t *testing.T) {
+		for _, tt := range []struct {
+			name     string
+			max      int
+			exceeded bool
+		}{
+			{name: "unlimited"},
+			{name: "at limit", max: len(record)},
+			{name: "exceeded", max: len(record) - 1, exceeded: true},
+		} {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				opts := SchemaOptions{MaxRecordBytes: tt.max}
+				codec, err := NewCodec[*library.Book](opts)
+				assert.NilError(t, err)
+				avroJSON, err := SchemaOptions{}.MarshalAvroJSON(book)
+				assert.NilError(t, err)
+				var b bytes.Buffer
+				marshaler, err := NewMarshaler(book.ProtoReflect().Descriptor(), &b)
+				assert.NilError(t, err)
+				assert.NilError(t, marshaler.Marshal(book))
+				unmarshaler, err := opts.NewUnmarshaler(&b)
+				assert.NilError(t, err)
+				// blocks that exceed the limit stop decompressing, before their values are decoded
+				var ocfErr error
+				if unmarshaler.Scan() {
+					ocfErr = unmarshaler.Unmarshal(&library.Book{})
+				} else {
+					ocfErr = unmarshaler.Err()
+				}
+
+				// the Avro JSON encoding is larger than the binary encoding
+				jsonOpts := opts
+				if tt.max > 0 {
+					jsonOpts.MaxRecordBytes += len(avroJSON) - len(record)
+				}
+				got, codecErr := codec.Unmarshal(record)
+				for _, err := range []error{
+					codecErr,
+					jsonOpts.UnmarshalAvroJSON(avroJSON, &library.Book{}),
+					ocfErr,
+				} {
+					if !tt.exceeded {
+						assert.NilError(t, err)
+						continue
+					}
+					var limitErr *LimitError
+					assert.Assert(t, errors.As(err, &limitErr), err)
+					assert.Equal(t, "MaxRecordBytes", limitErr.Limit)
+					assert.Assert(t, errors.Is(err, ErrLimitExceeded))
+				}
+				if !tt.exceeded {
+					assert.DeepEqual(t, book, got, protocmp.Transform())
+				}
+			})
+		}
+	})
+
+	t.Run("max nesting depth", func(t *testing.T) {
+		msg := &examplev1.ExampleRecursive{
+			Recursive: &examplev1.ExampleRecursive{
+				Recursive: &examplev1.ExampleRecursive{},
+			},
+		}
+		// the root union and record, and a union and a record for each nested message
+		const depth = 6
+		for _, tt := range []struct {
+			name     string
+			max      int
+			exceeded bool
+		}{
+			{name: "unlimited"},
+			{name: "at limit", max: depth},
+			{name: "exceeded", max: depth - 1, exceeded: true},
+		} {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				opts := SchemaOptions{MaxNestingDepth: tt.max}
+				codec, err := NewCodec[*examplev1.ExampleRecursive](opts)
+				assert.NilError(t, err)
+				b, err := codec.Marshal(msg)
+				assert.NilError(t, err)
+				avroJSON, err := SchemaOptions{}.MarshalAvroJSON(msg)
+				assert.NilError(t, err)
+				got, codecErr := codec.Unmarshal(b)
+				for _, err := range []error{
+					codecErr,
+					opts.UnmarshalAvroJSON(avroJSON, &examplev1.ExampleRecursive{}),
+				} {
+					if !tt.exceeded {
+						assert.NilError(t, err)
+						continue
+					}
+					var limitErr *LimitError
+					assert.Assert(t, errors.As(err, &limitErr), err)
+					assert.Equal(t, "MaxNestingDepth", limitErr.Limit)
+					assert.Equal(t, tt.max, limitErr.Max)
+				}
+				if !tt.exceeded {
+					assert.DeepEqual(t, msg, got, protocmp.Transform())
+				}
+			})
+		}
+	})
+}
+
+func TestLimits_DirectDecoding(t *testing.T) {
+	for _, msg := range []proto.Message{
+		&examplev1.ExampleRecursive{Recursive: &examplev1.ExampleRecursive{}},
+		&examplev1.ExampleList{
+			StringList: []string{"[{"},
+			NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"a"}}},
+		},
+		&examplev1.ExampleMap{
+			StringToNested: map[string]*examplev1.ExampleMap_Nested{
+				"a": {StringToString: map[string]string{"x": "y"}},
+			},
+		},
+		&library.Book{Name: "shelves/1/books/1"},
+	} {
+		msg := msg
+		t.Run(string(msg.ProtoReflect().Descriptor().Name()), func(t *testing.T) {
+			var opts SchemaOptions
+			codec := newTestGoavroCodec(t, opts, msg)
+			encoded, err := opts.encodeJSON(msg)
+			assert.NilError(t, err)
+			data, err := codec.BinaryFromNative(nil, encoded)
+			assert.NilError(t, err)
+			native, _, err := codec.NativeFromBinary(data)
+			assert.NilError(t, err)
+			avroJSON, err := codec.TextualFromNative(nil, native)
+			assert.NilError(t, err)
+			// the depth of the generic value, which direct decoding must agree with
+			depth := 1
+			for !withinNestingDepth(native, depth) {
+				depth++
+			}
+			assert.Assert(t, withinJSONNestingDepth(avroJSON, depth))
+			assert.Assert(t, !withinJSONNestingDepth(avroJSON, depth-1))
+			schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+			root, err := opts.newBinaryDecoder(msg.ProtoReflect().Descriptor(), schema)
+			assert.NilError(t, err)
+			for _, max := range []int{depth, depth - 1} {
+				opts := SchemaOptions{MaxNestingDepth: max}
+				err := opts.withDecoderOptions(root.root).decode(data, msg.ProtoReflect().New())
+				jsonErr := opts.UnmarshalAvroJSON(avroJSON, msg.ProtoReflect().New().Interface())
+				if max == depth {
+					assert.NilError(t, err)
+					assert.NilError(t, jsonErr)
+					continue
+				}
+				assert.DeepEqual(t, &LimitError{Limit: "MaxNestingDepth", Max: max}, err)
+				assert.DeepEqual(t, &LimitError{Limit: "MaxNestingDepth", Max: max}, jsonErr)
+			}
+		})
+	}
+
+	t.Run("block count", func(t *testing.T) {
+		data := appendLong(nil, 1<<20)
+		r := binaryReader{buf: data, maxItems: 1024}
+		err := r.readBlocks(func() error {
+			t.Fatal("item read beyond the limit")
+			return nil
+		})
+		assert.DeepEqual(t, &LimitError{Limit: "MaxRecordBytes", Max: 1024}, err)
+		r = binaryReader{buf: appendLong(nil, 1<<40)}
+		assert.ErrorContains(t, r.readBlocks(func() error { return nil }), "invalid block count")
+	})
+}
+
+func TestLimits_DecompressionBomb(t *testing.T) {
+	// a block of a single record that deflates a hundredfold
+	book := &library.Book{Name: "shelves/1/books/1", Title: strings.Repeat("a", 1<<20)}
+	var b bytes.Buffer
+	marshaler, err := SchemaOptions{Compression: CompressionDeflate}.NewMarshaler(book.ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(book))
+	assert.Assert(t, b.Len() < 1<<14, b.Len())
+	for _, parallelism := range []int{0, 2} {
+		opts := SchemaOptions{MaxRecordBytes: 1 << 10, ReadParallelism: parallelism}
+		unmarshaler, err := opts.NewUnmarshaler(bytes.NewReader(b.Bytes()))
+		assert.NilError(t, err)
+		assert.Assert(t, !unmarshaler.Scan())
+		assert.Assert(t, errors.Is(unmarshaler.Err(), ErrLimitExceeded), unmarshaler.Err())
+	}
+
+	t.Run("limited codec", func(t *testing.T) {
+		// blocks are decompressed at most up to the limit of all of their records
+		codec := &limitRecordingCompression{}
+		RegisterCompressionCodec("limit-recording", codec)
+		var b bytes.Buffer
+		opts := SchemaOptions{Compression: "limit-recording"}
+		marshaler, err := opts.NewMarshaler(book.ProtoReflect().Descriptor(), &b)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(book, book, book))
+		unmarshaler, err := SchemaOptions{MaxRecordBytes: 1 << 10}.NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		assert.Assert(t, !unmarshaler.Scan())
+		assert.Assert(t, errors.Is(unmarshaler.Err(), ErrLimitExceeded), unmarshaler.Err())
+		assert.Equal(t, 3<<10, codec.max)
+	})
+}
+
+// limitRecordingCompression is a test codec that records the limit of DecompressLimit, and fails to decompress
+// blocks without a limit.
+type limitRecordingCompression struct {
+	nullCompression
+	max int
+}
+
+func (c *limitRecordingCompression) Decompress([]byte) ([]byte, error) {
+	return nil, errors.New("decompressed without limit")
+}
+
+func (c *limitRecordingCompression) DecompressLimit(block []byte, max int) ([]byte, error) {
+	c.max = max
+	return nullCompression{}.DecompressLimit(block, max)
+}
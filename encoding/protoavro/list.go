@@ -0,0 +1,130 @@
+package protoavro
+
+import (
+	"errors"
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NullListItems is how null items of Avro arrays are decoded, since protobuf lists can not hold null items.
+type NullListItems int
+
+const (
+	// NullListItemsZero decodes null items as the zero value of the item type, such as empty messages.
+	NullListItemsZero NullListItems = iota
+	// NullListItemsSkip skips null items, so that decoded lists only hold the items that were not null.
+	NullListItemsSkip
+	// NullListItemsError fails decoding of null items with ErrNullListItem.
+	NullListItemsError
+)
+
+// ErrNullListItem is returned when a null item of an Avro array is decoded with NullListItemsError.
+var ErrNullListItem = errors.New("null list item")
+
+// nullListItem returns the value of a null item of the list field, and false if the item is skipped.
+func (o SchemaOptions) nullListItem(
+	list protoreflect.List,
+	field protoreflect.FieldDescriptor,
+) (protoreflect.Value, bool, error) {
+	switch o.NullListItems {
+	case NullListItemsSkip:
+		return protoreflect.Value{}, false, nil
+	case NullListItemsError:
+		return protoreflect.Value{}, false, fmt.Errorf("field '%s': %w", field.Name(), ErrNullListItem)
+	}
+	return list.NewElement(), true, nil
+}
+
+// listItemsSchema returns the schema of the items of a list field, with the schema of the field kind.
+func (o SchemaOptions) listItemsSchema(kind avro.Schema) avro.Schema {
+	if !o.NonNullListItems {
+		return avro.Nullable(kind)
+	}
+	if branch, ok := singleBranch(kind); ok {
+		return branch
+	}
+	return kind
+}
+
+// unwrapsListItems returns true if the items of the list field are encoded without the union of
+// the field kind, when items are not nullable. The branch is the name of the union branch of items
+// that are decoded as unions, which are well-known types and messages with custom codecs.
+func (o SchemaOptions) unwrapsListItems(field protoreflect.FieldDescriptor) (branch string, unwrap bool, err error) {
+	if !o.NonNullListItems {
+		return "", false, nil
+	}
+	if field.Message() == nil {
+		return "", true, nil
+	}
+	_, isCodec := lookupMessageCodec(field.Message().FullName())
+	if !isCodec && !o.isWKT(field.Message().FullName()) {
+		return "", true, nil
+	}
+	schema, err := o.schemaWKT(field.Message())
+	if err != nil {
+		return "", false, err
+	}
+	single, ok := singleBranch(schema)
+	if !ok {
+		return "", false, nil
+	}
+	return o.unionBranchName(single), true, nil
+}
+
+// singleBranch returns the non-null branch of a union of null and one other schema.
+func singleBranch(schema avro.Schema) (avro.Schema, bool) {
+	union, ok := schema.(avro.Union)
+	if !ok || len(union) != 2 {
+		return nil, false
+	}
+	switch {
+	case union[0] == avro.Null():
+		return union[1], true
+	case union[1] == avro.Null():
+		return union[0], true
+	}
+	return nil, false
+}
+
+// unionBranchName returns the name of the branch of a union of the schema.
+func (o SchemaOptions) unionBranchName(schema avro.Schema) string {
+	switch s := schema.(type) {
+	case avro.Primitive:
+		if s.LogicalType != "" {
+			return string(s.Type) + "." + string(s.LogicalType)
+		}
+		return string(s.Type)
+	case avro.Reference:
+		return string(s)
+	case avro.Record:
+		return joinName(s.Namespace, s.Name)
+	case avro.Enum:
+		return joinName(s.Namespace, s.Name)
+	case avro.Fixed:
+		return joinName(s.Namespace, s.Name)
+	case avro.Array:
+		return string(avro.ArrayType)
+	case avro.Map:
+		return string(avro.MapType)
+	}
+	return ""
+}
+
+func joinName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// unwrapUnion returns the value of a union value wrapped in a map keyed by the name of its branch.
+func unwrapUnion(value interface{}) interface{} {
+	if m, ok := value.(map[string]interface{}); ok && len(m) == 1 {
+		for _, v := range m {
+			return v
+		}
+	}
+	return value
+}
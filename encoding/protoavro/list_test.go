@@ -0,0 +1,141 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestNonNullListItems(t *testing.T) {
+	opts := SchemaOptions{NonNullListItems: true}
+	schema, err := opts.InferSchema((&examplev1.ExampleList{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	items := make(map[string]avro.Schema, len(record.Fields))
+	for _, field := range record.Fields {
+		// the field itself is still nullable
+		items[field.Name] = nonNullSchema(field.Type).(avro.Array).Items
+	}
+	assert.DeepEqual(t, items["int64_list"], avro.Long())
+	assert.DeepEqual(t, items["string_list"], avro.String())
+	assert.Equal(t, items["enum_list"].(avro.Enum).Name, "Enum")
+	assert.Equal(t, items["nested_list"].(avro.Record).Name, "Nested")
+	assert.DeepEqual(t, items["float_value_list"], avro.Float())
+
+	msg := &examplev1.ExampleList{
+		Int64List:  []int64{1, 2},
+		StringList: []string{"a", "b"},
+		EnumList:   []examplev1.ExampleList_Enum{examplev1.ExampleList_ENUM_VALUE2},
+		NestedList: []*examplev1.ExampleList_Nested{
+			{StringList: []string{"c"}},
+		},
+		FloatValueList: []*wrapperspb.FloatValue{wrapperspb.Float(1.5)},
+	}
+	encoded, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	fields := encoded.(map[string]interface{})["einride.avro.example.v1.ExampleList"].(map[string]interface{})
+	assert.DeepEqual(t, fields["string_list"], map[string]interface{}{"array": []interface{}{"a", "b"}})
+	assert.DeepEqual(t, fields["float_value_list"], map[string]interface{}{"array": []interface{}{float32(1.5)}})
+
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	binary, err := codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+	native, _, err := codec.NativeFromBinary(binary)
+	assert.NilError(t, err)
+	decoded := &examplev1.ExampleList{}
+	assert.NilError(t, opts.decodeJSON(native, decoded))
+	assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+}
+
+func TestNonNullListItems_Default(t *testing.T) {
+	schema, err := InferSchema((&examplev1.ExampleList{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	field := schema.(avro.Union)[1].(avro.Record).Fields[1]
+	assert.Equal(t, field.Name, "string_list")
+	assert.DeepEqual(t, nonNullSchema(field.Type).(avro.Array).Items, avro.Nullable(avro.String()))
+}
+
+func TestNullListItems(t *testing.T) {
+	// the second nested item and the first int64 item are null
+	data := []byte(`{"einride.avro.example.v1.ExampleList":{` +
+		`"int64_list":{"array":[null,{"long":1}]},"string_list":{"array":[]},"enum_list":{"array":[]},` +
+		`"nested_list":{"array":[{"einride.avro.example.v1.ExampleList.Nested":{"string_list":{"array":[]}}},null]},` +
+		`"float_value_list":{"array":[]}}}`)
+	for _, tt := range []struct {
+		name          string
+		nullListItems NullListItems
+		expected      *examplev1.ExampleList
+		errorContains string
+	}{
+		{
+			name:          "zero",
+			nullListItems: NullListItemsZero,
+			expected: &examplev1.ExampleList{
+				Int64List:  []int64{0, 1},
+				NestedList: []*examplev1.ExampleList_Nested{{}, {}},
+			},
+		},
+		{
+			name:          "skip",
+			nullListItems: NullListItemsSkip,
+			expected: &examplev1.ExampleList{
+				Int64List:  []int64{1},
+				NestedList: []*examplev1.ExampleList_Nested{{}},
+			},
+		},
+		{
+			name:          "error",
+			nullListItems: NullListItemsError,
+			errorContains: "null list item",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SchemaOptions{NullListItems: tt.nullListItems}
+			desc := (&examplev1.ExampleList{}).ProtoReflect().Descriptor()
+			schema, err := opts.InferSchema(desc)
+			assert.NilError(t, err)
+			codec := newTestGoavroCodec(t, opts, &examplev1.ExampleList{})
+			native, _, err := codec.NativeFromTextual(data)
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, native)
+			assert.NilError(t, err)
+			binaryDecoder, err := opts.newBinaryDecoder(desc, schema)
+			assert.NilError(t, err)
+			jsonDecoder, err := opts.newAvroJSONDecoder(desc, schema)
+			assert.NilError(t, err)
+			for name, decode := range map[string]func(msg *examplev1.ExampleList) error{
+				"generic": func(msg *examplev1.ExampleList) error {
+					return opts.decodeJSON(native, msg)
+				},
+				"binary": func(msg *examplev1.ExampleList) error {
+					return binaryDecoder.decode(binary, msg.ProtoReflect())
+				},
+				"avro json": func(msg *examplev1.ExampleList) error {
+					return jsonDecoder.decode(bytes.NewReader(data), msg.ProtoReflect())
+				},
+			} {
+				var msg examplev1.ExampleList
+				err := decode(&msg)
+				if tt.errorContains != "" {
+					assert.ErrorContains(t, err, tt.errorContains, name)
+					assert.Assert(t, errors.Is(err, ErrNullListItem), name)
+					continue
+				}
+				assert.NilError(t, err, name)
+				assert.DeepEqual(t, tt.expected, &msg, protocmp.Transform())
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package protoavro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// CompressionLZ4 is the name of the built-in LZ4 codec, which favors speed over ratio for the standalone records
+// compressed with SchemaOptions.RecordCompression. The Avro specification defines no LZ4 codec for Object
+// Container Files, so files compressed with it are only readable by readers that register the same codec.
+const CompressionLZ4 = "lz4"
+
+const (
+	// lz4MinMatch is the length of the shortest match of the LZ4 block format.
+	lz4MinMatch = 4
+	// lz4MaxOffset is the largest distance of a match of the LZ4 block format.
+	lz4MaxOffset = 1<<16 - 1
+	// lz4LastLiterals is the number of bytes at the end of a block that are always literals.
+	lz4LastLiterals = 5
+	// lz4MatchLimit is the number of bytes at the end of a block in which no match may start.
+	lz4MatchLimit = 12
+	// lz4HashLog is the number of bits of the hashes of the table of positions of the compressor.
+	lz4HashLog = 12
+	// lz4MaxRatio bounds the ratio of decompressed to compressed sizes of LZ4 blocks.
+	lz4MaxRatio = 255
+)
+
+var errLZ4Corrupt = errors.New("corrupt lz4 block")
+
+// lz4Compression compresses blocks with the LZ4 block format, prefixed with the length of the uncompressed
+// block as a uvarint.
+type lz4Compression struct{}
+
+func (lz4Compression) Compress(block []byte) ([]byte, error) {
+	compressed := make([]byte, 0, binary.MaxVarintLen64+len(block)+len(block)/lz4MaxRatio+16)
+	compressed = binary.AppendUvarint(compressed, uint64(len(block)))
+	return lz4CompressBlock(compressed, block), nil
+}
+
+func (c lz4Compression) Decompress(block []byte) ([]byte, error) {
+	return c.DecompressLimit(block, int(^uint(0)>>1))
+}
+
+func (lz4Compression) DecompressLimit(block []byte, max int) ([]byte, error) {
+	size, n := binary.Uvarint(block)
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: invalid length", errLZ4Corrupt)
+	}
+	// the length is checked before the block is allocated, so that corrupted lengths allocate nothing
+	if size > uint64(len(block)-n)*lz4MaxRatio {
+		return nil, fmt.Errorf("%w: length %d exceeds the maximum ratio", errLZ4Corrupt, size)
+	}
+	if size > uint64(max) {
+		return nil, ErrLimitExceeded
+	}
+	return lz4DecompressBlock(block[n:], int(size))
+}
+
+// lz4CompressBlock appends the LZ4 block of src to dst, with greedy matching of a hash table of positions.
+func lz4CompressBlock(dst, src []byte) []byte {
+	anchor := 0
+	if len(src) > lz4MatchLimit {
+		table := make([]int32, 1<<lz4HashLog) // positions plus one, zero is empty
+		for i := 0; i < len(src)-lz4MatchLimit; {
+			sequence := binary.LittleEndian.Uint32(src[i:])
+			hash := (sequence * 2654435761) >> (32 - lz4HashLog)
+			candidate := int(table[hash]) - 1
+			table[hash] = int32(i + 1)
+			if candidate < 0 || i-candidate > lz4MaxOffset ||
+				binary.LittleEndian.Uint32(src[candidate:]) != sequence {
+				i++
+				continue
+			}
+			length := lz4MinMatch
+			for i+length < len(src)-lz4LastLiterals && src[candidate+length] == src[i+length] {
+				length++
+			}
+			dst = lz4AppendSequence(dst, src[anchor:i], i-candidate, length)
+			i += length
+			anchor = i
+		}
+	}
+	return lz4AppendSequence(dst, src[anchor:], 0, 0)
+}
+
+// lz4AppendSequence appends a sequence of literals followed by a match to dst. The last sequence of a block
+// has no match, and an offset of zero.
+func lz4AppendSequence(dst, literals []byte, offset, length int) []byte {
+	literalsToken := len(literals)
+	if literalsToken > 15 {
+		literalsToken = 15
+	}
+	matchToken := 0
+	if offset > 0 {
+		matchToken = length - lz4MinMatch
+		if matchToken > 15 {
+			matchToken = 15
+		}
+	}
+	dst = append(dst, byte(literalsToken<<4|matchToken))
+	if literalsToken == 15 {
+		dst = lz4AppendLength(dst, len(literals)-15)
+	}
+	dst = append(dst, literals...)
+	if offset == 0 {
+		return dst
+	}
+	dst = append(dst, byte(offset), byte(offset>>8))
+	if matchToken == 15 {
+		dst = lz4AppendLength(dst, length-lz4MinMatch-15)
+	}
+	return dst
+}
+
+// lz4AppendLength appends the bytes that extend a length of a token to dst.
+func lz4AppendLength(dst []byte, n int) []byte {
+	for ; n >= 255; n -= 255 {
+		dst = append(dst, 255)
+	}
+	return append(dst, byte(n))
+}
+
+// lz4DecompressBlock decompresses the LZ4 block of src, which must decompress to exactly size bytes.
+func lz4DecompressBlock(src []byte, size int) ([]byte, error) {
+	dst := make([]byte, 0, size)
+	for i := 0; i < len(src); {
+		token := src[i]
+		i++
+		literals := int(token >> 4)
+		if literals == 15 {
+			n, read, err := lz4ReadLength(src[i:])
+			if err != nil {
+				return nil, err
+			}
+			literals += n
+			i += read
+		}
+		if literals > len(src)-i || literals > size-len(dst) {
+			return nil, fmt.Errorf("%w: literals out of bounds", errLZ4Corrupt)
+		}
+		dst = append(dst, src[i:i+literals]...)
+		i += literals
+		if i == len(src) {
+			break
+		}
+		if len(src)-i < 2 {
+			return nil, fmt.Errorf("%w: truncated offset", errLZ4Corrupt)
+		}
+		offset := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		if offset == 0 || offset > len(dst) {
+			return nil, fmt.Errorf("%w: offset %d out of bounds", errLZ4Corrupt, offset)
+		}
+		length := int(token&15) + lz4MinMatch
+		if token&15 == 15 {
+			n, read, err := lz4ReadLength(src[i:])
+			if err != nil {
+				return nil, err
+			}
+			length += n
+			i += read
+		}
+		if length > size-len(dst) {
+			return nil, fmt.Errorf("%w: match out of bounds", errLZ4Corrupt)
+		}
+		start := len(dst) - offset
+		if offset >= length {
+			dst = append(dst, dst[start:start+length]...)
+			continue
+		}
+		// overlapping matches repeat the bytes they copy
+		for j := 0; j < length; j++ {
+			dst = append(dst, dst[start+j])
+		}
+	}
+	if len(dst) != size {
+		return nil, fmt.Errorf("%w: decompressed %d bytes instead of %d", errLZ4Corrupt, len(dst), size)
+	}
+	return dst, nil
+}
+
+// lz4ReadLength reads the bytes that extend a length of a token, and returns the length and the number of
+// bytes read.
+func lz4ReadLength(src []byte) (int, int, error) {
+	var n int
+	for i, b := range src {
+		n += int(b)
+		if b != 255 {
+			return n, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%w: truncated length", errLZ4Corrupt)
+}
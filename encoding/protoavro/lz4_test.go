@@ -0,0 +1,74 @@
+package protoavro
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_LZ4Compression(t *testing.T) {
+	random := make([]byte, 1<<12)
+	rand.New(rand.NewSource(1)).Read(random)
+	for _, tt := range []struct {
+		name  string
+		block []byte
+	}{
+		{name: "empty", block: []byte{}},
+		{name: "short", block: []byte("Harry")},
+		{name: "repeated", block: bytes.Repeat([]byte("Harry Potter"), 1000)},
+		{name: "run", block: bytes.Repeat([]byte{'a'}, 1000)},
+		{name: "random", block: random},
+		{name: "long literals", block: append(append([]byte{}, random...), bytes.Repeat([]byte("ab"), 500)...)},
+		{name: "far matches", block: append(append(append([]byte{}, random...), make([]byte, 1<<16)...), random...)},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := lz4Compression{}.Compress(tt.block)
+			assert.NilError(t, err)
+			decompressed, err := lz4Compression{}.Decompress(compressed)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.block, decompressed)
+			decompressed, err = lz4Compression{}.DecompressLimit(compressed, len(tt.block))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.block, decompressed)
+			if len(tt.block) > 0 {
+				_, err = lz4Compression{}.DecompressLimit(compressed, len(tt.block)-1)
+				assert.Assert(t, errors.Is(err, ErrLimitExceeded))
+			}
+		})
+	}
+
+	t.Run("compresses", func(t *testing.T) {
+		block := bytes.Repeat([]byte("Harry Potter"), 1000)
+		compressed, err := lz4Compression{}.Compress(block)
+		assert.NilError(t, err)
+		assert.Assert(t, len(compressed) < len(block)/50, len(compressed))
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		compressed, err := lz4Compression{}.Compress(bytes.Repeat([]byte("Harry Potter"), 100))
+		assert.NilError(t, err)
+		for _, tt := range []struct {
+			name  string
+			block []byte
+		}{
+			{name: "empty", block: nil},
+			{name: "truncated", block: compressed[:len(compressed)-1]},
+			{name: "length exceeds ratio", block: []byte{0xff, 0xff, 0xff, 0xff, 0x0f, 0x00}},
+			{name: "length mismatch", block: []byte{2, 0x10, 'a'}},
+			{name: "offset out of bounds", block: []byte{8, 0x10, 'a', 2, 0, 0x00}},
+			{name: "zero offset", block: []byte{8, 0x10, 'a', 0, 0, 0x00}},
+			{name: "truncated offset", block: []byte{8, 0x10, 'a', 1}},
+			{name: "truncated length", block: []byte{20, 0xf0, 255}},
+		} {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := lz4Compression{}.Decompress(tt.block)
+				assert.Assert(t, errors.Is(err, errLZ4Corrupt), err)
+			})
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package protoavro
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 
@@ -8,8 +9,42 @@ import (
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-func (s schemaInferrer) inferMapSchema(field protoreflect.FieldDescriptor, recursiveIndex int) (avro.Schema, error) {
-	fieldKind, err := s.inferFieldKind(field, recursiveIndex)
+// NullMapValues is how null values of map entries are decoded, since protobuf maps can not hold null values.
+type NullMapValues int
+
+const (
+	// NullMapValuesZero decodes null values as the zero value of the value type, such as empty messages.
+	NullMapValuesZero NullMapValues = iota
+	// NullMapValuesSkip skips entries with null values, so that decoded maps only hold the entries with values.
+	NullMapValuesSkip
+	// NullMapValuesError fails decoding of null values with ErrNullMapValue.
+	NullMapValuesError
+)
+
+// ErrNullMapValue is returned when a null value of a map entry is decoded with NullMapValuesError.
+var ErrNullMapValue = errors.New("null map value")
+
+// nullMapValue returns the value of a map entry with a null value of the map field, and false if the entry is
+// skipped.
+func (o SchemaOptions) nullMapValue(
+	mp protoreflect.Map,
+	field protoreflect.FieldDescriptor,
+) (protoreflect.Value, bool, error) {
+	switch o.NullMapValues {
+	case NullMapValuesSkip:
+		return protoreflect.Value{}, false, nil
+	case NullMapValuesError:
+		return protoreflect.Value{}, false, fmt.Errorf("field '%s': %w", field.Name(), ErrNullMapValue)
+	}
+	return mp.NewValue(), true, nil
+}
+
+func (s schemaInferrer) inferMapSchema(
+	field protoreflect.FieldDescriptor,
+	recursiveIndex int,
+	mask fieldMaskTree,
+) (avro.Schema, error) {
+	fieldKind, err := s.inferFieldKind(field, recursiveIndex, mask.mapEntry())
 	if err != nil {
 		return nil, err
 	}
@@ -23,6 +58,7 @@ func (o *SchemaOptions) encodeMap(
 	field protoreflect.FieldDescriptor,
 	m protoreflect.Map,
 	recursiveIndex int,
+	mask fieldMaskTree,
 ) (interface{}, error) {
 	// m.Range ranges over the entries in unspecified order.
 	// To aid in testing, the keys are sorted. This is similar
@@ -32,22 +68,19 @@ func (o *SchemaOptions) encodeMap(
 		keys = append(keys, key)
 		return true
 	})
-	sort.Slice(keys, func(i, j int) bool {
-		// key.String will return a string for any key type (not just strings)
-		// for example 1 would be "1"
-		return keys[i].String() < keys[j].String()
-	})
+	o.sortMapKeys(keys)
 
 	entries := make([]interface{}, 0, m.Len())
 	valueField := field.MapValue()
 	keyField := field.MapKey()
 	for _, key := range keys {
 		value := m.Get(key)
-		keyValue, err := o.fieldKindJSON(keyField, key.Value(), recursiveIndex)
+		keyValue, err := o.fieldKindJSON(keyField, key.Value(), recursiveIndex, nil)
 		if err != nil {
 			return nil, err
 		}
-		valueValue, err := o.fieldKindJSON(valueField, value, recursiveIndex)
+		// values are nested in the records of map entries
+		valueValue, err := o.fieldKindJSON(valueField, value, recursiveIndex+1, mask)
 		if err != nil {
 			return nil, err
 		}
@@ -59,37 +92,102 @@ func (o *SchemaOptions) encodeMap(
 	return o.unionValue("array", entries), nil
 }
 
-func (o SchemaOptions) decodeMap(data interface{}, f protoreflect.FieldDescriptor, mp protoreflect.Map) error {
+// sortMapKeys sorts the keys of a map by their string, or in the natural order of their kind with Deterministic.
+func (o SchemaOptions) sortMapKeys(keys []protoreflect.MapKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if o.Deterministic {
+			return lessMapKey(keys[i], keys[j])
+		}
+		// key.String will return a string for any key type (not just strings)
+		// for example 1 would be "1"
+		return keys[i].String() < keys[j].String()
+	})
+}
+
+// lessMapKey orders integer keys numerically, false before true, and strings bytewise.
+func lessMapKey(a, b protoreflect.MapKey) bool {
+	switch v := a.Interface().(type) {
+	case bool:
+		return !v && b.Bool()
+	case int32, int64:
+		return a.Int() < b.Int()
+	case uint32, uint64:
+		return a.Uint() < b.Uint()
+	}
+	return a.String() < b.String()
+}
+
+func (o SchemaOptions) decodeMap(
+	data interface{},
+	f protoreflect.FieldDescriptor,
+	mp protoreflect.Map,
+	mask fieldMaskTree,
+) error {
 	list, err := decodeListLike(data, "array")
 	if err != nil {
 		return err
 	}
-	return o.decodeMapEntries(list, f, mp)
+	return o.decodeMapEntries(list, f, mp, mask)
 }
 
-func (o SchemaOptions) decodeMapEntries(data []interface{}, f protoreflect.FieldDescriptor, mp protoreflect.Map) error {
-	for _, el := range data {
-		entry, ok := el.(map[string]interface{})
-		if !ok {
-			return fmt.Errorf("expected map entry, got %T for '%s'", el, f.Name())
-		}
-		keyData, ok := entry["key"]
-		if !ok {
-			return fmt.Errorf("missing 'key' in map entry for '%s'", f.Name())
-		}
-		valueData, ok := entry["value"]
-		if !ok {
-			return fmt.Errorf("missing 'value' in map entry for '%s'", f.Name())
+func (o SchemaOptions) decodeMapEntries(
+	data []interface{},
+	f protoreflect.FieldDescriptor,
+	mp protoreflect.Map,
+	mask fieldMaskTree,
+) error {
+	errs := fieldErrors{}
+	for i, el := range data {
+		if err := o.decodeMapEntry(el, f, mp, mask); err != nil {
+			if !o.CollectErrors {
+				return err
+			}
+			errs.add("", fmt.Sprintf("[%d]", i), err)
 		}
-		keyValue, err := o.decodeFieldKind(keyData, protoreflect.Value{}, f.MapKey())
+	}
+	return errs.err()
+}
+
+// decodeMapEntry decodes the record of a map entry into the map.
+func (o SchemaOptions) decodeMapEntry(
+	el interface{},
+	f protoreflect.FieldDescriptor,
+	mp protoreflect.Map,
+	mask fieldMaskTree,
+) error {
+	entry, ok := el.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected map entry, got %T for '%s'", el, f.Name())
+	}
+	keyData, ok := entry["key"]
+	if !ok {
+		return fmt.Errorf("missing 'key' in map entry for '%s'", f.Name())
+	}
+	valueData, ok := entry["value"]
+	if !ok {
+		return fmt.Errorf("missing 'value' in map entry for '%s'", f.Name())
+	}
+	if keyData == nil {
+		return fmt.Errorf("missing 'key' in map entry for '%s'", f.Name())
+	}
+	keyValue, err := o.decodeFieldKind(keyData, protoreflect.Value{}, f.MapKey(), nil)
+	if err != nil {
+		return err
+	}
+	if valueData == nil {
+		value, ok, err := o.nullMapValue(mp, f)
 		if err != nil {
 			return err
 		}
-		valueValue, err := o.decodeFieldKind(valueData, mp.NewValue(), f.MapValue())
-		if err != nil {
-			return err
+		if ok {
+			mp.Set(keyValue.MapKey(), value)
 		}
-		mp.Set(keyValue.MapKey(), valueValue)
+		return nil
+	}
+	valueValue, err := o.decodeFieldKind(valueData, mp.NewValue(), f.MapValue(), mask)
+	if err != nil {
+		return err
 	}
+	mp.Set(keyValue.MapKey(), valueValue)
 	return nil
 }
@@ -1,6 +1,8 @@
 package protoavro
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 
 	"go.einride.tech/protobuf-avro/avro"
@@ -8,7 +10,10 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
 )
 
 func Test_MapSchema(t *testing.T) {
@@ -179,6 +184,7 @@ func Test_MapSchema(t *testing.T) {
 			schema, err := tt.opts.newSchemaInferrer().inferMapSchema(
 				tt.msg.ProtoReflect().Descriptor().Fields().ByName(tt.fieldName),
 				0,
+				nil,
 			)
 			assert.NilError(t, err)
 			assert.DeepEqual(t, schema, tt.expected)
@@ -248,12 +254,129 @@ func Test_MapEncode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "int32 key deterministic",
+			opts: SchemaOptions{Deterministic: true},
+			msg: &examplev1.ExampleMap{
+				Int32ToString: map[int32]string{
+					10: "a",
+					-1: "b",
+					2:  "c",
+				},
+			},
+			fieldName: "int32_to_string",
+			expected: map[string]interface{}{
+				"array": []interface{}{
+					map[string]interface{}{
+						"key":   map[string]interface{}{"int": int32(-1)},
+						"value": map[string]interface{}{"string": "b"},
+					},
+					map[string]interface{}{
+						"key":   map[string]interface{}{"int": int32(2)},
+						"value": map[string]interface{}{"string": "c"},
+					},
+					map[string]interface{}{
+						"key":   map[string]interface{}{"int": int32(10)},
+						"value": map[string]interface{}{"string": "a"},
+					},
+				},
+			},
+		},
+		{
+			name: "enum value",
+			msg: &examplev1.ExampleMap{
+				StringToEnum: map[string]examplev1.ExampleMap_Enum{
+					"1": examplev1.ExampleMap_ENUM_VALUE1,
+					"2": 99,
+				},
+			},
+			fieldName: "string_to_enum",
+			// values that are not values of the enum are encoded as the zero value
+			expected: map[string]interface{}{
+				"array": []interface{}{
+					map[string]interface{}{
+						"key":   map[string]interface{}{"string": "1"},
+						"value": map[string]interface{}{"einride.avro.example.v1.ExampleMap.Enum": "ENUM_VALUE1"},
+					},
+					map[string]interface{}{
+						"key":   map[string]interface{}{"string": "2"},
+						"value": map[string]interface{}{"einride.avro.example.v1.ExampleMap.Enum": "ENUM_UNSPECIFIED"},
+					},
+				},
+			},
+		},
+		{
+			name: "message value",
+			msg: &examplev1.ExampleMap{
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{
+					"1": {StringToString: map[string]string{"a": "b"}},
+				},
+			},
+			fieldName: "string_to_nested",
+			expected: map[string]interface{}{
+				"array": []interface{}{
+					map[string]interface{}{
+						"key": map[string]interface{}{"string": "1"},
+						"value": map[string]interface{}{
+							"einride.avro.example.v1.ExampleMap.Nested": map[string]interface{}{
+								"string_to_string": map[string]interface{}{
+									"array": []interface{}{
+										map[string]interface{}{
+											"key":   map[string]interface{}{"string": "a"},
+											"value": map[string]interface{}{"string": "b"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "wrapper value",
+			msg: &examplev1.ExampleMap{
+				StringToFloatValue: map[string]*wrapperspb.FloatValue{"1": wrapperspb.Float(1.5)},
+			},
+			fieldName: "string_to_float_value",
+			expected: map[string]interface{}{
+				"array": []interface{}{
+					map[string]interface{}{
+						"key":   map[string]interface{}{"string": "1"},
+						"value": map[string]interface{}{"float": float32(1.5)},
+					},
+				},
+			},
+		},
+		{
+			name: "bool key deterministic",
+			opts: SchemaOptions{Deterministic: true},
+			msg: &examplev1.ExampleMap{
+				BoolToString: map[bool]string{
+					true:  "a",
+					false: "b",
+				},
+			},
+			fieldName: "bool_to_string",
+			expected: map[string]interface{}{
+				"array": []interface{}{
+					map[string]interface{}{
+						"key":   map[string]interface{}{"boolean": false},
+						"value": map[string]interface{}{"string": "b"},
+					},
+					map[string]interface{}{
+						"key":   map[string]interface{}{"boolean": true},
+						"value": map[string]interface{}{"string": "a"},
+					},
+				},
+			},
+		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			desc := tt.msg.ProtoReflect().Descriptor().Fields().ByName(tt.fieldName)
 			val := tt.msg.ProtoReflect().Get(desc)
-			got, err := tt.opts.encodeMap(desc, val.Map(), 0)
+			got, err := tt.opts.encodeMap(desc, val.Map(), 0, nil)
 			assert.NilError(t, err)
 			assert.DeepEqual(t, got, tt.expected)
 		})
@@ -287,6 +410,94 @@ func Test_MapDecode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "enum value",
+			msg:       &examplev1.ExampleMap{},
+			fieldName: "string_to_enum",
+			data: []interface{}{
+				map[string]interface{}{
+					"key":   "1",
+					"value": map[string]interface{}{"einride.avro.example.v1.ExampleMap.Enum": "ENUM_VALUE2"},
+				},
+				map[string]interface{}{"key": "2", "value": nil},
+			},
+			expected: &examplev1.ExampleMap{
+				StringToEnum: map[string]examplev1.ExampleMap_Enum{
+					"1": examplev1.ExampleMap_ENUM_VALUE2,
+					"2": examplev1.ExampleMap_ENUM_UNSPECIFIED,
+				},
+			},
+		},
+		{
+			name:      "stripped enum value",
+			msg:       &examplev1.ExampleMap{},
+			opts:      SchemaOptions{StripEnumPrefix: true},
+			fieldName: "string_to_enum",
+			data: []interface{}{
+				map[string]interface{}{"key": "1", "value": "VALUE2"},
+			},
+			expected: &examplev1.ExampleMap{
+				StringToEnum: map[string]examplev1.ExampleMap_Enum{"1": examplev1.ExampleMap_ENUM_VALUE2},
+			},
+		},
+		{
+			name:      "message value",
+			msg:       &examplev1.ExampleMap{},
+			fieldName: "string_to_nested",
+			data: []interface{}{
+				map[string]interface{}{
+					"key": "1",
+					"value": map[string]interface{}{
+						"einride.avro.example.v1.ExampleMap.Nested": map[string]interface{}{
+							"string_to_string": []interface{}{map[string]interface{}{"key": "a", "value": "b"}},
+						},
+					},
+				},
+				map[string]interface{}{"key": "2", "value": nil},
+			},
+			expected: &examplev1.ExampleMap{
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{
+					"1": {StringToString: map[string]string{"a": "b"}},
+					"2": {},
+				},
+			},
+		},
+		{
+			name:      "wrapper value",
+			msg:       &examplev1.ExampleMap{},
+			fieldName: "string_to_float_value",
+			data: []interface{}{
+				map[string]interface{}{"key": "1", "value": map[string]interface{}{"float": float32(1.5)}},
+				map[string]interface{}{"key": "2", "value": nil},
+			},
+			expected: &examplev1.ExampleMap{
+				StringToFloatValue: map[string]*wrapperspb.FloatValue{
+					"1": wrapperspb.Float(1.5),
+					"2": {},
+				},
+			},
+		},
+		{
+			name:      "skip null values",
+			msg:       &examplev1.ExampleMap{},
+			opts:      SchemaOptions{NullMapValues: NullMapValuesSkip},
+			fieldName: "string_to_string",
+			data: []interface{}{
+				map[string]interface{}{"key": "1", "value": "a"},
+				map[string]interface{}{"key": "2", "value": nil},
+			},
+			expected: &examplev1.ExampleMap{StringToString: map[string]string{"1": "a"}},
+		},
+		{
+			name:      "null value error",
+			msg:       &examplev1.ExampleMap{},
+			opts:      SchemaOptions{NullMapValues: NullMapValuesError},
+			fieldName: "string_to_string",
+			data: []interface{}{
+				map[string]interface{}{"key": "1", "value": nil},
+			},
+			expectErr: "field 'string_to_string': null map value",
+		},
 		{
 			name:      "invalid type",
 			msg:       &examplev1.ExampleMap{},
@@ -312,6 +523,15 @@ func Test_MapDecode(t *testing.T) {
 			},
 			expectErr: "missing 'key' in map entry for 'string_to_string'",
 		},
+		{
+			name:      "null key",
+			msg:       &examplev1.ExampleMap{},
+			fieldName: "string_to_string",
+			data: []interface{}{
+				map[string]interface{}{"key": nil, "value": "a"},
+			},
+			expectErr: "missing 'key' in map entry for 'string_to_string'",
+		},
 		{
 			name:      "missing value field",
 			msg:       &examplev1.ExampleMap{},
@@ -326,7 +546,7 @@ func Test_MapDecode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			desc := tt.msg.ProtoReflect().Descriptor().Fields().ByName(tt.fieldName)
 			val := tt.msg.ProtoReflect().Mutable(desc)
-			err := tt.opts.decodeMap(tt.data, desc, val.Map())
+			err := tt.opts.decodeMap(tt.data, desc, val.Map(), nil)
 			if tt.expectErr != "" {
 				assert.ErrorContains(t, err, tt.expectErr)
 				return
@@ -336,3 +556,106 @@ func Test_MapDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestNullMapValues(t *testing.T) {
+	// the values of the second string entry, of the nested entry and of the enum entry are null
+	data := []byte(`{"einride.avro.example.v1.ExampleMap":{` +
+		`"string_to_string":{"array":[{"key":{"string":"a"},"value":{"string":"b"}},` +
+		`{"key":{"string":"c"},"value":null}]},` +
+		`"string_to_nested":{"array":[{"key":{"string":"a"},"value":null}]},` +
+		`"string_to_enum":{"array":[{"key":{"string":"a"},"value":null}]},` +
+		`"int32_to_string":{"array":[]},"int64_to_string":{"array":[]},"uint32_to_string":{"array":[]},` +
+		`"bool_to_string":{"array":[]},"string_to_float_value":{"array":[]}}}`)
+	for _, tt := range []struct {
+		name          string
+		nullMapValues NullMapValues
+		expected      *examplev1.ExampleMap
+		errorContains string
+	}{
+		{
+			name:          "zero",
+			nullMapValues: NullMapValuesZero,
+			expected: &examplev1.ExampleMap{
+				StringToString: map[string]string{"a": "b", "c": ""},
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{"a": {}},
+				StringToEnum:   map[string]examplev1.ExampleMap_Enum{"a": examplev1.ExampleMap_ENUM_UNSPECIFIED},
+			},
+		},
+		{
+			name:          "skip",
+			nullMapValues: NullMapValuesSkip,
+			expected: &examplev1.ExampleMap{
+				StringToString: map[string]string{"a": "b"},
+			},
+		},
+		{
+			name:          "error",
+			nullMapValues: NullMapValuesError,
+			errorContains: "null map value",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SchemaOptions{NullMapValues: tt.nullMapValues}
+			desc := (&examplev1.ExampleMap{}).ProtoReflect().Descriptor()
+			schema, err := opts.InferSchema(desc)
+			assert.NilError(t, err)
+			codec := newTestGoavroCodec(t, opts, &examplev1.ExampleMap{})
+			native, _, err := codec.NativeFromTextual(data)
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, native)
+			assert.NilError(t, err)
+			binaryDecoder, err := opts.newBinaryDecoder(desc, schema)
+			assert.NilError(t, err)
+			jsonDecoder, err := opts.newAvroJSONDecoder(desc, schema)
+			assert.NilError(t, err)
+			for name, decode := range map[string]func(msg *examplev1.ExampleMap) error{
+				"generic": func(msg *examplev1.ExampleMap) error {
+					return opts.decodeJSON(native, msg)
+				},
+				"binary": func(msg *examplev1.ExampleMap) error {
+					return binaryDecoder.decode(binary, msg.ProtoReflect())
+				},
+				"avro json": func(msg *examplev1.ExampleMap) error {
+					return jsonDecoder.decode(bytes.NewReader(data), msg.ProtoReflect())
+				},
+			} {
+				var msg examplev1.ExampleMap
+				err := decode(&msg)
+				if tt.errorContains != "" {
+					assert.ErrorContains(t, err, tt.errorContains, name)
+					assert.Assert(t, errors.Is(err, ErrNullMapValue), name)
+					continue
+				}
+				assert.NilError(t, err, name)
+				assert.DeepEqual(t, tt.expected, &msg, protocmp.Transform())
+			}
+		})
+	}
+}
+
+func Test_Deterministic(t *testing.T) {
+	opts := SchemaOptions{Deterministic: true}
+	msg := &examplev1.ExampleMap{
+		Int64ToString: map[int64]string{100: "a", 9: "b", -20: "c", 0: "d"},
+	}
+	direct, err := opts.MarshalAvroJSON(msg)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Contains(string(direct), `[{"key":{"long":-20},"value":{"string":"c"}},{"key":{"long":0},`))
+	// Avro JSON encoded through goavro has its keys sorted
+	opts.ValidateEncoding = true
+	first, err := opts.MarshalAvroJSON(msg)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Contains(string(first), `{"bool_to_string":{"array":[]},"int32_to_string":{"array":[]},`))
+	for i := 0; i < 10; i++ {
+		encoded, err := opts.MarshalAvroJSON(msg)
+		assert.NilError(t, err)
+		assert.Equal(t, string(first), string(encoded))
+	}
+
+	value, err := structpb.NewStruct(map[string]interface{}{"b": []interface{}{1, "x"}, "a": map[string]interface{}{}})
+	assert.NilError(t, err)
+	encoded, err := opts.encodeStruct(value)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]interface{}{"string": `{"a":{},"b":[1,"x"]}`}, encoded)
+}
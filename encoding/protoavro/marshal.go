@@ -1,9 +1,11 @@
 package protoavro
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/linkedin/goavro/v2"
 	"google.golang.org/protobuf/proto"
@@ -19,35 +21,135 @@ func NewMarshaler(descriptor protoreflect.MessageDescriptor, writer io.Writer) (
 // NewMarshaler returns a new marshaler that writes protobuf messages to writer in
 // Avro binary format.
 func (o SchemaOptions) NewMarshaler(descriptor protoreflect.MessageDescriptor, writer io.Writer) (*Marshaler, error) {
+	codec, err := o.ocfCodec(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	var counter *countingWriter
+	if o.Instrumentation != nil {
+		counter = &countingWriter{w: writer}
+		writer = counter
+	}
+	metadata, err := o.ocfMetadata(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	if metadata, err = o.withDictionaryMetadata(descriptor, metadata); err != nil {
+		return nil, err
+	}
+	w, err := newOCFWriter(writer, codec, o.Compression, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("new ocf writer: %w", err)
+	}
+	return o.newMarshaler(descriptor, w, counter), nil
+}
+
+// NewAppendingMarshaler returns a new marshaler that appends protobuf messages to the Object Container File
+// in file, with the compression codec and the sync marker of the file. An empty file is written like by
+// NewMarshaler. The schema of the file must be equal, in Avro canonical form, to the schema inferred for
+// the message.
+func (o SchemaOptions) NewAppendingMarshaler(
+	descriptor protoreflect.MessageDescriptor,
+	file io.ReadWriteSeeker,
+) (*Marshaler, error) {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("new appending marshaler: %w", err)
+	}
+	if size == 0 {
+		return o.NewMarshaler(descriptor, file)
+	}
+	codec, err := o.ocfCodec(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	w, err := appendOCFWriter(file)
+	if err != nil {
+		return nil, fmt.Errorf("new appending marshaler: %w", err)
+	}
+	if w.header.codec.CanonicalSchema() != codec.CanonicalSchema() {
+		return nil, fmt.Errorf(
+			"new appending marshaler: schema of file does not match schema of message %s", descriptor.FullName(),
+		)
+	}
+	var counter *countingWriter
+	if o.Instrumentation != nil {
+		counter = &countingWriter{w: w.w}
+		w.w = counter
+	}
+	return o.newMarshaler(descriptor, w, counter), nil
+}
+
+// ocfCodec returns the goavro codec of the schema inferred for the message, with the dictionary fields when
+// DictionaryFieldFunc selects fields, and with the checksum field when RecordChecksums is set.
+func (o SchemaOptions) ocfCodec(descriptor protoreflect.MessageDescriptor) (*goavro.Codec, error) {
 	schema, err := o.InferSchema(descriptor)
 	if err != nil {
 		return nil, fmt.Errorf("infer schema: %w", err)
 	}
+	if fields := o.dictionaryFields(descriptor); len(fields) > 0 {
+		if schema, err = withDictionaryFields(schema, fields); err != nil {
+			return nil, err
+		}
+	}
+	if o.RecordChecksums {
+		if schema, err = withChecksumField(schema); err != nil {
+			return nil, err
+		}
+	}
 	schemaBytes, err := json.Marshal(schema)
 	if err != nil {
 		return nil, fmt.Errorf("json marshal schema: %w", err)
 	}
-	w, err := goavro.NewOCFWriter(goavro.OCFConfig{
-		W:      writer,
-		Schema: string(schemaBytes),
-	})
+	codec, err := goavro.NewCodec(string(schemaBytes))
 	if err != nil {
-		return nil, fmt.Errorf("new ocf writer: %w", err)
+		return nil, fmt.Errorf("new codec: %w", err)
 	}
-	return &Marshaler{w: w, desc: descriptor, opts: o}, nil
+	return codec, nil
+}
+
+func (o SchemaOptions) newMarshaler(
+	descriptor protoreflect.MessageDescriptor,
+	w *ocfWriter,
+	counter *countingWriter,
+) *Marshaler {
+	m := &Marshaler{w: w, desc: descriptor, opts: o.clone(), counter: counter}
+	if o.CollectStats {
+		m.stats = newStatsCollector(o)
+	}
+	return m
 }
 
 // Marshaler encodes and writes Avro binary encoded messages.
+// A Marshaler is safe for concurrent use. Messages are encoded concurrently, and written one call at a time.
 type Marshaler struct {
-	opts SchemaOptions
-	desc protoreflect.MessageDescriptor
-	w    *goavro.OCFWriter
+	opts  SchemaOptions
+	desc  protoreflect.MessageDescriptor
+	w     *ocfWriter
+	stats *statsCollector
+	// counter counts the bytes written, when instrumentation is enabled.
+	counter *countingWriter
+	// reported is the number of written bytes reported to the instrumentation.
+	reported int64
+	// mu guards the writer, the statistics and the reported bytes.
+	mu sync.Mutex
 }
 
 // Marshal encodes and writes messages to the writer.
 func (m *Marshaler) Marshal(messages ...proto.Message) error {
+	return m.MarshalContext(context.Background(), messages...)
+}
+
+// MarshalContext encodes and writes messages to the writer.
+// No messages are written if the context is done before all messages are encoded.
+func (m *Marshaler) MarshalContext(ctx context.Context, messages ...proto.Message) (err error) {
+	ctx, end := m.opts.startSpan(ctx, "protoavro.Marshal")
+	defer func() { end(err) }()
 	data := make([]interface{}, 0, len(messages))
 	for _, message := range messages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		a := message.ProtoReflect().Descriptor().FullName()
 		b := m.desc.FullName()
 		if a != b {
@@ -59,12 +161,39 @@ func (m *Marshaler) Marshal(messages ...proto.Message) error {
 		}
 		data = append(data, m)
 	}
+	return m.write(ctx, messages, data)
+}
+
+// write writes the encoded messages to the writer, and collects their statistics.
+func (m *Marshaler) write(ctx context.Context, messages []proto.Message, data []interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if err := m.w.Append(data); err != nil {
 		return fmt.Errorf("append: %w", err)
 	}
+	m.opts.recordsEncoded(ctx, len(messages))
+	m.bytesWritten(ctx)
+	m.opts.FlightRecorder.record(m.w.header.datumCodec(), messages, data)
+	if m.stats != nil {
+		mask := newFieldMaskTree(m.opts.SchemaMask)
+		for _, message := range messages {
+			m.stats.collect(message.ProtoReflect(), mask)
+		}
+	}
 	return nil
 }
 
+// Flush ends the current batch of messages, and returns the column statistics
+// collected for the batch. The returned statistics are nil unless SchemaOptions.CollectStats is set.
+func (m *Marshaler) Flush() (*Stats, error) {
+	if m.stats == nil {
+		return nil, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats.flush(), nil
+}
+
 // Encode encodes the message.
 func (o SchemaOptions) Encode(message proto.Message) (interface{}, error) {
 	encJSON, err := o.encodeJSON(message)
@@ -82,7 +211,8 @@ func (m *Marshaler) Append(messages interface{}) error {
 		// If messages is not a slice, make it a slice.
 		data = append(data, messages)
 	}
-
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if err := m.w.Append(data); err != nil {
 		return fmt.Errorf("append: %w", err)
 	}
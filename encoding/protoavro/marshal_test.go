@@ -2,6 +2,8 @@ package protoavro_test
 
 import (
 	"bytes"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -53,6 +55,55 @@ func Test_MarshalUnmarshal(t *testing.T) {
 	assert.DeepEqual(t, msgs, got, protocmp.Transform())
 }
 
+func Test_Marshaler_Concurrent(t *testing.T) {
+	var b bytes.Buffer
+	marshaler, err := protoavro.SchemaOptions{CollectStats: true}.NewMarshaler(
+		(&library.Book{}).ProtoReflect().Descriptor(),
+		&b,
+	)
+	assert.NilError(t, err)
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				msg := &library.Book{Name: fmt.Sprintf("shelves/%d/books/%d", i, j)}
+				if err := marshaler.Marshal(msg); err != nil {
+					errs <- err
+					return
+				}
+				if err := marshaler.MarshalBatch([]proto.Message{msg}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NilError(t, err)
+	}
+	stats, err := marshaler.Flush()
+	assert.NilError(t, err)
+	assert.Equal(t, int64(8*50*2), stats.Records)
+
+	unmarshaler, err := protoavro.NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	names := make(map[string]int)
+	for unmarshaler.Scan() {
+		var msg library.Book
+		assert.NilError(t, unmarshaler.Unmarshal(&msg))
+		names[msg.GetName()]++
+	}
+	assert.Equal(t, 8*50, len(names))
+	for name, count := range names {
+		assert.Equal(t, 2, count, name)
+	}
+}
+
 func Test_MarshalSymmetric(t *testing.T) {
 	// when `goavro` decodes a file, it will not read back exactly what was
 	// written. For example timestamps are returned as `time.Time`. These
@@ -0,0 +1,153 @@
+package protoavro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// disambiguatedNames caches the names of the types reachable from a message, when disambiguating names.
+// The cached maps are shared by concurrent inferences, encoders and decoders, and must never be modified.
+var disambiguatedNames descriptorCache[namesKey, map[protoreflect.FullName]string]
+
+// namesKey is the key of the names of the types reachable from a message, with the namespace options.
+type namesKey struct {
+	root                protoreflect.MessageDescriptor
+	stripNamespaces     bool
+	trimNamespacePrefix string
+}
+
+// avroName returns the Avro full name of a message or enum.
+// With the default namespaces, the Avro full name is the full name of the descriptor, which is returned
+// as is instead of being concatenated from the namespace and the name on every call.
+func (o SchemaOptions) avroName(desc protoreflect.Descriptor) string {
+	if o.usesFullNames(desc) {
+		return string(desc.FullName())
+	}
+	name, ns := o.schemaName(desc)
+	if ns == "" {
+		return name
+	}
+	return ns + "." + name
+}
+
+// usesFullNames returns true when the Avro full name of a message or enum is its protobuf full name.
+func (o SchemaOptions) usesFullNames(desc protoreflect.Descriptor) bool {
+	if o.StripNamespaces || o.NamespaceFunc != nil || o.TrimNamespacePrefix != "" {
+		return false
+	}
+	if _, ok := o.names[desc.FullName()]; ok {
+		return false
+	}
+	// types outside of packages and messages are namespaced by their own name, see namespace
+	return desc.FullName().Parent() != ""
+}
+
+// schemaName returns the Avro name and namespace of a message or enum.
+func (o SchemaOptions) schemaName(desc protoreflect.Descriptor) (string, string) {
+	if name, ok := o.names[desc.FullName()]; ok {
+		return name, o.avroNamespace(desc)
+	}
+	return string(desc.Name()), o.avroNamespace(desc)
+}
+
+// avroNamespace returns the Avro namespace of a message or enum.
+func (o SchemaOptions) avroNamespace(desc protoreflect.Descriptor) string {
+	switch {
+	case o.StripNamespaces:
+		return ""
+	case o.NamespaceFunc != nil:
+		return o.NamespaceFunc(desc)
+	case o.TrimNamespacePrefix != "":
+		ns := namespace(desc)
+		prefix := strings.TrimSuffix(o.TrimNamespacePrefix, ".")
+		if ns == prefix {
+			return ""
+		}
+		return strings.TrimPrefix(ns, prefix+".")
+	}
+	return namespace(desc)
+}
+
+// withNames returns the options with the names of disambiguated types reachable from the root messages.
+func (o SchemaOptions) withNames(roots ...protoreflect.MessageDescriptor) SchemaOptions {
+	if !o.DisambiguateNames || o.names != nil || len(roots) == 0 {
+		return o
+	}
+	// names are not cached with a namespace function, which can not be compared
+	cache := len(roots) == 1 && o.NamespaceFunc == nil
+	key := namesKey{root: roots[0], stripNamespaces: o.StripNamespaces, trimNamespacePrefix: o.TrimNamespacePrefix}
+	if cache {
+		if names, ok := disambiguatedNames.load(key); ok {
+			o.names = names
+			return o
+		}
+	}
+	o.names = o.disambiguateNames(roots)
+	if cache {
+		o.names = disambiguatedNames.loadOrStore(key, o.names)
+	}
+	return o
+}
+
+// disambiguateNames returns the disambiguated names of the messages and enums reachable from the root messages
+// that share an Avro full name. Disambiguated names are the full protobuf name of the type, with dots replaced
+// by underscores.
+func (o SchemaOptions) disambiguateNames(roots []protoreflect.MessageDescriptor) map[protoreflect.FullName]string {
+	types := make(map[string]map[protoreflect.FullName]struct{})
+	add := func(desc protoreflect.Descriptor) bool {
+		name := o.avroName(desc)
+		if _, ok := types[name][desc.FullName()]; ok {
+			return false
+		}
+		if types[name] == nil {
+			types[name] = make(map[protoreflect.FullName]struct{})
+		}
+		types[name][desc.FullName()] = struct{}{}
+		return true
+	}
+	var walk func(message protoreflect.MessageDescriptor)
+	walk = func(message protoreflect.MessageDescriptor) {
+		if _, ok := lookupMessageCodec(message.FullName()); ok || o.isWKT(message.FullName()) {
+			return
+		}
+		if !add(message) {
+			return
+		}
+		for i := 0; i < message.Fields().Len(); i++ {
+			field := message.Fields().Get(i)
+			switch {
+			case field.Enum() != nil:
+				add(field.Enum())
+			case field.Message() != nil:
+				walk(field.Message())
+			}
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+	names := make(map[protoreflect.FullName]string)
+	for _, fullNames := range types {
+		if len(fullNames) < 2 {
+			continue
+		}
+		for fullName := range fullNames {
+			names[fullName] = strings.ReplaceAll(string(fullName), ".", "_")
+		}
+	}
+	return names
+}
+
+// claimName records the Avro name of a named type, and returns an error if the name is used by another type.
+func (s schemaInferrer) claimName(name string, fullName protoreflect.FullName) error {
+	if claimed, ok := s.names[name]; ok && claimed != fullName {
+		conflicting := []string{string(claimed), string(fullName)}
+		sort.Strings(conflicting)
+		return fmt.Errorf("avro name collision: %s is the name of both %s", name, strings.Join(conflicting, " and "))
+	}
+	s.names[name] = fullName
+	return nil
+}
@@ -0,0 +1,241 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+// collidingEvents returns a message with fields of two messages named Event, in different packages.
+func collidingEvents(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	var files protoregistry.Files
+	for _, pkg := range []string{"a.v1", "b.v1"} {
+		file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+			Name:    proto.String(pkg + "/event.proto"),
+			Package: proto.String(pkg),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{
+				{
+					Name: proto.String("Event"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						{
+							Name:     proto.String("id"),
+							Number:   proto.Int32(1),
+							Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							JsonName: proto.String("id"),
+						},
+					},
+				},
+			},
+		}, &files)
+		assert.NilError(t, err)
+		assert.NilError(t, files.RegisterFile(file))
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("c/v1/batch.proto"),
+		Package:    proto.String("c.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"a.v1/event.proto", "b.v1/event.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Batch"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("a"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".a.v1.Event"),
+						JsonName: proto.String("a"),
+					},
+					{
+						Name:     proto.String("b"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".b.v1.Event"),
+						JsonName: proto.String("b"),
+					},
+				},
+			},
+		},
+	}, &files)
+	assert.NilError(t, err)
+	return file.Messages().Get(0)
+}
+
+func TestStripNamespaces(t *testing.T) {
+	opts := SchemaOptions{StripNamespaces: true}
+	schema, err := opts.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	assert.Equal(t, record.Name, "Book")
+	assert.Equal(t, record.Namespace, "")
+
+	msg := &library.Book{Title: "Harry Potter"}
+	encoded, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	_, err = codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+	decoded := &library.Book{}
+	assert.NilError(t, opts.decodeJSON(encoded, decoded))
+	assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+}
+
+func TestStripNamespaces_Collision(t *testing.T) {
+	desc := collidingEvents(t)
+	_, err := SchemaOptions{StripNamespaces: true}.InferSchema(desc)
+	assert.Error(t, err, "avro name collision: Event is the name of both a.v1.Event and b.v1.Event")
+	_, err = SchemaOptions{
+		NamespaceFunc: func(protoreflect.Descriptor) string { return "events" },
+	}.InferSchema(desc)
+	assert.Error(t, err, "avro name collision: events.Event is the name of both a.v1.Event and b.v1.Event")
+	// namespaces prevent collisions
+	_, err = SchemaOptions{}.InferSchema(desc)
+	assert.NilError(t, err)
+}
+
+func TestDisambiguateNames(t *testing.T) {
+	desc := collidingEvents(t)
+	opts := SchemaOptions{StripNamespaces: true, DisambiguateNames: true}
+	schema, err := opts.InferSchema(desc)
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	assert.Equal(t, record.Name, "Batch")
+	assert.Equal(t, record.Fields[0].Type.(avro.Union)[1].(avro.Record).Name, "a_v1_Event")
+	assert.Equal(t, record.Fields[1].Type.(avro.Union)[1].(avro.Record).Name, "b_v1_Event")
+
+	msg := dynamicpb.NewMessage(desc)
+	for _, name := range []protoreflect.Name{"a", "b"} {
+		field := desc.Fields().ByName(name)
+		event := msg.NewField(field).Message()
+		event.Set(field.Message().Fields().ByName("id"), protoreflect.ValueOfString(string(name)))
+		msg.Set(field, protoreflect.ValueOfMessage(event))
+	}
+	encoded, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+	binary, err := codec.BinaryFromNative(nil, encoded)
+	assert.NilError(t, err)
+	native, _, err := codec.NativeFromBinary(binary)
+	assert.NilError(t, err)
+	decoded := dynamicpb.NewMessage(desc)
+	assert.NilError(t, opts.decodeJSON(native, decoded))
+	assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+}
+
+func Test_avroName(t *testing.T) {
+	desc := (&examplev1.ExampleEnum{}).ProtoReflect().Descriptor()
+	enum := examplev1.ExampleEnum_ENUM_UNSPECIFIED.Descriptor()
+	for _, tt := range []struct {
+		name     string
+		opts     SchemaOptions
+		desc     protoreflect.Descriptor
+		expected string
+	}{
+		{name: "message", desc: desc, expected: "einride.avro.example.v1.ExampleEnum"},
+		{name: "nested enum", desc: enum, expected: "einride.avro.example.v1.ExampleEnum.Enum"},
+		{
+			name:     "stripped namespaces",
+			opts:     SchemaOptions{StripNamespaces: true},
+			desc:     enum,
+			expected: "Enum",
+		},
+		{
+			name:     "trimmed namespace prefix",
+			opts:     SchemaOptions{TrimNamespacePrefix: "einride.avro"},
+			desc:     enum,
+			expected: "example.v1.ExampleEnum.Enum",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.opts.avroName(tt.desc), tt.expected)
+		})
+	}
+	// full names are not concatenated on every call with the default namespaces
+	allocs := testing.AllocsPerRun(100, func() {
+		SchemaOptions{}.avroName(enum)
+	})
+	assert.Equal(t, allocs, 0.0)
+}
+
+func TestNamespaceOptions(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		opts          SchemaOptions
+		namespace     string
+		enumNamespace string
+	}{
+		{
+			name:          "default",
+			namespace:     "einride.avro.example.v1",
+			enumNamespace: "einride.avro.example.v1.ExampleEnum",
+		},
+		{
+			name:          "trim prefix",
+			opts:          SchemaOptions{TrimNamespacePrefix: "einride.avro"},
+			namespace:     "example.v1",
+			enumNamespace: "example.v1.ExampleEnum",
+		},
+		{
+			name:          "trim whole namespace",
+			opts:          SchemaOptions{TrimNamespacePrefix: "einride.avro.example.v1."},
+			namespace:     "",
+			enumNamespace: "ExampleEnum",
+		},
+		{
+			name: "namespace func",
+			opts: SchemaOptions{
+				NamespaceFunc: func(desc protoreflect.Descriptor) string {
+					return "com.einride." + string(desc.ParentFile().Package())
+				},
+			},
+			namespace:     "com.einride.einride.avro.example.v1",
+			enumNamespace: "com.einride.einride.avro.example.v1",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE2}
+			schema, err := tt.opts.InferSchema(msg.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+			record := schema.(avro.Union)[1].(avro.Record)
+			assert.Equal(t, record.Namespace, tt.namespace)
+			assert.Equal(t, record.Fields[0].Type.(avro.Union)[1].(avro.Enum).Namespace, tt.enumNamespace)
+
+			// round-trip through the binary encoding
+			encoded, err := tt.opts.encodeJSON(msg)
+			assert.NilError(t, err)
+			schemaBytes, err := json.Marshal(schema)
+			assert.NilError(t, err)
+			codec, err := goavro.NewCodec(string(schemaBytes))
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, encoded)
+			assert.NilError(t, err)
+			native, _, err := codec.NativeFromBinary(binary)
+			assert.NilError(t, err)
+			decoded := &examplev1.ExampleEnum{}
+			assert.NilError(t, tt.opts.decodeJSON(native, decoded))
+			assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+		})
+	}
+}
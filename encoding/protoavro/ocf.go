@@ -0,0 +1,789 @@
+package protoavro
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/linkedin/goavro/v2"
+)
+
+// Names of the compression codecs of Object Container Files defined by the Avro specification.
+const (
+	CompressionNull      = "null"
+	CompressionDeflate   = "deflate"
+	CompressionSnappy    = "snappy"
+	CompressionZstandard = "zstandard"
+)
+
+// CompressionCodec compresses and decompresses the blocks of Object Container Files.
+type CompressionCodec interface {
+	// Compress returns the compressed block.
+	Compress(block []byte) ([]byte, error)
+	// Decompress returns the decompressed block.
+	Decompress(block []byte) ([]byte, error)
+}
+
+// LimitedCompressionCodec is a CompressionCodec that stops decompressing blocks that exceed a maximum size,
+// so that records compressed with SchemaOptions.RecordCompression are never decompressed beyond
+// SchemaOptions.MaxRecordBytes. Records compressed with other codecs are decompressed fully before their size
+// is checked. The built-in codecs implement LimitedCompressionCodec.
+type LimitedCompressionCodec interface {
+	CompressionCodec
+	// DecompressLimit returns the decompressed block, or an error wrapping ErrLimitExceeded when the
+	// decompressed block exceeds max bytes.
+	DecompressLimit(block []byte, max int) ([]byte, error)
+}
+
+var compressionCodecs = struct {
+	mu     sync.RWMutex
+	codecs map[string]CompressionCodec
+}{codecs: map[string]CompressionCodec{
+	CompressionNull:    nullCompression{},
+	CompressionDeflate: deflateCompression{},
+	CompressionSnappy:  snappyCompression{},
+	CompressionLZ4:     lz4Compression{},
+}}
+
+// RegisterCompressionCodec registers a compression codec of Object Container Files with the name, written to
+// the "avro.codec" metadata of the files, and replaces any codec previously registered for the same name.
+// The codecs null, deflate and snappy, and the CompressionLZ4 codec of standalone records, are built in. The
+// zstandard codec, which is defined by the Avro specification but has no implementation in the standard
+// library, is registered with the name CompressionZstandard by importing the package
+// encoding/protoavro/zstdavro.
+//
+// RegisterCompressionCodec is typically called from an init function.
+func RegisterCompressionCodec(name string, codec CompressionCodec) {
+	compressionCodecs.mu.Lock()
+	defer compressionCodecs.mu.Unlock()
+	compressionCodecs.codecs[name] = codec
+}
+
+func lookupCompressionCodec(name string) (CompressionCodec, error) {
+	if name == "" {
+		name = CompressionNull
+	}
+	compressionCodecs.mu.RLock()
+	defer compressionCodecs.mu.RUnlock()
+	codec, ok := compressionCodecs.codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("compression codec %s is not registered, see RegisterCompressionCodec", name)
+	}
+	return codec, nil
+}
+
+type nullCompression struct{}
+
+func (nullCompression) Compress(block []byte) ([]byte, error) {
+	return block, nil
+}
+
+func (nullCompression) Decompress(block []byte) ([]byte, error) {
+	return block, nil
+}
+
+func (nullCompression) DecompressLimit(block []byte, max int) ([]byte, error) {
+	if len(block) > max {
+		return nil, ErrLimitExceeded
+	}
+	return block, nil
+}
+
+// deflateCompression compresses blocks with raw deflate, without zlib headers, as specified by Avro.
+type deflateCompression struct{}
+
+func (deflateCompression) Compress(block []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := flate.NewWriter(&b, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(block); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (deflateCompression) Decompress(block []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(block))
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (deflateCompression) DecompressLimit(block []byte, max int) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(block))
+	defer r.Close()
+	decompressed, err := io.ReadAll(io.LimitReader(r, int64(max)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > max {
+		return nil, ErrLimitExceeded
+	}
+	return decompressed, nil
+}
+
+// snappyCompression compresses blocks with snappy, followed by the big-endian CRC32 checksum of the
+// uncompressed block, as specified by Avro.
+type snappyCompression struct{}
+
+func (snappyCompression) Compress(block []byte) ([]byte, error) {
+	compressed := snappy.Encode(nil, block)
+	return binary.BigEndian.AppendUint32(compressed, crc32.ChecksumIEEE(block)), nil
+}
+
+func (snappyCompression) Decompress(block []byte) ([]byte, error) {
+	if len(block) < crc32.Size {
+		return nil, fmt.Errorf("snappy block of %d bytes is too short for its checksum", len(block))
+	}
+	n := len(block) - crc32.Size
+	decompressed, err := snappy.Decode(nil, block[:n])
+	if err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(decompressed) != binary.BigEndian.Uint32(block[n:]) {
+		return nil, errors.New("snappy block checksum mismatch")
+	}
+	return decompressed, nil
+}
+
+func (c snappyCompression) DecompressLimit(block []byte, max int) ([]byte, error) {
+	if len(block) >= crc32.Size {
+		// the decoded length is read from the snappy header, before anything is decompressed
+		if n, err := snappy.DecodedLen(block[:len(block)-crc32.Size]); err == nil && n > max {
+			return nil, ErrLimitExceeded
+		}
+	}
+	return c.Decompress(block)
+}
+
+const (
+	ocfMagic          = "Obj\x01"
+	ocfSyncLength     = 16
+	ocfSchemaKey      = "avro.schema"
+	ocfCodecKey       = "avro.codec"
+	ocfReservedPrefix = "avro."
+	ocfMaxHeaderBytes = 1 << 26
+)
+
+// ocfHeader is the header of an Object Container File.
+type ocfHeader struct {
+	codec       *goavro.Codec
+	compression string
+	syncMarker  [ocfSyncLength]byte
+	// payload is the codec of the schema without its checksum field, when records have checksums.
+	payload *goavro.Codec
+	// metadata holds the custom metadata of the file, without the reserved avro.* keys.
+	metadata map[string][]byte
+	// dictionary holds the names of the dictionary encoded fields of the root record.
+	dictionary []string
+	// datum is the codec of the values once decoded from the dictionary, when the file has dictionary fields.
+	datum *goavro.Codec
+}
+
+// initDictionary initializes the dictionary fields of the header from the metadata of the file.
+func (h *ocfHeader) initDictionary(metadata map[string][]byte) error {
+	fields, err := parseDictionaryMetadata(metadata)
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+	datum, err := dictionaryDatumCodec(h.datumCodec().Schema(), fields)
+	if err != nil {
+		return fmt.Errorf("dictionary: %w", err)
+	}
+	h.dictionary, h.datum = fields, datum
+	return nil
+}
+
+// ocfWriter writes blocks of binary encoded values to an Object Container File.
+type ocfWriter struct {
+	header      ocfHeader
+	compression CompressionCodec
+	w           io.Writer
+	// dictionary encodes the dictionary fields of the values, when the file has dictionary fields.
+	dictionary *dictionaryEncoder
+}
+
+// newOCFWriter writes the header of a new Object Container File, with a random sync marker, and the custom
+// metadata.
+func newOCFWriter(
+	w io.Writer,
+	codec *goavro.Codec,
+	compressionName string,
+	metadata map[string][]byte,
+) (*ocfWriter, error) {
+	if compressionName == "" {
+		compressionName = CompressionNull
+	}
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		if strings.HasPrefix(key, ocfReservedPrefix) {
+			return nil, fmt.Errorf("metadata key %s: the prefix %s is reserved", key, ocfReservedPrefix)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	compression, err := lookupCompressionCodec(compressionName)
+	if err != nil {
+		return nil, err
+	}
+	header := ocfHeader{codec: codec, compression: compressionName}
+	if header.payload, err = checksumPayloadCodec(codec.Schema()); err != nil {
+		return nil, err
+	}
+	if err := header.initDictionary(metadata); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(header.syncMarker[:]); err != nil {
+		return nil, fmt.Errorf("generate sync marker: %w", err)
+	}
+	buf := []byte(ocfMagic)
+	// metadata is a map of bytes, written as a single block of entries followed by an empty block
+	buf = appendLong(buf, int64(2+len(keys)))
+	buf = appendBytes(buf, []byte(ocfSchemaKey))
+	buf = appendBytes(buf, []byte(codec.Schema()))
+	buf = appendBytes(buf, []byte(ocfCodecKey))
+	buf = appendBytes(buf, []byte(compressionName))
+	for _, key := range keys {
+		buf = appendBytes(buf, []byte(key))
+		buf = appendBytes(buf, metadata[key])
+	}
+	buf = appendLong(buf, 0)
+	buf = append(buf, header.syncMarker[:]...)
+	if _, err := w.Write(buf); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+	ow := &ocfWriter{header: header, compression: compression, w: w}
+	if len(header.dictionary) > 0 {
+		ow.dictionary = newDictionaryEncoder(codec, header.dictionary)
+	}
+	return ow, nil
+}
+
+// appendOCFWriter returns a writer that appends blocks to the existing Object Container File, with the schema,
+// compression codec and sync marker of its header. The file must end with its sync marker, which is written
+// after the header and every block, so that a file truncated by an interrupted write is not appended to.
+func appendOCFWriter(file io.ReadWriteSeeker) (*ocfWriter, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header, err := readOCFHeader(bufio.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	if len(header.dictionary) > 0 {
+		return nil, errors.New("appending to files with dictionary fields is not supported")
+	}
+	compression, err := lookupCompressionCodec(header.compression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(-ocfSyncLength, io.SeekEnd); err != nil {
+		return nil, err
+	}
+	var tail [ocfSyncLength]byte
+	if _, err := io.ReadFull(file, tail[:]); err != nil {
+		return nil, fmt.Errorf("read sync marker: %w", err)
+	}
+	if tail != header.syncMarker {
+		return nil, errors.New("file does not end with its sync marker, the last block may be truncated")
+	}
+	return &ocfWriter{header: header, compression: compression, w: file}, nil
+}
+
+// Append encodes the values, and writes them as blocks of at most goavro.MaxBlockCount values.
+func (w *ocfWriter) Append(values []interface{}) error {
+	if w.dictionary != nil {
+		encoded := make([]interface{}, len(values))
+		for i, value := range values {
+			var err error
+			if encoded[i], err = w.dictionary.encode(value); err != nil {
+				return fmt.Errorf("encode value: %w", err)
+			}
+		}
+		values = encoded
+	}
+	for len(values) > 0 {
+		n := int64(len(values))
+		if n > goavro.MaxBlockCount {
+			n = goavro.MaxBlockCount
+		}
+		if err := w.appendBlock(values[:n]); err != nil {
+			return err
+		}
+		values = values[n:]
+	}
+	return nil
+}
+
+func (w *ocfWriter) appendBlock(values []interface{}) error {
+	var block []byte
+	for _, value := range values {
+		var err error
+		if block, err = w.header.encode(block, value); err != nil {
+			return fmt.Errorf("encode value: %w", err)
+		}
+	}
+	compressed, err := w.compression.Compress(block)
+	if err != nil {
+		return fmt.Errorf("compress block with %s: %w", w.header.compression, err)
+	}
+	buf := make([]byte, 0, len(compressed)+2*binary.MaxVarintLen64+ocfSyncLength)
+	buf = appendLong(buf, int64(len(values)))
+	buf = appendLong(buf, int64(len(compressed)))
+	buf = append(buf, compressed...)
+	buf = append(buf, w.header.syncMarker[:]...)
+	_, err = w.w.Write(buf)
+	return err
+}
+
+// ocfReader reads binary encoded values from the blocks of an Object Container File.
+type ocfReader struct {
+	header      ocfHeader
+	compression CompressionCodec
+	r           *bufio.Reader
+	// block is the remainder of the current decompressed block, with count values.
+	block []byte
+	count int64
+	// values are the remaining values of the current block, when blocks are decoded in parallel.
+	values []interface{}
+	// blocks receives the decoded blocks in order, when blocks are decoded in parallel.
+	blocks <-chan chan ocfBlock
+	// done stops the parallel decoding of blocks.
+	done chan struct{}
+	err  error
+	// seeker is the underlying reader, if it can seek, see Unmarshaler.Index.
+	seeker io.ReadSeeker
+	// counter counts the bytes read from the underlying reader since the offset start.
+	counter *countingReader
+	start   int64
+	// fileStart is the offset of the file in the underlying reader. Unlike start, it is not moved by seeks.
+	fileStart int64
+	// headerSize is the size of the header, which is followed by the first block.
+	headerSize int64
+	// index holds the blocks of the file, once indexed.
+	index []OCFBlock
+	// filter skips the values whose record it returns false for, see SchemaOptions.RecordFilter.
+	filter func(record map[string]interface{}) bool
+	// unionRoot is true when the values of the file are unions of the root records.
+	unionRoot bool
+	// next is the value that satisfied the filter, read ahead by Scan.
+	next    interface{}
+	hasNext bool
+	// maxRecordBytes is the maximum size of the values of the file, see SchemaOptions.MaxRecordBytes.
+	maxRecordBytes int
+	// dictionary decodes the dictionary fields of the values, in the order of the file, when the file has
+	// dictionary fields.
+	dictionary *dictionaryDecoder
+}
+
+// ocfBlock is a block read from an Object Container File.
+type ocfBlock struct {
+	count int64
+	data  []byte
+	// values are the decoded values of the block.
+	values []interface{}
+	err    error
+}
+
+// newOCFReader reads the header of an Object Container File.
+func newOCFReader(r io.Reader) (*ocfReader, error) {
+	or := &ocfReader{counter: &countingReader{r: r}}
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			or.seeker, or.start, or.fileStart = seeker, start, start
+		}
+	}
+	or.r = bufio.NewReader(or.counter)
+	header, err := readOCFHeader(or.r)
+	if err != nil {
+		return nil, err
+	}
+	or.header = header
+	if or.compression, err = lookupCompressionCodec(header.compression); err != nil {
+		return nil, err
+	}
+	or.headerSize = or.offset() - or.start
+	if len(header.dictionary) > 0 {
+		or.dictionary = newDictionaryDecoder(header.codec, header.dictionary)
+	}
+	return or, nil
+}
+
+// decodeParallel decompresses and decodes the blocks ahead of Read, with parallelism workers. Blocks are read
+// from the file in order, by a single goroutine, and at most parallelism blocks are decoded ahead of Read.
+func (r *ocfReader) decodeParallel(parallelism int) {
+	type job struct {
+		block ocfBlock
+		// result receives the decoded block
+		result chan<- ocfBlock
+	}
+	// blocks are the results of the blocks, in the order of the file
+	blocks := make(chan chan ocfBlock, parallelism)
+	jobs := make(chan job)
+	done := make(chan struct{})
+	r.blocks = blocks
+	r.done = done
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			for job := range jobs {
+				job.block.values, job.block.err = r.decodeBlock(job.block)
+				job.block.data = nil
+				job.result <- job.block
+			}
+		}()
+	}
+	go func() {
+		defer close(blocks)
+		defer close(jobs)
+		for {
+			var block ocfBlock
+			block.count, block.data, block.err = r.readBlock()
+			result := make(chan ocfBlock, 1)
+			select {
+			case blocks <- result:
+			case <-done:
+				return
+			}
+			if block.err != nil {
+				result <- block
+				return
+			}
+			select {
+			case jobs <- job{block: block, result: result}:
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// decodeBlock decompresses and decodes all values of the block.
+func (r *ocfReader) decodeBlock(block ocfBlock) ([]interface{}, error) {
+	data, err := r.decompress(block.count, block.data)
+	if err != nil {
+		return nil, err
+	}
+	// the count is read from the file, and bounds the capacity only as far as the data could hold the values
+	capacity := block.count
+	if capacity > int64(len(data)) {
+		capacity = int64(len(data))
+	}
+	values := make([]interface{}, 0, capacity)
+	for i := int64(0); i < block.count; i++ {
+		var value interface{}
+		if value, data, err = r.decodeValue(data); err != nil {
+			return nil, fmt.Errorf("decode value: %w", err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Close stops the parallel decoding of blocks.
+func (r *ocfReader) Close() {
+	if r.done != nil {
+		close(r.done)
+		r.done = nil
+	}
+}
+
+// Codec returns the codec of the values returned by Read, which is the codec of the schema of the file, without
+// the checksum field when records have checksums.
+func (r *ocfReader) Codec() *goavro.Codec {
+	return r.header.datumCodec()
+}
+
+// CompressionName returns the name of the compression codec of the file.
+func (r *ocfReader) CompressionName() string {
+	return r.header.compression
+}
+
+// Scan returns true when there is at least one more value to be read, and the context is not done while values
+// are skipped by a filter.
+func (r *ocfReader) Scan(ctx context.Context) bool {
+	if r.filter != nil {
+		return r.scanFiltered(ctx)
+	}
+	for r.err == nil && r.count == 0 {
+		r.err = r.nextBlock()
+	}
+	return r.err == nil
+}
+
+func (r *ocfReader) nextBlock() error {
+	if r.blocks == nil {
+		count, data, err := r.readBlock()
+		if err != nil {
+			return err
+		}
+		if r.block, err = r.decompress(count, data); err != nil {
+			return err
+		}
+		r.count = count
+		return nil
+	}
+	result, ok := <-r.blocks
+	if !ok {
+		return io.EOF
+	}
+	block := <-result
+	if block.err != nil {
+		return block.err
+	}
+	r.values, r.count = block.values, block.count
+	return nil
+}
+
+// Err returns the error that stopped scanning, if any.
+func (r *ocfReader) Err() error {
+	if errors.Is(r.err, io.EOF) {
+		return nil
+	}
+	return r.err
+}
+
+// Read decodes the next value. Scan must have returned true before Read is called.
+func (r *ocfReader) Read() (interface{}, error) {
+	if r.hasNext {
+		value := r.next
+		r.next, r.hasNext = nil, false
+		return value, nil
+	}
+	return r.read()
+}
+
+func (r *ocfReader) read() (interface{}, error) {
+	value, err := r.readValue()
+	if err != nil || r.dictionary == nil {
+		return value, err
+	}
+	if err := r.dictionary.decode(value); err != nil {
+		r.err = fmt.Errorf("decode value: %w", err)
+		return nil, r.err
+	}
+	return value, nil
+}
+
+func (r *ocfReader) readValue() (interface{}, error) {
+	if r.count == 0 {
+		if r.err != nil && !errors.Is(r.err, io.EOF) {
+			return nil, r.err
+		}
+		return nil, errors.New("no value to read, call Scan first")
+	}
+	if r.blocks != nil {
+		value := r.values[0]
+		r.values[0] = nil
+		r.values = r.values[1:]
+		r.count--
+		return value, nil
+	}
+	value, rest, err := r.decodeValue(r.block)
+	if err != nil {
+		r.err = fmt.Errorf("decode value: %w", err)
+		return nil, r.err
+	}
+	r.block = rest
+	r.count--
+	return value, nil
+}
+
+// decodeValue decodes the next value of the decompressed block data, and returns the rest of the data.
+func (r *ocfReader) decodeValue(data []byte) (interface{}, []byte, error) {
+	value, rest, err := r.header.decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.maxRecordBytes > 0 && len(data)-len(rest) > r.maxRecordBytes {
+		return nil, nil, &LimitError{Limit: "MaxRecordBytes", Max: r.maxRecordBytes}
+	}
+	return value, rest, nil
+}
+
+// readBlock reads the value count and the compressed data of the next block.
+func (r *ocfReader) readBlock() (int64, []byte, error) {
+	count, err := readLong(r.r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.EOF
+		}
+		return 0, nil, fmt.Errorf("read block count: %w", err)
+	}
+	if count < 0 || count > goavro.MaxBlockCount {
+		return 0, nil, fmt.Errorf("invalid block count %d", count)
+	}
+	size, err := readLong(r.r)
+	if err != nil {
+		return 0, nil, fmt.Errorf("read block size: %w", noEOF(err))
+	}
+	if size < 0 || size > goavro.MaxBlockSize {
+		return 0, nil, fmt.Errorf("invalid block size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return 0, nil, fmt.Errorf("read block: %w", noEOF(err))
+	}
+	var syncMarker [ocfSyncLength]byte
+	if _, err := io.ReadFull(r.r, syncMarker[:]); err != nil {
+		return 0, nil, fmt.Errorf("read sync marker: %w", noEOF(err))
+	}
+	if syncMarker != r.header.syncMarker {
+		return 0, nil, errors.New("sync marker mismatch")
+	}
+	return count, data, nil
+}
+
+// decompress decompresses the data of a block of count values. Blocks stop decompressing when they exceed
+// count times maxRecordBytes, since at least one of their values would exceed it.
+func (r *ocfReader) decompress(count int64, data []byte) ([]byte, error) {
+	if r.maxRecordBytes <= 0 {
+		decompressed, err := r.compression.Decompress(data)
+		if err != nil {
+			return nil, fmt.Errorf("decompress block with %s: %w", r.header.compression, err)
+		}
+		return decompressed, nil
+	}
+	max := math.MaxInt
+	if count < int64(max/r.maxRecordBytes) {
+		max = int(count) * r.maxRecordBytes
+	}
+	var decompressed []byte
+	var err error
+	if limited, ok := r.compression.(LimitedCompressionCodec); ok {
+		decompressed, err = limited.DecompressLimit(data, max)
+	} else if decompressed, err = r.compression.Decompress(data); err == nil && len(decompressed) > max {
+		err = ErrLimitExceeded
+	}
+	if errors.Is(err, ErrLimitExceeded) {
+		return nil, &LimitError{Limit: "MaxRecordBytes", Max: r.maxRecordBytes}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompress block with %s: %w", r.header.compression, err)
+	}
+	return decompressed, nil
+}
+
+func readOCFHeader(r *bufio.Reader) (ocfHeader, error) {
+	var header ocfHeader
+	magic := make([]byte, len(ocfMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return header, fmt.Errorf("read magic: %w", noEOF(err))
+	}
+	if string(magic) != ocfMagic {
+		return header, fmt.Errorf("invalid magic %q, not an object container file", magic)
+	}
+	metadata := make(map[string][]byte)
+	var total int64
+	for {
+		count, err := readLong(r)
+		if err != nil {
+			return header, fmt.Errorf("read metadata: %w", noEOF(err))
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// negative counts are followed by the size of the block in bytes
+			count = -count
+			if _, err := readLong(r); err != nil {
+				return header, fmt.Errorf("read metadata: %w", noEOF(err))
+			}
+		}
+		for i := int64(0); i < count; i++ {
+			key, err := readBytes(r, &total)
+			if err != nil {
+				return header, fmt.Errorf("read metadata: %w", noEOF(err))
+			}
+			value, err := readBytes(r, &total)
+			if err != nil {
+				return header, fmt.Errorf("read metadata: %w", noEOF(err))
+			}
+			metadata[string(key)] = value
+			if !strings.HasPrefix(string(key), ocfReservedPrefix) {
+				if header.metadata == nil {
+					header.metadata = make(map[string][]byte)
+				}
+				header.metadata[string(key)] = value
+			}
+		}
+	}
+	schema, ok := metadata[ocfSchemaKey]
+	if !ok {
+		return header, fmt.Errorf("metadata has no %s", ocfSchemaKey)
+	}
+	codec, err := goavro.NewCodec(string(schema))
+	if err != nil {
+		return header, fmt.Errorf("parse schema: %w", err)
+	}
+	header.codec = codec
+	if header.payload, err = checksumPayloadCodec(string(schema)); err != nil {
+		return header, err
+	}
+	if err := header.initDictionary(metadata); err != nil {
+		return header, err
+	}
+	header.compression = CompressionNull
+	if compression, ok := metadata[ocfCodecKey]; ok && len(compression) > 0 {
+		header.compression = string(compression)
+	}
+	if _, err := io.ReadFull(r, header.syncMarker[:]); err != nil {
+		return header, fmt.Errorf("read sync marker: %w", noEOF(err))
+	}
+	return header, nil
+}
+
+// readBytes reads length-prefixed bytes, and adds the length to total, which is bounded to reject corrupt
+// headers before allocating them.
+func readBytes(r *bufio.Reader, total *int64) ([]byte, error) {
+	n, err := readLong(r)
+	if err != nil {
+		return nil, err
+	}
+	*total += n
+	if n < 0 || *total > ocfMaxHeaderBytes {
+		return nil, fmt.Errorf("invalid length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// readLong reads a zig-zag encoded variable-length long.
+func readLong(r io.ByteReader) (int64, error) {
+	return binary.ReadVarint(r)
+}
+
+// appendLong appends a zig-zag encoded variable-length long.
+func appendLong(b []byte, v int64) []byte {
+	return binary.AppendVarint(b, v)
+}
+
+func appendBytes(b []byte, v []byte) []byte {
+	return append(appendLong(b, int64(len(v))), v...)
+}
+
+// noEOF reports an unexpected end of the file, within a header or block, as io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,292 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func testBooks(offset, n int) []*library.Book {
+	books := make([]*library.Book, 0, n)
+	for i := offset; i < offset+n; i++ {
+		books = append(books, &library.Book{
+			Name:   fmt.Sprintf("shelves/1/books/%d", i),
+			Title:  "Harry Potter",
+			Author: "J. K. Rowling",
+		})
+	}
+	return books
+}
+
+func marshalBooks(t *testing.T, m *protoavro.Marshaler, books []*library.Book) {
+	t.Helper()
+	for _, book := range books {
+		assert.NilError(t, m.Marshal(book))
+	}
+}
+
+func unmarshalBooks(t *testing.T, u *protoavro.Unmarshaler) []*library.Book {
+	t.Helper()
+	var books []*library.Book
+	for u.Scan() {
+		var book library.Book
+		assert.NilError(t, u.Unmarshal(&book))
+		books = append(books, &book)
+	}
+	assert.NilError(t, u.Err())
+	return books
+}
+
+// reverseCompression is a test codec that reverses the bytes of blocks.
+type reverseCompression struct{}
+
+func (reverseCompression) Compress(block []byte) ([]byte, error) {
+	return reverse(block), nil
+}
+
+func (reverseCompression) Decompress(block []byte) ([]byte, error) {
+	return reverse(block), nil
+}
+
+func reverse(block []byte) []byte {
+	reversed := make([]byte, len(block))
+	for i, b := range block {
+		reversed[len(block)-1-i] = b
+	}
+	return reversed
+}
+
+func Test_Compression(t *testing.T) {
+	protoavro.RegisterCompressionCodec("reverse", reverseCompression{})
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	for _, compression := range []string{
+		"",
+		protoavro.CompressionNull,
+		protoavro.CompressionDeflate,
+		protoavro.CompressionSnappy,
+		"reverse",
+	} {
+		compression := compression
+		t.Run(compression, func(t *testing.T) {
+			books := testBooks(0, 100)
+			var b bytes.Buffer
+			opts := protoavro.SchemaOptions{Compression: compression}
+			marshaler, err := opts.NewMarshaler(desc, &b)
+			assert.NilError(t, err)
+			marshalBooks(t, marshaler, books)
+			unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(b.Bytes()))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, books, unmarshalBooks(t, unmarshaler), protocmp.Transform())
+		})
+	}
+}
+
+func Test_Compression_Goavro(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	for _, compression := range []string{
+		protoavro.CompressionNull,
+		protoavro.CompressionDeflate,
+		protoavro.CompressionSnappy,
+	} {
+		compression := compression
+		t.Run(compression, func(t *testing.T) {
+			books := testBooks(0, 10)
+			// written by protoavro, read by goavro
+			var b bytes.Buffer
+			marshaler, err := protoavro.SchemaOptions{Compression: compression}.NewMarshaler(desc, &b)
+			assert.NilError(t, err)
+			marshalBooks(t, marshaler, books)
+			r, err := goavro.NewOCFReader(&b)
+			assert.NilError(t, err)
+			assert.Equal(t, compression, r.CompressionName())
+			var n int
+			for r.Scan() {
+				_, err := r.Read()
+				assert.NilError(t, err)
+				n++
+			}
+			assert.NilError(t, r.Err())
+			assert.Equal(t, len(books), n)
+			// written by goavro, read by protoavro
+			codec, err := protoavro.NewGoavroCodec(desc)
+			assert.NilError(t, err)
+			b.Reset()
+			w, err := goavro.NewOCFWriter(goavro.OCFConfig{W: &b, Codec: codec, CompressionName: compression})
+			assert.NilError(t, err)
+			for _, book := range books {
+				data, err := protoavro.SchemaOptions{}.Encode(book)
+				assert.NilError(t, err)
+				assert.NilError(t, w.Append([]interface{}{data}))
+			}
+			unmarshaler, err := protoavro.NewUnmarshaler(&b)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, books, unmarshalBooks(t, unmarshaler), protocmp.Transform())
+		})
+	}
+}
+
+func Test_Compression_NotRegistered(t *testing.T) {
+	var b bytes.Buffer
+	_, err := protoavro.SchemaOptions{Compression: protoavro.CompressionZstandard}.NewMarshaler(
+		(&library.Book{}).ProtoReflect().Descriptor(),
+		&b,
+	)
+	assert.ErrorContains(t, err, "compression codec zstandard is not registered")
+}
+
+func Test_AppendingMarshaler(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	path := filepath.Join(t.TempDir(), "books.avro")
+	// an empty file is written like a new file
+	file, err := os.Create(path)
+	assert.NilError(t, err)
+	opts := protoavro.SchemaOptions{Compression: protoavro.CompressionDeflate}
+	marshaler, err := opts.NewAppendingMarshaler(desc, file)
+	assert.NilError(t, err)
+	marshalBooks(t, marshaler, testBooks(0, 10))
+	assert.NilError(t, file.Close())
+	// appended blocks are compressed with the codec of the file
+	file, err = os.OpenFile(path, os.O_RDWR, 0)
+	assert.NilError(t, err)
+	marshaler, err = protoavro.SchemaOptions{}.NewAppendingMarshaler(desc, file)
+	assert.NilError(t, err)
+	marshalBooks(t, marshaler, testBooks(10, 10))
+	assert.NilError(t, file.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	r, err := goavro.NewOCFReader(bytes.NewReader(data))
+	assert.NilError(t, err)
+	assert.Equal(t, protoavro.CompressionDeflate, r.CompressionName())
+	unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(data))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, testBooks(0, 20), unmarshalBooks(t, unmarshaler), protocmp.Transform())
+}
+
+func Test_AppendingMarshaler_Errors(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	path := filepath.Join(t.TempDir(), "books.avro")
+	file, err := os.Create(path)
+	assert.NilError(t, err)
+	marshaler, err := protoavro.NewMarshaler(desc, file)
+	assert.NilError(t, err)
+	marshalBooks(t, marshaler, testBooks(0, 10))
+	assert.NilError(t, file.Close())
+
+	t.Run("schema mismatch", func(t *testing.T) {
+		file, err := os.OpenFile(path, os.O_RDWR, 0)
+		assert.NilError(t, err)
+		defer file.Close()
+		_, err = protoavro.SchemaOptions{}.NewAppendingMarshaler((&library.Shelf{}).ProtoReflect().Descriptor(), file)
+		assert.ErrorContains(t, err, "schema of file does not match schema of message google.example.library.v1.Shelf")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		info, err := os.Stat(path)
+		assert.NilError(t, err)
+		assert.NilError(t, os.Truncate(path, info.Size()-1))
+		file, err := os.OpenFile(path, os.O_RDWR, 0)
+		assert.NilError(t, err)
+		defer file.Close()
+		_, err = protoavro.SchemaOptions{}.NewAppendingMarshaler(desc, file)
+		assert.ErrorContains(t, err, "last block may be truncated")
+	})
+}
+
+func Test_ReadParallelism(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	for _, compression := range []string{protoavro.CompressionNull, protoavro.CompressionSnappy} {
+		compression := compression
+		t.Run(compression, func(t *testing.T) {
+			var b bytes.Buffer
+			marshaler, err := protoavro.SchemaOptions{Compression: compression}.NewMarshaler(desc, &b)
+			assert.NilError(t, err)
+			// each call to Marshal writes a block of messages
+			books := testBooks(0, 1000)
+			for i := 0; i < len(books); i += 7 {
+				batch := make([]proto.Message, 0, 7)
+				for j := i; j < i+7 && j < len(books); j++ {
+					batch = append(batch, books[j])
+				}
+				assert.NilError(t, marshaler.Marshal(batch...))
+			}
+			unmarshaler, err := protoavro.SchemaOptions{ReadParallelism: 4}.NewUnmarshaler(&b)
+			assert.NilError(t, err)
+			defer unmarshaler.Close()
+			assert.DeepEqual(t, books, unmarshalBooks(t, unmarshaler), protocmp.Transform())
+		})
+	}
+}
+
+func Test_ReadParallelism_Close(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	var b bytes.Buffer
+	marshaler, err := protoavro.NewMarshaler(desc, &b)
+	assert.NilError(t, err)
+	marshalBooks(t, marshaler, testBooks(0, 100))
+	unmarshaler, err := protoavro.SchemaOptions{ReadParallelism: 2}.NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	assert.Assert(t, unmarshaler.Scan())
+	var book library.Book
+	assert.NilError(t, unmarshaler.Unmarshal(&book))
+	assert.NilError(t, unmarshaler.Close())
+	assert.NilError(t, unmarshaler.Close())
+}
+
+func Test_ReadParallelism_Corrupt(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	var b bytes.Buffer
+	marshaler, err := protoavro.NewMarshaler(desc, &b)
+	assert.NilError(t, err)
+	marshalBooks(t, marshaler, testBooks(0, 10))
+	data := b.Bytes()
+	// the sync marker of the last block is corrupted
+	data[len(data)-1]++
+	unmarshaler, err := protoavro.SchemaOptions{ReadParallelism: 4}.NewUnmarshaler(bytes.NewReader(data))
+	assert.NilError(t, err)
+	defer unmarshaler.Close()
+	var n int
+	for unmarshaler.Scan() {
+		var book library.Book
+		assert.NilError(t, unmarshaler.Unmarshal(&book))
+		n++
+	}
+	assert.Equal(t, 9, n)
+	assert.ErrorContains(t, unmarshaler.Err(), "sync marker mismatch")
+}
+
+func Test_ReadParallelism_CorruptCount(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	var b bytes.Buffer
+	marshaler, err := protoavro.NewMarshaler(desc, &b)
+	assert.NilError(t, err)
+	marshalBooks(t, marshaler, testBooks(0, 1))
+	// the header ends with the sync marker, which also ends the block
+	syncMarker := b.Bytes()[b.Len()-16:]
+	data := b.Bytes()[:bytes.Index(b.Bytes(), syncMarker)+16]
+	// a block of the largest count, and no data
+	data = binary.AppendVarint(data, goavro.MaxBlockCount)
+	data = binary.AppendVarint(data, 0)
+	data = append(data, syncMarker...)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	unmarshaler, err := protoavro.SchemaOptions{ReadParallelism: 4}.NewUnmarshaler(bytes.NewReader(data))
+	assert.NilError(t, err)
+	defer unmarshaler.Close()
+	assert.Assert(t, !unmarshaler.Scan())
+	assert.ErrorContains(t, unmarshaler.Err(), "decode value")
+	runtime.ReadMemStats(&after)
+	// values are not preallocated for the count of the block
+	assert.Assert(t, after.TotalAlloc-before.TotalAlloc < 1<<26, after.TotalAlloc-before.TotalAlloc)
+}
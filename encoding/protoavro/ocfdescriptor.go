@@ -0,0 +1,152 @@
+package protoavro
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Keys of the metadata of Object Container Files with embedded descriptors, see SchemaOptions.EmbedDescriptor.
+const (
+	// OCFDescriptorKey is the binary encoded FileDescriptorSet of the files of the written messages, and the
+	// files they import.
+	OCFDescriptorKey = "protoavro.descriptor"
+	// OCFMessageKey is the comma-separated full names of the written messages.
+	OCFMessageKey = "protoavro.message"
+	// OCFDescriptorEncodingKey is the encoding of the FileDescriptorSet, "gzip" when it is compressed with
+	// SchemaOptions.CompressDescriptor, and absent when it is not compressed.
+	OCFDescriptorEncodingKey = "protoavro.descriptor.encoding"
+)
+
+const descriptorEncodingGzip = "gzip"
+
+// ErrNoDescriptor is returned when the descriptor of the written messages is not embedded in a file.
+var ErrNoDescriptor = errors.New("no embedded descriptor")
+
+// ocfMetadata returns the custom metadata of files of the messages, with their descriptors when
+// EmbedDescriptor is set.
+func (o SchemaOptions) ocfMetadata(descs ...protoreflect.MessageDescriptor) (map[string][]byte, error) {
+	if !o.EmbedDescriptor {
+		return o.OCFMetadata, nil
+	}
+	metadata := make(map[string][]byte, len(o.OCFMetadata)+3)
+	for key, value := range o.OCFMetadata {
+		metadata[key] = value
+	}
+	var set descriptorpb.FileDescriptorSet
+	seen := make(map[string]struct{})
+	names := make([]string, 0, len(descs))
+	for _, desc := range descs {
+		names = append(names, string(desc.FullName()))
+		for _, file := range fileDescriptorSet(desc.ParentFile()).GetFile() {
+			if _, ok := seen[file.GetName()]; !ok {
+				seen[file.GetName()] = struct{}{}
+				set.File = append(set.File, file)
+			}
+		}
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(&set)
+	if err != nil {
+		return nil, fmt.Errorf("embed descriptor: %w", err)
+	}
+	if o.CompressDescriptor {
+		var b bytes.Buffer
+		w := gzip.NewWriter(&b)
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("embed descriptor: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("embed descriptor: %w", err)
+		}
+		data = b.Bytes()
+		metadata[OCFDescriptorEncodingKey] = []byte(descriptorEncodingGzip)
+	}
+	metadata[OCFDescriptorKey] = data
+	metadata[OCFMessageKey] = []byte(strings.Join(names, ","))
+	return metadata, nil
+}
+
+// MessageDescriptors returns the descriptors of the messages the file was written with, when they are embedded
+// in the metadata of the file with SchemaOptions.EmbedDescriptor, so that files can be decoded without the
+// generated code of the messages, for example with dynamicpb. ErrNoDescriptor is returned for files without
+// embedded descriptors.
+func (m *Unmarshaler) MessageDescriptors() ([]protoreflect.MessageDescriptor, error) {
+	data, ok := m.r.header.metadata[OCFDescriptorKey]
+	if !ok {
+		return nil, ErrNoDescriptor
+	}
+	switch encoding := string(m.r.header.metadata[OCFDescriptorEncodingKey]); encoding {
+	case "":
+	case descriptorEncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("message descriptors: %w", err)
+		}
+		if data, err = io.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("message descriptors: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("message descriptors: unsupported encoding %s", encoding)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("message descriptors: %w", err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("message descriptors: %w", err)
+	}
+	names := strings.Split(string(m.r.header.metadata[OCFMessageKey]), ",")
+	descs := make([]protoreflect.MessageDescriptor, 0, len(names))
+	for _, name := range names {
+		desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("message descriptors: %s: %w", name, err)
+		}
+		message, ok := desc.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("message descriptors: %s is not a message", name)
+		}
+		descs = append(descs, message)
+	}
+	return descs, nil
+}
+
+// UnmarshalDynamic consumes one message from the reader and returns it as a dynamic message of the descriptor
+// embedded in the file, see MessageDescriptors, so that files remain readable without the generated code of
+// the messages they were written with. Messages of files written with a UnionMarshaler are of the type of
+// their union branch. ErrNoDescriptor is returned for files without embedded descriptors.
+func (m *Unmarshaler) UnmarshalDynamic() (*dynamicpb.Message, error) {
+	if m.descs == nil {
+		descs, err := m.MessageDescriptors()
+		if err != nil {
+			return nil, err
+		}
+		m.descs = descs
+	}
+	data, err := m.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read message: %w", err)
+	}
+	opts := m.opts.withNames(m.descs...)
+	desc := m.descs[0]
+	if len(m.descs) > 1 {
+		if desc, err = opts.unionBranch(data, m.descs); err != nil {
+			return nil, fmt.Errorf("decode message: %w", err)
+		}
+	}
+	message := dynamicpb.NewMessage(desc)
+	if err := opts.decodeJSON(data, message); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	return message, nil
+}
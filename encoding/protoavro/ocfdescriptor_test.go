@@ -0,0 +1,183 @@
+package protoavro
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gotest.tools/v3/assert"
+)
+
+func TestEmbedDescriptor(t *testing.T) {
+	opts := SchemaOptions{
+		EmbedDescriptor: true,
+		OCFMetadata:     map[string][]byte{"pipeline.id": []byte("books-daily")},
+	}
+	book := &library.Book{Name: "shelves/1/books/1", Title: "Dune", Read: true}
+	var b bytes.Buffer
+	marshaler, err := opts.NewMarshaler(book.ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(book))
+	unmarshaler, err := NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	metadata := unmarshaler.Metadata()
+	assert.Equal(t, "books-daily", string(metadata["pipeline.id"]))
+	assert.Equal(t, "google.example.library.v1.Book", string(metadata[OCFMessageKey]))
+	descs, err := unmarshaler.MessageDescriptors()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(descs))
+	assert.Equal(t, protoreflect.FullName("google.example.library.v1.Book"), descs[0].FullName())
+	// files decode into messages of the embedded descriptor, without the generated code of the message
+	assert.Assert(t, unmarshaler.Scan())
+	decoded := dynamicpb.NewMessage(descs[0])
+	assert.NilError(t, unmarshaler.Unmarshal(decoded))
+	assert.Equal(t, "Dune", decoded.Get(descs[0].Fields().ByName("title")).String())
+
+	t.Run("union", func(t *testing.T) {
+		var b bytes.Buffer
+		marshaler, err := opts.NewUnionMarshaler(
+			&b, (&library.Book{}).ProtoReflect().Descriptor(), (&library.Shelf{}).ProtoReflect().Descriptor(),
+		)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(&library.Shelf{Name: "shelves/1"}))
+		unmarshaler, err := NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		descs, err := unmarshaler.MessageDescriptors()
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(descs))
+		assert.Equal(t, protoreflect.FullName("google.example.library.v1.Shelf"), descs[1].FullName())
+	})
+
+	t.Run("not embedded", func(t *testing.T) {
+		var b bytes.Buffer
+		marshaler, err := NewMarshaler(book.ProtoReflect().Descriptor(), &b)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(book))
+		unmarshaler, err := NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, map[string][]byte{}, unmarshaler.Metadata())
+		_, err = unmarshaler.MessageDescriptors()
+		assert.Assert(t, errors.Is(err, ErrNoDescriptor))
+		assert.Assert(t, unmarshaler.Scan())
+		var decoded library.Book
+		assert.NilError(t, unmarshaler.Unmarshal(&decoded))
+		assert.DeepEqual(t, book, &decoded, protocmp.Transform())
+	})
+}
+
+func TestUnmarshaler_UnmarshalDynamic(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		opts  SchemaOptions
+		descs []protoreflect.MessageDescriptor
+		msgs  []proto.Message
+	}{
+		{
+			name: "message",
+			opts: SchemaOptions{EmbedDescriptor: true},
+			msgs: []proto.Message{
+				&library.Book{Name: "shelves/1/books/1", Title: "Dune"},
+				&library.Book{Name: "shelves/1/books/2", Author: "Frank Herbert"},
+			},
+		},
+		{
+			name: "compressed",
+			opts: SchemaOptions{EmbedDescriptor: true, CompressDescriptor: true},
+			msgs: []proto.Message{&library.Book{Name: "shelves/1/books/1", Title: "Dune"}},
+		},
+		{
+			name: "well-known types",
+			opts: SchemaOptions{EmbedDescriptor: true, CompressDescriptor: true},
+			msgs: []proto.Message{
+				&examplev1.ExampleTimestamp{Timestamp: timestamppb.New(time.Unix(1700000000, 1000).UTC())},
+			},
+		},
+		{
+			name: "union",
+			opts: SchemaOptions{EmbedDescriptor: true, DisambiguateNames: true},
+			descs: []protoreflect.MessageDescriptor{
+				(&library.Book{}).ProtoReflect().Descriptor(),
+				(&library.Shelf{}).ProtoReflect().Descriptor(),
+			},
+			msgs: []proto.Message{
+				&library.Shelf{Name: "shelves/1", Theme: "Science fiction"},
+				&library.Book{Name: "shelves/1/books/1", Title: "Dune"},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if len(tt.descs) > 0 {
+				marshaler, err := tt.opts.NewUnionMarshaler(&b, tt.descs...)
+				assert.NilError(t, err)
+				assert.NilError(t, marshaler.Marshal(tt.msgs...))
+			} else {
+				marshaler, err := tt.opts.NewMarshaler(tt.msgs[0].ProtoReflect().Descriptor(), &b)
+				assert.NilError(t, err)
+				assert.NilError(t, marshaler.Marshal(tt.msgs...))
+			}
+			unmarshaler, err := tt.opts.NewUnmarshaler(&b)
+			assert.NilError(t, err)
+			for _, msg := range tt.msgs {
+				assert.Assert(t, unmarshaler.Scan())
+				decoded, err := unmarshaler.UnmarshalDynamic()
+				assert.NilError(t, err)
+				assert.Equal(t, msg.ProtoReflect().Descriptor().FullName(), decoded.Descriptor().FullName())
+				// dynamic messages compare by their binary encoding, as their descriptors are not the
+				// descriptors of the generated code
+				expected, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+				assert.NilError(t, err)
+				actual, err := proto.MarshalOptions{Deterministic: true}.Marshal(decoded)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, expected, actual)
+			}
+			assert.Assert(t, !unmarshaler.Scan())
+			assert.NilError(t, unmarshaler.Err())
+		})
+	}
+
+	t.Run("compressed metadata", func(t *testing.T) {
+		book := &library.Book{Name: "shelves/1/books/1"}
+		sizes := make(map[bool]int, 2)
+		for _, compress := range []bool{false, true} {
+			var b bytes.Buffer
+			opts := SchemaOptions{EmbedDescriptor: true, CompressDescriptor: compress}
+			marshaler, err := opts.NewMarshaler(book.ProtoReflect().Descriptor(), &b)
+			assert.NilError(t, err)
+			assert.NilError(t, marshaler.Marshal(book))
+			unmarshaler, err := NewUnmarshaler(&b)
+			assert.NilError(t, err)
+			metadata := unmarshaler.Metadata()
+			sizes[compress] = len(metadata[OCFDescriptorKey])
+			if compress {
+				assert.Equal(t, "gzip", string(metadata[OCFDescriptorEncodingKey]))
+			} else {
+				_, ok := metadata[OCFDescriptorEncodingKey]
+				assert.Assert(t, !ok)
+			}
+		}
+		assert.Assert(t, sizes[true] < sizes[false])
+	})
+
+	t.Run("not embedded", func(t *testing.T) {
+		var b bytes.Buffer
+		book := &library.Book{Name: "shelves/1/books/1"}
+		marshaler, err := NewMarshaler(book.ProtoReflect().Descriptor(), &b)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(book))
+		unmarshaler, err := NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		assert.Assert(t, unmarshaler.Scan())
+		_, err = unmarshaler.UnmarshalDynamic()
+		assert.Assert(t, errors.Is(err, ErrNoDescriptor))
+	})
+}
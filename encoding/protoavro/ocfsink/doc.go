@@ -0,0 +1,3 @@
+// Package ocfsink writes protobuf messages to Avro Object Container Files in an object store, such as Amazon S3
+// or Google Cloud Storage, and rotates the files by size, age and number of records.
+package ocfsink
@@ -0,0 +1,189 @@
+package ocfsink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+)
+
+// filePerm is the permissions of the files and manifests written by SafeWriters.
+const filePerm = 0o644
+
+// ManifestSuffix is appended to the name of a file written by a SafeWriter for the name of its manifest.
+const ManifestSuffix = ".manifest.json"
+
+// Manifest describes a complete file written by a SafeWriter, for example for downstream batch jobs to verify
+// the files they read.
+type Manifest struct {
+	// Name is the base name of the file.
+	Name string `json:"name"`
+	// Records is the number of records of the file.
+	Records int64 `json:"records"`
+	// Fingerprint is the hex encoded CRC-64-AVRO (Rabin) fingerprint of the schema of the file.
+	Fingerprint string `json:"fingerprint"`
+	// Bytes is the size of the file in bytes.
+	Bytes int64 `json:"bytes"`
+}
+
+// SafeWriter writes an Object Container File to a file system, such that the file is either complete or not
+// visible at all, so that partially written files are never picked up by downstream batch jobs.
+// Data is written to a hidden temporary file in the directory of the file. Close syncs the temporary file,
+// verifies its blocks, atomically renames the temporary file to the file, and then writes the manifest of the
+// file. When the manifest can not be written, the file is removed again.
+type SafeWriter struct {
+	path string
+	tmp  *os.File
+	done bool
+}
+
+// NewSafeWriter returns a writer of the file at the path. The directory of the file must exist.
+func NewSafeWriter(path string) (*SafeWriter, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("new safe writer: %w", err)
+	}
+	return &SafeWriter{path: path, tmp: tmp}, nil
+}
+
+// SafeWriterFactory returns a factory of SafeWriters of files in the directory, named by the object names of
+// a Sink, where slashes separate directories, which are created as needed.
+func SafeWriterFactory(dir string) WriterFactory {
+	return func(_ context.Context, name string) (io.WriteCloser, error) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		return NewSafeWriter(path)
+	}
+}
+
+// Write writes to the temporary file.
+func (w *SafeWriter) Write(p []byte) (int, error) {
+	return w.tmp.Write(p)
+}
+
+// Close commits the file, and its manifest. When the file can not be committed, for example when it is not a
+// valid Object Container File, the temporary file is removed and the file is not created.
+func (w *SafeWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	if err := w.commit(); err != nil {
+		return errors.Join(fmt.Errorf("commit %s: %w", w.path, err), w.remove())
+	}
+	return nil
+}
+
+// Abort discards the data written, without creating the file.
+func (w *SafeWriter) Abort() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	return w.remove()
+}
+
+func (w *SafeWriter) remove() error {
+	_ = w.tmp.Close()
+	if err := os.Remove(w.tmp.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (w *SafeWriter) commit() error {
+	// temporary files are only readable by their owner
+	if err := w.tmp.Chmod(filePerm); err != nil {
+		return err
+	}
+	if err := w.tmp.Sync(); err != nil {
+		return err
+	}
+	manifest, err := w.manifest()
+	if err != nil {
+		return err
+	}
+	if err := w.tmp.Close(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(w.tmp.Name(), w.path); err != nil {
+		return err
+	}
+	// the manifest is written last, so that no manifest describes a file that was not committed
+	if err := writeFileAtomic(w.path+ManifestSuffix, data); err != nil {
+		return errors.Join(fmt.Errorf("write manifest: %w", err), os.Remove(w.path))
+	}
+	return syncDir(filepath.Dir(w.path))
+}
+
+// manifest reads back the blocks of the temporary file, without decoding any records.
+func (w *SafeWriter) manifest() (Manifest, error) {
+	if _, err := w.tmp.Seek(0, io.SeekStart); err != nil {
+		return Manifest{}, err
+	}
+	unmarshaler, err := protoavro.NewUnmarshaler(w.tmp)
+	if err != nil {
+		return Manifest{}, err
+	}
+	index, err := unmarshaler.Index()
+	if err != nil {
+		return Manifest{}, err
+	}
+	info, err := w.tmp.Stat()
+	if err != nil {
+		return Manifest{}, err
+	}
+	manifest := Manifest{
+		Name:        filepath.Base(w.path),
+		Fingerprint: fmt.Sprintf("%016x", unmarshaler.Fingerprint()),
+		Bytes:       info.Size(),
+	}
+	for _, block := range index {
+		manifest.Records += block.Count
+	}
+	return manifest, nil
+}
+
+// writeFileAtomic writes the file through a synced temporary file, which is renamed to the file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(filePerm); err != nil {
+		return errors.Join(err, tmp.Close(), os.Remove(tmp.Name()))
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return errors.Join(err, tmp.Close(), os.Remove(tmp.Name()))
+	}
+	if err := tmp.Sync(); err != nil {
+		return errors.Join(err, tmp.Close(), os.Remove(tmp.Name()))
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Join(err, os.Remove(tmp.Name()))
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Join(err, os.Remove(tmp.Name()))
+	}
+	return nil
+}
+
+// syncDir syncs the directory, so that renames of its files are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	return errors.Join(d.Sync(), d.Close())
+}
@@ -0,0 +1,135 @@
+package ocfsink_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/ocfsink"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"gotest.tools/v3/assert"
+)
+
+// dirFiles returns the names of the files in the directory, including hidden files.
+func dirFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	assert.NilError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestSafeWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "books.avro")
+	w, err := ocfsink.NewSafeWriter(path)
+	assert.NilError(t, err)
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	marshaler, err := protoavro.NewMarshaler(desc, w)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(testBooks(0, 3)...))
+	assert.NilError(t, marshaler.Marshal(testBooks(3, 2)...))
+	// the file is not visible until it is committed
+	_, err = os.Stat(path)
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+	assert.NilError(t, w.Close())
+	assert.DeepEqual(t, []string{"books.avro", "books.avro" + ocfsink.ManifestSuffix}, dirFiles(t, dir))
+	info, err := os.Stat(path)
+	assert.NilError(t, err)
+	data, err := os.ReadFile(path + ocfsink.ManifestSuffix)
+	assert.NilError(t, err)
+	var manifest ocfsink.Manifest
+	assert.NilError(t, json.Unmarshal(data, &manifest))
+	codec, err := protoavro.NewGoavroCodec(desc)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ocfsink.Manifest{
+		Name:        "books.avro",
+		Records:     5,
+		Fingerprint: fmt.Sprintf("%016x", codec.Rabin),
+		Bytes:       info.Size(),
+	}, manifest)
+	// closing again is a no-op
+	assert.NilError(t, w.Close())
+}
+
+func TestSafeWriter_Abort(t *testing.T) {
+	dir := t.TempDir()
+	w, err := ocfsink.NewSafeWriter(filepath.Join(dir, "books.avro"))
+	assert.NilError(t, err)
+	marshaler, err := protoavro.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), w)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(testBooks(0, 3)...))
+	assert.NilError(t, w.Abort())
+	assert.NilError(t, w.Close())
+	assert.DeepEqual(t, []string{}, dirFiles(t, dir))
+}
+
+func TestSafeWriter_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	w, err := ocfsink.NewSafeWriter(filepath.Join(dir, "books.avro"))
+	assert.NilError(t, err)
+	_, err = w.Write([]byte("not an object container file"))
+	assert.NilError(t, err)
+	assert.ErrorContains(t, w.Close(), "commit")
+	// neither the partial file nor its manifest are visible
+	assert.DeepEqual(t, []string{}, dirFiles(t, dir))
+}
+
+func TestSafeWriter_CommitFailure(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		blocked string
+	}{
+		// renaming onto a directory that is not empty fails
+		{name: "file", blocked: "books.avro"},
+		{name: "manifest", blocked: "books.avro" + ocfsink.ManifestSuffix},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.NilError(t, os.MkdirAll(filepath.Join(dir, tt.blocked, "blocked"), 0o755))
+			w, err := ocfsink.NewSafeWriter(filepath.Join(dir, "books.avro"))
+			assert.NilError(t, err)
+			marshaler, err := protoavro.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), w)
+			assert.NilError(t, err)
+			assert.NilError(t, marshaler.Marshal(testBooks(0, 3)...))
+			assert.ErrorContains(t, w.Close(), "commit")
+			// neither a file without its manifest nor a manifest without its file is visible
+			assert.DeepEqual(t, []string{tt.blocked}, dirFiles(t, dir))
+		})
+	}
+}
+
+func TestSafeWriterFactory(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	sink, err := ocfsink.New(
+		(&library.Book{}).ProtoReflect().Descriptor(),
+		ocfsink.SafeWriterFactory(dir),
+		ocfsink.Options{MaxRecords: 2, Now: func() time.Time { return now }},
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Write(context.Background(), testBooks(0, 5)...))
+	assert.NilError(t, sink.Close())
+	files, err := filepath.Glob(filepath.Join(dir, "fingerprint=*", "dt=2024-01-02", "hour=15", "*.avro"))
+	assert.NilError(t, err)
+	assert.Equal(t, 3, len(files))
+	var records int64
+	for _, file := range files {
+		data, err := os.ReadFile(file + ocfsink.ManifestSuffix)
+		assert.NilError(t, err)
+		var manifest ocfsink.Manifest
+		assert.NilError(t, json.Unmarshal(data, &manifest))
+		records += manifest.Records
+	}
+	assert.Equal(t, int64(5), records)
+}
@@ -0,0 +1,245 @@
+package ocfsink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WriterFactory returns a writer of the object with the name, for example a multipart upload to an object
+// store. The object is complete when the writer is closed.
+type WriterFactory func(ctx context.Context, name string) (io.WriteCloser, error)
+
+// File describes a file written by a Sink.
+type File struct {
+	// Fingerprint is the CRC-64-AVRO (Rabin) fingerprint of the schema of the file.
+	Fingerprint uint64
+	// Start is the time of the first records of the file.
+	Start time.Time
+	// Sequence is the number of files opened by the sink before the file.
+	Sequence int
+}
+
+// DefaultName names files by the fingerprint of their schema and hourly time partitions of their start time,
+// for example "fingerprint=8a2b4c6d8e0f1a3b/dt=2024-01-02/hour=15/20240102T150405.000000000Z-000042.avro".
+func DefaultName(file File) string {
+	start := file.Start.UTC()
+	return fmt.Sprintf(
+		"fingerprint=%016x/dt=%s/hour=%s/%s-%06d.avro",
+		file.Fingerprint,
+		start.Format("2006-01-02"),
+		start.Format("15"),
+		start.Format("20060102T150405.000000000Z"),
+		file.Sequence,
+	)
+}
+
+// Options configures a Sink.
+type Options struct {
+	// SchemaOptions are the options of the schema inferred for messages, and of the written files.
+	SchemaOptions protoavro.SchemaOptions
+	// Prefix is prepended to the names of files, for example "events/".
+	Prefix string
+	// NameFunc returns the name of a file, after Prefix. Defaults to DefaultName.
+	NameFunc func(file File) string
+	// MaxBytes rotates files that have reached the size, in bytes. Files can exceed the size by one write.
+	// Zero means no limit.
+	MaxBytes int64
+	// MaxRecords rotates files that have reached the number of records. Zero means no limit.
+	MaxRecords int
+	// MaxAge rotates files that were opened longer ago than the duration, when records are written.
+	// Zero means no limit.
+	MaxAge time.Duration
+	// Partition rotates files when records are written in a later time partition of the duration than the
+	// start time of the file, so that files do not span time partitions. Defaults to one hour.
+	// A negative duration disables partitioning.
+	Partition time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// EmbedSchemaVersion writes the protoavro.SchemaVersion of the messages to the metadata of every file,
+	// created at the start time of the file, to trace files back to the protobuf sources they were written with.
+	EmbedSchemaVersion bool
+}
+
+// Sink writes protobuf messages of one type to rotated Object Container Files. Files are opened when the first
+// records are written, and closed when they are rotated or when the sink is closed.
+// A Sink is safe for concurrent use.
+type Sink struct {
+	descriptor  protoreflect.MessageDescriptor
+	factory     WriterFactory
+	opts        Options
+	fingerprint uint64
+	// version is the schema version embedded in files, when EmbedSchemaVersion is set.
+	version *protoavro.SchemaVersion
+	// mu guards the current file and the number of opened files.
+	mu       sync.Mutex
+	current  *file
+	sequence int
+}
+
+// file is an open file of a sink.
+type file struct {
+	File
+	name      string
+	writer    io.WriteCloser
+	counter   *countingWriter
+	marshaler *protoavro.Marshaler
+	records   int
+}
+
+// New returns a new sink of messages of the descriptor, which writes files with the writers of the factory.
+func New(descriptor protoreflect.MessageDescriptor, factory WriterFactory, opts Options) (*Sink, error) {
+	if opts.NameFunc == nil {
+		opts.NameFunc = DefaultName
+	}
+	if opts.Partition == 0 {
+		opts.Partition = time.Hour
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	codec, err := opts.SchemaOptions.NewGoavroCodec(descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("new sink: %w", err)
+	}
+	s := &Sink{
+		descriptor:  descriptor,
+		factory:     factory,
+		opts:        opts,
+		fingerprint: codec.Rabin,
+	}
+	if opts.EmbedSchemaVersion {
+		version, err := opts.SchemaOptions.SchemaVersion(descriptor, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("new sink: %w", err)
+		}
+		s.version = &version
+	}
+	return s, nil
+}
+
+// Write writes the messages to the current file, and rotates files according to the options.
+// Messages are split across files when they exceed MaxRecords.
+func (s *Sink) Write(ctx context.Context, messages ...proto.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(messages) > 0 {
+		now := s.opts.Now()
+		if s.current != nil && s.expired(now) {
+			if err := s.closeCurrent(); err != nil {
+				return err
+			}
+		}
+		if s.current == nil {
+			if err := s.open(ctx, now); err != nil {
+				return err
+			}
+		}
+		n := len(messages)
+		if s.opts.MaxRecords > 0 && s.current.records+n > s.opts.MaxRecords {
+			n = s.opts.MaxRecords - s.current.records
+		}
+		if err := s.current.marshaler.MarshalContext(ctx, messages[:n]...); err != nil {
+			return fmt.Errorf("write %s: %w", s.current.name, err)
+		}
+		s.current.records += n
+		messages = messages[n:]
+		if s.full() {
+			if err := s.closeCurrent(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rotate closes the current file, if it has expired according to MaxAge or Partition, for example on a
+// ticker, so that files of idle sinks are completed without waiting for more records.
+func (s *Sink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil || !s.expired(s.opts.Now()) {
+		return nil
+	}
+	return s.closeCurrent()
+}
+
+// Close closes the current file.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return nil
+	}
+	return s.closeCurrent()
+}
+
+func (s *Sink) open(ctx context.Context, now time.Time) error {
+	f := &file{File: File{Fingerprint: s.fingerprint, Start: now, Sequence: s.sequence}}
+	f.name = s.opts.Prefix + s.opts.NameFunc(f.File)
+	writer, err := s.factory(ctx, f.name)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.name, err)
+	}
+	s.sequence++
+	f.writer = writer
+	f.counter = &countingWriter{w: writer}
+	opts := s.opts.SchemaOptions
+	if s.version != nil {
+		version := *s.version
+		version.Created = now
+		metadata := version.OCFMetadata()
+		for key, value := range opts.OCFMetadata {
+			metadata[key] = value
+		}
+		opts.OCFMetadata = metadata
+	}
+	if f.marshaler, err = opts.NewMarshaler(s.descriptor, f.counter); err != nil {
+		return errors.Join(fmt.Errorf("open %s: %w", f.name, err), writer.Close())
+	}
+	s.current = f
+	return nil
+}
+
+func (s *Sink) closeCurrent() error {
+	f := s.current
+	s.current = nil
+	if err := f.writer.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// full returns true if the current file has reached MaxBytes or MaxRecords.
+func (s *Sink) full() bool {
+	return (s.opts.MaxBytes > 0 && s.current.counter.n >= s.opts.MaxBytes) ||
+		(s.opts.MaxRecords > 0 && s.current.records >= s.opts.MaxRecords)
+}
+
+// expired returns true if the current file has reached MaxAge, or records at the time belong to a later
+// time partition than the file.
+func (s *Sink) expired(now time.Time) bool {
+	if s.opts.MaxAge > 0 && now.Sub(s.current.Start) >= s.opts.MaxAge {
+		return true
+	}
+	return s.opts.Partition > 0 && !now.Truncate(s.opts.Partition).Equal(s.current.Start.Truncate(s.opts.Partition))
+}
+
+// countingWriter counts the bytes written to a writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,206 @@
+package ocfsink_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/ocfsink"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+// fakeStore is an in-memory object store, where objects are complete when their writer is closed.
+type fakeStore struct {
+	objects map[string][]byte
+	opened  []string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{objects: map[string][]byte{}}
+}
+
+func (s *fakeStore) NewWriter(_ context.Context, name string) (io.WriteCloser, error) {
+	s.opened = append(s.opened, name)
+	return &fakeObject{store: s, name: name}, nil
+}
+
+type fakeObject struct {
+	bytes.Buffer
+	store *fakeStore
+	name  string
+}
+
+func (o *fakeObject) Close() error {
+	o.store.objects[o.name] = o.Bytes()
+	return nil
+}
+
+// books returns the books of the objects of the store, sorted by object name.
+func (s *fakeStore) books(t *testing.T) [][]*library.Book {
+	t.Helper()
+	names := make([]string, 0, len(s.objects))
+	for name := range s.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([][]*library.Book, 0, len(names))
+	for _, name := range names {
+		unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(s.objects[name]))
+		assert.NilError(t, err)
+		var books []*library.Book
+		for unmarshaler.Scan() {
+			var book library.Book
+			assert.NilError(t, unmarshaler.Unmarshal(&book))
+			books = append(books, &book)
+		}
+		assert.NilError(t, unmarshaler.Err())
+		result = append(result, books)
+	}
+	return result
+}
+
+func testBooks(offset, n int) []proto.Message {
+	books := make([]proto.Message, 0, n)
+	for i := offset; i < offset+n; i++ {
+		books = append(books, &library.Book{Name: fmt.Sprintf("shelves/1/books/%d", i), Title: "Harry Potter"})
+	}
+	return books
+}
+
+// clock is a fake clock.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestSink_MaxRecords(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	sink, err := ocfsink.New(desc, store.NewWriter, ocfsink.Options{MaxRecords: 4})
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Write(ctx, testBooks(0, 3)...))
+	// writes are split across files
+	assert.NilError(t, sink.Write(ctx, testBooks(3, 7)...))
+	assert.NilError(t, sink.Close())
+	assert.Equal(t, 3, len(store.objects))
+	var lengths []int
+	var books []proto.Message
+	for _, file := range store.books(t) {
+		lengths = append(lengths, len(file))
+		for _, book := range file {
+			books = append(books, book)
+		}
+	}
+	assert.DeepEqual(t, []int{4, 4, 2}, lengths)
+	assert.DeepEqual(t, testBooks(0, 10), books, protocmp.Transform())
+}
+
+func TestSink_MaxBytes(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	sink, err := ocfsink.New(desc, store.NewWriter, ocfsink.Options{MaxBytes: 1})
+	assert.NilError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, sink.Write(ctx, testBooks(i, 1)...))
+	}
+	// full files are closed without waiting for more records
+	assert.Equal(t, 3, len(store.objects))
+	assert.NilError(t, sink.Close())
+	assert.Equal(t, 3, len(store.objects))
+}
+
+func TestSink_MaxAge(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	c := &clock{now: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	sink, err := ocfsink.New(desc, store.NewWriter, ocfsink.Options{MaxAge: time.Minute, Now: c.Now})
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Write(ctx, testBooks(0, 1)...))
+	c.now = c.now.Add(30 * time.Second)
+	assert.NilError(t, sink.Rotate())
+	assert.NilError(t, sink.Write(ctx, testBooks(1, 1)...))
+	assert.Equal(t, 0, len(store.objects))
+	c.now = c.now.Add(30 * time.Second)
+	assert.NilError(t, sink.Rotate())
+	assert.Equal(t, 1, len(store.objects))
+	assert.NilError(t, sink.Write(ctx, testBooks(2, 1)...))
+	c.now = c.now.Add(time.Minute)
+	assert.NilError(t, sink.Write(ctx, testBooks(3, 1)...))
+	assert.NilError(t, sink.Close())
+	assert.Equal(t, 3, len(store.objects))
+}
+
+func TestSink_Partition(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	c := &clock{now: time.Date(2024, 1, 2, 15, 59, 59, 0, time.UTC)}
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	sink, err := ocfsink.New(desc, store.NewWriter, ocfsink.Options{Prefix: "books/", Now: c.Now})
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Write(ctx, testBooks(0, 2)...))
+	c.now = c.now.Add(time.Second)
+	assert.NilError(t, sink.Write(ctx, testBooks(2, 2)...))
+	assert.NilError(t, sink.Close())
+	codec, err := protoavro.NewGoavroCodec(desc)
+	assert.NilError(t, err)
+	fingerprint := fmt.Sprintf("%016x", codec.Rabin)
+	assert.DeepEqual(t, []string{
+		"books/fingerprint=" + fingerprint + "/dt=2024-01-02/hour=15/20240102T155959.000000000Z-000000.avro",
+		"books/fingerprint=" + fingerprint + "/dt=2024-01-02/hour=16/20240102T160000.000000000Z-000001.avro",
+	}, store.opened)
+	files := store.books(t)
+	assert.Equal(t, 2, len(files))
+	assert.Equal(t, "shelves/1/books/2", files[1][0].GetName())
+}
+
+func TestSink_EmbedSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	c := &clock{now: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	sink, err := ocfsink.New(desc, store.NewWriter, ocfsink.Options{EmbedSchemaVersion: true, Now: c.Now})
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Write(ctx, testBooks(0, 1)...))
+	assert.NilError(t, sink.Close())
+	assert.Equal(t, 1, len(store.opened))
+	unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(store.objects[store.opened[0]]))
+	assert.NilError(t, err)
+	version, err := protoavro.ParseSchemaVersionOCFMetadata(unmarshaler.Metadata())
+	assert.NilError(t, err)
+	expected, err := protoavro.SchemaOptions{}.SchemaVersion(desc, c.now)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expected, version)
+}
+
+func TestSink_Errors(t *testing.T) {
+	ctx := context.Background()
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	sink, err := ocfsink.New(desc, func(context.Context, string) (io.WriteCloser, error) {
+		return nil, errors.New("access denied")
+	}, ocfsink.Options{NameFunc: func(file ocfsink.File) string {
+		return fmt.Sprintf("%d.avro", file.Sequence)
+	}})
+	assert.NilError(t, err)
+	err = sink.Write(ctx, testBooks(0, 1)...)
+	assert.ErrorContains(t, err, "open 0.avro: access denied")
+	store := newFakeStore()
+	sink, err = ocfsink.New(desc, store.NewWriter, ocfsink.Options{})
+	assert.NilError(t, err)
+	err = sink.Write(ctx, &library.Shelf{})
+	assert.ErrorContains(t, err, "google.example.library.v1.Shelf")
+}
@@ -1,7 +1,339 @@
 package protoavro
 
+import (
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
 // SchemaOptions contains configuration options for Avro schema inference.
 // OmitRootElement is used to determine whether the root element of a message should be omitted, when writing to Avro.
 type SchemaOptions struct {
 	OmitRootElement bool
+	// DecodeMask restricts decoding to the fields selected by the mask paths.
+	// Fields not selected by the mask are skipped when decoding, and left unset in the message.
+	// A nil or empty mask selects all fields.
+	DecodeMask *fieldmaskpb.FieldMask
+	// SchemaMask restricts inferred schemas, and encoded records, to the fields selected by the mask paths.
+	// Paths traverse into nested messages, list elements and map values.
+	// A nil or empty mask selects all fields.
+	SchemaMask *fieldmaskpb.FieldMask
+	// CollectStats enables collection of column statistics in Marshaler.
+	// The statistics for a batch of messages are returned by Marshaler.Flush.
+	CollectStats bool
+	// GoogleTypeMappings enables built-in mappings for common google.type messages:
+	// google.type.Money is mapped to a record of the currency code and a decimal amount,
+	// and google.type.LatLng and google.type.PostalAddress to records of non-nullable fields.
+	GoogleTypeMappings bool
+	// FlattenMessages maps the listed messages, by their full name, to the nullable Avro type of their single
+	// field instead of records, like the well-known wrapper types, for example a message OrderId with a single
+	// field string value to a nullable string. Messages that are not set are null, and messages that are set are
+	// encoded as the value of their field. Inference and encoding fail for listed messages that have other
+	// fields, or whose field is repeated, or of a message, enum or fixed type.
+	FlattenMessages []protoreflect.FullName
+	// FlattenDepth flattens the fields of message fields of root records into the root record, down to
+	// FlattenDepth levels of nested messages, for loading into flat tables. The fields of flattened messages are
+	// named by the path of field names from the root record, joined by FlattenSeparator, for example
+	// shipping_address_city, and are null when the message is not set. Message fields nested deeper, repeated
+	// and map fields, and well-known types are not flattened. Decoding moves the fields back into the messages,
+	// where messages with only null fields decode as not set. Recursive root messages can not be flattened,
+	// and messages are decoded and encoded through generic values when FlattenDepth is set.
+	FlattenDepth int
+	// FlattenSeparator joins the names of the fields of flattened messages, see FlattenDepth. Defaults to "_",
+	// since Avro names can not contain dots.
+	FlattenSeparator string
+	// AnnotateProtoKinds annotates record fields with the protobuf kind of the field, as the custom
+	// property "protoKind", see ProtoKindProperty. The property is ignored by Avro readers, but makes it possible to
+	// reconstruct the exact protobuf type from the schema.
+	AnnotateProtoKinds bool
+	// AnnotateFieldNumbers annotates record fields with the protobuf field number of the field, as the custom
+	// property "protoFieldNumber", see ProtoFieldNumberProperty.
+	AnnotateFieldNumbers bool
+	// NonNullListItems maps the items of repeated fields to non-nullable Avro types, since elements of protobuf
+	// lists can never be null. By default, items are nullable unions like other fields.
+	NonNullListItems bool
+	// NullListItems is how null items of arrays are decoded into lists, which can not hold null items.
+	// Defaults to NullListItemsZero, which decodes null items as zero values, such as empty messages.
+	NullListItems NullListItems
+	// NullMapValues is how null values of map entries are decoded into maps, which can not hold null values.
+	// Defaults to NullMapValuesZero, which decodes null values as zero values, such as empty messages.
+	NullMapValues NullMapValues
+	// PreservePresence encodes scalar fields of proto2 messages with explicit presence, such as optional fields,
+	// as null when the field is not set, like scalar fields of oneofs and proto3 optional fields, so that they
+	// are decoded as not set. By default, proto2 scalar fields that are not set are encoded as their default value,
+	// and decoded as set.
+	PreservePresence bool
+	// StripEnumPrefix strips the conventional prefix of enum values from Avro enum symbols, for example
+	// VEHICLE_STATE_DRIVING of the enum VehicleState is mapped to the symbol DRIVING.
+	// Enums where any value lacks the prefix are not stripped. Decoding accepts both stripped and original symbols.
+	StripEnumPrefix bool
+	// EnumDefault sets the default of Avro enums to the first symbol, which allows readers to resolve
+	// unknown symbols written by newer schemas.
+	EnumDefault bool
+	// NullDefaults sets the default of nullable record fields, and of nullable envelope fields, to null, as
+	// required by schema registries to add fields with BACKWARD compatibility.
+	NullDefaults bool
+	// FixedSizes maps bytes fields, by their full name, to Avro fixed types of the size instead of bytes, for
+	// example 16 for UUIDs or 32 for SHA-256 hashes. The fixed type is named by the field, in the namespace of
+	// the record of its message. Values of other lengths fail to encode and decode, except that empty values of
+	// singular fields, such as of fields that are not set, are encoded as null.
+	FixedSizes map[protoreflect.FullName]int
+	// FixedSizeFunc returns the size of the Avro fixed type of bytes fields that are not in FixedSizes, for
+	// example read from a custom field option, or zero to map the field to bytes.
+	FixedSizeFunc func(field protoreflect.FieldDescriptor) int
+	// TimestampPrecision is the precision of google.protobuf.Timestamp values. Defaults to microseconds.
+	TimestampPrecision TimestampPrecision
+	// TimestampRounding is how google.protobuf.Timestamp values are rounded to TimestampPrecision.
+	// Defaults to truncation.
+	TimestampRounding TimestampRounding
+	// TimestampEncoding is how google.protobuf.Timestamp values are encoded. Defaults to a long of
+	// TimestampPrecision.
+	TimestampEncoding TimestampEncoding
+	// TimestampLocation is the time zone of timestamps encoded as strings, see TimestampString.
+	// Defaults to UTC.
+	TimestampLocation *time.Location
+	// DurationEncoding is how google.protobuf.Duration values are encoded. Defaults to a float of seconds.
+	DurationEncoding DurationEncoding
+	// DurationUnit is the unit of durations encoded as longs, see DurationLong. Defaults to microseconds.
+	DurationUnit time.Duration
+	// WKTMappings maps well-known types to records, logical types or strings, per type, and overrides
+	// TimestampEncoding and DurationEncoding.
+	WKTMappings WKTMappings
+	// NamespaceFunc returns the Avro namespace of a record or enum. Defaults to the protobuf package, followed
+	// by the names of enclosing messages. In Avro, named types without a namespace inherit the namespace of the
+	// enclosing record, so NamespaceFunc should return an empty namespace for all or none of the types.
+	NamespaceFunc func(desc protoreflect.Descriptor) string
+	// TrimNamespacePrefix trims a prefix of packages from the Avro namespace of records and enums,
+	// for example "einride" maps the namespace einride.avro.example.v1 to avro.example.v1.
+	TrimNamespacePrefix string
+	// StripNamespaces omits the namespace of records and enums, which are named by their protobuf name.
+	// StripNamespaces takes precedence over NamespaceFunc and TrimNamespacePrefix.
+	// Named types of well-known types keep their namespace.
+	// Inference fails when two types share an Avro full name, unless DisambiguateNames is set.
+	StripNamespaces bool
+	// DisambiguateNames names types that share an Avro full name, for example when namespaces are stripped, by
+	// their full protobuf name with dots replaced by underscores. Types share a name if they are reachable from
+	// the same root message.
+	DisambiguateNames bool
+	// UseJSONNames names record fields by the protobuf JSON names of their fields, for example bookId or the custom
+	// json_name of the field, instead of their original names. Decoding matches fields of records by the names of
+	// UseJSONNames first, and accepts the other names of fields. FieldTransforms and masks still name fields by
+	// their original names.
+	UseJSONNames bool
+	// FieldNameFunc returns the name of the record field of a field, given its original name or its JSON name with
+	// UseJSONNames, for example to rename fields named like reserved words of target systems, such as class.
+	// Names that are not valid Avro names are made valid by replacing invalid characters with underscores, and
+	// names that collide with the name of a field with a lower field number are suffixed with _2, _3 and so on.
+	// Renamed record fields have the name they would have had otherwise as an alias, and decoding accepts records
+	// of either name.
+	FieldNameFunc func(field protoreflect.FieldDescriptor, name string) string
+	// InlineReferences defines named types again wherever they are used, instead of referencing their first
+	// definition by name, for consumers that can not resolve references, such as old versions of Hive.
+	// Recursive messages are still referenced, since they can not be inlined.
+	InlineReferences bool
+	// RecursionStrategy is how recursive messages are mapped. Defaults to RecursionReference.
+	RecursionStrategy RecursionStrategy
+	// MaxRecursionDepth is the number of records that recursive messages are nested in, below the root record,
+	// with RecursionDepthLimit. Zero omits all fields of recursive messages.
+	MaxRecursionDepth int
+	// OmitDocs omits the docs of records, fields and enums, which are copied from leading comments of the
+	// protobuf descriptors by default.
+	OmitDocs bool
+	// DocFunc post-processes the docs copied from leading comments, for example to remove internal notes.
+	DocFunc func(desc protoreflect.Descriptor, doc string) string
+	// MaxDocLength truncates docs to at most MaxDocLength characters. Zero means no limit.
+	MaxDocLength int
+	// RecordPropertiesFunc returns custom properties of the record of a message, for example
+	// {"owner": "team-x"}, which are written as attributes of the record. Properties can be read from custom
+	// options of the message descriptor. Properties named like attributes of records are ignored.
+	RecordPropertiesFunc func(desc protoreflect.MessageDescriptor) avro.Properties
+	// FieldPropertiesFunc returns custom properties of the record field of a message field, for example
+	// {"sensitivity": "pii"}, which are written as attributes of the field. Properties can be read from custom
+	// options of the field descriptor. Properties named like attributes of fields are ignored.
+	FieldPropertiesFunc func(field protoreflect.FieldDescriptor) avro.Properties
+	// AnnotateResources annotates the records of messages with a google.api.resource option with the resource
+	// type, as the custom property "resourceType", and fields with a google.api.resource_reference option with
+	// the referenced type, as "resourceReference", or child type, as "resourceChildReference", for consumers to
+	// know which tables to join resource names against. Properties returned by RecordPropertiesFunc and
+	// FieldPropertiesFunc take precedence.
+	AnnotateResources bool
+	// AnnotateDeprecated annotates the records of messages and the fields of fields that are marked with the
+	// deprecated option with the custom property "deprecated": true, and a line "Deprecated." at the end of their
+	// docs, for consumers to plan the removal of columns. Properties returned by RecordPropertiesFunc and
+	// FieldPropertiesFunc take precedence.
+	AnnotateDeprecated bool
+	// OmitDeprecatedFields omits fields that are marked with the deprecated option from records, for the gradual
+	// removal of columns. Omitted fields are not encoded, and are not set when decoded.
+	OmitDeprecatedFields bool
+	// PreserveUnknownFields preserves record fields without a matching message field when decoding, for example
+	// fields written from a newer version of the message, instead of failing. The native values of the fields are
+	// stored in the unknown fields of the message, with the field number UnknownFieldsNumber, and restored exactly
+	// when the message is encoded, so that longs, bytes and logical types keep their Go types.
+	PreserveUnknownFields bool
+	// LenientUnions accepts values of nullable fields that are not wrapped by the name of their union branch when
+	// decoding, for interop with writers that do not name union branches, such as plain JSON. Values wrapped by
+	// the name of their branch, as specified by Avro, are accepted as well. Binary data is not affected, since
+	// unions are encoded by the index of their branch.
+	LenientUnions bool
+	// CoerceScalars converts values of boolean, string and integer fields that are of another Avro type when
+	// decoding, instead of failing, for interop with writers that are loose with types, such as Python jobs
+	// writing Object Container Files or generic values. Booleans are decoded from the strings accepted by
+	// strconv.ParseBool, such as "true" and "False", and from the integers 0 and 1. Strings are decoded from
+	// booleans and numbers. Integers are decoded from integers of the other width, decimal strings, booleans, and
+	// floating point numbers without a fractional part. Values of 32-bit kinds out of range of the kind, and other
+	// values, fail to decode. Data decoded directly with the schema inferred for the message is not affected,
+	// since its values are of the types of the schema.
+	CoerceScalars bool
+	// AvroJSONMode is how unions, and NaN and infinities, are represented in the JSON encoding of the Avro
+	// specification, which Avro implementations differ on, see AvroJSONMode. Data is encoded and decoded like
+	// goavro by default. Other modes rewrite the data of the JSON encoding, and not generic values.
+	AvroJSONMode AvroJSONMode
+	// RequireFields fails decoding of records where fields with REQUIRED google.api.field_behavior are missing
+	// or null, in the root message and in the messages it contains, so that incomplete records are rejected at
+	// the boundary. Fields without explicit presence are missing when they decode to their zero value.
+	RequireFields bool
+	// CollectErrors decodes all fields of records when fields fail to decode, instead of stopping at the first
+	// error, and fails with a DecodeErrors listing the error of every field that failed, including fields of
+	// nested messages, items of lists and entries of maps, to shorten debugging of malformed data. Messages are
+	// decoded through generic values when CollectErrors is set, which is slower than decoding them directly, and
+	// are partially decoded when they fail to decode, without the fields, items of lists and entries of maps that
+	// failed.
+	CollectErrors bool
+	// EnvelopeFields are injected into the root record of inferred schemas, and populated for each
+	// encoded message. Envelope fields are skipped when decoding.
+	EnvelopeFields []EnvelopeField
+	// RawProto injects the envelope field RawProtoField into the root record of inferred schemas, with the
+	// protobuf wire format of each encoded message, so that messages can be reprocessed losslessly where the Avro
+	// mapping is lossy, for example for unknown fields or with SchemaMask. Decoding with RawProto restores
+	// messages from the wire format when the field is set, unless DecodeMask is set.
+	RawProto bool
+	// FieldTransforms rename, redact, compute or add fields of the records of messages, with CEL expressions
+	// that are compiled once per message and transform. Renamed fields are decoded into their fields, and
+	// derived fields are skipped when decoding.
+	FieldTransforms []FieldTransform
+	// Tokenizer holds the keys of field transforms that tokenize fields, see FieldTransform.Tokenize.
+	Tokenizer *Tokenizer
+	// Deterministic encodes identical messages as identical bytes across builds of the program, for deduplication
+	// and content hashing downstream. Entries of maps are always sorted by the string of their keys, and are
+	// sorted in the natural order of their keys with Deterministic: integers numerically, and false before true.
+	// Values of google.protobuf.Struct and google.protobuf.Any are encoded as compact JSON, since the whitespace
+	// of protobuf JSON varies between builds, and the keys of Avro JSON encoded through goavro, which writes the
+	// fields of records in random order, are sorted.
+	Deterministic bool
+	// MaxRecordBytes is the maximum size in bytes of a decoded record, after RecordCompression, to protect
+	// services from hostile or corrupted inputs. Records of Avro binary or Avro JSON data, and records of Object
+	// Container Files, that are larger fail to decode with a LimitError. Compressed records and blocks of
+	// Object Container Files stop decompressing at the limit, and items of arrays and maps decoded directly
+	// count as at least one byte each, so that items that take no bytes can not decode to unbounded lists.
+	// Zero means no limit.
+	MaxRecordBytes int
+	// MaxNestingDepth is the maximum number of records, arrays, maps and unions that a decoded record, itself
+	// included, may be nested in, to protect services from deeply nested inputs. Records that are nested
+	// deeper fail to decode with a LimitError. Messages decoded directly from Avro binary stop decoding at the
+	// limit, and other values are checked once they are decoded to generic values. Zero means no limit.
+	MaxNestingDepth int
+	// Workers is the number of goroutines used to encode messages in MarshalBatch.
+	// Defaults to GOMAXPROCS.
+	Workers int
+	// ReadParallelism is the number of blocks of Object Container Files that unmarshalers decompress and decode
+	// concurrently, ahead of Unmarshal. Messages are still unmarshaled in the order of the file. Zero or one
+	// decodes blocks one at a time, when they are unmarshaled.
+	ReadParallelism int
+	// RecordFilter skips the records of Object Container Files read by unmarshalers for which it returns false,
+	// before they are decoded into messages, which speeds up selective scans of large files. The record is the
+	// generic goavro datum of the message, without the union of the root record, where values of unions, such
+	// as nullable fields, are maps keyed by the name of their type. Scan returns true only for records that
+	// satisfy the filter, and ScanContext stops skipping records when its context is done.
+	RecordFilter func(record map[string]interface{}) bool
+	// Compression is the name of the codec that compresses the blocks of Object Container Files written by
+	// marshalers: CompressionNull (default), CompressionDeflate, CompressionSnappy, or the name of a codec
+	// registered with RegisterCompressionCodec, such as CompressionZstandard.
+	// Marshalers appending to an existing file use the codec of the file.
+	Compression string
+	// RecordCompression is the name of the codec that compresses the standalone records encoded by Codec, for
+	// example the payloads of Kafka messages, behind a small header with the name of the codec: CompressionNull
+	// (default), CompressionDeflate, CompressionSnappy, CompressionLZ4, or CompressionZstandard after importing
+	// the package encoding/protoavro/zstdavro. Codecs detect and decompress compressed records regardless of
+	// the option, see DecompressRecord, and stop decompressing records that exceed MaxRecordBytes.
+	RecordCompression string
+	// OCFMetadata is custom metadata written to the headers of Object Container Files written by marshalers,
+	// for example the metadata of a SchemaVersion. Keys with the prefix "avro." are reserved by the Avro
+	// specification. Marshalers appending to an existing file keep the metadata of the file.
+	OCFMetadata map[string][]byte
+	// EmbedDescriptor writes the descriptors of the written messages, and of the files they import, to the
+	// headers of Object Container Files written by marshalers, so that readers can recover the exact descriptors
+	// the file was written with from the file itself, see Unmarshaler.MessageDescriptors.
+	EmbedDescriptor bool
+	// CompressDescriptor compresses the descriptors written with EmbedDescriptor with gzip, which typically
+	// shrinks them several times for messages that import many files.
+	CompressDescriptor bool
+	// ProtoVersionFunc returns the version of a protobuf file in SchemaVersion, for example from a custom file
+	// option set to the commit of the sources. Defaults to DescriptorHash.
+	ProtoVersionFunc func(file protoreflect.FileDescriptor) string
+	// RecordChecksums appends a field ChecksumField to the root record of Object Container Files written by
+	// marshalers of a message, with the CRC-32C checksum of the binary encoding of the record, and marks the
+	// record with the custom property ChecksumProperty. Unmarshalers verify the checksum of every record of files
+	// with the property, and fail with ErrChecksumMismatch on corrupted records that would otherwise decode
+	// without errors. Schemas returned by InferSchema, and other encodings, have no checksum field.
+	RecordChecksums bool
+	// DictionaryFieldFunc returns true for the string fields of root messages whose values are dictionary encoded
+	// in Object Container Files written by marshalers, for example fields tagged with a custom field option.
+	// Values of dictionary encoded fields are written as int indexes into a dictionary of the file, and each
+	// value is written once, in the trailing field DictionaryField of the first record it occurs in. The fields
+	// are listed in the metadata DictionaryMetadataKey, and unmarshalers decode their values transparently.
+	// Dictionaries suit fields with few distinct values, such as identifiers of vehicles, and grow with every
+	// distinct value. Files with dictionary encoded fields can not be appended to, and readers of such files
+	// can not seek, since records reference values of the records before them.
+	DictionaryFieldFunc func(field protoreflect.FieldDescriptor) bool
+	// EncryptedFieldFunc returns the ID of the key that values of the field are encrypted with by FieldCipher,
+	// for example read from a custom field option, or an empty ID for fields that are not encrypted. Encrypted
+	// fields are mapped to bytes of the ciphertext of their protobuf binary encoding, with the key ID as the
+	// custom property EncryptionKeyProperty, and are null when not set. Fields of map entries are not encrypted.
+	EncryptedFieldFunc func(field protoreflect.FieldDescriptor) string
+	// FieldCipher encrypts the values of the fields selected by EncryptedFieldFunc when encoding, and decrypts
+	// them when decoding.
+	FieldCipher FieldCipher
+	// Instrumentation receives telemetry from marshaling and unmarshaling. Nil disables instrumentation.
+	Instrumentation Instrumentation
+	// FlightRecorder samples the records written by marshalers, for debugging. Nil disables sampling.
+	FlightRecorder *FlightRecorder
+	// ValidateEncoding validates every encoded message against the schema inferred for the message, before
+	// the encoding is returned or written, and fails with the path of the first invalid value. Validation
+	// catches mismatches introduced by custom codecs registered with RegisterMessageCodec, or by bugs in the
+	// mapping. Validation infers the schema of each encoded message, and is meant for debugging and tests.
+	ValidateEncoding bool
+
+	// names holds the Avro names of disambiguated types.
+	names map[protoreflect.FullName]string
+}
+
+// clone returns a copy of the options that shares no mutable state with the options, so that marshalers and
+// codecs are unaffected by changes to the field masks, envelope fields and fixed sizes they were created with.
+func (o SchemaOptions) clone() SchemaOptions {
+	if o.DecodeMask != nil {
+		o.DecodeMask = proto.Clone(o.DecodeMask).(*fieldmaskpb.FieldMask)
+	}
+	if o.SchemaMask != nil {
+		o.SchemaMask = proto.Clone(o.SchemaMask).(*fieldmaskpb.FieldMask)
+	}
+	if o.EnvelopeFields != nil {
+		o.EnvelopeFields = append([]EnvelopeField(nil), o.EnvelopeFields...)
+	}
+	if o.FieldTransforms != nil {
+		o.FieldTransforms = append([]FieldTransform(nil), o.FieldTransforms...)
+	}
+	if o.FlattenMessages != nil {
+		o.FlattenMessages = append([]protoreflect.FullName(nil), o.FlattenMessages...)
+	}
+	if o.FixedSizes != nil {
+		fixedSizes := make(map[protoreflect.FullName]int, len(o.FixedSizes))
+		for name, size := range o.FixedSizes {
+			fixedSizes[name] = size
+		}
+		o.FixedSizes = fixedSizes
+	}
+	return o
 }
@@ -0,0 +1,35 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// nullWhenUnset returns true if the field is encoded as null when it is not set, which are fields of oneofs,
+// including proto3 optional fields, and with PreservePresence, scalar fields with explicit presence.
+// Other scalar fields that are not set are encoded as their default value.
+func (o SchemaOptions) nullWhenUnset(field protoreflect.FieldDescriptor) bool {
+	if field.IsList() || field.IsMap() {
+		return false
+	}
+	return field.ContainingOneof() != nil || (o.PreservePresence && field.HasPresence() && field.Message() == nil)
+}
+
+// encodesNull returns true if the field of the message is encoded as null, since it is not set.
+func (o SchemaOptions) encodesNull(message protoreflect.Message, field protoreflect.FieldDescriptor) bool {
+	return o.nullWhenUnset(field) && !message.Has(field)
+}
+
+// checkOneofCase returns an error if another field of the oneof of the field is already set in the message,
+// so that the case of a decoded oneof is the field of the record that was not null.
+func checkOneofCase(msg protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	oneof := fd.ContainingOneof()
+	if oneof == nil {
+		return nil
+	}
+	if set := msg.WhichOneof(oneof); set != nil && set.Number() != fd.Number() {
+		return fmt.Errorf("oneof %s: fields %s and %s are both set", oneof.Name(), set.Name(), fd.Name())
+	}
+	return nil
+}
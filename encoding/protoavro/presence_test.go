@@ -0,0 +1,186 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestPreservePresence(t *testing.T) {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/presence.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Presence"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:   proto.String("name"),
+						Number: proto.Int32(1),
+						Label:  optional,
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+					{
+						Name:   proto.String("count"),
+						Number: proto.Int32(2),
+						Label:  optional,
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+					},
+					{
+						Name:     proto.String("nested"),
+						Number:   proto.Int32(3),
+						Label:    optional,
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".example.v1.Presence.Nested"),
+					},
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Nested"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:   proto.String("value"),
+								Number: proto.Int32(1),
+								Label:  optional,
+								Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	assert.NilError(t, err)
+	desc := file.Messages().Get(0)
+	name, count, nested := desc.Fields().ByName("name"), desc.Fields().ByName("count"), desc.Fields().ByName("nested")
+	nestedDesc := nested.Message()
+
+	for _, tt := range []struct {
+		name string
+		set  func(msg *dynamicpb.Message)
+	}{
+		{
+			name: "unset",
+			set:  func(msg *dynamicpb.Message) {},
+		},
+		{
+			name: "set to default values",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(name, protoreflect.ValueOfString(""))
+				msg.Set(count, protoreflect.ValueOfInt64(0))
+				msg.Set(nested, protoreflect.ValueOfMessage(dynamicpb.NewMessage(nestedDesc)))
+			},
+		},
+		{
+			name: "set",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(name, protoreflect.ValueOfString("name"))
+				msg.Set(count, protoreflect.ValueOfInt64(42))
+				value := dynamicpb.NewMessage(nestedDesc)
+				value.Set(nestedDesc.Fields().ByName("value"), protoreflect.ValueOfString(""))
+				msg.Set(nested, protoreflect.ValueOfMessage(value))
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SchemaOptions{PreservePresence: true}
+			msg := dynamicpb.NewMessage(desc)
+			tt.set(msg)
+			schema, err := opts.InferSchema(desc)
+			assert.NilError(t, err)
+			codec := newTestGoavroCodec(t, opts, msg)
+			native, err := opts.encodeJSON(msg)
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, native)
+			assert.NilError(t, err)
+			encoder, err := opts.newAvroJSONEncoder(desc, schema)
+			assert.NilError(t, err)
+			data, err := encoder.encode(nil, msg)
+			assert.NilError(t, err)
+			// the direct encoder agrees with the generic encoder
+			decodedNative, _, err := codec.NativeFromTextual(data)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, native, decodedNative)
+			binaryDecoder, err := opts.newBinaryDecoder(desc, schema)
+			assert.NilError(t, err)
+			jsonDecoder, err := opts.newAvroJSONDecoder(desc, schema)
+			assert.NilError(t, err)
+			for decoderName, decode := range map[string]func(msg protoreflect.Message) error{
+				"generic": func(msg protoreflect.Message) error {
+					return opts.decodeJSON(native, msg.Interface())
+				},
+				"binary": func(msg protoreflect.Message) error {
+					return binaryDecoder.decode(binary, msg)
+				},
+				"avro json": func(msg protoreflect.Message) error {
+					return jsonDecoder.decode(bytes.NewReader(data), msg)
+				},
+			} {
+				decoded := dynamicpb.NewMessage(desc)
+				assert.NilError(t, decode(decoded), decoderName)
+				assert.DeepEqual(t, msg, decoded, protocmp.Transform())
+				for _, field := range []protoreflect.FieldDescriptor{name, count, nested} {
+					assert.Equal(t, msg.Has(field), decoded.Has(field), "%s: %s", decoderName, field.Name())
+				}
+			}
+		})
+	}
+	t.Run("default", func(t *testing.T) {
+		// scalar fields that are not set are encoded as their default value, and decoded as set
+		opts := SchemaOptions{}
+		msg := dynamicpb.NewMessage(desc)
+		native, err := opts.encodeJSON(msg)
+		assert.NilError(t, err)
+		decoded := dynamicpb.NewMessage(desc)
+		assert.NilError(t, opts.decodeJSON(native, decoded))
+		assert.Assert(t, decoded.Has(name))
+		assert.Assert(t, decoded.Has(count))
+		assert.Assert(t, !decoded.Has(nested))
+	})
+}
+
+func TestDecode_OneofCase(t *testing.T) {
+	// both fields of the first oneof are set
+	data := []byte(`{"einride.avro.example.v1.ExampleOneof":{` +
+		`"oneof_empty_message_1":{"einride.avro.example.v1.ExampleOneof.EmptyMessage":{}},` +
+		`"oneof_bool_1":{"boolean":true},"oneof_empty_message_2":null,"oneof_message":null}}`)
+	opts := SchemaOptions{}
+	desc := (&examplev1.ExampleOneof{}).ProtoReflect().Descriptor()
+	schema, err := opts.InferSchema(desc)
+	assert.NilError(t, err)
+	codec := newTestGoavroCodec(t, opts, &examplev1.ExampleOneof{})
+	native, _, err := codec.NativeFromTextual(data)
+	assert.NilError(t, err)
+	binary, err := codec.BinaryFromNative(nil, native)
+	assert.NilError(t, err)
+	binaryDecoder, err := opts.newBinaryDecoder(desc, schema)
+	assert.NilError(t, err)
+	jsonDecoder, err := opts.newAvroJSONDecoder(desc, schema)
+	assert.NilError(t, err)
+	for name, decode := range map[string]func(msg *examplev1.ExampleOneof) error{
+		"generic": func(msg *examplev1.ExampleOneof) error {
+			return opts.decodeJSON(native, msg)
+		},
+		"binary": func(msg *examplev1.ExampleOneof) error {
+			return binaryDecoder.decode(binary, msg.ProtoReflect())
+		},
+		"avro json": func(msg *examplev1.ExampleOneof) error {
+			return jsonDecoder.decode(bytes.NewReader(data), msg.ProtoReflect())
+		},
+	} {
+		var msg examplev1.ExampleOneof
+		assert.ErrorContains(t, decode(&msg), "oneof oneof_fields_1", name)
+	}
+}
@@ -0,0 +1,106 @@
+package protoavrotest
+
+import (
+	"io"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+)
+
+// Benchmark runs sub-benchmarks of schema inference, encoding and decoding of the fixtures, with the given options.
+//
+// Sub-benchmarks are named <fixture>/<operation>, and report allocations, so that the output of two runs can be
+// compared with benchstat.
+func Benchmark(b *testing.B, opts protoavro.SchemaOptions, fixtures ...Fixture) {
+	b.Helper()
+	for _, fixture := range fixtures {
+		fixture := fixture
+		desc := fixture.Message.ProtoReflect().Descriptor()
+		b.Run(fixture.Name+"/InferSchema", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := opts.InferSchema(desc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fixture.Name+"/Encode", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := opts.Encode(fixture.Message); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		native, err := opts.Encode(fixture.Message)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fixture.Name+"/Decode", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := opts.Decode(native, fixture.Message.ProtoReflect().New().Interface()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		codec, err := opts.NewGoavroCodec(desc)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fixture.Name+"/MarshalBinary", func(b *testing.B) {
+			b.ReportAllocs()
+			var buf []byte
+			for i := 0; i < b.N; i++ {
+				native, err := opts.Encode(fixture.Message)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if buf, err = codec.BinaryFromNative(buf[:0], native); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		data, err := codec.BinaryFromNative(nil, native)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fixture.Name+"/UnmarshalBinary", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				native, _, err := codec.NativeFromBinary(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := opts.Decode(native, fixture.Message.ProtoReflect().New().Interface()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fixture.Name+"/MarshalAvroJSON", func(b *testing.B) {
+			encoder, err := opts.NewAvroJSONEncoder(desc, io.Discard)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := encoder.Encode(fixture.Message); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		textual, err := opts.MarshalAvroJSON(fixture.Message)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fixture.Name+"/UnmarshalAvroJSON", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := opts.UnmarshalAvroJSON(textual, fixture.Message.ProtoReflect().New().Interface()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package protoavrotest provides representative messages and benchmarks of the protobuf mapping of
+// package protoavro, to compare the performance of encoding and decoding between versions, or to
+// benchmark the mapping of your own messages.
+package protoavrotest
@@ -0,0 +1,140 @@
+package protoavrotest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	publicv1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/bigquery/public/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Fixture is a representative message to benchmark the mapping with.
+type Fixture struct {
+	// Name of the fixture, used as the name of its sub-benchmarks.
+	Name string
+	// Message of the fixture.
+	Message proto.Message
+}
+
+// Fixtures returns representative messages:
+//
+//   - small: a message of a few scalar fields.
+//   - large: a message of many fields, with repeated nested messages and well-known types.
+//   - nested: a deeply nested recursive message.
+//   - repeated: a message of long lists of scalars, enums, messages and well-known types.
+//   - map: a message of large maps.
+//   - wkt: a message of well-known types only.
+func Fixtures() []Fixture {
+	return []Fixture{
+		{Name: "small", Message: smallFixture()},
+		{Name: "large", Message: largeFixture()},
+		{Name: "nested", Message: nestedFixture()},
+		{Name: "repeated", Message: repeatedFixture()},
+		{Name: "map", Message: mapFixture()},
+		{Name: "wkt", Message: wktFixture()},
+	}
+}
+
+func smallFixture() proto.Message {
+	return &library.Book{
+		Name:   "shelves/1/books/1",
+		Author: "J. K. Rowling",
+		Title:  "Harry Potter and the Philosopher's Stone",
+		Read:   true,
+	}
+}
+
+func largeFixture() proto.Message {
+	blockTime := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+	tx := &publicv1.DogecoinTransaction{
+		Hash:                strings.Repeat("ab", 32),
+		Size:                1_234,
+		VirtualSize:         1_234,
+		Version:             1,
+		LockTime:            0,
+		BlockHash:           strings.Repeat("cd", 32),
+		BlockNumber:         3_765_432,
+		BlockTimestamp:      timestamppb.New(blockTime),
+		BlockTimestampMonth: &date.Date{Year: 2021, Month: 6, Day: 1},
+		InputCount:          64,
+		OutputCount:         64,
+		IsCoinbase:          false,
+	}
+	for i := 0; i < 64; i++ {
+		tx.Inputs = append(tx.Inputs, &publicv1.DogecoinTransaction_Input{
+			Index:                int64(i),
+			SpentTransactionHash: strings.Repeat("ef", 32),
+			SpentOutputIndex:     int64(i),
+			ScriptAsm:            strings.Repeat("OP_DUP OP_HASH160 ", 8),
+			ScriptHex:            strings.Repeat("76a914", 16),
+			Sequence:             4_294_967_295,
+			RequiredSignatures:   1,
+			Type:                 "pubkeyhash",
+			Addresses:            []string{fmt.Sprintf("D%033d", i)},
+		})
+		tx.Outputs = append(tx.Outputs, &publicv1.DogecoinTransaction_Output{
+			Index:              int64(i),
+			ScriptAsm:          strings.Repeat("OP_DUP OP_HASH160 ", 8),
+			ScriptHex:          strings.Repeat("76a914", 16),
+			RequiredSignatures: 1,
+			Type:               "pubkeyhash",
+			Addresses:          []string{fmt.Sprintf("D%033d", i)},
+		})
+	}
+	return tx
+}
+
+func nestedFixture() proto.Message {
+	msg := &examplev1.ExampleRecursive{}
+	for i := 0; i < 32; i++ {
+		msg = &examplev1.ExampleRecursive{Recursive: msg}
+	}
+	return msg
+}
+
+func repeatedFixture() proto.Message {
+	msg := &examplev1.ExampleList{}
+	for i := 0; i < 256; i++ {
+		msg.Int64List = append(msg.Int64List, int64(i)<<20)
+		msg.StringList = append(msg.StringList, fmt.Sprintf("item-%d", i))
+		msg.EnumList = append(msg.EnumList, examplev1.ExampleList_Enum(i%3))
+		msg.NestedList = append(msg.NestedList, &examplev1.ExampleList_Nested{StringList: []string{"a", "b"}})
+		msg.FloatValueList = append(msg.FloatValueList, wrapperspb.Float(float32(i)/2))
+	}
+	return msg
+}
+
+func mapFixture() proto.Message {
+	msg := &examplev1.ExampleMap{
+		StringToString: make(map[string]string),
+		StringToNested: make(map[string]*examplev1.ExampleMap_Nested),
+		Int64ToString:  make(map[int64]string),
+	}
+	for i := 0; i < 256; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		msg.StringToString[key] = fmt.Sprintf("value-%d", i)
+		msg.StringToNested[key] = &examplev1.ExampleMap_Nested{StringToString: map[string]string{"a": "b"}}
+		msg.Int64ToString[int64(i)<<20] = key
+	}
+	return msg
+}
+
+func wktFixture() proto.Message {
+	return &examplev1.ExampleWrappers{
+		FloatValue:  wrapperspb.Float(1.5),
+		DoubleValue: wrapperspb.Double(2.5),
+		StringValue: wrapperspb.String("foo"),
+		BytesValue:  wrapperspb.Bytes([]byte("bar")),
+		Int32Value:  wrapperspb.Int32(-32),
+		Int64Value:  wrapperspb.Int64(-64),
+		Uint32Value: wrapperspb.UInt32(32),
+		Uint64Value: wrapperspb.UInt64(64),
+		BoolValue:   wrapperspb.Bool(true),
+	}
+}
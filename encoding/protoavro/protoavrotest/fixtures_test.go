@@ -0,0 +1,22 @@
+package protoavrotest
+
+import (
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestFixtures(t *testing.T) {
+	for _, fixture := range Fixtures() {
+		fixture := fixture
+		t.Run(fixture.Name, func(t *testing.T) {
+			data, err := protoavro.SchemaOptions{}.MarshalAvroJSON(fixture.Message)
+			assert.NilError(t, err)
+			got := fixture.Message.ProtoReflect().New().Interface()
+			assert.NilError(t, protoavro.SchemaOptions{}.UnmarshalAvroJSON(data, got))
+			assert.DeepEqual(t, fixture.Message, got, protocmp.Transform())
+		})
+	}
+}
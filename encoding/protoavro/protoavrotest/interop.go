@@ -0,0 +1,265 @@
+package protoavrotest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+//go:embed testdata/interop
+var interopFS embed.FS
+
+// InteropCase is a golden case of the Avro encodings of a message, as written by another implementation of
+// Avro, to check that the mapping of the message stays compatible with readers and writers in other languages.
+type InteropCase struct {
+	// Name of the case, used as the name of its subtest.
+	Name string
+	// Options that the message is mapped with.
+	Options protoavro.SchemaOptions
+	// Message of the case.
+	Message proto.Message
+	// Schema is the JSON of the Avro schema of the message.
+	Schema []byte
+	// Binary is the Avro binary encoding of the message.
+	Binary []byte
+	// JSON is the Avro JSON encoding of the message.
+	JSON []byte
+}
+
+// ReadInteropCase reads the schema, and the binary and JSON encodings of a case, from the files schema.avsc,
+// datum.bin and datum.json of the directory, for example as written by avro-tools jsontofrag and fragtojson.
+// The case is named by the directory, and its message and options are set by the caller.
+func ReadInteropCase(fsys fs.FS, dir string) (InteropCase, error) {
+	c := InteropCase{Name: path.Base(dir)}
+	for _, file := range []struct {
+		name string
+		data *[]byte
+	}{
+		{name: "schema.avsc", data: &c.Schema},
+		{name: "datum.bin", data: &c.Binary},
+		{name: "datum.json", data: &c.JSON},
+	} {
+		data, err := fs.ReadFile(fsys, path.Join(dir, file.name))
+		if err != nil {
+			return InteropCase{}, fmt.Errorf("read interop case %s: %w", c.Name, err)
+		}
+		*file.data = data
+	}
+	return c, nil
+}
+
+// InteropCases returns the golden cases of the package, with encodings in the formats of the Java
+// implementation of Avro, which names the union branches of logical types by their type:
+//
+//   - scalars: a message of all scalar kinds, with negative, large and non-ASCII values.
+//   - unsigned: a message of unsigned kinds with values above the signed range of their Avro types, which wrap
+//     around to negative values.
+//   - list: a message of lists of scalars, enums, messages and wrappers.
+//   - enum: a message of an enum.
+//   - timestamp: a message of a timestamp.
+func InteropCases() []InteropCase {
+	opts := protoavro.SchemaOptions{OmitRootElement: true, OmitDocs: true, AvroJSONMode: protoavro.AvroJSONJava}
+	cases := []InteropCase{
+		{
+			Name: "scalars",
+			Message: &examplev1.ExampleScalars{
+				Double:   1.5,
+				Float:    -0.25,
+				Int32:    -1,
+				Int64:    1 << 40,
+				Uint32:   4294967295,
+				Uint64:   7,
+				Sint32:   -64,
+				Sint64:   64,
+				Fixed32:  3,
+				Fixed64:  4,
+				Sfixed32: -5,
+				Sfixed64: -6,
+				Bool:     true,
+				String_:  "héllo",
+				Bytes:    []byte{0x00, 0x7f, 0x80, 0xff},
+			},
+		},
+		{
+			Name: "unsigned",
+			Message: &examplev1.ExampleScalars{
+				Uint64:  1 << 63,
+				Fixed32: 1 << 31,
+				Fixed64: math.MaxUint64,
+			},
+		},
+		{
+			Name: "list",
+			Message: &examplev1.ExampleList{
+				Int64List:      []int64{1, -2},
+				StringList:     []string{"a"},
+				EnumList:       []examplev1.ExampleList_Enum{examplev1.ExampleList_ENUM_VALUE2},
+				NestedList:     []*examplev1.ExampleList_Nested{{StringList: []string{"b"}}},
+				FloatValueList: []*wrapperspb.FloatValue{wrapperspb.Float(2)},
+			},
+		},
+		{
+			Name:    "enum",
+			Message: &examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE3},
+		},
+		{
+			Name: "timestamp",
+			Message: &examplev1.ExampleTimestamp{
+				Timestamp: timestamppb.New(time.Date(2021, 1, 2, 3, 4, 5, 6000, time.UTC)),
+			},
+		},
+	}
+	for i, c := range cases {
+		golden, err := ReadInteropCase(interopFS, path.Join("testdata/interop", c.Name))
+		if err != nil {
+			// the cases are embedded
+			panic(err)
+		}
+		golden.Options = opts
+		golden.Message = c.Message
+		cases[i] = golden
+	}
+	return cases
+}
+
+// CheckInterop returns an error if the message of the case does not map to the schema of the case, if the
+// message does not encode to the binary encoding of the case byte for byte, or to its JSON encoding, or if the
+// encodings do not decode to the message. JSON encodings are compared as JSON values, since implementations
+// differ in whitespace, escaping and the formatting of numbers.
+func CheckInterop(c InteropCase) error {
+	opts := c.Options
+	desc := c.Message.ProtoReflect().Descriptor()
+	schema, err := opts.InferSchema(desc)
+	if err != nil {
+		return fmt.Errorf("infer schema: %w", err)
+	}
+	expectedSchema, err := avro.Parse(c.Schema)
+	if err != nil {
+		return fmt.Errorf("parse schema: %w", err)
+	}
+	if !reflect.DeepEqual(expectedSchema, schema) {
+		got, _ := avro.MarshalMinified(schema)
+		return fmt.Errorf("schema %s, expected %s", got, bytes.TrimSpace(c.Schema))
+	}
+	binary, err := opts.MarshalBatch([]proto.Message{c.Message})
+	if err != nil {
+		return fmt.Errorf("encode binary: %w", err)
+	}
+	if !bytes.Equal(binary[0], c.Binary) {
+		return fmt.Errorf("binary encoding %x, expected %x", binary[0], c.Binary)
+	}
+	codec, err := opts.NewGoavroCodec(desc)
+	if err != nil {
+		return err
+	}
+	native, rest, err := codec.NativeFromBinary(c.Binary)
+	if err != nil {
+		return fmt.Errorf("decode binary: %w", err)
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("decode binary: %d bytes after datum", len(rest))
+	}
+	decoded := c.Message.ProtoReflect().New().Interface()
+	if err := opts.Decode(native, decoded); err != nil {
+		return fmt.Errorf("decode binary: %w", err)
+	}
+	if !proto.Equal(c.Message, decoded) {
+		return fmt.Errorf("decoded binary %v, expected %v", decoded, c.Message)
+	}
+	data, err := opts.MarshalAvroJSON(c.Message)
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	equal, err := equalJSON(data, c.JSON)
+	if err != nil {
+		return fmt.Errorf("compare json: %w", err)
+	}
+	if !equal {
+		return fmt.Errorf("json encoding %s, expected %s", data, bytes.TrimSpace(c.JSON))
+	}
+	decoded = c.Message.ProtoReflect().New().Interface()
+	if err := opts.UnmarshalAvroJSON(bytes.TrimSpace(c.JSON), decoded); err != nil {
+		return fmt.Errorf("decode json: %w", err)
+	}
+	if !proto.Equal(c.Message, decoded) {
+		return fmt.Errorf("decoded json %v, expected %v", decoded, c.Message)
+	}
+	return nil
+}
+
+// AssertInterop checks the cases with CheckInterop, in a subtest per case.
+func AssertInterop(t *testing.T, cases ...InteropCase) {
+	t.Helper()
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if err := CheckInterop(c); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// equalJSON returns true if the JSON documents are equal as values, with numbers compared by value.
+func equalJSON(a, b []byte) (bool, error) {
+	va, err := decodeJSONValue(a)
+	if err != nil {
+		return false, err
+	}
+	vb, err := decodeJSONValue(b)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(va, vb), nil
+}
+
+func decodeJSONValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(value), nil
+}
+
+// normalizeJSONNumbers replaces the numbers of the value by integers, or floats when they are not integers,
+// so that numbers formatted differently compare equal, such as 2 and 2.0.
+func normalizeJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONNumbers(item)
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			if i := int64(f); float64(i) == f {
+				return i
+			}
+			return f
+		}
+	}
+	return value
+}
@@ -0,0 +1,21 @@
+package protoavrotest
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestInteropCases(t *testing.T) {
+	AssertInterop(t, InteropCases()...)
+}
+
+func TestCheckInterop(t *testing.T) {
+	c := InteropCases()[0]
+	c.Binary = append([]byte(nil), c.Binary...)
+	c.Binary[1] ^= 0xff
+	assert.ErrorContains(t, CheckInterop(c), "binary encoding")
+	c = InteropCases()[0]
+	c.JSON = []byte(`{"double":{"double":2.5}}`)
+	assert.ErrorContains(t, CheckInterop(c), "json encoding")
+}
@@ -0,0 +1,19 @@
+#!/usr/bin/env bash
+# Regenerates the golden interop cases in testdata/interop with avro-tools of the Java implementation of Avro.
+# The binary encoding of each case is written by jsontofrag from its schema and JSON encoding, and the JSON
+# encoding is rewritten by fragtojson from the binary encoding, so that both are as written by Java.
+set -euo pipefail
+
+AVRO_VERSION="${AVRO_VERSION:-1.11.3}"
+cd "$(dirname "$0")/interop"
+jar="${TMPDIR:-/tmp}/avro-tools-${AVRO_VERSION}.jar"
+if [ ! -f "$jar" ]; then
+	curl -fsSL -o "$jar" \
+		"https://repo1.maven.org/maven2/org/apache/avro/avro-tools/${AVRO_VERSION}/avro-tools-${AVRO_VERSION}.jar"
+fi
+for dir in */; do
+	dir="${dir%/}"
+	java -jar "$jar" jsontofrag --schema-file "$dir/schema.avsc" "$dir/datum.json" >"$dir/datum.bin.tmp"
+	mv "$dir/datum.bin.tmp" "$dir/datum.bin"
+	java -jar "$jar" fragtojson --no-pretty --schema-file "$dir/schema.avsc" "$dir/datum.bin" >"$dir/datum.json"
+done
@@ -0,0 +1,56 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoJSONToAvroJSON converts the protobuf JSON encoding of a message of desc, see protojson.Unmarshal, to the
+// JSON encoding of the Avro specification of the schema inferred for the message, see MarshalAvroJSON, so that
+// messages received as protobuf JSON can be written as Avro without generated Go types.
+// Messages of types that are not registered in protoregistry.GlobalTypes are decoded as dynamic messages.
+func (o SchemaOptions) ProtoJSONToAvroJSON(desc protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+	message, err := unmarshalProtoJSON(desc, data)
+	if err != nil {
+		return nil, err
+	}
+	return o.MarshalAvroJSON(message.Interface())
+}
+
+// ProtoJSONToAvroBinary converts the protobuf JSON encoding of a message of desc, see protojson.Unmarshal, to the
+// Avro binary encoding of the schema inferred for the message, compressed with RecordCompression when it is set,
+// like records encoded by Codec.
+// Messages of types that are not registered in protoregistry.GlobalTypes are decoded as dynamic messages.
+func (o SchemaOptions) ProtoJSONToAvroBinary(desc protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+	message, err := unmarshalProtoJSON(desc, data)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := o.InferSchema(desc)
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	codec, err := newGoavroCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+	native, err := o.encodeJSON(message.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("encode json: %w", err)
+	}
+	b, err := codec.BinaryFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("binary from native: %w", err)
+	}
+	return CompressRecord(o.RecordCompression, b)
+}
+
+func unmarshalProtoJSON(desc protoreflect.MessageDescriptor, data []byte) (protoreflect.Message, error) {
+	message := newMessage(desc)
+	if err := protojson.Unmarshal(data, message.Interface()); err != nil {
+		return nil, fmt.Errorf("unmarshal proto json: %w", err)
+	}
+	return message, nil
+}
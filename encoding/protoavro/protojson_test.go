@@ -0,0 +1,106 @@
+package protoavro
+
+import (
+	"testing"
+	"time"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gotest.tools/v3/assert"
+)
+
+func TestProtoJSONToAvro(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts SchemaOptions
+		msg  proto.Message
+	}{
+		{
+			name: "book",
+			msg:  &library.Book{Name: "shelves/1/books/1", Title: "Dune", Author: "Frank Herbert", Read: true},
+		},
+		{
+			name: "well-known types",
+			msg:  &examplev1.ExampleTimestamp{Timestamp: timestamppb.New(time.Unix(1700000000, 1000).UTC())},
+		},
+		{
+			name: "record compression",
+			opts: SchemaOptions{RecordCompression: CompressionDeflate},
+			msg:  &library.Book{Name: "shelves/1/books/1", Title: "Dune"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := protojson.Marshal(tt.msg)
+			assert.NilError(t, err)
+			desc := tt.msg.ProtoReflect().Descriptor()
+
+			avroJSON, err := tt.opts.ProtoJSONToAvroJSON(desc, data)
+			assert.NilError(t, err)
+			got := tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, tt.opts.UnmarshalAvroJSON(avroJSON, got))
+			assert.DeepEqual(t, tt.msg, got, protocmp.Transform())
+
+			avroBinary, err := tt.opts.ProtoJSONToAvroBinary(desc, data)
+			assert.NilError(t, err)
+			record, err := DecompressRecord(avroBinary)
+			assert.NilError(t, err)
+			native, _, err := newTestGoavroCodec(t, tt.opts, tt.msg).NativeFromBinary(record)
+			assert.NilError(t, err)
+			got = tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, tt.opts.Decode(native, got))
+			assert.DeepEqual(t, tt.msg, got, protocmp.Transform())
+		})
+	}
+
+	t.Run("dynamic message", func(t *testing.T) {
+		file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+			Name:       proto.String("example/v1/event.proto"),
+			Package:    proto.String("example.v1"),
+			Syntax:     proto.String("proto3"),
+			Dependency: []string{"google/protobuf/timestamp.proto"},
+			MessageType: []*descriptorpb.DescriptorProto{
+				{
+					Name: proto.String("Event"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						{
+							Name:     proto.String("id"),
+							Number:   proto.Int32(1),
+							Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							JsonName: proto.String("id"),
+						},
+						{
+							Name:     proto.String("create_time"),
+							Number:   proto.Int32(2),
+							Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+							TypeName: proto.String(".google.protobuf.Timestamp"),
+							JsonName: proto.String("createTime"),
+						},
+					},
+				},
+			},
+		}, protoregistry.GlobalFiles)
+		assert.NilError(t, err)
+		desc := file.Messages().Get(0)
+		data := []byte(`{"id": "e1", "createTime": "2023-11-14T22:13:20Z"}`)
+		avroJSON, err := SchemaOptions{}.ProtoJSONToAvroJSON(desc, data)
+		assert.NilError(t, err)
+		expected := `{"example.v1.Event":{"id":{"string":"e1"},` +
+			`"create_time":{"long.timestamp-micros":1700000000000000}}}`
+		assert.Equal(t, expected, string(avroJSON))
+		_, err = SchemaOptions{}.ProtoJSONToAvroBinary(desc, data)
+		assert.NilError(t, err)
+	})
+
+	t.Run("invalid proto json", func(t *testing.T) {
+		_, err := SchemaOptions{}.ProtoJSONToAvroBinary((&library.Book{}).ProtoReflect().Descriptor(), []byte(`{"title": 1}`))
+		assert.ErrorContains(t, err, "unmarshal proto json")
+	})
+}
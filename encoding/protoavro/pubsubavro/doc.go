@@ -0,0 +1,3 @@
+// Package pubsubavro integrates the Avro schemas of protobuf messages with Google Cloud Pub/Sub topics that
+// enforce an Avro schema.
+package pubsubavro
@@ -0,0 +1,125 @@
+package pubsubavro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Encoding is the message encoding declared in the schema settings of a Pub/Sub topic.
+type Encoding string
+
+const (
+	// EncodingBinary is the Avro binary encoding.
+	EncodingBinary Encoding = "BINARY"
+	// EncodingJSON is the Avro JSON encoding.
+	EncodingJSON Encoding = "JSON"
+)
+
+// Topic is the part of a Pub/Sub topic used by a Publisher, typically implemented by an adapter of the
+// topics of the Cloud Pub/Sub client that publishes a message and waits for its result.
+type Topic interface {
+	// Publish publishes a message with the data and attributes, and returns the server-assigned ID of the message.
+	Publish(ctx context.Context, data []byte, attributes map[string]string) (string, error)
+}
+
+// PublisherOptions configures a Publisher.
+type PublisherOptions struct {
+	// SchemaOptions are the options of the schema inferred for messages, and of their encoding.
+	SchemaOptions protoavro.SchemaOptions
+	// Encoding is the message encoding declared in the schema settings of the topic.
+	Encoding Encoding
+}
+
+// Publisher publishes protobuf messages to a Pub/Sub topic with an Avro schema, in the encoding declared by
+// the topic. A Publisher is safe for concurrent use.
+type Publisher struct {
+	topic      Topic
+	opts       PublisherOptions
+	descriptor protoreflect.MessageDescriptor
+	schema     avro.Schema
+	codec      *goavro.Codec
+}
+
+// NewPublisher returns a new publisher of messages of the descriptor to the topic.
+func NewPublisher(topic Topic, descriptor protoreflect.MessageDescriptor, opts PublisherOptions) (*Publisher, error) {
+	switch opts.Encoding {
+	case EncodingBinary, EncodingJSON:
+	default:
+		return nil, fmt.Errorf("new publisher: unsupported encoding %q", opts.Encoding)
+	}
+	schema, err := opts.SchemaOptions.InferSchema(descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("new publisher: infer schema: %w", err)
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("new publisher: json marshal schema: %w", err)
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new publisher: new codec: %w", err)
+	}
+	return &Publisher{
+		topic:      topic,
+		opts:       opts,
+		descriptor: descriptor,
+		schema:     schema,
+		codec:      codec,
+	}, nil
+}
+
+// SchemaDefinition returns the definition of the schema of the messages, in the form accepted by Pub/Sub Avro
+// schemas, for example to create the schema of the topic.
+func (p *Publisher) SchemaDefinition() (string, error) {
+	return FormatSchema(p.schema)
+}
+
+// Encode returns the message encoded in the encoding of the topic.
+func (p *Publisher) Encode(message proto.Message) ([]byte, error) {
+	if message.ProtoReflect().Descriptor().FullName() != p.descriptor.FullName() {
+		return nil, fmt.Errorf(
+			"unexpected message '%s', expected '%s'",
+			message.ProtoReflect().Descriptor().FullName(),
+			p.descriptor.FullName(),
+		)
+	}
+	native, err := p.opts.SchemaOptions.Encode(message)
+	if err != nil {
+		return nil, err
+	}
+	switch p.opts.Encoding {
+	case EncodingJSON:
+		data, err := p.codec.TextualFromNative(nil, native)
+		if err != nil {
+			return nil, fmt.Errorf("textual from native: %w", err)
+		}
+		return data, nil
+	default:
+		data, err := p.codec.BinaryFromNative(nil, native)
+		if err != nil {
+			return nil, fmt.Errorf("binary from native: %w", err)
+		}
+		return data, nil
+	}
+}
+
+// Publish encodes the message in the encoding of the topic, publishes it with the attributes, and returns
+// the server-assigned ID of the message.
+func (p *Publisher) Publish(ctx context.Context, message proto.Message, attributes map[string]string) (string, error) {
+	data, err := p.Encode(message)
+	if err != nil {
+		return "", fmt.Errorf("publish: encode: %w", err)
+	}
+	id, err := p.topic.Publish(ctx, data, attributes)
+	if err != nil {
+		return "", fmt.Errorf("publish: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,80 @@
+package pubsubavro_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/pubsubavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+// fakeTopic records published messages.
+type fakeTopic struct {
+	data       [][]byte
+	attributes []map[string]string
+	err        error
+}
+
+func (t *fakeTopic) Publish(_ context.Context, data []byte, attributes map[string]string) (string, error) {
+	if t.err != nil {
+		return "", t.err
+	}
+	t.data = append(t.data, data)
+	t.attributes = append(t.attributes, attributes)
+	return "1", nil
+}
+
+func TestPublisher(t *testing.T) {
+	ctx := context.Background()
+	book := &library.Book{Name: "shelves/1/books/1", Title: "Harry Potter", Author: "J. K. Rowling"}
+	desc := book.ProtoReflect().Descriptor()
+	for _, encoding := range []pubsubavro.Encoding{pubsubavro.EncodingBinary, pubsubavro.EncodingJSON} {
+		encoding := encoding
+		t.Run(string(encoding), func(t *testing.T) {
+			topic := &fakeTopic{}
+			publisher, err := pubsubavro.NewPublisher(topic, desc, pubsubavro.PublisherOptions{Encoding: encoding})
+			assert.NilError(t, err)
+			id, err := publisher.Publish(ctx, book, map[string]string{"origin": "test"})
+			assert.NilError(t, err)
+			assert.Equal(t, "1", id)
+			assert.Equal(t, 1, len(topic.data))
+			assert.DeepEqual(t, map[string]string{"origin": "test"}, topic.attributes[0])
+			// messages are decoded with the schema definition of the topic
+			definition, err := publisher.SchemaDefinition()
+			assert.NilError(t, err)
+			codec, err := goavro.NewCodec(definition)
+			assert.NilError(t, err)
+			var native interface{}
+			if encoding == pubsubavro.EncodingJSON {
+				native, _, err = codec.NativeFromTextual(topic.data[0])
+			} else {
+				native, _, err = codec.NativeFromBinary(topic.data[0])
+			}
+			assert.NilError(t, err)
+			var decoded library.Book
+			assert.NilError(t, protoavro.SchemaOptions{}.Decode(native, &decoded))
+			assert.DeepEqual(t, book, &decoded, protocmp.Transform())
+		})
+	}
+}
+
+func TestPublisher_Errors(t *testing.T) {
+	ctx := context.Background()
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	_, err := pubsubavro.NewPublisher(&fakeTopic{}, desc, pubsubavro.PublisherOptions{})
+	assert.ErrorContains(t, err, `unsupported encoding ""`)
+	topic := &fakeTopic{err: errors.New("topic not found")}
+	publisher, err := pubsubavro.NewPublisher(topic, desc, pubsubavro.PublisherOptions{
+		Encoding: pubsubavro.EncodingBinary,
+	})
+	assert.NilError(t, err)
+	_, err = publisher.Publish(ctx, &library.Shelf{}, nil)
+	assert.ErrorContains(t, err, "unexpected message 'google.example.library.v1.Shelf'")
+	_, err = publisher.Publish(ctx, &library.Book{}, nil)
+	assert.ErrorContains(t, err, "publish: topic not found")
+}
@@ -0,0 +1,143 @@
+package pubsubavro
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.einride.tech/protobuf-avro/avro"
+)
+
+// supportedLogicalTypes are the logical types of Avro 1.11, which Pub/Sub validates schemas with.
+var supportedLogicalTypes = map[avro.LogicalType]struct{}{
+	avro.DateLogicalType:            {},
+	avro.DecimalLogicalType:         {},
+	avro.DurationLogicalType:        {},
+	avro.TimeMicrosLogicalType:      {},
+	avro.TimestampMillisLogicalType: {},
+	avro.TimestampMicrosLogicalType: {},
+	"time-millis":                   {},
+	"uuid":                          {},
+	"local-timestamp-millis":        {},
+	"local-timestamp-micros":        {},
+}
+
+// FormatSchema returns the definition of the schema in the form accepted by Pub/Sub Avro schemas.
+// Logical types that Pub/Sub does not support, such as timestamp-nanos, are replaced by their underlying type,
+// which has the same encoding. Named types are defined once, and referenced by their full name everywhere else.
+func FormatSchema(schema avro.Schema) (string, error) {
+	f := formatter{definitions: make(map[string]avro.Schema)}
+	formatted, err := f.format(schema, "")
+	if err != nil {
+		return "", fmt.Errorf("format schema: %w", err)
+	}
+	definition, err := avro.MarshalMinified(formatted)
+	if err != nil {
+		return "", fmt.Errorf("format schema: %w", err)
+	}
+	return string(definition), nil
+}
+
+// formatter formats schemas for Pub/Sub.
+type formatter struct {
+	// definitions holds the named types defined so far by full name.
+	definitions map[string]avro.Schema
+}
+
+func (f formatter) format(schema avro.Schema, namespace string) (avro.Schema, error) {
+	switch s := schema.(type) {
+	case avro.Primitive:
+		if _, ok := supportedLogicalTypes[s.LogicalType]; !ok {
+			return avro.Primitive{Type: s.Type}, nil
+		}
+		return s, nil
+	case avro.Union:
+		union := make(avro.Union, 0, len(s))
+		for _, branch := range s {
+			formatted, err := f.format(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			union = append(union, formatted)
+		}
+		return union, nil
+	case avro.Array:
+		items, err := f.format(s.Items, namespace)
+		if err != nil {
+			return nil, err
+		}
+		s.Items = items
+		return s, nil
+	case avro.Map:
+		values, err := f.format(s.Values, namespace)
+		if err != nil {
+			return nil, err
+		}
+		s.Values = values
+		return s, nil
+	case avro.Record:
+		name, ref, err := f.define(s, s.Name, s.Namespace, namespace)
+		if err != nil || ref != nil {
+			return ref, err
+		}
+		fields := make([]avro.Field, 0, len(s.Fields))
+		for _, field := range s.Fields {
+			formatted, err := f.format(field.Type, name.namespace)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", name.full, field.Name, err)
+			}
+			field.Type = formatted
+			fields = append(fields, field)
+		}
+		s.Fields = fields
+		return s, nil
+	case avro.Enum:
+		_, ref, err := f.define(s, s.Name, s.Namespace, namespace)
+		if err != nil || ref != nil {
+			return ref, err
+		}
+		return s, nil
+	case avro.Fixed:
+		_, ref, err := f.define(s, s.Name, s.Namespace, namespace)
+		if err != nil || ref != nil {
+			return ref, err
+		}
+		if _, ok := supportedLogicalTypes[s.LogicalType]; !ok {
+			s.LogicalType = ""
+		}
+		return s, nil
+	}
+	return schema, nil
+}
+
+type fullName struct {
+	full      string
+	namespace string
+}
+
+// define defines the named type, and returns a reference to the type if it is already defined.
+func (f formatter) define(
+	schema avro.Schema,
+	name string,
+	namespace string,
+	enclosingNamespace string,
+) (fullName, avro.Schema, error) {
+	if namespace == "" {
+		namespace = enclosingNamespace
+	}
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		namespace = name[:i]
+	}
+	full := fullName{full: name, namespace: namespace}
+	if namespace != "" && !strings.Contains(name, ".") {
+		full.full = namespace + "." + name
+	}
+	if defined, ok := f.definitions[full.full]; ok {
+		if !reflect.DeepEqual(defined, schema) {
+			return full, nil, fmt.Errorf("named type %s has more than one definition", full.full)
+		}
+		return full, avro.Reference(full.full), nil
+	}
+	f.definitions[full.full] = schema
+	return full, nil, nil
+}
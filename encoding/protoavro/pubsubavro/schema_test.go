@@ -0,0 +1,101 @@
+package pubsubavro_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/pubsubavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestFormatSchema(t *testing.T) {
+	nested := avro.Record{
+		Type:      avro.RecordType,
+		Name:      "Nested",
+		Namespace: "example.v1",
+		Fields:    []avro.Field{{Name: "at", Type: avro.TimestampNanos()}},
+	}
+	for _, tt := range []struct {
+		name     string
+		schema   avro.Schema
+		expected string
+	}{
+		{
+			name:     "supported logical type",
+			schema:   avro.TimestampMicros(),
+			expected: `{"type":"long","logicalType":"timestamp-micros"}`,
+		},
+		{
+			name:     "unsupported logical type",
+			schema:   avro.TimestampNanos(),
+			expected: `"long"`,
+		},
+		{
+			name: "named types are defined once",
+			schema: avro.Record{
+				Type:      avro.RecordType,
+				Name:      "Message",
+				Namespace: "example.v1",
+				Fields: []avro.Field{
+					{Name: "first", Type: avro.Nullable(nested)},
+					{Name: "second", Type: avro.Array{Type: avro.ArrayType, Items: nested}},
+				},
+			},
+			expected: `{"type":"record","namespace":"example.v1","name":"Message","fields":[` +
+				`{"name":"first","type":["null",{"type":"record","namespace":"example.v1","name":"Nested",` +
+				`"fields":[{"name":"at","type":"long"}]}]},` +
+				`{"name":"second","type":{"type":"array","items":"example.v1.Nested"}}]}`,
+		},
+		{
+			name: "namespaces are inherited",
+			schema: avro.Record{
+				Type:      avro.RecordType,
+				Name:      "Message",
+				Namespace: "example.v1",
+				Fields: []avro.Field{
+					{Name: "first", Type: avro.Enum{Type: avro.EnumType, Name: "State", Symbols: []string{"A"}}},
+					{Name: "second", Type: avro.Enum{Type: avro.EnumType, Name: "State", Symbols: []string{"A"}}},
+				},
+			},
+			expected: `{"type":"record","namespace":"example.v1","name":"Message","fields":[` +
+				`{"name":"first","type":{"type":"enum","name":"State","symbols":["A"]}},` +
+				`{"name":"second","type":"example.v1.State"}]}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			definition, err := pubsubavro.FormatSchema(tt.schema)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, definition)
+			_, err = goavro.NewCodec(definition)
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestFormatSchema_Conflict(t *testing.T) {
+	_, err := pubsubavro.FormatSchema(avro.Record{
+		Type: avro.RecordType,
+		Name: "Message",
+		Fields: []avro.Field{
+			{Name: "first", Type: avro.Enum{Type: avro.EnumType, Name: "State", Symbols: []string{"A"}}},
+			{Name: "second", Type: avro.Enum{Type: avro.EnumType, Name: "State", Symbols: []string{"B"}}},
+		},
+	})
+	assert.ErrorContains(t, err, "Message.second: named type State has more than one definition")
+}
+
+func TestFormatSchema_Inferred(t *testing.T) {
+	opts := protoavro.SchemaOptions{TimestampPrecision: protoavro.TimestampNanos}
+	schema, err := opts.InferSchema((&examplev1.ExampleTimestamp{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	definition, err := pubsubavro.FormatSchema(schema)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(definition, "timestamp-nanos"))
+	_, err = goavro.NewCodec(definition)
+	assert.NilError(t, err)
+}
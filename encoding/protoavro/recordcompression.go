@@ -0,0 +1,78 @@
+package protoavro
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// recordCompressionMagic starts the header of records compressed with SchemaOptions.RecordCompression. The
+// header is the magic, the length of the name of the compression codec in one byte, and the name.
+// The Avro binary encoding of root records starts with the index of the branch of a union, or with the
+// length of a string, which are never encoded as the magic.
+var recordCompressionMagic = []byte{0xc5, 0x01}
+
+// CompressRecord compresses a standalone Avro binary encoded record, for example the payload of a Kafka
+// message, with the compression codec registered with the name, and prepends a header with the name of the
+// codec, see DecompressRecord. Records are returned as they are for CompressionNull and the empty name.
+func CompressRecord(name string, record []byte) ([]byte, error) {
+	if name == "" || name == CompressionNull {
+		return record, nil
+	}
+	if len(name) > math.MaxUint8 {
+		return nil, fmt.Errorf("compress record: name of compression codec %s is too long", name)
+	}
+	codec, err := lookupCompressionCodec(name)
+	if err != nil {
+		return nil, fmt.Errorf("compress record: %w", err)
+	}
+	compressed, err := codec.Compress(record)
+	if err != nil {
+		return nil, fmt.Errorf("compress record with %s: %w", name, err)
+	}
+	data := make([]byte, 0, len(recordCompressionMagic)+1+len(name)+len(compressed))
+	data = append(data, recordCompressionMagic...)
+	data = append(data, byte(len(name)))
+	data = append(data, name...)
+	return append(data, compressed...), nil
+}
+
+// DecompressRecord decompresses a record compressed with CompressRecord, with the compression codec named by
+// its header. Records without the header are not compressed, and are returned as they are, so that readers
+// of a stream of records detect compression record by record.
+func DecompressRecord(data []byte) ([]byte, error) {
+	return decompressRecord(data, 0)
+}
+
+// decompressRecord decompresses a record like DecompressRecord, and returns a LimitError when the record
+// exceeds maxRecordBytes, unless it is zero. Codecs that implement LimitedCompressionCodec stop decompressing
+// at the limit.
+func decompressRecord(data []byte, maxRecordBytes int) ([]byte, error) {
+	if !bytes.HasPrefix(data, recordCompressionMagic) {
+		return data, nil
+	}
+	header := data[len(recordCompressionMagic):]
+	if len(header) == 0 || len(header) < 1+int(header[0]) {
+		return nil, errors.New("decompress record: truncated header")
+	}
+	name := string(header[1 : 1+header[0]])
+	codec, err := lookupCompressionCodec(name)
+	if err != nil {
+		return nil, fmt.Errorf("decompress record: %w", err)
+	}
+	compressed := header[1+header[0]:]
+	var record []byte
+	if limited, ok := codec.(LimitedCompressionCodec); ok && maxRecordBytes > 0 {
+		record, err = limited.DecompressLimit(compressed, maxRecordBytes)
+	} else {
+		record, err = codec.Decompress(compressed)
+	}
+	if maxRecordBytes > 0 && errors.Is(err, ErrLimitExceeded) {
+		return nil, &LimitError{Limit: "MaxRecordBytes", Max: maxRecordBytes}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompress record with %s: %w", name, err)
+	}
+	return record, nil
+}
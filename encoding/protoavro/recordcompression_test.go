@@ -0,0 +1,96 @@
+package protoavro_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestDecompressRecord(t *testing.T) {
+	t.Run("uncompressed", func(t *testing.T) {
+		record := []byte{0x02, 'a'}
+		got, err := protoavro.DecompressRecord(record)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, record, got)
+	})
+
+	t.Run("truncated header", func(t *testing.T) {
+		for _, data := range [][]byte{
+			{0xc5, 0x01},
+			{0xc5, 0x01, 6, 's', 'n'},
+		} {
+			_, err := protoavro.DecompressRecord(data)
+			assert.ErrorContains(t, err, "decompress record: truncated header")
+		}
+	})
+
+	t.Run("unknown codec", func(t *testing.T) {
+		_, err := protoavro.DecompressRecord(append([]byte{0xc5, 0x01, 7}, "unknown"...))
+		assert.ErrorContains(t, err, "compression codec unknown is not registered")
+		opts := protoavro.SchemaOptions{RecordCompression: "unknown"}
+		_, err = protoavro.NewCodec[*library.Book](opts)
+		assert.ErrorContains(t, err, "compression codec unknown is not registered")
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		record, err := protoavro.CompressRecord(protoavro.CompressionLZ4, []byte("Harry Potter"))
+		assert.NilError(t, err)
+		_, err = protoavro.DecompressRecord(record[:len(record)-1])
+		assert.ErrorContains(t, err, "decompress record with lz4")
+	})
+}
+
+func TestDecompressRecord_MaxRecordBytes(t *testing.T) {
+	protoavro.RegisterCompressionCodec("reverse", reverseCompression{})
+	// a highly compressible record, which decompresses to much more than its compressed size
+	book := &library.Book{Name: "shelves/1/books/1", Title: strings.Repeat("Harry Potter", 1<<16)}
+	plain, err := protoavro.NewCodec[*library.Book](protoavro.SchemaOptions{})
+	assert.NilError(t, err)
+	record, err := plain.Marshal(book)
+	assert.NilError(t, err)
+	for _, compression := range []string{
+		protoavro.CompressionNull,
+		protoavro.CompressionDeflate,
+		protoavro.CompressionSnappy,
+		protoavro.CompressionLZ4,
+		// codecs that are not limited are checked after decompression
+		"reverse",
+	} {
+		compression := compression
+		t.Run(compression, func(t *testing.T) {
+			compressed, err := protoavro.CompressRecord(compression, record)
+			assert.NilError(t, err)
+			for _, tt := range []struct {
+				name     string
+				max      int
+				exceeded bool
+			}{
+				{name: "unlimited"},
+				{name: "at limit", max: len(record)},
+				{name: "exceeded", max: len(record) - 1, exceeded: true},
+				{name: "far exceeded", max: 1024, exceeded: true},
+			} {
+				tt := tt
+				t.Run(tt.name, func(t *testing.T) {
+					codec, err := protoavro.NewCodec[*library.Book](protoavro.SchemaOptions{MaxRecordBytes: tt.max})
+					assert.NilError(t, err)
+					got, err := codec.Unmarshal(compressed)
+					if !tt.exceeded {
+						assert.NilError(t, err)
+						assert.DeepEqual(t, book, got, protocmp.Transform())
+						return
+					}
+					assert.Assert(t, errors.Is(err, protoavro.ErrLimitExceeded), err)
+					var limitErr *protoavro.LimitError
+					assert.Assert(t, errors.As(err, &limitErr))
+					assert.DeepEqual(t, protoavro.LimitError{Limit: "MaxRecordBytes", Max: tt.max}, *limitErr)
+				})
+			}
+		})
+	}
+}
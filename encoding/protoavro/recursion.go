@@ -0,0 +1,241 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RecursionStrategy is how recursive messages, which contain fields of their own type, are mapped. Messages
+// also recurse through the items of lists and the values of maps, for example a message Node with a field
+// map<string, Node> children, and the strategy applies to these fields the same way.
+type RecursionStrategy int
+
+const (
+	// RecursionReference maps recursive fields to references to the record of the message they recurse into.
+	RecursionReference RecursionStrategy = iota
+	// RecursionError fails schema inference of recursive messages, for targets that reject recursive schemas.
+	RecursionError
+	// RecursionDepthLimit inlines recursive messages down to MaxRecursionDepth nested records, and omits fields
+	// of recursive messages that are nested deeper. Records of messages that contain recursive messages are
+	// named with their depth, for example Node_2, since their definitions differ by depth.
+	RecursionDepthLimit
+	// RecursionJSONString maps fields that recurse into a message that contains them to nullable strings, with
+	// the protobuf JSON encoding of the field value.
+	RecursionJSONString
+)
+
+// recursionGraph holds the strongly connected components of the messages reachable from a message, where
+// messages are connected by their message fields, and the values of their map fields.
+type recursionGraph struct {
+	// components holds the index of the component of each message.
+	components map[protoreflect.FullName]int
+	// cyclic holds the components that contain a cycle.
+	cyclic map[int]bool
+	// recursive is true if a cyclic component is reachable from the message.
+	recursive bool
+}
+
+type recursionGraphKey struct {
+	desc               protoreflect.MessageDescriptor
+	googleTypeMappings bool
+}
+
+// recursionGraphs caches the recursion graphs of messages.
+var recursionGraphs descriptorCache[recursionGraphKey, *recursionGraph]
+
+// recursionGraph returns the recursion graph of the messages reachable from the message. Well-known types,
+// and messages with custom codecs, are not traversed.
+func (o SchemaOptions) recursionGraph(desc protoreflect.MessageDescriptor) *recursionGraph {
+	key := recursionGraphKey{desc: desc, googleTypeMappings: o.GoogleTypeMappings}
+	if g, ok := recursionGraphs.load(key); ok {
+		return g
+	}
+	g := &recursionGraph{components: make(map[protoreflect.FullName]int), cyclic: make(map[int]bool)}
+	// Tarjan's algorithm
+	index := make(map[protoreflect.FullName]int)
+	lowlink := make(map[protoreflect.FullName]int)
+	onStack := make(map[protoreflect.FullName]bool)
+	var stack []protoreflect.FullName
+	var visit func(message protoreflect.MessageDescriptor)
+	visit = func(message protoreflect.MessageDescriptor) {
+		name := message.FullName()
+		index[name], lowlink[name] = len(index), len(index)
+		stack = append(stack, name)
+		onStack[name] = true
+		selfLoop := false
+		for _, next := range o.recursionEdges(message) {
+			switch _, visited := index[next.FullName()]; {
+			case next.FullName() == name:
+				selfLoop = true
+			case !visited:
+				visit(next)
+				if lowlink[next.FullName()] < lowlink[name] {
+					lowlink[name] = lowlink[next.FullName()]
+				}
+			case onStack[next.FullName()]:
+				if index[next.FullName()] < lowlink[name] {
+					lowlink[name] = index[next.FullName()]
+				}
+			}
+		}
+		if lowlink[name] != index[name] {
+			return
+		}
+		component := len(g.cyclic)
+		g.cyclic[component] = selfLoop
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			g.components[top] = component
+			if top == name {
+				break
+			}
+			g.cyclic[component] = true
+		}
+	}
+	visit(desc)
+	for _, cyclic := range g.cyclic {
+		g.recursive = g.recursive || cyclic
+	}
+	return recursionGraphs.loadOrStore(key, g)
+}
+
+// recursionEdges returns the messages of the message fields of the message, with the values of map fields
+// in place of map entries.
+func (o SchemaOptions) recursionEdges(message protoreflect.MessageDescriptor) []protoreflect.MessageDescriptor {
+	if o.isWKT(message.FullName()) {
+		return nil
+	}
+	var edges []protoreflect.MessageDescriptor
+	for i := 0; i < message.Fields().Len(); i++ {
+		if target := recursionTarget(message.Fields().Get(i)); target != nil {
+			edges = append(edges, target)
+		}
+	}
+	return edges
+}
+
+// recursionTarget returns the message of a message field, or the message of the values of a map field.
+func recursionTarget(field protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	if field.IsMap() {
+		return field.MapValue().Message()
+	}
+	return field.Message()
+}
+
+// encodesJSONString returns true if the field is encoded as the protobuf JSON encoding of its value, since it
+// recurses into the message that contains it with RecursionJSONString.
+func (o SchemaOptions) encodesJSONString(field protoreflect.FieldDescriptor) bool {
+	if o.RecursionStrategy != RecursionJSONString {
+		return false
+	}
+	target := recursionTarget(field)
+	if target == nil || field.ContainingMessage().IsMapEntry() {
+		return false
+	}
+	g := o.recursionGraph(field.ContainingMessage())
+	component := g.components[field.ContainingMessage().FullName()]
+	return g.cyclic[component] && g.components[target.FullName()] == component
+}
+
+// omitsField returns true if the field of a record at the depth is omitted, since it is deprecated with
+// OmitDeprecatedFields, or a field of a recursive message nested deeper than MaxRecursionDepth with
+// RecursionDepthLimit.
+func (o SchemaOptions) omitsField(field protoreflect.FieldDescriptor, depth int) bool {
+	if o.OmitDeprecatedFields && isDeprecated(field) {
+		return true
+	}
+	if o.RecursionStrategy != RecursionDepthLimit {
+		return false
+	}
+	target := recursionTarget(field)
+	if target == nil || !o.recursionGraph(target).recursive {
+		return false
+	}
+	// the values of maps are nested in map entries
+	depth++
+	if field.IsMap() {
+		depth++
+	}
+	return depth > o.MaxRecursionDepth
+}
+
+// depthSuffix returns the suffix of the name of the record of a message at the depth, which names the records
+// of messages that contain recursive messages by their depth with RecursionDepthLimit.
+func (o SchemaOptions) depthSuffix(desc protoreflect.MessageDescriptor, depth int) string {
+	if o.RecursionStrategy != RecursionDepthLimit || depth == 0 || !o.recursionGraph(desc).recursive {
+		return ""
+	}
+	return "_" + strconv.Itoa(depth)
+}
+
+// recordName returns the Avro full name of the record of a message at the depth.
+func (o SchemaOptions) recordName(desc protoreflect.MessageDescriptor, depth int) string {
+	return o.avroName(desc) + o.depthSuffix(desc, depth)
+}
+
+// encodeJSONString returns the protobuf JSON encoding of the value of a field of the message.
+func encodeJSONString(message protoreflect.Message, field protoreflect.FieldDescriptor) (string, error) {
+	holder := message.New()
+	holder.Set(field, message.Get(field))
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(holder.Interface())
+	if err != nil {
+		return "", fmt.Errorf("field %s: %w", field.Name(), err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("field %s: %w", field.Name(), err)
+	}
+	// protojson randomly varies its whitespace
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, fields[string(field.Name())]); err != nil {
+		return "", fmt.Errorf("field %s: %w", field.Name(), err)
+	}
+	return compact.String(), nil
+}
+
+// decodeJSONString decodes the protobuf JSON encoding of the value of a field into the message.
+func decodeJSONString(data string, message protoreflect.Message, field protoreflect.FieldDescriptor) error {
+	name, err := json.Marshal(string(field.Name()))
+	if err != nil {
+		return err
+	}
+	holder := message.New()
+	if err := protojson.Unmarshal([]byte("{"+string(name)+":"+data+"}"), holder.Interface()); err != nil {
+		return fmt.Errorf("field %s: %w", field.Name(), err)
+	}
+	message.Set(field, holder.Get(field))
+	return nil
+}
+
+// unwrapDepthRecord unwraps the union of a record of the message that is named by its depth, for example
+// Node_2, with RecursionDepthLimit.
+func (o SchemaOptions) unwrapDepthRecord(
+	d map[string]interface{},
+	desc protoreflect.MessageDescriptor,
+) (interface{}, bool) {
+	if o.RecursionStrategy != RecursionDepthLimit || len(d) != 1 {
+		return nil, false
+	}
+	for name, value := range d {
+		depth, ok := strings.CutPrefix(name, o.avroName(desc)+"_")
+		if !ok {
+			return nil, false
+		}
+		if _, err := strconv.Atoi(depth); err != nil {
+			return nil, false
+		}
+		if _, ok := o.findField(desc, name); ok {
+			return nil, false
+		}
+		return value, true
+	}
+	return nil, false
+}
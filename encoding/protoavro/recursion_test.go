@@ -0,0 +1,419 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestRecursionStrategy_Error(t *testing.T) {
+	_, err := SchemaOptions{RecursionStrategy: RecursionError}.InferSchema(
+		(&examplev1.ExampleRecursive{}).ProtoReflect().Descriptor(),
+	)
+	assert.ErrorContains(t, err, "recursive message einride.avro.example.v1.ExampleRecursive")
+	// messages that are not recursive are not affected
+	_, err = SchemaOptions{RecursionStrategy: RecursionError}.InferSchema(
+		(&examplev1.ExampleMap{}).ProtoReflect().Descriptor(),
+	)
+	assert.NilError(t, err)
+}
+
+func TestRecursionStrategy_DepthLimit(t *testing.T) {
+	opts := SchemaOptions{RecursionStrategy: RecursionDepthLimit, MaxRecursionDepth: 2}
+	desc := (&examplev1.ExampleRecursive{}).ProtoReflect().Descriptor()
+	schema, err := opts.InferSchema(desc)
+	assert.NilError(t, err)
+	depth1 := recordOf(t, recordOf(t, schema).Fields[0].Type)
+	assert.Equal(t, depth1.Name, "ExampleRecursive_1")
+	depth2 := recordOf(t, depth1.Fields[0].Type)
+	assert.Equal(t, depth2.Name, "ExampleRecursive_2")
+	assert.Equal(t, len(depth2.Fields), 0)
+
+	msg := &examplev1.ExampleRecursive{
+		Recursive: &examplev1.ExampleRecursive{
+			Recursive: &examplev1.ExampleRecursive{
+				Recursive: &examplev1.ExampleRecursive{},
+			},
+		},
+	}
+	// messages nested deeper than the limit are truncated
+	expected := &examplev1.ExampleRecursive{
+		Recursive: &examplev1.ExampleRecursive{
+			Recursive: &examplev1.ExampleRecursive{},
+		},
+	}
+	assertRecursionRoundTrip(t, opts, msg, expected, true)
+
+	t.Run("lists and maps", func(t *testing.T) {
+		desc := newRecursiveForestDescriptor(t)
+		opts := SchemaOptions{RecursionStrategy: RecursionDepthLimit, MaxRecursionDepth: 3}
+		tree := newForestTree(desc)
+		// values of maps are nested one record deeper than items of lists
+		msg := newForest(desc, tree("root",
+			[]*dynamicpb.Message{
+				tree("a", []*dynamicpb.Message{tree("b", []*dynamicpb.Message{tree("c", nil, nil)}, nil)},
+					map[string]*dynamicpb.Message{"k": tree("d", nil, nil)}),
+			},
+			map[string]*dynamicpb.Message{"k": tree("e", []*dynamicpb.Message{tree("f", nil, nil)}, nil)},
+		))
+		expected := newForest(desc, tree("root",
+			[]*dynamicpb.Message{tree("a", []*dynamicpb.Message{tree("b", nil, nil)}, nil)},
+			map[string]*dynamicpb.Message{"k": tree("e", nil, nil)},
+		))
+		assertRecursionRoundTrip(t, opts, msg, expected, true)
+	})
+}
+
+func TestRecursionStrategy_JSONString(t *testing.T) {
+	desc := newRecursiveForestDescriptor(t)
+	opts := SchemaOptions{RecursionStrategy: RecursionJSONString}
+	schema, err := opts.InferSchema(desc)
+	assert.NilError(t, err)
+	tree := recordOf(t, recordOf(t, schema).Fields[0].Type)
+	assert.Equal(t, tree.Name, "Tree")
+	assert.DeepEqual(t, tree.Fields[1].Type, avro.Nullable(avro.String()))
+	assert.DeepEqual(t, tree.Fields[2].Type, avro.Nullable(avro.String()))
+	// fields that do not recurse are mapped as usual
+	assert.Equal(t, recordOf(t, tree.Fields[3].Type).Name, "Leaf")
+
+	newTree := newForestTree(desc)
+	msg := newForest(desc, newTree("root",
+		[]*dynamicpb.Message{newTree("first", nil, nil), newTree("second", nil, nil)},
+		map[string]*dynamicpb.Message{"key": newTree("value", nil, nil)},
+	))
+
+	native, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	record := native.(map[string]interface{})["example.v1.Forest"].(map[string]interface{})
+	encodedTree := record["tree"].(map[string]interface{})["example.v1.Tree"].(map[string]interface{})
+	assert.DeepEqual(t, encodedTree["children"], map[string]interface{}{
+		"string": `[{"name":"first"},{"name":"second"}]`,
+	})
+	assert.DeepEqual(t, encodedTree["index"], map[string]interface{}{
+		"string": `{"key":{"name":"value"}}`,
+	})
+	assert.Equal(t, encodedTree["leaf"], nil)
+	assertRecursionRoundTrip(t, opts, msg, msg, false)
+}
+
+func TestRecursionStrategy_Maps(t *testing.T) {
+	desc := newRecursiveNodeDescriptor(t)
+	node := newNode(desc)
+	msg := node("root", map[string]*dynamicpb.Message{
+		"a": node("a", map[string]*dynamicpb.Message{"b": node("b", nil)}),
+	})
+
+	t.Run("reference", func(t *testing.T) {
+		opts := SchemaOptions{}
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		children := recordOf(t, schema).Fields[1].Type.(avro.Union)[1].(avro.Array)
+		entry := children.Items.(avro.Record)
+		assert.Equal(t, entry.Name, "ChildrenEntry")
+		assert.DeepEqual(t, entry.Fields[1].Type, avro.Nullable(avro.Reference("example.v1.Node")))
+		assertRecursionRoundTrip(t, opts, msg, msg, true)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := SchemaOptions{RecursionStrategy: RecursionError}.InferSchema(desc)
+		assert.ErrorContains(t, err, "recursive message example.v1.Node")
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		opts := SchemaOptions{RecursionStrategy: RecursionDepthLimit, MaxRecursionDepth: 2}
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		children := recordOf(t, schema).Fields[1].Type.(avro.Union)[1].(avro.Array)
+		entry := children.Items.(avro.Record)
+		assert.Equal(t, entry.Name, "ChildrenEntry_1")
+		value := recordOf(t, entry.Fields[1].Type)
+		assert.Equal(t, value.Name, "Node_2")
+		assert.Equal(t, len(value.Fields), 1)
+		// values of maps are nested in the records of map entries, one record deeper
+		expected := node("root", map[string]*dynamicpb.Message{"a": node("a", nil)})
+		assertRecursionRoundTrip(t, opts, msg, expected, true)
+	})
+
+	t.Run("json string", func(t *testing.T) {
+		opts := SchemaOptions{RecursionStrategy: RecursionJSONString}
+		schema, err := opts.InferSchema(desc)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, recordOf(t, schema).Fields[1].Type, avro.Nullable(avro.String()))
+		native, err := opts.encodeJSON(msg)
+		assert.NilError(t, err)
+		record := native.(map[string]interface{})["example.v1.Node"].(map[string]interface{})
+		assert.DeepEqual(t, record["children"], map[string]interface{}{
+			"string": `{"a":{"name":"a","children":{"b":{"name":"b"}}}}`,
+		})
+		assertRecursionRoundTrip(t, opts, msg, msg, false)
+	})
+
+	t.Run("field values", func(t *testing.T) {
+		field := desc.Fields().ByName("children")
+		for _, tt := range []struct {
+			name     string
+			opts     SchemaOptions
+			expected *dynamicpb.Message
+		}{
+			{name: "reference", expected: msg},
+			{
+				name: "depth limit",
+				opts: SchemaOptions{RecursionStrategy: RecursionDepthLimit, MaxRecursionDepth: 2},
+				// the field is at depth 1, as in the record of its message
+				expected: node("", map[string]*dynamicpb.Message{"a": node("a", nil)}),
+			},
+			{name: "json string", opts: SchemaOptions{RecursionStrategy: RecursionJSONString}, expected: msg},
+		} {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				schema, err := tt.opts.InferFieldSchema(field)
+				assert.NilError(t, err)
+				encoded, err := tt.opts.EncodeValue(field, msg.Get(field))
+				assert.NilError(t, err)
+				schemaBytes, err := json.Marshal(avro.Record{
+					Type:   avro.RecordType,
+					Name:   "Value",
+					Fields: []avro.Field{{Name: "value", Type: schema}},
+				})
+				assert.NilError(t, err)
+				codec, err := goavro.NewCodec(string(schemaBytes))
+				assert.NilError(t, err)
+				binary, err := codec.BinaryFromNative(nil, map[string]interface{}{"value": encoded})
+				assert.NilError(t, err)
+				native, _, err := codec.NativeFromBinary(binary)
+				assert.NilError(t, err)
+				decoded, err := tt.opts.DecodeValue(field, native.(map[string]interface{})["value"])
+				assert.NilError(t, err)
+				got := dynamicpb.NewMessage(desc)
+				got.Set(field, decoded)
+				expected := dynamicpb.NewMessage(desc)
+				expected.Set(field, tt.expected.Get(field))
+				assert.DeepEqual(t, expected, got, protocmp.Transform())
+			})
+		}
+	})
+}
+
+// recordOf returns the record of a nullable record schema.
+func recordOf(t *testing.T, schema avro.Schema) avro.Record {
+	t.Helper()
+	if union, ok := schema.(avro.Union); ok {
+		assert.Equal(t, len(union), 2)
+		schema = union[1]
+	}
+	record, ok := schema.(avro.Record)
+	assert.Assert(t, ok, "expected record, got %T", schema)
+	return record
+}
+
+// assertRecursionRoundTrip asserts that the message decodes to the expected message, with the generic decoder,
+// and with the direct decoders and encoder when direct is set.
+func assertRecursionRoundTrip(t *testing.T, opts SchemaOptions, msg, expected proto.Message, direct bool) {
+	t.Helper()
+	codec := newTestGoavroCodec(t, opts, msg)
+	native, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	binary, err := codec.BinaryFromNative(nil, native)
+	assert.NilError(t, err)
+	decoded, _, err := codec.NativeFromBinary(binary)
+	assert.NilError(t, err)
+	got := msg.ProtoReflect().New().Interface()
+	assert.NilError(t, opts.decodeJSON(decoded, got))
+	assert.DeepEqual(t, expected, got, protocmp.Transform())
+	if !direct {
+		return
+	}
+	desc := msg.ProtoReflect().Descriptor()
+	schema, err := opts.InferSchema(desc)
+	assert.NilError(t, err)
+	encoder, err := opts.newAvroJSONEncoder(desc, schema)
+	assert.NilError(t, err)
+	data, err := encoder.encode(nil, msg.ProtoReflect())
+	assert.NilError(t, err)
+	// the direct encoder agrees with the generic encoder, where goavro decodes empty arrays of text as nil
+	decodedNative, _, err := codec.NativeFromTextual(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decoded, decodedNative, cmpopts.EquateEmpty())
+	binaryDecoder, err := opts.newBinaryDecoder(desc, schema)
+	assert.NilError(t, err)
+	got = msg.ProtoReflect().New().Interface()
+	assert.NilError(t, binaryDecoder.decode(binary, got.ProtoReflect()))
+	assert.DeepEqual(t, expected, got, protocmp.Transform())
+	jsonDecoder, err := opts.newAvroJSONDecoder(desc, schema)
+	assert.NilError(t, err)
+	got = msg.ProtoReflect().New().Interface()
+	assert.NilError(t, jsonDecoder.decode(bytes.NewReader(data), got.ProtoReflect()))
+	assert.DeepEqual(t, expected, got, protocmp.Transform())
+}
+
+// newForest returns a Forest message of the descriptor returned by newRecursiveForestDescriptor.
+func newForest(desc protoreflect.MessageDescriptor, tree *dynamicpb.Message) *dynamicpb.Message {
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("tree"), protoreflect.ValueOfMessage(tree))
+	return msg
+}
+
+// newForestTree returns a constructor of Tree messages of the Forest descriptor.
+func newForestTree(
+	desc protoreflect.MessageDescriptor,
+) func(name string, children []*dynamicpb.Message, index map[string]*dynamicpb.Message) *dynamicpb.Message {
+	treeDesc := desc.Fields().ByName("tree").Message()
+	return func(name string, children []*dynamicpb.Message, index map[string]*dynamicpb.Message) *dynamicpb.Message {
+		msg := dynamicpb.NewMessage(treeDesc)
+		msg.Set(treeDesc.Fields().ByName("name"), protoreflect.ValueOfString(name))
+		if len(children) > 0 {
+			list := msg.Mutable(treeDesc.Fields().ByName("children")).List()
+			for _, child := range children {
+				list.Append(protoreflect.ValueOfMessage(child))
+			}
+		}
+		if len(index) > 0 {
+			mp := msg.Mutable(treeDesc.Fields().ByName("index")).Map()
+			for key, value := range index {
+				mp.Set(protoreflect.ValueOfString(key).MapKey(), protoreflect.ValueOfMessage(value))
+			}
+		}
+		return msg
+	}
+}
+
+// newRecursiveForestDescriptor returns the descriptor of a message Forest, with a field of a message Tree that
+// recurses through a list and a map.
+func newRecursiveForestDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/forest.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Forest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name: proto.String("tree"), Number: proto.Int32(1), Label: optional, Type: message,
+						TypeName: proto.String(".example.v1.Tree"),
+					},
+				},
+			},
+			{
+				Name: proto.String("Tree"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1), Label: optional, Type: str},
+					{
+						Name: proto.String("children"), Number: proto.Int32(2), Label: repeated, Type: message,
+						TypeName: proto.String(".example.v1.Tree"),
+					},
+					{
+						Name: proto.String("index"), Number: proto.Int32(3), Label: repeated, Type: message,
+						TypeName: proto.String(".example.v1.Tree.IndexEntry"),
+					},
+					{
+						Name: proto.String("leaf"), Number: proto.Int32(4), Label: optional, Type: message,
+						TypeName: proto.String(".example.v1.Leaf"),
+					},
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("IndexEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{Name: proto.String("key"), Number: proto.Int32(1), Label: optional, Type: str},
+							{
+								Name: proto.String("value"), Number: proto.Int32(2), Label: optional, Type: message,
+								TypeName: proto.String(".example.v1.Tree"),
+							},
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+			{
+				Name: proto.String("Leaf"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("value"), Number: proto.Int32(1), Label: optional, Type: str},
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	assert.NilError(t, err)
+	return file.Messages().ByName("Forest")
+}
+
+// newNode returns a constructor of Node messages of the descriptor returned by newRecursiveNodeDescriptor.
+func newNode(
+	desc protoreflect.MessageDescriptor,
+) func(name string, children map[string]*dynamicpb.Message) *dynamicpb.Message {
+	var node func(name string, children map[string]*dynamicpb.Message) *dynamicpb.Message
+	node = func(name string, children map[string]*dynamicpb.Message) *dynamicpb.Message {
+		msg := dynamicpb.NewMessage(desc)
+		if name != "" {
+			msg.Set(desc.Fields().ByName("name"), protoreflect.ValueOfString(name))
+		}
+		if len(children) > 0 {
+			mp := msg.Mutable(desc.Fields().ByName("children")).Map()
+			for key, value := range children {
+				mp.Set(protoreflect.ValueOfString(key).MapKey(), protoreflect.ValueOfMessage(value))
+			}
+		}
+		return msg
+	}
+	return node
+}
+
+// newRecursiveNodeDescriptor returns the descriptor of a message Node, which recurses through the values of a
+// map of its children.
+func newRecursiveNodeDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/node.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Node"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1), Label: optional, Type: str},
+					{
+						Name: proto.String("children"), Number: proto.Int32(2), Label: repeated, Type: message,
+						TypeName: proto.String(".example.v1.Node.ChildrenEntry"),
+					},
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("ChildrenEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{Name: proto.String("key"), Number: proto.Int32(1), Label: optional, Type: str},
+							{
+								Name: proto.String("value"), Number: proto.Int32(2), Label: optional, Type: message,
+								TypeName: proto.String(".example.v1.Node"),
+							},
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	assert.NilError(t, err)
+	return file.Messages().ByName("Node")
+}
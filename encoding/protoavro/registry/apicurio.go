@@ -0,0 +1,204 @@
+package registry
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"go.einride.tech/protobuf-avro/avro"
+)
+
+// ApicurioCompatPath is the path of the Confluent-compatible API of Apicurio Registry.
+const ApicurioCompatPath = "/apis/ccompat/v6"
+
+// NewApicurioCompatClient returns a client of the Confluent-compatible API of Apicurio Registry, where the URL
+// of the options is the base URL of the registry, for example "http://localhost:8080".
+// Messages are framed in the Confluent wire format, with the 4-byte content IDs of the schemas.
+func NewApicurioCompatClient(opts ClientOptions) *Client {
+	if opts.URL != "" {
+		opts.URL = strings.TrimSuffix(opts.URL, "/") + ApicurioCompatPath
+	}
+	return NewClient(opts)
+}
+
+// Header names of the global IDs of schemas, when the global IDs are carried in the headers of Kafka records
+// instead of framing the data.
+const (
+	ApicurioKeyGlobalIDHeader   = "apicurio.key.globalId"
+	ApicurioValueGlobalIDHeader = "apicurio.value.globalId"
+)
+
+// ApicurioOptions configures an ApicurioBackend.
+type ApicurioOptions struct {
+	// ClientOptions configures the requests to Apicurio Registry, where the URL is the base URL of the registry,
+	// for example "http://localhost:8080", and Offline maps the fingerprints of schemas to their global IDs.
+	ClientOptions
+	// GroupID is the group of the artifacts of the schemas. Defaults to "default".
+	GroupID string
+}
+
+// apicurioMagicByte is the first byte of the Apicurio wire format, followed by the big-endian 8-byte global
+// ID of the schema.
+const apicurioMagicByte = 0
+
+// ApicurioBackend is a Backend of the artifact API of Apicurio Registry. The artifact IDs of schemas are the
+// subjects of the messages. Global IDs are cached by subject and fingerprint, and schemas by global ID.
+// An ApicurioBackend is safe for concurrent use.
+type ApicurioBackend struct {
+	rest    restClient
+	groupID string
+	offline map[Fingerprint]int64
+	mu      sync.RWMutex
+	// ids caches the global IDs of the schemas of subjects.
+	ids map[subjectFingerprint]int64
+	// schemas caches schemas by global ID.
+	schemas map[int64]avro.Schema
+}
+
+var _ Backend = &ApicurioBackend{}
+
+// NewApicurioBackend returns a new backend of the artifact API of Apicurio Registry.
+func NewApicurioBackend(opts ApicurioOptions) *ApicurioBackend {
+	if opts.GroupID == "" {
+		opts.GroupID = "default"
+	}
+	offline := make(map[Fingerprint]int64, len(opts.Offline))
+	for fingerprint, id := range opts.Offline {
+		offline[fingerprint] = int64(id)
+	}
+	return &ApicurioBackend{
+		rest:    newRESTClient(opts.ClientOptions),
+		groupID: opts.GroupID,
+		offline: offline,
+		ids:     make(map[subjectFingerprint]int64),
+		schemas: make(map[int64]avro.Schema),
+	}
+}
+
+// Register creates or updates the artifact with the subject as artifact ID, unless the schema is already
+// registered, and returns the global ID of the schema.
+func (a *ApicurioBackend) Register(ctx context.Context, subject string, schema avro.Schema) (int64, error) {
+	fingerprint, err := SchemaFingerprint(schema)
+	if err != nil {
+		return 0, err
+	}
+	if id, ok := a.offline[fingerprint]; ok {
+		return id, nil
+	}
+	key := subjectFingerprint{subject: subject, fingerprint: fingerprint}
+	a.mu.RLock()
+	id, ok := a.ids[key]
+	a.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+	if a.rest.url == "" {
+		return 0, fmt.Errorf("subject %s: schema with fingerprint %x: %w", subject, uint64(fingerprint), ErrOffline)
+	}
+	body, err := json.Marshal(schema)
+	if err != nil {
+		return 0, fmt.Errorf("json marshal schema: %w", err)
+	}
+	path := "/apis/registry/v2/groups/" + url.PathEscape(a.groupID) +
+		"/artifacts?ifExists=RETURN_OR_UPDATE&canonical=true"
+	header := make(http.Header)
+	header.Set("Accept", "application/json")
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Registry-ArtifactId", subject)
+	header.Set("X-Registry-ArtifactType", "AVRO")
+	var response struct {
+		GlobalID int64 `json:"globalId"`
+	}
+	if err := a.rest.do(ctx, operationRegister, http.MethodPost, path, header, body, &response); err != nil {
+		return 0, fmt.Errorf("subject %s: %w", subject, err)
+	}
+	a.mu.Lock()
+	a.ids[key] = response.GlobalID
+	a.schemas[response.GlobalID] = schema
+	a.mu.Unlock()
+	return response.GlobalID, nil
+}
+
+// Schema returns the schema with the global ID.
+func (a *ApicurioBackend) Schema(ctx context.Context, globalID int64) (avro.Schema, error) {
+	a.mu.RLock()
+	schema, ok := a.schemas[globalID]
+	a.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+	if a.rest.url == "" {
+		return nil, fmt.Errorf("schema %d: %w", globalID, ErrOffline)
+	}
+	var response json.RawMessage
+	path := fmt.Sprintf("/apis/registry/v2/ids/globalIds/%d", globalID)
+	header := make(http.Header)
+	header.Set("Accept", "application/json")
+	if err := a.rest.do(ctx, operationSchema, http.MethodGet, path, header, nil, &response); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", globalID, err)
+	}
+	schema, err := avro.Parse(response)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", globalID, err)
+	}
+	a.mu.Lock()
+	a.schemas[globalID] = schema
+	a.mu.Unlock()
+	return schema, nil
+}
+
+// Encode registers the schema under the subject, and returns the data in the Apicurio wire format: a zero
+// magic byte and the 8-byte big-endian global ID of the schema, followed by the data.
+func (a *ApicurioBackend) Encode(ctx context.Context, subject string, schema avro.Schema, data []byte) ([]byte, error) {
+	id, err := a.Register(ctx, subject, schema)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	framed := make([]byte, 0, 9+len(data))
+	framed = append(framed, apicurioMagicByte)
+	framed = binary.BigEndian.AppendUint64(framed, uint64(id))
+	return append(framed, data...), nil
+}
+
+// Decode returns the schema and data of framed data in the Apicurio wire format.
+func (a *ApicurioBackend) Decode(ctx context.Context, framed []byte) (avro.Schema, []byte, error) {
+	if len(framed) < 9 {
+		return nil, nil, errors.New("decode: data is too short for the apicurio wire format")
+	}
+	if framed[0] != apicurioMagicByte {
+		return nil, nil, fmt.Errorf("decode: unknown magic byte %d", framed[0])
+	}
+	schema, err := a.Schema(ctx, int64(binary.BigEndian.Uint64(framed[1:9])))
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode: %w", err)
+	}
+	return schema, framed[9:], nil
+}
+
+// EncodeHeader registers the schema under the subject, and returns the value of the global ID header of
+// unframed data, to be carried in the ApicurioKeyGlobalIDHeader or ApicurioValueGlobalIDHeader of a record.
+func (a *ApicurioBackend) EncodeHeader(ctx context.Context, subject string, schema avro.Schema) ([]byte, error) {
+	id, err := a.Register(ctx, subject, schema)
+	if err != nil {
+		return nil, fmt.Errorf("encode header: %w", err)
+	}
+	return binary.BigEndian.AppendUint64(nil, uint64(id)), nil
+}
+
+// DecodeHeader returns the schema of the value of a global ID header.
+func (a *ApicurioBackend) DecodeHeader(ctx context.Context, header []byte) (avro.Schema, error) {
+	if len(header) != 8 {
+		return nil, fmt.Errorf("decode header: global ID header has %d bytes, expected 8", len(header))
+	}
+	schema, err := a.Schema(ctx, int64(binary.BigEndian.Uint64(header)))
+	if err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	return schema, nil
+}
@@ -0,0 +1,178 @@
+package registry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/registry"
+	"gotest.tools/v3/assert"
+)
+
+// fakeApicurio is an Apicurio Registry with the artifact API, where every artifact version has a global ID.
+type fakeApicurio struct {
+	mu        sync.Mutex
+	requests  int
+	artifacts map[string]int64
+	schemas   []string
+}
+
+func newFakeApicurio(t *testing.T) (*fakeApicurio, *httptest.Server) {
+	t.Helper()
+	a := &fakeApicurio{artifacts: map[string]int64{}}
+	server := httptest.NewServer(a)
+	t.Cleanup(server.Close)
+	return a, server
+}
+
+func (a *fakeApicurio) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests++
+	writeError := func(status int, message string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"error_code": status, "message": message})
+	}
+	switch {
+	case req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, "/apis/registry/v2/ids/globalIds/"):
+		id, err := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/apis/registry/v2/ids/globalIds/"))
+		if err != nil || id <= 0 || id > len(a.schemas) {
+			writeError(http.StatusNotFound, "No artifact with ID found")
+			return
+		}
+		_, _ = io.WriteString(w, a.schemas[id-1])
+	case req.Method == http.MethodPost && req.URL.Path == "/apis/registry/v2/groups/library/artifacts":
+		if req.URL.Query().Get("ifExists") != "RETURN_OR_UPDATE" || req.Header.Get("X-Registry-ArtifactType") != "AVRO" {
+			writeError(http.StatusBadRequest, "Bad request")
+			return
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeError(http.StatusBadRequest, "Bad request")
+			return
+		}
+		key := req.Header.Get("X-Registry-ArtifactId") + "/" + string(body)
+		id, ok := a.artifacts[key]
+		if !ok {
+			a.schemas = append(a.schemas, string(body))
+			id = int64(len(a.schemas))
+			a.artifacts[key] = id
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"globalId": id, "type": "AVRO"})
+	default:
+		writeError(http.StatusNotFound, "Not found")
+	}
+}
+
+func TestApicurioBackend(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeApicurio(t)
+	opts := registry.ApicurioOptions{ClientOptions: registry.ClientOptions{URL: server.URL}, GroupID: "library"}
+	backend := registry.NewApicurioBackend(opts)
+	schema := bookSchema(t)
+	framed, err := backend.Encode(ctx, "books-value", schema, []byte{2, 4})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 4}, framed)
+	// global IDs are cached
+	_, err = backend.Encode(ctx, "books-value", schema, []byte{2, 4})
+	assert.NilError(t, err)
+	assert.Equal(t, 1, fake.requests)
+	// schemas are resolved by other backends
+	decoded, data, err := registry.NewApicurioBackend(opts).Decode(ctx, framed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, decoded)
+	assert.DeepEqual(t, []byte{2, 4}, data)
+	assert.Equal(t, 2, fake.requests)
+	// other artifacts have other global IDs
+	id, err := backend.Register(ctx, "strings-value", avro.String())
+	assert.NilError(t, err)
+	assert.Equal(t, int64(2), id)
+}
+
+func TestApicurioBackend_Header(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFakeApicurio(t)
+	opts := registry.ApicurioOptions{ClientOptions: registry.ClientOptions{URL: server.URL}, GroupID: "library"}
+	schema := bookSchema(t)
+	header, err := registry.NewApicurioBackend(opts).EncodeHeader(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte{0, 0, 0, 0, 0, 0, 0, 1}, header)
+	decoded, err := registry.NewApicurioBackend(opts).DecodeHeader(ctx, header)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, decoded)
+	_, err = registry.NewApicurioBackend(opts).DecodeHeader(ctx, []byte{0, 0, 0, 1})
+	assert.ErrorContains(t, err, "global ID header has 4 bytes, expected 8")
+}
+
+func TestApicurioBackend_Errors(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFakeApicurio(t)
+	backend := registry.NewApicurioBackend(registry.ApicurioOptions{
+		ClientOptions: registry.ClientOptions{URL: server.URL},
+		GroupID:       "library",
+	})
+	for _, tt := range []struct {
+		name          string
+		framed        []byte
+		errorContains string
+	}{
+		{name: "too short", framed: []byte{0, 0, 1}, errorContains: "too short for the apicurio wire format"},
+		{name: "magic byte", framed: []byte{3, 0, 0, 0, 0, 0, 0, 0, 1}, errorContains: "unknown magic byte 3"},
+		{
+			name:          "unknown global ID",
+			framed:        []byte{0, 0, 0, 0, 0, 0, 0, 0, 42},
+			errorContains: "schema 42: schema registry: 404 No artifact with ID found",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := backend.Decode(ctx, tt.framed)
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+	// artifacts are registered in the default group unless configured
+	_, err := registry.NewApicurioBackend(registry.ApicurioOptions{
+		ClientOptions: registry.ClientOptions{URL: server.URL},
+	}).Register(ctx, "books-value", bookSchema(t))
+	assert.ErrorContains(t, err, "404 Not found")
+}
+
+func TestApicurioBackend_Offline(t *testing.T) {
+	ctx := context.Background()
+	schema := bookSchema(t)
+	fingerprint, err := registry.SchemaFingerprint(schema)
+	assert.NilError(t, err)
+	backend := registry.NewApicurioBackend(registry.ApicurioOptions{
+		ClientOptions: registry.ClientOptions{Offline: map[registry.Fingerprint]int{fingerprint: 7}},
+	})
+	framed, err := backend.Encode(ctx, "books-value", schema, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 7}, framed)
+	_, err = backend.Register(ctx, "strings-value", avro.String())
+	assert.Assert(t, errors.Is(err, registry.ErrOffline))
+	_, _, err = backend.Decode(ctx, framed)
+	assert.Assert(t, errors.Is(err, registry.ErrOffline))
+}
+
+func TestNewApicurioCompatClient(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeRegistry{subjects: map[string][]int{}}
+	server := httptest.NewServer(http.StripPrefix(registry.ApicurioCompatPath, fake))
+	t.Cleanup(server.Close)
+	client := registry.NewApicurioCompatClient(registry.ClientOptions{URL: server.URL + "/"})
+	schema := bookSchema(t)
+	framed, err := client.Encode(ctx, "books-value", schema, []byte{2, 4})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte{0, 0, 0, 0, 1, 2, 4}, framed)
+	decoded, _, err := registry.NewApicurioCompatClient(registry.ClientOptions{URL: server.URL}).Decode(ctx, framed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, decoded)
+}
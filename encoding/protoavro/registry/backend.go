@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+)
+
+// Backend is a schema registry, together with the wire format that frames encoded messages with the
+// identifier of their schema in the schema registry.
+type Backend interface {
+	// Encode registers the schema under the subject, unless it is already registered, and returns the Avro
+	// binary data framed with the identifier of the schema.
+	Encode(ctx context.Context, subject string, schema avro.Schema, data []byte) ([]byte, error)
+	// Decode returns the schema that framed data was written with, and the Avro binary data of the frame.
+	Decode(ctx context.Context, framed []byte) (avro.Schema, []byte, error)
+}
+
+var _ Backend = &Client{}
+
+// Operations of requests to schema registries, reported to protoavro.Instrumentation.RegistryRequest.
+const (
+	operationRegister = "registry.Register"
+	operationLookup   = "registry.Lookup"
+	operationSchema   = "registry.Schema"
+)
+
+// confluentMagicByte is the first byte of the Confluent wire format, followed by the big-endian 4-byte ID of
+// the schema.
+const confluentMagicByte = 0
+
+// Encode registers the schema under the subject, and returns the data in the Confluent wire format: a zero
+// magic byte and the 4-byte big-endian ID of the schema, followed by the data.
+func (c *Client) Encode(ctx context.Context, subject string, schema avro.Schema, data []byte) ([]byte, error) {
+	id, err := c.Register(ctx, subject, schema)
+	if err != nil {
+		return nil, err
+	}
+	framed := make([]byte, 0, 5+len(data))
+	framed = append(framed, confluentMagicByte)
+	framed = binary.BigEndian.AppendUint32(framed, uint32(id))
+	return append(framed, data...), nil
+}
+
+// Decode returns the schema and data of framed data in the Confluent wire format.
+func (c *Client) Decode(ctx context.Context, framed []byte) (avro.Schema, []byte, error) {
+	if len(framed) < 5 {
+		return nil, nil, errors.New("decode: data is too short for the confluent wire format")
+	}
+	if framed[0] != confluentMagicByte {
+		return nil, nil, fmt.Errorf("decode: unknown magic byte %d", framed[0])
+	}
+	schema, err := c.Schema(ctx, int(binary.BigEndian.Uint32(framed[1:5])))
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode: %w", err)
+	}
+	return schema, framed[5:], nil
+}
@@ -0,0 +1,345 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+)
+
+// Fingerprint is the CRC-64-AVRO (Rabin) fingerprint of the canonical form of a schema.
+type Fingerprint uint64
+
+// SchemaFingerprint returns the fingerprint of the schema.
+func SchemaFingerprint(schema avro.Schema) (Fingerprint, error) {
+	codec, err := newCodec(schema)
+	if err != nil {
+		return 0, fmt.Errorf("fingerprint: %w", err)
+	}
+	return Fingerprint(codec.Rabin), nil
+}
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// URL of the schema registry, for example "http://localhost:8081".
+	// An empty URL is offline mode, where schemas are only resolved from Offline.
+	URL string
+	// HTTPClient sends the requests to the schema registry, for example with a transport that authenticates
+	// the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// MaxRetries is the maximum number of retries of requests that fail with a server error (5xx), or
+	// without a response. Defaults to 3. A negative value disables retries.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, which is doubled for every following retry.
+	// Defaults to 100 milliseconds.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum delay between retries. Defaults to 5 seconds.
+	MaxBackoff time.Duration
+	// Offline maps the fingerprints of schemas to their IDs, for example for air-gapped deployments without
+	// access to the schema registry. Schemas in Offline are resolved without requests to the schema registry.
+	Offline map[Fingerprint]int
+	// Instrumentation receives the latency of requests to the schema registry, see
+	// protoavro.Instrumentation.RegistryRequest. Nil disables instrumentation.
+	Instrumentation protoavro.Instrumentation
+}
+
+// ErrOffline is returned in offline mode when a schema can not be resolved without the schema registry.
+var ErrOffline = errors.New("schema registry is offline")
+
+// Error is an error response of the schema registry.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is the error code of the schema registry, for example 40401 when a subject is not found.
+	Code int `json:"error_code"`
+	// Message is the error message of the schema registry.
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("schema registry: %d %s (error code %d)", e.StatusCode, e.Message, e.Code)
+}
+
+// Client is a client of a schema registry with the REST API of the Confluent Schema Registry.
+// The IDs of schemas are cached by subject and fingerprint, and schemas are cached by ID, so that every schema
+// is resolved with the schema registry at most once. A Client is safe for concurrent use.
+type Client struct {
+	rest    restClient
+	offline map[Fingerprint]int
+	mu      sync.RWMutex
+	// ids caches the IDs of the schemas of subjects.
+	ids map[subjectFingerprint]int
+	// schemas caches schemas by ID.
+	schemas map[int]avro.Schema
+}
+
+type subjectFingerprint struct {
+	subject     string
+	fingerprint Fingerprint
+	// metadata is the canonical form of the metadata properties the schema was registered with.
+	metadata string
+}
+
+// registerRequest is the body of requests that register or look up schemas.
+type registerRequest struct {
+	Schema   string          `json:"schema"`
+	Metadata *schemaMetadata `json:"metadata,omitempty"`
+}
+
+// schemaMetadata is the metadata of a schema, as defined by data contracts.
+type schemaMetadata struct {
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// metadataKey returns the canonical form of metadata properties, to cache schemas by their metadata.
+func metadataKey(properties map[string]string) string {
+	if len(properties) == 0 {
+		return ""
+	}
+	// json.Marshal sorts the keys of maps
+	data, _ := json.Marshal(properties)
+	return string(data)
+}
+
+// NewClient returns a new schema registry client.
+func NewClient(opts ClientOptions) *Client {
+	offline := make(map[Fingerprint]int, len(opts.Offline))
+	for fingerprint, id := range opts.Offline {
+		offline[fingerprint] = id
+	}
+	return &Client{
+		rest:    newRESTClient(opts),
+		offline: offline,
+		ids:     make(map[subjectFingerprint]int),
+		schemas: make(map[int]avro.Schema),
+	}
+}
+
+// Register registers the schema under the subject, unless it is already registered, and returns the ID of
+// the schema.
+func (c *Client) Register(ctx context.Context, subject string, schema avro.Schema) (int, error) {
+	return c.resolve(ctx, operationRegister, "/subjects/"+url.PathEscape(subject)+"/versions", subject, schema, nil)
+}
+
+// RegisterWithMetadata registers the schema under the subject with the metadata properties, for example the
+// properties of a protoavro.SchemaVersion, unless it is already registered with the properties, and returns
+// the ID of the schema. Schema metadata requires a schema registry with support for data contracts.
+func (c *Client) RegisterWithMetadata(
+	ctx context.Context,
+	subject string,
+	schema avro.Schema,
+	properties map[string]string,
+) (int, error) {
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	return c.resolve(ctx, operationRegister, path, subject, schema, properties)
+}
+
+// Lookup returns the ID of the schema, which must be registered under the subject.
+func (c *Client) Lookup(ctx context.Context, subject string, schema avro.Schema) (int, error) {
+	return c.resolve(ctx, operationLookup, "/subjects/"+url.PathEscape(subject), subject, schema, nil)
+}
+
+func (c *Client) resolve(
+	ctx context.Context,
+	operation string,
+	path string,
+	subject string,
+	schema avro.Schema,
+	properties map[string]string,
+) (int, error) {
+	fingerprint, err := SchemaFingerprint(schema)
+	if err != nil {
+		return 0, err
+	}
+	if id, ok := c.offline[fingerprint]; ok {
+		return id, nil
+	}
+	key := subjectFingerprint{subject: subject, fingerprint: fingerprint, metadata: metadataKey(properties)}
+	c.mu.RLock()
+	id, ok := c.ids[key]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+	if c.rest.url == "" {
+		return 0, fmt.Errorf("subject %s: schema with fingerprint %x: %w", subject, uint64(fingerprint), ErrOffline)
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return 0, fmt.Errorf("json marshal schema: %w", err)
+	}
+	request := registerRequest{Schema: string(schemaBytes)}
+	if len(properties) > 0 {
+		request.Metadata = &schemaMetadata{Properties: properties}
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+	var response struct {
+		ID int `json:"id"`
+	}
+	if err := c.rest.do(ctx, operation, http.MethodPost, path, nil, body, &response); err != nil {
+		return 0, fmt.Errorf("subject %s: %w", subject, err)
+	}
+	c.mu.Lock()
+	c.ids[key] = response.ID
+	c.schemas[response.ID] = schema
+	c.mu.Unlock()
+	return response.ID, nil
+}
+
+// Schema returns the schema with the ID.
+func (c *Client) Schema(ctx context.Context, id int) (avro.Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+	if c.rest.url == "" {
+		return nil, fmt.Errorf("schema %d: %w", id, ErrOffline)
+	}
+	var response struct {
+		Schema string `json:"schema"`
+	}
+	path := fmt.Sprintf("/schemas/ids/%d", id)
+	if err := c.rest.do(ctx, operationSchema, http.MethodGet, path, nil, nil, &response); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	schema, err := avro.Parse([]byte(response.Schema))
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	c.mu.Lock()
+	c.schemas[id] = schema
+	c.mu.Unlock()
+	return schema, nil
+}
+
+// restClient sends requests to the REST API of a schema registry.
+type restClient struct {
+	url            string
+	httpClient     *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	// instrumentation receives the latency of requests, or is nil.
+	instrumentation protoavro.Instrumentation
+}
+
+func newRESTClient(opts ClientOptions) restClient {
+	c := restClient{
+		url:             strings.TrimSuffix(opts.URL, "/"),
+		httpClient:      opts.HTTPClient,
+		maxRetries:      opts.MaxRetries,
+		initialBackoff:  opts.InitialBackoff,
+		maxBackoff:      opts.MaxBackoff,
+		instrumentation: opts.Instrumentation,
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = 3
+	}
+	if c.initialBackoff <= 0 {
+		c.initialBackoff = 100 * time.Millisecond
+	}
+	if c.maxBackoff <= 0 {
+		c.maxBackoff = 5 * time.Second
+	}
+	return c
+}
+
+// do sends a request of the operation to the schema registry, and retries server errors with exponential
+// backoff. The header overrides the default headers of the request.
+func (c restClient) do(
+	ctx context.Context,
+	operation string,
+	method string,
+	path string,
+	header http.Header,
+	body []byte,
+	response interface{},
+) (err error) {
+	if c.instrumentation != nil {
+		start := time.Now()
+		defer func() {
+			c.instrumentation.RegistryRequest(ctx, operation, time.Since(start), err)
+		}()
+	}
+	backoff := c.initialBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := c.doOnce(ctx, method, path, header, body, response)
+		if err == nil || !retry || attempt >= c.maxRetries {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
+}
+
+// doOnce sends a request to the schema registry, and returns whether a failed request can be retried.
+func (c restClient) doOnce(
+	ctx context.Context,
+	method string,
+	path string,
+	header http.Header,
+	body []byte,
+	response interface{},
+) (bool, error) {
+	var requestBody io.Reader
+	if body != nil {
+		requestBody = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, c.url+path, requestBody)
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	httpResponse, err := c.httpClient.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer httpResponse.Body.Close()
+	responseBody, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return true, err
+	}
+	if httpResponse.StatusCode >= 300 {
+		registryErr := &Error{StatusCode: httpResponse.StatusCode}
+		if err := json.Unmarshal(responseBody, registryErr); err != nil || registryErr.Message == "" {
+			registryErr.Message = http.StatusText(httpResponse.StatusCode)
+		}
+		return httpResponse.StatusCode >= 500, registryErr
+	}
+	if err := json.Unmarshal(responseBody, response); err != nil {
+		return false, fmt.Errorf("json unmarshal response: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,304 @@
+package registry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/registry"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"gotest.tools/v3/assert"
+)
+
+// fakeRegistry is a schema registry that fails the first failures requests with a server error.
+type fakeRegistry struct {
+	mu       sync.Mutex
+	failures int
+	requests int
+	schemas  []string
+	subjects map[string][]int
+	// properties holds the metadata properties of the last registered schema.
+	properties map[string]string
+}
+
+func newFakeRegistry(t *testing.T, failures int) (*fakeRegistry, *httptest.Server) {
+	t.Helper()
+	r := &fakeRegistry{failures: failures, subjects: map[string][]int{}}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	writeError := func(status int, code int, message string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"error_code": code, "message": message})
+	}
+	switch {
+	case req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, "/schemas/ids/"):
+		var id int
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(req.URL.Path, "/schemas/ids/")), &id); err != nil ||
+			id <= 0 || id > len(r.schemas) {
+			writeError(http.StatusNotFound, 40403, "Schema not found")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"schema": r.schemas[id-1]})
+	case req.Method == http.MethodPost && strings.HasPrefix(req.URL.Path, "/subjects/"):
+		var body struct {
+			Schema   string `json:"schema"`
+			Metadata struct {
+				Properties map[string]string `json:"properties"`
+			} `json:"metadata"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeError(http.StatusUnprocessableEntity, 42201, "Invalid schema")
+			return
+		}
+		subject := strings.TrimPrefix(req.URL.Path, "/subjects/")
+		register := strings.HasSuffix(subject, "/versions")
+		subject = strings.TrimSuffix(subject, "/versions")
+		id := 0
+		for i, schema := range r.schemas {
+			if schema == body.Schema {
+				id = i + 1
+			}
+		}
+		if !register {
+			for _, registered := range r.subjects[subject] {
+				if registered == id {
+					_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+					return
+				}
+			}
+			writeError(http.StatusNotFound, 40403, "Schema not found")
+			return
+		}
+		if id == 0 {
+			r.schemas = append(r.schemas, body.Schema)
+			id = len(r.schemas)
+		}
+		r.subjects[subject] = append(r.subjects[subject], id)
+		r.properties = body.Metadata.Properties
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+	default:
+		writeError(http.StatusNotFound, 404, "Not found")
+	}
+}
+
+func bookSchema(t *testing.T) avro.Schema {
+	t.Helper()
+	schema, err := protoavro.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	return schema
+}
+
+func TestClient_Register(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeRegistry(t, 0)
+	client := registry.NewClient(registry.ClientOptions{URL: server.URL})
+	schema := bookSchema(t)
+	_, err := client.Lookup(ctx, "books-value", schema)
+	var registryErr *registry.Error
+	assert.Assert(t, errors.As(err, &registryErr))
+	assert.Equal(t, 40403, registryErr.Code)
+	id, err := client.Register(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, id)
+	// registered schemas are cached
+	requests := fake.requests
+	id, err = client.Register(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, id)
+	id, err = client.Lookup(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, id)
+	got, err := client.Schema(ctx, 1)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, got)
+	assert.Equal(t, requests, fake.requests)
+	// other subjects are registered with the schema registry
+	id, err = client.Register(ctx, "google.example.library.v1.Book", schema)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, id)
+	assert.Equal(t, requests+1, fake.requests)
+}
+
+func TestClient_RegisterWithMetadata(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeRegistry(t, 0)
+	client := registry.NewClient(registry.ClientOptions{URL: server.URL})
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	version, err := protoavro.SchemaOptions{}.SchemaVersion(desc, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	assert.NilError(t, err)
+	schema := bookSchema(t)
+	id, err := client.RegisterWithMetadata(ctx, "books-value", schema, version.Properties())
+	assert.NilError(t, err)
+	assert.Equal(t, 1, id)
+	assert.DeepEqual(t, version.Properties(), fake.properties)
+	parsed, err := protoavro.ParseSchemaVersion(fake.properties)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, version, parsed)
+	// schemas are cached by their metadata
+	requests := fake.requests
+	_, err = client.RegisterWithMetadata(ctx, "books-value", schema, version.Properties())
+	assert.NilError(t, err)
+	assert.Equal(t, requests, fake.requests)
+	_, err = client.Register(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	assert.Equal(t, requests+1, fake.requests)
+	assert.Assert(t, fake.properties == nil)
+}
+
+func TestClient_Schema(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFakeRegistry(t, 0)
+	schema := bookSchema(t)
+	id, err := registry.NewClient(registry.ClientOptions{URL: server.URL}).Register(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	client := registry.NewClient(registry.ClientOptions{URL: server.URL})
+	got, err := client.Schema(ctx, id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, got)
+	_, err = client.Schema(ctx, 42)
+	assert.ErrorContains(t, err, "schema 42: schema registry: 404 Schema not found (error code 40403)")
+}
+
+func TestClient_Retries(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeRegistry(t, 2)
+	client := registry.NewClient(registry.ClientOptions{URL: server.URL, InitialBackoff: time.Millisecond})
+	id, err := client.Register(ctx, "books-value", bookSchema(t))
+	assert.NilError(t, err)
+	assert.Equal(t, 1, id)
+	assert.Equal(t, 3, fake.requests)
+
+	fake, server = newFakeRegistry(t, 10)
+	client = registry.NewClient(registry.ClientOptions{
+		URL:            server.URL,
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+	})
+	_, err = client.Register(ctx, "books-value", bookSchema(t))
+	var registryErr *registry.Error
+	assert.Assert(t, errors.As(err, &registryErr))
+	assert.Equal(t, http.StatusServiceUnavailable, registryErr.StatusCode)
+	assert.Equal(t, 3, fake.requests)
+
+	// client errors are not retried
+	fake, server = newFakeRegistry(t, 0)
+	client = registry.NewClient(registry.ClientOptions{URL: server.URL, InitialBackoff: time.Millisecond})
+	_, err = client.Lookup(ctx, "books-value", bookSchema(t))
+	assert.ErrorContains(t, err, "404")
+	assert.Equal(t, 1, fake.requests)
+}
+
+// registryInstrumentation records the requests to schema registries.
+type registryInstrumentation struct {
+	mu       sync.Mutex
+	requests []registryRequest
+}
+
+type registryRequest struct {
+	operation string
+	err       error
+}
+
+func (r *registryInstrumentation) StartSpan(ctx context.Context, _ string) (context.Context, func(error)) {
+	return ctx, func(error) {}
+}
+
+func (r *registryInstrumentation) RecordsEncoded(context.Context, int) {}
+
+func (r *registryInstrumentation) BytesWritten(context.Context, int64) {}
+
+func (r *registryInstrumentation) DecodeError(context.Context, string, error) {}
+
+func (r *registryInstrumentation) RegistryRequest(_ context.Context, operation string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d <= 0 {
+		err = errors.New("no latency")
+	}
+	r.requests = append(r.requests, registryRequest{operation: operation, err: err})
+}
+
+func TestClient_Instrumentation(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeRegistry(t, 1)
+	var instrumentation registryInstrumentation
+	opts := registry.ClientOptions{
+		URL:             server.URL,
+		InitialBackoff:  time.Millisecond,
+		Instrumentation: &instrumentation,
+	}
+	client := registry.NewClient(opts)
+	schema := bookSchema(t)
+	_, err := client.Register(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	// retries are part of the request
+	assert.Equal(t, 2, fake.requests)
+	_, err = client.Lookup(ctx, "strings-value", avro.String())
+	assert.ErrorContains(t, err, "404")
+	// cached schemas are not requested
+	_, err = client.Register(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	_, err = registry.NewClient(opts).Schema(ctx, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, 3, len(instrumentation.requests))
+	assert.Equal(t, "registry.Register", instrumentation.requests[0].operation)
+	assert.NilError(t, instrumentation.requests[0].err)
+	assert.Equal(t, "registry.Lookup", instrumentation.requests[1].operation)
+	var registryErr *registry.Error
+	assert.Assert(t, errors.As(instrumentation.requests[1].err, &registryErr))
+	assert.Equal(t, "registry.Schema", instrumentation.requests[2].operation)
+	assert.NilError(t, instrumentation.requests[2].err)
+}
+
+func TestClient_Offline(t *testing.T) {
+	ctx := context.Background()
+	schema := bookSchema(t)
+	fingerprint, err := registry.SchemaFingerprint(schema)
+	assert.NilError(t, err)
+	client := registry.NewClient(registry.ClientOptions{Offline: map[registry.Fingerprint]int{fingerprint: 7}})
+	id, err := client.Register(ctx, "books-value", schema)
+	assert.NilError(t, err)
+	assert.Equal(t, 7, id)
+	_, err = client.Register(ctx, "strings-value", avro.String())
+	assert.Assert(t, errors.Is(err, registry.ErrOffline))
+	_, err = client.Schema(ctx, 7)
+	assert.Assert(t, errors.Is(err, registry.ErrOffline))
+}
+
+func TestClient_Decode(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFakeRegistry(t, 0)
+	client := registry.NewClient(registry.ClientOptions{URL: server.URL})
+	schema := bookSchema(t)
+	framed, err := client.Encode(ctx, "books-value", schema, []byte{2, 4})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte{0, 0, 0, 0, 1, 2, 4}, framed)
+	decoded, data, err := registry.NewClient(registry.ClientOptions{URL: server.URL}).Decode(ctx, framed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, decoded)
+	assert.DeepEqual(t, []byte{2, 4}, data)
+	_, _, err = client.Decode(ctx, []byte{0, 0, 1})
+	assert.ErrorContains(t, err, "too short for the confluent wire format")
+	_, _, err = client.Decode(ctx, []byte{3, 0, 0, 0, 1})
+	assert.ErrorContains(t, err, "unknown magic byte 3")
+}
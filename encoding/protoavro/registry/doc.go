@@ -0,0 +1,3 @@
+// Package registry integrates the Avro schemas of protobuf messages with schema registries, such as the
+// Confluent Schema Registry used with Kafka.
+package registry
@@ -0,0 +1,214 @@
+package registry
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+)
+
+// GlueAPI is the part of the AWS Glue API used by a GlueBackend, typically implemented by an adapter of the
+// Glue client of the AWS SDK, with the name of the schema registry.
+type GlueAPI interface {
+	// RegisterSchemaVersion registers the Avro schema definition as a version of the schema with the name,
+	// unless it is already registered, and returns the ID of the schema version.
+	// The schema is created if it does not exist, for example with GetSchemaByDefinition, CreateSchema and
+	// RegisterSchemaVersion.
+	RegisterSchemaVersion(ctx context.Context, schemaName string, definition string) (string, error)
+	// GetSchemaVersion returns the Avro schema definition of the schema version with the ID.
+	GetSchemaVersion(ctx context.Context, schemaVersionID string) (string, error)
+}
+
+// GlueOptions configures a GlueBackend.
+type GlueOptions struct {
+	// Compression compresses the data of encoded messages with zlib.
+	Compression bool
+	// Instrumentation receives the latency of requests to the Glue API, see
+	// protoavro.Instrumentation.RegistryRequest. Nil disables instrumentation.
+	Instrumentation protoavro.Instrumentation
+}
+
+// Header bytes of the Glue wire format.
+const (
+	glueHeaderVersion      = 3
+	glueCompressionNone    = 0
+	glueCompressionZlib    = 5
+	glueSchemaVersionIDLen = 16
+	glueHeaderLen          = 2 + glueSchemaVersionIDLen
+)
+
+// GlueBackend is a Backend of the AWS Glue Schema Registry. The names of Glue schemas are the subjects of
+// the messages. Schema version IDs are cached by subject and fingerprint, and schemas by schema version ID.
+// A GlueBackend is safe for concurrent use.
+type GlueBackend struct {
+	api  GlueAPI
+	opts GlueOptions
+	mu   sync.RWMutex
+	// ids caches the schema version IDs of the schemas of subjects.
+	ids map[subjectFingerprint][glueSchemaVersionIDLen]byte
+	// schemas caches schemas by schema version ID.
+	schemas map[[glueSchemaVersionIDLen]byte]avro.Schema
+}
+
+var _ Backend = &GlueBackend{}
+
+// NewGlueBackend returns a new backend of the AWS Glue Schema Registry.
+func NewGlueBackend(api GlueAPI, opts GlueOptions) *GlueBackend {
+	return &GlueBackend{
+		api:     api,
+		opts:    opts,
+		ids:     make(map[subjectFingerprint][glueSchemaVersionIDLen]byte),
+		schemas: make(map[[glueSchemaVersionIDLen]byte]avro.Schema),
+	}
+}
+
+// Encode registers the schema as a version of the Glue schema named by the subject, and returns the data in
+// the Glue wire format: a header version byte, a compression byte and the 16-byte UUID of the schema version,
+// followed by the data, compressed with zlib if Compression is set.
+func (g *GlueBackend) Encode(ctx context.Context, subject string, schema avro.Schema, data []byte) ([]byte, error) {
+	id, err := g.register(ctx, subject, schema)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	framed := make([]byte, 0, glueHeaderLen+len(data))
+	if !g.opts.Compression {
+		framed = append(framed, glueHeaderVersion, glueCompressionNone)
+		framed = append(framed, id[:]...)
+		return append(framed, data...), nil
+	}
+	framed = append(framed, glueHeaderVersion, glueCompressionZlib)
+	framed = append(framed, id[:]...)
+	b := bytes.NewBuffer(framed)
+	w := zlib.NewWriter(b)
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("encode: compress: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("encode: compress: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// Decode returns the schema and data of framed data in the Glue wire format.
+func (g *GlueBackend) Decode(ctx context.Context, framed []byte) (avro.Schema, []byte, error) {
+	if len(framed) < glueHeaderLen {
+		return nil, nil, errors.New("decode: data is too short for the glue wire format")
+	}
+	if framed[0] != glueHeaderVersion {
+		return nil, nil, fmt.Errorf("decode: unknown header version %d", framed[0])
+	}
+	var id [glueSchemaVersionIDLen]byte
+	copy(id[:], framed[2:glueHeaderLen])
+	data := framed[glueHeaderLen:]
+	switch framed[1] {
+	case glueCompressionNone:
+	case glueCompressionZlib:
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("decode: decompress: %w", err)
+		}
+		if data, err = io.ReadAll(r); err != nil {
+			return nil, nil, fmt.Errorf("decode: decompress: %w", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("decode: unknown compression %d", framed[1])
+	}
+	schema, err := g.schema(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode: %w", err)
+	}
+	return schema, data, nil
+}
+
+func (g *GlueBackend) register(
+	ctx context.Context,
+	subject string,
+	schema avro.Schema,
+) ([glueSchemaVersionIDLen]byte, error) {
+	var id [glueSchemaVersionIDLen]byte
+	fingerprint, err := SchemaFingerprint(schema)
+	if err != nil {
+		return id, err
+	}
+	key := subjectFingerprint{subject: subject, fingerprint: fingerprint}
+	g.mu.RLock()
+	id, ok := g.ids[key]
+	g.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+	definition, err := json.Marshal(schema)
+	if err != nil {
+		return id, fmt.Errorf("json marshal schema: %w", err)
+	}
+	start := time.Now()
+	versionID, err := g.api.RegisterSchemaVersion(ctx, subject, string(definition))
+	g.registryRequest(ctx, operationRegister, start, err)
+	if err != nil {
+		return id, fmt.Errorf("schema %s: %w", subject, err)
+	}
+	if id, err = parseUUID(versionID); err != nil {
+		return id, fmt.Errorf("schema %s: schema version ID: %w", subject, err)
+	}
+	g.mu.Lock()
+	g.ids[key] = id
+	g.schemas[id] = schema
+	g.mu.Unlock()
+	return id, nil
+}
+
+func (g *GlueBackend) schema(ctx context.Context, id [glueSchemaVersionIDLen]byte) (avro.Schema, error) {
+	g.mu.RLock()
+	schema, ok := g.schemas[id]
+	g.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+	versionID := formatUUID(id)
+	start := time.Now()
+	definition, err := g.api.GetSchemaVersion(ctx, versionID)
+	g.registryRequest(ctx, operationSchema, start, err)
+	if err != nil {
+		return nil, fmt.Errorf("schema version %s: %w", versionID, err)
+	}
+	if schema, err = avro.Parse([]byte(definition)); err != nil {
+		return nil, fmt.Errorf("schema version %s: %w", versionID, err)
+	}
+	g.mu.Lock()
+	g.schemas[id] = schema
+	g.mu.Unlock()
+	return schema, nil
+}
+
+func (g *GlueBackend) registryRequest(ctx context.Context, operation string, start time.Time, err error) {
+	if g.opts.Instrumentation != nil {
+		g.opts.Instrumentation.RegistryRequest(ctx, operation, time.Since(start), err)
+	}
+}
+
+// parseUUID parses a UUID in its canonical form, for example "b8a3b4a2-4d13-4a8e-9e1e-7b1b3d1c2a4f".
+func parseUUID(s string) ([glueSchemaVersionIDLen]byte, error) {
+	var id [glueSchemaVersionIDLen]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return id, fmt.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(id[:], []byte(strings.ReplaceAll(s, "-", ""))); err != nil {
+		return id, fmt.Errorf("invalid UUID %q: %w", s, err)
+	}
+	return id, nil
+}
+
+func formatUUID(id [glueSchemaVersionIDLen]byte) string {
+	s := hex.EncodeToString(id[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
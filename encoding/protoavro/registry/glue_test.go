@@ -0,0 +1,152 @@
+package registry_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/registry"
+	"gotest.tools/v3/assert"
+)
+
+// fakeGlue is an in-memory AWS Glue Schema Registry.
+type fakeGlue struct {
+	mu       sync.Mutex
+	requests int
+	versions map[string]string
+}
+
+func newFakeGlue() *fakeGlue {
+	return &fakeGlue{versions: map[string]string{}}
+}
+
+func (g *fakeGlue) RegisterSchemaVersion(_ context.Context, schemaName string, definition string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requests++
+	for id, registered := range g.versions {
+		if registered == definition {
+			return id, nil
+		}
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(len(g.versions)+1))
+	id := fmt.Sprintf("b8a3b4a2-4d13-4a8e-%x-%x", b[:2], b[2:])
+	g.versions[id] = definition
+	return id, nil
+}
+
+func (g *fakeGlue) GetSchemaVersion(_ context.Context, schemaVersionID string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requests++
+	definition, ok := g.versions[schemaVersionID]
+	if !ok {
+		return "", fmt.Errorf("schema version %s not found", schemaVersionID)
+	}
+	return definition, nil
+}
+
+func TestGlueBackend(t *testing.T) {
+	ctx := context.Background()
+	schema := bookSchema(t)
+	data := []byte("avro binary data avro binary data avro binary data")
+	for _, tt := range []struct {
+		name        string
+		opts        registry.GlueOptions
+		compression byte
+	}{
+		{name: "uncompressed", compression: 0},
+		{name: "zlib", opts: registry.GlueOptions{Compression: true}, compression: 5},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			glue := newFakeGlue()
+			backend := registry.NewGlueBackend(glue, tt.opts)
+			framed, err := backend.Encode(ctx, "books", schema, data)
+			assert.NilError(t, err)
+			assert.Equal(t, byte(3), framed[0])
+			assert.Equal(t, tt.compression, framed[1])
+			assert.DeepEqual(
+				t,
+				[]byte{0xb8, 0xa3, 0xb4, 0xa2, 0x4d, 0x13, 0x4a, 0x8e, 0, 0, 0, 0, 0, 0, 0, 1},
+				framed[2:18],
+			)
+			// schema version IDs are cached
+			_, err = backend.Encode(ctx, "books", schema, data)
+			assert.NilError(t, err)
+			assert.Equal(t, 1, glue.requests)
+			// schemas are resolved by other backends
+			decoded, payload, err := registry.NewGlueBackend(glue, registry.GlueOptions{}).Decode(ctx, framed)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, schema, decoded)
+			assert.DeepEqual(t, data, payload)
+			assert.Equal(t, 2, glue.requests)
+		})
+	}
+}
+
+func TestGlueBackend_Errors(t *testing.T) {
+	ctx := context.Background()
+	backend := registry.NewGlueBackend(newFakeGlue(), registry.GlueOptions{})
+	for _, tt := range []struct {
+		name          string
+		framed        []byte
+		errorContains string
+	}{
+		{name: "too short", framed: []byte{3, 0, 1}, errorContains: "too short"},
+		{name: "header version", framed: make([]byte, 18), errorContains: "unknown header version 0"},
+		{name: "compression", framed: append([]byte{3, 1}, make([]byte, 16)...), errorContains: "unknown compression 1"},
+		{
+			name:          "unknown schema version",
+			framed:        append([]byte{3, 0}, make([]byte, 16)...),
+			errorContains: "schema version 00000000-0000-0000-0000-000000000000 not found",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := backend.Decode(ctx, tt.framed)
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+	_, err := registry.NewGlueBackend(invalidGlue{}, registry.GlueOptions{}).Encode(ctx, "strings", avro.String(), nil)
+	assert.ErrorContains(t, err, `invalid UUID "not-a-uuid"`)
+}
+
+func TestGlueBackend_Instrumentation(t *testing.T) {
+	ctx := context.Background()
+	var instrumentation registryInstrumentation
+	glue := newFakeGlue()
+	opts := registry.GlueOptions{Instrumentation: &instrumentation}
+	framed, err := registry.NewGlueBackend(glue, opts).Encode(ctx, "books", bookSchema(t), nil)
+	assert.NilError(t, err)
+	_, _, err = registry.NewGlueBackend(glue, opts).Decode(ctx, framed)
+	assert.NilError(t, err)
+	_, _, err = registry.NewGlueBackend(glue, opts).Decode(ctx, append([]byte{3, 0}, make([]byte, 16)...))
+	assert.ErrorContains(t, err, "not found")
+	assert.DeepEqual(
+		t,
+		[]string{"registry.Register", "registry.Schema", "registry.Schema"},
+		[]string{
+			instrumentation.requests[0].operation,
+			instrumentation.requests[1].operation,
+			instrumentation.requests[2].operation,
+		},
+	)
+	assert.NilError(t, instrumentation.requests[1].err)
+	assert.ErrorContains(t, instrumentation.requests[2].err, "not found")
+}
+
+// invalidGlue returns invalid schema version IDs.
+type invalidGlue struct{}
+
+func (invalidGlue) RegisterSchemaVersion(context.Context, string, string) (string, error) {
+	return "not-a-uuid", nil
+}
+
+func (invalidGlue) GetSchemaVersion(context.Context, string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// SerdeOptions configures a Serde.
+type SerdeOptions struct {
+	// SchemaOptions are the options of the schemas inferred for messages, and of their encoding.
+	SchemaOptions protoavro.SchemaOptions
+	// SubjectNameStrategy names the subjects of the schemas of messages. Defaults to TopicNameStrategy.
+	SubjectNameStrategy SubjectNameStrategy
+}
+
+// Serde encodes and decodes protobuf messages, for example the keys and values of Kafka records, in the wire
+// format of a schema registry Backend. A Serde is safe for concurrent use.
+type Serde struct {
+	backend Backend
+	opts    SerdeOptions
+	// writers caches the schemas and codecs of messages by full name.
+	writers sync.Map
+	// readers caches the codecs of writer schemas by fingerprint.
+	readers sync.Map
+}
+
+type serdeWriter struct {
+	schema avro.Schema
+	codec  *goavro.Codec
+}
+
+// NewSerde returns a new serde of the backend.
+func NewSerde(backend Backend, opts SerdeOptions) *Serde {
+	if opts.SubjectNameStrategy == nil {
+		opts.SubjectNameStrategy = TopicNameStrategy
+	}
+	return &Serde{backend: backend, opts: opts}
+}
+
+// Marshal encodes the message, as the key or value of a record in the topic, in Avro binary format, framed
+// by the backend with the identifier of the schema of the message.
+func (s *Serde) Marshal(ctx context.Context, topic string, isKey bool, message proto.Message) ([]byte, error) {
+	writer, err := s.writer(message.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	subject, err := s.opts.SubjectNameStrategy(topic, isKey, writer.schema)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	native, err := s.opts.SchemaOptions.Encode(message)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	data, err := writer.codec.BinaryFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: binary from native: %w", err)
+	}
+	framed, err := s.backend.Encode(ctx, subject, writer.schema, data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	return framed, nil
+}
+
+// Unmarshal decodes framed data into the message, with the schema that the data was written with.
+func (s *Serde) Unmarshal(ctx context.Context, framed []byte, message proto.Message) error {
+	schema, data, err := s.backend.Decode(ctx, framed)
+	if err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	codec, err := s.reader(schema)
+	if err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	native, _, err := codec.NativeFromBinary(data)
+	if err != nil {
+		return fmt.Errorf("unmarshal: native from binary: %w", err)
+	}
+	if err := s.opts.SchemaOptions.Decode(native, message); err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	return nil
+}
+
+func (s *Serde) writer(desc protoreflect.MessageDescriptor) (*serdeWriter, error) {
+	if writer, ok := s.writers.Load(desc.FullName()); ok {
+		return writer.(*serdeWriter), nil
+	}
+	schema, err := s.opts.SchemaOptions.InferSchema(desc)
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	codec, err := newCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+	writer, _ := s.writers.LoadOrStore(desc.FullName(), &serdeWriter{schema: schema, codec: codec})
+	return writer.(*serdeWriter), nil
+}
+
+func (s *Serde) reader(schema avro.Schema) (*goavro.Codec, error) {
+	fingerprint, err := SchemaFingerprint(schema)
+	if err != nil {
+		return nil, err
+	}
+	if codec, ok := s.readers.Load(fingerprint); ok {
+		return codec.(*goavro.Codec), nil
+	}
+	codec, err := newCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+	stored, _ := s.readers.LoadOrStore(fingerprint, codec)
+	return stored.(*goavro.Codec), nil
+}
+
+func newCodec(schema avro.Schema) (*goavro.Codec, error) {
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new codec: %w", err)
+	}
+	return codec, nil
+}
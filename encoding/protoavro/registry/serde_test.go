@@ -0,0 +1,56 @@
+package registry_test
+
+import (
+	"context"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro/registry"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestSerde(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFakeRegistry(t, 0)
+	for _, tt := range []struct {
+		name    string
+		backend registry.Backend
+		header  []byte
+	}{
+		{
+			name:    "confluent",
+			backend: registry.NewClient(registry.ClientOptions{URL: server.URL}),
+			header:  []byte{0, 0, 0, 0, 1},
+		},
+		{
+			name:    "glue",
+			backend: registry.NewGlueBackend(newFakeGlue(), registry.GlueOptions{}),
+			header:  []byte{3, 0, 0xb8, 0xa3, 0xb4, 0xa2, 0x4d, 0x13, 0x4a, 0x8e, 0, 0, 0, 0, 0, 0, 0, 1},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			serde := registry.NewSerde(tt.backend, registry.SerdeOptions{})
+			book := &library.Book{Name: "shelves/1/books/1", Title: "Harry Potter", Author: "J. K. Rowling"}
+			framed, err := serde.Marshal(ctx, "books", false, book)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.header, framed[:len(tt.header)])
+			var got library.Book
+			assert.NilError(t, serde.Unmarshal(ctx, framed, &got))
+			assert.DeepEqual(t, book, &got, protocmp.Transform())
+		})
+	}
+}
+
+func TestSerde_SubjectNameStrategy(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeRegistry(t, 0)
+	serde := registry.NewSerde(
+		registry.NewClient(registry.ClientOptions{URL: server.URL}),
+		registry.SerdeOptions{SubjectNameStrategy: registry.RecordNameStrategy},
+	)
+	_, err := serde.Marshal(ctx, "books", false, &library.Book{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []int{1}, fake.subjects["google.example.library.v1.Book"])
+}
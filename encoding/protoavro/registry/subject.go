@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.einride.tech/protobuf-avro/avro"
+)
+
+// SubjectNameStrategy returns the subject that the schema of the keys, or values, of records in a topic is
+// registered under. The strategies of the Confluent Schema Registry are TopicNameStrategy, RecordNameStrategy
+// and TopicRecordNameStrategy. Other conventions are implemented as a custom SubjectNameStrategy.
+type SubjectNameStrategy func(topic string, isKey bool, schema avro.Schema) (string, error)
+
+// TopicNameStrategy names subjects by the topic, with the suffix "-key" or "-value", for example
+// "orders-value". Every record of the topic must have the same schema, or a compatible version of it.
+// TopicNameStrategy is the default strategy of the Confluent Schema Registry.
+func TopicNameStrategy(topic string, isKey bool, _ avro.Schema) (string, error) {
+	if topic == "" {
+		return "", errors.New("topic name strategy: empty topic")
+	}
+	if isKey {
+		return topic + "-key", nil
+	}
+	return topic + "-value", nil
+}
+
+// RecordNameStrategy names subjects by the full name of the record, for example "einride.orders.v1.Order",
+// so that records of several types can share a topic, and a type has the same subject in every topic.
+func RecordNameStrategy(_ string, _ bool, schema avro.Schema) (string, error) {
+	name, err := RecordFullName(schema)
+	if err != nil {
+		return "", fmt.Errorf("record name strategy: %w", err)
+	}
+	return name, nil
+}
+
+// TopicRecordNameStrategy names subjects by the topic and the full name of the record, for example
+// "orders-einride.orders.v1.Order", so that records of several types can share a topic, with subjects
+// that are specific to the topic.
+func TopicRecordNameStrategy(topic string, _ bool, schema avro.Schema) (string, error) {
+	if topic == "" {
+		return "", errors.New("topic record name strategy: empty topic")
+	}
+	name, err := RecordFullName(schema)
+	if err != nil {
+		return "", fmt.Errorf("topic record name strategy: %w", err)
+	}
+	return topic + "-" + name, nil
+}
+
+// RecordFullName returns the full name of the record of a schema, which is a record or a nullable record
+// as inferred for protobuf messages.
+func RecordFullName(schema avro.Schema) (string, error) {
+	if union, ok := schema.(avro.Union); ok && len(union) == 2 && union[0] == avro.Null() {
+		schema = union[1]
+	}
+	record, ok := schema.(avro.Record)
+	if !ok {
+		return "", fmt.Errorf("schema of type %T is not a record", schema)
+	}
+	if record.Namespace == "" || strings.Contains(record.Name, ".") {
+		return record.Name, nil
+	}
+	return record.Namespace + "." + record.Name, nil
+}
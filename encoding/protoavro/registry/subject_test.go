@@ -0,0 +1,106 @@
+package registry_test
+
+import (
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/registry"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestSubjectNameStrategy(t *testing.T) {
+	schema, err := protoavro.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	stripped, err := protoavro.SchemaOptions{StripNamespaces: true}.InferSchema(
+		(&library.Book{}).ProtoReflect().Descriptor(),
+	)
+	assert.NilError(t, err)
+	// custom strategies are functions, for example to prefix subjects by environment
+	environmentStrategy := func(topic string, isKey bool, schema avro.Schema) (string, error) {
+		subject, err := registry.TopicNameStrategy(topic, isKey, schema)
+		if err != nil {
+			return "", err
+		}
+		return "staging." + subject, nil
+	}
+	for _, tt := range []struct {
+		name          string
+		strategy      registry.SubjectNameStrategy
+		topic         string
+		isKey         bool
+		schema        avro.Schema
+		expected      string
+		errorContains string
+	}{
+		{
+			name:     "topic name value",
+			strategy: registry.TopicNameStrategy,
+			topic:    "books",
+			schema:   schema,
+			expected: "books-value",
+		},
+		{
+			name:     "topic name key",
+			strategy: registry.TopicNameStrategy,
+			topic:    "books",
+			isKey:    true,
+			schema:   avro.String(),
+			expected: "books-key",
+		},
+		{
+			name:          "topic name without topic",
+			strategy:      registry.TopicNameStrategy,
+			schema:        schema,
+			errorContains: "empty topic",
+		},
+		{
+			name:     "record name",
+			strategy: registry.RecordNameStrategy,
+			topic:    "books",
+			schema:   schema,
+			expected: "google.example.library.v1.Book",
+		},
+		{
+			name:     "record name without namespace",
+			strategy: registry.RecordNameStrategy,
+			topic:    "books",
+			schema:   stripped,
+			expected: "Book",
+		},
+		{
+			name:          "record name of primitive",
+			strategy:      registry.RecordNameStrategy,
+			topic:         "books",
+			isKey:         true,
+			schema:        avro.String(),
+			errorContains: "record name strategy: schema of type avro.Primitive is not a record",
+		},
+		{
+			name:     "topic record name",
+			strategy: registry.TopicRecordNameStrategy,
+			topic:    "books",
+			schema:   schema,
+			expected: "books-google.example.library.v1.Book",
+		},
+		{
+			name:     "custom",
+			strategy: environmentStrategy,
+			topic:    "books",
+			schema:   schema,
+			expected: "staging.books-value",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := tt.strategy(tt.topic, tt.isKey, tt.schema)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, subject)
+		})
+	}
+}
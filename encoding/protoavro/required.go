@@ -0,0 +1,69 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// requiredFields caches the fields of messages with REQUIRED field behavior.
+var requiredFields descriptorCache[protoreflect.MessageDescriptor, []protoreflect.FieldDescriptor]
+
+// requiredFieldsOf returns the fields of the message with REQUIRED google.api.field_behavior.
+func requiredFieldsOf(desc protoreflect.MessageDescriptor) []protoreflect.FieldDescriptor {
+	if fields, ok := requiredFields.load(desc); ok {
+		return fields
+	}
+	var fields []protoreflect.FieldDescriptor
+	for i := 0; i < desc.Fields().Len(); i++ {
+		field := desc.Fields().Get(i)
+		behaviors, _ := proto.GetExtension(field.Options(), annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+		for _, behavior := range behaviors {
+			if behavior == annotations.FieldBehavior_REQUIRED {
+				fields = append(fields, field)
+				break
+			}
+		}
+	}
+	return requiredFields.loadOrStore(desc, fields)
+}
+
+// checkRequiredFields returns an error if a field with REQUIRED field behavior, selected by the mask, is not set
+// in the decoded message or in the messages it contains.
+func checkRequiredFields(msg protoreflect.Message, mask fieldMaskTree) error {
+	for _, field := range requiredFieldsOf(msg.Descriptor()) {
+		if _, ok := mask.child(string(field.Name())); ok && !msg.Has(field) {
+			return fmt.Errorf("missing required field %s", field.FullName())
+		}
+	}
+	var err error
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		fieldMask, ok := mask.child(string(field.Name()))
+		if !ok {
+			return true
+		}
+		switch {
+		case field.IsMap():
+			if field.MapValue().Message() == nil {
+				return true
+			}
+			value.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+				err = checkRequiredFields(value.Message(), fieldMask)
+				return err == nil
+			})
+		case field.IsList():
+			if field.Message() == nil {
+				return true
+			}
+			for i := 0; i < value.List().Len() && err == nil; i++ {
+				err = checkRequiredFields(value.List().Get(i).Message(), fieldMask)
+			}
+		case field.Message() != nil:
+			err = checkRequiredFields(value.Message(), fieldMask)
+		}
+		return err == nil
+	})
+	return err
+}
@@ -0,0 +1,96 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestRequireFields(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		msg      proto.Message
+		expected string
+	}{
+		{
+			name: "all set",
+			msg: &library.CreateBookRequest{
+				Parent: "shelves/1",
+				Book:   &library.Book{Title: "title"},
+			},
+		},
+		{
+			name:     "missing message",
+			msg:      &library.CreateBookRequest{Parent: "shelves/1"},
+			expected: "missing required field google.example.library.v1.CreateBookRequest.book",
+		},
+		{
+			name:     "zero value",
+			msg:      &library.CreateBookRequest{Book: &library.Book{}},
+			expected: "missing required field google.example.library.v1.CreateBookRequest.parent",
+		},
+		{
+			name: "well-known type",
+			msg: &library.UpdateBookRequest{
+				Book:       &library.Book{},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SchemaOptions{RequireFields: true}
+			desc := tt.msg.ProtoReflect().Descriptor()
+			schema, err := opts.InferSchema(desc)
+			assert.NilError(t, err)
+			codec := newTestGoavroCodec(t, opts, tt.msg)
+			native, err := opts.encodeJSON(tt.msg)
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, native)
+			assert.NilError(t, err)
+			textual, err := codec.TextualFromNative(nil, native)
+			assert.NilError(t, err)
+			binaryDecoder, err := opts.newBinaryDecoder(desc, schema)
+			assert.NilError(t, err)
+			jsonDecoder, err := opts.newAvroJSONDecoder(desc, schema)
+			assert.NilError(t, err)
+			for decoderName, decode := range map[string]func(msg protoreflect.Message) error{
+				"generic": func(msg protoreflect.Message) error {
+					return opts.decodeJSON(native, msg.Interface())
+				},
+				"binary": func(msg protoreflect.Message) error {
+					return binaryDecoder.decode(binary, msg)
+				},
+				"avro json": func(msg protoreflect.Message) error {
+					return jsonDecoder.decode(bytes.NewReader(textual), msg)
+				},
+			} {
+				decoded := tt.msg.ProtoReflect().New()
+				err := decode(decoded)
+				if tt.expected != "" {
+					assert.Error(t, err, tt.expected, decoderName)
+					continue
+				}
+				assert.NilError(t, err, decoderName)
+				assert.DeepEqual(t, tt.msg, decoded.Interface(), protocmp.Transform())
+			}
+			// required fields are not checked by default
+			decoded := tt.msg.ProtoReflect().New()
+			assert.NilError(t, SchemaOptions{}.Decode(native, decoded.Interface()))
+		})
+	}
+
+	t.Run("decode mask", func(t *testing.T) {
+		opts := SchemaOptions{RequireFields: true, DecodeMask: &fieldmaskpb.FieldMask{Paths: []string{"book"}}}
+		native, err := opts.encodeJSON(&library.CreateBookRequest{Book: &library.Book{}})
+		assert.NilError(t, err)
+		// fields that are not decoded are not required
+		assert.NilError(t, opts.decodeJSON(native, &library.CreateBookRequest{}))
+	})
+}
@@ -0,0 +1,80 @@
+package protoavro
+
+import (
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoKindProperty is the custom property of record fields with the protobuf kind of the field, for example
+// "sfixed64". See SchemaOptions.AnnotateProtoKinds.
+const ProtoKindProperty = "protoKind"
+
+// ProtoFieldNumberProperty is the custom property of record fields with the protobuf field number of the field,
+// as a JSON number. See SchemaOptions.AnnotateFieldNumbers.
+const ProtoFieldNumberProperty = "protoFieldNumber"
+
+// recordProperties returns the custom properties of the record of a message.
+func (o SchemaOptions) recordProperties(desc protoreflect.MessageDescriptor) avro.Properties {
+	var properties avro.Properties
+	if o.annotatesDeprecated(desc) {
+		properties = avro.Properties{"deprecated": true}
+	}
+	if o.AnnotateResources {
+		resource, _ := proto.GetExtension(desc.Options(), annotations.E_Resource).(*annotations.ResourceDescriptor)
+		if resource.GetType() != "" {
+			properties = mergeProperties(properties, avro.Properties{"resourceType": resource.GetType()})
+		}
+	}
+	if o.RecordPropertiesFunc != nil {
+		properties = mergeProperties(properties, o.RecordPropertiesFunc(desc))
+	}
+	return properties
+}
+
+// fieldProperties returns the custom properties of the record field of a message field.
+func (o SchemaOptions) fieldProperties(field protoreflect.FieldDescriptor) avro.Properties {
+	var properties avro.Properties
+	if o.AnnotateProtoKinds {
+		properties = mergeProperties(properties, avro.Properties{ProtoKindProperty: field.Kind().String()})
+	}
+	if o.AnnotateFieldNumbers {
+		// numbers are float64, like the numbers of parsed properties
+		properties = mergeProperties(
+			properties, avro.Properties{ProtoFieldNumberProperty: float64(field.Number())},
+		)
+	}
+	if o.annotatesDeprecated(field) {
+		properties = mergeProperties(properties, avro.Properties{"deprecated": true})
+	}
+	if keyID, ok := o.encryptionKey(field); ok {
+		properties = mergeProperties(properties, avro.Properties{EncryptionKeyProperty: keyID})
+	}
+	if o.AnnotateResources {
+		reference, _ := proto.GetExtension(field.Options(), annotations.E_ResourceReference).(*annotations.ResourceReference)
+		switch {
+		case reference.GetType() != "":
+			properties = mergeProperties(properties, avro.Properties{"resourceReference": reference.GetType()})
+		case reference.GetChildType() != "":
+			properties = mergeProperties(
+				properties, avro.Properties{"resourceChildReference": reference.GetChildType()},
+			)
+		}
+	}
+	if o.FieldPropertiesFunc != nil {
+		properties = mergeProperties(properties, o.FieldPropertiesFunc(field))
+	}
+	return properties
+}
+
+// mergeProperties returns the properties with the overrides, which take precedence.
+func mergeProperties(properties, overrides avro.Properties) avro.Properties {
+	if len(properties) == 0 {
+		return overrides
+	}
+	for name, value := range overrides {
+		properties[name] = value
+	}
+	return properties
+}
@@ -5,58 +5,269 @@ import (
 	"strings"
 
 	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/internal/wkt"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // InferSchema returns the Avro schema, with default SchemaOptions, for the protobuf message descriptor.
 func InferSchema(desc protoreflect.MessageDescriptor) (avro.Schema, error) {
-	return SchemaOptions{}.newSchemaInferrer().inferMessageSchema(desc, 0)
+	return SchemaOptions{}.InferSchema(desc)
 }
 
 // InferSchema returns the Avro schema for the protobuf message descriptor.
 func (o SchemaOptions) InferSchema(desc protoreflect.MessageDescriptor) (avro.Schema, error) {
-	return o.newSchemaInferrer().inferMessageSchema(desc, 0)
+	return o.NewSchemaInferrer().InferSchema(desc)
+}
+
+// InferSchemas returns the Avro schemas, with default SchemaOptions, for several protobuf message descriptors.
+func InferSchemas(descs []protoreflect.MessageDescriptor) (map[protoreflect.FullName]avro.Schema, error) {
+	return SchemaOptions{}.InferSchemas(descs)
+}
+
+// InferSchemas returns the Avro schemas for several protobuf message descriptors, keyed by the full name of
+// the message, for example to generate a consistent bundle of schemas for the messages of a protobuf package.
+// The schemas share named types: each named type is defined exactly once across the schemas, and referenced
+// by its full name elsewhere. Messages in descs that are nested in other messages in descs are defined by
+// their own schema, and referenced by the schemas of the messages they are nested in.
+func (o SchemaOptions) InferSchemas(
+	descs []protoreflect.MessageDescriptor,
+) (map[protoreflect.FullName]avro.Schema, error) {
+	inferrer := o.withNames(descs...).NewSchemaInferrer()
+	schemas := make(map[protoreflect.FullName]avro.Schema, len(descs))
+	for _, desc := range o.nestedFirst(descs) {
+		if _, ok := schemas[desc.FullName()]; ok {
+			continue
+		}
+		schema, err := inferrer.InferSchema(desc)
+		if err != nil {
+			return nil, fmt.Errorf("infer schema %s: %w", desc.FullName(), err)
+		}
+		schemas[desc.FullName()] = schema
+	}
+	return schemas, nil
+}
+
+// nestedFirst returns the message descriptors ordered so that messages nested in other messages come first.
+// Recursive messages are ordered as they were first reached.
+func (o SchemaOptions) nestedFirst(descs []protoreflect.MessageDescriptor) []protoreflect.MessageDescriptor {
+	listed := make(map[protoreflect.FullName]struct{}, len(descs))
+	for _, desc := range descs {
+		listed[desc.FullName()] = struct{}{}
+	}
+	visited := make(map[protoreflect.FullName]struct{})
+	result := make([]protoreflect.MessageDescriptor, 0, len(descs))
+	var visit func(message protoreflect.MessageDescriptor)
+	visit = func(message protoreflect.MessageDescriptor) {
+		if _, ok := visited[message.FullName()]; ok {
+			return
+		}
+		visited[message.FullName()] = struct{}{}
+		if _, ok := lookupMessageCodec(message.FullName()); !ok && !o.isWKT(message.FullName()) {
+			for i := 0; i < message.Fields().Len(); i++ {
+				if field := message.Fields().Get(i); field.Message() != nil {
+					visit(field.Message())
+				}
+			}
+		}
+		if _, ok := listed[message.FullName()]; ok {
+			result = append(result, message)
+		}
+	}
+	for _, desc := range descs {
+		visit(desc)
+	}
+	return result
+}
+
+// SchemaInferrer infers Avro schemas for several protobuf messages that share named types.
+// Named types defined by a schema returned from the inferrer are emitted as references in subsequent schemas.
+// A SchemaInferrer is not safe for concurrent use, since the named types it has defined depend on the order
+// schemas are inferred in.
+type SchemaInferrer struct {
+	opts        SchemaOptions
+	inferrer    schemaInferrer
+	definitions avro.Union
+}
+
+// NewSchemaInferrer returns a new SchemaInferrer with the options.
+func (o SchemaOptions) NewSchemaInferrer() *SchemaInferrer {
+	return &SchemaInferrer{opts: o, inferrer: o.newSchemaInferrer()}
+}
+
+// InferSchema returns the Avro schema for the protobuf message descriptor. Named types that were defined
+// by a previously returned schema are referenced by their full name. If the message itself has been defined,
+// a reference to the message is returned. With InlineReferences, named types are defined again instead.
+func (i *SchemaInferrer) InferSchema(desc protoreflect.MessageDescriptor) (avro.Schema, error) {
+	o := i.opts.withNames(desc)
+	if len(o.envelopeFields()) > 0 && o.isWKT(desc.FullName()) {
+		return nil, fmt.Errorf("envelope fields are not supported for message %s", desc.FullName())
+	}
+	// infer with copies of the named types, to leave the inferrer unchanged on errors
+	s := o.newSchemaInferrer()
+	for name := range i.inferrer.seen {
+		s.seen[name] = struct{}{}
+	}
+	for name, mask := range i.inferrer.masks {
+		s.masks[name] = mask
+	}
+	for name, fullName := range i.inferrer.names {
+		s.names[name] = fullName
+	}
+	s.root = desc.FullName()
+	schema, err := s.inferMessageSchema(desc, 0, newFieldMaskTree(o.SchemaMask))
+	if err != nil {
+		return nil, err
+	}
+	i.inferrer = s
+	if definition := nonNullSchema(schema); definition != nil {
+		if _, ok := definition.(avro.Reference); !ok {
+			i.definitions = append(i.definitions, definition)
+		}
+	}
+	return schema, nil
+}
+
+// Definitions returns the named types defined by the schemas returned from the inferrer, as a union of the
+// non-null root types in the order they were inferred. Each named type is defined exactly once, before it
+// is referenced, so the union can be exported as a single schema that subsequent schemas are resolved against.
+func (i *SchemaInferrer) Definitions() avro.Union {
+	return append(avro.Union(nil), i.definitions...)
+}
+
+// nonNullSchema returns the non-null branch of a nullable schema, or the schema itself if not a union.
+func nonNullSchema(schema avro.Schema) avro.Schema {
+	union, ok := schema.(avro.Union)
+	if !ok {
+		return schema
+	}
+	for _, branch := range union {
+		if branch != avro.Null() {
+			return branch
+		}
+	}
+	return nil
 }
 
 type schemaInferrer struct {
 	opts SchemaOptions
 	seen map[protoreflect.FullName]struct{}
+	// masks holds the field mask each seen message was projected with.
+	masks map[protoreflect.FullName]string
+	// root is the full name of the root message.
+	root protoreflect.FullName
+	// names holds the full name of the type each Avro name is claimed by.
+	names map[string]protoreflect.FullName
+	// parents holds the messages being inferred, which are referenced by recursive fields even when
+	// InlineReferences is set.
+	parents map[protoreflect.FullName]struct{}
 }
 
 func (o SchemaOptions) newSchemaInferrer() schemaInferrer {
-	return schemaInferrer{seen: make(map[protoreflect.FullName]struct{}), opts: o}
+	return schemaInferrer{
+		seen:    make(map[protoreflect.FullName]struct{}),
+		masks:   make(map[protoreflect.FullName]string),
+		names:   make(map[string]protoreflect.FullName),
+		parents: make(map[protoreflect.FullName]struct{}),
+		opts:    o,
+	}
+}
+
+// references returns true if a named type that has been defined is referenced, rather than defined again.
+func (s schemaInferrer) references(name protoreflect.FullName) bool {
+	if _, ok := s.seen[name]; !ok {
+		return false
+	}
+	if _, ok := s.parents[name]; ok {
+		return true
+	}
+	return !s.opts.InlineReferences
 }
 
 func (s schemaInferrer) inferMessageSchema(
 	message protoreflect.MessageDescriptor,
 	recursiveIndex int,
+	mask fieldMaskTree,
 ) (avro.Schema, error) {
-	if isWKT(message.FullName()) {
-		return schemaWKT(message)
+	if s.opts.isWKT(message.FullName()) {
+		if message.FullName() == wkt.Duration && s.opts.durationEncoding() == DurationFixed {
+			// the fixed is a named type, which can only be defined once
+			if s.references(message.FullName()) {
+				return avro.Nullable(avro.Reference(message.FullName())), nil
+			}
+			s.seen[message.FullName()] = struct{}{}
+		}
+		return s.opts.schemaWKT(message)
 	}
-	if _, ok := s.seen[message.FullName()]; ok {
-		return avro.Nullable(avro.Reference(message.FullName())), nil
+	// records of messages are keyed by their depth when their definitions differ by depth
+	suffix := s.opts.depthSuffix(message, recursiveIndex)
+	key := protoreflect.FullName(string(message.FullName()) + suffix)
+	if _, ok := s.parents[key]; ok && s.opts.RecursionStrategy == RecursionError {
+		return nil, fmt.Errorf("recursive message %s", message.FullName())
 	}
-	s.seen[message.FullName()] = struct{}{}
-	doc := message.ParentFile().SourceLocations().ByDescriptor(message).LeadingComments
+	if s.references(key) {
+		if len(s.opts.envelopeFields()) > 0 && message.FullName() == s.root {
+			return nil, fmt.Errorf("envelope fields are not supported for recursive message %s", message.FullName())
+		}
+		if s.opts.FlattenDepth > 0 && message.FullName() == s.root {
+			return nil, fmt.Errorf("flattened records are not supported for recursive message %s", message.FullName())
+		}
+		if s.masks[key] != mask.String() {
+			return nil, fmt.Errorf("message %s is projected by different field masks", message.FullName())
+		}
+		reference := avro.Reference(s.opts.avroName(message) + suffix)
+		if message.IsMapEntry() {
+			// records of map entries are the items of arrays, which are not nullable
+			return reference, nil
+		}
+		return avro.Nullable(reference), nil
+	}
+	s.seen[key] = struct{}{}
+	s.masks[key] = mask.String()
+	s.parents[key] = struct{}{}
+	defer delete(s.parents, key)
+	if err := s.claimName(s.opts.avroName(message)+suffix, message.FullName()); err != nil {
+		return nil, err
+	}
+	if err := mask.validate(message); err != nil {
+		return nil, err
+	}
+	doc := s.opts.doc(message)
+	name, ns := s.opts.schemaName(message)
 	record := avro.Record{
 		Type:      avro.RecordType,
 		Doc:       doc,
-		Name:      string(message.Name()),
-		Namespace: namespace(message),
+		Name:      name + suffix,
+		Namespace: ns,
 		Fields:    make([]avro.Field, 0, message.Fields().Len()),
 	}
-	for i := 0; i < message.Fields().Len(); i++ {
-		field := message.Fields().Get(i)
-		fieldSchema, err := s.inferField(field, recursiveIndex+1)
+	record.Properties = s.opts.recordProperties(message)
+	fields, err := s.inferRecordFields(message, recursiveIndex, mask, "")
+	if err != nil {
+		return nil, err
+	}
+	if recursiveIndex == 0 && s.opts.FlattenDepth > 0 {
+		if err := checkFlatFieldNames(fields); err != nil {
+			return nil, err
+		}
+	}
+	fields, err = s.opts.transformSchema(message, fields)
+	if err != nil {
+		return nil, err
+	}
+	record.Fields = fields
+	if recursiveIndex == 0 && len(s.opts.envelopeFields()) > 0 {
+		envelope, err := s.opts.envelopeSchema(message)
 		if err != nil {
 			return nil, err
 		}
-		fieldSchema.Type = avro.Nullable(fieldSchema.Type)
-		record.Fields = append(
-			record.Fields,
-			fieldSchema,
-		)
+		record.Fields = append(record.Fields, envelope...)
+	}
+	if s.opts.NullDefaults {
+		for i, field := range record.Fields {
+			if union, ok := field.Type.(avro.Union); ok && len(union) > 0 && union[0] == avro.Null() {
+				record.Fields[i].Default = avro.NullDefault()
+			}
+		}
 	}
 	if message.IsMapEntry() {
 		return record, nil
@@ -67,46 +278,108 @@ func (s schemaInferrer) inferMessageSchema(
 	return avro.Nullable(record), nil
 }
 
+// inferRecordFields returns the record fields of the fields of a message, where the fields of messages that are
+// flattened into the root record are named by their path from the prefix, see SchemaOptions.FlattenDepth.
+func (s schemaInferrer) inferRecordFields(
+	message protoreflect.MessageDescriptor,
+	recursiveIndex int,
+	mask fieldMaskTree,
+	prefix string,
+) ([]avro.Field, error) {
+	fields := make([]avro.Field, 0, message.Fields().Len())
+	for i := 0; i < message.Fields().Len(); i++ {
+		field := message.Fields().Get(i)
+		fieldMask, ok := mask.child(string(field.Name()))
+		if !ok || s.opts.omitsField(field, recursiveIndex) {
+			continue
+		}
+		if s.opts.flattensField(field, recursiveIndex, prefix) {
+			if err := s.opts.checkFlattenedTransforms(field); err != nil {
+				return nil, err
+			}
+			flattened, err := s.inferRecordFields(
+				field.Message(), recursiveIndex+1, fieldMask, s.opts.flatFieldName(prefix, field),
+			)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, flattened...)
+			continue
+		}
+		fieldSchema, err := s.inferField(field, recursiveIndex+1, fieldMask)
+		if err != nil {
+			return nil, err
+		}
+		fieldSchema.Type = avro.Nullable(fieldSchema.Type)
+		if prefix == "" {
+			fieldSchema.Aliases = s.opts.fieldAliases(field)
+		} else {
+			fieldSchema.Name = s.opts.flatFieldName(prefix, field)
+		}
+		fieldSchema.Properties = s.opts.fieldProperties(field)
+		fields = append(fields, fieldSchema)
+	}
+	return fields, nil
+}
+
 func namespace(desc protoreflect.Descriptor) string {
 	return strings.TrimSuffix(string(desc.FullName()), "."+string(desc.Name()))
 }
 
-func (s schemaInferrer) inferField(field protoreflect.FieldDescriptor, recursiveIndex int) (avro.Field, error) {
-	doc := field.ParentFile().SourceLocations().ByDescriptor(field).LeadingComments
+func (s schemaInferrer) inferField(
+	field protoreflect.FieldDescriptor,
+	recursiveIndex int,
+	mask fieldMaskTree,
+) (avro.Field, error) {
+	doc := s.opts.doc(field)
+	if s.opts.encodesJSONString(field) {
+		return avro.Field{
+			Name: s.opts.fieldName(field),
+			Doc:  doc,
+			Type: avro.String(),
+		}, nil
+	}
+	if s.opts.encryptsField(field) {
+		return avro.Field{
+			Name: s.opts.fieldName(field),
+			Doc:  doc,
+			Type: avro.Bytes(),
+		}, nil
+	}
 	if field.IsMap() {
-		mapType, err := s.inferMapSchema(field, recursiveIndex)
+		mapType, err := s.inferMapSchema(field, recursiveIndex, mask)
 		if err != nil {
 			return avro.Field{}, err
 		}
 		return avro.Field{
-			Name: string(field.Name()),
+			Name: s.opts.fieldName(field),
 			Doc:  doc,
 			Type: mapType,
 		}, nil
 	}
-	fieldKind, err := s.inferFieldKind(field, recursiveIndex)
+	fieldKind, err := s.inferFieldKind(field, recursiveIndex, mask)
 	if err != nil {
 		return avro.Field{}, err
 	}
 	if field.IsList() {
 		return avro.Field{
-			Name: string(field.Name()),
+			Name: s.opts.fieldName(field),
 			Doc:  doc,
 			Type: avro.Array{
 				Type:  avro.ArrayType,
-				Items: avro.Nullable(fieldKind),
+				Items: s.opts.listItemsSchema(fieldKind),
 			},
 		}, nil
 	}
 	if oneof := field.ContainingOneof(); oneof != nil {
 		return avro.Field{
-			Name: string(field.Name()),
-			Doc:  oneofDoc(doc, oneof),
+			Name: s.opts.fieldName(field),
+			Doc:  s.opts.oneofDoc(doc, oneof),
 			Type: avro.Nullable(fieldKind),
 		}, nil
 	}
 	return avro.Field{
-		Name: string(field.Name()),
+		Name: s.opts.fieldName(field),
 		Doc:  doc,
 		Type: fieldKind,
 	}, nil
@@ -125,7 +398,11 @@ func oneofDoc(doc string, oneof protoreflect.OneofDescriptor) string {
 	return fmt.Sprintf("%s\n\n%s", doc, oneofDoc)
 }
 
-func (s schemaInferrer) inferFieldKind(field protoreflect.FieldDescriptor, recursiveIndex int) (avro.Schema, error) {
+func (s schemaInferrer) inferFieldKind(
+	field protoreflect.FieldDescriptor,
+	recursiveIndex int,
+	mask fieldMaskTree,
+) (avro.Schema, error) {
 	switch field.Kind() {
 	case protoreflect.DoubleKind:
 		return avro.Double(), nil
@@ -146,31 +423,50 @@ func (s schemaInferrer) inferFieldKind(field protoreflect.FieldDescriptor, recur
 	case protoreflect.BoolKind:
 		return avro.Boolean(), nil
 	case protoreflect.BytesKind:
+		if size := s.opts.fixedSize(field); size != 0 {
+			return s.inferFixedSchema(field, size)
+		}
 		return avro.Bytes(), nil
 	case protoreflect.StringKind:
 		return avro.String(), nil
 	case protoreflect.EnumKind:
-		return s.inferEnumSchema(field.Enum()), nil
+		return s.inferEnumSchema(field.Enum())
 	case protoreflect.MessageKind, protoreflect.GroupKind:
-		return s.inferMessageSchema(field.Message(), recursiveIndex)
+		return s.inferMessageSchema(field.Message(), recursiveIndex, mask)
 	}
 	return nil, fmt.Errorf("unsupported field kind %s %s", field.Name(), field.Kind())
 }
 
-func (s schemaInferrer) inferEnumSchema(enum protoreflect.EnumDescriptor) avro.Schema {
-	if _, ok := s.seen[enum.FullName()]; ok {
-		return avro.Reference(enum.FullName())
+func (s schemaInferrer) inferEnumSchema(enum protoreflect.EnumDescriptor) (avro.Schema, error) {
+	if s.references(enum.FullName()) {
+		return avro.Reference(s.opts.avroName(enum)), nil
 	}
 	s.seen[enum.FullName()] = struct{}{}
-	doc := enum.ParentFile().SourceLocations().ByDescriptor(enum).LeadingComments
+	if err := s.claimName(s.opts.avroName(enum), enum.FullName()); err != nil {
+		return nil, err
+	}
+	doc := s.opts.doc(enum)
+	name, ns := s.opts.schemaName(enum)
 	e := avro.Enum{
 		Type:      avro.EnumType,
 		Doc:       doc,
-		Name:      string(enum.Name()),
-		Namespace: namespace(enum),
+		Name:      name,
+		Namespace: ns,
 	}
 	for i := 0; i < enum.Values().Len(); i++ {
-		e.Symbols = append(e.Symbols, string(enum.Values().Get(i).Name()))
+		value := enum.Values().Get(i)
+		// aliases share the number of the first value declared with the number
+		if canonical := enum.Values().ByNumber(value.Number()); canonical != value {
+			if e.SymbolAliases == nil {
+				e.SymbolAliases = make(map[string]string)
+			}
+			e.SymbolAliases[s.opts.enumSymbol(value)] = s.opts.enumSymbol(canonical)
+			continue
+		}
+		e.Symbols = append(e.Symbols, s.opts.enumSymbol(value))
+	}
+	if s.opts.EnumDefault && len(e.Symbols) > 0 {
+		e.Default = e.Symbols[0]
 	}
-	return e
+	return e, nil
 }
@@ -1,12 +1,17 @@
 package protoavro
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
+	"github.com/linkedin/goavro/v2"
 	"go.einride.tech/protobuf-avro/avro"
 	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
 	"google.golang.org/genproto/googleapis/example/library/v1"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"gotest.tools/v3/assert"
 )
 
@@ -1125,3 +1130,285 @@ func TestInferRootSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestInferSchema_AnnotateProtoKinds(t *testing.T) {
+	msg := &examplev1.ExampleScalars{}
+	schema, err := SchemaOptions{AnnotateProtoKinds: true}.InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	fields := msg.ProtoReflect().Descriptor().Fields()
+	assert.Equal(t, len(record.Fields), fields.Len())
+	for i, field := range record.Fields {
+		assert.Equal(t, field.Properties[ProtoKindProperty], fields.Get(i).Kind().String())
+	}
+	assert.Equal(t, record.Fields[11].Properties[ProtoKindProperty], "sfixed64")
+	_, ok := record.Fields[0].Properties[ProtoFieldNumberProperty]
+	assert.Assert(t, !ok)
+
+	// assert that annotations are ignored by avro readers
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	_, err = goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+}
+
+func TestInferSchema_AnnotateFieldNumbers(t *testing.T) {
+	msg := &library.Book{}
+	schema, err := SchemaOptions{AnnotateFieldNumbers: true}.InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	fields := msg.ProtoReflect().Descriptor().Fields()
+	assert.Equal(t, len(record.Fields), fields.Len())
+	for i, field := range record.Fields {
+		assert.Equal(t, field.Properties[ProtoFieldNumberProperty], float64(fields.Get(i).Number()))
+		_, ok := field.Properties[ProtoKindProperty]
+		assert.Assert(t, !ok)
+	}
+
+	// assert that annotations are ignored by avro readers
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	_, err = goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+}
+
+func TestInferSchema_NullDefaults(t *testing.T) {
+	msg := &examplev1.ExampleMap{}
+	opts := SchemaOptions{
+		NullDefaults:   true,
+		EnvelopeFields: []EnvelopeField{{Name: "ingest_time", Schema: avro.Long()}},
+	}
+	schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	for _, field := range record.Fields {
+		if field.Name == "ingest_time" {
+			// non-nullable fields have no default
+			assert.Assert(t, field.Default == nil)
+			continue
+		}
+		assert.DeepEqual(t, avro.NullDefault(), field.Default)
+	}
+	// fields of map entries have null defaults
+	entry := record.Fields[0].Type.(avro.Union)[1].(avro.Array).Items.(avro.Record)
+	for _, field := range entry.Fields {
+		assert.DeepEqual(t, avro.NullDefault(), field.Default)
+	}
+
+	// assert that defaults are written, and accepted by avro readers
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(schemaBytes), `"default":null`), string(schemaBytes))
+	_, err = goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+}
+
+func TestInferSchema_Properties(t *testing.T) {
+	msg := &library.Book{}
+	opts := SchemaOptions{
+		RecordPropertiesFunc: func(desc protoreflect.MessageDescriptor) avro.Properties {
+			return avro.Properties{"owner": "team-" + string(desc.Name())}
+		},
+		FieldPropertiesFunc: func(field protoreflect.FieldDescriptor) avro.Properties {
+			if field.Name() != "author" {
+				return nil
+			}
+			return avro.Properties{"sensitivity": "pii"}
+		},
+	}
+	schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	assert.DeepEqual(t, avro.Properties{"owner": "team-Book"}, record.Properties)
+	for _, field := range record.Fields {
+		if field.Name == "author" {
+			assert.DeepEqual(t, avro.Properties{"sensitivity": "pii"}, field.Properties)
+		} else {
+			assert.Assert(t, field.Properties == nil, field.Name)
+		}
+	}
+
+	// assert that properties are written, and ignored by avro readers
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(schemaBytes), `"owner":"team-Book"`), string(schemaBytes))
+	assert.Assert(t, strings.Contains(string(schemaBytes), `"sensitivity":"pii"`), string(schemaBytes))
+	_, err = goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+}
+
+func TestInferSchema_AnnotateResources(t *testing.T) {
+	opts := SchemaOptions{AnnotateResources: true}
+	schema, err := opts.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.DeepEqual(
+		t,
+		avro.Properties{"resourceType": "library-example.googleapis.com/Book"},
+		schema.(avro.Union)[1].(avro.Record).Properties,
+	)
+	schema, err = opts.InferSchema((&library.MoveBookRequest{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	record := schema.(avro.Union)[1].(avro.Record)
+	assert.Assert(t, record.Properties == nil)
+	assert.DeepEqual(
+		t,
+		avro.Properties{"resourceReference": "library-example.googleapis.com/Book"},
+		record.Fields[0].Properties,
+	)
+	assert.DeepEqual(
+		t,
+		avro.Properties{"resourceReference": "library-example.googleapis.com/Shelf"},
+		record.Fields[1].Properties,
+	)
+
+	// properties of functions take precedence
+	opts.FieldPropertiesFunc = func(field protoreflect.FieldDescriptor) avro.Properties {
+		return avro.Properties{"resourceReference": "custom", "sensitivity": "pii"}
+	}
+	schema, err = opts.InferSchema((&library.MoveBookRequest{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.DeepEqual(
+		t,
+		avro.Properties{"resourceReference": "custom", "sensitivity": "pii"},
+		schema.(avro.Union)[1].(avro.Record).Fields[0].Properties,
+	)
+
+	// resources are not annotated by default
+	schema, err = SchemaOptions{}.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.Assert(t, schema.(avro.Union)[1].(avro.Record).Properties == nil)
+}
+
+func TestInferSchema_InlineReferences(t *testing.T) {
+	opts := SchemaOptions{InlineReferences: true}
+	schema, err := opts.InferSchema((&examplev1.ExampleOneof{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	fields := schema.(avro.Union)[1].(avro.Record).Fields
+	// the empty message is defined by both fields that use it
+	assert.DeepEqual(t, fields[0].Type, fields[2].Type)
+	assert.Equal(t, nonNullSchema(fields[2].Type).(avro.Record).Name, "EmptyMessage")
+	codec := newTestGoavroCodec(t, opts, &examplev1.ExampleOneof{})
+	msg := &examplev1.ExampleOneof{
+		OneofFields_2: &examplev1.ExampleOneof_OneofEmptyMessage_2{
+			OneofEmptyMessage_2: &examplev1.ExampleOneof_EmptyMessage{},
+		},
+	}
+	native, err := opts.encodeJSON(msg)
+	assert.NilError(t, err)
+	_, err = codec.BinaryFromNative(nil, native)
+	assert.NilError(t, err)
+
+	// recursive messages are still referenced
+	schema, err = opts.InferSchema((&examplev1.ExampleRecursive{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.DeepEqual(
+		t,
+		schema.(avro.Union)[1].(avro.Record).Fields[0].Type,
+		avro.Nullable(avro.Reference("einride.avro.example.v1.ExampleRecursive")),
+	)
+
+	// types of previously inferred schemas are defined again
+	inferrer := opts.NewSchemaInferrer()
+	book, err := inferrer.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	again, err := inferrer.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, book, again)
+}
+
+func TestSchemaInferrer(t *testing.T) {
+	inferrer := SchemaOptions{}.NewSchemaInferrer()
+	book, err := inferrer.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.Equal(t, book.(avro.Union)[1].(avro.Record).Name, "Book")
+
+	// the second schema references the book defined by the first
+	response, err := inferrer.InferSchema((&library.ListBooksResponse{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	books := response.(avro.Union)[1].(avro.Record).Fields[0]
+	assert.DeepEqual(
+		t,
+		books.Type,
+		avro.Nullable(avro.Array{
+			Type:  avro.ArrayType,
+			Items: avro.Nullable(avro.Reference("google.example.library.v1.Book")),
+		}),
+	)
+	again, err := inferrer.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, again, avro.Nullable(avro.Reference("google.example.library.v1.Book")))
+
+	// the definitions define each named type once
+	definitions := inferrer.Definitions()
+	assert.Equal(t, len(definitions), 2)
+	assert.DeepEqual(t, definitions[0], book.(avro.Union)[1])
+	assert.DeepEqual(t, definitions[1], response.(avro.Union)[1])
+	schemaBytes, err := json.Marshal(definitions)
+	assert.NilError(t, err)
+	_, err = goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+}
+
+func TestInferSchemas(t *testing.T) {
+	response := (&library.ListBooksResponse{}).ProtoReflect().Descriptor()
+	book := (&library.Book{}).ProtoReflect().Descriptor()
+	shelf := (&library.Shelf{}).ProtoReflect().Descriptor()
+	timestamp := (&timestamppb.Timestamp{}).ProtoReflect().Descriptor()
+	schemas, err := InferSchemas([]protoreflect.MessageDescriptor{response, book, shelf, timestamp})
+	assert.NilError(t, err)
+	assert.Equal(t, len(schemas), 4)
+
+	// the book is defined by its own schema, and referenced by the response
+	expectedBook, err := InferSchema(book)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expectedBook, schemas[book.FullName()])
+	assert.DeepEqual(
+		t,
+		schemas[response.FullName()].(avro.Union)[1].(avro.Record).Fields[0].Type,
+		avro.Nullable(avro.Array{
+			Type:  avro.ArrayType,
+			Items: avro.Nullable(avro.Reference("google.example.library.v1.Book")),
+		}),
+	)
+	expectedShelf, err := InferSchema(shelf)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expectedShelf, schemas[shelf.FullName()])
+	expectedTimestamp, err := InferSchema(timestamp)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expectedTimestamp, schemas[timestamp.FullName()])
+
+	// the schemas parse in the order of the definitions
+	bundle := avro.Union{
+		nonNullSchema(schemas[book.FullName()]),
+		nonNullSchema(schemas[response.FullName()]),
+		nonNullSchema(schemas[shelf.FullName()]),
+	}
+	schemaBytes, err := json.Marshal(bundle)
+	assert.NilError(t, err)
+	_, err = goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+}
+
+func TestInferSchemas_Recursive(t *testing.T) {
+	recursive := (&examplev1.ExampleRecursive{}).ProtoReflect().Descriptor()
+	schemas, err := InferSchemas([]protoreflect.MessageDescriptor{recursive, recursive})
+	assert.NilError(t, err)
+	expected, err := InferSchema(recursive)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[protoreflect.FullName]avro.Schema{recursive.FullName(): expected}, schemas)
+}
+
+func TestSchemaInferrer_Error(t *testing.T) {
+	inferrer := SchemaOptions{
+		EnvelopeFields: []EnvelopeField{{Name: "name", Schema: avro.String()}},
+	}.NewSchemaInferrer()
+	_, err := inferrer.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.ErrorContains(t, err, "collides")
+	assert.Equal(t, len(inferrer.Definitions()), 0)
+	// named types of failed inferences are not referenced
+	inferrer.opts.EnvelopeFields = nil
+	book, err := inferrer.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	_, ok := book.(avro.Union)[1].(avro.Record)
+	assert.Assert(t, ok)
+}
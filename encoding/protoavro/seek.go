@@ -0,0 +1,179 @@
+package protoavro
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// OCFBlock is the location of a block of messages in an Object Container File.
+type OCFBlock struct {
+	// Offset is the offset of the block in the underlying reader, in bytes.
+	Offset int64
+	// Size is the size of the block in bytes, including its message count, byte size and sync marker.
+	Size int64
+	// Count is the number of messages in the block.
+	Count int64
+}
+
+// Index returns the blocks of the Object Container File, for example to split a large file between workers,
+// like input splits in Hadoop, that each read a range of blocks with SeekToBlock.
+// Index skips over the data of each block, and verifies the sync marker that ends it, without decompressing
+// or decoding any messages. The underlying reader must implement io.ReadSeeker. Scanning continues where it
+// left off after Index returns.
+func (m *Unmarshaler) Index() ([]OCFBlock, error) {
+	index, err := m.r.blockIndex()
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+	return append([]OCFBlock(nil), index...), nil
+}
+
+// SeekToBlock continues scanning at the first message of block n of the Object Container File, as returned by
+// Index. Seeking to the number of blocks ends scanning. The underlying reader must implement io.ReadSeeker.
+func (m *Unmarshaler) SeekToBlock(n int) error {
+	if err := m.r.seekToBlock(n); err != nil {
+		return fmt.Errorf("seek to block %d: %w", n, err)
+	}
+	m.err = nil
+	return nil
+}
+
+func (r *ocfReader) blockIndex() (index []OCFBlock, err error) {
+	if err := r.checkSeekable(); err != nil {
+		return nil, err
+	}
+	if r.index != nil {
+		return r.index, nil
+	}
+	resume := r.offset()
+	// scanning continues where it left off, also when the file can not be indexed
+	defer func() {
+		if seekErr := r.seekTo(resume); seekErr != nil {
+			index, err = nil, errors.Join(err, seekErr)
+		}
+		if err == nil {
+			r.index = index
+		}
+	}()
+	return r.scanBlocks()
+}
+
+// scanBlocks reads the blocks of the file from the underlying reader, from the first block to the end of the
+// file, and leaves the underlying reader anywhere in the file.
+func (r *ocfReader) scanBlocks() ([]OCFBlock, error) {
+	index := []OCFBlock{}
+	offset := r.fileStart + r.headerSize
+	if _, err := r.seeker.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	byteReader := singleByteReader{r: r.seeker}
+	for {
+		count, err := readLong(byteReader)
+		if errors.Is(err, io.EOF) {
+			return index, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read block count at offset %d: %w", offset, err)
+		}
+		size, err := readLong(byteReader)
+		if err != nil {
+			return nil, fmt.Errorf("read block size at offset %d: %w", offset, noEOF(err))
+		}
+		if count < 0 || size < 0 {
+			return nil, fmt.Errorf("invalid block at offset %d", offset)
+		}
+		if _, err := r.seeker.Seek(size, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		var syncMarker [ocfSyncLength]byte
+		if _, err := io.ReadFull(r.seeker, syncMarker[:]); err != nil {
+			return nil, fmt.Errorf("read sync marker of block at offset %d: %w", offset, noEOF(err))
+		}
+		if syncMarker != r.header.syncMarker {
+			return nil, fmt.Errorf("sync marker mismatch of block at offset %d", offset)
+		}
+		end, err := r.seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		index = append(index, OCFBlock{Offset: offset, Size: end - offset, Count: count})
+		offset = end
+	}
+}
+
+func (r *ocfReader) seekToBlock(n int) error {
+	index, err := r.blockIndex()
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > len(index) {
+		return fmt.Errorf("out of range of %d blocks", len(index))
+	}
+	offset := r.fileStart + r.headerSize
+	if n < len(index) {
+		offset = index[n].Offset
+	} else if n > 0 {
+		offset = index[n-1].Offset + index[n-1].Size
+	}
+	if err := r.seekTo(offset); err != nil {
+		return err
+	}
+	r.block, r.count, r.err = nil, 0, nil
+	r.next, r.hasNext = nil, false
+	return nil
+}
+
+func (r *ocfReader) checkSeekable() error {
+	if r.seeker == nil {
+		return errors.New("reader does not implement io.ReadSeeker")
+	}
+	if r.blocks != nil {
+		return errors.New("not supported with ReadParallelism")
+	}
+	if r.dictionary != nil {
+		return errors.New("not supported for files with dictionary fields")
+	}
+	return nil
+}
+
+// offset returns the offset in the underlying reader of the next byte to be read from the buffered reader.
+func (r *ocfReader) offset() int64 {
+	return r.start + r.counter.n - int64(r.r.Buffered())
+}
+
+// seekTo continues reading at the offset in the underlying reader.
+func (r *ocfReader) seekTo(offset int64) error {
+	if _, err := r.seeker.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	r.r.Reset(r.counter)
+	r.counter.n = 0
+	r.start = offset
+	return nil
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// singleByteReader reads single bytes from the underlying reader, without reading ahead.
+type singleByteReader struct {
+	r io.Reader
+}
+
+func (s singleByteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	if _, err := io.ReadFull(s.r, b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
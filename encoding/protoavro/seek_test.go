@@ -0,0 +1,165 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+// marshalBlocks writes n blocks of 3 books each.
+func marshalBlocks(t *testing.T, n int) ([]byte, []*library.Book) {
+	t.Helper()
+	var b bytes.Buffer
+	opts := protoavro.SchemaOptions{Compression: protoavro.CompressionDeflate}
+	marshaler, err := opts.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	books := testBooks(0, 3*n)
+	for i := 0; i < len(books); i += 3 {
+		assert.NilError(t, marshaler.Marshal([]proto.Message{books[i], books[i+1], books[i+2]}...))
+	}
+	return b.Bytes(), books
+}
+
+func TestUnmarshaler_Index(t *testing.T) {
+	data, books := marshalBlocks(t, 10)
+	unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(data))
+	assert.NilError(t, err)
+	// index in the middle of a block
+	var got []*library.Book
+	for i := 0; i < 4; i++ {
+		assert.Assert(t, unmarshaler.Scan())
+		var book library.Book
+		assert.NilError(t, unmarshaler.Unmarshal(&book))
+		got = append(got, &book)
+	}
+	index, err := unmarshaler.Index()
+	assert.NilError(t, err)
+	assert.Equal(t, 10, len(index))
+	assert.Assert(t, index[0].Offset > 0)
+	for i, block := range index {
+		assert.Equal(t, int64(3), block.Count)
+		if i > 0 {
+			assert.Equal(t, index[i-1].Offset+index[i-1].Size, block.Offset)
+		}
+	}
+	assert.Equal(t, int64(len(data)), index[9].Offset+index[9].Size)
+	// scanning continues where it left off
+	got = append(got, unmarshalBooks(t, unmarshaler)...)
+	assert.DeepEqual(t, books, got, protocmp.Transform())
+}
+
+func TestUnmarshaler_SeekToBlock(t *testing.T) {
+	data, books := marshalBlocks(t, 10)
+	unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(data))
+	assert.NilError(t, err)
+	assert.NilError(t, unmarshaler.SeekToBlock(5))
+	assert.DeepEqual(t, books[15:], unmarshalBooks(t, unmarshaler), protocmp.Transform())
+	// seeking back after scanning to the end
+	assert.NilError(t, unmarshaler.SeekToBlock(0))
+	assert.DeepEqual(t, books, unmarshalBooks(t, unmarshaler), protocmp.Transform())
+	assert.NilError(t, unmarshaler.SeekToBlock(10))
+	assert.Assert(t, !unmarshaler.Scan())
+	assert.NilError(t, unmarshaler.Err())
+	assert.ErrorContains(t, unmarshaler.SeekToBlock(11), "out of range of 10 blocks")
+}
+
+func TestUnmarshaler_SeekToBlock_Consecutive(t *testing.T) {
+	data, books := marshalBlocks(t, 10)
+	unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(data))
+	assert.NilError(t, err)
+	assert.NilError(t, unmarshaler.SeekToBlock(3))
+	assert.NilError(t, unmarshaler.SeekToBlock(7))
+	assert.DeepEqual(t, books[21:], unmarshalBooks(t, unmarshaler), protocmp.Transform())
+
+	// a file without blocks, after other data in the reader
+	empty, _ := marshalBlocks(t, 0)
+	prefix := []byte("prefix")
+	r := bytes.NewReader(append(prefix, empty...))
+	_, err = r.Seek(int64(len(prefix)), io.SeekStart)
+	assert.NilError(t, err)
+	unmarshaler, err = protoavro.NewUnmarshaler(r)
+	assert.NilError(t, err)
+	for i := 0; i < 2; i++ {
+		assert.NilError(t, unmarshaler.SeekToBlock(0))
+		offset, err := r.Seek(0, io.SeekCurrent)
+		assert.NilError(t, err)
+		assert.Equal(t, int64(len(prefix)+len(empty)), offset)
+		assert.Assert(t, !unmarshaler.Scan())
+		assert.NilError(t, unmarshaler.Err())
+	}
+}
+
+func TestUnmarshaler_SeekToBlock_Splits(t *testing.T) {
+	data, books := marshalBlocks(t, 10)
+	unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(data))
+	assert.NilError(t, err)
+	index, err := unmarshaler.Index()
+	assert.NilError(t, err)
+	// each worker reads the messages of a range of blocks
+	var got []*library.Book
+	for _, split := range [][2]int{{0, 4}, {4, 7}, {7, 10}} {
+		worker, err := protoavro.NewUnmarshaler(bytes.NewReader(data))
+		assert.NilError(t, err)
+		assert.NilError(t, worker.SeekToBlock(split[0]))
+		for _, block := range index[split[0]:split[1]] {
+			for i := int64(0); i < block.Count; i++ {
+				assert.Assert(t, worker.Scan())
+				var book library.Book
+				assert.NilError(t, worker.Unmarshal(&book))
+				got = append(got, &book)
+			}
+		}
+	}
+	assert.DeepEqual(t, books, got, protocmp.Transform())
+}
+
+func TestUnmarshaler_Index_Errors(t *testing.T) {
+	data, _ := marshalBlocks(t, 2)
+	t.Run("not seekable", func(t *testing.T) {
+		unmarshaler, err := protoavro.NewUnmarshaler(io.MultiReader(bytes.NewReader(data)))
+		assert.NilError(t, err)
+		_, err = unmarshaler.Index()
+		assert.ErrorContains(t, err, "reader does not implement io.ReadSeeker")
+	})
+	t.Run("parallel", func(t *testing.T) {
+		unmarshaler, err := protoavro.SchemaOptions{ReadParallelism: 2}.NewUnmarshaler(bytes.NewReader(data))
+		assert.NilError(t, err)
+		defer unmarshaler.Close()
+		assert.ErrorContains(t, unmarshaler.SeekToBlock(1), "not supported with ReadParallelism")
+	})
+	t.Run("corrupt sync marker", func(t *testing.T) {
+		corrupt := append([]byte(nil), data...)
+		corrupt[len(corrupt)-1]++
+		unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(corrupt))
+		assert.NilError(t, err)
+		_, err = unmarshaler.Index()
+		assert.ErrorContains(t, err, "sync marker mismatch of block at offset")
+	})
+	t.Run("truncated", func(t *testing.T) {
+		// larger than the buffer of the unmarshaler, so that scanning reads from the underlying reader again
+		data, books := marshalBlocks(t, 200)
+		assert.Assert(t, len(data) > 1<<13, len(data))
+		unmarshaler, err := protoavro.NewUnmarshaler(bytes.NewReader(data[:len(data)-1]))
+		assert.NilError(t, err)
+		assert.Assert(t, unmarshaler.Scan())
+		var book library.Book
+		assert.NilError(t, unmarshaler.Unmarshal(&book))
+		_, err = unmarshaler.Index()
+		assert.ErrorContains(t, err, "read sync marker of block at offset")
+		// scanning continues where it left off, up to the truncated block
+		var got []*library.Book
+		for unmarshaler.Scan() {
+			var book library.Book
+			assert.NilError(t, unmarshaler.Unmarshal(&book))
+			got = append(got, &book)
+		}
+		assert.ErrorContains(t, unmarshaler.Err(), "read sync marker")
+		assert.DeepEqual(t, books[1:len(books)-3], got, protocmp.Transform())
+	})
+}
@@ -0,0 +1,78 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// selfDescribing is a message in self-describing format, with the Avro schema of the message embedded.
+type selfDescribing struct {
+	Schema json.RawMessage `json:"schema"`
+	Datum  json.RawMessage `json:"datum"`
+}
+
+// MarshalSelfDescribing encodes the message, with default SchemaOptions, in self-describing format.
+// See SchemaOptions.MarshalSelfDescribing.
+func MarshalSelfDescribing(message proto.Message) ([]byte, error) {
+	return SchemaOptions{}.MarshalSelfDescribing(message)
+}
+
+// UnmarshalSelfDescribing decodes a message, with default SchemaOptions, from self-describing format.
+// See SchemaOptions.UnmarshalSelfDescribing.
+func UnmarshalSelfDescribing(data []byte, message proto.Message) error {
+	return SchemaOptions{}.UnmarshalSelfDescribing(data, message)
+}
+
+// MarshalSelfDescribing encodes the message as a JSON object of the form {"schema": ..., "datum": ...},
+// where schema is the Avro schema of the message and datum is the message in Avro JSON encoding.
+// Self-describing messages can be decoded without access to a schema registry, for example when
+// carried over HTTP.
+func (o SchemaOptions) MarshalSelfDescribing(message proto.Message) ([]byte, error) {
+	schema, err := o.InferSchema(message.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	codec, err := newGoavroCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+	datum, err := o.marshalAvroJSON(schema, message)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(selfDescribing{Schema: json.RawMessage(codec.Schema()), Datum: datum})
+}
+
+// UnmarshalSelfDescribing decodes a message from the self-describing format of MarshalSelfDescribing.
+// The embedded schema must be equal, in Avro canonical form, to the schema inferred for the message.
+func (o SchemaOptions) UnmarshalSelfDescribing(data []byte, message proto.Message) error {
+	var sd selfDescribing
+	if err := json.Unmarshal(data, &sd); err != nil {
+		return fmt.Errorf("json unmarshal: %w", err)
+	}
+	if len(sd.Schema) == 0 || len(sd.Datum) == 0 {
+		return fmt.Errorf("unmarshal self-describing: expected schema and datum")
+	}
+	embedded, err := goavro.NewCodec(string(sd.Schema))
+	if err != nil {
+		return fmt.Errorf("embedded schema: %w", err)
+	}
+	schema, err := o.InferSchema(message.ProtoReflect().Descriptor())
+	if err != nil {
+		return fmt.Errorf("infer schema: %w", err)
+	}
+	codec, err := newGoavroCodec(schema)
+	if err != nil {
+		return err
+	}
+	if embedded.CanonicalSchema() != codec.CanonicalSchema() {
+		return fmt.Errorf(
+			"embedded schema does not match schema of '%s'",
+			message.ProtoReflect().Descriptor().FullName(),
+		)
+	}
+	return o.unmarshalAvroJSON(schema, sd.Datum, message)
+}
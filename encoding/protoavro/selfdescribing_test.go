@@ -0,0 +1,78 @@
+package protoavro
+
+import (
+	"testing"
+	"time"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gotest.tools/v3/assert"
+)
+
+func TestSelfDescribing(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		msg  proto.Message
+	}{
+		{
+			name: "book",
+			msg:  &library.Book{Name: "shelves/1/books/1", Title: "Harry Potter", Read: true},
+		},
+		{
+			name: "list",
+			msg:  &library.ListBooksResponse{Books: []*library.Book{{Title: "Harry Potter"}}, NextPageToken: "next"},
+		},
+		{
+			name: "enum",
+			msg:  &examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE2},
+		},
+		{
+			name: "timestamp",
+			msg: &examplev1.ExampleTimestamp{
+				Timestamp: timestamppb.New(time.Unix(1_600_000_000, 123_000).UTC()),
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalSelfDescribing(tt.msg)
+			assert.NilError(t, err)
+			decoded := tt.msg.ProtoReflect().New().Interface()
+			assert.NilError(t, UnmarshalSelfDescribing(data, decoded))
+			assert.DeepEqual(t, tt.msg, decoded, protocmp.Transform())
+		})
+	}
+}
+
+func TestSelfDescribing_SchemaMismatch(t *testing.T) {
+	data, err := MarshalSelfDescribing(&library.Book{Title: "Harry Potter"})
+	assert.NilError(t, err)
+	err = UnmarshalSelfDescribing(data, &library.Shelf{})
+	assert.Error(t, err, "embedded schema does not match schema of 'google.example.library.v1.Shelf'")
+	// options that change the schema are verified
+	err = SchemaOptions{StripNamespaces: true}.UnmarshalSelfDescribing(data, &library.Book{})
+	assert.ErrorContains(t, err, "embedded schema does not match")
+	// docs are not part of the canonical form
+	assert.NilError(t, SchemaOptions{OmitDocs: true}.UnmarshalSelfDescribing(data, &library.Book{}))
+}
+
+func TestSelfDescribing_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "not json", data: "{", err: "json unmarshal"},
+		{name: "missing datum", data: `{"schema": "string"}`, err: "expected schema and datum"},
+		{name: "invalid schema", data: `{"schema": "foo", "datum": "bar"}`, err: "embedded schema"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := UnmarshalSelfDescribing([]byte(tt.data), &library.Book{})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package protoavro
+
+import (
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Stats contains column statistics for a batch of marshaled messages.
+type Stats struct {
+	// Records is the number of marshaled messages.
+	Records int64
+	// Fields contains statistics per field path, in the order the paths were first seen.
+	Fields []*FieldStats
+}
+
+// FieldStats contains statistics for the values of a single field path.
+// Values of list fields are counted per element, and values of map fields per entry value.
+type FieldStats struct {
+	// Path is the dot-separated path of the field from the root message.
+	Path string
+	// Count is the number of non-null values.
+	Count int64
+	// NullCount is the number of null values.
+	NullCount int64
+	// NaNCount is the number of NaN values of a floating point field, which are counted in Count, but not in Min
+	// and Max.
+	NaNCount int64
+	// Min is the smallest value of a numeric field, and invalid if no values, other than NaN, were seen.
+	Min protoreflect.Value
+	// Max is the largest value of a numeric field, and invalid if no values, other than NaN, were seen.
+	Max protoreflect.Value
+	// EnumSymbols contains the distinct symbols, in sorted order, of an enum field.
+	EnumSymbols []string
+}
+
+type statsCollector struct {
+	opts    SchemaOptions
+	stats   Stats
+	fields  map[string]*FieldStats
+	symbols map[string]map[string]struct{}
+}
+
+func newStatsCollector(opts SchemaOptions) *statsCollector {
+	return &statsCollector{
+		opts:    opts,
+		fields:  make(map[string]*FieldStats),
+		symbols: make(map[string]map[string]struct{}),
+	}
+}
+
+// flush returns the statistics collected so far, and resets the collector.
+func (c *statsCollector) flush() *Stats {
+	stats := c.stats
+	for _, field := range stats.Fields {
+		symbols, ok := c.symbols[field.Path]
+		if !ok {
+			continue
+		}
+		field.EnumSymbols = make([]string, 0, len(symbols))
+		for symbol := range symbols {
+			field.EnumSymbols = append(field.EnumSymbols, symbol)
+		}
+		sort.Strings(field.EnumSymbols)
+	}
+	*c = *newStatsCollector(c.opts)
+	return &stats
+}
+
+func (c *statsCollector) field(path string) *FieldStats {
+	if field, ok := c.fields[path]; ok {
+		return field
+	}
+	field := &FieldStats{Path: path}
+	c.fields[path] = field
+	c.stats.Fields = append(c.stats.Fields, field)
+	return field
+}
+
+func (c *statsCollector) collect(message protoreflect.Message, mask fieldMaskTree) {
+	c.stats.Records++
+	c.collectMessage(message, "", mask)
+}
+
+func (c *statsCollector) collectMessage(message protoreflect.Message, prefix string, mask fieldMaskTree) {
+	desc := message.Descriptor()
+	for i := 0; i < desc.Fields().Len(); i++ {
+		field := desc.Fields().Get(i)
+		fieldMask, ok := mask.child(string(field.Name()))
+		if !ok {
+			continue
+		}
+		path := prefix + string(field.Name())
+		c.field(path)
+		switch {
+		case field.IsList():
+			list := message.Get(field).List()
+			for j := 0; j < list.Len(); j++ {
+				c.collectValue(field, list.Get(j), path, fieldMask)
+			}
+		case field.IsMap():
+			message.Get(field).Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+				c.collectValue(field.MapValue(), value, path, fieldMask)
+				return true
+			})
+		case c.opts.encodesNull(message, field):
+			c.field(path).NullCount++
+		default:
+			c.collectValue(field, message.Get(field), path, fieldMask)
+		}
+	}
+}
+
+func (c *statsCollector) collectValue(
+	field protoreflect.FieldDescriptor,
+	value protoreflect.Value,
+	path string,
+	mask fieldMaskTree,
+) {
+	stats := c.field(path)
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if !value.Message().IsValid() {
+			stats.NullCount++
+			return
+		}
+		stats.Count++
+		if !c.opts.isWKT(field.Message().FullName()) {
+			c.collectMessage(value.Message(), path+".", mask)
+		}
+	case protoreflect.EnumKind:
+		stats.Count++
+		symbols, ok := c.symbols[path]
+		if !ok {
+			symbols = make(map[string]struct{})
+			c.symbols[path] = symbols
+		}
+		if v := field.Enum().Values().ByNumber(value.Enum()); v != nil {
+			symbols[c.opts.enumSymbol(v)] = struct{}{}
+		} else {
+			symbols[c.opts.enumSymbol(field.Enum().Values().ByNumber(0))] = struct{}{}
+		}
+	case protoreflect.Int32Kind,
+		protoreflect.Int64Kind,
+		protoreflect.Sint32Kind,
+		protoreflect.Sint64Kind,
+		protoreflect.Sfixed32Kind,
+		protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind,
+		protoreflect.Uint64Kind,
+		protoreflect.Fixed32Kind,
+		protoreflect.Fixed64Kind,
+		protoreflect.FloatKind,
+		protoreflect.DoubleKind:
+		stats.Count++
+		if (field.Kind() == protoreflect.FloatKind || field.Kind() == protoreflect.DoubleKind) &&
+			math.IsNaN(value.Float()) {
+			stats.NaNCount++
+			return
+		}
+		if !stats.Min.IsValid() || lessNumeric(field.Kind(), value, stats.Min) {
+			stats.Min = value
+		}
+		if !stats.Max.IsValid() || lessNumeric(field.Kind(), stats.Max, value) {
+			stats.Max = value
+		}
+	default:
+		stats.Count++
+	}
+}
+
+func lessNumeric(kind protoreflect.Kind, a, b protoreflect.Value) bool {
+	switch kind {
+	case protoreflect.Uint32Kind,
+		protoreflect.Uint64Kind,
+		protoreflect.Fixed32Kind,
+		protoreflect.Fixed64Kind:
+		return a.Uint() < b.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return a.Float() < b.Float()
+	default:
+		return a.Int() < b.Int()
+	}
+}
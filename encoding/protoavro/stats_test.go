@@ -0,0 +1,116 @@
+package protoavro_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"gotest.tools/v3/assert"
+)
+
+func Test_MarshalStats(t *testing.T) {
+	var b bytes.Buffer
+	opts := protoavro.SchemaOptions{CollectStats: true}
+	marshaler, err := opts.NewMarshaler((&examplev1.ExampleList{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(
+		&examplev1.ExampleList{
+			Int64List:  []int64{3, -1},
+			EnumList:   []examplev1.ExampleList_Enum{examplev1.ExampleList_ENUM_VALUE1},
+			NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"a"}}},
+		},
+		&examplev1.ExampleList{
+			Int64List: []int64{10},
+			EnumList: []examplev1.ExampleList_Enum{
+				examplev1.ExampleList_ENUM_VALUE2,
+				examplev1.ExampleList_ENUM_VALUE1,
+			},
+		},
+	))
+	stats, err := marshaler.Flush()
+	assert.NilError(t, err)
+	assert.Equal(t, stats.Records, int64(2))
+	paths := make([]string, 0, len(stats.Fields))
+	for _, field := range stats.Fields {
+		paths = append(paths, field.Path)
+	}
+	assert.DeepEqual(t, paths, []string{
+		"int64_list",
+		"string_list",
+		"enum_list",
+		"nested_list",
+		"nested_list.string_list",
+		"float_value_list",
+	})
+	int64List := stats.Fields[0]
+	assert.Equal(t, int64List.Count, int64(3))
+	assert.Equal(t, int64List.Min.Int(), int64(-1))
+	assert.Equal(t, int64List.Max.Int(), int64(10))
+	assert.Equal(t, stats.Fields[1].Count, int64(0))
+	assert.Assert(t, !stats.Fields[1].Min.IsValid())
+	assert.DeepEqual(t, stats.Fields[2].EnumSymbols, []string{"ENUM_VALUE1", "ENUM_VALUE2"})
+	assert.Equal(t, stats.Fields[4].Count, int64(1))
+
+	// statistics are reset after flush
+	stats, err = marshaler.Flush()
+	assert.NilError(t, err)
+	assert.Equal(t, stats.Records, int64(0))
+	assert.Equal(t, len(stats.Fields), 0)
+}
+
+func Test_MarshalStatsNulls(t *testing.T) {
+	var b bytes.Buffer
+	opts := protoavro.SchemaOptions{CollectStats: true}
+	marshaler, err := opts.NewMarshaler((&library.UpdateBookRequest{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(
+		&library.UpdateBookRequest{Book: &library.Book{Name: "books/1"}},
+		&library.UpdateBookRequest{},
+	))
+	stats, err := marshaler.Flush()
+	assert.NilError(t, err)
+	assert.Equal(t, stats.Fields[0].Path, "book")
+	assert.Equal(t, stats.Fields[0].Count, int64(1))
+	assert.Equal(t, stats.Fields[0].NullCount, int64(1))
+	assert.Equal(t, stats.Fields[1].Path, "book.name")
+	assert.Equal(t, stats.Fields[1].Count, int64(1))
+	assert.Equal(t, stats.Fields[len(stats.Fields)-1].Path, "update_mask")
+	assert.Equal(t, stats.Fields[len(stats.Fields)-1].NullCount, int64(2))
+}
+
+func Test_MarshalStatsNaN(t *testing.T) {
+	var b bytes.Buffer
+	opts := protoavro.SchemaOptions{CollectStats: true}
+	marshaler, err := opts.NewMarshaler((&examplev1.ExampleScalars{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(
+		&examplev1.ExampleScalars{Double: math.NaN(), Float: float32(math.NaN())},
+		&examplev1.ExampleScalars{Double: 2, Float: 1},
+		&examplev1.ExampleScalars{Double: -1, Float: float32(math.NaN())},
+	))
+	stats, err := marshaler.Flush()
+	assert.NilError(t, err)
+	double, float := stats.Fields[0], stats.Fields[1]
+	assert.Equal(t, double.Path, "double")
+	assert.Equal(t, double.Count, int64(3))
+	assert.Equal(t, double.NaNCount, int64(1))
+	assert.Equal(t, double.Min.Float(), float64(-1))
+	assert.Equal(t, double.Max.Float(), float64(2))
+	assert.Equal(t, float.Path, "float")
+	assert.Equal(t, float.NaNCount, int64(2))
+	assert.Equal(t, float.Min.Float(), float64(1))
+	assert.Equal(t, float.Max.Float(), float64(1))
+}
+
+func Test_MarshalStatsDisabled(t *testing.T) {
+	var b bytes.Buffer
+	marshaler, err := protoavro.NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(&library.Book{Name: "books/1"}))
+	stats, err := marshaler.Flush()
+	assert.NilError(t, err)
+	assert.Assert(t, stats == nil)
+}
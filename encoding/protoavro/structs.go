@@ -0,0 +1,397 @@
+package protoavro
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.einride.tech/protobuf-avro/internal/wkt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// EncodeStruct encodes a Go struct, with default SchemaOptions, as the message described by desc.
+func EncodeStruct(desc protoreflect.MessageDescriptor, v interface{}) (interface{}, error) {
+	return SchemaOptions{}.EncodeStruct(desc, v)
+}
+
+// EncodeStruct encodes a Go struct as the message described by desc, for callers that have not yet migrated
+// from plain Go structs to protobuf messages. The struct is converted to the message and encoded like Encode,
+// so that the encoding always matches the schema inferred for desc.
+//
+// Exported struct fields are matched with the message field named by the `avro` tag of the struct field, or
+// else with the message field whose name equals the struct field name, ignoring case and underscores.
+// Struct fields tagged with `avro:"-"` are ignored, and embedded structs are flattened like in encoding/json,
+// except that the fields of embedded structs of unexported types are ignored.
+// Struct fields without a matching message field, and values that can not be represented by the message field,
+// are reported as errors.
+//
+// Scalar fields are set from Go values of a matching kind, within the range of the field. Enum fields are set
+// from the name or the number of an enum value. Message fields are set from structs, messages of the same
+// type, time.Time for google.protobuf.Timestamp, time.Duration for google.protobuf.Duration, and scalars
+// for wrappers. Repeated fields are set from slices, and map fields from maps. Nil pointers, slices and maps
+// leave the field unset.
+func (o SchemaOptions) EncodeStruct(desc protoreflect.MessageDescriptor, v interface{}) (interface{}, error) {
+	message, err := messageFromStruct(desc, reflect.ValueOf(v))
+	if err != nil {
+		return nil, fmt.Errorf("message %s from struct: %w", desc.FullName(), err)
+	}
+	return o.Encode(message.Interface())
+}
+
+// MarshalStructs converts Go structs to the message of the marshaler, like SchemaOptions.EncodeStruct,
+// and writes them to the writer.
+func (m *Marshaler) MarshalStructs(values ...interface{}) error {
+	messages := make([]proto.Message, 0, len(values))
+	for _, v := range values {
+		message, err := messageFromStruct(m.desc, reflect.ValueOf(v))
+		if err != nil {
+			return fmt.Errorf("message %s from struct: %w", m.desc.FullName(), err)
+		}
+		messages = append(messages, message.Interface())
+	}
+	return m.Marshal(messages...)
+}
+
+// messageFromStruct returns a new message with the fields of the struct v.
+func messageFromStruct(desc protoreflect.MessageDescriptor, v reflect.Value) (protoreflect.Message, error) {
+	message := newMessage(desc)
+	if err := setStructFields(message, v); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// setStructFields sets the fields of the message from the fields of the struct v.
+func setStructFields(message protoreflect.Message, v reflect.Value) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("unexpected %s, expected struct", v.Type())
+	}
+	// the indices of embedded structs of unexported types, whose fields are ignored
+	var unexported [][]int
+	for _, sf := range reflect.VisibleFields(v.Type()) {
+		if embeddedIn(unexported, sf.Index) {
+			continue
+		}
+		if sf.Anonymous && indirectType(sf.Type).Kind() == reflect.Struct {
+			// the fields of embedded structs are visible fields themselves, and follow them
+			if !sf.IsExported() {
+				unexported = append(unexported, sf.Index)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name, ok := sf.Tag.Lookup("avro")
+		if name == "-" {
+			continue
+		}
+		fd := structFieldDescriptor(message.Descriptor(), sf.Name, name, ok)
+		if fd == nil {
+			return fmt.Errorf("no field matching %s in message %s", sf.Name, message.Descriptor().FullName())
+		}
+		fv, err := v.FieldByIndexErr(sf.Index)
+		if err != nil {
+			// a field of a nil embedded struct
+			continue
+		}
+		if err := setStructField(message, fd, fv); err != nil {
+			return fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+// embeddedIn returns true if the struct field at index is a field of one of the embedded structs at indices.
+func embeddedIn(indices [][]int, index []int) bool {
+Outer:
+	for _, embedded := range indices {
+		if len(embedded) >= len(index) {
+			continue
+		}
+		for i := range embedded {
+			if embedded[i] != index[i] {
+				continue Outer
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// structFieldDescriptor returns the message field named by the tag of a struct field, or else the message
+// field with the name of the struct field, ignoring case and underscores.
+func structFieldDescriptor(
+	desc protoreflect.MessageDescriptor,
+	name string,
+	tag string,
+	tagged bool,
+) protoreflect.FieldDescriptor {
+	if tagged && tag != "" {
+		return desc.Fields().ByName(protoreflect.Name(tag))
+	}
+	for i := 0; i < desc.Fields().Len(); i++ {
+		fd := desc.Fields().Get(i)
+		if strings.EqualFold(strings.ReplaceAll(string(fd.Name()), "_", ""), name) {
+			return fd
+		}
+	}
+	return nil
+}
+
+// setStructField sets the message field from the value of a struct field, or leaves the field unset if the
+// value is a nil pointer, slice or map.
+func setStructField(message protoreflect.Message, fd protoreflect.FieldDescriptor, v reflect.Value) error {
+	switch {
+	case fd.IsList():
+		v = indirect(v)
+		if !v.IsValid() || v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return fmt.Errorf("unexpected %s, expected slice", v.Type())
+		}
+		list := message.Mutable(fd).List()
+		for i := 0; i < v.Len(); i++ {
+			value, err := structValue(list.NewElement, fd, v.Index(i))
+			if err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+			if !value.IsValid() {
+				return fmt.Errorf("index %d: unexpected nil", i)
+			}
+			list.Append(value)
+		}
+		return nil
+	case fd.IsMap():
+		v = indirect(v)
+		if !v.IsValid() || v.IsNil() {
+			return nil
+		}
+		if v.Kind() != reflect.Map {
+			return fmt.Errorf("unexpected %s, expected map", v.Type())
+		}
+		mp := message.Mutable(fd).Map()
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := structValue(nil, fd.MapKey(), iter.Key())
+			if err != nil {
+				return fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			value, err := structValue(mp.NewValue, fd.MapValue(), iter.Value())
+			if err != nil {
+				return fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			if !value.IsValid() {
+				return fmt.Errorf("key %v: unexpected nil", iter.Key())
+			}
+			mp.Set(key.MapKey(), value)
+		}
+		return nil
+	}
+	value, err := structValue(func() protoreflect.Value { return message.NewField(fd) }, fd, v)
+	if err != nil || !value.IsValid() {
+		return err
+	}
+	message.Set(fd, value)
+	return nil
+}
+
+// structValue returns the value of a singular field from the Go value v, or an invalid value if v is nil.
+// New message values are created with newValue.
+func structValue(
+	newValue func() protoreflect.Value,
+	fd protoreflect.FieldDescriptor,
+	v reflect.Value,
+) (protoreflect.Value, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return protoreflect.Value{}, nil
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if v.Kind() == reflect.Bool {
+			return protoreflect.ValueOfBool(v.Bool()), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if i, ok := structInt(v, math.MinInt32, math.MaxInt32); ok {
+			return protoreflect.ValueOfInt32(int32(i)), nil
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if i, ok := structInt(v, math.MinInt64, math.MaxInt64); ok {
+			return protoreflect.ValueOfInt64(i), nil
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if u, ok := structUint(v, math.MaxUint32); ok {
+			return protoreflect.ValueOfUint32(uint32(u)), nil
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if u, ok := structUint(v, math.MaxUint64); ok {
+			return protoreflect.ValueOfUint64(u), nil
+		}
+	case protoreflect.FloatKind:
+		if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+			return protoreflect.ValueOfFloat32(float32(v.Float())), nil
+		}
+	case protoreflect.DoubleKind:
+		if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+			return protoreflect.ValueOfFloat64(v.Float()), nil
+		}
+	case protoreflect.StringKind:
+		if v.Kind() == reflect.String {
+			return protoreflect.ValueOfString(v.String()), nil
+		}
+	case protoreflect.BytesKind:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return protoreflect.ValueOfBytes(v.Bytes()), nil
+		}
+	case protoreflect.EnumKind:
+		return structEnumValue(fd.Enum(), v)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return structMessageValue(newValue, fd.Message(), v)
+	}
+	return protoreflect.Value{}, fmt.Errorf("unexpected %s for %s field", v.Type(), fd.Kind())
+}
+
+// structInt returns the integer value of v, if v is an integer within the range of min and max.
+func structInt(v reflect.Value, min, max int64) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		return i, i >= min && i <= max
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		return int64(u), u <= uint64(max)
+	}
+	return 0, false
+}
+
+// structUint returns the unsigned integer value of v, if v is a non-negative integer of at most max.
+func structUint(v reflect.Value, max uint64) (uint64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		return uint64(i), i >= 0 && uint64(i) <= max
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		return u, u <= max
+	}
+	return 0, false
+}
+
+// structEnumValue returns the enum value with the name, or the number, of v.
+func structEnumValue(enum protoreflect.EnumDescriptor, v reflect.Value) (protoreflect.Value, error) {
+	if v.Kind() == reflect.String {
+		value := enum.Values().ByName(protoreflect.Name(v.String()))
+		if value == nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown value %s of enum %s", v.String(), enum.FullName())
+		}
+		return protoreflect.ValueOfEnum(value.Number()), nil
+	}
+	if i, ok := structInt(v, math.MinInt32, math.MaxInt32); ok {
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unexpected %s for enum %s", v.Type(), enum.FullName())
+}
+
+// structMessageValue returns the message value of v, which is either a struct, a message of the same type,
+// or a Go value of a well-known type.
+func structMessageValue(
+	newValue func() protoreflect.Value,
+	desc protoreflect.MessageDescriptor,
+	v reflect.Value,
+) (protoreflect.Value, error) {
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	if m, ok := v.Interface().(proto.Message); ok {
+		return structProtoMessage(newValue, desc, m)
+	}
+	v = indirect(v)
+	switch desc.FullName() {
+	case wkt.Timestamp:
+		if t, ok := v.Interface().(time.Time); ok {
+			return structProtoMessage(newValue, desc, timestamppb.New(t))
+		}
+	case wkt.Duration:
+		if d, ok := v.Interface().(time.Duration); ok {
+			return structProtoMessage(newValue, desc, durationpb.New(d))
+		}
+	case wkt.DoubleValue, wkt.FloatValue, wkt.Int32Value, wkt.Int64Value, wkt.UInt32Value,
+		wkt.UInt64Value, wkt.BoolValue, wkt.StringValue, wkt.BytesValue:
+		if v.Kind() != reflect.Struct {
+			message := newValue().Message()
+			fd := desc.Fields().ByName("value")
+			value, err := structValue(nil, fd, v)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			message.Set(fd, value)
+			return protoreflect.ValueOfMessage(message), nil
+		}
+	}
+	if v.Kind() != reflect.Struct {
+		return protoreflect.Value{}, fmt.Errorf("unexpected %s for message %s", v.Type(), desc.FullName())
+	}
+	message := newValue().Message()
+	if err := setStructFields(message, v); err != nil {
+		return protoreflect.Value{}, err
+	}
+	return protoreflect.ValueOfMessage(message), nil
+}
+
+// structProtoMessage returns the value of a message of the same type as desc.
+func structProtoMessage(
+	newValue func() protoreflect.Value,
+	desc protoreflect.MessageDescriptor,
+	m proto.Message,
+) (protoreflect.Value, error) {
+	if m.ProtoReflect().Descriptor().FullName() != desc.FullName() {
+		return protoreflect.Value{}, fmt.Errorf(
+			"unexpected message %s, expected %s",
+			m.ProtoReflect().Descriptor().FullName(),
+			desc.FullName(),
+		)
+	}
+	message := newValue().Message()
+	if message.Type() == m.ProtoReflect().Type() {
+		return protoreflect.ValueOfMessage(m.ProtoReflect()), nil
+	}
+	// copy messages of different Go types, such as dynamic messages, through the wire format
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	if err := proto.Unmarshal(data, message.Interface()); err != nil {
+		return protoreflect.Value{}, err
+	}
+	return protoreflect.ValueOfMessage(message), nil
+}
+
+// indirect returns the value that v points to, or an invalid value if v is a nil pointer or interface.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// indirectType returns the type that t points to.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,240 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestEncodeStruct(t *testing.T) {
+	type Audit struct {
+		Read bool
+	}
+	type nested struct {
+		StringList []string `avro:"string_list"`
+	}
+	type audit struct {
+		Read  bool
+		Extra string
+	}
+	for _, tt := range []struct {
+		name     string
+		v        interface{}
+		expected proto.Message
+	}{
+		{
+			name: "field names",
+			v: struct {
+				Name   string
+				Author *string
+				Title  string `avro:"title"`
+				Ignore string `avro:"-"`
+				Audit
+				internal int
+			}{Name: "shelves/1/books/1", Title: "Harry Potter", Ignore: "foo", Audit: Audit{Read: true}},
+			expected: &library.Book{Name: "shelves/1/books/1", Title: "Harry Potter", Read: true},
+		},
+		{
+			name: "unexported embedded structs",
+			v: struct {
+				Title string
+				audit
+				*nested
+			}{Title: "Harry Potter", audit: audit{Read: true, Extra: "foo"}, nested: &nested{}},
+			expected: &library.Book{Title: "Harry Potter"},
+		},
+		{
+			name: "lists",
+			v: &struct {
+				Int64List      []int
+				StringList     [2]string
+				EnumList       []interface{}
+				NestedList     []*nested
+				FloatValueList []float32
+			}{
+				Int64List:      []int{1, -2},
+				StringList:     [2]string{"a", "b"},
+				EnumList:       []interface{}{"ENUM_VALUE1", 2, examplev1.ExampleList_ENUM_VALUE1},
+				NestedList:     []*nested{{StringList: []string{"c"}}},
+				FloatValueList: []float32{1.5},
+			},
+			expected: &examplev1.ExampleList{
+				Int64List:  []int64{1, -2},
+				StringList: []string{"a", "b"},
+				EnumList: []examplev1.ExampleList_Enum{
+					examplev1.ExampleList_ENUM_VALUE1,
+					examplev1.ExampleList_ENUM_VALUE2,
+					examplev1.ExampleList_ENUM_VALUE1,
+				},
+				NestedList:     []*examplev1.ExampleList_Nested{{StringList: []string{"c"}}},
+				FloatValueList: []*wrapperspb.FloatValue{wrapperspb.Float(1.5)},
+			},
+		},
+		{
+			name: "maps",
+			v: struct {
+				StringToString map[string]string
+				StringToNested map[string]*examplev1.ExampleMap_Nested
+				Int32ToString  map[int]string
+				Uint32ToString map[uint32]string
+				BoolToString   map[bool]string
+			}{
+				StringToString: map[string]string{"a": "b"},
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{"c": {StringToString: map[string]string{"d": "e"}}},
+				Int32ToString:  map[int]string{-1: "f"},
+				Uint32ToString: map[uint32]string{1: "g"},
+			},
+			expected: &examplev1.ExampleMap{
+				StringToString: map[string]string{"a": "b"},
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{"c": {StringToString: map[string]string{"d": "e"}}},
+				Int32ToString:  map[int32]string{-1: "f"},
+				Uint32ToString: map[uint32]string{1: "g"},
+			},
+		},
+		{
+			name: "timestamp",
+			v: struct {
+				Timestamp time.Time
+			}{Timestamp: time.Date(2021, 1, 2, 3, 4, 5, 6000, time.UTC)},
+			expected: &examplev1.ExampleTimestamp{
+				Timestamp: timestamppb.New(time.Date(2021, 1, 2, 3, 4, 5, 6000, time.UTC)),
+			},
+		},
+		{
+			name: "duration",
+			v: struct {
+				Duration *time.Duration
+			}{Duration: func() *time.Duration { d := 90 * time.Second; return &d }()},
+			expected: &examplev1.ExampleDuration{Duration: durationpb.New(90 * time.Second)},
+		},
+		{
+			name: "wrappers",
+			v: struct {
+				StringValue *string
+				Int64Value  int64
+				BoolValue   *bool
+				BytesValue  *wrapperspb.BytesValue
+			}{
+				StringValue: func() *string { s := "foo"; return &s }(),
+				Int64Value:  0,
+				BytesValue:  wrapperspb.Bytes([]byte("bar")),
+			},
+			expected: &examplev1.ExampleWrappers{
+				StringValue: wrapperspb.String("foo"),
+				Int64Value:  wrapperspb.Int64(0),
+				BytesValue:  wrapperspb.Bytes([]byte("bar")),
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeStruct(tt.expected.ProtoReflect().Descriptor(), tt.v)
+			assert.NilError(t, err)
+			expected, err := SchemaOptions{}.Encode(tt.expected)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, expected, got)
+		})
+	}
+}
+
+func TestEncodeStruct_Error(t *testing.T) {
+	desc := (&examplev1.ExampleList{}).ProtoReflect().Descriptor()
+	for _, tt := range []struct {
+		name          string
+		v             interface{}
+		errorContains string
+	}{
+		{
+			name:          "not a struct",
+			v:             "foo",
+			errorContains: "unexpected string, expected struct",
+		},
+		{
+			name:          "unknown field",
+			v:             struct{ Foo string }{},
+			errorContains: "no field matching Foo in message einride.avro.example.v1.ExampleList",
+		},
+		{
+			name: "unknown tagged field",
+			v: struct {
+				Foo []string `avro:"foo"`
+			}{},
+			errorContains: "no field matching Foo",
+		},
+		{
+			name:          "wrong type",
+			v:             struct{ Int64List []string }{Int64List: []string{"1"}},
+			errorContains: "field Int64List: index 0: unexpected string for int64 field",
+		},
+		{
+			name:          "not a list",
+			v:             struct{ Int64List int64 }{},
+			errorContains: "field Int64List: unexpected int64, expected slice",
+		},
+		{
+			name:          "unknown enum value",
+			v:             struct{ EnumList []string }{EnumList: []string{"FOO"}},
+			errorContains: "unknown value FOO of enum einride.avro.example.v1.ExampleList.Enum",
+		},
+		{
+			name:          "wrong message",
+			v:             struct{ NestedList []*library.Book }{NestedList: []*library.Book{{}}},
+			errorContains: "unexpected message google.example.library.v1.Book",
+		},
+		{
+			name:          "nil element",
+			v:             struct{ NestedList []*library.Book }{NestedList: []*library.Book{nil}},
+			errorContains: "index 0: unexpected nil",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := EncodeStruct(desc, tt.v)
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+
+	// integers out of range of the field
+	_, err := EncodeStruct(
+		(&examplev1.ExampleMap{}).ProtoReflect().Descriptor(),
+		struct{ Uint32ToString map[int]string }{Uint32ToString: map[int]string{-1: "a"}},
+	)
+	assert.ErrorContains(t, err, "key -1: unexpected int for uint32 field")
+}
+
+func TestMarshaler_MarshalStructs(t *testing.T) {
+	type book struct {
+		Name  string
+		Title string
+	}
+	var b bytes.Buffer
+	marshaler, err := NewMarshaler((&library.Book{}).ProtoReflect().Descriptor(), &b)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.MarshalStructs(
+		book{Name: "shelves/1/books/1", Title: "Harry Potter"},
+		&book{Name: "shelves/1/books/2"},
+	))
+	unmarshaler, err := NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	var got []*library.Book
+	for unmarshaler.Scan() {
+		msg := &library.Book{}
+		assert.NilError(t, unmarshaler.Unmarshal(msg))
+		got = append(got, msg)
+	}
+	assert.DeepEqual(
+		t,
+		[]*library.Book{{Name: "shelves/1/books/1", Title: "Harry Potter"}, {Name: "shelves/1/books/2"}},
+		got,
+		protocmp.Transform(),
+	)
+}
@@ -0,0 +1,144 @@
+package protoavro
+
+import (
+	"fmt"
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TimestampPrecision is the precision of google.protobuf.Timestamp values encoded as Avro.
+type TimestampPrecision int
+
+const (
+	// TimestampMicros encodes timestamps as the logical type timestamp-micros.
+	TimestampMicros TimestampPrecision = iota
+	// TimestampMillis encodes timestamps as the logical type timestamp-millis.
+	TimestampMillis
+	// TimestampNanos encodes timestamps as the logical type timestamp-nanos.
+	// Readers that do not support the logical type read the values as plain longs.
+	TimestampNanos
+)
+
+// TimestampRounding is how google.protobuf.Timestamp values are rounded to the TimestampPrecision.
+type TimestampRounding int
+
+const (
+	// TimestampTruncate truncates timestamps towards the Unix epoch.
+	TimestampTruncate TimestampRounding = iota
+	// TimestampRoundNearest rounds timestamps to the nearest multiple of the precision, rounding halfway values up.
+	TimestampRoundNearest
+)
+
+// TimestampEncoding is how google.protobuf.Timestamp values are encoded as Avro.
+type TimestampEncoding int
+
+const (
+	// TimestampLong encodes timestamps as a long of the logical type of the TimestampPrecision.
+	TimestampLong TimestampEncoding = iota
+	// TimestampString encodes timestamps as RFC 3339 strings, like protojson, for consumers that store
+	// timestamps as strings. Strings have the fractional digits of the TimestampPrecision, so that they sort
+	// in the order of time when they share a time zone, for example "2021-02-03T04:05:06.789000Z" with
+	// microseconds. Strings are in UTC, unless SchemaOptions.TimestampLocation is set. Strings with any
+	// number of fractional digits and any offset are decoded.
+	TimestampString
+)
+
+func (p TimestampPrecision) schema() avro.Primitive {
+	switch p {
+	case TimestampMillis:
+		return avro.TimestampMillis()
+	case TimestampNanos:
+		return avro.TimestampNanos()
+	default:
+		return avro.TimestampMicros()
+	}
+}
+
+// unionKey returns the key of timestamp values in unions. The logical type timestamp-nanos is not supported by
+// goavro, which falls back to long.
+func (p TimestampPrecision) unionKey() string {
+	switch p {
+	case TimestampMillis:
+		return "long.timestamp-millis"
+	case TimestampNanos:
+		return "long"
+	default:
+		return "long.timestamp-micros"
+	}
+}
+
+func (p TimestampPrecision) unit() time.Duration {
+	switch p {
+	case TimestampMillis:
+		return time.Millisecond
+	case TimestampNanos:
+		return time.Nanosecond
+	default:
+		return time.Microsecond
+	}
+}
+
+// layout returns the RFC 3339 layout of timestamps encoded as strings, with the fractional digits of the precision.
+func (p TimestampPrecision) layout() string {
+	switch p {
+	case TimestampMillis:
+		return "2006-01-02T15:04:05.000Z07:00"
+	case TimestampNanos:
+		return "2006-01-02T15:04:05.000000000Z07:00"
+	default:
+		return "2006-01-02T15:04:05.000000Z07:00"
+	}
+}
+
+func (o SchemaOptions) schemaTimestamp() avro.Schema {
+	if o.timestampEncoding() == TimestampString {
+		return avro.Nullable(avro.String())
+	}
+	return avro.Nullable(o.TimestampPrecision.schema())
+}
+
+func (o SchemaOptions) encodeTimestamp(t *timestamppb.Timestamp) map[string]interface{} {
+	unit := o.TimestampPrecision.unit()
+	tm := t.AsTime()
+	if o.TimestampRounding == TimestampRoundNearest {
+		tm = tm.Round(unit)
+	}
+	if o.timestampEncoding() == TimestampString {
+		location := time.UTC
+		if o.TimestampLocation != nil {
+			location = o.TimestampLocation
+		}
+		// formatting truncates fractional seconds to the digits of the layout
+		return o.unionValue("string", tm.In(location).Format(o.TimestampPrecision.layout()))
+	}
+	return o.unionValue(o.TimestampPrecision.unionKey(), tm.UnixNano()/int64(unit))
+}
+
+func (o SchemaOptions) decodeTimestamp(v map[string]interface{}) (*timestamppb.Timestamp, error) {
+	if o.timestampEncoding() == TimestampString {
+		s, err := decodeString(v, "string")
+		if err != nil {
+			return nil, fmt.Errorf("google.protobuf.Timestamp: %w", err)
+		}
+		tm, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("google.protobuf.Timestamp: %w", err)
+		}
+		return timestamppb.New(tm), nil
+	}
+	// timestamps written with a different precision are decoded as time.Time
+	for _, key := range []string{"long.timestamp-millis", "long.timestamp-micros"} {
+		if tm, ok := tryDecodeTime(v, key); ok {
+			return timestamppb.New(tm), nil
+		}
+	}
+	n, err := decodeInt(v, o.TimestampPrecision.unionKey())
+	if err != nil {
+		return nil, fmt.Errorf("google.protobuf.Timestamp: %w", err)
+	}
+	perSecond := int64(time.Second / o.TimestampPrecision.unit())
+	t := time.Unix(n/perSecond, (n%perSecond)*int64(o.TimestampPrecision.unit()))
+	return timestamppb.New(t), nil
+}
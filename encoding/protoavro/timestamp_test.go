@@ -0,0 +1,184 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gotest.tools/v3/assert"
+)
+
+func Test_TimestampPrecision(t *testing.T) {
+	tm := time.Date(2021, 6, 27, 1, 39, 24, 123456789, time.UTC)
+	for _, tt := range []struct {
+		name     string
+		opts     SchemaOptions
+		schema   avro.Schema
+		expected map[string]interface{}
+		decoded  time.Time
+	}{
+		{
+			name:     "default",
+			schema:   avro.TimestampMicros(),
+			expected: map[string]interface{}{"long.timestamp-micros": int64(1624757964123456)},
+			decoded:  tm.Truncate(time.Microsecond),
+		},
+		{
+			name:     "millis",
+			opts:     SchemaOptions{TimestampPrecision: TimestampMillis},
+			schema:   avro.TimestampMillis(),
+			expected: map[string]interface{}{"long.timestamp-millis": int64(1624757964123)},
+			decoded:  tm.Truncate(time.Millisecond),
+		},
+		{
+			name:     "millis rounded",
+			opts:     SchemaOptions{TimestampPrecision: TimestampMillis, TimestampRounding: TimestampRoundNearest},
+			schema:   avro.TimestampMillis(),
+			expected: map[string]interface{}{"long.timestamp-millis": int64(1624757964123)},
+			decoded:  tm.Truncate(time.Millisecond),
+		},
+		{
+			name:     "micros rounded",
+			opts:     SchemaOptions{TimestampRounding: TimestampRoundNearest},
+			schema:   avro.TimestampMicros(),
+			expected: map[string]interface{}{"long.timestamp-micros": int64(1624757964123457)},
+			decoded:  tm.Round(time.Microsecond),
+		},
+		{
+			name:     "nanos",
+			opts:     SchemaOptions{TimestampPrecision: TimestampNanos},
+			schema:   avro.TimestampNanos(),
+			expected: map[string]interface{}{"long": int64(1624757964123456789)},
+			decoded:  tm,
+		},
+		{
+			name:     "string",
+			opts:     SchemaOptions{TimestampEncoding: TimestampString},
+			schema:   avro.String(),
+			expected: map[string]interface{}{"string": "2021-06-27T01:39:24.123456Z"},
+			decoded:  tm.Truncate(time.Microsecond),
+		},
+		{
+			name: "string millis rounded",
+			opts: SchemaOptions{
+				TimestampEncoding:  TimestampString,
+				TimestampPrecision: TimestampMillis,
+				TimestampRounding:  TimestampRoundNearest,
+			},
+			schema:   avro.String(),
+			expected: map[string]interface{}{"string": "2021-06-27T01:39:24.123Z"},
+			decoded:  tm.Truncate(time.Millisecond),
+		},
+		{
+			name:     "string nanos",
+			opts:     SchemaOptions{TimestampEncoding: TimestampString, TimestampPrecision: TimestampNanos},
+			schema:   avro.String(),
+			expected: map[string]interface{}{"string": "2021-06-27T01:39:24.123456789Z"},
+			decoded:  tm,
+		},
+		{
+			name: "string location",
+			opts: SchemaOptions{
+				TimestampEncoding: TimestampString,
+				TimestampLocation: time.FixedZone("CEST", 2*60*60),
+			},
+			schema:   avro.String(),
+			expected: map[string]interface{}{"string": "2021-06-27T03:39:24.123456+02:00"},
+			decoded:  tm.Truncate(time.Microsecond),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &examplev1.ExampleTimestamp{Timestamp: timestamppb.New(tm)}
+			schema, err := tt.opts.InferSchema(msg.ProtoReflect().Descriptor())
+			assert.NilError(t, err)
+			field := schema.(avro.Union)[1].(avro.Record).Fields[0]
+			assert.DeepEqual(t, field.Type, avro.Nullable(tt.schema))
+
+			encoded, err := tt.opts.encodeJSON(msg)
+			assert.NilError(t, err)
+			record := encoded.(map[string]interface{})["einride.avro.example.v1.ExampleTimestamp"].(map[string]interface{})
+			assert.DeepEqual(t, record["timestamp"], tt.expected)
+
+			// round-trip through the binary encoding
+			schemaBytes, err := json.Marshal(schema)
+			assert.NilError(t, err)
+			codec, err := goavro.NewCodec(string(schemaBytes))
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, encoded)
+			assert.NilError(t, err)
+			native, _, err := codec.NativeFromBinary(binary)
+			assert.NilError(t, err)
+			decoded := &examplev1.ExampleTimestamp{}
+			assert.NilError(t, tt.opts.decodeJSON(native, decoded))
+			assert.DeepEqual(
+				t,
+				&examplev1.ExampleTimestamp{Timestamp: timestamppb.New(tt.decoded)},
+				decoded,
+				protocmp.Transform(),
+			)
+		})
+	}
+}
+
+func Test_TimestampString(t *testing.T) {
+	opts := SchemaOptions{TimestampEncoding: TimestampString}
+	for _, tt := range []struct {
+		name          string
+		value         string
+		expected      time.Time
+		errorContains string
+	}{
+		{
+			name:     "no fractional seconds",
+			value:    "2021-06-27T01:39:24Z",
+			expected: time.Date(2021, 6, 27, 1, 39, 24, 0, time.UTC),
+		},
+		{
+			name:     "offset",
+			value:    "2021-06-27T03:39:24.5+02:00",
+			expected: time.Date(2021, 6, 27, 1, 39, 24, 500000000, time.UTC),
+		},
+		{
+			name:          "not RFC 3339",
+			value:         "2021-06-27 01:39:24",
+			errorContains: "google.protobuf.Timestamp: parsing time",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := opts.decodeTimestamp(map[string]interface{}{"string": tt.value})
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Assert(t, value.AsTime().Equal(tt.expected), value.AsTime())
+		})
+	}
+
+	t.Run("codec", func(t *testing.T) {
+		msg := &examplev1.ExampleTimestamp{
+			Timestamp: timestamppb.New(time.Date(2021, 6, 27, 1, 39, 24, 123456000, time.UTC)),
+		}
+		codec, err := NewCodec[*examplev1.ExampleTimestamp](opts)
+		assert.NilError(t, err)
+		b, err := codec.Marshal(msg)
+		assert.NilError(t, err)
+		got, err := codec.Unmarshal(b)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, msg, got, protocmp.Transform())
+		avroJSON, err := opts.MarshalAvroJSON(msg)
+		assert.NilError(t, err)
+		assert.Equal(t, `{"einride.avro.example.v1.ExampleTimestamp":{"timestamp":{"string":"2021-06-27T01:39:24.123456Z"}}}`,
+			string(avroJSON))
+		var fromJSON examplev1.ExampleTimestamp
+		assert.NilError(t, opts.UnmarshalAvroJSON(avroJSON, &fromJSON))
+		assert.DeepEqual(t, msg, &fromJSON, protocmp.Transform())
+	})
+}
@@ -0,0 +1,176 @@
+package protoavro
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TokenizationKeyProperty is the custom property of tokenized record fields, with the ID of the key that their
+// values are tokenized with. See FieldTransform.Tokenize.
+const TokenizationKeyProperty = "tokenizationKeyId"
+
+const (
+	// TokenizeHMAC tokenizes values as the hex encoding of their HMAC-SHA256, of 64 characters.
+	TokenizeHMAC = "hmac"
+	// TokenizeFormatHash tokenizes values as keyed hashes of the same length and format, where digits, lower
+	// case and upper case ASCII letters are replaced by characters of the same class, drawn uniformly from the
+	// HMAC-SHA256 of the value, and other characters are kept, so that for example tokens of "AB-123" are two
+	// upper case letters, a hyphen and three digits. Unlike format-preserving encryption, tokens can not be
+	// decrypted, tokens of short values are easy to guess by trying all values of the format, and tokens of
+	// different values may collide.
+	TokenizeFormatHash = "format-hash"
+)
+
+// Tokenizer pseudonymizes the values of string fields with keyed hashes, see FieldTransform.Tokenize. Tokens are
+// deterministic for a key, so that tokenized values can still be joined and counted, but can not be reversed.
+type Tokenizer struct {
+	// Keys are the keys of the tokenizer, from the oldest to the newest, which tokenizes values. Keys are rotated
+	// by appending a key, and older keys are kept to compute tokens of the data tokenized with them, by Token.
+	Keys []TokenizationKey
+}
+
+// TokenizationKey is a secret key of a Tokenizer.
+type TokenizationKey struct {
+	// ID of the key, recorded as the custom property TokenizationKeyProperty of tokenized fields.
+	ID string
+	// Secret of the key, which should be random and at least 32 bytes long.
+	Secret []byte
+}
+
+// errNoTokenizationKeys is returned when values are tokenized without keys.
+var errNoTokenizationKeys = errors.New("tokenized field without Tokenizer keys")
+
+// currentKey returns the newest key of the tokenizer.
+func (t *Tokenizer) currentKey() (TokenizationKey, error) {
+	if t == nil || len(t.Keys) == 0 {
+		return TokenizationKey{}, errNoTokenizationKeys
+	}
+	return t.Keys[len(t.Keys)-1], nil
+}
+
+// Token returns the token of the value by the method, TokenizeHMAC or TokenizeFormatHash, with the key of
+// the ID, for example to look up values tokenized before the keys were rotated.
+func (t *Tokenizer) Token(keyID, method, value string) (string, error) {
+	if t != nil {
+		for _, key := range t.Keys {
+			if key.ID == keyID {
+				return key.token(method, value)
+			}
+		}
+	}
+	return "", fmt.Errorf("unknown tokenization key %s", keyID)
+}
+
+func (k TokenizationKey) token(method, value string) (string, error) {
+	switch method {
+	case TokenizeHMAC:
+		return hex.EncodeToString(k.mac(0, value)), nil
+	case TokenizeFormatHash:
+		return k.formatHashToken(value), nil
+	}
+	return "", fmt.Errorf("unsupported tokenization '%s'", method)
+}
+
+// mac returns the HMAC-SHA256 of the value, prefixed by the counter, which derives streams of bytes longer than a
+// single MAC.
+func (k TokenizationKey) mac(counter uint32, value string) []byte {
+	mac := hmac.New(sha256.New, k.Secret)
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], counter)
+	_, _ = mac.Write(prefix[:])
+	_, _ = mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// formatHashToken returns the token of the value by TokenizeFormatHash. Characters are drawn from the bytes of
+// the MAC by rejection sampling, which skips the bytes beyond the largest multiple of the size of the class, so
+// that all characters of a class are equally likely.
+func (k TokenizationKey) formatHashToken(value string) string {
+	token := []rune(value)
+	var stream []byte
+	var counter uint32
+	for i, r := range token {
+		var first, size rune
+		switch {
+		case r >= '0' && r <= '9':
+			first, size = '0', 10
+		case r >= 'a' && r <= 'z':
+			first, size = 'a', 26
+		case r >= 'A' && r <= 'Z':
+			first, size = 'A', 26
+		default:
+			continue
+		}
+		limit := 256 - 256%size
+		for {
+			if len(stream) == 0 {
+				stream = k.mac(counter, value)
+				counter++
+			}
+			b := rune(stream[0])
+			stream = stream[1:]
+			if b < limit {
+				token[i] = first + b%size
+				break
+			}
+		}
+	}
+	return string(token)
+}
+
+// checkTokenize returns an error if the field can not be tokenized by the transform.
+func (t FieldTransform) checkTokenize(field protoreflect.FieldDescriptor) error {
+	switch {
+	case t.Tokenize != TokenizeHMAC && t.Tokenize != TokenizeFormatHash:
+		return fmt.Errorf("unsupported tokenization '%s'", t.Tokenize)
+	case t.Redact || t.Expression != "":
+		return fmt.Errorf("tokenization of redacted or computed field")
+	case field == nil:
+		return fmt.Errorf("tokenization of derived field")
+	case field.Kind() != protoreflect.StringKind || field.IsList() || field.IsMap():
+		return fmt.Errorf("tokenization of %s field", describeField(field))
+	}
+	return nil
+}
+
+// tokenizeSchema annotates the record field of the tokenized field with the ID of the current key.
+func (o SchemaOptions) tokenizeSchema(field *avro.Field) error {
+	key, err := o.Tokenizer.currentKey()
+	if err != nil {
+		return err
+	}
+	properties := make(avro.Properties, len(field.Properties)+1)
+	for name, value := range field.Properties {
+		properties[name] = value
+	}
+	properties[TokenizationKeyProperty] = key.ID
+	field.Properties = properties
+	return nil
+}
+
+// tokenize returns the token of the value of the field of the message, with the current key.
+func (o SchemaOptions) tokenize(
+	t FieldTransform,
+	message protoreflect.Message,
+	field protoreflect.FieldDescriptor,
+) (interface{}, error) {
+	if !message.Has(field) {
+		return nil, nil
+	}
+	key, err := o.Tokenizer.currentKey()
+	if err != nil {
+		return nil, err
+	}
+	token, err := key.token(t.Tokenize, message.Get(field).String())
+	if err != nil {
+		return nil, err
+	}
+	return o.unionValue("string", token), nil
+}
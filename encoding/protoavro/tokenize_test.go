@@ -0,0 +1,147 @@
+package protoavro
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tokenizer := &Tokenizer{Keys: []TokenizationKey{
+		{ID: "k1", Secret: []byte("first secret of thirty-two bytes")},
+		{ID: "k2", Secret: []byte("second secret of thirty-two byte")},
+	}}
+	opts := SchemaOptions{
+		OmitRootElement: true,
+		Tokenizer:       tokenizer,
+		FieldTransforms: []FieldTransform{
+			{Message: "google.example.library.v1.Book", Field: "author", Tokenize: TokenizeHMAC},
+			{
+				Message:  "google.example.library.v1.Book",
+				Field:    "name",
+				Rename:   "book_name",
+				Tokenize: TokenizeFormatHash,
+			},
+		},
+	}
+	book := &library.Book{Name: "shelves/1/books/42", Author: "Frank Herbert", Title: "Dune"}
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := opts.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		record := recordOf(t, schema)
+		assert.Equal(t, "book_name", record.Fields[0].Name)
+		assert.DeepEqual(t, avro.Properties{TokenizationKeyProperty: "k2"}, record.Fields[0].Properties)
+		assert.DeepEqual(t, avro.Nullable(avro.String()), record.Fields[1].Type)
+		assert.DeepEqual(t, avro.Properties{TokenizationKeyProperty: "k2"}, record.Fields[1].Properties)
+		assert.Assert(t, record.Fields[2].Properties == nil)
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		data, err := opts.Encode(book)
+		assert.NilError(t, err)
+		record := data.(map[string]interface{})
+		author := record["author"].(map[string]interface{})["string"].(string)
+		expected, err := tokenizer.Token("k2", TokenizeHMAC, "Frank Herbert")
+		assert.NilError(t, err)
+		assert.Equal(t, expected, author)
+		assert.Assert(t, regexp.MustCompile("^[0-9a-f]{64}$").MatchString(author), author)
+		previous, err := tokenizer.Token("k1", TokenizeHMAC, "Frank Herbert")
+		assert.NilError(t, err)
+		assert.Assert(t, previous != author)
+		name := record["book_name"].(map[string]interface{})["string"].(string)
+		assert.Assert(t, regexp.MustCompile("^[a-z]{7}/[0-9]/[a-z]{5}/[0-9]{2}$").MatchString(name), name)
+		assert.Assert(t, name != book.GetName())
+		// tokens are deterministic
+		again, err := opts.Encode(book)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, data, again)
+		// fields that are not set are null
+		data, err = opts.Encode(&library.Book{Title: "Dune"})
+		assert.NilError(t, err)
+		assert.Equal(t, nil, data.(map[string]interface{})["author"])
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		data, err := opts.MarshalAvroJSON(book)
+		assert.NilError(t, err)
+		decoded := &library.Book{}
+		assert.NilError(t, opts.UnmarshalAvroJSON(data, decoded))
+		author, err := tokenizer.Token("k2", TokenizeHMAC, "Frank Herbert")
+		assert.NilError(t, err)
+		name, err := tokenizer.Token("k2", TokenizeFormatHash, book.GetName())
+		assert.NilError(t, err)
+		expected := &library.Book{Name: name, Author: author, Title: "Dune"}
+		assert.DeepEqual(t, expected, decoded, protocmp.Transform())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		desc := book.ProtoReflect().Descriptor()
+		for _, tt := range []struct {
+			name     string
+			opts     SchemaOptions
+			expected string
+			// invalid options are reported by Validate, which does not check the kinds of fields
+			invalid bool
+		}{
+			{
+				name: "no keys",
+				opts: SchemaOptions{FieldTransforms: []FieldTransform{
+					{Message: "google.example.library.v1.Book", Field: "author", Tokenize: TokenizeHMAC},
+				}},
+				expected: "field transform google.example.library.v1.Book.author: tokenized field without Tokenizer keys",
+				invalid:  true,
+			},
+			{
+				name: "kind",
+				opts: SchemaOptions{Tokenizer: tokenizer, FieldTransforms: []FieldTransform{
+					{Message: "google.example.library.v1.Book", Field: "read", Tokenize: TokenizeHMAC},
+				}},
+				expected: "field transform google.example.library.v1.Book.read: tokenization of bool field",
+			},
+			{
+				name: "method",
+				opts: SchemaOptions{Tokenizer: tokenizer, FieldTransforms: []FieldTransform{
+					{Message: "google.example.library.v1.Book", Field: "author", Tokenize: "sha1"},
+				}},
+				expected: "field transform google.example.library.v1.Book.author: unsupported tokenization 'sha1'",
+				invalid:  true,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := tt.opts.InferSchema(desc)
+				assert.ErrorContains(t, err, tt.expected)
+				_, err = tt.opts.Encode(book)
+				assert.ErrorContains(t, err, tt.expected)
+				if tt.invalid {
+					assert.ErrorContains(t, tt.opts.Validate(), tt.expected)
+				}
+			})
+		}
+		_, err := tokenizer.Token("k3", TokenizeHMAC, "x")
+		assert.ErrorContains(t, err, "unknown tokenization key k3")
+	})
+}
+
+func TestTokenizeFormatHash_Uniform(t *testing.T) {
+	key := TokenizationKey{ID: "k1", Secret: []byte("first secret of thirty-two bytes")}
+	counts := make(map[rune]int)
+	const values, length = 200, 1000
+	for i := 0; i < values; i++ {
+		token := key.formatHashToken(fmt.Sprintf("%d-%s", i, strings.Repeat("a", length)))
+		for _, r := range token[strings.IndexByte(token, '-')+1:] {
+			counts[r]++
+		}
+	}
+	// the modulo of bytes would draw the last 4 letters about 9% less often than the others
+	expected := values * length / 26
+	for r := 'a'; r <= 'z'; r++ {
+		assert.Assert(t, counts[r] > expected*95/100 && counts[r] < expected*105/100, "%c: %d", r, counts[r])
+	}
+}
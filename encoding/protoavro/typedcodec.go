@@ -0,0 +1,110 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes and decodes messages of type T in Avro binary format.
+// The schema of the codec is inferred from T, so that messages and schema always match.
+// A Codec is safe for concurrent use: its state is immutable after construction, and is not affected by
+// later changes to the options it was created with.
+type Codec[T proto.Message] struct {
+	opts   SchemaOptions
+	schema avro.Schema
+	codec  *goavro.Codec
+	// decoder decodes messages directly from Avro binary, or is nil when the options are not supported.
+	decoder *binaryDecoder
+}
+
+// NewCodec returns a new codec for messages of type T.
+func NewCodec[T proto.Message](opts SchemaOptions) (*Codec[T], error) {
+	opts = opts.clone()
+	var zero T
+	schema, err := opts.InferSchema(zero.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, fmt.Errorf("infer schema: %w", err)
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new codec: %w", err)
+	}
+	if _, err := lookupCompressionCodec(opts.RecordCompression); err != nil {
+		return nil, fmt.Errorf("record compression: %w", err)
+	}
+	decoder, err := opts.newBinaryDecoder(zero.ProtoReflect().Descriptor(), schema)
+	if err != nil && !errors.Is(err, errUnsupported) {
+		return nil, fmt.Errorf("new decoder: %w", err)
+	}
+	return &Codec[T]{opts: opts, schema: schema, codec: codec, decoder: decoder}, nil
+}
+
+// Schema returns the Avro schema of the codec.
+func (c *Codec[T]) Schema() avro.Schema {
+	return c.schema
+}
+
+// Marshal encodes the message in Avro binary format, compressed with RecordCompression when it is set.
+func (c *Codec[T]) Marshal(message T) ([]byte, error) {
+	return c.marshal(message, c.opts)
+}
+
+func (c *Codec[T]) marshal(message T, opts SchemaOptions) ([]byte, error) {
+	data, err := opts.encodeJSON(message)
+	if err != nil {
+		return nil, fmt.Errorf("encode json: %w", err)
+	}
+	b, err := c.codec.BinaryFromNative(nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("binary from native: %w", err)
+	}
+	return CompressRecord(opts.RecordCompression, b)
+}
+
+// Unmarshal decodes a message from Avro binary format.
+// Messages are decoded directly from the binary data, without the intermediate Avro JSON encoding,
+// unless PreserveUnknownFields, EnvelopeFields, RawProto or DecodeMask are set.
+// Records compressed with RecordCompression are decompressed first, whatever the options of the codec.
+func (c *Codec[T]) Unmarshal(b []byte) (T, error) {
+	return c.unmarshal(b, c.opts, c.decoder)
+}
+
+// unmarshal decodes a message with the options, directly with the decoder unless it is nil.
+func (c *Codec[T]) unmarshal(b []byte, opts SchemaOptions, decoder *binaryDecoder) (T, error) {
+	var zero T
+	b, err := decompressRecord(b, opts.MaxRecordBytes)
+	if err != nil {
+		return zero, err
+	}
+	if err := opts.checkRecordBytes(len(b)); err != nil {
+		return zero, err
+	}
+	if decoder != nil {
+		message := zero.ProtoReflect().New().Interface().(T)
+		if err := decoder.decode(b, message.ProtoReflect()); err != nil {
+			return zero, fmt.Errorf("native from binary: %w", err)
+		}
+		return message, nil
+	}
+	data, rest, err := c.codec.NativeFromBinary(b)
+	if err != nil {
+		return zero, fmt.Errorf("native from binary: %w", err)
+	}
+	if err := checkTrailingBytes(len(rest)); err != nil {
+		return zero, fmt.Errorf("native from binary: %w", err)
+	}
+	message := zero.ProtoReflect().New().Interface().(T)
+	if err := opts.decodeJSON(data, message); err != nil {
+		return zero, fmt.Errorf("decode message: %w", err)
+	}
+	return message, nil
+}
@@ -0,0 +1,145 @@
+package protoavro_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gotest.tools/v3/assert"
+)
+
+func Test_Codec(t *testing.T) {
+	codec, err := protoavro.NewCodec[*library.Book](protoavro.SchemaOptions{})
+	assert.NilError(t, err)
+	expected, err := protoavro.InferSchema((&library.Book{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, expected, codec.Schema())
+
+	msg := &library.Book{
+		Name:   "shelves/1/books/1",
+		Title:  "Harry Potter",
+		Author: "J. K. Rowling",
+	}
+	b, err := codec.Marshal(msg)
+	assert.NilError(t, err)
+	got, err := codec.Unmarshal(b)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, msg, got, protocmp.Transform())
+
+	_, err = codec.Unmarshal([]byte{0xff})
+	assert.ErrorContains(t, err, "native from binary")
+}
+
+func Test_Codec_TrailingBytes(t *testing.T) {
+	for _, opts := range []protoavro.SchemaOptions{
+		{},
+		// decoded through goavro
+		{PreserveUnknownFields: true},
+	} {
+		codec, err := protoavro.NewCodec[*library.Book](opts)
+		assert.NilError(t, err)
+		b, err := codec.Marshal(&library.Book{Name: "shelves/1/books/1"})
+		assert.NilError(t, err)
+		_, err = codec.Unmarshal(append(b, 0x02))
+		assert.ErrorContains(t, err, "1 trailing bytes after the datum")
+	}
+}
+
+func Test_Codec_Concurrent(t *testing.T) {
+	for _, opts := range []protoavro.SchemaOptions{
+		{},
+		// decoded through goavro
+		{PreserveUnknownFields: true},
+	} {
+		codec, err := protoavro.NewCodec[*library.Book](opts)
+		assert.NilError(t, err)
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					msg := &library.Book{Name: fmt.Sprintf("shelves/%d/books/%d", i, j), Title: "Harry Potter"}
+					b, err := codec.Marshal(msg)
+					if err != nil {
+						errs <- err
+						return
+					}
+					got, err := codec.Unmarshal(b)
+					if err != nil {
+						errs <- err
+						return
+					}
+					if got.GetName() != msg.GetName() || got.GetTitle() != msg.GetTitle() {
+						errs <- fmt.Errorf("unexpected message %v, expected %v", got, msg)
+						return
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NilError(t, err)
+		}
+	}
+}
+
+func Test_Codec_OptionsCopied(t *testing.T) {
+	opts := protoavro.SchemaOptions{SchemaMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}}
+	codec, err := protoavro.NewCodec[*library.Book](opts)
+	assert.NilError(t, err)
+	// changes to the options do not affect the codec
+	opts.SchemaMask.Paths[0] = "title"
+	b, err := codec.Marshal(&library.Book{Name: "shelves/1/books/1", Title: "Harry Potter"})
+	assert.NilError(t, err)
+	got, err := codec.Unmarshal(b)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, &library.Book{Name: "shelves/1/books/1"}, got, protocmp.Transform())
+}
+
+func Test_Codec_RecordCompression(t *testing.T) {
+	protoavro.RegisterCompressionCodec("reverse", reverseCompression{})
+	msg := &library.Book{
+		Name:   "shelves/1/books/1",
+		Title:  "Harry Potter and the Philosopher's Stone, Harry Potter and the Chamber of Secrets",
+		Author: "J. K. Rowling",
+	}
+	uncompressed, err := protoavro.NewCodec[*library.Book](protoavro.SchemaOptions{})
+	assert.NilError(t, err)
+	plain, err := uncompressed.Marshal(msg)
+	assert.NilError(t, err)
+	for _, compression := range []string{
+		protoavro.CompressionNull,
+		protoavro.CompressionDeflate,
+		protoavro.CompressionSnappy,
+		protoavro.CompressionLZ4,
+		"reverse",
+	} {
+		compression := compression
+		t.Run(compression, func(t *testing.T) {
+			codec, err := protoavro.NewCodec[*library.Book](protoavro.SchemaOptions{RecordCompression: compression})
+			assert.NilError(t, err)
+			b, err := codec.Marshal(msg)
+			assert.NilError(t, err)
+			got, err := codec.Unmarshal(b)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, msg, got, protocmp.Transform())
+			// compression is detected by the header of the record, whatever the options of the codec
+			got, err = uncompressed.Unmarshal(b)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, msg, got, protocmp.Transform())
+			got, err = codec.Unmarshal(plain)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, msg, got, protocmp.Transform())
+			decompressed, err := protoavro.DecompressRecord(b)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, plain, decompressed)
+		})
+	}
+}
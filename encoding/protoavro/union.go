@@ -0,0 +1,205 @@
+package protoavro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// InferUnionSchema returns the Avro union schema, with default SchemaOptions, of the protobuf messages.
+// See SchemaOptions.InferUnionSchema.
+func InferUnionSchema(descs ...protoreflect.MessageDescriptor) (avro.Union, error) {
+	return SchemaOptions{}.InferUnionSchema(descs...)
+}
+
+// InferUnionSchema returns an Avro union schema with a branch for the record of each protobuf message,
+// for example for a stream of several event types. Named types shared by the messages are defined once.
+// Values of the union are records wrapped by the full name of the message, see EncodeUnion.
+func (o SchemaOptions) InferUnionSchema(descs ...protoreflect.MessageDescriptor) (avro.Union, error) {
+	if len(descs) == 0 {
+		return nil, fmt.Errorf("infer union schema: no messages")
+	}
+	seen := make(map[protoreflect.FullName]struct{}, len(descs))
+	inferrer := o.withNames(descs...).NewSchemaInferrer()
+	union := make(avro.Union, 0, len(descs))
+	for _, desc := range descs {
+		if _, ok := seen[desc.FullName()]; ok {
+			return nil, fmt.Errorf("infer union schema: duplicate message %s", desc.FullName())
+		}
+		seen[desc.FullName()] = struct{}{}
+		if o.isWKT(desc.FullName()) {
+			return nil, fmt.Errorf("infer union schema: unsupported message %s", desc.FullName())
+		}
+		schema, err := inferrer.InferSchema(desc)
+		if err != nil {
+			return nil, fmt.Errorf("infer union schema: %w", err)
+		}
+		union = append(union, nonNullSchema(schema))
+	}
+	return union, nil
+}
+
+// EncodeUnion encodes the message as a value of the union returned by InferUnionSchema.
+// The descriptors are the messages of the union, which are required to resolve the names of types
+// when DisambiguateNames is set.
+func (o SchemaOptions) EncodeUnion(message proto.Message, descs ...protoreflect.MessageDescriptor) (interface{}, error) {
+	roots := make([]protoreflect.MessageDescriptor, 0, len(descs)+1)
+	o = o.withNames(append(append(roots, descs...), message.ProtoReflect().Descriptor())...)
+	o.OmitRootElement = true
+	record, err := o.encodeJSON(message)
+	if err != nil {
+		return nil, fmt.Errorf("encode union: %w", err)
+	}
+	return o.unionValue(o.avroName(message.ProtoReflect().Descriptor()), record), nil
+}
+
+// DecodeUnion decodes a value of the union returned by InferUnionSchema, to a new message of the type
+// of the branch. The descriptors are the messages of the union.
+// Messages are of the type registered in protoregistry.GlobalTypes, or dynamic messages when the
+// message type is not registered.
+func (o SchemaOptions) DecodeUnion(data interface{}, descs ...protoreflect.MessageDescriptor) (proto.Message, error) {
+	o = o.withNames(descs...)
+	desc, err := o.unionBranch(data, descs)
+	if err != nil {
+		return nil, fmt.Errorf("decode union: %w", err)
+	}
+	message := newMessage(desc).Interface()
+	if err := o.decodeJSON(data, message); err != nil {
+		return nil, fmt.Errorf("decode union: %w", err)
+	}
+	return message, nil
+}
+
+// unionBranch returns the message of the branch of a value of the union returned by InferUnionSchema.
+func (o SchemaOptions) unionBranch(
+	data interface{},
+	descs []protoreflect.MessageDescriptor,
+) (protoreflect.MessageDescriptor, error) {
+	d, ok := data.(map[string]interface{})
+	if !ok || len(d) != 1 {
+		return nil, fmt.Errorf("expected union value encoded as map[string]interface{}, got %T", data)
+	}
+	var name string
+	for key := range d {
+		name = key
+	}
+	for _, desc := range descs {
+		if o.avroName(desc) == name {
+			return desc, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected message %s", name)
+}
+
+// NewUnionMarshaler returns a new marshaler that writes protobuf messages of several types to writer in
+// Avro binary format, with the union schema returned by InferUnionSchema.
+func (o SchemaOptions) NewUnionMarshaler(
+	writer io.Writer,
+	descs ...protoreflect.MessageDescriptor,
+) (*UnionMarshaler, error) {
+	schema, err := o.InferUnionSchema(descs...)
+	if err != nil {
+		return nil, err
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal schema: %w", err)
+	}
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("new codec: %w", err)
+	}
+	metadata, err := o.ocfMetadata(descs...)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newOCFWriter(writer, codec, o.Compression, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("new ocf writer: %w", err)
+	}
+	m := &UnionMarshaler{opts: o.withNames(descs...), w: w, descs: make(map[protoreflect.FullName]struct{}, len(descs))}
+	for _, desc := range descs {
+		m.descs[desc.FullName()] = struct{}{}
+	}
+	return m, nil
+}
+
+// UnionMarshaler encodes and writes Avro binary encoded messages of several types.
+type UnionMarshaler struct {
+	opts  SchemaOptions
+	descs map[protoreflect.FullName]struct{}
+	w     *ocfWriter
+}
+
+// Marshal encodes and writes messages to the writer.
+func (m *UnionMarshaler) Marshal(messages ...proto.Message) error {
+	data := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		name := message.ProtoReflect().Descriptor().FullName()
+		if _, ok := m.descs[name]; !ok {
+			return fmt.Errorf("unexpected message '%s'", name)
+		}
+		value, err := m.opts.EncodeUnion(message)
+		if err != nil {
+			return err
+		}
+		data = append(data, value)
+	}
+	if err := m.w.Append(data); err != nil {
+		return fmt.Errorf("append: %w", err)
+	}
+	return nil
+}
+
+// NewUnionUnmarshaler returns a new unmarshaler that reads protobuf messages of several types from reader in
+// Avro binary format. The descriptors are the messages of the union.
+func (o SchemaOptions) NewUnionUnmarshaler(
+	reader io.Reader,
+	descs ...protoreflect.MessageDescriptor,
+) (*UnionUnmarshaler, error) {
+	u, err := o.withNames(descs...).NewUnmarshaler(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &UnionUnmarshaler{u: u, descs: descs}, nil
+}
+
+// UnionUnmarshaler reads and decodes Avro binary encoded messages of several types.
+type UnionUnmarshaler struct {
+	u     *Unmarshaler
+	descs []protoreflect.MessageDescriptor
+}
+
+// Scan returns true when there is at least one more
+// message to be read. Scan should be called prior to calling Unmarshal.
+func (m *UnionUnmarshaler) Scan() bool {
+	return m.u.Scan()
+}
+
+// Err returns the error that stopped scanning, if any.
+func (m *UnionUnmarshaler) Err() error {
+	return m.u.Err()
+}
+
+// Close stops the goroutines that decode blocks ahead of Unmarshal, see Unmarshaler.Close.
+func (m *UnionUnmarshaler) Close() error {
+	return m.u.Close()
+}
+
+// Unmarshal consumes one message from the reader and returns it as a message of the type of its union branch.
+func (m *UnionUnmarshaler) Unmarshal() (proto.Message, error) {
+	data, err := m.u.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read message: %w", err)
+	}
+	message, err := m.u.opts.DecodeUnion(data, m.descs...)
+	if err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	return message, nil
+}
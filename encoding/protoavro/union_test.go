@@ -0,0 +1,82 @@
+package protoavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestInferUnionSchema(t *testing.T) {
+	schema, err := InferUnionSchema(
+		(&library.Book{}).ProtoReflect().Descriptor(),
+		(&library.ListBooksResponse{}).ProtoReflect().Descriptor(),
+		(&examplev1.ExampleEnum{}).ProtoReflect().Descriptor(),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(schema), 3)
+	assert.Equal(t, schema[0].(avro.Record).Name, "Book")
+	assert.Equal(t, schema[1].(avro.Record).Name, "ListBooksResponse")
+	assert.Equal(t, schema[2].(avro.Record).Name, "ExampleEnum")
+
+	// assert that the book is defined once
+	schemaBytes, err := json.Marshal(schema)
+	assert.NilError(t, err)
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	assert.NilError(t, err)
+
+	for _, msg := range []proto.Message{
+		&library.Book{Name: "shelves/1/books/1", Title: "Harry Potter"},
+		&library.ListBooksResponse{Books: []*library.Book{{Title: "Harry Potter"}}},
+		&examplev1.ExampleEnum{EnumValue: examplev1.ExampleEnum_ENUM_VALUE2},
+	} {
+		encoded, err := SchemaOptions{}.EncodeUnion(msg)
+		assert.NilError(t, err)
+		_, err = codec.BinaryFromNative(nil, encoded)
+		assert.NilError(t, err)
+	}
+}
+
+func TestInferUnionSchema_Error(t *testing.T) {
+	book := (&library.Book{}).ProtoReflect().Descriptor()
+	_, err := InferUnionSchema()
+	assert.ErrorContains(t, err, "no messages")
+	_, err = InferUnionSchema(book, book)
+	assert.ErrorContains(t, err, "duplicate message google.example.library.v1.Book")
+}
+
+func TestUnionMarshaler(t *testing.T) {
+	descs := []protoreflect.MessageDescriptor{
+		(&library.Book{}).ProtoReflect().Descriptor(),
+		(&library.Shelf{}).ProtoReflect().Descriptor(),
+	}
+	msgs := []proto.Message{
+		&library.Book{Name: "shelves/1/books/1", Title: "Harry Potter"},
+		&library.Shelf{Name: "shelves/1", Theme: "Fantasy"},
+		&library.Book{Name: "shelves/1/books/2", Title: "The Hobbit"},
+	}
+	var b bytes.Buffer
+	marshaler, err := SchemaOptions{}.NewUnionMarshaler(&b, descs...)
+	assert.NilError(t, err)
+	assert.NilError(t, marshaler.Marshal(msgs...))
+	assert.ErrorContains(t, marshaler.Marshal(&examplev1.ExampleEnum{}), "unexpected message")
+
+	unmarshaler, err := SchemaOptions{}.NewUnionUnmarshaler(&b, descs...)
+	assert.NilError(t, err)
+	got := make([]proto.Message, 0, len(msgs))
+	for unmarshaler.Scan() {
+		msg, err := unmarshaler.Unmarshal()
+		assert.NilError(t, err)
+		got = append(got, msg)
+	}
+	assert.NilError(t, unmarshaler.Err())
+	assert.DeepEqual(t, msgs, got, protocmp.Transform())
+}
@@ -0,0 +1,255 @@
+package protoavro
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// UnknownFieldsNumber is the field number of the unknown field that record fields without a matching message
+// field are preserved in, when SchemaOptions.PreserveUnknownFields is set.
+const UnknownFieldsNumber protowire.Number = protowire.MaxValidNumber
+
+// preserveUnknownFields stores the record fields without a matching message field in the unknown fields of msg.
+// The native values of the fields are stored exactly, see appendNative.
+func preserveUnknownFields(msg protoreflect.Message, fields map[string]interface{}) error {
+	data, err := appendNative(nil, fields)
+	if err != nil {
+		return fmt.Errorf("preserve unknown fields: %w", err)
+	}
+	raw := protowire.AppendTag(nil, UnknownFieldsNumber, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, data)
+	msg.SetUnknown(append(msg.GetUnknown(), raw...))
+	return nil
+}
+
+// restoreUnknownFields adds the record fields preserved in the unknown fields of msg to record.
+func restoreUnknownFields(msg protoreflect.Message, record map[string]interface{}) error {
+	b := msg.GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("restore unknown fields: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+		if num != UnknownFieldsNumber || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return fmt.Errorf("restore unknown fields: %w", protowire.ParseError(n))
+			}
+			b = b[n:]
+			continue
+		}
+		data, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return fmt.Errorf("restore unknown fields: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+		value, rest, err := consumeNative(data)
+		if err != nil {
+			return fmt.Errorf("restore unknown fields: %w", err)
+		}
+		fields, ok := value.(map[string]interface{})
+		if !ok || len(rest) > 0 {
+			return fmt.Errorf("restore unknown fields: invalid preserved fields")
+		}
+		for name, value := range fields {
+			if _, ok := record[name]; !ok {
+				record[name] = value
+			}
+		}
+	}
+	return nil
+}
+
+// Tags of the native values of preserved fields, see appendNative.
+const (
+	nativeNull byte = iota
+	nativeBoolean
+	nativeInt
+	nativeLong
+	nativeFloat
+	nativeDouble
+	nativeBytes
+	nativeString
+	nativeArray
+	nativeMap
+	nativeTime
+	nativeDuration
+	nativeDecimal
+)
+
+// appendNative appends the encoding of a native goavro value to b. Values are encoded as a tag, followed by the
+// value, so that they are decoded with the same Go type, unlike with a JSON round-trip. Map entries are encoded
+// in the order of their keys, so that the encoding is deterministic.
+func appendNative(b []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, nativeNull), nil
+	case bool:
+		b = append(b, nativeBoolean)
+		return protowire.AppendVarint(b, protowire.EncodeBool(v)), nil
+	case int32:
+		b = append(b, nativeInt)
+		return protowire.AppendVarint(b, protowire.EncodeZigZag(int64(v))), nil
+	case int64:
+		b = append(b, nativeLong)
+		return protowire.AppendVarint(b, protowire.EncodeZigZag(v)), nil
+	case float32:
+		b = append(b, nativeFloat)
+		return protowire.AppendFixed32(b, math.Float32bits(v)), nil
+	case float64:
+		b = append(b, nativeDouble)
+		return protowire.AppendFixed64(b, math.Float64bits(v)), nil
+	case []byte:
+		b = append(b, nativeBytes)
+		return protowire.AppendBytes(b, v), nil
+	case string:
+		b = append(b, nativeString)
+		return protowire.AppendString(b, v), nil
+	case []interface{}:
+		b = append(b, nativeArray)
+		b = protowire.AppendVarint(b, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			if b, err = appendNative(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		b = append(b, nativeMap)
+		b = protowire.AppendVarint(b, uint64(len(v)))
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			b = protowire.AppendString(b, key)
+			var err error
+			if b, err = appendNative(b, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case time.Time:
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, nativeTime)
+		return protowire.AppendBytes(b, data), nil
+	case time.Duration:
+		b = append(b, nativeDuration)
+		return protowire.AppendVarint(b, protowire.EncodeZigZag(int64(v))), nil
+	case *big.Rat:
+		b = append(b, nativeDecimal)
+		return protowire.AppendString(b, v.String()), nil
+	}
+	return nil, fmt.Errorf("unsupported native value of type %T", value)
+}
+
+// consumeNative decodes a native goavro value encoded by appendNative, and returns the remaining bytes.
+func consumeNative(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	tag, b := b[0], b[1:]
+	switch tag {
+	case nativeNull:
+		return nil, b, nil
+	case nativeBoolean, nativeInt, nativeLong, nativeDuration:
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		switch tag {
+		case nativeBoolean:
+			return protowire.DecodeBool(v), b[n:], nil
+		case nativeInt:
+			return int32(protowire.DecodeZigZag(v)), b[n:], nil
+		case nativeLong:
+			return protowire.DecodeZigZag(v), b[n:], nil
+		default:
+			return time.Duration(protowire.DecodeZigZag(v)), b[n:], nil
+		}
+	case nativeFloat:
+		v, n := protowire.ConsumeFixed32(b)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		return math.Float32frombits(v), b[n:], nil
+	case nativeDouble:
+		v, n := protowire.ConsumeFixed64(b)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		return math.Float64frombits(v), b[n:], nil
+	case nativeBytes, nativeString, nativeTime, nativeDecimal:
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		switch tag {
+		case nativeBytes:
+			return append([]byte{}, v...), b[n:], nil
+		case nativeString:
+			return string(v), b[n:], nil
+		case nativeTime:
+			var t time.Time
+			if err := t.UnmarshalBinary(v); err != nil {
+				return nil, nil, err
+			}
+			return t, b[n:], nil
+		default:
+			r, ok := new(big.Rat).SetString(string(v))
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid decimal %q", v)
+			}
+			return r, b[n:], nil
+		}
+	case nativeArray, nativeMap:
+		length, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if length > uint64(len(b)) {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		if tag == nativeArray {
+			items := make([]interface{}, 0, length)
+			for i := uint64(0); i < length; i++ {
+				item, rest, err := consumeNative(b)
+				if err != nil {
+					return nil, nil, err
+				}
+				items = append(items, item)
+				b = rest
+			}
+			return items, b, nil
+		}
+		entries := make(map[string]interface{}, length)
+		for i := uint64(0); i < length; i++ {
+			key, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return nil, nil, protowire.ParseError(n)
+			}
+			value, rest, err := consumeNative(b[n:])
+			if err != nil {
+				return nil, nil, err
+			}
+			entries[key] = value
+			b = rest
+		}
+		return entries, b, nil
+	}
+	return nil, nil, fmt.Errorf("unknown native value tag %d", tag)
+}
@@ -0,0 +1,85 @@
+package protoavro
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func Test_PreserveUnknownFields(t *testing.T) {
+	opts := SchemaOptions{PreserveUnknownFields: true}
+	// a record written from a newer version of the message
+	data := map[string]interface{}{
+		"name":      map[string]interface{}{"string": "shelves/1/books/1"},
+		"title":     map[string]interface{}{"string": "Harry Potter"},
+		"publisher": map[string]interface{}{"string": "Bloomsbury"},
+		"pages":     map[string]interface{}{"long": int64(223)},
+		"cover":     map[string]interface{}{"bytes": []byte{0xff, 0x00, 0xd8}},
+	}
+	decoded := &library.Book{}
+	assert.NilError(t, opts.decodeJSON(data, decoded))
+	assert.DeepEqual(
+		t,
+		&library.Book{Name: "shelves/1/books/1", Title: "Harry Potter"},
+		decoded,
+		protocmp.Transform(),
+		protocmp.IgnoreUnknown(),
+	)
+
+	// the preserved fields survive a round-trip through the binary proto encoding
+	b, err := proto.Marshal(decoded)
+	assert.NilError(t, err)
+	proxied := &library.Book{}
+	assert.NilError(t, proto.Unmarshal(b, proxied))
+
+	encoded, err := opts.encodeJSON(proxied)
+	assert.NilError(t, err)
+	record := encoded.(map[string]interface{})["google.example.library.v1.Book"].(map[string]interface{})
+	assert.DeepEqual(t, record["publisher"], data["publisher"])
+	assert.DeepEqual(t, record["pages"], data["pages"])
+	assert.DeepEqual(t, record["cover"], data["cover"])
+
+	// without the option, unknown fields are neither decoded nor encoded
+	var defaultOpts SchemaOptions
+	assert.ErrorContains(t, defaultOpts.decodeJSON(data, &library.Book{}), "unexpected field")
+	encoded, err = defaultOpts.encodeJSON(proxied)
+	assert.NilError(t, err)
+	record = encoded.(map[string]interface{})["google.example.library.v1.Book"].(map[string]interface{})
+	_, ok := record["publisher"]
+	assert.Assert(t, !ok)
+}
+
+func Test_AppendNative(t *testing.T) {
+	for _, value := range []interface{}{
+		nil,
+		true,
+		int32(-7),
+		int64(math.MaxInt64),
+		float32(1.5),
+		math.Inf(-1),
+		[]byte{0x00, 0xff},
+		"Harry Potter",
+		[]interface{}{int64(1), nil, "a"},
+		map[string]interface{}{"long": int64(-1), "map": map[string]interface{}{}},
+		time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC),
+		90 * time.Second,
+		big.NewRat(-12345, 100),
+	} {
+		b, err := appendNative(nil, value)
+		assert.NilError(t, err)
+		got, rest, err := consumeNative(b)
+		assert.NilError(t, err)
+		assert.Equal(t, len(rest), 0)
+		assert.DeepEqual(t, value, got, cmp.Comparer(func(a, b *big.Rat) bool { return a.Cmp(b) == 0 }))
+	}
+	_, err := appendNative(nil, struct{}{})
+	assert.ErrorContains(t, err, "unsupported native value")
+}
@@ -1,19 +1,21 @@
 package protoavro
 
 import (
+	"context"
 	"fmt"
 	"io"
 
-	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // NewUnmarshaler returns a new unmarshaler that reads protobuf messages from reader in
 // Avro binary format.
 func NewUnmarshaler(reader io.Reader) (*Unmarshaler, error) {
-	r, err := goavro.NewOCFReader(reader)
+	r, err := newOCFReader(reader)
 	if err != nil {
-		return nil, fmt.Errorf("new ocf writer: %w", err)
+		return nil, fmt.Errorf("new ocf reader: %w", err)
 	}
 	return &Unmarshaler{r: r}, nil
 }
@@ -21,32 +23,106 @@ func NewUnmarshaler(reader io.Reader) (*Unmarshaler, error) {
 // NewUnmarshaler returns a new unmarshaler that reads protobuf messages from reader in
 // Avro binary format.
 func (o SchemaOptions) NewUnmarshaler(reader io.Reader) (*Unmarshaler, error) {
-	r, err := goavro.NewOCFReader(reader)
+	r, err := newOCFReader(reader)
 	if err != nil {
-		return nil, fmt.Errorf("new ocf writer: %w", err)
+		return nil, fmt.Errorf("new ocf reader: %w", err)
+	}
+	r.maxRecordBytes = o.MaxRecordBytes
+	if o.ReadParallelism > 1 {
+		r.decodeParallel(o.ReadParallelism)
+	}
+	if o.RecordFilter != nil {
+		r.filterRecords(o.RecordFilter)
 	}
 	return &Unmarshaler{opts: o, r: r}, nil
 }
 
 // Unmarshaler reads and decodes Avro binary encoded messages.
 type Unmarshaler struct {
-	opts SchemaOptions
-	r    *goavro.OCFReader
+	opts  SchemaOptions
+	r     *ocfReader
+	err   error
+	descs []protoreflect.MessageDescriptor
 }
 
 // Scan returns true when there is at least one more
 // message to be read. Scan should be called prior to calling Unmarshal.
 func (m *Unmarshaler) Scan() bool {
-	return m.r.Scan()
+	return m.ScanContext(context.Background())
+}
+
+// ScanContext returns true when there is at least one more message to be read, and the context is not done.
+// ScanContext should be called prior to calling Unmarshal.
+func (m *Unmarshaler) ScanContext(ctx context.Context) bool {
+	if err := ctx.Err(); err != nil {
+		m.err = err
+		return false
+	}
+	return m.r.Scan(ctx)
+}
+
+// Metadata returns the custom metadata of the header of the file, without the keys reserved by the Avro
+// specification, for example the metadata of a SchemaVersion.
+func (m *Unmarshaler) Metadata() map[string][]byte {
+	metadata := make(map[string][]byte, len(m.r.header.metadata))
+	for key, value := range m.r.header.metadata {
+		metadata[key] = append([]byte(nil), value...)
+	}
+	return metadata
+}
+
+// Fingerprint returns the CRC-64-AVRO (Rabin) fingerprint of the canonical form of the schema of the file.
+func (m *Unmarshaler) Fingerprint() uint64 {
+	return m.r.header.codec.Rabin
+}
+
+// Err returns the error that stopped scanning, if any.
+func (m *Unmarshaler) Err() error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.r.Err()
+}
+
+// Close stops the goroutines that decode blocks ahead of Unmarshal, when SchemaOptions.ReadParallelism is set.
+// Close must be called when the unmarshaler is discarded before all messages are read, and does not close the
+// underlying reader.
+func (m *Unmarshaler) Close() error {
+	m.r.Close()
+	return nil
+}
+
+// VerifyCompatible checks that the messages of the reader, written with the schema of the Object Container File,
+// can be decoded into messages described by desc. See SchemaOptions.VerifyCompatible.
+func (m *Unmarshaler) VerifyCompatible(desc protoreflect.MessageDescriptor) error {
+	writer, err := avro.Parse([]byte(m.r.Codec().Schema()))
+	if err != nil {
+		return fmt.Errorf("verify compatible: %w", err)
+	}
+	return m.opts.VerifyCompatible(writer, desc)
 }
 
 // Unmarshal consumes one message from the reader and places it in message.
 func (m *Unmarshaler) Unmarshal(message proto.Message) error {
+	return m.UnmarshalContext(context.Background(), message)
+}
+
+// UnmarshalContext consumes one message from the reader and places it in message.
+func (m *Unmarshaler) UnmarshalContext(ctx context.Context, message proto.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	data, err := m.r.Read()
 	if err != nil {
+		m.opts.decodeError(ctx, decodeErrorTypeRead, err)
 		return fmt.Errorf("read message: %w", err)
 	}
 	if err := m.opts.decodeJSON(data, message); err != nil {
+		m.opts.decodeError(ctx, decodeErrorTypeDecode, err)
+		// explain errors caused by a skew between the writer schema and the message
+		if compatibilityErr := m.VerifyCompatible(message.ProtoReflect().Descriptor()); compatibilityErr != nil {
+			return fmt.Errorf("decode message: %w: %w", err, compatibilityErr)
+		}
 		return fmt.Errorf("decode message: %w", err)
 	}
 	return nil
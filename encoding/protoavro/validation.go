@@ -0,0 +1,287 @@
+package protoavro
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// OptionsError is returned by SchemaOptions.Validate, with every problem of the options.
+type OptionsError struct {
+	// Problems describe the invalid options, and the options that have no effect combined with other options.
+	Problems []string
+}
+
+// Error implements error.
+func (e *OptionsError) Error() string {
+	return "invalid schema options: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the options, and returns an *OptionsError listing the options that are invalid, such as
+// negative limits, unknown strategies and unregistered compression codecs, and the options that have no effect
+// combined with other options, such as TimestampLocation without TimestampString, so that misconfigurations fail
+// fast instead of resulting in unexpected schemas later. Problems that depend on the messages, such as masks
+// and field transforms of unknown fields, are reported when schemas are inferred. Options returned by Normalize
+// only fail validation for invalid options.
+func (o SchemaOptions) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	if o.NullListItems < NullListItemsZero || o.NullListItems > NullListItemsError {
+		add("unknown NullListItems %d", o.NullListItems)
+	}
+	if o.NullMapValues < NullMapValuesZero || o.NullMapValues > NullMapValuesError {
+		add("unknown NullMapValues %d", o.NullMapValues)
+	}
+	if o.AvroJSONMode < AvroJSONGoavro || o.AvroJSONMode > AvroJSONJava {
+		add("unknown AvroJSONMode %d", o.AvroJSONMode)
+	}
+	fixedFields := make([]protoreflect.FullName, 0, len(o.FixedSizes))
+	for field := range o.FixedSizes {
+		fixedFields = append(fixedFields, field)
+	}
+	sort.Slice(fixedFields, func(i, j int) bool {
+		return fixedFields[i] < fixedFields[j]
+	})
+	for _, field := range fixedFields {
+		if size := o.FixedSizes[field]; size < 0 {
+			add("negative FixedSizes %d of field %s", size, field)
+		}
+	}
+	if o.TimestampPrecision < TimestampMicros || o.TimestampPrecision > TimestampNanos {
+		add("unknown TimestampPrecision %d", o.TimestampPrecision)
+	}
+	if o.TimestampRounding < TimestampTruncate || o.TimestampRounding > TimestampRoundNearest {
+		add("unknown TimestampRounding %d", o.TimestampRounding)
+	}
+	if o.TimestampEncoding < TimestampLong || o.TimestampEncoding > TimestampString {
+		add("unknown TimestampEncoding %d", o.TimestampEncoding)
+	}
+	if o.TimestampLocation != nil && o.timestampEncoding() != TimestampString {
+		add("TimestampLocation has no effect without TimestampString")
+	}
+	if o.DurationEncoding < DurationSeconds || o.DurationEncoding > DurationString {
+		add("unknown DurationEncoding %d", o.DurationEncoding)
+	}
+	if o.DurationUnit < 0 {
+		add("negative DurationUnit %s", o.DurationUnit)
+	}
+	if o.DurationUnit > 0 && o.durationEncoding() != DurationLong {
+		add("DurationUnit has no effect without DurationLong")
+	}
+	problems = append(problems, o.WKTMappings.problems()...)
+	if o.TimestampEncoding != TimestampLong && o.WKTMappings.Timestamp != WKTDefault {
+		add("TimestampEncoding has no effect with WKTMappings.Timestamp")
+	}
+	if o.DurationEncoding != DurationSeconds && o.WKTMappings.Duration != WKTDefault {
+		add("DurationEncoding has no effect with WKTMappings.Duration")
+	}
+	if o.StripNamespaces && o.NamespaceFunc != nil {
+		add("NamespaceFunc has no effect with StripNamespaces")
+	}
+	if o.TrimNamespacePrefix != "" && (o.StripNamespaces || o.NamespaceFunc != nil) {
+		add("TrimNamespacePrefix has no effect with StripNamespaces or NamespaceFunc")
+	}
+	if o.RecursionStrategy < RecursionReference || o.RecursionStrategy > RecursionJSONString {
+		add("unknown RecursionStrategy %d", o.RecursionStrategy)
+	}
+	if o.MaxRecursionDepth < 0 {
+		add("negative MaxRecursionDepth %d", o.MaxRecursionDepth)
+	}
+	if o.MaxRecursionDepth > 0 && o.RecursionStrategy != RecursionDepthLimit {
+		add("MaxRecursionDepth has no effect without RecursionDepthLimit")
+	}
+	if o.MaxDocLength < 0 {
+		add("negative MaxDocLength %d", o.MaxDocLength)
+	}
+	if o.OmitDocs && (o.DocFunc != nil || o.MaxDocLength > 0) {
+		add("DocFunc and MaxDocLength have no effect with OmitDocs")
+	}
+	if o.FlattenDepth < 0 {
+		add("negative FlattenDepth %d", o.FlattenDepth)
+	}
+	if o.FlattenSeparator != "" && validAvroName("_"+o.FlattenSeparator) != "_"+o.FlattenSeparator {
+		add("FlattenSeparator %q is not valid in Avro names", o.FlattenSeparator)
+	}
+	if o.FlattenSeparator != "" && o.FlattenDepth <= 0 {
+		add("FlattenSeparator has no effect without FlattenDepth")
+	}
+	envelopeFields := make(map[string]struct{}, len(o.EnvelopeFields))
+	for _, field := range o.envelopeFields() {
+		switch {
+		case field.Name == "":
+			add("envelope field without name")
+		case validAvroName(field.Name) != field.Name:
+			add("envelope field %s is not a valid Avro name", field.Name)
+		}
+		if _, ok := envelopeFields[field.Name]; ok {
+			add("envelope field %s is defined more than once", field.Name)
+		}
+		envelopeFields[field.Name] = struct{}{}
+		if field.Schema == nil || field.Value == nil {
+			add("envelope field %s without schema or value", field.Name)
+		}
+	}
+	for _, transform := range o.FieldTransforms {
+		name := transform.Message + "." + transform.Field
+		if transform.Message == "" || transform.Field == "" {
+			add("field transform %s without message or field", name)
+		}
+		if transform.Redact && transform.Expression != "" {
+			add("field transform %s: expression of redacted field", name)
+		}
+		if transform.Type != "" {
+			if _, ok := derivedFieldTypes[transform.Type]; !ok {
+				add("field transform %s: unsupported type '%s' of derived field", name, transform.Type)
+			}
+			if transform.Expression == "" {
+				add("field transform %s: derived field without expression", name)
+			}
+		}
+		if transform.Tokenize != "" {
+			if transform.Tokenize != TokenizeHMAC && transform.Tokenize != TokenizeFormatHash {
+				add("field transform %s: unsupported tokenization '%s'", name, transform.Tokenize)
+			}
+			if transform.Redact || transform.Expression != "" {
+				add("field transform %s: tokenization of redacted or computed field", name)
+			}
+			if o.Tokenizer == nil || len(o.Tokenizer.Keys) == 0 {
+				add("field transform %s: %v", name, errNoTokenizationKeys)
+			}
+		}
+	}
+	if o.Tokenizer != nil {
+		tokenizationKeys := make(map[string]struct{}, len(o.Tokenizer.Keys))
+		for _, key := range o.Tokenizer.Keys {
+			if key.ID == "" || len(key.Secret) == 0 {
+				add("Tokenizer key %q without ID or secret", key.ID)
+			}
+			if _, ok := tokenizationKeys[key.ID]; ok {
+				add("Tokenizer key %q is defined more than once", key.ID)
+			}
+			tokenizationKeys[key.ID] = struct{}{}
+		}
+	}
+	if o.MaxRecordBytes < 0 {
+		add("negative MaxRecordBytes %d", o.MaxRecordBytes)
+	}
+	if o.MaxNestingDepth < 0 {
+		add("negative MaxNestingDepth %d", o.MaxNestingDepth)
+	}
+	if o.Workers < 0 {
+		add("negative Workers %d", o.Workers)
+	}
+	if o.ReadParallelism < 0 {
+		add("negative ReadParallelism %d", o.ReadParallelism)
+	}
+	if _, err := lookupCompressionCodec(o.Compression); err != nil {
+		add("Compression: %v", err)
+	}
+	if _, err := lookupCompressionCodec(o.RecordCompression); err != nil {
+		add("RecordCompression: %v", err)
+	}
+	if len(o.RecordCompression) > math.MaxUint8 {
+		add("RecordCompression: name of compression codec %s is too long", o.RecordCompression)
+	}
+	metadataKeys := make([]string, 0, len(o.OCFMetadata))
+	for key := range o.OCFMetadata {
+		metadataKeys = append(metadataKeys, key)
+	}
+	sort.Strings(metadataKeys)
+	for _, key := range metadataKeys {
+		if strings.HasPrefix(key, ocfReservedPrefix) {
+			add("OCFMetadata key %s: the prefix %s is reserved", key, ocfReservedPrefix)
+		}
+	}
+	if o.EncryptedFieldFunc != nil && o.FieldCipher == nil {
+		add("EncryptedFieldFunc without FieldCipher")
+	}
+	if o.FieldCipher != nil && o.EncryptedFieldFunc == nil {
+		add("FieldCipher has no effect without EncryptedFieldFunc")
+	}
+	if o.CompressDescriptor && !o.EmbedDescriptor {
+		add("CompressDescriptor has no effect without EmbedDescriptor")
+	}
+	if len(problems) > 0 {
+		return &OptionsError{Problems: problems}
+	}
+	return nil
+}
+
+// Normalize returns a copy of the options as they are applied, with the defaults of unset options resolved,
+// such as the number of Workers and the unit of durations encoded as longs, and with options that have no
+// effect combined with other options unset, such as DocFunc with OmitDocs. Messages are encoded and decoded
+// the same with the options and with the normalized options.
+func (o SchemaOptions) Normalize() SchemaOptions {
+	o = o.clone()
+	if o.timestampEncoding() == TimestampString {
+		if o.TimestampLocation == nil {
+			o.TimestampLocation = time.UTC
+		}
+	} else {
+		o.TimestampLocation = nil
+	}
+	if o.WKTMappings.Timestamp != WKTDefault {
+		o.TimestampEncoding = TimestampLong
+	}
+	if o.WKTMappings.Duration != WKTDefault {
+		o.DurationEncoding = DurationSeconds
+	}
+	if o.durationEncoding() == DurationLong {
+		o.DurationUnit = o.durationUnit()
+	} else {
+		o.DurationUnit = 0
+	}
+	if o.StripNamespaces {
+		o.NamespaceFunc = nil
+	}
+	if o.StripNamespaces || o.NamespaceFunc != nil {
+		o.TrimNamespacePrefix = ""
+	}
+	if o.RecursionStrategy != RecursionDepthLimit {
+		o.MaxRecursionDepth = 0
+	}
+	if o.OmitDocs || o.MaxDocLength < 0 {
+		o.MaxDocLength = 0
+	}
+	if o.OmitDocs {
+		o.DocFunc = nil
+	}
+	if o.FlattenDepth <= 0 {
+		o.FlattenDepth = 0
+		o.FlattenSeparator = ""
+	} else if o.FlattenSeparator == "" {
+		o.FlattenSeparator = "_"
+	}
+	if o.MaxRecordBytes < 0 {
+		o.MaxRecordBytes = 0
+	}
+	if o.MaxNestingDepth < 0 {
+		o.MaxNestingDepth = 0
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.GOMAXPROCS(0)
+	}
+	if o.ReadParallelism <= 0 {
+		o.ReadParallelism = 1
+	}
+	if o.Compression == "" {
+		o.Compression = CompressionNull
+	}
+	if o.RecordCompression == "" {
+		o.RecordCompression = CompressionNull
+	}
+	if !o.EmbedDescriptor {
+		o.CompressDescriptor = false
+	}
+	if o.EncryptedFieldFunc == nil {
+		o.FieldCipher = nil
+	}
+	return o
+}
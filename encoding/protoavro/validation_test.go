@@ -0,0 +1,232 @@
+package protoavro
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gotest.tools/v3/assert"
+)
+
+func TestSchemaOptions_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		opts     SchemaOptions
+		problems []string
+	}{
+		{
+			name: "zero",
+		},
+		{
+			name: "valid",
+			opts: SchemaOptions{
+				TimestampEncoding:  TimestampString,
+				TimestampLocation:  time.UTC,
+				DurationEncoding:   DurationLong,
+				DurationUnit:       time.Millisecond,
+				RecursionStrategy:  RecursionDepthLimit,
+				MaxRecursionDepth:  2,
+				Compression:        CompressionSnappy,
+				EmbedDescriptor:    true,
+				CompressDescriptor: true,
+				OCFMetadata:        map[string][]byte{"owner": []byte("team-x")},
+			},
+		},
+		{
+			name: "invalid",
+			opts: SchemaOptions{
+				NullListItems:     NullListItems(3),
+				AvroJSONMode:      AvroJSONMode(3),
+				FixedSizes:        map[protoreflect.FullName]int{"example.v1.Book.hash": -1},
+				TimestampEncoding: TimestampEncoding(-1),
+				RecursionStrategy: RecursionStrategy(4),
+				FlattenDepth:      1,
+				FlattenSeparator:  ".",
+				MaxNestingDepth:   -1,
+				Workers:           -2,
+				Compression:       "brotli",
+				OCFMetadata:       map[string][]byte{"avro.schema": nil},
+			},
+			problems: []string{
+				"unknown NullListItems 3",
+				"unknown AvroJSONMode 3",
+				"negative FixedSizes -1 of field example.v1.Book.hash",
+				"unknown TimestampEncoding -1",
+				"unknown RecursionStrategy 4",
+				`FlattenSeparator "." is not valid in Avro names`,
+				"negative MaxNestingDepth -1",
+				"negative Workers -2",
+				"Compression: compression codec brotli is not registered, see RegisterCompressionCodec",
+				"OCFMetadata key avro.schema: the prefix avro. is reserved",
+			},
+		},
+		{
+			name: "no effect",
+			opts: SchemaOptions{
+				TimestampLocation:   time.UTC,
+				DurationUnit:        time.Second,
+				StripNamespaces:     true,
+				NamespaceFunc:       func(protoreflect.Descriptor) string { return "" },
+				TrimNamespacePrefix: "einride",
+				MaxRecursionDepth:   3,
+				OmitDocs:            true,
+				MaxDocLength:        80,
+				CompressDescriptor:  true,
+				FlattenSeparator:    "__",
+			},
+			problems: []string{
+				"TimestampLocation has no effect without TimestampString",
+				"DurationUnit has no effect without DurationLong",
+				"NamespaceFunc has no effect with StripNamespaces",
+				"TrimNamespacePrefix has no effect with StripNamespaces or NamespaceFunc",
+				"MaxRecursionDepth has no effect without RecursionDepthLimit",
+				"DocFunc and MaxDocLength have no effect with OmitDocs",
+				"FlattenSeparator has no effect without FlattenDepth",
+				"CompressDescriptor has no effect without EmbedDescriptor",
+			},
+		},
+		{
+			name: "wkt mappings",
+			opts: SchemaOptions{
+				TimestampEncoding: TimestampString,
+				DurationEncoding:  DurationLong,
+				WKTMappings: WKTMappings{
+					Timestamp: WKTRecord,
+					Duration:  WKTString,
+					Struct:    WKTLogicalType,
+					Any:       WKTMapping(5),
+					Wrappers:  WKTString,
+				},
+			},
+			problems: []string{
+				"WKTMappings.Struct: unsupported mapping logical type",
+				"unknown WKTMappings.Any 5",
+				"WKTMappings.Wrappers: unsupported mapping string",
+				"TimestampEncoding has no effect with WKTMappings.Timestamp",
+				"DurationEncoding has no effect with WKTMappings.Duration",
+			},
+		},
+		{
+			name: "encryption",
+			opts: SchemaOptions{
+				EncryptedFieldFunc: func(protoreflect.FieldDescriptor) string { return "" },
+			},
+			problems: []string{"EncryptedFieldFunc without FieldCipher"},
+		},
+		{
+			name:     "field cipher",
+			opts:     SchemaOptions{FieldCipher: xorCipher{}},
+			problems: []string{"FieldCipher has no effect without EncryptedFieldFunc"},
+		},
+		{
+			name: "envelope fields and field transforms",
+			opts: SchemaOptions{
+				RawProto: true,
+				EnvelopeFields: []EnvelopeField{
+					{Name: "ingest-time", Schema: avro.Long(), Value: func(proto.Message) (interface{}, error) {
+						return int64(0), nil
+					}},
+					{Name: RawProtoField},
+				},
+				FieldTransforms: []FieldTransform{
+					{Message: "google.example.library.v1.Book", Field: "title", Redact: true, Expression: "msg.title"},
+					{Message: "google.example.library.v1.Book", Field: "pages", Type: "uint"},
+				},
+			},
+			problems: []string{
+				"envelope field ingest-time is not a valid Avro name",
+				"envelope field _raw_proto without schema or value",
+				"envelope field _raw_proto is defined more than once",
+				"field transform google.example.library.v1.Book.title: expression of redacted field",
+				"field transform google.example.library.v1.Book.pages: unsupported type 'uint' of derived field",
+				"field transform google.example.library.v1.Book.pages: derived field without expression",
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if len(tt.problems) == 0 {
+				assert.NilError(t, err)
+				return
+			}
+			var optionsErr *OptionsError
+			assert.Assert(t, errors.As(err, &optionsErr), err)
+			assert.DeepEqual(t, tt.problems, optionsErr.Problems)
+		})
+	}
+}
+
+func TestSchemaOptions_Normalize(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		normalized := SchemaOptions{TimestampEncoding: TimestampString, DurationEncoding: DurationLong}.Normalize()
+		assert.Equal(t, time.UTC, normalized.TimestampLocation)
+		assert.Equal(t, time.Microsecond, normalized.DurationUnit)
+		assert.Equal(t, runtime.GOMAXPROCS(0), normalized.Workers)
+		assert.Equal(t, 1, normalized.ReadParallelism)
+		assert.Equal(t, CompressionNull, normalized.Compression)
+		assert.Equal(t, CompressionNull, normalized.RecordCompression)
+		assert.NilError(t, normalized.Validate())
+	})
+
+	t.Run("no effect", func(t *testing.T) {
+		opts := SchemaOptions{
+			TimestampLocation:   time.UTC,
+			DurationUnit:        time.Second,
+			StripNamespaces:     true,
+			TrimNamespacePrefix: "einride",
+			MaxRecursionDepth:   3,
+			OmitDocs:            true,
+			MaxDocLength:        80,
+			CompressDescriptor:  true,
+			Workers:             -1,
+		}
+		assert.Assert(t, opts.Validate() != nil)
+		normalized := opts.Normalize()
+		assert.NilError(t, normalized.Validate())
+		assert.Assert(t, normalized.TimestampLocation == nil)
+		assert.Equal(t, time.Duration(0), normalized.DurationUnit)
+		assert.Equal(t, "", normalized.TrimNamespacePrefix)
+		assert.Equal(t, 0, normalized.MaxRecursionDepth)
+		assert.Equal(t, 0, normalized.MaxDocLength)
+		assert.Equal(t, runtime.GOMAXPROCS(0), normalized.Workers)
+		// messages are encoded the same
+		book := &library.Book{Name: "shelves/1/books/1", Title: "Dune"}
+		expected, err := opts.Encode(book)
+		assert.NilError(t, err)
+		actual, err := normalized.Encode(book)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, expected, actual)
+		expectedSchema, err := opts.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		actualSchema, err := normalized.InferSchema(book.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, expectedSchema, actualSchema)
+	})
+
+	t.Run("wkt mappings", func(t *testing.T) {
+		opts := SchemaOptions{
+			TimestampEncoding: TimestampLong,
+			DurationEncoding:  DurationFixed,
+			DurationUnit:      time.Second,
+			WKTMappings:       WKTMappings{Timestamp: WKTString, Duration: WKTRecord},
+		}
+		normalized := opts.Normalize()
+		assert.NilError(t, normalized.Validate())
+		assert.Equal(t, time.UTC, normalized.TimestampLocation)
+		assert.Equal(t, DurationSeconds, normalized.DurationEncoding)
+		assert.Equal(t, time.Duration(0), normalized.DurationUnit)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		opts := SchemaOptions{FixedSizes: map[protoreflect.FullName]int{"example.v1.Book.hash": 16}}
+		normalized := opts.Normalize()
+		normalized.FixedSizes["example.v1.Book.hash"] = 32
+		assert.Equal(t, 16, opts.FixedSizes["example.v1.Book.hash"])
+	})
+}
@@ -0,0 +1,113 @@
+package protoavro
+
+import (
+	"go.einride.tech/protobuf-avro/avro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// InferFieldSchema returns the Avro schema of a single field, as it appears in the record of its message.
+func (o SchemaOptions) InferFieldSchema(field protoreflect.FieldDescriptor) (avro.Schema, error) {
+	o = o.withNames(field.ContainingMessage())
+	fieldSchema, err := o.newSchemaInferrer().inferField(field, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	return avro.Nullable(fieldSchema.Type), nil
+}
+
+// EncodeValue returns the Avro JSON encoding, with default SchemaOptions, of a single field value.
+// See SchemaOptions.EncodeValue.
+func EncodeValue(field protoreflect.FieldDescriptor, value protoreflect.Value) (interface{}, error) {
+	return SchemaOptions{}.EncodeValue(field, value)
+}
+
+// EncodeValue returns the Avro JSON encoding of a single field value, matching the schema returned by
+// InferFieldSchema. The value must be of the type returned by protoreflect.Message.Get for the field.
+func (o SchemaOptions) EncodeValue(field protoreflect.FieldDescriptor, value protoreflect.Value) (interface{}, error) {
+	o = o.withNames(field.ContainingMessage())
+	if o.encodesJSONString(field) {
+		parent := newMessage(field.ContainingMessage())
+		parent.Set(field, value)
+		if !parent.Has(field) {
+			return nil, nil
+		}
+		jsonValue, err := encodeJSONString(parent, field)
+		if err != nil {
+			return nil, err
+		}
+		return o.unionValue("string", jsonValue), nil
+	}
+	if o.encryptsField(field) {
+		parent := newMessage(field.ContainingMessage())
+		parent.Set(field, value)
+		if !parent.Has(field) {
+			return nil, nil
+		}
+		ciphertext, err := o.encryptField(parent, field)
+		if err != nil {
+			return nil, err
+		}
+		return o.unionValue("bytes", ciphertext), nil
+	}
+	return o.fieldJSON(field, value, 1, nil)
+}
+
+// DecodeValue decodes, with default SchemaOptions, the Avro JSON encoding of a single field value.
+// See SchemaOptions.DecodeValue.
+func DecodeValue(field protoreflect.FieldDescriptor, data interface{}) (protoreflect.Value, error) {
+	return SchemaOptions{}.DecodeValue(field, data)
+}
+
+// DecodeValue decodes the Avro JSON encoding of a single field value, as returned by EncodeValue.
+// Message values are of the type registered in protoregistry.GlobalTypes, or dynamic messages when the
+// message type is not registered.
+func (o SchemaOptions) DecodeValue(field protoreflect.FieldDescriptor, data interface{}) (protoreflect.Value, error) {
+	o = o.withNames(field.ContainingMessage())
+	parent := newMessage(field.ContainingMessage())
+	if err := o.decodeRecordField(data, parent, field, nil); err != nil {
+		return protoreflect.Value{}, err
+	}
+	return parent.Get(field), nil
+}
+
+// newMessage returns a new message of the type registered in protoregistry.GlobalTypes, or a dynamic message
+// when the message type is not registered.
+func newMessage(desc protoreflect.MessageDescriptor) protoreflect.Message {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName()); err == nil {
+		return mt.New()
+	}
+	return dynamicpb.NewMessage(desc)
+}
+
+// mergeMessage merges src into dst, where dst may be a dynamic message of a descriptor other than the
+// descriptor of src, for example of a descriptor embedded in a file, which proto.Merge does not support.
+func mergeMessage(dst protoreflect.Message, src proto.Message) error {
+	if dst.Descriptor() == src.ProtoReflect().Descriptor() {
+		proto.Merge(dst.Interface(), src)
+		return nil
+	}
+	data, err := proto.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return proto.UnmarshalOptions{Merge: true}.Unmarshal(data, dst.Interface())
+}
+
+// generatedMessage returns a dynamic message as a message of the type registered in protoregistry.GlobalTypes,
+// for example to encode dynamic messages of well-known types, which are encoded from their generated types.
+func generatedMessage(message protoreflect.Message) (protoreflect.Message, error) {
+	if _, ok := message.Interface().(*dynamicpb.Message); !ok {
+		return message, nil
+	}
+	generated := newMessage(message.Descriptor())
+	if _, ok := generated.Interface().(*dynamicpb.Message); ok {
+		return message, nil
+	}
+	if err := mergeMessage(generated, message.Interface()); err != nil {
+		return nil, err
+	}
+	return generated, nil
+}
@@ -0,0 +1,98 @@
+package protoavro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"go.einride.tech/protobuf-avro/avro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func Test_EncodeDecodeValue(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		msg   proto.Message
+		field protoreflect.Name
+	}{
+		{
+			name:  "string",
+			msg:   &library.Book{Author: "J. K. Rowling"},
+			field: "author",
+		},
+		{
+			name:  "bool",
+			msg:   &library.Book{Read: true},
+			field: "read",
+		},
+		{
+			name:  "list",
+			msg:   &examplev1.ExampleList{Int64List: []int64{1, 2, 3}},
+			field: "int64_list",
+		},
+		{
+			name: "list of messages",
+			msg: &examplev1.ExampleList{
+				NestedList: []*examplev1.ExampleList_Nested{{StringList: []string{"a"}}},
+			},
+			field: "nested_list",
+		},
+		{
+			name:  "list of wrappers",
+			msg:   &examplev1.ExampleList{FloatValueList: []*wrapperspb.FloatValue{wrapperspb.Float(1)}},
+			field: "float_value_list",
+		},
+		{
+			name: "map",
+			msg: &examplev1.ExampleMap{
+				StringToNested: map[string]*examplev1.ExampleMap_Nested{
+					"a": {StringToString: map[string]string{"b": "c"}},
+				},
+			},
+			field: "string_to_nested",
+		},
+		{
+			name:  "enum",
+			msg:   &examplev1.ExampleList{EnumList: []examplev1.ExampleList_Enum{examplev1.ExampleList_ENUM_VALUE2}},
+			field: "enum_list",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			field := tt.msg.ProtoReflect().Descriptor().Fields().ByName(tt.field)
+			value := tt.msg.ProtoReflect().Get(field)
+
+			schema, err := protoavro.SchemaOptions{}.InferFieldSchema(field)
+			assert.NilError(t, err)
+			encoded, err := protoavro.EncodeValue(field, value)
+			assert.NilError(t, err)
+
+			// assert that it matches schema
+			schemaBytes, err := json.Marshal(avro.Record{
+				Type:   avro.RecordType,
+				Name:   "Value",
+				Fields: []avro.Field{{Name: "value", Type: schema}},
+			})
+			assert.NilError(t, err)
+			codec, err := goavro.NewCodec(string(schemaBytes))
+			assert.NilError(t, err)
+			binary, err := codec.BinaryFromNative(nil, map[string]interface{}{"value": encoded})
+			assert.NilError(t, err)
+			native, _, err := codec.NativeFromBinary(binary)
+			assert.NilError(t, err)
+
+			decoded, err := protoavro.DecodeValue(field, native.(map[string]interface{})["value"])
+			assert.NilError(t, err)
+			got := tt.msg.ProtoReflect().New()
+			got.Set(field, decoded)
+			assert.DeepEqual(t, tt.msg, got.Interface(), protocmp.Transform())
+		})
+	}
+}
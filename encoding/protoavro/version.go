@@ -0,0 +1,134 @@
+package protoavro
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Keys of the metadata of a SchemaVersion.
+const (
+	SchemaVersionFingerprintKey  = "protoavro.fingerprint"
+	SchemaVersionProtoVersionKey = "protoavro.proto_version"
+	SchemaVersionCreatedKey      = "protoavro.created"
+)
+
+// SchemaVersion identifies the version of the schema of a message, to trace files and registered schemas back
+// to the protobuf sources they were written with.
+type SchemaVersion struct {
+	// Fingerprint is the CRC-64-AVRO (Rabin) fingerprint of the canonical form of the schema inferred for
+	// the message.
+	Fingerprint uint64
+	// ProtoVersion is the version of the protobuf file of the message, returned by ProtoVersionFunc, or the
+	// DescriptorHash of the file by default.
+	ProtoVersion string
+	// Created is the time the version was created, for example the start of the time partition of a file.
+	Created time.Time
+}
+
+// SchemaVersion returns the version of the schema of the message, created at the time.
+func (o SchemaOptions) SchemaVersion(desc protoreflect.MessageDescriptor, created time.Time) (SchemaVersion, error) {
+	codec, err := o.NewGoavroCodec(desc)
+	if err != nil {
+		return SchemaVersion{}, fmt.Errorf("schema version: %w", err)
+	}
+	version := SchemaVersion{Fingerprint: codec.Rabin, Created: created}
+	if o.ProtoVersionFunc != nil {
+		version.ProtoVersion = o.ProtoVersionFunc(desc.ParentFile())
+	} else {
+		version.ProtoVersion = DescriptorHash(desc.ParentFile())
+	}
+	return version, nil
+}
+
+// DescriptorHash returns the hex encoded SHA-256 hash of the file descriptor and the files it imports,
+// transitively, which changes with every change to the protobuf sources of the file, comments included.
+func DescriptorHash(file protoreflect.FileDescriptor) string {
+	hash := sha256.New()
+	for _, file := range fileDescriptorSet(file).GetFile() {
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(file)
+		if err != nil {
+			// file descriptor protos always marshal
+			panic(err)
+		}
+		hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// fileDescriptorSet returns the file descriptor and the files it imports, transitively, where files come after
+// the files they import.
+func fileDescriptorSet(file protoreflect.FileDescriptor) *descriptorpb.FileDescriptorSet {
+	var set descriptorpb.FileDescriptorSet
+	seen := make(map[string]struct{})
+	var collect func(file protoreflect.FileDescriptor)
+	collect = func(file protoreflect.FileDescriptor) {
+		if _, ok := seen[file.Path()]; ok {
+			return
+		}
+		seen[file.Path()] = struct{}{}
+		for i := 0; i < file.Imports().Len(); i++ {
+			collect(file.Imports().Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+	}
+	collect(file)
+	return &set
+}
+
+// Properties returns the version as string properties, for example the metadata of schemas registered with
+// registry.Client.RegisterWithMetadata.
+func (v SchemaVersion) Properties() map[string]string {
+	return map[string]string{
+		SchemaVersionFingerprintKey:  fmt.Sprintf("%016x", v.Fingerprint),
+		SchemaVersionProtoVersionKey: v.ProtoVersion,
+		SchemaVersionCreatedKey:      v.Created.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// OCFMetadata returns the version as metadata of Object Container Files, see SchemaOptions.OCFMetadata.
+func (v SchemaVersion) OCFMetadata() map[string][]byte {
+	properties := v.Properties()
+	metadata := make(map[string][]byte, len(properties))
+	for key, value := range properties {
+		metadata[key] = []byte(value)
+	}
+	return metadata
+}
+
+// ParseSchemaVersion parses a version from the properties returned by SchemaVersion.Properties.
+func ParseSchemaVersion(properties map[string]string) (SchemaVersion, error) {
+	var version SchemaVersion
+	for _, key := range []string{SchemaVersionFingerprintKey, SchemaVersionProtoVersionKey, SchemaVersionCreatedKey} {
+		if _, ok := properties[key]; !ok {
+			return SchemaVersion{}, fmt.Errorf("parse schema version: missing %s", key)
+		}
+	}
+	fingerprint, err := strconv.ParseUint(properties[SchemaVersionFingerprintKey], 16, 64)
+	if err != nil {
+		return SchemaVersion{}, fmt.Errorf("parse schema version: %s: %w", SchemaVersionFingerprintKey, err)
+	}
+	version.Fingerprint = fingerprint
+	version.ProtoVersion = properties[SchemaVersionProtoVersionKey]
+	if version.Created, err = time.Parse(time.RFC3339Nano, properties[SchemaVersionCreatedKey]); err != nil {
+		return SchemaVersion{}, fmt.Errorf("parse schema version: %s: %w", SchemaVersionCreatedKey, err)
+	}
+	return version, nil
+}
+
+// ParseSchemaVersionOCFMetadata parses a version from the metadata of an Object Container File, as returned
+// by Unmarshaler.Metadata.
+func ParseSchemaVersionOCFMetadata(metadata map[string][]byte) (SchemaVersion, error) {
+	properties := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		properties[key] = string(value)
+	}
+	return ParseSchemaVersion(properties)
+}
@@ -0,0 +1,68 @@
+package protoavro
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gotest.tools/v3/assert"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	created := time.Date(2024, 1, 2, 15, 4, 5, 6, time.UTC)
+	version, err := SchemaOptions{}.SchemaVersion(desc, created)
+	assert.NilError(t, err)
+	codec, err := NewGoavroCodec(desc)
+	assert.NilError(t, err)
+	assert.Equal(t, codec.Rabin, version.Fingerprint)
+	assert.Equal(t, DescriptorHash(desc.ParentFile()), version.ProtoVersion)
+	assert.Equal(t, 64, len(version.ProtoVersion))
+	assert.Equal(t, created, version.Created)
+
+	t.Run("proto version func", func(t *testing.T) {
+		opts := SchemaOptions{ProtoVersionFunc: func(file protoreflect.FileDescriptor) string {
+			return "commit-" + file.Path()
+		}}
+		version, err := opts.SchemaVersion(desc, created)
+		assert.NilError(t, err)
+		assert.Equal(t, "commit-google/example/library/v1/library.proto", version.ProtoVersion)
+	})
+
+	t.Run("properties", func(t *testing.T) {
+		properties := version.Properties()
+		assert.Equal(t, "2024-01-02T15:04:05.000000006Z", properties[SchemaVersionCreatedKey])
+		parsed, err := ParseSchemaVersion(properties)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, version, parsed)
+		delete(properties, SchemaVersionProtoVersionKey)
+		_, err = ParseSchemaVersion(properties)
+		assert.Error(t, err, "parse schema version: missing protoavro.proto_version")
+	})
+
+	t.Run("ocf metadata", func(t *testing.T) {
+		var b bytes.Buffer
+		marshaler, err := SchemaOptions{OCFMetadata: version.OCFMetadata()}.NewMarshaler(desc, &b)
+		assert.NilError(t, err)
+		assert.NilError(t, marshaler.Marshal(&library.Book{Name: "shelves/1/books/1"}))
+		unmarshaler, err := NewUnmarshaler(&b)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, version.OCFMetadata(), unmarshaler.Metadata())
+		parsed, err := ParseSchemaVersionOCFMetadata(unmarshaler.Metadata())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, version, parsed)
+		// the file is readable as usual
+		assert.Assert(t, unmarshaler.Scan())
+		var book library.Book
+		assert.NilError(t, unmarshaler.Unmarshal(&book))
+		assert.Equal(t, "shelves/1/books/1", book.GetName())
+	})
+
+	t.Run("reserved metadata", func(t *testing.T) {
+		var b bytes.Buffer
+		_, err := SchemaOptions{OCFMetadata: map[string][]byte{"avro.codec": nil}}.NewMarshaler(desc, &b)
+		assert.ErrorContains(t, err, "metadata key avro.codec: the prefix avro. is reserved")
+	})
+}
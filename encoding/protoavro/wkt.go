@@ -1,6 +1,8 @@
 package protoavro
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -19,7 +21,19 @@ import (
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
-func isWKT(name protoreflect.FullName) bool {
+func (o SchemaOptions) isWKT(name protoreflect.FullName) bool {
+	if _, ok := lookupMessageCodec(name); ok {
+		return true
+	}
+	if o.flattens(name) {
+		return true
+	}
+	if o.GoogleTypeMappings && isGoogleType(name) {
+		return true
+	}
+	if o.wktMapping(name) == WKTRecord {
+		return false
+	}
 	switch name {
 	case wkt.DoubleValue,
 		wkt.FloatValue,
@@ -41,7 +55,19 @@ func isWKT(name protoreflect.FullName) bool {
 	return false
 }
 
-func schemaWKT(message protoreflect.MessageDescriptor) (avro.Schema, error) {
+func (o SchemaOptions) schemaWKT(message protoreflect.MessageDescriptor) (avro.Schema, error) {
+	if codec, ok := lookupMessageCodec(message.FullName()); ok {
+		return codec.schema(message)
+	}
+	if o.flattens(message.FullName()) {
+		return o.schemaFlattened(message)
+	}
+	if o.GoogleTypeMappings && isGoogleType(message.FullName()) {
+		return schemaGoogleType(message)
+	}
+	if err := o.checkWKTMapping(message.FullName()); err != nil {
+		return nil, err
+	}
 	switch message.FullName() {
 	case wkt.DoubleValue,
 		wkt.FloatValue,
@@ -62,19 +88,38 @@ func schemaWKT(message protoreflect.MessageDescriptor) (avro.Schema, error) {
 	case wkt.Any:
 		return schemaAny(), nil
 	case wkt.Timestamp:
-		return schemaTimestamp(), nil
+		return o.schemaTimestamp(), nil
 	case wkt.Duration:
-		return schemaDuration(), nil
+		return o.schemaDuration(), nil
 	case wkt.Date:
+		if o.WKTMappings.Date == WKTString {
+			return avro.Nullable(avro.String()), nil
+		}
 		return schemaDate(), nil
 	case wkt.TimeOfDay:
+		if o.WKTMappings.TimeOfDay == WKTString {
+			return avro.Nullable(avro.String()), nil
+		}
 		return schemaTimeOfDay(), nil
 	}
 	return nil, fmt.Errorf("uknown wellknown type %s", message.FullName())
 }
 
 func (o SchemaOptions) encodeWKT(message protoreflect.Message) (map[string]interface{}, error) {
+	message, err := generatedMessage(message)
+	if err != nil {
+		return nil, err
+	}
 	desc := message.Descriptor()
+	if o.flattens(desc.FullName()) {
+		return o.encodeFlattened(message)
+	}
+	if o.GoogleTypeMappings && isGoogleType(desc.FullName()) {
+		return o.encodeGoogleType(message)
+	}
+	if err := o.checkWKTMapping(desc.FullName()); err != nil {
+		return nil, err
+	}
 	switch desc.FullName() {
 	case wkt.DoubleValue,
 		wkt.FloatValue,
@@ -105,33 +150,56 @@ func (o SchemaOptions) encodeWKT(message protoreflect.Message) (map[string]inter
 	case wkt.Timestamp:
 		return o.encodeTimestamp(message.Interface().(*timestamppb.Timestamp)), nil
 	case wkt.Duration:
-		return o.encodeDuration(message.Interface().(*durationpb.Duration)), nil
+		return o.encodeDuration(message.Interface().(*durationpb.Duration))
 	case wkt.Date:
+		if o.WKTMappings.Date == WKTString {
+			return o.encodeDateString(message.Interface().(*date.Date)), nil
+		}
 		return o.encodeDate(message.Interface().(*date.Date)), nil
 	case wkt.TimeOfDay:
+		if o.WKTMappings.TimeOfDay == WKTString {
+			return o.encodeTimeOfDayString(message.Interface().(*timeofday.TimeOfDay)), nil
+		}
 		return o.encodeTimeOfDay(message.Interface().(*timeofday.TimeOfDay)), nil
 	default:
 		return nil, fmt.Errorf("unknown wellknown type %s", desc.FullName())
 	}
 }
 
-func decodeWKT(data map[string]interface{}, msg protoreflect.Message) error {
+func (o SchemaOptions) decodeWKT(data map[string]interface{}, msg protoreflect.Message) error {
 	desc := msg.Descriptor()
+	if o.flattens(desc.FullName()) {
+		return o.decodeFlattened(data, msg)
+	}
+	if o.GoogleTypeMappings && isGoogleType(desc.FullName()) {
+		return decodeGoogleType(data, msg)
+	}
+	if err := o.checkWKTMapping(desc.FullName()); err != nil {
+		return err
+	}
 	var value proto.Message
 	var err error
 	switch desc.FullName() {
 	case wkt.Any:
 		value, err = decodeAny(data)
 	case wkt.Date:
-		value, err = decodeDate(data)
+		if o.WKTMappings.Date == WKTString && data != nil {
+			value, err = decodeDateString(data)
+		} else {
+			value, err = decodeDate(data)
+		}
 	case wkt.Struct:
 		value, err = decodeStruct(data)
 	case wkt.TimeOfDay:
-		value, err = decodeTimeOfDay(data)
+		if o.WKTMappings.TimeOfDay == WKTString && data != nil {
+			value, err = decodeTimeOfDayString(data)
+		} else {
+			value, err = decodeTimeOfDay(data)
+		}
 	case wkt.Duration:
-		value, err = decodeDuration(data)
+		value, err = o.decodeDuration(data)
 	case wkt.Timestamp:
-		value, err = decodeTimestamp(data)
+		value, err = o.decodeTimestamp(data)
 	case wkt.FloatValue,
 		wkt.DoubleValue,
 		wkt.UInt32Value,
@@ -148,8 +216,7 @@ func decodeWKT(data map[string]interface{}, msg protoreflect.Message) error {
 	if err != nil {
 		return err
 	}
-	proto.Merge(msg.Interface(), value)
-	return nil
+	return mergeMessage(msg, value)
 }
 
 func schemaWrapper(w string) (avro.Schema, error) {
@@ -270,11 +337,7 @@ func schemaDate() avro.Schema {
 }
 
 func (o SchemaOptions) encodeDate(d *date.Date) map[string]interface{} {
-	civilDate := civil.Date{
-		Year:  int(d.Year),
-		Month: time.Month(d.Month),
-		Day:   int(d.Day),
-	}
+	civilDate := dateToCivil(d)
 	epoch := civil.Date{
 		Year:  1970,
 		Month: time.January,
@@ -299,6 +362,14 @@ func decodeDate(v map[string]interface{}) (*date.Date, error) {
 	return dateFromCivil(d), nil
 }
 
+func dateToCivil(d *date.Date) civil.Date {
+	return civil.Date{
+		Year:  int(d.Year),
+		Month: time.Month(d.Month),
+		Day:   int(d.Day),
+	}
+}
+
 func dateFromCivil(c civil.Date) *date.Date {
 	return &date.Date{
 		Year:  int32(c.Year),
@@ -316,9 +387,24 @@ func (o SchemaOptions) encodeAny(a *anypb.Any) (map[string]interface{}, error) {
 	if err != nil {
 		return nil, fmt.Errorf("google.protobuf.Any: marshal: %w", err)
 	}
+	if data, err = o.compactJSON(data); err != nil {
+		return nil, fmt.Errorf("google.protobuf.Any: compact: %w", err)
+	}
 	return o.unionValue("string", string(data)), nil
 }
 
+// compactJSON compacts protobuf JSON with Deterministic, since protojson varies its whitespace between builds.
+func (o SchemaOptions) compactJSON(data []byte) ([]byte, error) {
+	if !o.Deterministic {
+		return data, nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, err
+	}
+	return compact.Bytes(), nil
+}
+
 func decodeAny(v map[string]interface{}) (*anypb.Any, error) {
 	if v == nil {
 		return nil, nil
@@ -343,6 +429,9 @@ func (o *SchemaOptions) encodeStruct(a *structpb.Struct) (map[string]interface{}
 	if err != nil {
 		return nil, fmt.Errorf("google.protobuf.Struct: marshal: %w", err)
 	}
+	if data, err = o.compactJSON(data); err != nil {
+		return nil, fmt.Errorf("google.protobuf.Struct: compact: %w", err)
+	}
 	return o.unionValue("string", string(data)), nil
 }
 
@@ -404,47 +493,14 @@ func timeOfDayFromDuration(dur time.Duration) *timeofday.TimeOfDay {
 	}
 }
 
-func schemaDuration() avro.Schema {
-	return avro.Nullable(avro.Float())
-}
-
-func (o *SchemaOptions) encodeDuration(dur *durationpb.Duration) map[string]interface{} {
-	return o.unionValue("float", dur.AsDuration().Seconds())
-}
-
-func decodeDuration(v map[string]interface{}) (*durationpb.Duration, error) {
-	seconds, err := decodeFloatLike(v, "float")
-	if err != nil {
-		return nil, fmt.Errorf("google.protobuf.Duration: %w", err)
-	}
-	// prevent downcasting float64 to int64 when passing to time.Duration
-	micros := seconds / time.Microsecond.Seconds()
-	return durationpb.New(time.Microsecond * time.Duration(micros)), nil
-}
-
-func schemaTimestamp() avro.Schema {
-	return avro.Nullable(avro.TimestampMicros())
-}
-
-func (o *SchemaOptions) encodeTimestamp(t *timestamppb.Timestamp) map[string]interface{} {
-	return o.unionValue("long.timestamp-micros", t.AsTime().UnixNano()/1e3)
-}
-
-func decodeTimestamp(v map[string]interface{}) (*timestamppb.Timestamp, error) {
-	if tm, ok := tryDecodeTime(v, "long.timestamp-micros"); ok {
-		return timestamppb.New(tm), nil
-	}
-	micros, err := decodeInt(v, "long.timestamp-micros")
-	if err != nil {
-		return nil, fmt.Errorf("google.protobuf.Timestamp: %w", err)
-	}
-	t := time.Unix(0, 0).Add(time.Microsecond * time.Duration(micros))
-	return timestamppb.New(t), nil
-}
-
 func decodeIntLike(v interface{}, key string) (int64, error) {
-	if i, ok := v.(int); ok {
+	switch i := v.(type) {
+	case int:
 		return int64(i), nil
+	case int32:
+		return int64(i), nil
+	case int64:
+		return i, nil
 	}
 	if m, ok := v.(map[string]interface{}); ok {
 		return decodeInt(m, key)
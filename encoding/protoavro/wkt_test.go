@@ -67,7 +67,7 @@ func Test_WKT(t *testing.T) {
 			assert.NilError(t, err)
 			t.Log(encoded)
 			decoded := tt.ProtoReflect().New()
-			assert.NilError(t, decodeWKT(encoded, decoded))
+			assert.NilError(t, SchemaOptions{}.decodeWKT(encoded, decoded))
 			assert.DeepEqual(t, tt, decoded.Interface(), protocmp.Transform())
 		})
 	}
@@ -141,7 +141,7 @@ func Test_DecodeWKTErr(t *testing.T) {
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			err := decodeWKT(tt.data, tt.msg.ProtoReflect())
+			err := SchemaOptions{}.decodeWKT(tt.data, tt.msg.ProtoReflect())
 			assert.ErrorContains(t, err, tt.errContains)
 		})
 	}
@@ -0,0 +1,215 @@
+package protoavro
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/civil"
+	"go.einride.tech/protobuf-avro/internal/wkt"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/timeofday"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WKTMapping is how a well-known type is represented in Avro, see WKTMappings.
+type WKTMapping int
+
+const (
+	// WKTDefault maps the well-known type as configured by the other options, such as TimestampEncoding.
+	WKTDefault WKTMapping = iota
+	// WKTRecord maps the well-known type as a record of its fields, like any other message.
+	WKTRecord
+	// WKTLogicalType maps the well-known type to an Avro logical type: timestamp-micros, or the logical type
+	// of the TimestampPrecision, for timestamps, duration for durations, date for dates and time-micros for
+	// times of day.
+	WKTLogicalType
+	// WKTString maps the well-known type to a string: RFC 3339 for timestamps, see TimestampString, the
+	// protojson format for durations, such as "1.500s", ISO 8601 dates such as "2021-02-03", times of day such
+	// as "04:05:06.789000000", and protojson for structs and any.
+	WKTString
+)
+
+// String returns the name of the mapping.
+func (m WKTMapping) String() string {
+	switch m {
+	case WKTDefault:
+		return "default"
+	case WKTRecord:
+		return "record"
+	case WKTLogicalType:
+		return "logical type"
+	case WKTString:
+		return "string"
+	}
+	return fmt.Sprintf("WKTMapping(%d)", int(m))
+}
+
+// WKTMappings maps well-known types to the representation that downstream systems expect, where they differ,
+// per type, so that for example timestamps are mapped to strings and durations to records by the same options.
+// Types with WKTDefault are mapped as configured by the other options.
+type WKTMappings struct {
+	// Timestamp is the mapping of google.protobuf.Timestamp, and overrides TimestampEncoding.
+	Timestamp WKTMapping
+	// Duration is the mapping of google.protobuf.Duration, and overrides DurationEncoding.
+	Duration WKTMapping
+	// Date is the mapping of google.type.Date. Defaults to the logical type date.
+	Date WKTMapping
+	// TimeOfDay is the mapping of google.type.TimeOfDay. Defaults to the logical type time-micros.
+	TimeOfDay WKTMapping
+	// Struct is the mapping of google.protobuf.Struct. Defaults to a protojson string. Structs can not be
+	// mapped to a logical type.
+	Struct WKTMapping
+	// Any is the mapping of google.protobuf.Any. Defaults to a protojson string. Any can not be mapped to a
+	// logical type.
+	Any WKTMapping
+	// Wrappers is the mapping of the wrapper types, such as google.protobuf.StringValue. Defaults to a
+	// nullable value of the wrapped type. Wrappers can only be mapped to records otherwise.
+	Wrappers WKTMapping
+}
+
+// wktMappingField is a field of WKTMappings, with the name of a well-known type that it maps.
+type wktMappingField struct {
+	name     string
+	mapping  WKTMapping
+	typeName protoreflect.FullName
+}
+
+func (m WKTMappings) fields() []wktMappingField {
+	return []wktMappingField{
+		{name: "Timestamp", mapping: m.Timestamp, typeName: wkt.Timestamp},
+		{name: "Duration", mapping: m.Duration, typeName: wkt.Duration},
+		{name: "Date", mapping: m.Date, typeName: wkt.Date},
+		{name: "TimeOfDay", mapping: m.TimeOfDay, typeName: wkt.TimeOfDay},
+		{name: "Struct", mapping: m.Struct, typeName: wkt.Struct},
+		{name: "Any", mapping: m.Any, typeName: wkt.Any},
+		{name: "Wrappers", mapping: m.Wrappers, typeName: wkt.StringValue},
+	}
+}
+
+// problems returns the problems of the mappings, for SchemaOptions.Validate.
+func (m WKTMappings) problems() []string {
+	var problems []string
+	for _, field := range m.fields() {
+		switch {
+		case field.mapping < WKTDefault || field.mapping > WKTString:
+			problems = append(problems, fmt.Sprintf("unknown WKTMappings.%s %d", field.name, field.mapping))
+		case !supportsWKTMapping(field.typeName, field.mapping):
+			problems = append(problems, fmt.Sprintf("WKTMappings.%s: unsupported mapping %s", field.name, field.mapping))
+		}
+	}
+	return problems
+}
+
+// supportsWKTMapping returns true if the well-known type of the name can be mapped by the mapping.
+func supportsWKTMapping(name protoreflect.FullName, mapping WKTMapping) bool {
+	switch mapping {
+	case WKTDefault, WKTRecord:
+		return true
+	case WKTLogicalType:
+		return name != wkt.Struct && name != wkt.Any && !isWrapper(name)
+	case WKTString:
+		return !isWrapper(name)
+	}
+	return false
+}
+
+func isWrapper(name protoreflect.FullName) bool {
+	_, err := schemaWrapper(string(name))
+	return err == nil
+}
+
+// wktMapping returns the mapping of the well-known type of the name by WKTMappings.
+func (o SchemaOptions) wktMapping(name protoreflect.FullName) WKTMapping {
+	switch name {
+	case wkt.Timestamp:
+		return o.WKTMappings.Timestamp
+	case wkt.Duration:
+		return o.WKTMappings.Duration
+	case wkt.Date:
+		return o.WKTMappings.Date
+	case wkt.TimeOfDay:
+		return o.WKTMappings.TimeOfDay
+	case wkt.Struct:
+		return o.WKTMappings.Struct
+	case wkt.Any:
+		return o.WKTMappings.Any
+	case wkt.DoubleValue,
+		wkt.FloatValue,
+		wkt.Int32Value,
+		wkt.UInt32Value,
+		wkt.Int64Value,
+		wkt.UInt64Value,
+		wkt.BoolValue,
+		wkt.StringValue,
+		wkt.BytesValue:
+		return o.WKTMappings.Wrappers
+	}
+	return WKTDefault
+}
+
+// checkWKTMapping returns an error if the well-known type of the name does not support its mapping.
+func (o SchemaOptions) checkWKTMapping(name protoreflect.FullName) error {
+	if mapping := o.wktMapping(name); !supportsWKTMapping(name, mapping) {
+		return fmt.Errorf("%s: unsupported mapping %s", name, mapping)
+	}
+	return nil
+}
+
+// timestampEncoding returns the encoding of timestamps, by WKTMappings or TimestampEncoding.
+func (o SchemaOptions) timestampEncoding() TimestampEncoding {
+	switch o.WKTMappings.Timestamp {
+	case WKTLogicalType:
+		return TimestampLong
+	case WKTString:
+		return TimestampString
+	}
+	return o.TimestampEncoding
+}
+
+// durationEncoding returns the encoding of durations, by WKTMappings or DurationEncoding.
+func (o SchemaOptions) durationEncoding() DurationEncoding {
+	switch o.WKTMappings.Duration {
+	case WKTLogicalType:
+		return DurationFixed
+	case WKTString:
+		return DurationString
+	}
+	return o.DurationEncoding
+}
+
+func (o SchemaOptions) encodeDateString(d *date.Date) map[string]interface{} {
+	return o.unionValue("string", dateToCivil(d).String())
+}
+
+func decodeDateString(v map[string]interface{}) (*date.Date, error) {
+	s, err := decodeString(v, "string")
+	if err != nil {
+		return nil, fmt.Errorf("google.type.Date: %w", err)
+	}
+	d, err := civil.ParseDate(s)
+	if err != nil {
+		return nil, fmt.Errorf("google.type.Date: %w", err)
+	}
+	return dateFromCivil(d), nil
+}
+
+func (o SchemaOptions) encodeTimeOfDayString(t *timeofday.TimeOfDay) map[string]interface{} {
+	c := civil.Time{Hour: int(t.Hours), Minute: int(t.Minutes), Second: int(t.Seconds), Nanosecond: int(t.Nanos)}
+	return o.unionValue("string", c.String())
+}
+
+func decodeTimeOfDayString(v map[string]interface{}) (*timeofday.TimeOfDay, error) {
+	s, err := decodeString(v, "string")
+	if err != nil {
+		return nil, fmt.Errorf("google.type.TimeOfDay: %w", err)
+	}
+	c, err := civil.ParseTime(s)
+	if err != nil {
+		return nil, fmt.Errorf("google.type.TimeOfDay: %w", err)
+	}
+	return &timeofday.TimeOfDay{
+		Hours:   int32(c.Hour),
+		Minutes: int32(c.Minute),
+		Seconds: int32(c.Second),
+		Nanos:   int32(c.Nanosecond),
+	}, nil
+}
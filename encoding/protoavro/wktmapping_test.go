@@ -0,0 +1,145 @@
+package protoavro
+
+import (
+	"testing"
+	"time"
+
+	"go.einride.tech/protobuf-avro/avro"
+	examplev1 "go.einride.tech/protobuf-avro/internal/examples/proto/gen/einride/avro/example/v1"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/timeofday"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestWKTMappings(t *testing.T) {
+	timestamp := &examplev1.ExampleTimestamp{
+		Timestamp: timestamppb.New(time.Date(2021, 2, 3, 4, 5, 6, 789000000, time.UTC)),
+	}
+	duration := &examplev1.ExampleDuration{Duration: durationpb.New(1500 * time.Millisecond)}
+	day := &examplev1.ExampleDate{Date: &date.Date{Year: 2021, Month: 2, Day: 3}}
+	timeOfDay := &examplev1.ExampleTimeOfDay{
+		TimeOfDay: &timeofday.TimeOfDay{Hours: 4, Minutes: 5, Seconds: 6, Nanos: 789},
+	}
+	strct, err := structpb.NewStruct(map[string]interface{}{"name": "x", "list": []interface{}{1.0, true}})
+	assert.NilError(t, err)
+	structMsg := &examplev1.ExampleStruct{Struct: strct}
+	anyValue, err := anypb.New(wrapperspb.String("x"))
+	assert.NilError(t, err)
+	anyMsg := &examplev1.ExampleAny{Any: anyValue}
+	wrappers := &examplev1.ExampleWrappers{StringValue: wrapperspb.String("x"), Int64Value: wrapperspb.Int64(-1)}
+
+	fieldSchema := func(t *testing.T, opts SchemaOptions, msg proto.Message) avro.Schema {
+		t.Helper()
+		schema, err := opts.InferSchema(msg.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		return recordOf(t, schema).Fields[0].Type
+	}
+
+	t.Run("strings", func(t *testing.T) {
+		opts := SchemaOptions{
+			OmitRootElement: true,
+			WKTMappings: WKTMappings{
+				Timestamp: WKTString,
+				Duration:  WKTString,
+				Date:      WKTString,
+				TimeOfDay: WKTString,
+			},
+		}
+		for _, tt := range []struct {
+			msg      proto.Message
+			expected string
+		}{
+			{msg: timestamp, expected: "2021-02-03T04:05:06.789000Z"},
+			{msg: duration, expected: "1.500s"},
+			{msg: day, expected: "2021-02-03"},
+			{msg: timeOfDay, expected: "04:05:06.000000789"},
+		} {
+			assert.DeepEqual(t, avro.Nullable(avro.String()), fieldSchema(t, opts, tt.msg))
+			data, err := opts.Encode(tt.msg)
+			assert.NilError(t, err)
+			for _, value := range data.(map[string]interface{}) {
+				assert.DeepEqual(t, map[string]interface{}{"string": tt.expected}, value)
+			}
+			assertRecursionRoundTrip(t, opts, tt.msg, tt.msg, true)
+		}
+	})
+
+	t.Run("logical types", func(t *testing.T) {
+		opts := SchemaOptions{
+			WKTMappings: WKTMappings{
+				Timestamp: WKTLogicalType,
+				Duration:  WKTLogicalType,
+				Date:      WKTLogicalType,
+				TimeOfDay: WKTLogicalType,
+			},
+			// overridden by WKTMappings
+			TimestampEncoding: TimestampString,
+		}
+		assert.DeepEqual(t, avro.Nullable(avro.TimestampMicros()), fieldSchema(t, opts, timestamp))
+		assert.DeepEqual(t, avro.Nullable(avro.Duration("Duration", "google.protobuf")), fieldSchema(t, opts, duration))
+		assert.DeepEqual(t, avro.Nullable(avro.Date()), fieldSchema(t, opts, day))
+		assert.DeepEqual(t, avro.Nullable(avro.TimeMicros()), fieldSchema(t, opts, timeOfDay))
+		for _, msg := range []proto.Message{timestamp, duration, day} {
+			assertRecursionRoundTrip(t, opts, msg, msg, true)
+		}
+	})
+
+	t.Run("records", func(t *testing.T) {
+		opts := SchemaOptions{
+			WKTMappings: WKTMappings{
+				Timestamp: WKTRecord,
+				Duration:  WKTRecord,
+				Date:      WKTRecord,
+				TimeOfDay: WKTRecord,
+				Struct:    WKTRecord,
+				Any:       WKTRecord,
+				Wrappers:  WKTRecord,
+			},
+		}
+		timestampSchema := recordOf(t, fieldSchema(t, opts, timestamp))
+		assert.Equal(t, "Timestamp", timestampSchema.Name)
+		assert.Equal(t, "seconds", timestampSchema.Fields[0].Name)
+		assert.Equal(t, "FloatValue", recordOf(t, fieldSchema(t, opts, wrappers)).Name)
+		for _, msg := range []proto.Message{timestamp, duration, day, timeOfDay, structMsg, anyMsg, wrappers} {
+			assertRecursionRoundTrip(t, opts, msg, msg, true)
+		}
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		opts := SchemaOptions{WKTMappings: WKTMappings{Timestamp: WKTString, Duration: WKTRecord}}
+		assert.DeepEqual(t, avro.Nullable(avro.String()), fieldSchema(t, opts, timestamp))
+		assert.Equal(t, "Duration", recordOf(t, fieldSchema(t, opts, duration)).Name)
+		assert.DeepEqual(t, avro.Nullable(avro.Date()), fieldSchema(t, opts, day))
+		assert.DeepEqual(t, avro.Nullable(avro.String()), fieldSchema(t, opts, structMsg))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		for _, tt := range []struct {
+			opts     SchemaOptions
+			msg      proto.Message
+			expected string
+		}{
+			{
+				opts:     SchemaOptions{WKTMappings: WKTMappings{Struct: WKTLogicalType}},
+				msg:      structMsg,
+				expected: "google.protobuf.Struct: unsupported mapping logical type",
+			},
+			{
+				opts:     SchemaOptions{WKTMappings: WKTMappings{Wrappers: WKTString}},
+				msg:      wrappers,
+				expected: "Value: unsupported mapping string",
+			},
+		} {
+			_, err := tt.opts.InferSchema(tt.msg.ProtoReflect().Descriptor())
+			assert.ErrorContains(t, err, tt.expected)
+			_, err = tt.opts.Encode(tt.msg)
+			assert.ErrorContains(t, err, tt.expected)
+		}
+	})
+}
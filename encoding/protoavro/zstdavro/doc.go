@@ -0,0 +1,9 @@
+// Package zstdavro registers the zstandard compression codec of Avro Object Container Files, see
+// protoavro.CompressionZstandard, implemented with github.com/klauspost/compress/zstd.
+//
+// The codec is registered when the package is imported:
+//
+//	import _ "go.einride.tech/protobuf-avro/encoding/protoavro/zstdavro"
+//
+// The package is a separate module, so that only its users depend on klauspost/compress.
+package zstdavro
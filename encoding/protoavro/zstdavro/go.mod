@@ -0,0 +1,28 @@
+module go.einride.tech/protobuf-avro/encoding/protoavro/zstdavro
+
+go 1.22
+
+require (
+	github.com/klauspost/compress v1.18.0
+	go.einride.tech/protobuf-avro v0.0.0-00010101000000-000000000000
+	google.golang.org/genproto v0.0.0-20230209215440-0dfe4f8abfcc
+	google.golang.org/protobuf v1.28.1
+	gotest.tools/v3 v3.4.0
+)
+
+require (
+	cloud.google.com/go v0.110.0 // indirect
+	github.com/antlr/antlr4/runtime/Go/antlr v0.0.0-20220418222510-f25a4f6275ed // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/golang/snappy v0.0.4 // indirect
+	github.com/google/cel-go v0.12.6 // indirect
+	github.com/google/go-cmp v0.5.9 // indirect
+	github.com/linkedin/goavro/v2 v2.12.0 // indirect
+	github.com/stoewer/go-strcase v1.2.0 // indirect
+	golang.org/x/net v0.6.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
+	google.golang.org/grpc v1.53.0 // indirect
+)
+
+replace go.einride.tech/protobuf-avro => ../../..
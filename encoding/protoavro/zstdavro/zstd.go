@@ -0,0 +1,80 @@
+package zstdavro
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+)
+
+func init() {
+	codec, err := NewCodec()
+	if err != nil {
+		panic(err)
+	}
+	protoavro.RegisterCompressionCodec(protoavro.CompressionZstandard, codec)
+}
+
+// Codec compresses and decompresses blocks as zstandard frames, as specified by Avro.
+// Codec is safe for concurrent use.
+type Codec struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	// streams are the streaming decoders of DecompressLimit, which stop at the limit.
+	streams sync.Pool // *zstd.Decoder
+}
+
+var _ protoavro.LimitedCompressionCodec = &Codec{}
+
+// NewCodec returns a zstandard codec with the encoder options, for example to register it with another
+// compression level.
+func NewCodec(opts ...zstd.EOption) (*Codec, error) {
+	encoder, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("new zstd codec: %w", err)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("new zstd codec: %w", err)
+	}
+	return &Codec{encoder: encoder, decoder: decoder}, nil
+}
+
+// Compress implements protoavro.CompressionCodec.
+func (c *Codec) Compress(block []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(block, nil), nil
+}
+
+// Decompress implements protoavro.CompressionCodec.
+func (c *Codec) Decompress(block []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(block, nil)
+}
+
+// DecompressLimit implements protoavro.LimitedCompressionCodec.
+func (c *Codec) DecompressLimit(block []byte, max int) ([]byte, error) {
+	stream, ok := c.streams.Get().(*zstd.Decoder)
+	if !ok {
+		var err error
+		if stream, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+			return nil, err
+		}
+	}
+	if err := stream.Reset(bytes.NewReader(block)); err != nil {
+		return nil, err
+	}
+	decompressed, err := io.ReadAll(io.LimitReader(stream, int64(max)+1))
+	// decoders are reset to release the block before they are pooled
+	if resetErr := stream.Reset(nil); resetErr == nil {
+		c.streams.Put(stream)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > max {
+		return nil, protoavro.ErrLimitExceeded
+	}
+	return decompressed, nil
+}
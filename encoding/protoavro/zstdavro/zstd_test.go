@@ -0,0 +1,96 @@
+package zstdavro_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"go.einride.tech/protobuf-avro/encoding/protoavro"
+	"go.einride.tech/protobuf-avro/encoding/protoavro/zstdavro"
+	"google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestCompressionZstandard(t *testing.T) {
+	books := make([]*library.Book, 0, 100)
+	for i := 0; i < 100; i++ {
+		books = append(books, &library.Book{
+			Name:   fmt.Sprintf("shelves/1/books/%d", i),
+			Title:  "Harry Potter",
+			Author: "J. K. Rowling",
+		})
+	}
+	desc := (&library.Book{}).ProtoReflect().Descriptor()
+	var b bytes.Buffer
+	marshaler, err := protoavro.SchemaOptions{Compression: protoavro.CompressionZstandard}.NewMarshaler(desc, &b)
+	assert.NilError(t, err)
+	for _, book := range books {
+		assert.NilError(t, marshaler.Marshal(book))
+	}
+	assert.Assert(t, bytes.Contains(b.Bytes(), []byte(protoavro.CompressionZstandard)))
+
+	unmarshaler, err := protoavro.NewUnmarshaler(&b)
+	assert.NilError(t, err)
+	got := make([]*library.Book, 0, len(books))
+	for unmarshaler.Scan() {
+		var book library.Book
+		assert.NilError(t, unmarshaler.Unmarshal(&book))
+		got = append(got, &book)
+	}
+	assert.NilError(t, unmarshaler.Err())
+	assert.DeepEqual(t, books, got, protocmp.Transform())
+}
+
+func TestCodec(t *testing.T) {
+	block := bytes.Repeat([]byte("Harry Potter"), 100)
+	codec, err := zstdavro.NewCodec(zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	assert.NilError(t, err)
+	compressed, err := codec.Compress(block)
+	assert.NilError(t, err)
+	// blocks are plain zstandard frames
+	decoder, err := zstd.NewReader(nil)
+	assert.NilError(t, err)
+	decompressed, err := decoder.DecodeAll(compressed, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, block, decompressed)
+	decompressed, err = codec.Decompress(compressed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, block, decompressed)
+	_, err = codec.Decompress([]byte("not zstandard"))
+	assert.Assert(t, err != nil)
+}
+
+func TestCodec_DecompressLimit(t *testing.T) {
+	block := bytes.Repeat([]byte("Harry Potter"), 1<<16)
+	codec, err := zstdavro.NewCodec()
+	assert.NilError(t, err)
+	compressed, err := codec.Compress(block)
+	assert.NilError(t, err)
+	decompressed, err := codec.DecompressLimit(compressed, len(block))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, block, decompressed)
+	_, err = codec.DecompressLimit(compressed, len(block)-1)
+	assert.Assert(t, errors.Is(err, protoavro.ErrLimitExceeded))
+	_, err = codec.DecompressLimit([]byte("not zstandard"), len(block))
+	assert.Assert(t, err != nil)
+}
+
+func TestRecordCompression(t *testing.T) {
+	book := &library.Book{Name: "shelves/1/books/1", Title: strings.Repeat("Harry Potter", 1<<12)}
+	opts := protoavro.SchemaOptions{RecordCompression: protoavro.CompressionZstandard}
+	codec, err := protoavro.NewCodec[*library.Book](opts)
+	assert.NilError(t, err)
+	b, err := codec.Marshal(book)
+	assert.NilError(t, err)
+	got, err := codec.Unmarshal(b)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, book, got, protocmp.Transform())
+	limited, err := protoavro.NewCodec[*library.Book](protoavro.SchemaOptions{MaxRecordBytes: 1024})
+	assert.NilError(t, err)
+	_, err = limited.Unmarshal(b)
+	assert.Assert(t, errors.Is(err, protoavro.ErrLimitExceeded), err)
+}
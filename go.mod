@@ -1,12 +1,24 @@
 module go.einride.tech/protobuf-avro
 
-go 1.16
+go 1.20
 
 require (
 	cloud.google.com/go v0.110.0
+	github.com/golang/snappy v0.0.4
+	github.com/google/cel-go v0.12.6
 	github.com/google/go-cmp v0.5.9
 	github.com/linkedin/goavro/v2 v2.12.0
 	google.golang.org/genproto v0.0.0-20230209215440-0dfe4f8abfcc
 	google.golang.org/protobuf v1.28.1
 	gotest.tools/v3 v3.4.0
 )
+
+require (
+	github.com/antlr/antlr4/runtime/Go/antlr v0.0.0-20220418222510-f25a4f6275ed // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/stoewer/go-strcase v1.2.0 // indirect
+	golang.org/x/net v0.6.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
+	google.golang.org/grpc v1.53.0 // indirect
+)
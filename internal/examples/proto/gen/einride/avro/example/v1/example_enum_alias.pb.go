@@ -0,0 +1,215 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: einride/avro/example/v1/example_enum_alias.proto
+
+package examplev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ExampleEnumAlias_Enum int32
+
+const (
+	ExampleEnumAlias_ENUM_UNSPECIFIED ExampleEnumAlias_Enum = 0
+	ExampleEnumAlias_ENUM_STARTED     ExampleEnumAlias_Enum = 1
+	ExampleEnumAlias_ENUM_RUNNING     ExampleEnumAlias_Enum = 1
+	ExampleEnumAlias_ENUM_STOPPED     ExampleEnumAlias_Enum = 2
+)
+
+// Enum value maps for ExampleEnumAlias_Enum.
+var (
+	ExampleEnumAlias_Enum_name = map[int32]string{
+		0: "ENUM_UNSPECIFIED",
+		1: "ENUM_STARTED",
+		// Duplicate value: 1: "ENUM_RUNNING",
+		2: "ENUM_STOPPED",
+	}
+	ExampleEnumAlias_Enum_value = map[string]int32{
+		"ENUM_UNSPECIFIED": 0,
+		"ENUM_STARTED":     1,
+		"ENUM_RUNNING":     1,
+		"ENUM_STOPPED":     2,
+	}
+)
+
+func (x ExampleEnumAlias_Enum) Enum() *ExampleEnumAlias_Enum {
+	p := new(ExampleEnumAlias_Enum)
+	*p = x
+	return p
+}
+
+func (x ExampleEnumAlias_Enum) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExampleEnumAlias_Enum) Descriptor() protoreflect.EnumDescriptor {
+	return file_einride_avro_example_v1_example_enum_alias_proto_enumTypes[0].Descriptor()
+}
+
+func (ExampleEnumAlias_Enum) Type() protoreflect.EnumType {
+	return &file_einride_avro_example_v1_example_enum_alias_proto_enumTypes[0]
+}
+
+func (x ExampleEnumAlias_Enum) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExampleEnumAlias_Enum.Descriptor instead.
+func (ExampleEnumAlias_Enum) EnumDescriptor() ([]byte, []int) {
+	return file_einride_avro_example_v1_example_enum_alias_proto_rawDescGZIP(), []int{0, 0}
+}
+
+type ExampleEnumAlias struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EnumValue ExampleEnumAlias_Enum `protobuf:"varint,1,opt,name=enum_value,json=enumValue,proto3,enum=einride.avro.example.v1.ExampleEnumAlias_Enum" json:"enum_value,omitempty"`
+}
+
+func (x *ExampleEnumAlias) Reset() {
+	*x = ExampleEnumAlias{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_einride_avro_example_v1_example_enum_alias_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExampleEnumAlias) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExampleEnumAlias) ProtoMessage() {}
+
+func (x *ExampleEnumAlias) ProtoReflect() protoreflect.Message {
+	mi := &file_einride_avro_example_v1_example_enum_alias_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExampleEnumAlias.ProtoReflect.Descriptor instead.
+func (*ExampleEnumAlias) Descriptor() ([]byte, []int) {
+	return file_einride_avro_example_v1_example_enum_alias_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ExampleEnumAlias) GetEnumValue() ExampleEnumAlias_Enum {
+	if x != nil {
+		return x.EnumValue
+	}
+	return ExampleEnumAlias_ENUM_UNSPECIFIED
+}
+
+var File_einride_avro_example_v1_example_enum_alias_proto protoreflect.FileDescriptor
+
+var file_einride_avro_example_v1_example_enum_alias_proto_rawDesc = []byte{
+	0x0a, 0x30, 0x65, 0x69, 0x6e, 0x72, 0x69, 0x64, 0x65, 0x2f, 0x61, 0x76, 0x72, 0x6f, 0x2f, 0x65,
+	0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2f, 0x76, 0x31, 0x2f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c,
+	0x65, 0x5f, 0x65, 0x6e, 0x75, 0x6d, 0x5f, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x17, 0x65, 0x69, 0x6e, 0x72, 0x69, 0x64, 0x65, 0x2e, 0x61, 0x76, 0x72, 0x6f,
+	0x2e, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x76, 0x31, 0x22, 0xb9, 0x01, 0x0a, 0x10,
+	0x45, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x45, 0x6e, 0x75, 0x6d, 0x41, 0x6c, 0x69, 0x61, 0x73,
+	0x12, 0x4d, 0x0a, 0x0a, 0x65, 0x6e, 0x75, 0x6d, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x2e, 0x2e, 0x65, 0x69, 0x6e, 0x72, 0x69, 0x64, 0x65, 0x2e, 0x61,
+	0x76, 0x72, 0x6f, 0x2e, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x45,
+	0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x45, 0x6e, 0x75, 0x6d, 0x41, 0x6c, 0x69, 0x61, 0x73, 0x2e,
+	0x45, 0x6e, 0x75, 0x6d, 0x52, 0x09, 0x65, 0x6e, 0x75, 0x6d, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x22,
+	0x56, 0x0a, 0x04, 0x45, 0x6e, 0x75, 0x6d, 0x12, 0x14, 0x0a, 0x10, 0x45, 0x4e, 0x55, 0x4d, 0x5f,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x10, 0x0a,
+	0x0c, 0x45, 0x4e, 0x55, 0x4d, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12,
+	0x10, 0x0a, 0x0c, 0x45, 0x4e, 0x55, 0x4d, 0x5f, 0x52, 0x55, 0x4e, 0x4e, 0x49, 0x4e, 0x47, 0x10,
+	0x01, 0x12, 0x10, 0x0a, 0x0c, 0x45, 0x4e, 0x55, 0x4d, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x50, 0x45,
+	0x44, 0x10, 0x02, 0x1a, 0x02, 0x10, 0x01, 0x42, 0x5d, 0x5a, 0x5b, 0x67, 0x6f, 0x2e, 0x65, 0x69,
+	0x6e, 0x72, 0x69, 0x64, 0x65, 0x2e, 0x74, 0x65, 0x63, 0x68, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2d, 0x61, 0x76, 0x72, 0x6f, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61,
+	0x6c, 0x2f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x65, 0x69, 0x6e, 0x72, 0x69, 0x64, 0x65, 0x2f, 0x61, 0x76, 0x72,
+	0x6f, 0x2f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2f, 0x76, 0x31, 0x3b, 0x65, 0x78, 0x61,
+	0x6d, 0x70, 0x6c, 0x65, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_einride_avro_example_v1_example_enum_alias_proto_rawDescOnce sync.Once
+	file_einride_avro_example_v1_example_enum_alias_proto_rawDescData = file_einride_avro_example_v1_example_enum_alias_proto_rawDesc
+)
+
+func file_einride_avro_example_v1_example_enum_alias_proto_rawDescGZIP() []byte {
+	file_einride_avro_example_v1_example_enum_alias_proto_rawDescOnce.Do(func() {
+		file_einride_avro_example_v1_example_enum_alias_proto_rawDescData = protoimpl.X.CompressGZIP(file_einride_avro_example_v1_example_enum_alias_proto_rawDescData)
+	})
+	return file_einride_avro_example_v1_example_enum_alias_proto_rawDescData
+}
+
+var file_einride_avro_example_v1_example_enum_alias_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_einride_avro_example_v1_example_enum_alias_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_einride_avro_example_v1_example_enum_alias_proto_goTypes = []interface{}{
+	(ExampleEnumAlias_Enum)(0), // 0: einride.avro.example.v1.ExampleEnumAlias.Enum
+	(*ExampleEnumAlias)(nil),   // 1: einride.avro.example.v1.ExampleEnumAlias
+}
+var file_einride_avro_example_v1_example_enum_alias_proto_depIdxs = []int32{
+	0, // 0: einride.avro.example.v1.ExampleEnumAlias.enum_value:type_name -> einride.avro.example.v1.ExampleEnumAlias.Enum
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_einride_avro_example_v1_example_enum_alias_proto_init() }
+func file_einride_avro_example_v1_example_enum_alias_proto_init() {
+	if File_einride_avro_example_v1_example_enum_alias_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_einride_avro_example_v1_example_enum_alias_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExampleEnumAlias); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_einride_avro_example_v1_example_enum_alias_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_einride_avro_example_v1_example_enum_alias_proto_goTypes,
+		DependencyIndexes: file_einride_avro_example_v1_example_enum_alias_proto_depIdxs,
+		EnumInfos:         file_einride_avro_example_v1_example_enum_alias_proto_enumTypes,
+		MessageInfos:      file_einride_avro_example_v1_example_enum_alias_proto_msgTypes,
+	}.Build()
+	File_einride_avro_example_v1_example_enum_alias_proto = out.File
+	file_einride_avro_example_v1_example_enum_alias_proto_rawDesc = nil
+	file_einride_avro_example_v1_example_enum_alias_proto_goTypes = nil
+	file_einride_avro_example_v1_example_enum_alias_proto_depIdxs = nil
+}
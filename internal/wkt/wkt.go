@@ -1,21 +1,23 @@
 package wkt
 
 const (
-	Timestamp   = "google.protobuf.Timestamp"
-	Duration    = "google.protobuf.Duration"
-	Struct      = "google.protobuf.Struct"
-	Any         = "google.protobuf.Any"
-	TimeOfDay   = "google.type.TimeOfDay"
-	Date        = "google.type.Date"
-	DateTime    = "google.type.DateTime"
-	LatLng      = "google.type.LatLng"
-	DoubleValue = "google.protobuf.DoubleValue"
-	FloatValue  = "google.protobuf.FloatValue"
-	Int32Value  = "google.protobuf.Int32Value"
-	Int64Value  = "google.protobuf.Int64Value"
-	UInt32Value = "google.protobuf.UInt32Value"
-	UInt64Value = "google.protobuf.UInt64Value"
-	BoolValue   = "google.protobuf.BoolValue"
-	StringValue = "google.protobuf.StringValue"
-	BytesValue  = "google.protobuf.BytesValue"
+	Timestamp     = "google.protobuf.Timestamp"
+	Duration      = "google.protobuf.Duration"
+	Struct        = "google.protobuf.Struct"
+	Any           = "google.protobuf.Any"
+	TimeOfDay     = "google.type.TimeOfDay"
+	Date          = "google.type.Date"
+	DateTime      = "google.type.DateTime"
+	LatLng        = "google.type.LatLng"
+	Money         = "google.type.Money"
+	PostalAddress = "google.type.PostalAddress"
+	DoubleValue   = "google.protobuf.DoubleValue"
+	FloatValue    = "google.protobuf.FloatValue"
+	Int32Value    = "google.protobuf.Int32Value"
+	Int64Value    = "google.protobuf.Int64Value"
+	UInt32Value   = "google.protobuf.UInt32Value"
+	UInt64Value   = "google.protobuf.UInt64Value"
+	BoolValue     = "google.protobuf.BoolValue"
+	StringValue   = "google.protobuf.StringValue"
+	BytesValue    = "google.protobuf.BytesValue"
 )